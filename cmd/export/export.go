@@ -0,0 +1,169 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/export"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// defaultTestResultDays is the test-results window when -from is omitted,
+// the same as the API's.
+const defaultTestResultDays = 30
+
+// options are the command-line flags. Integer filters are -1 when unset.
+type options struct {
+	data, format string
+
+	domain                          string
+	zone, net, node, point          int
+	listSource                      string
+	systemName, location, sysopName string
+	from, to                        string
+	latest                          bool
+}
+
+func (o options) validate() (export.Format, error) {
+	format, err := export.ParseFormat(o.format)
+	if err != nil {
+		return "", err
+	}
+	switch o.data {
+	case "nodes":
+		if o.point >= 0 || o.listSource != "" {
+			return "", fmt.Errorf("-point and -list-source apply to points only")
+		}
+	case "points":
+	case "test-results":
+		if o.point >= 0 || o.listSource != "" || o.systemName != "" || o.location != "" || o.sysopName != "" || o.latest {
+			return "", fmt.Errorf("test-results filter by -domain, -zone, -net, -node, -from and -to only")
+		}
+	case "":
+		return "", fmt.Errorf("-data is required")
+	default:
+		return "", fmt.Errorf("unknown dataset %q (want nodes, points or test-results)", o.data)
+	}
+	for _, d := range []string{o.from, o.to} {
+		if d == "" {
+			continue
+		}
+		if _, err := time.Parse(time.DateOnly, d); err != nil {
+			return "", fmt.Errorf("dates must be YYYY-MM-DD, got %q", d)
+		}
+	}
+	return format, nil
+}
+
+// nodeFilter is the NodeFilter the flags describe. For test-results the
+// window is completed from the defaults.
+func (o options) nodeFilter(now time.Time) database.NodeFilter {
+	var f database.NodeFilter
+	if o.domain != "" {
+		d := strings.ToLower(o.domain)
+		f.Domain = &d
+	}
+	f.Zone, f.Net, f.Node = intFlag(o.zone), intFlag(o.net), intFlag(o.node)
+	f.SystemName, f.Location, f.SysopName = stringFlag(o.systemName), stringFlag(o.location), stringFlag(o.sysopName)
+	f.DateFrom, f.DateTo = dateFlag(o.from), dateFlag(o.to)
+	if o.latest {
+		f.LatestOnly = &o.latest
+	}
+
+	if o.data == "test-results" {
+		if f.DateTo == nil {
+			today := now.UTC().Truncate(24 * time.Hour)
+			f.DateTo = &today
+		}
+		if f.DateFrom == nil {
+			from := f.DateTo.AddDate(0, 0, -defaultTestResultDays)
+			f.DateFrom = &from
+		}
+	}
+	return f
+}
+
+// pointFilter is the PointFilter the flags describe.
+func (o options) pointFilter() database.PointFilter {
+	n := o.nodeFilter(time.Time{})
+	f := database.PointFilter{
+		Domain: n.Domain, Zone: n.Zone, Net: n.Net, Node: n.Node, PointNum: intFlag(o.point),
+		SystemName: n.SystemName, Location: n.Location, SysopName: n.SysopName,
+		DateFrom: n.DateFrom, DateTo: n.DateTo, LatestOnly: n.LatestOnly,
+	}
+	if o.listSource != "" {
+		s := strings.ToLower(o.listSource)
+		f.ListSource = &s
+	}
+	return f
+}
+
+// exportSource is the slice of storage an export reads.
+type exportSource interface {
+	StreamNodes(ctx context.Context, filter database.NodeFilter, fn func(database.Node) error) error
+	StreamPoints(ctx context.Context, filter database.PointFilter, fn func(database.Point) error) error
+	StreamTestResults(ctx context.Context, filter database.NodeFilter, fn func(storage.NodeTestResult) error) error
+}
+
+// run writes the export to w and reports how many rows it wrote.
+func run(ctx context.Context, src exportSource, o options, format export.Format, w io.Writer) (int, error) {
+	switch o.data {
+	case "nodes":
+		return write(format, w, export.NodeColumns, func(fn func(database.Node) error) error {
+			return src.StreamNodes(ctx, o.nodeFilter(time.Now()), fn)
+		})
+	case "points":
+		return write(format, w, export.PointColumns, func(fn func(database.Point) error) error {
+			return src.StreamPoints(ctx, o.pointFilter(), fn)
+		})
+	default:
+		return write(format, w, export.TestResultColumns, func(fn func(storage.NodeTestResult) error) error {
+			return src.StreamTestResults(ctx, o.nodeFilter(time.Now()), fn)
+		})
+	}
+}
+
+func write[T any](format export.Format, w io.Writer, columns []export.Column[T], stream func(func(T) error) error) (int, error) {
+	ew, err := export.NewWriter(format, w, columns)
+	if err != nil {
+		return 0, err
+	}
+	rows := 0
+	err = stream(func(row T) error {
+		rows++
+		return ew.Write(&row)
+	})
+	if err != nil {
+		return rows, err
+	}
+	return rows, ew.Close()
+}
+
+func intFlag(v int) *int {
+	if v < 0 {
+		return nil
+	}
+	return &v
+}
+
+func stringFlag(v string) *string {
+	if v == "" {
+		return nil
+	}
+	return &v
+}
+
+func dateFlag(v string) *time.Time {
+	if v == "" {
+		return nil
+	}
+	t, err := time.Parse(time.DateOnly, v)
+	if err != nil {
+		return nil // validate rejected it already
+	}
+	return &t
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/export"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// fakeSource streams fixed rows and records the filters it was given.
+type fakeSource struct {
+	nodes       []database.Node
+	nodeFilter  database.NodeFilter
+	pointFilter database.PointFilter
+	testFilter  database.NodeFilter
+}
+
+func (f *fakeSource) StreamNodes(_ context.Context, filter database.NodeFilter, fn func(database.Node) error) error {
+	f.nodeFilter = filter
+	for _, n := range f.nodes {
+		if err := fn(n); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (f *fakeSource) StreamPoints(_ context.Context, filter database.PointFilter, _ func(database.Point) error) error {
+	f.pointFilter = filter
+	return nil
+}
+
+func (f *fakeSource) StreamTestResults(_ context.Context, filter database.NodeFilter, _ func(storage.NodeTestResult) error) error {
+	f.testFilter = filter
+	return nil
+}
+
+// unset is options with every integer filter unset, as the flag defaults leave it.
+func unset() options {
+	return options{format: "csv", zone: -1, net: -1, node: -1, point: -1}
+}
+
+func TestValidate(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		edit    func(*options)
+		wantErr string
+	}{
+		{"nodes", func(o *options) { o.data = "nodes" }, ""},
+		{"points with list source", func(o *options) { o.data, o.listSource = "points", "r24" }, ""},
+		{"no dataset", func(o *options) {}, "-data is required"},
+		{"unknown dataset", func(o *options) { o.data = "sysops" }, "unknown dataset"},
+		{"unknown format", func(o *options) { o.data, o.format = "nodes", "xlsx" }, "unknown export format"},
+		{"point on nodes", func(o *options) { o.data, o.point = "nodes", 1 }, "points only"},
+		{"sysop on test results", func(o *options) { o.data, o.sysopName = "test-results", "Ivanov" }, "-from and -to only"},
+		{"bad date", func(o *options) { o.data, o.from = "nodes", "2026-13-01" }, "YYYY-MM-DD"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			o := unset()
+			tc.edit(&o)
+			_, err := o.validate()
+			if tc.wantErr == "" {
+				if err != nil {
+					t.Fatalf("validate: %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("validate = %v, want an error containing %q", err, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestFilters(t *testing.T) {
+	src := &fakeSource{}
+	o := unset()
+	o.data, o.domain, o.zone, o.net, o.point, o.listSource, o.latest = "points", "FidoNet", 2, 0, 1, "R24", true
+	if _, err := run(context.Background(), src, o, export.CSV, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	f := src.pointFilter
+	if f.Domain == nil || *f.Domain != "fidonet" || f.ListSource == nil || *f.ListSource != "r24" {
+		t.Errorf("domain/list source not normalized: %+v", f)
+	}
+	if f.Zone == nil || *f.Zone != 2 || f.Net == nil || *f.Net != 0 || f.Node != nil || f.PointNum == nil || *f.PointNum != 1 {
+		t.Errorf("address filters = %+v (net 0 is a real net, node is unset)", f)
+	}
+	if f.LatestOnly == nil || !*f.LatestOnly {
+		t.Errorf("latest not passed through: %+v", f)
+	}
+
+	o = unset()
+	o.data, o.to = "test-results", "2026-07-31"
+	if _, err := run(context.Background(), src, o, export.CSV, &bytes.Buffer{}); err != nil {
+		t.Fatal(err)
+	}
+	tf := src.testFilter
+	if tf.DateFrom == nil || !tf.DateFrom.Equal(time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("test-results window starts %v, want 30 days before -to", tf.DateFrom)
+	}
+}
+
+func TestRunCountsRows(t *testing.T) {
+	src := &fakeSource{nodes: []database.Node{{Zone: 2, Net: 5001, Node: 100}, {Zone: 2, Net: 5001, Node: 101}}}
+	o := unset()
+	o.data, o.format = "nodes", "jsonl"
+	var out bytes.Buffer
+	rows, err := run(context.Background(), src, o, export.JSONL, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if rows != 2 || strings.Count(out.String(), "\n") != 2 {
+		t.Errorf("rows = %d, output:\n%s", rows, out.String())
+	}
+}
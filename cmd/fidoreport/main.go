@@ -0,0 +1,145 @@
+// fidoreport posts NodelistDB's analytics reports into FidoNet.
+//
+// Much of the audience the reports are about never opens the web site; they
+// read echomail. This renders a report as plain 79-column text and writes it
+// as a type-2+ packet into a mailer's outbound directory, either as echomail
+// to the configured echo or as one netmail to each sysop the report names.
+// The packet is addressed to the configured uplink, which tosses the echomail
+// and routes the netmail onward.
+//
+// Reports:
+//
+//	ipv6-news     IPv6 weekly news: new nodes with working or broken IPv6,
+//	              and established nodes that gained or lost it
+//	aka-mismatch  operational nodes that did not announce their own address
+//	pstn          nodes answered when dialled over PSTN
+//
+// Netmail to sysops exists for the reports that name something a sysop can
+// fix: aka-mismatch, and the broken and lost IPv6 sections of ipv6-news.
+//
+// Usage:
+//
+//	fidoreport -report <name> [-to echo|sysop] [-config config.yaml] [-domain fidonet] [-dry-run]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nodelistdb/internal/config"
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/ftnpkt"
+	"github.com/nodelistdb/internal/storage"
+)
+
+func main() {
+	var (
+		configPath  = flag.String("config", "config.yaml", "Path to configuration file")
+		report      = flag.String("report", "", "Report to post: ipv6-news, aka-mismatch or pstn")
+		to          = flag.String("to", "echo", "Post to the configured echo (echo) or netmail each affected sysop (sysop)")
+		domain      = flag.String("domain", "fidonet", "FTN network the report covers")
+		limit       = flag.Int("limit", 200, "Maximum nodes per report section")
+		days        = flag.Int("days", 7, "Look-back window in days (aka-mismatch, pstn)")
+		includeZero = flag.Bool("include-zero", false, "Include /0 (host) entries")
+		dryRun      = flag.Bool("dry-run", false, "Print the messages instead of writing a packet")
+	)
+	flag.Parse()
+
+	opts := options{
+		report:      *report,
+		to:          *to,
+		domain:      *domain,
+		limit:       *limit,
+		days:        *days,
+		includeZero: *includeZero,
+	}
+	if err := opts.validate(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	cfg, err := config.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to load configuration: %v\n", err)
+		os.Exit(1)
+	}
+	fc := cfg.FidoReports
+	if fc.Outbound == "" {
+		fmt.Fprintf(os.Stderr, "Error: fido_reports.outbound is not set in %s\n", *configPath)
+		os.Exit(1)
+	}
+	if opts.to == "echo" && fc.EchoArea == "" {
+		fmt.Fprintf(os.Stderr, "Error: fido_reports.echo_area is required to post to an echo\n")
+		os.Exit(1)
+	}
+
+	chConfig, err := cfg.ClickHouse.ToClickHouseDatabaseConfig()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid ClickHouse configuration: %v\n", err)
+		os.Exit(1)
+	}
+	db, err := database.NewClickHouse(chConfig)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to connect to ClickHouse: %v\n", err)
+		os.Exit(1)
+	}
+	defer db.Close()
+
+	store, err := storage.New(db)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to initialize storage: %v\n", err)
+		os.Exit(1)
+	}
+	defer store.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+
+	posts, err := collect(ctx, store, opts)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if len(posts) == 0 {
+		fmt.Println("Nothing to report")
+		return
+	}
+
+	// Validated by config.LoadConfig.
+	from, _ := ftnpkt.ParseAddress(fc.FromAddress)
+	uplink, _ := ftnpkt.ParseAddress(fc.Uplink)
+	if from.Domain == "" {
+		from.Domain = opts.domain
+	}
+
+	now := time.Now()
+	msgs := buildMessages(fc, from, uplink, posts, now)
+
+	if *dryRun {
+		for _, m := range msgs {
+			dest := m.Dest.String()
+			if m.Area != "" {
+				dest = "echo " + m.Area
+			}
+			fmt.Printf("=== To: %s (%s)\n=== Subject: %s\n\n%s\n", m.To, dest, m.Subject, m.Body)
+		}
+		fmt.Printf("%d message(s) not written (dry run)\n", len(msgs))
+		return
+	}
+
+	path, err := ftnpkt.WriteFile(fc.Outbound, ftnpkt.Header{
+		Orig:     from,
+		Dest:     uplink,
+		Password: fc.PacketPassword,
+		Created:  now,
+	}, msgs)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to write packet: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d message(s) written to %s\n", len(msgs), path)
+}
@@ -0,0 +1,406 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/config"
+	"github.com/nodelistdb/internal/ftnpkt"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// options is what a run was asked for.
+type options struct {
+	report      string // ipv6-news, aka-mismatch or pstn
+	to          string // echo or sysop
+	domain      string
+	limit       int
+	days        int
+	includeZero bool
+}
+
+func (o options) validate() error {
+	switch o.report {
+	case "ipv6-news", "aka-mismatch", "pstn":
+	case "":
+		return fmt.Errorf("-report is required")
+	default:
+		return fmt.Errorf("unknown report %q", o.report)
+	}
+	switch o.to {
+	case "echo":
+	case "sysop":
+		if o.report == "pstn" {
+			return fmt.Errorf("the pstn report lists working nodes; there is nothing to tell their sysops")
+		}
+	default:
+		return fmt.Errorf("-to must be echo or sysop, got %q", o.to)
+	}
+	if o.limit < 1 || o.days < 1 {
+		return fmt.Errorf("-limit and -days must be positive")
+	}
+	return nil
+}
+
+// reportSource is the slice of storage the reports are built from.
+type reportSource interface {
+	GetIPv6WeeklyNews(ctx context.Context, limit int, includeZeroNodes bool, domain string) (*storage.IPv6WeeklyNews, error)
+	GetAKAMismatchNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]storage.NodeTestResult, error)
+	GetModemAccessibleNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]storage.ModemAccessibleNode, error)
+}
+
+// post is one message before it is addressed: to the echo when netmail is
+// false, otherwise to the sysop of node.
+type post struct {
+	netmail bool
+	node    ftnpkt.Address
+	toName  string
+	subject string
+	body    string
+}
+
+// collect fetches the report and renders it into posts.
+func collect(ctx context.Context, src reportSource, o options) ([]post, error) {
+	switch o.report {
+	case "ipv6-news":
+		news, err := src.GetIPv6WeeklyNews(ctx, o.limit, o.includeZero, o.domain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get IPv6 weekly news: %w", err)
+		}
+		if o.to == "sysop" {
+			return ipv6SysopPosts(news, o.domain), nil
+		}
+		if len(news.NewNodesWorking)+len(news.NewNodesNonWorking)+len(news.OldNodesLostIPv6)+len(news.OldNodesGainedIPv6) == 0 {
+			return nil, nil
+		}
+		return []post{{subject: "IPv6 weekly news", body: renderIPv6News(news)}}, nil
+
+	case "aka-mismatch":
+		nodes, err := src.GetAKAMismatchNodes(ctx, o.limit, o.days, o.includeZero, o.domain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get AKA mismatch nodes: %w", err)
+		}
+		if o.to == "sysop" {
+			return akaSysopPosts(nodes, o.domain), nil
+		}
+		if len(nodes) == 0 {
+			return nil, nil
+		}
+		return []post{{subject: "Nodes announcing the wrong AKA", body: renderAKAMismatch(nodes, o.days)}}, nil
+
+	case "pstn":
+		nodes, err := src.GetModemAccessibleNodes(ctx, o.limit, o.days, o.includeZero, o.domain)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get PSTN accessible nodes: %w", err)
+		}
+		if len(nodes) == 0 {
+			return nil, nil
+		}
+		return []post{{subject: "Nodes answering over PSTN", body: renderPSTN(nodes, o.days)}}, nil
+	}
+	return nil, fmt.Errorf("unknown report %q", o.report)
+}
+
+// renderIPv6News renders the four sections of the IPv6 weekly news.
+func renderIPv6News(news *storage.IPv6WeeklyNews) string {
+	var b strings.Builder
+	b.WriteString("IPv6 connectivity changes over the last week, as seen by the\n")
+	b.WriteString("NodelistDB test daemon.\n")
+
+	section := func(title string, nodes []storage.NodeTestResult, protocols func(storage.NodeTestResult) string) {
+		if len(nodes) == 0 {
+			return
+		}
+		fmt.Fprintf(&b, "\n%s (%d)\n%s\n", title, len(nodes), strings.Repeat("-", len(title)+len(fmt.Sprint(len(nodes)))+3))
+		for _, n := range nodes {
+			fmt.Fprintf(&b, "%-18s %-36s %s\n", nodeAddress(n), cut(testedHostname(n), 36), protocols(n))
+		}
+	}
+	section("New nodes with working IPv6", news.NewNodesWorking, ipv6Working)
+	section("New nodes with broken IPv6", news.NewNodesNonWorking, ipv6Failing)
+	section("Nodes that gained IPv6", news.OldNodesGainedIPv6, ipv6Working)
+	section("Nodes that lost IPv6", news.OldNodesLostIPv6, ipv6Failing)
+	return b.String()
+}
+
+// renderAKAMismatch renders the AKA mismatch list.
+func renderAKAMismatch(nodes []storage.NodeTestResult, days int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Nodes that answered a test over the last %d days but did not\n", days)
+	b.WriteString("announce their nodelist address among their AKAs. Mail routed to\n")
+	b.WriteString("them by nodelist may be refused or misdelivered.\n\n")
+	fmt.Fprintf(&b, "%-18s %s\n", "Node", "Announced")
+	fmt.Fprintf(&b, "%-18s %s\n", "----", "---------")
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "%-18s %s\n", nodeAddress(n), cut(strings.Join(announced(n), " "), 60))
+	}
+	return b.String()
+}
+
+// renderPSTN renders the list of nodes reached by modem.
+func renderPSTN(nodes []storage.ModemAccessibleNode, days int) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "Nodes that answered a modem call over the last %d days.\n\n", days)
+	fmt.Fprintf(&b, "%-16s %-20s %6s  %s\n", "Node", "Phone", "Speed", "System")
+	fmt.Fprintf(&b, "%-16s %-20s %6s  %s\n", "----", "-----", "-----", "------")
+	for _, n := range nodes {
+		fmt.Fprintf(&b, "%-16s %-20s %6d  %s\n", n.Address, cut(n.ModemPhoneDialed, 20), n.ModemConnectSpeed, cut(n.ModemSystemName, 33))
+	}
+	return b.String()
+}
+
+// akaSysopPosts writes each node with an AKA mismatch a netmail of its own.
+func akaSysopPosts(nodes []storage.NodeTestResult, domain string) []post {
+	var posts []post
+	seen := make(map[string]bool)
+	for _, n := range nodes {
+		addr := nodeFTNAddress(n, domain)
+		if seen[addr.String()] {
+			continue
+		}
+		seen[addr.String()] = true
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Hello %s,\n\n", sysopName(n))
+		fmt.Fprintf(&b, "When the NodelistDB test daemon last connected to your system\n")
+		fmt.Fprintf(&b, "(%s, %s), it answered but did not announce\n", cut(testedHostname(n), 40), n.TestTime.UTC().Format("2006-01-02"))
+		fmt.Fprintf(&b, "your nodelist address %s among its AKAs. It announced:\n\n", addr.String())
+		for _, a := range announced(n) {
+			fmt.Fprintf(&b, "    %s\n", a)
+		}
+		b.WriteString("\nMailers calling you for " + addr.String() + " may refuse the session\n")
+		b.WriteString("or hand the mail to the wrong address. Please check the address\n")
+		b.WriteString("list in your mailer's configuration.\n")
+		posts = append(posts, post{
+			netmail: true,
+			node:    addr,
+			toName:  sysopName(n),
+			subject: "Your system does not announce " + addr.String(),
+			body:    b.String(),
+		})
+	}
+	return posts
+}
+
+// ipv6SysopPosts writes each node whose IPv6 is broken or gone a netmail of
+// its own. Nodes that gained or kept working IPv6 have nothing to fix.
+func ipv6SysopPosts(news *storage.IPv6WeeklyNews, domain string) []post {
+	var posts []post
+	seen := make(map[string]bool)
+	add := func(n storage.NodeTestResult, what string) {
+		addr := nodeFTNAddress(n, domain)
+		if seen[addr.String()] {
+			return
+		}
+		seen[addr.String()] = true
+
+		var b strings.Builder
+		fmt.Fprintf(&b, "Hello %s,\n\n", sysopName(n))
+		fmt.Fprintf(&b, "%s\n\n", what)
+		fmt.Fprintf(&b, "Host:    %s\n", testedHostname(n))
+		if len(n.ResolvedIPv6) > 0 {
+			fmt.Fprintf(&b, "IPv6:    %s\n", strings.Join(n.ResolvedIPv6, " "))
+		}
+		fmt.Fprintf(&b, "Tested:  %s\n", n.TestTime.UTC().Format("2006-01-02 15:04 UTC"))
+		for _, f := range ipv6Failures(n) {
+			fmt.Fprintf(&b, "  %s\n", f)
+		}
+		b.WriteString("\nIf the AAAA record is not meant to be there, removing it lets\n")
+		b.WriteString("IPv6 callers fall back to IPv4 straight away.\n")
+		posts = append(posts, post{
+			netmail: true,
+			node:    addr,
+			toName:  sysopName(n),
+			subject: "IPv6 problem at " + addr.String(),
+			body:    b.String(),
+		})
+	}
+	for _, n := range news.NewNodesNonWorking {
+		add(n, "Your node is new to the nodelist and its hostname has an IPv6\naddress, but nothing answered the test daemon on it.")
+	}
+	for _, n := range news.OldNodesLostIPv6 {
+		add(n, "Your node used to answer over IPv6, but this week it no longer\ndid.")
+	}
+	return posts
+}
+
+// buildMessages addresses posts, splitting any too long for one message.
+func buildMessages(fc config.FidoReportsConfig, from, uplink ftnpkt.Address, posts []post, now time.Time) []ftnpkt.Message {
+	var msgs []ftnpkt.Message
+	for _, p := range posts {
+		parts := splitBody(p.body, fc.MaxMessageSize)
+		for i, part := range parts {
+			m := ftnpkt.Message{
+				Orig:    from,
+				From:    fc.FromName,
+				Subject: p.subject,
+				// Distinct dates keep the parts in order in readers that
+				// sort by date.
+				Date: now.Add(time.Duration(len(msgs)) * time.Second),
+				PID:  "NodelistDB fidoreport",
+				Body: part,
+			}
+			if len(parts) > 1 {
+				m.Subject = fmt.Sprintf("%s (%d/%d)", p.subject, i+1, len(parts))
+			}
+			if p.netmail {
+				m.Dest = p.node
+				m.To = p.toName
+				m.Attr = ftnpkt.AttrPrivate | ftnpkt.AttrLocal | ftnpkt.AttrKillSent
+			} else {
+				m.Dest = uplink
+				m.To = fc.EchoTo
+				m.Area = fc.EchoArea
+				m.Origin = fc.Origin
+				m.Attr = ftnpkt.AttrLocal
+			}
+			msgs = append(msgs, m)
+		}
+	}
+	return msgs
+}
+
+// splitBody cuts text into parts of at most max bytes, between lines.
+func splitBody(text string, max int) []string {
+	if max <= 0 || len(text) <= max {
+		return []string{text}
+	}
+	var parts []string
+	var cur strings.Builder
+	for _, line := range strings.SplitAfter(text, "\n") {
+		for len(line) > max {
+			// A single line longer than a message; cut it where it must.
+			if cur.Len() > 0 {
+				parts = append(parts, cur.String())
+				cur.Reset()
+			}
+			parts = append(parts, line[:max])
+			line = line[max:]
+		}
+		if cur.Len()+len(line) > max {
+			parts = append(parts, cur.String())
+			cur.Reset()
+		}
+		cur.WriteString(line)
+	}
+	if cur.Len() > 0 {
+		parts = append(parts, cur.String())
+	}
+	return parts
+}
+
+// nodeAddress is a result's node address for display.
+func nodeAddress(n storage.NodeTestResult) string {
+	if n.Address != "" {
+		return n.Address
+	}
+	return fmt.Sprintf("%d:%d/%d", n.Zone, n.Net, n.Node)
+}
+
+// nodeFTNAddress is where netmail for a result's sysop goes.
+func nodeFTNAddress(n storage.NodeTestResult, domain string) ftnpkt.Address {
+	if n.Domain != "" {
+		domain = n.Domain
+	}
+	return ftnpkt.Address{Zone: n.Zone, Net: n.Net, Node: n.Node, Domain: domain}
+}
+
+// sysopName is who a netmail to a result's node is addressed to: the name
+// its handshake gave, or "Sysop".
+func sysopName(n storage.NodeTestResult) string {
+	for _, s := range []string{n.BinkPSysop, n.VModemSysop} {
+		if s = strings.TrimSpace(s); s != "" {
+			return s
+		}
+	}
+	return "Sysop"
+}
+
+// testedHostname is the hostname a result was tested on.
+func testedHostname(n storage.NodeTestResult) string {
+	if n.TestedHostname != "" {
+		return n.TestedHostname
+	}
+	return n.Hostname
+}
+
+// announced is every address a result's handshakes announced, deduplicated
+// and sorted.
+func announced(n storage.NodeTestResult) []string {
+	set := make(map[string]bool)
+	for _, list := range [][]string{n.BinkPAddresses, n.IfcicoAddresses, n.VModemAddresses} {
+		for _, a := range list {
+			set[a] = true
+		}
+	}
+	out := make([]string, 0, len(set))
+	for a := range set {
+		out = append(out, a)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// ipv6Working lists the protocols that answered over IPv6.
+func ipv6Working(n storage.NodeTestResult) string {
+	var out []string
+	for _, p := range ipv6Protocols(n) {
+		if p.ok {
+			out = append(out, p.name)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// ipv6Failing lists the protocols tested over IPv6 that did not answer.
+func ipv6Failing(n storage.NodeTestResult) string {
+	var out []string
+	for _, p := range ipv6Protocols(n) {
+		if p.tested && !p.ok {
+			out = append(out, p.name)
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// ipv6Failures describes each protocol that failed over IPv6.
+func ipv6Failures(n storage.NodeTestResult) []string {
+	var out []string
+	for _, p := range ipv6Protocols(n) {
+		if p.tested && !p.ok {
+			msg := p.err
+			if msg == "" {
+				msg = "failed"
+			}
+			out = append(out, fmt.Sprintf("%-7s %s", p.name+":", cut(msg, 60)))
+		}
+	}
+	return out
+}
+
+type ipv6Protocol struct {
+	name       string
+	tested, ok bool
+	err        string
+}
+
+func ipv6Protocols(n storage.NodeTestResult) []ipv6Protocol {
+	return []ipv6Protocol{
+		{"BinkP", n.BinkPIPv6Tested, n.BinkPIPv6Success, n.BinkPIPv6Error},
+		{"IFCICO", n.IfcicoIPv6Tested, n.IfcicoIPv6Success, n.IfcicoIPv6Error},
+		{"Telnet", n.TelnetIPv6Tested, n.TelnetIPv6Success, n.TelnetIPv6Error},
+		{"FTP", n.FTPIPv6Tested, n.FTPIPv6Success, n.FTPIPv6Error},
+	}
+}
+
+// cut shortens s to n runes for a fixed-width column.
+func cut(s string, n int) string {
+	r := []rune(s)
+	if len(r) <= n {
+		return s
+	}
+	return string(r[:n-1]) + "~"
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/config"
+	"github.com/nodelistdb/internal/ftnpkt"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// fakeSource serves fixed report data.
+type fakeSource struct {
+	news  *storage.IPv6WeeklyNews
+	aka   []storage.NodeTestResult
+	modem []storage.ModemAccessibleNode
+}
+
+func (f *fakeSource) GetIPv6WeeklyNews(context.Context, int, bool, string) (*storage.IPv6WeeklyNews, error) {
+	if f.news == nil {
+		return &storage.IPv6WeeklyNews{}, nil
+	}
+	return f.news, nil
+}
+
+func (f *fakeSource) GetAKAMismatchNodes(context.Context, int, int, bool, string) ([]storage.NodeTestResult, error) {
+	return f.aka, nil
+}
+
+func (f *fakeSource) GetModemAccessibleNodes(context.Context, int, int, bool, string) ([]storage.ModemAccessibleNode, error) {
+	return f.modem, nil
+}
+
+func testSource() *fakeSource {
+	return &fakeSource{
+		news: &storage.IPv6WeeklyNews{
+			NewNodesWorking: []storage.NodeTestResult{
+				{Zone: 2, Net: 5020, Node: 1, Address: "2:5020/1", Hostname: "f1.example.net", BinkPIPv6Tested: true, BinkPIPv6Success: true},
+			},
+			OldNodesLostIPv6: []storage.NodeTestResult{
+				{Zone: 2, Net: 5020, Node: 2, Address: "2:5020/2", TestedHostname: "f2.example.net",
+					BinkPSysop: "Jane Doe", BinkPIPv6Tested: true, BinkPIPv6Error: "connection timed out"},
+			},
+		},
+		aka: []storage.NodeTestResult{
+			{Zone: 2, Net: 5020, Node: 3, Address: "2:5020/3", Hostname: "f3.example.net",
+				BinkPAddresses: []string{"2:5020/33@fidonet", "2:5020/33@fidonet"}, IfcicoAddresses: []string{"2:5020/4"}},
+			{Zone: 2, Net: 5020, Node: 3, Address: "2:5020/3", TestedHostname: "backup.example.net"},
+		},
+		modem: []storage.ModemAccessibleNode{
+			{Address: "2:5020/5", ModemPhoneDialed: "+7-495-555-0101", ModemConnectSpeed: 33600, ModemSystemName: "Modem BBS"},
+		},
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    options
+		wantErr bool
+	}{
+		{"echo report", options{report: "ipv6-news", to: "echo", limit: 10, days: 7}, false},
+		{"sysop report", options{report: "aka-mismatch", to: "sysop", limit: 10, days: 7}, false},
+		{"no report", options{to: "echo", limit: 10, days: 7}, true},
+		{"unknown report", options{report: "weather", to: "echo", limit: 10, days: 7}, true},
+		{"pstn has no sysop form", options{report: "pstn", to: "sysop", limit: 10, days: 7}, true},
+		{"bad target", options{report: "pstn", to: "everyone", limit: 10, days: 7}, true},
+		{"zero limit", options{report: "pstn", to: "echo", days: 7}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.opts.validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%s: validate() = %v, wantErr %v", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestCollectEcho(t *testing.T) {
+	tests := []struct {
+		report string
+		want   []string
+	}{
+		{"ipv6-news", []string{
+			"New nodes with working IPv6 (1)\n",
+			"2:5020/1           f1.example.net                       BinkP\n",
+			"Nodes that lost IPv6 (1)\n",
+			"2:5020/2           f2.example.net                       BinkP\n",
+		}},
+		{"aka-mismatch", []string{"2:5020/3           2:5020/33@fidonet 2:5020/4\n"}},
+		{"pstn", []string{"2:5020/5         +7-495-555-0101       33600  Modem BBS\n"}},
+	}
+	for _, tt := range tests {
+		posts, err := collect(context.Background(), testSource(), options{report: tt.report, to: "echo", domain: "fidonet", limit: 10, days: 7})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.report, err)
+		}
+		if len(posts) != 1 || posts[0].netmail {
+			t.Fatalf("%s: got %d posts, want one echo post", tt.report, len(posts))
+		}
+		for _, want := range tt.want {
+			if !strings.Contains(posts[0].body, want) {
+				t.Errorf("%s: body lacks %q:\n%s", tt.report, want, posts[0].body)
+			}
+		}
+		for _, line := range strings.Split(posts[0].body, "\n") {
+			if len([]rune(line)) > 79 {
+				t.Errorf("%s: line wider than 79 columns: %q", tt.report, line)
+			}
+		}
+	}
+
+	// An empty report posts nothing.
+	posts, err := collect(context.Background(), &fakeSource{}, options{report: "ipv6-news", to: "echo", limit: 10, days: 7})
+	if err != nil || len(posts) != 0 {
+		t.Errorf("empty news gave %d posts, %v", len(posts), err)
+	}
+}
+
+func TestCollectSysop(t *testing.T) {
+	posts, err := collect(context.Background(), testSource(), options{report: "aka-mismatch", to: "sysop", domain: "fidonet", limit: 10, days: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both rows are for 2:5020/3: one netmail.
+	if len(posts) != 1 {
+		t.Fatalf("got %d posts, want 1", len(posts))
+	}
+	p := posts[0]
+	if !p.netmail || p.node != (ftnpkt.Address{Zone: 2, Net: 5020, Node: 3, Domain: "fidonet"}) || p.toName != "Sysop" {
+		t.Errorf("post addressed to %+v %q", p.node, p.toName)
+	}
+	if !strings.Contains(p.body, "    2:5020/33@fidonet\n    2:5020/4\n") {
+		t.Errorf("body does not list the announced AKAs:\n%s", p.body)
+	}
+
+	posts, err = collect(context.Background(), testSource(), options{report: "ipv6-news", to: "sysop", domain: "fidonet", limit: 10, days: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Only the node that lost IPv6 has anything to fix.
+	if len(posts) != 1 || posts[0].node.Node != 2 || posts[0].toName != "Jane Doe" {
+		t.Fatalf("got %+v, want one netmail to Jane Doe at 2:5020/2", posts)
+	}
+	if !strings.Contains(posts[0].body, "BinkP:  connection timed out") {
+		t.Errorf("body lacks the failure:\n%s", posts[0].body)
+	}
+}
+
+func TestBuildMessages(t *testing.T) {
+	fc := config.FidoReportsConfig{
+		FromName:       "NodelistDB",
+		EchoArea:       "NODELIST.STATS",
+		EchoTo:         "All",
+		Origin:         "NodelistDB",
+		MaxMessageSize: 20,
+	}
+	from := ftnpkt.Address{Zone: 2, Net: 5020, Node: 1042}
+	uplink := ftnpkt.Address{Zone: 2, Net: 5020, Node: 1}
+	node := ftnpkt.Address{Zone: 1, Net: 234, Node: 5}
+	posts := []post{
+		{subject: "Report", body: "0123456789\n0123456789\n"},
+		{netmail: true, node: node, toName: "Joe", subject: "Hi", body: "short\n"},
+	}
+	msgs := buildMessages(fc, from, uplink, posts, time.Date(2026, 10, 1, 0, 0, 0, 0, time.UTC))
+	if len(msgs) != 3 {
+		t.Fatalf("got %d messages, want the echo post in two parts plus one netmail", len(msgs))
+	}
+	if msgs[0].Subject != "Report (1/2)" || msgs[1].Subject != "Report (2/2)" {
+		t.Errorf("subjects %q, %q", msgs[0].Subject, msgs[1].Subject)
+	}
+	if msgs[0].Area != "NODELIST.STATS" || msgs[0].Dest != uplink || msgs[0].To != "All" {
+		t.Errorf("echo part addressed %+v", msgs[0])
+	}
+	if !msgs[1].Date.After(msgs[0].Date) {
+		t.Error("parts share a date")
+	}
+	nm := msgs[2]
+	if nm.Area != "" || nm.Dest != node || nm.To != "Joe" || nm.Attr&ftnpkt.AttrPrivate == 0 {
+		t.Errorf("netmail addressed %+v", nm)
+	}
+}
+
+func TestSplitBody(t *testing.T) {
+	tests := []struct {
+		text string
+		max  int
+		want []string
+	}{
+		{"a\nb\n", 100, []string{"a\nb\n"}},
+		{"aaaa\nbbbb\ncccc\n", 10, []string{"aaaa\nbbbb\n", "cccc\n"}},
+		{"aaaaaaaaaaaa\nb\n", 5, []string{"aaaaa", "aaaaa", "aa\nb\n"}},
+	}
+	for _, tt := range tests {
+		got := splitBody(tt.text, tt.max)
+		if strings.Join(got, "|") != strings.Join(tt.want, "|") {
+			t.Errorf("splitBody(%q, %d) = %q, want %q", tt.text, tt.max, got, tt.want)
+		}
+		if strings.Join(got, "") != tt.text {
+			t.Errorf("splitBody(%q, %d) lost text", tt.text, tt.max)
+		}
+	}
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/nodelistdb/internal/nodelistfs"
+	"github.com/nodelistdb/internal/parser"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// runExportNodelist rebuilds the network's nodelist for one imported date from
+// the database and writes it to output (default: the network's file name for
+// that day, e.g. nodelist.182, in the current directory). It is the same
+// rebuild /api/nodelist/{domain}/{date}.txt serves.
+func runExportNodelist(storageLayer *storage.Storage, network, exportDate, output string, quiet bool) error {
+	date, err := time.Parse("2006-01-02", exportDate)
+	if err != nil {
+		return fmt.Errorf("invalid -export-date %q: %w", exportDate, err)
+	}
+
+	nodes, text, err := storageLayer.GetNodelistLines(context.Background(), network, date)
+	if err != nil {
+		return err
+	}
+	if len(nodes) == 0 && len(text) == 0 {
+		return fmt.Errorf("no %s nodelist imported for %s", network, exportDate)
+	}
+
+	dayNumber := date.YearDay()
+	header := parser.SyntheticHeader(nodelistfs.DisplayName(network), date, dayNumber)
+	lines, exact := parser.RebuildNodelist(nodes, text, header)
+
+	if output == "" {
+		output = fmt.Sprintf("%s%03d", nodelistfs.FilePrefix(network), dayNumber)
+	}
+	if err := writeNodelistFile(output, lines); err != nil {
+		return err
+	}
+
+	// Not quiet-suppressed: the caller asked for a nodelist and is getting
+	// something less, which cron output should show.
+	switch {
+	case !exact && len(text) > 0:
+		fmt.Fprintf(os.Stderr, "Warning: %s does not match the CRC its header declares; lines were lost or altered in the database\n",
+			output)
+	case !exact:
+		fmt.Fprintf(os.Stderr, "Warning: %s was imported before line numbers were recorded; %s is an approximation (address order, synthesized header, no comments)\n",
+			exportDate, output)
+	}
+	if !quiet {
+		fmt.Printf("Wrote %s: %d lines, CRC %05d\n", output, len(lines), parser.NodelistCRC(lines))
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nodelistdb/internal/geocode"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// runGeocodeLocations resolves every nodelist Location not yet resolved
+// with the bundled gazetteer and stores the coordinates the node map reads.
+func runGeocodeLocations(storageLayer *storage.Storage, quiet bool) error {
+	start := time.Now()
+	g, err := geocode.Default()
+	if err != nil {
+		return err
+	}
+	result, err := storageLayer.LocationGeocodeOps().GeocodeLocations(context.Background(), g)
+	if err != nil {
+		return fmt.Errorf("location geocoding failed: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Geocoded %d new locations with gazetteer %s in %v\n",
+			result.Locations, result.Gazetteer, time.Since(start).Round(time.Second))
+		fmt.Printf("Cities: %d, states and provinces: %d, countries only: %d, unresolved: %d\n",
+			result.City, result.Region, result.Country, result.Unresolved)
+	}
+	return nil
+}
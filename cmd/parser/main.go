@@ -48,6 +48,29 @@ func main() {
 		plReimport     = flag.Bool("reimport", false, "Delete and reimport already-imported pointlist files (corrected-file replay)")
 		plForce        = flag.Bool("force", false, "Bypass pointlist sanity thresholds (0 points / <50% of nearest issue)")
 		plShrinkCheck  = flag.String("shrink-check", "fail", "When a pointlist shrinks below 50% of the nearest imported issue: fail (refuse) or warn (import anyway)")
+
+		// NODEDIFF support (FTS-5000 section 3)
+		nodediffMode = flag.Bool("nodediff", false, "Treat -path as NODEDIFF files: apply each to its base nodelist from the archive, then import the result")
+		nodediffOut  = flag.String("nodediff-out", "", "Directory reconstructed nodelists are written to as <dir>/<year>/<file> (default: a temporary directory)")
+		diffFrom     = flag.String("diff-from", "", "Generate a NODEDIFF from the archived nodelist of this date (YYYY-MM-DD); requires -diff-to")
+		diffTo       = flag.String("diff-to", "", "Generate a NODEDIFF to the archived nodelist of this date (YYYY-MM-DD); requires -diff-from")
+		diffOutput   = flag.String("diff-output", "", "File the generated NODEDIFF is written to (default: nodediff.DDD)")
+
+		// CRC validation flags
+		crcCheck      = flag.String("crc-check", "warn", "Nodelists whose computed CRC disagrees with the header: strict (refuse and quarantine), warn (import and report) or off")
+		quarantineDir = flag.String("quarantine-dir", "", "Directory nodelists refused by -crc-check strict are copied to")
+
+		// Export flags
+		exportDate   = flag.String("export-date", "", "Rebuild the nodelist imported for this date (YYYY-MM-DD) from the database and write it out")
+		exportOutput = flag.String("export-output", "", "File the -export-date nodelist is written to (default: the network's file name for that day)")
+
+		// Sysop identities, node tenancies and location geocoding
+		resolveSysops    = flag.Bool("resolve-sysops", false, "Resolve the sysops of every network's nodes and points into persons for the /sysop pages (no import; run after imports)")
+		detectTenancies  = flag.Bool("detect-tenancies", false, "Split every node address's history by occupant for the node searches (no import; run after imports; combines with -resolve-sysops)")
+		geocodeLocations = flag.Bool("geocode-locations", false, "Resolve new nodelist Location fields to coordinates with the bundled gazetteer for the node map (no import; run after imports; combines with -resolve-sysops and -detect-tenancies)")
+
+		// Change notifications
+		notifyChanges = flag.Bool("notify", true, "Send node change notifications for nodelists newer than the newest already imported (when notifications are enabled in the config); -notify=false for bulk catch-up runs")
 	)
 	flag.Parse()
 
@@ -63,8 +86,27 @@ func main() {
 		os.Exit(1)
 	}
 
-	if *path == "" && !*rebuildFTSOnly {
-		fmt.Fprintf(os.Stderr, "Error: -path is required (unless using -rebuild-fts)\n")
+	makeNodediff := *diffFrom != "" || *diffTo != ""
+	if makeNodediff && (*diffFrom == "" || *diffTo == "") {
+		fmt.Fprintf(os.Stderr, "Error: -diff-from and -diff-to must be given together\n")
+		os.Exit(1)
+	}
+
+	exportMode := *exportDate != ""
+	if exportMode && (*path != "" || *rebuildFTSOnly || makeNodediff) {
+		fmt.Fprintf(os.Stderr, "Error: -export-date is mutually exclusive with -path, -rebuild-fts and -diff-from/-diff-to\n")
+		os.Exit(1)
+	}
+
+	// Modes that read everything imported and import nothing
+	postImport := *resolveSysops || *detectTenancies || *geocodeLocations
+	if postImport && (*path != "" || *rebuildFTSOnly || makeNodediff || exportMode) {
+		fmt.Fprintf(os.Stderr, "Error: -resolve-sysops, -detect-tenancies and -geocode-locations are mutually exclusive with -path, -rebuild-fts, -diff-from/-diff-to and -export-date\n")
+		os.Exit(1)
+	}
+
+	if *path == "" && !*rebuildFTSOnly && !makeNodediff && !exportMode && !postImport {
+		fmt.Fprintf(os.Stderr, "Error: -path is required (unless using -rebuild-fts, -diff-from/-diff-to, -export-date, -resolve-sysops, -detect-tenancies or -geocode-locations)\n")
 		flag.Usage()
 		os.Exit(1)
 	}
@@ -81,10 +123,21 @@ func main() {
 		fmt.Fprintf(os.Stderr, "Error: -extract-points is mutually exclusive with -pointlist, -rebuild-fts and -concurrent\n")
 		os.Exit(1)
 	}
+	if *nodediffMode && (*pointlistMode || *extractPoints || *rebuildFTSOnly) {
+		fmt.Fprintf(os.Stderr, "Error: -nodediff is mutually exclusive with -pointlist, -extract-points and -rebuild-fts\n")
+		os.Exit(1)
+	}
 	if *pointlistMode && *plShrinkCheck != "fail" && *plShrinkCheck != "warn" {
 		fmt.Fprintf(os.Stderr, "Error: -shrink-check must be 'fail' or 'warn'\n")
 		os.Exit(1)
 	}
+	crcMode, err := parser.ParseCRCCheckMode(*crcCheck)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: -crc-check: %v\n", err)
+		os.Exit(1)
+	}
+	crcPolicy := parser.CRCPolicy{Mode: crcMode, QuarantineDir: *quarantineDir, Quiet: *quiet}
+
 	// -1 is the "derive from filename family" sentinel; the stored column is
 	// UInt8, and an out-of-range value silently wrapping to 0 would make a
 	// file the MOST authoritative source in every snapshot.
@@ -122,6 +175,15 @@ func main() {
 		os.Exit(1)
 	}
 
+	// NODEDIFF generation works on the archive alone; no database needed
+	if makeNodediff {
+		if err := runMakeNodediff(networkCfg.Name, *diffFrom, *diffTo, *diffOutput, *quiet); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	// Verify database configuration
 	if cfg.ClickHouse.Host == "" {
 		logging.Fatalf("ClickHouse configuration is missing in %s", *configPath)
@@ -134,6 +196,20 @@ func main() {
 			cfg.ClickHouse.Host, cfg.ClickHouse.Port, cfg.ClickHouse.Database)
 		if *rebuildFTSOnly {
 			fmt.Println("Mode: FTS Index Rebuild")
+		} else if postImport {
+			if *resolveSysops {
+				fmt.Println("Mode: Sysop Identity Resolution (all networks)")
+			}
+			if *detectTenancies {
+				fmt.Println("Mode: Node Tenancy Detection (all networks)")
+			}
+			if *geocodeLocations {
+				fmt.Println("Mode: Location Geocoding (all networks)")
+			}
+		} else if exportMode {
+			fmt.Println("Mode: Nodelist Export")
+			fmt.Printf("Network: %s\n", networkCfg.Name)
+			fmt.Printf("Date: %s\n", *exportDate)
 		} else if *pointlistMode {
 			fmt.Println("Mode: Pointlist Import")
 			fmt.Printf("Network: %s\n", networkCfg.Name)
@@ -146,6 +222,9 @@ func main() {
 				fmt.Printf("List source: %s\n", *listSource)
 			}
 		} else {
+			if *nodediffMode {
+				fmt.Println("Mode: NODEDIFF Import")
+			}
 			fmt.Printf("Network: %s\n", networkCfg.Name)
 			fmt.Printf("Path: %s\n", *path)
 			fmt.Printf("Batch size: %d\n", *batchSize)
@@ -211,6 +290,39 @@ func main() {
 	}
 	defer storageLayer.Close()
 
+	// Export mode: rebuild one imported nodelist, no import
+	if exportMode {
+		if err := runExportNodelist(storageLayer, networkCfg.Name, *exportDate, *exportOutput, *quiet); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	// Sysop identity resolution, tenancy detection and location geocoding:
+	// read everything imported, import nothing
+	if postImport {
+		if *resolveSysops {
+			if err := runResolveSysops(storageLayer, *quiet); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if *detectTenancies {
+			if err := runDetectTenancies(storageLayer, *quiet); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		if *geocodeLocations {
+			if err := runGeocodeLocations(storageLayer, *quiet); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		return
+	}
+
 	// Extract-points backfill: inline nodelist points only, no node import
 	if *extractPoints {
 		failed := runExtractPoints(storageLayer, *path, networkCfg.Name, networkCfg.Pattern(), *recursive, *verbose, *quiet)
@@ -252,12 +364,45 @@ func main() {
 	nodelistParser.CollectPoints = true
 
 	// Find nodelist files
-	files, err := findNodelistFiles(*path, *recursive, networkCfg.Pattern())
+	filePattern := networkCfg.Pattern()
+	if *nodediffMode {
+		filePattern = nodediffPattern
+	}
+	files, err := findNodelistFiles(*path, *recursive, filePattern)
 	if err != nil {
 		logging.Fatalf("Failed to find nodelist files: %v", err)
 	}
 
+	// NODEDIFF mode: swap the diffs for the nodelists they reconstruct, which
+	// then go through the ordinary import below
+	nodediffFailed := 0
+	// removeNodediffOut deletes the temporary directory the reconstructed
+	// nodelists went to, if one was made. It is deferred, and also called by
+	// hand before every exit from here on: os.Exit skips deferred calls.
+	removeNodediffOut := func() {}
+	if *nodediffMode && len(files) > 0 {
+		outDir := *nodediffOut
+		if outDir == "" {
+			tmpDir, err := os.MkdirTemp("", "nodediff-")
+			if err != nil {
+				logging.Fatalf("Failed to create temporary directory: %v", err)
+			}
+			removeNodediffOut = func() { _ = os.RemoveAll(tmpDir) }
+			defer removeNodediffOut()
+			outDir = tmpDir
+		}
+		files, nodediffFailed = reconstructFromNodediffs(files, networkCfg.Name, outDir, *verbose, *quiet)
+		if !*quiet {
+			fmt.Println()
+		}
+	}
+
 	if len(files) == 0 {
+		if nodediffFailed > 0 {
+			fmt.Fprintf(os.Stderr, "%d NODEDIFF file(s) could not be applied\n", nodediffFailed)
+			removeNodediffOut()
+			os.Exit(1)
+		}
 		if !*quiet {
 			fmt.Printf("No nodelist files found in: %s\n", *path)
 		}
@@ -281,6 +426,17 @@ func main() {
 
 	ctx := context.Background()
 
+	// Where the network stood before this run: notifications cover only the
+	// nodelists past it
+	sendNotifications := *notifyChanges && cfg.Notifications.Enabled
+	var notifyHead time.Time
+	if sendNotifications {
+		if notifyHead, err = knownHead(ctx, storageLayer, networkCfg.Name); err != nil {
+			fmt.Printf("Warning: notifications disabled for this run: %v\n", err)
+			sendNotifications = false
+		}
+	}
+
 	if *enableConcurrent && len(files) > 1 {
 		// Use concurrent processing
 		if !*quiet {
@@ -290,9 +446,11 @@ func main() {
 		storageAdapter := concurrent.NewStorageAdapter(storageLayer.NodeOps(), storageLayer)
 		processor := concurrent.NewMultiProcessor(storageAdapter, *workers, *batchSize, *verbose, *quiet)
 		processor.SetDomain(networkCfg.Name)
+		processor.SetCRCCheck(crcPolicy.Mode, crcPolicy.QuarantineDir)
 
 		err := processor.ProcessFiles(ctx, files)
 		if err != nil {
+			removeNodediffOut()
 			logging.Fatalf("Concurrent processing failed: %v", err)
 		}
 	} else {
@@ -355,11 +513,18 @@ func main() {
 					} else if !*quiet {
 						fmt.Println("  Nodelist already processed, skipping")
 					}
+					// Recorded anyway: this backfills the registry for
+					// nodelists imported before it existed.
+					parser.RegisterNodelistFile(storageLayer, networkCfg.Name, parseResult, true)
 					filesProcessed++
 					continue
 				}
 			}
 
+			if !crcPolicy.Admit(storageLayer, networkCfg.Name, parseResult) {
+				continue
+			}
+
 			// Process nodes in batches, but only from current file
 			batchErrors := false
 			for i := 0; i < len(nodes); i += *batchSize {
@@ -390,6 +555,16 @@ func main() {
 				}
 			}
 
+			// Keep the lines the node rows do not reproduce, so the file can
+			// be rebuilt for export (-export-date, /api/nodelist/...).
+			if !batchErrors {
+				if err := storageLayer.InsertNodelistLines(parseResult.TextLines); err != nil {
+					fmt.Printf("  Warning: Failed to store nodelist text lines: %v\n", err)
+					// Non-fatal: the export falls back to an approximation
+				}
+				parser.RegisterNodelistFile(storageLayer, networkCfg.Name, parseResult, true)
+			}
+
 			// Import inline points (gated separately by pointlist_files).
 			// Called even with 0 points: a nodelist that dropped its inline
 			// points must supersede the previous issue in snapshot queries.
@@ -417,6 +592,10 @@ func main() {
 		}
 	}
 
+	if sendNotifications {
+		runNotifications(ctx, storageLayer, cfg.Notifications, cfg.NetworkNames(), networkCfg.Name, notifyHead, *quiet)
+	}
+
 	// Create FTS indexes for better search performance (after data loading)
 	if *createFTSIndexes {
 		if !*quiet {
@@ -440,6 +619,12 @@ func main() {
 		fmt.Println("Processing completed!")
 		fmt.Printf("Processing time: %v\n", duration)
 	}
+
+	if nodediffFailed > 0 {
+		fmt.Fprintf(os.Stderr, "%d NODEDIFF file(s) could not be applied\n", nodediffFailed)
+		removeNodediffOut()
+		os.Exit(1)
+	}
 }
 
 // findNodelistFiles finds all nodelist files in the specified path that match
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/nodelistdb/internal/nodelistfs"
+	"github.com/nodelistdb/internal/parser"
+)
+
+// nodediffPattern matches NODEDIFF filenames (.gz stripped first). Hubs
+// distribute them as NODEDIFF.DDD, DDD being the day of the nodelist the diff
+// produces.
+var nodediffPattern = regexp.MustCompile(`^nodediff\.\d{3}$`)
+
+// pendingNodediff is a parsed NODEDIFF waiting for its base nodelist.
+type pendingNodediff struct {
+	path     string
+	diff     *parser.Nodediff
+	baseDate time.Time
+}
+
+// reconstructFromNodediffs applies NODEDIFF files to their base nodelists and
+// writes each result to <outDir>/<year>/<network prefix>DDD, returning the
+// written paths in date order for the normal import loop.
+//
+// A diff's base is looked up first among the nodelists reconstructed in this
+// run and then in the network's archive, so several consecutive weeks of diffs
+// can be applied in one go on top of the last archived full list. Diffs are
+// applied oldest base first for the same reason. Returns the number of diffs
+// that could not be applied.
+func reconstructFromNodediffs(diffPaths []string, network, outDir string, verbose, quiet bool) ([]string, int) {
+	p := parser.New(verbose)
+	failed := 0
+
+	var pending []pendingNodediff
+	for _, path := range diffPaths {
+		diff, err := parser.ReadNodediffFile(path)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  ERROR: %s: %v\n", path, err)
+			failed++
+			continue
+		}
+		baseDate, _, err := p.HeaderDate(diff.BaseHeader)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  ERROR: %s: cannot date the base nodelist from %q: %v\n", path, diff.BaseHeader, err)
+			failed++
+			continue
+		}
+		pending = append(pending, pendingNodediff{path: path, diff: diff, baseDate: baseDate})
+	}
+	sort.SliceStable(pending, func(i, j int) bool { return pending[i].baseDate.Before(pending[j].baseDate) })
+
+	reconstructed := make(map[string][]string) // header line -> nodelist lines
+	var written []string
+
+	for _, pd := range pending {
+		if !quiet {
+			fmt.Printf("Applying %s to the %s nodelist of %s\n", filepath.Base(pd.path), network, pd.baseDate.Format("2006-01-02"))
+		}
+
+		base, ok := reconstructed[pd.diff.BaseHeader]
+		if !ok {
+			file, err := nodelistfs.FindByDate(network, pd.baseDate)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "  ERROR: %s: base nodelist not available: %v\n", pd.path, err)
+				failed++
+				continue
+			}
+			if base, err = parser.ReadNodelistFile(file.Path); err != nil {
+				fmt.Fprintf(os.Stderr, "  ERROR: %s: %v\n", pd.path, err)
+				failed++
+				continue
+			}
+			if verbose {
+				fmt.Printf("  Base: %s\n", file.Path)
+			}
+		}
+
+		result, err := pd.diff.Apply(base)
+		if err != nil {
+			var crcErr *parser.CRCError
+			switch {
+			case errors.Is(err, parser.ErrNodediffBaseMismatch):
+				fmt.Fprintf(os.Stderr, "  ERROR: %s: archived base header differs from the diff's (%q)\n", pd.path, pd.diff.BaseHeader)
+			case errors.As(err, &crcErr):
+				fmt.Fprintf(os.Stderr, "  ERROR: %s: reconstructed nodelist fails its CRC check (header %05d, computed %05d)\n",
+					pd.path, crcErr.Header, crcErr.Computed)
+			default:
+				fmt.Fprintf(os.Stderr, "  ERROR: %s: %v\n", pd.path, err)
+			}
+			failed++
+			continue
+		}
+
+		date, dayNumber, err := p.HeaderDate(result[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "  ERROR: %s: cannot date the reconstructed nodelist: %v\n", pd.path, err)
+			failed++
+			continue
+		}
+
+		outPath := filepath.Join(outDir, strconv.Itoa(date.Year()), nodelistfs.FilePrefix(network)+fmt.Sprintf("%03d", dayNumber))
+		if err := writeNodelistFile(outPath, result); err != nil {
+			fmt.Fprintf(os.Stderr, "  ERROR: %s: %v\n", pd.path, err)
+			failed++
+			continue
+		}
+		reconstructed[result[0]] = result
+		written = append(written, outPath)
+
+		if !quiet {
+			fmt.Printf("  ✓ Reconstructed %s (%d lines, CRC verified)\n", outPath, len(result))
+		}
+	}
+
+	return written, failed
+}
+
+// runMakeNodediff generates the NODEDIFF between two archived nodelists and
+// writes it to output (default: nodediff.DDD in the current directory, DDD
+// being the target's day number). The diff is applied back to its base before
+// it is written, so a file this produces is known to reconstruct the target.
+func runMakeNodediff(network, fromDate, toDate, output string, quiet bool) error {
+	from, err := time.Parse("2006-01-02", fromDate)
+	if err != nil {
+		return fmt.Errorf("invalid -diff-from date %q: %w", fromDate, err)
+	}
+	to, err := time.Parse("2006-01-02", toDate)
+	if err != nil {
+		return fmt.Errorf("invalid -diff-to date %q: %w", toDate, err)
+	}
+
+	baseFile, err := nodelistfs.FindByDate(network, from)
+	if err != nil {
+		return err
+	}
+	targetFile, err := nodelistfs.FindByDate(network, to)
+	if err != nil {
+		return err
+	}
+	base, err := parser.ReadNodelistFile(baseFile.Path)
+	if err != nil {
+		return err
+	}
+	target, err := parser.ReadNodelistFile(targetFile.Path)
+	if err != nil {
+		return err
+	}
+
+	diff := parser.GenerateNodediff(base, target)
+	if _, err := diff.Apply(base); err != nil {
+		var crcErr *parser.CRCError
+		if !errors.As(err, &crcErr) {
+			return fmt.Errorf("generated diff does not apply to its base: %w", err)
+		}
+		// The target itself carries a wrong CRC; the diff still reproduces it.
+		fmt.Fprintf(os.Stderr, "Warning: %s: %v\n", targetFile.Path, err)
+	}
+
+	if output == "" {
+		output = fmt.Sprintf("nodediff.%03d", targetFile.DayNumber)
+	}
+	f, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if _, err := diff.WriteTo(f); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	if !quiet {
+		fmt.Printf("Wrote %s: %s -> %s, %d command(s)\n", output, baseFile.Name, targetFile.Name, len(diff.Commands))
+	}
+	return nil
+}
+
+// writeNodelistFile writes raw nodelist lines to path, creating its directory.
+func writeNodelistFile(path string, lines []string) error {
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if err := parser.WriteNodelistLines(f, lines); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nodelistdb/internal/config"
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/notify"
+)
+
+// notifyStorage is what change notifications need from storage.
+type notifyStorage interface {
+	GetAvailableDates(ctx context.Context, domain string) ([]time.Time, error)
+	GetNodelistNodes(ctx context.Context, domain string, date time.Time) ([]database.Node, error)
+	GetNotificationSubscriptions(ctx context.Context) ([]database.NotificationSubscription, error)
+	notify.DeliveryLog
+}
+
+// loadSubscriptions returns the subscriptions file's entries, when there is
+// a file, followed by those registered through the API. A bad file fails the
+// lot, as it always has; a stored subscription that no longer validates, or
+// that reuses an ID from the file, is only left out with a warning.
+func loadSubscriptions(ctx context.Context, st notifyStorage, cfg config.NotificationsConfig, networks []string) ([]notify.Subscription, error) {
+	var subs []notify.Subscription
+	if cfg.SubscriptionsFile != "" {
+		var err error
+		if subs, err = notify.LoadSubscriptions(cfg.SubscriptionsFile, networks); err != nil {
+			return nil, err
+		}
+	}
+
+	rows, err := st.GetNotificationSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := notify.FromStored(rows, networks)
+	if err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	fromFile := make(map[string]bool, len(subs))
+	for _, sub := range subs {
+		fromFile[sub.ID] = true
+	}
+	for _, sub := range stored {
+		if fromFile[sub.ID] {
+			fmt.Printf("Warning: stored subscription %s skipped: the subscriptions file has the same id\n", sub.ID)
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// knownHead returns the network's newest imported nodelist date before this
+// run, or the zero time when nothing is imported yet.
+func knownHead(ctx context.Context, st notifyStorage, domain string) (time.Time, error) {
+	dates, err := st.GetAvailableDates(ctx, domain)
+	if err != nil || len(dates) == 0 {
+		return time.Time{}, err
+	}
+	return dates[0], nil
+}
+
+// runNotifications sends the change events of every nodelist this run added
+// beyond head, each compared with the nodelist before it.
+//
+// Only dates past the old head count. A run that backfills history below it
+// changes nothing anyone is running a mailer against, and a first import into
+// an empty database (zero head) has nothing to compare with - either would
+// mail subscribers about decades-old changes.
+//
+// Failures are reported and never fail the import: the nodes are in, and the
+// delivery log says what did not go out.
+func runNotifications(ctx context.Context, st notifyStorage, cfg config.NotificationsConfig, networks []string, domain string, head time.Time, quiet bool) {
+	if head.IsZero() {
+		if !quiet {
+			fmt.Println("Notifications: first import for this network, nothing to compare with")
+		}
+		return
+	}
+
+	subs, err := loadSubscriptions(ctx, st, cfg, networks)
+	if err != nil {
+		fmt.Printf("Warning: notifications not sent: %v\n", err)
+		return
+	}
+	dispatcher, err := notify.NewDispatcher(cfg, st)
+	if err != nil {
+		fmt.Printf("Warning: notifications not sent: %v\n", err)
+		return
+	}
+
+	dates, err := st.GetAvailableDates(ctx, domain)
+	if err != nil {
+		fmt.Printf("Warning: notifications not sent: %v\n", err)
+		return
+	}
+	// dates is newest first; walk the new ones oldest first, each with the
+	// date after it in the list as its predecessor.
+	for i := len(dates) - 1; i >= 0; i-- {
+		date := dates[i]
+		if !date.After(head) || i+1 >= len(dates) {
+			continue
+		}
+		previous := dates[i+1]
+
+		prevNodes, err := st.GetNodelistNodes(ctx, domain, previous)
+		if err != nil {
+			fmt.Printf("Warning: notifications for %s not sent: %v\n", date.Format("2006-01-02"), err)
+			continue
+		}
+		currNodes, err := st.GetNodelistNodes(ctx, domain, date)
+		if err != nil {
+			fmt.Printf("Warning: notifications for %s not sent: %v\n", date.Format("2006-01-02"), err)
+			continue
+		}
+
+		events := notify.Diff(prevNodes, currNodes)
+		outcomes := dispatcher.Notify(ctx, subs, domain, date, previous, events)
+
+		failed := 0
+		for _, out := range outcomes {
+			if out.Err != nil {
+				failed++
+				fmt.Printf("  Warning: %s notification to %s failed after %d attempt(s): %v\n",
+					out.Channel, out.SubscriptionID, out.Attempts, out.Err)
+			}
+		}
+		if !quiet {
+			fmt.Printf("Notifications for %s: %d change(s), %d batch(es) sent, %d failed\n",
+				date.Format("2006-01-02"), len(events), len(outcomes)-failed, failed)
+		}
+	}
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nodelistdb/internal/storage"
+)
+
+// runResolveSysops re-resolves every node and point sysop in the database
+// into persons and replaces the stored resolution the /sysop pages read.
+func runResolveSysops(storageLayer *storage.Storage, quiet bool) error {
+	start := time.Now()
+	result, err := storageLayer.SysopIdentityOps().ResolveSysopIdentities(context.Background())
+	if err != nil {
+		return fmt.Errorf("sysop identity resolution failed: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Resolved %d tenures into %d sysops (%d with more than one) in %v\n",
+			result.Tenures, result.Identities, result.Linked, time.Since(start).Round(time.Second))
+		fmt.Printf("IDs kept from the previous resolution: %d, retired IDs redirected: %d\n",
+			result.Kept, result.Redirects)
+	}
+	return nil
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nodelistdb/internal/storage"
+)
+
+// runDetectTenancies re-segments every node address's history by occupant
+// and replaces the stored segmentation the node searches join.
+func runDetectTenancies(storageLayer *storage.Storage, quiet bool) error {
+	start := time.Now()
+	result, err := storageLayer.NodeTenancyOps().DetectNodeTenancies(context.Background())
+	if err != nil {
+		return fmt.Errorf("node tenancy detection failed: %w", err)
+	}
+	if !quiet {
+		fmt.Printf("Split %d node addresses into %d tenancies in %v\n",
+			result.Addresses, result.Tenancies, time.Since(start).Round(time.Second))
+		fmt.Printf("Addresses reused by more than one occupant: %d\n", result.Reused)
+	}
+	return nil
+}
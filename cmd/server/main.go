@@ -13,6 +13,7 @@ import (
 	"time"
 
 	"github.com/nodelistdb/internal/api"
+	"github.com/nodelistdb/internal/apiaccess"
 	"github.com/nodelistdb/internal/cache"
 	"github.com/nodelistdb/internal/config"
 	"github.com/nodelistdb/internal/database"
@@ -121,6 +122,19 @@ func run(opts options) error {
 	}
 	apiServer.SetQueryBudgets(api.Budgets{Read: readBudget, Analytics: analyticsBudget})
 	webServer.SetQueryBudgets(web.Budgets{Read: readBudget, Analytics: analyticsBudget})
+
+	guard, stopAccess, err := buildAccessGuard(cfg, deps.store)
+	if err != nil {
+		return err
+	}
+	defer stopAccess()
+	if guard != nil {
+		apiServer.SetAccessGuard(guard, deps.store)
+		if cfg.Notifications.Enabled {
+			apiServer.SetSubscriptionStore(deps.store, cfg.NetworkNames())
+		}
+	}
+
 	if cfg.LinksFile != "" {
 		linksLoader := links.NewLoader(cfg.LinksFile)
 		defer linksLoader.Stop()
@@ -170,7 +184,8 @@ func buildQueryBudgets(cfg *config.Config) (read, analytics querybudget.Budget,
 // serverDeps are the storage objects the rest of the server is built on.
 type serverDeps struct {
 	storage storage.Operations
-	cache   cache.Cache // nil when caching is disabled
+	store   *storage.Storage // uncached, for what is not a cacheable read
+	cache   cache.Cache      // nil when caching is disabled
 }
 
 func openDatabase(cfg *config.Config) (*database.ClickHouseDB, error) {
@@ -201,7 +216,7 @@ func buildStorage(cfg *config.Config, db *database.ClickHouseDB) (serverDeps, fu
 		return serverDeps{}, nil, fmt.Errorf("initializing storage: %w", err)
 	}
 
-	deps := serverDeps{storage: storageLayer}
+	deps := serverDeps{storage: storageLayer, store: storageLayer}
 	closers := []func(){func() { _ = storageLayer.Close() }}
 	closeAll := func() {
 		for i := len(closers) - 1; i >= 0; i-- {
@@ -285,6 +300,60 @@ func buildAPI(cfg *config.Config, db *database.ClickHouseDB, deps serverDeps, ft
 	return apiServer, nil
 }
 
+// buildAccessGuard loads the API keys and starts their reloads and the
+// usage flushes. It returns a nil guard when api_access is off. The returned
+// stop function writes the last usage counters, so it must run before the
+// database is closed.
+func buildAccessGuard(cfg *config.Config, store *storage.Storage) (*apiaccess.Guard, func(), error) {
+	a := cfg.APIAccess
+	if !a.Enabled {
+		return nil, func() {}, nil
+	}
+	reload, flush, err := a.Durations()
+	if err != nil {
+		return nil, nil, err
+	}
+	proxies, err := a.Proxies()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	var source apiaccess.KeySource = store
+	if a.KeysSource == "file" {
+		source = apiaccess.FileKeys(a.KeysFile)
+	}
+	keys := apiaccess.NewKeyring(source, apiaccess.Limit{PerMinute: a.DefaultKey.RequestsPerMinute, Burst: a.DefaultKey.Burst})
+	// A server that cannot read its keys at start-up refuses to start rather
+	// than running with none and answering every keyed client 401.
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	err = keys.Reload(ctx)
+	cancel()
+	if err != nil {
+		return nil, nil, err
+	}
+	keys.Start(reload)
+
+	usage := apiaccess.NewRecorder(store)
+	usage.Start(flush)
+
+	logging.Info("API access control enabled",
+		slog.String("keys_source", a.KeysSource),
+		slog.Int("keys", keys.Len()),
+		slog.Int("anonymous_per_minute", a.Anonymous.RequestsPerMinute),
+		slog.Bool("require_key", a.RequireKey),
+		slog.Int("trusted_proxies", len(proxies)))
+
+	guard := apiaccess.NewGuard(apiaccess.Config{
+		Anonymous:      apiaccess.Limit{PerMinute: a.Anonymous.RequestsPerMinute, Burst: a.Anonymous.Burst},
+		RequireKey:     a.RequireKey,
+		TrustedProxies: proxies,
+	}, keys, usage)
+	return guard, func() {
+		keys.Stop()
+		usage.Stop()
+	}, nil
+}
+
 func buildHTTPServer(opts options, apiServer *api.Server, webServer *web.Server, longest querybudget.Budget) *http.Server {
 	// The API is a Chi router mounted under /api/; the web pages are on a
 	// plain ServeMux. One logging middleware wraps both.
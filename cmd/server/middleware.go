@@ -39,14 +39,14 @@ func loggingMiddleware(next http.Handler) http.Handler {
 
 // clientIP returns the address to log for this request.
 //
-// This is the server's single answer to "who is calling", and it is used for
-// log lines only. Every header it reads is client-supplied and trivially
-// forged; nothing here may be used for authorization or rate limiting without
-// first pinning which proxy is trusted. chi's middleware.RealIP used to run on
-// the API router as well, giving the two halves of the server different
-// answers, and it rewrote r.RemoteAddr in place so handlers could not tell the
-// forged value from the real one. It is gone; this reads headers and leaves
-// the request alone.
+// This is the server's answer to "who is calling" for log lines only. Every
+// header it reads is client-supplied and trivially forged; rate limiting uses
+// apiaccess.ClientIP instead, which believes X-Forwarded-For only from the
+// proxies listed in api_access.trusted_proxies. chi's middleware.RealIP used
+// to run on the API router as well, giving the two halves of the server
+// different answers, and it rewrote r.RemoteAddr in place so handlers could
+// not tell the forged value from the real one. It is gone; this reads headers
+// and leaves the request alone.
 func clientIP(r *http.Request) string {
 	// X-Real-IP is set by many reverse proxies, including Caddy by default.
 	if ip := r.Header.Get("X-Real-IP"); ip != "" {
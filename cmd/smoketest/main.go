@@ -81,12 +81,22 @@ func main() {
 	check("SearchNodesBySysop all", err)
 	_, err = s.SearchNodesBySysop(ctx, "Dmitry", 5, "fidonet")
 	check("SearchNodesBySysop fidonet", err)
+	_, err = s.SearchNodesBySysopFuzzy(ctx, "Dmitrii", 5, "")
+	check("SearchNodesBySysopFuzzy", err)
+	_, err = s.FuzzySearchSysops(ctx, "Дмитрий", 5, 0)
+	check("FuzzySearchSysops", err)
+	_, err = s.GetSysopIdentity(ctx, "000000000000")
+	check("GetSysopIdentity", err)
+	_, err = s.GetNodeSysopIdentities(ctx, 2, 5001, 100, "fidonet")
+	check("GetNodeSysopIdentities", err)
 	_, err = s.SearchNodesWithLifetime(ctx, database.NodeFilter{Zone: &z21, Domain: &fsx, Limit: 3})
 	check("SearchNodesWithLifetime fsxnet", err)
 	_, err = s.GetUniqueSysops(ctx, "", 5, 0)
 	check("GetUniqueSysops", err)
 	_, err = s.GetNodeChanges(ctx, 21, 1, 100, "fsxnet")
 	check("GetNodeChanges fsxnet", err)
+	_, err = s.GetNodeTenancies(ctx, 2, 5001, 100, "fidonet")
+	check("GetNodeTenancies", err)
 	_, err = s.GetPioneersByRegion(ctx, 2, 50, 3, "")
 	check("GetPioneersByRegion", err)
 
@@ -105,6 +115,8 @@ func main() {
 	check("GetNearestAvailableDate fsxnet", err)
 	_, err = s.GetNodeCountHistory(ctx, "fsxnet")
 	check("GetNodeCountHistory fsxnet", err)
+	_, err = s.GetNodeMap(ctx, fsxLatest, "fsxnet")
+	check("GetNodeMap fsxnet", err)
 	_, err = s.GetBrowseZones(ctx, fsxLatest, "fsxnet")
 	check("GetBrowseZones fsxnet", err)
 	_, err = s.GetBrowseRegions(ctx, fsxLatest, 21, "fsxnet")
@@ -113,6 +125,8 @@ func main() {
 	check("GetBrowseNets fsxnet", err)
 	_, err = s.GetBrowseNodes(ctx, fsxLatest, 21, 1, "fsxnet")
 	check("GetBrowseNodes fsxnet", err)
+	_, err = s.GetBrowseDiff(ctx, fsxLatest, fsxLatest, storage.BrowseDiffScope{Level: storage.BrowseLevelNodes, Zone: 21, Net: 1}, "fsxnet")
+	check("GetBrowseDiff fsxnet", err)
 
 	// --- Analytics (nodes table)
 	_, err = s.GetFlagFirstAppearance(ctx, "CM", "fidonet")
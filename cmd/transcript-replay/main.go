@@ -0,0 +1,233 @@
+// Command transcript-replay plays a recorded BinkP or IFCICO session back to
+// the protocol tester, so a test that failed against a live node can be
+// rerun, stepped through with -debug and fixed without that node.
+//
+// The transcript comes from a file (-file, as written by -save) or from
+// node_test_transcripts, picked by the node and the test time shown on the
+// test detail page. The testdaemon records transcripts only when a
+// protocol's "transcripts" setting asks for them.
+//
+// What is replayed is the remote's half of the session; the tester runs as it
+// is built now, against a loopback server. Passwords we sent are not stored,
+// so a password session is replayed with -password set to anything: the
+// remote's answers are the recorded ones either way.
+//
+// Usage:
+//
+//	transcript-replay -file session.json.gz -our-address 2:5001/5001 [-address 2:5020/1]
+//	transcript-replay -config config.yaml -zone 2 -net 5020 -node 1 \
+//	                  -time "2026-10-01 12:00:00" [-domain fidonet] [-n 1] \
+//	                  [-save session.json.gz | -dump | -our-address ...]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/config"
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/storage"
+	"github.com/nodelistdb/internal/testing/protocols"
+	"github.com/nodelistdb/internal/testing/transcript"
+)
+
+func main() {
+	var (
+		file       = flag.String("file", "", "Transcript file to replay")
+		configPath = flag.String("config", "config.yaml", "Path to configuration file (when reading from ClickHouse)")
+		domain     = flag.String("domain", "", "FTN network of the node (default any)")
+		zone       = flag.Int("zone", -1, "Zone")
+		net        = flag.Int("net", -1, "Net")
+		node       = flag.Int("node", -1, "Node")
+		testTime   = flag.String("time", "", "Test time as on the test detail page, YYYY-MM-DD HH:MM:SS")
+		index      = flag.Int("n", 1, "Which transcript of the test cycle, in the order the detail page lists them")
+		save       = flag.String("save", "", "Write the transcript to this file instead of replaying it")
+		dump       = flag.Bool("dump", false, "Print the decoded session and a hex dump instead of replaying it")
+		ourAddress = flag.String("our-address", "", "FTN address the tester presents (required to replay)")
+		address    = flag.String("address", "", "Address the tester expects the node to present")
+		password   = flag.String("password", "", "Session password to use when the recorded session had one")
+		idle       = flag.Duration("idle", transcript.DefaultIdle, "How long to wait for the tester before sending the next recorded chunk anyway")
+		timeout    = flag.Duration("timeout", 30*time.Second, "Tester timeout")
+		debug      = flag.Bool("debug", false, "Verbose protocol logging")
+	)
+	flag.Parse()
+
+	var (
+		t   *transcript.Transcript
+		raw []byte
+		err error
+	)
+	switch {
+	case *file != "":
+		raw, err = os.ReadFile(*file)
+		if err == nil {
+			t, err = transcript.Decode(raw)
+		}
+	case *zone >= 0 && *net >= 0 && *node >= 0 && *testTime != "":
+		raw, err = fetch(*configPath, *zone, *net, *node, *testTime, *domain, *index)
+		if err == nil {
+			t, err = transcript.Decode(raw)
+		}
+	default:
+		fmt.Fprintln(os.Stderr, "Error: give -file, or -zone, -net, -node and -time")
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	switch {
+	case *save != "":
+		if err := os.WriteFile(*save, raw, 0o644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Saved %s transcript of %s (%d bytes on the wire) to %s\n", t.Protocol, t.Remote, t.Bytes(), *save)
+		return
+	case *dump:
+		printTranscript(t)
+		return
+	}
+
+	// No default, as in the testdaemon: the address is announced to the
+	// tester under test, and a result against the wrong one is misleading.
+	if strings.TrimSpace(*ourAddress) == "" {
+		fmt.Fprintln(os.Stderr, "-our-address is required to replay: the tester announces it in the handshake")
+		os.Exit(2)
+	}
+
+	var tester protocols.Tester
+	switch t.Protocol {
+	case "binkp", "binkps":
+		// binkps is recorded above TLS, so the replay is plain BinkP.
+		bt := protocols.NewBinkPTester(*timeout, *ourAddress)
+		bt.SetDebug(*debug)
+		if *password != "" && *address != "" {
+			bt.SetSessionPasswords(map[string]string{*address: *password})
+			// A binkps session may have sent its password in plain text
+			// inside TLS; the replay has to be allowed to do the same.
+			bt.SetRequireCRAM(t.Protocol == "binkp")
+		}
+		tester = bt
+	case "ifcico":
+		it := protocols.NewIfcicoTester(*timeout, *ourAddress)
+		it.SetDebug(*debug)
+		tester = it
+	default:
+		fmt.Fprintf(os.Stderr, "Error: cannot replay a %q transcript\n", t.Protocol)
+		os.Exit(1)
+	}
+
+	srv, err := transcript.Serve(t, *idle)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Replaying %s session with %s, recorded %s\n", t.Protocol, t.Remote, t.Started.UTC().Format(time.RFC3339))
+	result := tester.Test(context.Background(), srv.Host(), srv.Port(), *address)
+	srv.Close()
+	if err := srv.Wait(); err != nil {
+		fmt.Printf("Replay: %v\n", err)
+	}
+	printResult(result)
+}
+
+// fetch reads the index'th transcript of a test cycle from ClickHouse.
+func fetch(configPath string, zone, net, node int, testTime, domain string, index int) ([]byte, error) {
+	cfg, err := config.LoadConfig(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load configuration: %w", err)
+	}
+	chConfig, err := cfg.ClickHouse.ToClickHouseDatabaseConfig()
+	if err != nil {
+		return nil, fmt.Errorf("invalid ClickHouse configuration: %w", err)
+	}
+	db, err := database.NewClickHouse(chConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to ClickHouse: %w", err)
+	}
+	defer db.Close()
+	store, err := storage.New(db)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	defer store.Close()
+
+	transcripts, err := store.GetTestTranscripts(context.Background(), zone, net, node, testTime, domain)
+	if err != nil {
+		return nil, err
+	}
+	if len(transcripts) == 0 {
+		return nil, fmt.Errorf("no transcripts recorded for %d:%d/%d at %s", zone, net, node, testTime)
+	}
+	if index < 1 || index > len(transcripts) {
+		return nil, fmt.Errorf("-n %d: the test cycle has %d transcripts", index, len(transcripts))
+	}
+	st := transcripts[index-1]
+	fmt.Fprintf(os.Stderr, "Transcript %d of %d: %s %s via %s, %s\n", index, len(transcripts),
+		st.Protocol, st.Hostname, st.RemoteIP, outcome(st.Success, st.Error))
+	return st.Data, nil
+}
+
+func outcome(success bool, errText string) string {
+	if success {
+		return "succeeded"
+	}
+	return "failed: " + errText
+}
+
+func printTranscript(t *transcript.Transcript) {
+	fmt.Printf("%s session with %s, recorded %s, %d bytes", t.Protocol, t.Remote, t.Started.UTC().Format(time.RFC3339), t.Bytes())
+	if t.Truncated {
+		fmt.Print(" (truncated)")
+	}
+	if t.RemoteClosed {
+		fmt.Print(", remote hung up")
+	}
+	fmt.Println()
+
+	var events []transcript.Event
+	switch t.Protocol {
+	case "binkp", "binkps":
+		events = transcript.BinkPFrames(t)
+	case "ifcico":
+		events = transcript.EMSIPackets(t)
+	}
+	for _, e := range events {
+		arrow := "->"
+		if e.Dir == transcript.RX {
+			arrow = "<-"
+		}
+		mark := " "
+		if e.Bad {
+			mark = "!"
+		}
+		fmt.Printf("%8dms %s %s %-10s %s\n", e.At.Milliseconds(), mark, arrow, e.Name, e.Detail)
+	}
+
+	for _, c := range t.Chunks {
+		fmt.Printf("\n%s at %dms, %d bytes\n", c.Dir, c.At.Milliseconds(), len(c.Data))
+		fmt.Print(transcript.Hexdump(c.Data))
+	}
+}
+
+func printResult(result protocols.TestResult) {
+	fmt.Printf("Result: %s (%dms)\n", outcome(result.IsSuccess(), result.GetError()), result.GetResponseTime())
+	switch r := result.(type) {
+	case *protocols.BinkPTestResult:
+		fmt.Printf("  system:    %s\n  sysop:     %s\n  location:  %s\n  mailer:    %s\n", r.SystemName, r.Sysop, r.Location, r.Version)
+		fmt.Printf("  addresses: %v (expected present: %v)\n", r.Addresses, r.AddressValid)
+		if r.MailExchange != "" {
+			fmt.Printf("  mail:      %s %s\n", r.MailExchange, r.MailDetail)
+		}
+	case *protocols.IfcicoTestResult:
+		fmt.Printf("  system:    %s\n  mailer:    %s\n  response:  %s\n", r.SystemName, r.MailerInfo, r.ResponseType)
+		fmt.Printf("  addresses: %v (expected present: %v)\n", r.Addresses, r.AddressValid)
+	}
+}
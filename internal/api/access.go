@@ -0,0 +1,214 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/go-chi/chi/v5/middleware"
+
+	"github.com/nodelistdb/internal/apiaccess"
+	"github.com/nodelistdb/internal/database"
+)
+
+// API keys, rate limits and usage accounting (api_access in config.yaml).
+// The middleware here is HTTP glue; who a caller is, how many requests it
+// has left and what gets counted are internal/apiaccess's business.
+
+// callerContextKey carries the apiaccess.Caller of a request.
+const callerContextKey contextKey = "api_caller"
+
+// unroutedPattern is the usage route of a request that matched no route.
+const unroutedPattern = "(unrouted)"
+
+// defaultUsageDays is the /api/usage window when date_from is omitted.
+const defaultUsageDays = 7
+
+// UsageReader is what GET /api/usage reads; *storage.Storage provides it.
+type UsageReader interface {
+	GetAPIUsage(ctx context.Context, filter database.APIUsageFilter) ([]database.APIUsageSummary, error)
+}
+
+// SetAccessGuard switches on API keys and rate limits, and registers
+// /api/usage over usage. It must be called before SetupRouter.
+func (s *Server) SetAccessGuard(guard *apiaccess.Guard, usage UsageReader) {
+	s.access = guard
+	s.usage = usage
+}
+
+// accessExempt are the paths the guard leaves alone: monitors poll health,
+// and the modem API authenticates its own callers with their own Bearer
+// tokens, which the guard would reject as unknown API keys.
+func accessExempt(path string) bool {
+	return path == "/api/health" || strings.HasPrefix(path, "/api/modem/")
+}
+
+// accessMiddleware identifies the caller, applies its rate limit and counts
+// the request once it has been answered. routes resolves the route pattern
+// of requests refused before routing.
+func (s *Server) accessMiddleware(routes chi.Routes) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if accessExempt(r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			start := time.Now()
+
+			key := extractBearerToken(r)
+			if key == "" {
+				key = strings.TrimSpace(r.Header.Get("X-API-Key"))
+			}
+			caller, err := s.access.Identify(r, key)
+			if err != nil {
+				WriteJSONError(w, err.Error(), http.StatusUnauthorized)
+				s.access.Record(caller, r.Method, matchPattern(routes, r), http.StatusUnauthorized, 0, time.Since(start))
+				return
+			}
+
+			decision, limit := s.access.Allow(caller)
+			h := w.Header()
+			h.Set("X-RateLimit-Limit", strconv.Itoa(limit.PerMinute))
+			h.Set("X-RateLimit-Remaining", strconv.Itoa(decision.Remaining))
+			if !decision.Allowed {
+				retry := int(math.Ceil(decision.RetryAfter.Seconds()))
+				if retry < 1 {
+					retry = 1
+				}
+				h.Set("Retry-After", strconv.Itoa(retry))
+				WriteJSONError(w, fmt.Sprintf("rate limit of %d requests per minute exceeded; retry in %ds", limit.PerMinute, retry), http.StatusTooManyRequests)
+				s.access.Record(caller, r.Method, matchPattern(routes, r), http.StatusTooManyRequests, 0, time.Since(start))
+				return
+			}
+
+			ww := middleware.NewWrapResponseWriter(w, r.ProtoMajor)
+			ctx := context.WithValue(r.Context(), callerContextKey, caller)
+			// Counted even when the handler panics (exports abort that way), or
+			// an aborted download would not show up at all.
+			defer func() {
+				status := ww.Status()
+				p := recover()
+				switch {
+				case status != 0:
+				case p != nil:
+					status = http.StatusInternalServerError
+				default:
+					status = http.StatusOK
+				}
+				s.access.Record(caller, r.Method, routePattern(r), status, int64(ww.BytesWritten()), time.Since(start))
+				if p != nil {
+					panic(p)
+				}
+			}()
+			next.ServeHTTP(ww, r.WithContext(ctx))
+		})
+	}
+}
+
+// routePattern is the pattern of the route chi dispatched r to.
+func routePattern(r *http.Request) string {
+	if rctx := chi.RouteContext(r.Context()); rctx != nil {
+		if p := rctx.RoutePattern(); p != "" {
+			return p
+		}
+	}
+	return unroutedPattern
+}
+
+// matchPattern is the pattern of the route r would be dispatched to.
+func matchPattern(routes chi.Routes, r *http.Request) string {
+	rctx := chi.NewRouteContext()
+	if routes.Match(rctx, r.Method, r.URL.Path) {
+		if p := rctx.RoutePattern(); p != "" {
+			return p
+		}
+	}
+	return unroutedPattern
+}
+
+// callerFromContext returns the caller the access middleware identified.
+func callerFromContext(ctx context.Context) (apiaccess.Caller, bool) {
+	c, ok := ctx.Value(callerContextKey).(apiaccess.Caller)
+	return c, ok
+}
+
+// UsageHandler reports API traffic per caller and route, busiest first.
+// Admin keys only. The last minute or so is not in it yet: counters reach
+// the database on the next flush.
+// GET /api/usage?date_from=2026-07-01&date_to=2026-07-07&key_id=research-lab
+func (s *Server) UsageHandler(w http.ResponseWriter, r *http.Request) {
+	caller, _ := callerFromContext(r.Context())
+	if caller.Key == nil {
+		WriteJSONError(w, "an admin API key is required", http.StatusUnauthorized)
+		return
+	}
+	if !caller.Key.Admin {
+		WriteJSONError(w, "API key "+caller.Key.ID+" is not an admin key", http.StatusForbidden)
+		return
+	}
+
+	filter, err := parseUsageFilter(r.URL.Query(), time.Now().UTC())
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	usage, err := s.usage.GetAPIUsage(r.Context(), filter)
+	if err != nil {
+		writeStorageErrorf(w, "Failed to get API usage", err)
+		return
+	}
+	if usage == nil {
+		usage = []database.APIUsageSummary{}
+	}
+
+	WriteJSONSuccess(w, map[string]interface{}{
+		"usage":     usage,
+		"count":     len(usage),
+		"date_from": filter.From.Format(time.DateOnly),
+		"date_to":   filter.To.AddDate(0, 0, -1).Format(time.DateOnly),
+		"limit":     filter.Limit,
+	})
+}
+
+// parseUsageFilter reads the /api/usage window and key. date_to is
+// inclusive; key_id present but empty selects anonymous traffic.
+func parseUsageFilter(query url.Values, now time.Time) (database.APIUsageFilter, error) {
+	var filter database.APIUsageFilter
+	to, hasTo, err := parseDateParam(query, "date_to")
+	if err != nil {
+		return filter, err
+	}
+	if !hasTo {
+		to = now.Truncate(24 * time.Hour)
+	}
+	from, hasFrom, err := parseDateParam(query, "date_from")
+	if err != nil {
+		return filter, err
+	}
+	if !hasFrom {
+		from = to.AddDate(0, 0, -defaultUsageDays+1)
+	}
+	if from.After(to) {
+		return filter, &ParamError{Field: "date_from", Value: from.Format(time.DateOnly), Message: "date_from cannot be after date_to"}
+	}
+	if to.Sub(from) > maxAnalyticsDays*24*time.Hour {
+		return filter, &ParamError{Field: "date_from", Value: from.Format(time.DateOnly), Message: fmt.Sprintf("the window cannot exceed %d days", maxAnalyticsDays)}
+	}
+	filter.From, filter.To = from, to.AddDate(0, 0, 1)
+
+	if keys, ok := query["key_id"]; ok {
+		id := ""
+		if len(keys) > 0 {
+			id = strings.TrimSpace(keys[0])
+		}
+		filter.KeyID = &id
+	}
+	filter.Limit, _ = parsePaginationParams(query, 100, 1000)
+	return filter, nil
+}
@@ -0,0 +1,158 @@
+package api
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/apiaccess"
+	"github.com/nodelistdb/internal/database"
+)
+
+type fakeKeys []database.APIKey
+
+func (f fakeKeys) GetAPIKeys(context.Context) ([]database.APIKey, error) { return f, nil }
+
+type fakeUsage struct {
+	rows   []database.APIUsage
+	filter database.APIUsageFilter
+}
+
+func (f *fakeUsage) InsertAPIUsage(_ context.Context, usage []database.APIUsage) error {
+	f.rows = append(f.rows, usage...)
+	return nil
+}
+
+func (f *fakeUsage) GetAPIUsage(_ context.Context, filter database.APIUsageFilter) ([]database.APIUsageSummary, error) {
+	f.filter = filter
+	return []database.APIUsageSummary{{KeyID: "lab", Route: "/api/nodes/", Requests: 3}}, nil
+}
+
+// guardedRouter is the API with access control: key "lab-secret" (burst 2),
+// admin key "ops-secret", and one anonymous request per address at a time.
+func guardedRouter(t *testing.T, usage *fakeUsage) (http.Handler, *apiaccess.Recorder) {
+	t.Helper()
+	keys := apiaccess.NewKeyring(fakeKeys{
+		{ID: "lab", KeyHash: apiaccess.HashKey("lab-secret"), RequestsPerMinute: 60, Burst: 2},
+		{ID: "ops", KeyHash: apiaccess.HashKey("ops-secret"), Admin: true},
+	}, apiaccess.Limit{PerMinute: 600, Burst: 100})
+	if err := keys.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	recorder := apiaccess.NewRecorder(usage)
+	s := New(&fakeOps{nodes: []database.Node{sampleNode()}})
+	s.SetAccessGuard(apiaccess.NewGuard(apiaccess.Config{Anonymous: apiaccess.Limit{PerMinute: 1, Burst: 1}}, keys, recorder), usage)
+	return s.SetupRouter(), recorder
+}
+
+func serve(h http.Handler, target string, header ...string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("GET", target, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestAccessLimitsAndCounts(t *testing.T) {
+	usage := &fakeUsage{}
+	h, recorder := guardedRouter(t, usage)
+
+	if rec := serve(h, "/api/nodes/2/5001/100", "Authorization", "Bearer nope"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("unknown key: status %d, want 401", rec.Code)
+	}
+
+	for i, want := range []int{http.StatusOK, http.StatusOK, http.StatusTooManyRequests} {
+		rec := serve(h, "/api/nodes/2/5001/100", "X-API-Key", "lab-secret")
+		if rec.Code != want {
+			t.Fatalf("keyed request %d: status %d, want %d", i+1, rec.Code, want)
+		}
+		if rec.Header().Get("X-RateLimit-Limit") != "60" {
+			t.Errorf("X-RateLimit-Limit = %q", rec.Header().Get("X-RateLimit-Limit"))
+		}
+		if want == http.StatusTooManyRequests && rec.Header().Get("Retry-After") != "1" {
+			t.Errorf("Retry-After = %q, want 1", rec.Header().Get("Retry-After"))
+		}
+	}
+
+	// Anonymous callers are limited on their own, and health is not limited.
+	if rec := serve(h, "/api/stats/dates"); rec.Code == http.StatusTooManyRequests {
+		t.Error("first anonymous request refused")
+	}
+	if rec := serve(h, "/api/stats/dates"); rec.Code != http.StatusTooManyRequests {
+		t.Errorf("second anonymous request: status %d, want 429", rec.Code)
+	}
+	for i := 0; i < 3; i++ {
+		if rec := serve(h, "/api/health"); rec.Code != http.StatusOK {
+			t.Errorf("health: status %d", rec.Code)
+		}
+	}
+
+	if err := recorder.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]int64{}
+	for _, row := range usage.rows {
+		got[row.KeyID+" "+row.Route+" "+http.StatusText(row.Status)] += row.Requests
+	}
+	for k, want := range map[string]int64{
+		" /api/nodes/{zone}/{net}/{node} Unauthorized":         1,
+		"lab /api/nodes/{zone}/{net}/{node} OK":                2,
+		"lab /api/nodes/{zone}/{net}/{node} Too Many Requests": 1,
+		" /api/stats/dates Too Many Requests":                  1,
+	} {
+		if got[k] != want {
+			t.Errorf("usage[%q] = %d, want %d (all: %v)", k, got[k], want, got)
+		}
+	}
+	for k := range got {
+		if strings.Contains(k, "/api/health") {
+			t.Errorf("health counted: %q", k)
+		}
+	}
+}
+
+func TestUsageEndpoint(t *testing.T) {
+	usage := &fakeUsage{}
+	h, _ := guardedRouter(t, usage)
+
+	if rec := serve(h, "/api/usage"); rec.Code != http.StatusUnauthorized {
+		t.Errorf("anonymous: status %d, want 401", rec.Code)
+	}
+	if rec := serve(h, "/api/usage", "X-API-Key", "lab-secret"); rec.Code != http.StatusForbidden {
+		t.Errorf("non-admin key: status %d, want 403", rec.Code)
+	}
+	rec := serve(h, "/api/usage?date_from=2026-07-01&date_to=2026-07-07&key_id=", "X-API-Key", "ops-secret")
+	if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"key_id":"lab"`) {
+		t.Fatalf("admin key: status %d: %s", rec.Code, rec.Body.String())
+	}
+	f := usage.filter
+	if !f.From.Equal(time.Date(2026, 7, 1, 0, 0, 0, 0, time.UTC)) || !f.To.Equal(time.Date(2026, 7, 8, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("window = %v..%v, want date_to inclusive", f.From, f.To)
+	}
+	if f.KeyID == nil || *f.KeyID != "" {
+		t.Errorf("key_id= should select anonymous traffic: %+v", f.KeyID)
+	}
+}
+
+func TestParseUsageFilterDefaults(t *testing.T) {
+	now := time.Date(2026, 7, 20, 15, 4, 5, 0, time.UTC)
+	f, err := parseUsageFilter(url.Values{}, now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.From.Equal(time.Date(2026, 7, 14, 0, 0, 0, 0, time.UTC)) || !f.To.Equal(time.Date(2026, 7, 21, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("default window = %v..%v, want the last seven days including today", f.From, f.To)
+	}
+	if f.KeyID != nil || f.Limit != 100 {
+		t.Errorf("filter = %+v", f)
+	}
+	if _, err := parseUsageFilter(url.Values{"date_from": {"2026-07-21"}, "date_to": {"2026-07-20"}}, now); err == nil {
+		t.Error("reversed window accepted")
+	}
+}
@@ -44,13 +44,17 @@ func writeStorageErrorf(w http.ResponseWriter, op string, err error) {
 	writeStorageMessage(w, op, fmt.Sprintf("%s: %v", op, err), err)
 }
 
+// budgetExceededMessage is what a client is told when its query outran its
+// budget.
+const budgetExceededMessage = "Query exceeded its time budget, please narrow it or retry"
+
 func writeStorageMessage(w http.ResponseWriter, op, msg string, err error) {
 	switch {
 	case errors.Is(err, context.Canceled):
 		logging.Debug("Request cancelled by client", slog.String("op", op))
 	case errors.Is(err, context.DeadlineExceeded):
 		logging.Warn("Query exceeded its time budget", slog.String("op", op))
-		WriteJSONError(w, "Query exceeded its time budget, please narrow it or retry", http.StatusServiceUnavailable)
+		WriteJSONError(w, budgetExceededMessage, http.StatusServiceUnavailable)
 	default:
 		logging.Error(op, slog.Any("error", err))
 		WriteJSONError(w, msg, http.StatusInternalServerError)
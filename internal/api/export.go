@@ -0,0 +1,182 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/export"
+	"github.com/nodelistdb/internal/logging"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// Bulk export: the search endpoints page through at most 500 rows at a time,
+// which is the wrong tool for "every row of 2:50 since 2015". These stream the
+// whole result straight from ClickHouse into the response as CSV, JSON Lines
+// or Parquet, a row (or a Parquet row group) at a time.
+//
+// The routes carry no query budget. An export is as long as the data it
+// covers, and the client going away cancels the query the same as anywhere
+// else. The server's WriteTimeout is pushed forward on every write instead,
+// so it cuts off a stalled reader, not a long download.
+
+// exportWriteTimeout is how long one write to the client may take.
+const exportWriteTimeout = 60 * time.Second
+
+// defaultTestResultWindow is the export window when date_from is omitted.
+const defaultTestResultWindow = 30
+
+// ExportNodesHandler streams nodelist rows.
+// GET /api/export/nodes
+func (s *Server) ExportNodesHandler(w http.ResponseWriter, r *http.Request) {
+	filter, hasConstraint, err := parseNodeFilter(r)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !hasConstraint {
+		WriteJSONError(w, "Export requires at least one specific constraint (zone, net, node, system_name, location, sysop_name, node_type, a flag, or date range)", http.StatusBadRequest)
+		return
+	}
+	filter.Limit, filter.Offset = 0, 0
+
+	streamExport(w, r, "nodes", export.NodeColumns, func(ctx context.Context, fn func(database.Node) error) error {
+		return s.storage.StreamNodes(ctx, filter, fn)
+	})
+}
+
+// ExportPointsHandler streams pointlist rows.
+// GET /api/export/points
+func (s *Server) ExportPointsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, hasConstraint, err := parsePointFilter(r)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if !hasConstraint {
+		WriteJSONError(w, "Export requires at least one specific constraint (zone, net, node, point, list_source, system_name, location, sysop_name, or date range)", http.StatusBadRequest)
+		return
+	}
+	filter.Limit, filter.Offset = 0, 0
+
+	streamExport(w, r, "points", export.PointColumns, func(ctx context.Context, fn func(database.Point) error) error {
+		return s.storage.StreamPoints(ctx, filter, fn)
+	})
+}
+
+// ExportTestResultsHandler streams node_test_results rows for a window of
+// test dates, the last 30 days unless date_from says otherwise.
+// GET /api/export/test-results
+func (s *Server) ExportTestResultsHandler(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseTestResultExportFilter(r, time.Now().UTC())
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	streamExport(w, r, "test-results", export.TestResultColumns, func(ctx context.Context, fn func(storage.NodeTestResult) error) error {
+		return s.storage.StreamTestResults(ctx, filter, fn)
+	})
+}
+
+// parseTestResultExportFilter reads the node filter's identity and date
+// parameters and defaults the window. The nodelist attributes are refused
+// rather than ignored: a caller asking for sysop_name=... would otherwise get
+// every node's results and not notice.
+func parseTestResultExportFilter(r *http.Request, now time.Time) (database.NodeFilter, error) {
+	filter, _, err := parseNodeFilter(r)
+	if err != nil {
+		return filter, err
+	}
+	query := r.URL.Query()
+	for _, key := range []string{"system_name", "location", "sysop_name", "node_type", "is_cm", "is_mo", "has_inet", "has_binkp", "latest_only"} {
+		if query.Has(key) {
+			return filter, &ParamError{
+				Field:   key,
+				Value:   query.Get(key),
+				Message: "test result exports filter by domain, zone, net, node, date_from and date_to only",
+			}
+		}
+	}
+
+	if filter.DateTo == nil {
+		today := now.Truncate(24 * time.Hour)
+		filter.DateTo = &today
+	}
+	if filter.DateFrom == nil {
+		from := filter.DateTo.AddDate(0, 0, -defaultTestResultWindow)
+		filter.DateFrom = &from
+	}
+	if filter.DateFrom.After(*filter.DateTo) {
+		return filter, &ParamError{Field: "date_from", Value: query.Get("date_from"), Message: "date_from cannot be after date_to"}
+	}
+	if filter.DateTo.Sub(*filter.DateFrom) > maxAnalyticsDays*24*time.Hour {
+		return filter, &ParamError{Field: "date_from", Value: query.Get("date_from"), Message: fmt.Sprintf("the window cannot exceed %d days", maxAnalyticsDays)}
+	}
+	filter.Limit, filter.Offset = 0, 0
+	return filter, nil
+}
+
+// streamExport runs stream into a writer of the requested format.
+//
+// A failure before the first byte is on the wire is answered like any other
+// storage error. After that the status line is gone, and finishing the
+// response normally would hand the client a truncated file that looks
+// complete; the handler aborts the connection instead, which the client sees
+// as a failed download.
+func streamExport[T any](w http.ResponseWriter, r *http.Request, name string, columns []export.Column[T], stream func(context.Context, func(T) error) error) {
+	format, err := export.ParseFormat(r.URL.Query().Get("format"))
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	out := &exportResponse{w: w, rc: http.NewResponseController(w), format: format, name: name}
+	ew, err := export.NewWriter(format, out, columns)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	err = stream(r.Context(), func(row T) error { return ew.Write(&row) })
+	if err == nil {
+		err = ew.Close()
+	}
+	if err == nil {
+		return
+	}
+	if !out.started {
+		writeStorageErrorf(w, "Export failed", err)
+		return
+	}
+	if !errors.Is(err, context.Canceled) {
+		logging.Error("Export aborted mid-stream", slog.String("export", name), slog.Any("error", err))
+	}
+	panic(http.ErrAbortHandler)
+}
+
+// exportResponse sends the download headers with the first write and keeps
+// the connection's write deadline moving while data flows.
+type exportResponse struct {
+	w       http.ResponseWriter
+	rc      *http.ResponseController
+	format  export.Format
+	name    string
+	started bool
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	if !e.started {
+		e.started = true
+		h := e.w.Header()
+		h.Set("Content-Type", e.format.ContentType())
+		h.Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, e.name, e.format.Extension()))
+	}
+	// Best effort: a writer that cannot move its deadline keeps the server's.
+	_ = e.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout))
+	return e.w.Write(p)
+}
@@ -0,0 +1,155 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// exportOps streams fakeOps' nodes and test results, optionally failing after
+// a number of rows.
+type exportOps struct {
+	*fakeOps
+
+	tests     []storage.NodeTestResult
+	failAfter int // rows sent before err; -1 never fails
+	err       error
+
+	nodeFilter database.NodeFilter
+	testFilter database.NodeFilter
+}
+
+func (e *exportOps) StreamNodes(ctx context.Context, filter database.NodeFilter, fn func(database.Node) error) error {
+	e.nodeFilter = filter
+	for i, n := range e.nodes {
+		if i == e.failAfter {
+			return e.err
+		}
+		if err := fn(n); err != nil {
+			return err
+		}
+	}
+	if e.failAfter >= len(e.nodes) {
+		return e.err
+	}
+	return nil
+}
+
+func (e *exportOps) StreamTestResults(ctx context.Context, filter database.NodeFilter, fn func(storage.NodeTestResult) error) error {
+	e.testFilter = filter
+	for _, r := range e.tests {
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestExportNodesStreamsCSV(t *testing.T) {
+	ops := &exportOps{fakeOps: &fakeOps{nodes: []database.Node{sampleNode(), sampleNode()}}, failAfter: -1}
+	rec, _ := call(t, ops, "GET", "/api/export/nodes?net=5001&date_from=2026-01-01&limit=5")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/csv; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if cd := rec.Header().Get("Content-Disposition"); cd != `attachment; filename="nodes.csv"` {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	lines := strings.Split(strings.TrimSpace(rec.Body.String()), "\n")
+	if len(lines) != 3 || !strings.HasPrefix(lines[0], "domain,zone,net,node,nodelist_date,") {
+		t.Fatalf("body:\n%s", rec.Body.String())
+	}
+	if !strings.HasPrefix(lines[1], "fidonet,2,5001,100,2026-07-20,") {
+		t.Errorf("row = %s", lines[1])
+	}
+	if f := ops.nodeFilter; f.Limit != 0 || f.Offset != 0 || f.Net == nil || *f.Net != 5001 || f.DateFrom == nil {
+		t.Errorf("filter = %+v: want the search filter without pagination", f)
+	}
+}
+
+func TestExportStatuses(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		target   string
+		err      error
+		wantCode int
+		wantErr  string
+	}{
+		{"unconstrained", "/api/export/nodes?domain=fidonet", nil, http.StatusBadRequest, "Export requires at least one specific constraint"},
+		{"unknown format", "/api/export/nodes?zone=2&format=xlsx", nil, http.StatusBadRequest, `unknown export format "xlsx"`},
+		{"failure before the first row", "/api/export/nodes?zone=2", fmt.Errorf("clickhouse unavailable"), http.StatusInternalServerError, "Export failed: clickhouse unavailable"},
+		{"attribute on test results", "/api/export/test-results?sysop_name=Ivanov", nil, http.StatusBadRequest, "filter by domain, zone, net, node, date_from and date_to only"},
+		{"window too long", "/api/export/test-results?date_from=2000-01-01&date_to=2026-01-01", nil, http.StatusBadRequest, "cannot exceed 3650 days"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			ops := &exportOps{fakeOps: &fakeOps{nodes: []database.Node{sampleNode()}}, failAfter: -1}
+			if tc.err != nil {
+				ops.failAfter, ops.err = 0, tc.err
+			}
+			rec, body := call(t, ops, "GET", tc.target)
+			if rec.Code != tc.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tc.wantCode)
+			}
+			if msg, _ := body["error"].(string); !strings.Contains(msg, tc.wantErr) {
+				t.Errorf("error = %q, want it to contain %q (body %s)", msg, tc.wantErr, rec.Body.String())
+			}
+		})
+	}
+}
+
+// TestExportAbortsMidStream: once rows are on the wire a failure must not end
+// the response normally, or the client keeps a truncated file that looks
+// complete.
+func TestExportAbortsMidStream(t *testing.T) {
+	nodes := make([]database.Node, 200)
+	for i := range nodes {
+		nodes[i] = sampleNode()
+		nodes[i].RawLine = strings.Repeat("x", 100)
+	}
+	ops := &exportOps{fakeOps: &fakeOps{nodes: nodes}, failAfter: len(nodes), err: fmt.Errorf("connection reset")}
+
+	defer func() {
+		if r := recover(); r != http.ErrAbortHandler {
+			t.Errorf("recovered %v, want http.ErrAbortHandler", r)
+		}
+	}()
+	New(ops).SetupRouter().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", "/api/export/nodes?zone=2", nil))
+	t.Error("handler returned normally after a mid-stream failure")
+}
+
+func TestExportTestResultsWindow(t *testing.T) {
+	ops := &exportOps{fakeOps: &fakeOps{}, failAfter: -1, tests: []storage.NodeTestResult{{
+		TestTime: time.Date(2026, 7, 21, 12, 0, 0, 0, time.UTC), Zone: 2, Net: 5001, Node: 100,
+		ResolvedIPv4: []string{"192.0.2.1", "192.0.2.2"}, BinkPTested: true,
+	}}}
+	rec, _ := call(t, ops, "GET", "/api/export/test-results?zone=2&format=jsonl")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "application/x-ndjson" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+	if !strings.HasPrefix(rec.Body.String(), `{"test_time":"2026-07-21T12:00:00Z","zone":2,"net":5001,"node":100,"address":"","hostname":"","resolved_ipv4":"192.0.2.1,192.0.2.2",`) {
+		t.Errorf("body = %s", rec.Body.String())
+	}
+
+	f := ops.testFilter
+	if f.DateFrom == nil || f.DateTo == nil {
+		t.Fatalf("window not defaulted: %+v", f)
+	}
+	if days := f.DateTo.Sub(*f.DateFrom).Hours() / 24; days != defaultTestResultWindow {
+		t.Errorf("default window = %v days, want %d", days, defaultTestResultWindow)
+	}
+	if f.Zone == nil || *f.Zone != 2 {
+		t.Errorf("zone not passed through: %+v", f)
+	}
+}
@@ -0,0 +1,1041 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/graphql"
+	"github.com/nodelistdb/internal/logging"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// The GraphQL endpoint is the REST API's readers joined into one graph, so a
+// client can go from a node to its history, its test results and where those
+// tests found it in one request instead of one per hop.
+//
+// It reads through the same Storage as every REST handler, which in
+// production is the CachedStorage, so a field costs what the matching REST
+// call costs and shares its cache entries. What GraphQL adds is fan-out: a
+// query can ask for the history of every node in a search. Three limits keep
+// that in the same cost class as the REST API:
+//
+//   - list arguments are capped exactly as the REST parameters are;
+//   - a query may nest at most graphqlMaxDepth fields deep;
+//   - a query may make at most graphqlMaxResolves storage reads. Once it has,
+//     the remaining fields come back null with one "too expensive" error.
+//
+// Budgets are picked per query rather than per route. A query that selects a
+// test-result field runs under the analytics budget, like the REST reports
+// over the same table; anything else gets the read budget.
+const (
+	graphqlMaxDepth    = 10
+	graphqlMaxResolves = 200
+	// graphqlMaxBody bounds a POSTed request. Queries are text a person
+	// wrote; a megabyte of one is not a query.
+	graphqlMaxBody = 1 << 20
+)
+
+// graphqlAPI is the schema and the fields that make a query heavy.
+type graphqlAPI struct {
+	schema *graphql.Schema
+	heavy  map[*graphql.Field]bool
+}
+
+// graphQL builds the schema on first use. It depends only on s.storage, which
+// is fixed by New.
+func (s *Server) graphQL() *graphqlAPI {
+	s.gqlOnce.Do(func() {
+		s.gql = newGraphQLAPI(s.storage)
+	})
+	return s.gql
+}
+
+// GraphQLHandler executes a GraphQL query.
+// POST /api/graphql {"query": "...", "variables": {...}, "operationName": "..."}
+// GET  /api/graphql?query=...&variables=...&operationName=...
+func (s *Server) GraphQLHandler(w http.ResponseWriter, r *http.Request) {
+	req, err := readGraphQLRequest(r)
+	if err != nil {
+		WriteJSON(w, &graphql.Response{Errors: []*graphql.Error{{Message: err.Error()}}}, http.StatusBadRequest)
+		return
+	}
+
+	gql := s.graphQL()
+	prepared, errs := gql.schema.Prepare(r.Context(), req)
+	if errs != nil {
+		if r.Context().Err() != nil {
+			logging.Debug("Request cancelled by client", slog.String("op", "graphql"))
+			return
+		}
+		WriteJSON(w, &graphql.Response{Errors: errs}, http.StatusBadRequest)
+		return
+	}
+
+	budget := s.budgets.Read
+	if prepared.Selects(func(f *graphql.Field) bool { return gql.heavy[f] }) {
+		budget = s.budgets.Analytics
+	}
+	ctx, cancel := budget.Apply(r.Context())
+	defer cancel()
+
+	resp := prepared.Execute(ctx)
+
+	// The two context errors mean what they mean for writeStorageError: a
+	// client that hung up gets nothing, and a query that ran out of budget
+	// gets a 503 with whatever it did manage to read.
+	status := http.StatusOK
+	for _, e := range resp.Errors {
+		switch {
+		case errors.Is(e, context.Canceled) && r.Context().Err() != nil:
+			logging.Debug("Request cancelled by client", slog.String("op", "graphql"))
+			return
+		case errors.Is(e, context.DeadlineExceeded):
+			e.Message = budgetExceededMessage
+			status = http.StatusServiceUnavailable
+		}
+	}
+	if status == http.StatusServiceUnavailable {
+		logging.Warn("Query exceeded its time budget", slog.String("op", "graphql"))
+	}
+	WriteJSON(w, resp, status)
+}
+
+// GraphQLSchemaHandler serves the schema as SDL. It stands in for
+// introspection, which the executor does not implement.
+// GET /api/graphql/schema
+func (s *Server) GraphQLSchemaHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	_, _ = w.Write([]byte(s.graphQL().schema.SDL()))
+}
+
+// readGraphQLRequest decodes a request in either of the GraphQL-over-HTTP
+// forms: a JSON body, or the same three fields as query parameters.
+func readGraphQLRequest(r *http.Request) (graphql.Request, error) {
+	var req graphql.Request
+	if r.Method == http.MethodGet {
+		q := r.URL.Query()
+		req.Query = q.Get("query")
+		req.OperationName = q.Get("operationName")
+		if v := q.Get("variables"); v != "" {
+			if err := json.Unmarshal([]byte(v), &req.Variables); err != nil {
+				return req, errors.New("variables must be a JSON object")
+			}
+		}
+	} else {
+		body := http.MaxBytesReader(nil, r.Body, graphqlMaxBody)
+		if err := json.NewDecoder(body).Decode(&req); err != nil {
+			return req, errors.New(`Request body must be a JSON object with a "query" string`)
+		}
+	}
+	if strings.TrimSpace(req.Query) == "" {
+		return req, errors.New("Missing query")
+	}
+	return req, nil
+}
+
+// graphqlStorageError is writeStorageErrorf for a resolver. The error keeps
+// its cause so GraphQLHandler can still tell a deadline from a failure, and
+// only real failures are logged.
+func graphqlStorageError(op string, err error) error {
+	if !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded) {
+		logging.Error(op, slog.Any("error", err))
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
+
+// graphqlArgs reads a search's optional arguments into filter fields,
+// remembering the first bad one and whether any narrowed the search.
+type graphqlArgs struct {
+	p           graphql.ResolveParams
+	err         error
+	constrained bool
+}
+
+func (a *graphqlArgs) int(name string) *int {
+	v, ok := a.p.Int(name)
+	if !ok {
+		return nil
+	}
+	a.constrained = true
+	return &v
+}
+
+// text is parseStringParam: trimmed, and rejected below minLen.
+func (a *graphqlArgs) text(name string, minLen int) *string {
+	v, ok := a.p.String(name)
+	if !ok {
+		return nil
+	}
+	v = strings.TrimSpace(v)
+	if len(v) < minLen {
+		if a.err == nil {
+			a.err = fmt.Errorf("%s must be at least %d characters long", name, minLen)
+		}
+		return nil
+	}
+	a.constrained = true
+	return &v
+}
+
+func (a *graphqlArgs) flag(name string) *bool {
+	v, ok := a.p.Bool(name)
+	if !ok {
+		return nil
+	}
+	a.constrained = true
+	return &v
+}
+
+func (a *graphqlArgs) date(name string) *time.Time {
+	v, ok := a.p.String(name)
+	if !ok {
+		return nil
+	}
+	t, err := time.Parse("2006-01-02", v)
+	if err != nil {
+		if a.err == nil {
+			a.err = fmt.Errorf("%s must be a date in YYYY-MM-DD form", name)
+		}
+		return nil
+	}
+	a.constrained = true
+	return &t
+}
+
+// domain is a network filter. Like ?domain= it does not count as a
+// constraint on its own.
+func (a *graphqlArgs) domain() *string {
+	v, ok := a.p.String("domain")
+	v = strings.ToLower(strings.TrimSpace(v))
+	if !ok || v == "" {
+		return nil
+	}
+	return &v
+}
+
+// page is parsePaginationParams for limit and offset arguments.
+func (a *graphqlArgs) page(defaultLimit, maxLimit int) (limit, offset int) {
+	limit = defaultLimit
+	if l, ok := a.p.Int("limit"); ok && l > 0 {
+		limit = min(l, maxLimit)
+	}
+	if o, ok := a.p.Int("offset"); ok && o >= 0 {
+		offset = o
+	}
+	return limit, offset
+}
+
+// graphqlDays is parseDaysParam for a days argument.
+func graphqlDays(p graphql.ResolveParams) int {
+	days, _ := p.Int("days")
+	return min(max(days, 1), maxAnalyticsDays)
+}
+
+// graphqlNode accepts a node as the schema hands it over: a value from a
+// list, or a pointer from a change record.
+func graphqlNode(src any) database.Node {
+	switch n := src.(type) {
+	case *database.Node:
+		return *n
+	case database.Node:
+		return n
+	}
+	return database.Node{}
+}
+
+func graphqlPoint(src any) database.Point {
+	switch pt := src.(type) {
+	case *database.Point:
+		return *pt
+	case database.Point:
+		return pt
+	}
+	return database.Point{}
+}
+
+func graphqlTestResult(src any) storage.NodeTestResult {
+	switch r := src.(type) {
+	case *storage.NodeTestResult:
+		return *r
+	case storage.NodeTestResult:
+		return r
+	}
+	return storage.NodeTestResult{}
+}
+
+// nodeDomain is the network a node row belongs to. Rows from before networks
+// existed have none and are fidonet.
+func nodeDomain(domain string) string {
+	if domain == "" {
+		return database.DefaultDomain
+	}
+	return domain
+}
+
+// graphqlGeolocation is where a test found a node's host.
+type graphqlGeolocation struct {
+	Country     string
+	CountryCode string
+	City        string
+	Region      string
+	Latitude    float32
+	Longitude   float32
+	ISP         string
+	Org         string
+	ASN         uint32
+}
+
+// graphqlProtocol is one protocol's columns of a test result, gathered under
+// common names so the five protocols share one type.
+type graphqlProtocol struct {
+	Tested         bool
+	Success        bool
+	ResponseMs     uint32
+	Error          string
+	SystemName     string
+	Sysop          string
+	Location       string
+	Software       string
+	Addresses      []string
+	Capabilities   []string
+	AnonymousLogin *bool
+	Variant        string
+	IPv4           graphqlAttempt
+	IPv6           graphqlAttempt
+}
+
+// graphqlAttempt is one address family's attempt at a protocol.
+type graphqlAttempt struct {
+	Tested     bool
+	Success    bool
+	ResponseMs uint32
+	Address    string
+	Error      string
+}
+
+func newGraphQLAPI(st Storage) *graphqlAPI {
+	api := &graphqlAPI{heavy: make(map[*graphql.Field]bool)}
+
+	str, integer, float, boolean := graphql.String, graphql.Int, graphql.Float, graphql.Boolean
+	nn, list := graphql.NonNullOf, graphql.ListOf
+	strs := nn(list(nn(str)))
+	arg := func(name string, t graphql.Type, def any, desc string) *graphql.Argument {
+		return &graphql.Argument{Name: name, Type: t, Default: def, Description: desc}
+	}
+	leaf := func(name string, t graphql.Type, desc string) *graphql.Field {
+		return &graphql.Field{Name: name, Type: t, Description: desc}
+	}
+
+	jsonScalar := &graphql.Scalar{
+		Name:        "JSON",
+		Description: "Any JSON value, passed through as stored.",
+		Serialize: func(v any) (any, error) {
+			if raw, ok := v.(json.RawMessage); ok && len(raw) == 0 {
+				return nil, nil
+			}
+			return v, nil
+		},
+	}
+
+	node := &graphql.Object{Name: "Node", Description: "One nodelist entry: a node as one nodelist issue listed it."}
+	point := &graphql.Object{Name: "Point", Description: "One pointlist entry."}
+	change := &graphql.Object{Name: "NodeChange", Description: "A difference between two consecutive entries of a node."}
+	testResult := &graphql.Object{Name: "TestResult", Description: "One testdaemon connection test of a node's host."}
+
+	geolocation := &graphql.Object{Name: "Geolocation", Description: "Where a test found a host, by IP address.", Fields: []*graphql.Field{
+		leaf("country", str, ""),
+		leaf("countryCode", str, ""),
+		leaf("city", str, ""),
+		leaf("region", str, ""),
+		leaf("latitude", float, ""),
+		leaf("longitude", float, ""),
+		leaf("isp", str, ""),
+		leaf("org", str, ""),
+		leaf("asn", float, "Autonomous system number. A Float because four-byte ASNs do not fit a GraphQL Int."),
+	}}
+
+	attempt := &graphql.Object{Name: "ProtocolAttempt", Description: "One address family's attempt at a protocol.", Fields: []*graphql.Field{
+		leaf("tested", nn(boolean), ""),
+		leaf("success", nn(boolean), ""),
+		leaf("responseMs", integer, ""),
+		leaf("address", str, "The IP address connected to."),
+		leaf("error", str, ""),
+	}}
+
+	protocol := &graphql.Object{Name: "ProtocolResult", Description: "One protocol's part of a test.", Fields: []*graphql.Field{
+		leaf("tested", nn(boolean), ""),
+		leaf("success", nn(boolean), "Whether either address family succeeded."),
+		leaf("responseMs", integer, ""),
+		leaf("error", str, ""),
+		leaf("systemName", str, "As the remote announced it."),
+		leaf("sysop", str, "As the remote announced it."),
+		leaf("location", str, "As the remote announced it."),
+		leaf("software", str, "The mailer the remote identified as."),
+		leaf("addresses", strs, "FTN addresses the remote announced."),
+		leaf("capabilities", strs, "BinkP only: options the remote offered."),
+		leaf("anonymousLogin", boolean, "FTP only: whether an anonymous login was accepted; null when not attempted."),
+		leaf("variant", str, "VModem only: the protocol actually found on the port."),
+		leaf("ipv4", nn(attempt), ""),
+		leaf("ipv6", nn(attempt), ""),
+	}}
+
+	reachability := &graphql.Object{Name: "Reachability", Description: "A node's test record summarised over a window.", Fields: []*graphql.Field{
+		leaf("totalTests", integer, ""),
+		leaf("fullySuccessfulTests", integer, ""),
+		leaf("partiallyFailedTests", integer, ""),
+		leaf("failedTests", integer, ""),
+		leaf("successRate", float, "Percent of tests in which the node was operational."),
+		leaf("averageResponseMs", float, ""),
+		leaf("lastTestTime", str, ""),
+		leaf("lastStatus", str, ""),
+		leaf("binkpSuccessRate", float, ""),
+		leaf("ifcicoSuccessRate", float, ""),
+		leaf("telnetSuccessRate", float, ""),
+		leaf("binkpIPv4SuccessRate", float, ""),
+		leaf("ifcicoIPv4SuccessRate", float, ""),
+		leaf("telnetIPv4SuccessRate", float, ""),
+		leaf("binkpIPv6SuccessRate", float, ""),
+		leaf("ifcicoIPv6SuccessRate", float, ""),
+		leaf("telnetIPv6SuccessRate", float, ""),
+	}}
+
+	lifetime := &graphql.Object{Name: "Lifetime", Description: "The first and last nodelist a node appears in.", Fields: []*graphql.Field{
+		leaf("firstDate", str, ""),
+		leaf("lastDate", str, ""),
+	}}
+
+	// Node
+	node.Fields = []*graphql.Field{
+		leaf("zone", nn(integer), ""),
+		leaf("net", nn(integer), ""),
+		leaf("node", nn(integer), ""),
+		leaf("domain", str, "The FTN network, such as fidonet."),
+		{Name: "address", Type: nn(str), Description: "zone:net/node", Derive: func(src any) any {
+			n := graphqlNode(src)
+			return fmt.Sprintf("%d:%d/%d", n.Zone, n.Net, n.Node)
+		}},
+		leaf("nodelistDate", str, ""),
+		leaf("dayNumber", integer, ""),
+		leaf("systemName", str, ""),
+		leaf("location", str, ""),
+		leaf("sysopName", str, ""),
+		leaf("phone", str, ""),
+		leaf("nodeType", str, "Zone, Region, Host, Hub, Pvt, Down, Hold or empty for a plain node."),
+		leaf("region", integer, ""),
+		leaf("maxSpeed", integer, ""),
+		leaf("isCM", boolean, ""),
+		leaf("isMO", boolean, ""),
+		leaf("hasInet", boolean, ""),
+		leaf("flags", strs, ""),
+		leaf("modemFlags", strs, ""),
+		leaf("internetConfig", jsonScalar, "Internet protocols, hosts and ports parsed from the flags."),
+		leaf("hasConflict", boolean, "Whether this address was listed twice in the same nodelist."),
+		leaf("conflictSequence", integer, ""),
+		leaf("ftsId", str, ""),
+		leaf("rawLine", str, "The nodelist line as published."),
+		{
+			Name:        "history",
+			Type:        nn(list(nn(node))),
+			Description: "Every entry of this node in its network, oldest first.",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				n := graphqlNode(p.Source)
+				history, err := st.GetNodeHistory(p.Context, n.Zone, n.Net, n.Node, nodeDomain(n.Domain))
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get node history", err)
+				}
+				return history, nil
+			},
+		},
+		{
+			Name: "changes",
+			Type: nn(list(nn(change))),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				n := graphqlNode(p.Source)
+				changes, err := st.GetNodeChanges(p.Context, n.Zone, n.Net, n.Node, nodeDomain(n.Domain))
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get node changes", err)
+				}
+				return changes, nil
+			},
+		},
+		{
+			Name: "lifetime",
+			Type: nn(lifetime),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				n := graphqlNode(p.Source)
+				first, last, err := st.GetNodeDateRange(p.Context, n.Zone, n.Net, n.Node, nodeDomain(n.Domain))
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get node date range", err)
+				}
+				return map[string]any{"firstDate": first, "lastDate": last}, nil
+			},
+		},
+		{
+			Name:        "availableDomains",
+			Type:        strs,
+			Description: "Every network this address appears in.",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				n := graphqlNode(p.Source)
+				domains, err := st.GetNodeDomains(p.Context, n.Zone, n.Net, n.Node)
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get node networks", err)
+				}
+				return domains, nil
+			},
+		},
+		{
+			Name:        "points",
+			Type:        nn(list(nn(point))),
+			Description: "The points under this node.",
+			Args:        []*graphql.Argument{arg("date", str, nil, "YYYY-MM-DD: the pointlist in force on this date. Default: the latest.")},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				n := graphqlNode(p.Source)
+				a := &graphqlArgs{p: p}
+				asOf := a.date("date")
+				if a.err != nil {
+					return nil, a.err
+				}
+				points, err := st.GetPointsByBoss(p.Context, nodeDomain(n.Domain), n.Zone, n.Net, n.Node, asOf)
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get points", err)
+				}
+				return points, nil
+			},
+		},
+	}
+	testResults := &graphql.Field{
+		Name:        "testResults",
+		Type:        nn(list(nn(testResult))),
+		Description: "Connection tests of this node, newest first.",
+		Args:        []*graphql.Argument{arg("days", integer, 30, "Look-back window, at most 3650.")},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			n := graphqlNode(p.Source)
+			results, err := st.GetNodeTestHistory(p.Context, n.Zone, n.Net, n.Node, graphqlDays(p), nodeDomain(n.Domain))
+			if err != nil {
+				return nil, graphqlStorageError("Failed to get test history", err)
+			}
+			return results, nil
+		},
+	}
+	reachabilityField := &graphql.Field{
+		Name:        "reachability",
+		Type:        reachability,
+		Description: "Test results summarised; null when the node was not tested in the window.",
+		Args:        []*graphql.Argument{arg("days", integer, 30, "Look-back window, at most 3650.")},
+		Resolve: func(p graphql.ResolveParams) (any, error) {
+			n := graphqlNode(p.Source)
+			stats, err := st.GetNodeReachabilityStats(p.Context, n.Zone, n.Net, n.Node, graphqlDays(p), nodeDomain(n.Domain))
+			if err != nil {
+				return nil, graphqlStorageError("Failed to get reachability", err)
+			}
+			return stats, nil
+		},
+	}
+	node.Fields = append(node.Fields, testResults, reachabilityField)
+	api.heavy[testResults] = true
+	api.heavy[reachabilityField] = true
+
+	// NodeChange
+	change.Fields = []*graphql.Field{
+		leaf("date", str, ""),
+		leaf("dayNumber", integer, ""),
+		leaf("changeType", str, "added, removed or modified"),
+		leaf("changes", jsonScalar, `Field name to "old -> new".`),
+		leaf("oldNode", node, ""),
+		leaf("newNode", node, ""),
+	}
+
+	// Point
+	point.Fields = []*graphql.Field{
+		leaf("zone", nn(integer), ""),
+		leaf("net", nn(integer), ""),
+		leaf("node", nn(integer), "The boss node."),
+		leaf("point", nn(integer), ""),
+		leaf("domain", str, ""),
+		{Name: "address", Type: nn(str), Description: "zone:net/node.point", Derive: func(src any) any {
+			pt := graphqlPoint(src)
+			return fmt.Sprintf("%d:%d/%d.%d", pt.Zone, pt.Net, pt.Node, pt.PointNum)
+		}},
+		leaf("pointlistDate", str, ""),
+		leaf("dayNumber", integer, ""),
+		leaf("listSource", str, "The pointlist series this entry came from."),
+		leaf("sourcePriority", integer, ""),
+		leaf("sourceFormat", str, ""),
+		leaf("systemName", str, ""),
+		leaf("location", str, ""),
+		leaf("sysopName", str, ""),
+		leaf("phone", str, ""),
+		leaf("maxSpeed", integer, ""),
+		leaf("isCM", boolean, ""),
+		leaf("isMO", boolean, ""),
+		leaf("hasInet", boolean, ""),
+		leaf("flags", strs, ""),
+		leaf("modemFlags", strs, ""),
+		leaf("internetConfig", jsonScalar, ""),
+		leaf("ftsId", str, ""),
+		leaf("rawLine", str, ""),
+		{
+			Name:        "history",
+			Type:        nn(list(nn(point))),
+			Description: "Every stored entry of this point across sources and dates.",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				pt := graphqlPoint(p.Source)
+				history, err := st.GetPointHistory(p.Context, nodeDomain(pt.Domain), pt.Zone, pt.Net, pt.Node, pt.PointNum)
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get point history", err)
+				}
+				return history, nil
+			},
+		},
+		{
+			Name:        "boss",
+			Type:        node,
+			Description: "The boss node's latest entry.",
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				pt := graphqlPoint(p.Source)
+				domain := nodeDomain(pt.Domain)
+				nodes, err := st.GetNodes(p.Context, database.NodeFilter{
+					Zone: &pt.Zone, Net: &pt.Net, Node: &pt.Node, Domain: &domain, Limit: 1,
+				})
+				if err != nil {
+					return nil, graphqlStorageError("Node lookup failed", err)
+				}
+				if len(nodes) == 0 {
+					return nil, nil
+				}
+				return nodes[0], nil
+			},
+		},
+	}
+
+	// TestResult
+	protocolField := func(name string, pick func(storage.NodeTestResult) graphqlProtocol) *graphql.Field {
+		return &graphql.Field{Name: name, Type: nn(protocol), Derive: func(src any) any {
+			return pick(graphqlTestResult(src))
+		}}
+	}
+	testResult.Fields = []*graphql.Field{
+		leaf("testTime", str, ""),
+		leaf("address", str, ""),
+		leaf("domain", str, ""),
+		leaf("hostname", str, "The hostname as listed."),
+		leaf("testedHostname", str, "The hostname this row tested, when the node lists several."),
+		leaf("hostnameIndex", integer, "-1 legacy, 0 primary, 1 and up backups."),
+		leaf("isAggregated", boolean, "Whether this row summarises all of the node's hostnames."),
+		leaf("resolvedIPv4", strs, ""),
+		leaf("resolvedIPv6", strs, ""),
+		leaf("dnsError", str, ""),
+		leaf("isOperational", boolean, ""),
+		leaf("hasConnectivityIssues", boolean, ""),
+		leaf("addressValidated", boolean, "Whether the remote announced the address it was tested as."),
+		{Name: "geolocation", Type: geolocation, Description: "Null when the host was not located.", Derive: func(src any) any {
+			r := graphqlTestResult(src)
+			if r.CountryCode == "" && r.Country == "" && r.ASN == 0 {
+				return nil
+			}
+			return graphqlGeolocation{
+				Country: r.Country, CountryCode: r.CountryCode, City: r.City, Region: r.Region,
+				Latitude: r.Latitude, Longitude: r.Longitude, ISP: r.ISP, Org: r.Org, ASN: r.ASN,
+			}
+		}},
+		protocolField("binkp", func(r storage.NodeTestResult) graphqlProtocol {
+			return graphqlProtocol{
+				Tested: r.BinkPTested, Success: r.BinkPSuccess, ResponseMs: r.BinkPResponseMs, Error: r.BinkPError,
+				SystemName: r.BinkPSystemName, Sysop: r.BinkPSysop, Location: r.BinkPLocation, Software: r.BinkPVersion,
+				Addresses: r.BinkPAddresses, Capabilities: r.BinkPCapabilities,
+				IPv4: graphqlAttempt{r.BinkPIPv4Tested, r.BinkPIPv4Success, r.BinkPIPv4ResponseMs, r.BinkPIPv4Address, r.BinkPIPv4Error},
+				IPv6: graphqlAttempt{r.BinkPIPv6Tested, r.BinkPIPv6Success, r.BinkPIPv6ResponseMs, r.BinkPIPv6Address, r.BinkPIPv6Error},
+			}
+		}),
+		protocolField("ifcico", func(r storage.NodeTestResult) graphqlProtocol {
+			return graphqlProtocol{
+				Tested: r.IfcicoTested, Success: r.IfcicoSuccess, ResponseMs: r.IfcicoResponseMs, Error: r.IfcicoError,
+				SystemName: r.IfcicoSystemName, Software: r.IfcicoMailerInfo, Addresses: r.IfcicoAddresses,
+				IPv4: graphqlAttempt{r.IfcicoIPv4Tested, r.IfcicoIPv4Success, r.IfcicoIPv4ResponseMs, r.IfcicoIPv4Address, r.IfcicoIPv4Error},
+				IPv6: graphqlAttempt{r.IfcicoIPv6Tested, r.IfcicoIPv6Success, r.IfcicoIPv6ResponseMs, r.IfcicoIPv6Address, r.IfcicoIPv6Error},
+			}
+		}),
+		protocolField("telnet", func(r storage.NodeTestResult) graphqlProtocol {
+			return graphqlProtocol{
+				Tested: r.TelnetTested, Success: r.TelnetSuccess, ResponseMs: r.TelnetResponseMs, Error: r.TelnetError,
+				IPv4: graphqlAttempt{r.TelnetIPv4Tested, r.TelnetIPv4Success, r.TelnetIPv4ResponseMs, r.TelnetIPv4Address, r.TelnetIPv4Error},
+				IPv6: graphqlAttempt{r.TelnetIPv6Tested, r.TelnetIPv6Success, r.TelnetIPv6ResponseMs, r.TelnetIPv6Address, r.TelnetIPv6Error},
+			}
+		}),
+		protocolField("ftp", func(r storage.NodeTestResult) graphqlProtocol {
+			return graphqlProtocol{
+				Tested: r.FTPTested, Success: r.FTPSuccess, ResponseMs: r.FTPResponseMs, Error: r.FTPError,
+				AnonymousLogin: r.FTPAnonSuccess,
+				IPv4:           graphqlAttempt{r.FTPIPv4Tested, r.FTPIPv4Success, r.FTPIPv4ResponseMs, r.FTPIPv4Address, r.FTPIPv4Error},
+				IPv6:           graphqlAttempt{r.FTPIPv6Tested, r.FTPIPv6Success, r.FTPIPv6ResponseMs, r.FTPIPv6Address, r.FTPIPv6Error},
+			}
+		}),
+		protocolField("vmodem", func(r storage.NodeTestResult) graphqlProtocol {
+			return graphqlProtocol{
+				Tested: r.VModemTested, Success: r.VModemSuccess, ResponseMs: r.VModemResponseMs, Error: r.VModemError,
+				SystemName: r.VModemSystemName, Sysop: r.VModemSysop, Location: r.VModemLocation, Software: r.VModemSoftware,
+				Addresses: r.VModemAddresses, Variant: r.VModemVariant,
+				IPv4: graphqlAttempt{r.VModemIPv4Tested, r.VModemIPv4Success, r.VModemIPv4ResponseMs, r.VModemIPv4Address, r.VModemIPv4Error},
+				IPv6: graphqlAttempt{r.VModemIPv6Tested, r.VModemIPv6Success, r.VModemIPv6ResponseMs, r.VModemIPv6Address, r.VModemIPv6Error},
+			}
+		}),
+	}
+
+	network := &graphql.Object{Name: "Network", Description: "An FTN network in the database.", Fields: []*graphql.Field{
+		leaf("domain", nn(str), ""),
+		leaf("latestDate", str, "Date of its newest nodelist."),
+		leaf("nodeCount", integer, "Nodes in that nodelist."),
+	}}
+
+	regionInfo := &graphql.Object{Name: "RegionSize", Fields: []*graphql.Field{
+		leaf("zone", nn(integer), ""),
+		leaf("region", nn(integer), ""),
+		leaf("nodeCount", integer, ""),
+		leaf("name", str, ""),
+	}}
+	netInfo := &graphql.Object{Name: "NetSize", Fields: []*graphql.Field{
+		leaf("zone", nn(integer), ""),
+		leaf("net", nn(integer), ""),
+		leaf("nodeCount", integer, ""),
+		leaf("name", str, ""),
+	}}
+	stats := &graphql.Object{Name: "Stats", Description: "Counts over one nodelist.", Fields: []*graphql.Field{
+		leaf("date", str, "The nodelist counted, which may differ from the date asked for."),
+		leaf("totalNodes", integer, ""),
+		leaf("activeNodes", integer, ""),
+		leaf("cmNodes", integer, ""),
+		leaf("moNodes", integer, ""),
+		leaf("binkpNodes", integer, ""),
+		leaf("telnetNodes", integer, ""),
+		leaf("pvtNodes", integer, ""),
+		leaf("downNodes", integer, ""),
+		leaf("holdNodes", integer, ""),
+		leaf("hubNodes", integer, ""),
+		leaf("zoneNodes", integer, ""),
+		leaf("regionNodes", integer, ""),
+		leaf("hostNodes", integer, ""),
+		leaf("internetNodes", integer, ""),
+		leaf("zoneDistribution", jsonScalar, "Zone number to node count."),
+		leaf("largestRegions", nn(list(nn(regionInfo))), ""),
+		leaf("largestNets", nn(list(nn(netInfo))), ""),
+	}}
+
+	sysop := &graphql.Object{Name: "Sysop", Fields: []*graphql.Field{
+		leaf("name", nn(str), ""),
+		leaf("nodeCount", integer, ""),
+		leaf("activeNodes", integer, ""),
+		leaf("firstSeen", str, ""),
+		leaf("lastSeen", str, ""),
+		leaf("zones", nn(list(nn(integer))), ""),
+		{
+			Name: "nodes",
+			Type: nn(list(nn(node))),
+			Args: []*graphql.Argument{arg("limit", integer, 100, "At most 1000.")},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				name := p.Source.(storage.SysopInfo).Name
+				limit, _ := (&graphqlArgs{p: p}).page(100, 1000)
+				nodes, err := st.GetNodesBySysop(p.Context, name, limit)
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get nodes", err)
+				}
+				return nodes, nil
+			},
+		},
+	}}
+
+	addressArgs := func(withPoint bool) []*graphql.Argument {
+		args := []*graphql.Argument{
+			arg("zone", nn(integer), nil, ""),
+			arg("net", nn(integer), nil, ""),
+			arg("node", nn(integer), nil, ""),
+		}
+		if withPoint {
+			args = append(args, arg("point", nn(integer), nil, ""))
+		}
+		return append(args, arg("domain", str, nil, "The FTN network. Default: the only one the address is in, or fidonet."))
+	}
+
+	query := &graphql.Object{Name: "Query", Fields: []*graphql.Field{
+		{
+			Name:        "node",
+			Type:        node,
+			Description: "A node's latest entry.",
+			Args:        addressArgs(false),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				zone, _ := p.Int("zone")
+				net, _ := p.Int("net")
+				num, _ := p.Int("node")
+				domain := ""
+				if d := (&graphqlArgs{p: p}).domain(); d != nil {
+					domain = *d
+				} else {
+					// As resolveNodeDomain: a failed lookup falls back to the default.
+					domains, _ := st.GetNodeDomains(p.Context, zone, net, num)
+					domain = preferDomain("", domains)
+				}
+				nodes, err := st.GetNodes(p.Context, database.NodeFilter{
+					Zone: &zone, Net: &net, Node: &num, Domain: &domain, Limit: 1,
+				})
+				if err != nil {
+					return nil, graphqlStorageError("Node lookup failed", err)
+				}
+				if len(nodes) == 0 {
+					return nil, nil
+				}
+				return nodes[0], nil
+			},
+		},
+		{
+			Name:        "nodes",
+			Type:        nn(list(nn(node))),
+			Description: "Search nodes, as GET /api/nodes. At least one argument other than domain, latestOnly, limit and offset is required.",
+			Args: []*graphql.Argument{
+				arg("zone", integer, nil, ""),
+				arg("net", integer, nil, ""),
+				arg("node", integer, nil, ""),
+				arg("domain", str, nil, "Default: every network."),
+				arg("systemName", str, nil, "Substring, at least 2 characters."),
+				arg("location", str, nil, "Substring, at least 2 characters."),
+				arg("sysopName", str, nil, "Substring, at least 2 characters."),
+				arg("nodeType", str, nil, ""),
+				arg("isCM", boolean, nil, ""),
+				arg("isMO", boolean, nil, ""),
+				arg("hasInet", boolean, nil, ""),
+				arg("hasBinkp", boolean, nil, ""),
+				arg("dateFrom", str, nil, "YYYY-MM-DD"),
+				arg("dateTo", str, nil, "YYYY-MM-DD"),
+				arg("latestOnly", boolean, nil, "Only each node's latest entry."),
+				arg("limit", integer, 100, "At most 500."),
+				arg("offset", integer, 0, ""),
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				a := &graphqlArgs{p: p}
+				filter := database.NodeFilter{
+					Zone:       a.int("zone"),
+					Net:        a.int("net"),
+					Node:       a.int("node"),
+					SystemName: a.text("systemName", 2),
+					Location:   a.text("location", 2),
+					SysopName:  a.text("sysopName", 2),
+					NodeType:   a.text("nodeType", 1),
+					IsCM:       a.flag("isCM"),
+					IsMO:       a.flag("isMO"),
+					HasInet:    a.flag("hasInet"),
+					HasBinkp:   a.flag("hasBinkp"),
+					DateFrom:   a.date("dateFrom"),
+					DateTo:     a.date("dateTo"),
+				}
+				if a.err != nil {
+					return nil, a.err
+				}
+				if !a.constrained {
+					return nil, errors.New("Search requires at least one specific constraint (zone, net, node, systemName, location, sysopName, nodeType, isCM, isMO, hasInet, hasBinkp, or a date range)")
+				}
+				filter.Domain = a.domain()
+				if latest, ok := p.Bool("latestOnly"); ok {
+					filter.LatestOnly = &latest
+				}
+				filter.Limit, filter.Offset = a.page(100, 500)
+				nodes, err := st.GetNodes(p.Context, filter)
+				if err != nil {
+					return nil, graphqlStorageError("Search failed", err)
+				}
+				return nodes, nil
+			},
+		},
+		{
+			Name:        "point",
+			Type:        point,
+			Description: "A point's current entry.",
+			Args:        addressArgs(true),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				zone, _ := p.Int("zone")
+				net, _ := p.Int("net")
+				num, _ := p.Int("node")
+				pointNum, _ := p.Int("point")
+				domain := ""
+				if d := (&graphqlArgs{p: p}).domain(); d != nil {
+					domain = *d
+				} else {
+					domains, _ := st.GetPointDomains(p.Context, zone, net, num, &pointNum)
+					domain = preferDomain("", domains)
+				}
+				latest := true
+				points, err := st.SearchPoints(p.Context, database.PointFilter{
+					Zone: &zone, Net: &net, Node: &num, PointNum: &pointNum, Domain: &domain, LatestOnly: &latest, Limit: 1,
+				})
+				if err != nil {
+					return nil, graphqlStorageError("Point lookup failed", err)
+				}
+				if len(points) == 0 {
+					return nil, nil
+				}
+				return points[0], nil
+			},
+		},
+		{
+			Name:        "points",
+			Type:        nn(list(nn(point))),
+			Description: "Search points, as GET /api/points. At least one argument other than domain, latestOnly, limit and offset is required.",
+			Args: []*graphql.Argument{
+				arg("zone", integer, nil, ""),
+				arg("net", integer, nil, ""),
+				arg("node", integer, nil, ""),
+				arg("point", integer, nil, ""),
+				arg("domain", str, nil, "Default: every network."),
+				arg("listSource", str, nil, "A pointlist series, such as z2."),
+				arg("systemName", str, nil, "Substring, at least 2 characters."),
+				arg("location", str, nil, "Substring, at least 2 characters."),
+				arg("sysopName", str, nil, "Substring, at least 2 characters."),
+				arg("dateFrom", str, nil, "YYYY-MM-DD"),
+				arg("dateTo", str, nil, "YYYY-MM-DD"),
+				arg("latestOnly", boolean, nil, ""),
+				arg("limit", integer, 100, "At most 500."),
+				arg("offset", integer, 0, ""),
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				a := &graphqlArgs{p: p}
+				filter := database.PointFilter{
+					Zone:       a.int("zone"),
+					Net:        a.int("net"),
+					Node:       a.int("node"),
+					PointNum:   a.int("point"),
+					ListSource: a.text("listSource", 1),
+					SystemName: a.text("systemName", 2),
+					Location:   a.text("location", 2),
+					SysopName:  a.text("sysopName", 2),
+					DateFrom:   a.date("dateFrom"),
+					DateTo:     a.date("dateTo"),
+				}
+				if a.err != nil {
+					return nil, a.err
+				}
+				if !a.constrained {
+					return nil, errors.New("Search requires at least one specific constraint (zone, net, node, point, systemName, location, sysopName, listSource, or a date range)")
+				}
+				if filter.ListSource != nil {
+					source := strings.ToLower(*filter.ListSource)
+					filter.ListSource = &source
+				}
+				filter.Domain = a.domain()
+				if latest, ok := p.Bool("latestOnly"); ok {
+					filter.LatestOnly = &latest
+				}
+				filter.Limit, filter.Offset = a.page(100, 500)
+				points, err := st.SearchPoints(p.Context, filter)
+				if err != nil {
+					return nil, graphqlStorageError("Search failed", err)
+				}
+				return points, nil
+			},
+		},
+		{
+			Name: "networks",
+			Type: nn(list(nn(network))),
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				networks, err := st.GetDomains(p.Context)
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get networks", err)
+				}
+				return networks, nil
+			},
+		},
+		{
+			Name:        "stats",
+			Type:        stats,
+			Description: "Counts over the nodelist nearest to date, or the latest.",
+			Args: []*graphql.Argument{
+				arg("date", str, nil, "YYYY-MM-DD"),
+				arg("domain", str, database.DefaultDomain, ""),
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				a := &graphqlArgs{p: p}
+				requested := a.date("date")
+				if a.err != nil {
+					return nil, a.err
+				}
+				domain := database.DefaultDomain
+				if d := a.domain(); d != nil {
+					domain = *d
+				}
+				var date time.Time
+				var err error
+				if requested != nil {
+					date, err = st.GetNearestAvailableDate(p.Context, *requested, domain)
+					if err != nil {
+						return nil, graphqlStorageError("Failed to find available date", err)
+					}
+				} else {
+					date, err = st.GetLatestStatsDate(p.Context, domain)
+					if err != nil {
+						return nil, graphqlStorageError("Failed to get latest date", err)
+					}
+				}
+				result, err := st.GetStats(p.Context, date, domain)
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get statistics", err)
+				}
+				return result, nil
+			},
+		},
+		{
+			Name:        "statsDates",
+			Type:        strs,
+			Description: "Every nodelist date with statistics, as YYYY-MM-DD.",
+			Args:        []*graphql.Argument{arg("domain", str, database.DefaultDomain, "")},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				domain := database.DefaultDomain
+				if d := (&graphqlArgs{p: p}).domain(); d != nil {
+					domain = *d
+				}
+				dates, err := st.GetAvailableDates(p.Context, domain)
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get available dates", err)
+				}
+				out := make([]string, len(dates))
+				for i, d := range dates {
+					out[i] = d.Format("2006-01-02")
+				}
+				return out, nil
+			},
+		},
+		{
+			Name: "sysops",
+			Type: nn(list(nn(sysop))),
+			Args: []*graphql.Argument{
+				arg("name", str, nil, "Substring of the sysop name."),
+				arg("limit", integer, 50, "At most 200."),
+				arg("offset", integer, 0, ""),
+			},
+			Resolve: func(p graphql.ResolveParams) (any, error) {
+				name, _ := p.String("name")
+				limit, offset := (&graphqlArgs{p: p}).page(50, 200)
+				sysops, err := st.GetUniqueSysops(p.Context, name, limit, offset)
+				if err != nil {
+					return nil, graphqlStorageError("Failed to get sysops", err)
+				}
+				return sysops, nil
+			},
+		},
+	}}
+
+	schema, err := graphql.NewSchema(graphql.SchemaConfig{
+		Query:       query,
+		MaxDepth:    graphqlMaxDepth,
+		MaxResolves: graphqlMaxResolves,
+	})
+	if err != nil {
+		// The schema is fixed at compile time; TestGraphQLSchemaBuilds keeps
+		// this unreachable.
+		panic(err)
+	}
+	api.schema = schema
+	return api
+}
@@ -0,0 +1,197 @@
+package api
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/querybudget"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// graphqlOps adds the test-result readers to fakeOps and records the
+// deadline each storage call ran under.
+type graphqlOps struct {
+	*fakeOps
+
+	tests     []storage.NodeTestResult
+	testsDays int
+	reach     *storage.NodeReachabilityStats
+
+	deadlines []time.Duration
+}
+
+func (g *graphqlOps) GetNodes(ctx context.Context, filter database.NodeFilter) ([]database.Node, error) {
+	g.record(ctx)
+	return g.fakeOps.GetNodes(ctx, filter)
+}
+
+func (g *graphqlOps) GetNodeTestHistory(ctx context.Context, zone, net, node int, days int, domain string) ([]storage.NodeTestResult, error) {
+	g.record(ctx)
+	g.testsDays = days
+	return g.tests, nil
+}
+
+func (g *graphqlOps) GetNodeReachabilityStats(ctx context.Context, zone, net, node int, days int, domain string) (*storage.NodeReachabilityStats, error) {
+	return g.reach, nil
+}
+
+func (g *graphqlOps) record(ctx context.Context) {
+	var left time.Duration
+	if dl, ok := ctx.Deadline(); ok {
+		left = time.Until(dl)
+	}
+	g.deadlines = append(g.deadlines, left)
+}
+
+// postGraphQL sends a query through the real router.
+func postGraphQL(t *testing.T, s *Server, query string, vars map[string]any) (*httptest.ResponseRecorder, map[string]any) {
+	t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": vars})
+	rec := httptest.NewRecorder()
+	s.SetupRouter().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/graphql", bytes.NewReader(body)))
+	var out map[string]any
+	if err := json.Unmarshal(rec.Body.Bytes(), &out); err != nil {
+		t.Fatalf("response is not JSON: %s", rec.Body.String())
+	}
+	return rec, out
+}
+
+func TestGraphQLSchemaBuilds(t *testing.T) {
+	rec, _ := call(t, &fakeOps{}, "GET", "/api/graphql/schema")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	for _, want := range []string{"type Query {", "type Node {", "testResults(days: Int = 30): [TestResult!]!", "scalar JSON"} {
+		if !strings.Contains(rec.Body.String(), want) {
+			t.Errorf("schema lacks %q", want)
+		}
+	}
+}
+
+// TestGraphQLFollowsTheGraph walks node -> history -> test results ->
+// geolocation in one request, which is the point of the endpoint.
+func TestGraphQLFollowsTheGraph(t *testing.T) {
+	node := sampleNode()
+	ops := &graphqlOps{
+		fakeOps: &fakeOps{nodes: []database.Node{node}, history: []database.Node{node, node}},
+		tests: []storage.NodeTestResult{{
+			TestTime: time.Date(2026, 7, 21, 12, 0, 0, 0, time.UTC),
+			Country:  "Russia", CountryCode: "RU", ASN: 4200000000,
+			BinkPTested: true, BinkPSuccess: true, BinkPVersion: "binkd/1.1a-115",
+			BinkPIPv6Tested: true, BinkPIPv6Error: "no route to host",
+		}},
+	}
+	rec, _ := postGraphQL(t, New(ops), `query($net: Int!) {
+		node(zone: 2, net: $net, node: 100) {
+			address
+			history { nodelistDate }
+			testResults(days: 7) {
+				testTime
+				geolocation { countryCode asn }
+				binkp { success software ipv6 { tested error } }
+				ftp { tested }
+			}
+		}
+	}`, map[string]any{"net": 5001})
+
+	// Fields come back in query order.
+	got := strings.TrimSpace(rec.Body.String())
+	want := `{"data":{"node":{"address":"2:5001/100",` +
+		`"history":[{"nodelistDate":"2026-07-20T00:00:00Z"},{"nodelistDate":"2026-07-20T00:00:00Z"}],` +
+		`"testResults":[{"testTime":"2026-07-21T12:00:00Z","geolocation":{"countryCode":"RU","asn":4200000000},` +
+		`"binkp":{"success":true,"software":"binkd/1.1a-115","ipv6":{"tested":true,"error":"no route to host"}},"ftp":{"tested":false}}]}}}`
+	if got != want {
+		t.Errorf("got  %s\nwant %s", got, want)
+	}
+	if ops.testsDays != 7 {
+		t.Errorf("test history read over %d days, want 7", ops.testsDays)
+	}
+}
+
+// TestGraphQLPicksTheBudget pins that a query touching test results runs
+// under the analytics budget and any other under the read budget.
+func TestGraphQLPicksTheBudget(t *testing.T) {
+	for _, tc := range []struct {
+		query string
+		want  time.Duration
+	}{
+		{`{ node(zone: 2, net: 5001, node: 100) { systemName } }`, time.Minute},
+		{`{ node(zone: 2, net: 5001, node: 100) { testResults { testTime } } }`, time.Hour},
+	} {
+		ops := &graphqlOps{fakeOps: &fakeOps{nodes: []database.Node{sampleNode()}}}
+		s := New(ops)
+		s.SetQueryBudgets(Budgets{
+			Read:      querybudget.New(true, time.Minute, "native"),
+			Analytics: querybudget.New(true, time.Hour, "native"),
+		})
+		if rec, _ := postGraphQL(t, s, tc.query, nil); rec.Code != http.StatusOK {
+			t.Fatalf("%s: status %d: %s", tc.query, rec.Code, rec.Body.String())
+		}
+		if len(ops.deadlines) == 0 {
+			t.Fatalf("%s: no storage call", tc.query)
+		}
+		if left := ops.deadlines[0]; left > tc.want || left < tc.want-10*time.Second {
+			t.Errorf("%s: ran with %v left, want about %v", tc.query, left, tc.want)
+		}
+	}
+}
+
+func TestGraphQLStatuses(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		ops      *fakeOps
+		query    string
+		wantCode int
+		wantErr  string
+	}{
+		{"invalid query", &fakeOps{}, `{ node(zone: 2, net: 5001, node: 100) { sysop } }`, http.StatusBadRequest, `Cannot query field "sysop" on type "Node".`},
+		{"unconstrained search", &fakeOps{}, `{ nodes(limit: 10) { address } }`, http.StatusOK, "Search requires at least one specific constraint"},
+		{"bad date", &fakeOps{}, `{ stats(date: "last week") { totalNodes } }`, http.StatusOK, "date must be a date in YYYY-MM-DD form"},
+		{"storage failure", &fakeOps{nodesErr: fmt.Errorf("clickhouse unavailable")}, `{ nodes(net: 5001) { address } }`, http.StatusOK, "Search failed: clickhouse unavailable"},
+		{"budget exceeded", &fakeOps{nodesErr: fmt.Errorf("query: %w", context.DeadlineExceeded)}, `{ nodes(net: 5001) { address } }`, http.StatusServiceUnavailable, budgetExceededMessage},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			rec, body := postGraphQL(t, New(tc.ops), tc.query, nil)
+			if rec.Code != tc.wantCode {
+				t.Errorf("status = %d, want %d", rec.Code, tc.wantCode)
+			}
+			errs, _ := body["errors"].([]any)
+			if len(errs) == 0 {
+				t.Fatalf("no errors in %s", rec.Body.String())
+			}
+			if msg := errs[0].(map[string]any)["message"].(string); !strings.Contains(msg, tc.wantErr) {
+				t.Errorf("error = %q, want it to contain %q", msg, tc.wantErr)
+			}
+		})
+	}
+}
+
+func TestGraphQLOverGET(t *testing.T) {
+	ops := &fakeOps{nodes: []database.Node{sampleNode()}}
+	target := "/api/graphql?" + url.Values{
+		"query":     {`query($n: Int!) { node(zone: 2, net: 5001, node: $n) { systemName } }`},
+		"variables": {`{"n": 100}`},
+	}.Encode()
+	rec, body := call(t, ops, "GET", target)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d: %s", rec.Code, rec.Body.String())
+	}
+	data, _ := body["data"].(map[string]any)
+	if node, _ := data["node"].(map[string]any); node["systemName"] != "Test_System" {
+		t.Errorf("got %s", rec.Body.String())
+	}
+
+	rec, _ = call(t, ops, "GET", "/api/graphql")
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("missing query: status = %d, want 400", rec.Code)
+	}
+}
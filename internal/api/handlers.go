@@ -3,8 +3,10 @@ package api
 import (
 	"encoding/json"
 	"net/http"
+	"sync"
 	"time"
 
+	"github.com/nodelistdb/internal/apiaccess"
 	"github.com/nodelistdb/internal/querybudget"
 )
 
@@ -16,6 +18,13 @@ type Server struct {
 	cacheStatsHandler http.HandlerFunc
 	ftpStatsHandler   http.HandlerFunc
 	budgets           Budgets
+	access            *apiaccess.Guard // nil: the API is open and unlimited
+	usage             UsageReader
+	subscriptions     SubscriptionStore // nil: no /api/subscriptions
+	networks          []string          // configured networks, for subscriptions
+
+	gqlOnce sync.Once
+	gql     *graphqlAPI
 }
 
 // Budgets are the per-route-group query deadlines. The zero value is no
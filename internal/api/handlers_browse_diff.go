@@ -0,0 +1,112 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/nodelistdb/internal/storage"
+)
+
+// BrowseDiffHandler compares two nodelists of a network over one part of the
+// hierarchy: every zone, the regions of a zone, the nets of a region, or the
+// nodes of a net, which is also where each node's field changes are listed.
+// GET /api/browse/diff?from=1996-03-01&to=1997-03-07&zone=2&region=50
+//
+// Both dates snap to the nearest nodelist. to defaults to the latest and
+// from to the nodelist before to; they are swapped if given the wrong way
+// round.
+func (s *Server) BrowseDiffHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	domain := domainOrDefault(r)
+
+	scope, err := parseBrowseDiffScope(r)
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fromDate, fromGiven, err := parseDateParam(query, "from")
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	toStr := query.Get("to")
+	if _, _, err := parseDateParam(query, "to"); err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	to, ok := s.resolveStatsDate(w, r, toStr, domain)
+	if !ok {
+		return
+	}
+	from := to
+	if fromGiven {
+		if from, err = s.storage.GetNearestAvailableDate(r.Context(), fromDate, domain); err != nil {
+			writeStorageErrorf(w, "Failed to find available date", err)
+			return
+		}
+	} else {
+		dates, err := s.storage.GetAvailableDates(r.Context(), domain)
+		if err != nil {
+			writeStorageErrorf(w, "Failed to get available dates", err)
+			return
+		}
+		if prev, ok := storage.PreviousNodelistDate(dates, to); ok {
+			from = prev
+		}
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+
+	diff, err := s.storage.GetBrowseDiff(r.Context(), from, to, scope, domain)
+	if err != nil {
+		writeStorageErrorf(w, "Failed to compare nodelists", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"diff":           diff,
+		"domain":         domain,
+		"requested_from": query.Get("from"),
+		"requested_to":   toStr,
+		"from":           from.Format(time.DateOnly),
+		"to":             to.Format(time.DateOnly),
+	}
+
+	WriteJSONSuccess(w, response)
+}
+
+// parseBrowseDiffScope picks the diff level from the address parameters
+// given: none for zones, zone for its regions, zone and region for that
+// region's nets (region=0 for nets outside any region), and zone and net for
+// that net's nodes.
+func parseBrowseDiffScope(r *http.Request) (storage.BrowseDiffScope, error) {
+	query := r.URL.Query()
+	zone, hasZone, err := parseIntParam(query, "zone")
+	if err != nil {
+		return storage.BrowseDiffScope{}, err
+	}
+	region, hasRegion, err := parseIntParam(query, "region")
+	if err != nil {
+		return storage.BrowseDiffScope{}, err
+	}
+	net, hasNet, err := parseIntParam(query, "net")
+	if err != nil {
+		return storage.BrowseDiffScope{}, err
+	}
+
+	switch {
+	case hasRegion && hasNet:
+		return storage.BrowseDiffScope{}, &ParamError{Field: "net", Value: query.Get("net"), Message: "give region or net, not both"}
+	case (hasRegion || hasNet) && !hasZone:
+		return storage.BrowseDiffScope{}, &ParamError{Field: "zone", Message: "zone is required with region or net"}
+	case hasNet:
+		return storage.BrowseDiffScope{Level: storage.BrowseLevelNodes, Zone: zone, Net: net}, nil
+	case hasRegion:
+		return storage.BrowseDiffScope{Level: storage.BrowseLevelNets, Zone: zone, Region: region}, nil
+	case hasZone:
+		return storage.BrowseDiffScope{Level: storage.BrowseLevelRegions, Zone: zone}, nil
+	}
+	return storage.BrowseDiffScope{Level: storage.BrowseLevelZones}, nil
+}
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -39,9 +40,25 @@ type fakeOps struct {
 	sysops        []storage.SysopInfo
 	sysopsErr     error
 	sysopsFilters []sysopQuery
+	fuzzySysops   []sysopQuery
 	sysopNodes    []database.Node
 	sysopNodesErr error
 	sysopNodesFor string
+
+	identities    map[string]*storage.SysopIdentity
+	identityAsked string
+	nodeSysops    []storage.SysopIdentityRef
+
+	tenancies []storage.NodeTenancy
+
+	nodeMap     *storage.NodeMap
+	mapDate     time.Time
+	nearestDate time.Time // zero answers every date as held
+
+	dates     []time.Time
+	diff      *storage.BrowseDiff
+	diffAsked []time.Time // from, to
+	diffScope storage.BrowseDiffScope
 }
 
 // sysopQuery records how a sysop listing was asked for.
@@ -55,11 +72,25 @@ func (f *fakeOps) GetUniqueSysops(ctx context.Context, nameFilter string, limit,
 	return f.sysops, f.sysopsErr
 }
 
+func (f *fakeOps) FuzzySearchSysops(ctx context.Context, name string, limit, offset int) ([]storage.SysopInfo, error) {
+	f.fuzzySysops = append(f.fuzzySysops, sysopQuery{name, limit, offset})
+	return f.sysops, f.sysopsErr
+}
+
 func (f *fakeOps) GetNodesBySysop(ctx context.Context, sysopName string, limit int) ([]database.Node, error) {
 	f.sysopNodesFor = sysopName
 	return f.sysopNodes, f.sysopNodesErr
 }
 
+func (f *fakeOps) GetSysopIdentity(ctx context.Context, id string) (*storage.SysopIdentity, error) {
+	f.identityAsked = id
+	return f.identities[id], nil
+}
+
+func (f *fakeOps) GetNodeSysopIdentities(ctx context.Context, zone, net, node int, domain string) ([]storage.SysopIdentityRef, error) {
+	return f.nodeSysops, nil
+}
+
 func (f *fakeOps) GetNodes(ctx context.Context, filter database.NodeFilter) ([]database.Node, error) {
 	f.nodesFilters = append(f.nodesFilters, filter)
 	return f.nodes, f.nodesErr
@@ -80,6 +111,36 @@ func (f *fakeOps) GetNodeChanges(ctx context.Context, zone, net, node int, domai
 	return f.changes, f.changesErr
 }
 
+func (f *fakeOps) GetNodeTenancies(ctx context.Context, zone, net, node int, domain string) ([]storage.NodeTenancy, error) {
+	return f.tenancies, nil
+}
+
+func (f *fakeOps) GetNodeMap(ctx context.Context, date time.Time, domain string) (*storage.NodeMap, error) {
+	f.mapDate = date
+	return f.nodeMap, nil
+}
+
+func (f *fakeOps) GetNearestAvailableDate(ctx context.Context, requestedDate time.Time, domain string) (time.Time, error) {
+	if f.nearestDate.IsZero() {
+		return requestedDate, nil
+	}
+	return f.nearestDate, nil
+}
+
+func (f *fakeOps) GetAvailableDates(ctx context.Context, domain string) ([]time.Time, error) {
+	return f.dates, nil
+}
+
+func (f *fakeOps) GetBrowseDiff(ctx context.Context, from, to time.Time, scope storage.BrowseDiffScope, domain string) (*storage.BrowseDiff, error) {
+	f.diffAsked = []time.Time{from, to}
+	f.diffScope = scope
+	return f.diff, nil
+}
+
+func (f *fakeOps) GetLatestStatsDate(ctx context.Context, domain string) (time.Time, error) {
+	return f.nearestDate, nil
+}
+
 func (f *fakeOps) GetNodeDomains(ctx context.Context, zone, net, node int) ([]string, error) {
 	return f.nodeDomains, nil
 }
@@ -387,6 +448,24 @@ func TestSysopEndpoints(t *testing.T) {
 		t.Errorf("limit = %d, want it capped at 200", last.limit)
 	}
 
+	// fuzzy=1 takes the ranked search instead, which needs a name.
+	rec, body = call(t, ops, "GET", "/api/sysops?name=Sergei+Ivanov&fuzzy=1&limit=5")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("fuzzy: status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if len(ops.fuzzySysops) != 1 || ops.fuzzySysops[0] != (sysopQuery{"Sergei Ivanov", 5, 0}) {
+		t.Errorf("fuzzy search saw %+v, want the name and limit from the query", ops.fuzzySysops)
+	}
+	if filter, _ := body["filter"].(map[string]interface{}); filter["fuzzy"] != true {
+		t.Errorf("filter = %v, want fuzzy reported", body["filter"])
+	}
+	if rec, _ := call(t, ops, "GET", "/api/sysops?fuzzy=1"); rec.Code != http.StatusBadRequest {
+		t.Errorf("fuzzy without a name: status = %d, want 400", rec.Code)
+	}
+	if rec, _ := call(t, ops, "GET", "/api/sysops?fuzzy=1&name="+strings.Repeat("a", 2000)); rec.Code != http.StatusBadRequest || len(ops.fuzzySysops) != 1 {
+		t.Errorf("fuzzy with an overlong name: status = %d, searches %d; want 400 before any search", rec.Code, len(ops.fuzzySysops))
+	}
+
 	// A percent-encoded name reaches storage decoded.
 	rec, body = call(t, ops, "GET", "/api/sysops/John%20Smith/nodes")
 	if rec.Code != http.StatusOK {
@@ -400,6 +479,171 @@ func TestSysopEndpoints(t *testing.T) {
 	}
 }
 
+func TestSysopIdentityEndpoints(t *testing.T) {
+	merged := &storage.SysopIdentity{ID: "aaaaaaaaaaaa", Name: "Sergey_Ivanov", NodeCount: 2}
+	ops := &fakeOps{
+		identities: map[string]*storage.SysopIdentity{
+			"aaaaaaaaaaaa": merged,
+			"bbbbbbbbbbbb": merged, // retired by a merge
+		},
+		nodeDomains: []string{"fidonet"},
+		nodeSysops:  []storage.SysopIdentityRef{{ID: "aaaaaaaaaaaa", Name: "Sergey_Ivanov"}},
+	}
+
+	rec, body := call(t, ops, "GET", "/api/sysop/bbbbbbbbbbbb")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if body["id"] != "aaaaaaaaaaaa" || body["node_count"] != float64(2) {
+		t.Errorf("body = %v, want the identity the retired ID was merged into", body)
+	}
+
+	if rec, _ := call(t, ops, "GET", "/api/sysop/cccccccccccc"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown ID: status = %d, want 404", rec.Code)
+	}
+	ops.identityAsked = ""
+	for _, bad := range []string{"ABCDEFABCDEF", "abc", "aaaaaaaaaaaaa", "zzzzzzzzzzzz"} {
+		if rec, _ := call(t, ops, "GET", "/api/sysop/"+bad); rec.Code != http.StatusBadRequest {
+			t.Errorf("%q: status = %d, want 400", bad, rec.Code)
+		}
+	}
+	if ops.identityAsked != "" {
+		t.Errorf("storage was asked for malformed ID %q", ops.identityAsked)
+	}
+
+	rec, body = call(t, ops, "GET", "/api/nodes/2/5020/1/sysops")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("node sysops: status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if body["address"] != "2:5020/1" || body["count"] != float64(1) {
+		t.Errorf("node sysops: body = %v", body)
+	}
+
+	// No resolution yet is an empty list, not null.
+	ops.nodeSysops = nil
+	_, body = call(t, ops, "GET", "/api/nodes/2/5020/1/sysops")
+	if list, ok := body["sysops"].([]interface{}); !ok || len(list) != 0 {
+		t.Errorf("sysops = %v, want []", body["sysops"])
+	}
+}
+
+func TestNodeTenanciesEndpoint(t *testing.T) {
+	ops := &fakeOps{
+		nodeDomains: []string{"fidonet"},
+		tenancies: []storage.NodeTenancy{
+			{Domain: "fidonet", Zone: 2, Net: 5020, Node: 113, Tenancy: 1, SysopName: "Boris_Paleev"},
+			{Domain: "fidonet", Zone: 2, Net: 5020, Node: 113, Tenancy: 2, SysopName: "Ivan_Petrov", StartReason: "sysop"},
+		},
+	}
+
+	rec, body := call(t, ops, "GET", "/api/nodes/2/5020/113/tenancies")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if body["address"] != "2:5020/113" || body["count"] != float64(2) {
+		t.Errorf("body = %v", body)
+	}
+	list, _ := body["tenancies"].([]interface{})
+	if len(list) != 2 {
+		t.Fatalf("tenancies = %v", body["tenancies"])
+	}
+	if first, _ := list[0].(map[string]interface{}); first["start_reason"] != nil {
+		t.Errorf("first tenancy carries start_reason %v, want it omitted", first["start_reason"])
+	}
+
+	ops.tenancies = nil
+	if rec, _ := call(t, ops, "GET", "/api/nodes/2/5020/999/tenancies"); rec.Code != http.StatusNotFound {
+		t.Errorf("never-listed node: status = %d, want 404", rec.Code)
+	}
+}
+
+func TestNodeMapEndpoint(t *testing.T) {
+	ops := &fakeOps{
+		nearestDate: time.Date(1995, 6, 2, 0, 0, 0, 0, time.UTC),
+		nodeMap: &storage.NodeMap{
+			Places: []storage.MapPlace{
+				{Place: "Moscow", CountryCode: "RU", Latitude: 55.7558, Longitude: 37.6173, Precision: "city", Nodes: 40},
+			},
+			Countries: []storage.MapCountry{{CountryCode: "RU", Country: "Russia", Nodes: 40}},
+			Located:   40,
+			Unlocated: 3,
+		},
+	}
+
+	rec, body := call(t, ops, "GET", "/api/geo/map?date=1995-06-01")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if !ops.mapDate.Equal(ops.nearestDate) {
+		t.Errorf("map asked for %v, want the nearest nodelist date %v", ops.mapDate, ops.nearestDate)
+	}
+	if body["actual_date"] != "1995-06-02" || body["date_adjusted"] != true || body["domain"] != "fidonet" {
+		t.Errorf("body = %v", body)
+	}
+	m, _ := body["map"].(map[string]interface{})
+	places, _ := m["places"].([]interface{})
+	if len(places) != 1 || m["unlocated"] != float64(3) {
+		t.Errorf("map = %v", body["map"])
+	}
+
+	if rec, _ := call(t, ops, "GET", "/api/geo/map?date=June"); rec.Code != http.StatusBadRequest {
+		t.Errorf("malformed date: status = %d, want 400", rec.Code)
+	}
+}
+
+func TestBrowseDiffEndpoint(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(1997, 3, d, 0, 0, 0, 0, time.UTC) }
+	ops := &fakeOps{
+		dates: []time.Time{day(14), day(7), day(1)},
+		diff:  &storage.BrowseDiff{Level: storage.BrowseLevelNets, Totals: storage.BrowseDiffCounts{Added: 2}},
+	}
+
+	tests := []struct {
+		target   string
+		from, to time.Time
+		scope    storage.BrowseDiffScope
+	}{
+		{"/api/browse/diff?to=1997-03-07&zone=2&region=50", day(1), day(7),
+			storage.BrowseDiffScope{Level: storage.BrowseLevelNets, Zone: 2, Region: 50}},
+		{"/api/browse/diff?from=1997-03-14&to=1997-03-01", day(1), day(14),
+			storage.BrowseDiffScope{Level: storage.BrowseLevelZones}},
+		{"/api/browse/diff?from=1997-03-01&to=1997-03-14&zone=2&net=5020", day(1), day(14),
+			storage.BrowseDiffScope{Level: storage.BrowseLevelNodes, Zone: 2, Net: 5020}},
+		{"/api/browse/diff?to=1997-03-01&zone=1", day(1), day(1),
+			storage.BrowseDiffScope{Level: storage.BrowseLevelRegions, Zone: 1}},
+	}
+	for _, tt := range tests {
+		rec, body := call(t, ops, "GET", tt.target)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("%s: status = %d, want 200: %s", tt.target, rec.Code, rec.Body.String())
+		}
+		if !ops.diffAsked[0].Equal(tt.from) || !ops.diffAsked[1].Equal(tt.to) || ops.diffScope != tt.scope {
+			t.Errorf("%s: compared %v..%v over %+v, want %v..%v over %+v",
+				tt.target, ops.diffAsked[0], ops.diffAsked[1], ops.diffScope, tt.from, tt.to, tt.scope)
+		}
+		if body["from"] != tt.from.Format("2006-01-02") || body["to"] != tt.to.Format("2006-01-02") {
+			t.Errorf("%s: body dates %v..%v", tt.target, body["from"], body["to"])
+		}
+		diff, _ := body["diff"].(map[string]interface{})
+		totals, _ := diff["totals"].(map[string]interface{})
+		if totals["added"] != float64(2) {
+			t.Errorf("%s: diff = %v", tt.target, body["diff"])
+		}
+	}
+
+	for _, target := range []string{
+		"/api/browse/diff?region=50",
+		"/api/browse/diff?zone=2&region=50&net=5020",
+		"/api/browse/diff?zone=two",
+		"/api/browse/diff?from=March",
+		"/api/browse/diff?to=March",
+	} {
+		if rec, _ := call(t, ops, "GET", target); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", target, rec.Code)
+		}
+	}
+}
+
 // TestResponsesAreJSON pins the content type across a representative handler
 // from each family, plus the two error shapes.
 func TestResponsesAreJSON(t *testing.T) {
@@ -1,12 +1,20 @@
 package api
 
 import (
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
+	"sort"
+	"strconv"
+	"time"
 
+	"github.com/go-chi/chi/v5"
+	"github.com/nodelistdb/internal/logging"
 	"github.com/nodelistdb/internal/nodelistfs"
+	"github.com/nodelistdb/internal/parser"
 )
 
 // LatestNodelistAPIHandler returns the newest nodelist of one FTN network:
@@ -56,3 +64,191 @@ func (s *Server) LatestNodelistAPIHandler(w http.ResponseWriter, r *http.Request
 		"download_url": latest.DownloadURL(),
 	})
 }
+
+// NodelistExportHandler rebuilds one network's nodelist for one imported date
+// from the database and serves it in distribution form (CR/LF, trailing ^Z),
+// for the dates the on-disk archive does not have.
+//
+// Dates imported since line numbers were recorded come back byte for byte,
+// as long as the result matches the CRC its header declares. Older dates are
+// approximated from the node rows under a synthesized header with a CRC
+// computed for it. The X-Nodelist-Rebuild header says which one the caller
+// got: exact or approximate. A rebuild that fails its own CRC is served as
+// approximate with the original header, and logged.
+//
+// GET /api/nodelist/{domain}/{date}.txt
+func (s *Server) NodelistExportHandler(w http.ResponseWriter, r *http.Request) {
+	network := nodelistfs.NormalizeNetwork(chi.URLParam(r, "domain"))
+	if !nodelistfs.ValidNetworkName(network) {
+		WriteJSONError(w, "Invalid domain", http.StatusBadRequest)
+		return
+	}
+	date, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		WriteJSONError(w, "date must be in YYYY-MM-DD form", http.StatusBadRequest)
+		return
+	}
+
+	nodes, text, err := s.storage.GetNodelistLines(r.Context(), network, date)
+	if err != nil {
+		writeStorageError(w, "Failed to load nodelist", err)
+		return
+	}
+	if len(nodes) == 0 && len(text) == 0 {
+		WriteJSONError(w, "No nodelist imported for that date", http.StatusNotFound)
+		return
+	}
+
+	dayNumber := date.YearDay()
+	header := parser.SyntheticHeader(nodelistfs.DisplayName(network), date, dayNumber)
+	lines, exact := parser.RebuildNodelist(nodes, text, header)
+
+	rebuild := "approximate"
+	if exact {
+		rebuild = "exact"
+	} else if len(text) > 0 {
+		logging.Warn("Rebuilt nodelist does not match its header CRC",
+			slog.String("domain", network), slog.String("date", date.Format("2006-01-02")))
+	}
+	filename := fmt.Sprintf("%s%03d", nodelistfs.FilePrefix(network), dayNumber)
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("X-Nodelist-Rebuild", rebuild)
+	_ = parser.WriteNodelistLines(w, lines)
+}
+
+// NodelistSegmentHandler serves one region's or one net's part of a nodelist,
+// the segment a coordinator would send up the chain, in distribution form.
+//
+// The segment is assembled from the browse queries rather than from the
+// stored line order, so it comes out in canonical order whatever the source
+// file looked like: for a region, the Region line and the region's
+// independent nodes first, then each net in ascending order; within a net,
+// the Host line and then its nodes by number. Every entry is its verbatim
+// raw_line. Rows stored without one, which only very old imports have, are
+// left out rather than made up.
+//
+// The requested date snaps to the nearest imported one, as the browse pages
+// do; X-Nodelist-Date says which date was served. The header is a synthesized
+// one naming that date, with the CRC computed over the segment itself.
+//
+// GET /api/nodelist/{domain}/{date}/region/{zone}/{region}.txt
+// GET /api/nodelist/{domain}/{date}/net/{zone}/{net}.txt
+func (s *Server) NodelistSegmentHandler(w http.ResponseWriter, r *http.Request) {
+	network := nodelistfs.NormalizeNetwork(chi.URLParam(r, "domain"))
+	if !nodelistfs.ValidNetworkName(network) {
+		WriteJSONError(w, "Invalid domain", http.StatusBadRequest)
+		return
+	}
+	requested, err := time.Parse("2006-01-02", chi.URLParam(r, "date"))
+	if err != nil {
+		WriteJSONError(w, "date must be in YYYY-MM-DD form", http.StatusBadRequest)
+		return
+	}
+	zone, err := strconv.Atoi(chi.URLParam(r, "zone"))
+	if err != nil || zone < 1 {
+		WriteJSONError(w, "Invalid zone", http.StatusBadRequest)
+		return
+	}
+
+	// Exactly one of the two is in the pattern that matched.
+	kind, param := "region", chi.URLParam(r, "region")
+	if param == "" {
+		kind, param = "net", chi.URLParam(r, "net")
+	}
+	number, err := strconv.Atoi(param)
+	if err != nil || number < 0 {
+		WriteJSONError(w, "Invalid "+kind, http.StatusBadRequest)
+		return
+	}
+
+	date, err := s.storage.GetNearestAvailableDate(r.Context(), requested, network)
+	if err != nil {
+		writeStorageError(w, "Failed to find available date", err)
+		return
+	}
+
+	var entries []string
+	var title string
+	if kind == "region" {
+		entries, err = s.regionSegment(r.Context(), date, zone, number, network)
+		title = fmt.Sprintf("Zone %d Region %d", zone, number)
+		if number == 0 {
+			title = fmt.Sprintf("Zone %d (nets with no region)", zone)
+		}
+	} else {
+		entries, err = s.netSegment(r.Context(), date, zone, number, network)
+		title = fmt.Sprintf("Net %d:%d", zone, number)
+	}
+	if err != nil {
+		writeStorageError(w, "Failed to load segment", err)
+		return
+	}
+	if len(entries) == 0 {
+		WriteJSONError(w, "No entries for that segment on that date", http.StatusNotFound)
+		return
+	}
+
+	dayNumber := date.YearDay()
+	lines := make([]string, 0, len(entries)+3)
+	lines = append(lines,
+		parser.SyntheticHeader(nodelistfs.DisplayName(network), date, dayNumber),
+		fmt.Sprintf(";S %s segment, extracted from the nodelist of %s", title, date.Format("2006-01-02")),
+		";S")
+	lines = append(lines, entries...)
+	parser.SetHeaderCRC(lines)
+
+	filename := fmt.Sprintf("%s%d.%03d", kind, number, dayNumber)
+	w.Header().Set("Content-Type", "text/plain")
+	w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", filename))
+	w.Header().Set("X-Nodelist-Date", date.Format("2006-01-02"))
+	_ = parser.WriteNodelistLines(w, lines)
+}
+
+// regionSegment returns the raw lines of every net in one region. The net
+// numbered like the region carries the Region line and the independent nodes
+// and goes first; region 0 has the Zone line in the net numbered like the
+// zone, which goes first for the same reason.
+func (s *Server) regionSegment(ctx context.Context, date time.Time, zone, region int, domain string) ([]string, error) {
+	nets, err := s.storage.GetBrowseNets(ctx, date, zone, region, domain)
+	if err != nil {
+		return nil, err
+	}
+	lead := region
+	if region == 0 {
+		lead = zone
+	}
+	sort.SliceStable(nets, func(i, j int) bool {
+		if (nets[i].Net == lead) != (nets[j].Net == lead) {
+			return nets[i].Net == lead
+		}
+		return nets[i].Net < nets[j].Net
+	})
+
+	var lines []string
+	for _, n := range nets {
+		netLines, err := s.netSegment(ctx, date, zone, n.Net, domain)
+		if err != nil {
+			return nil, err
+		}
+		lines = append(lines, netLines...)
+	}
+	return lines, nil
+}
+
+// netSegment returns the raw lines of one net, coordinator line first: the
+// browse query orders by node number, and the Host, Region or Zone line is
+// node 0.
+func (s *Server) netSegment(ctx context.Context, date time.Time, zone, net int, domain string) ([]string, error) {
+	nodes, err := s.storage.GetBrowseNodes(ctx, date, zone, net, domain)
+	if err != nil {
+		return nil, err
+	}
+	lines := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		if n.RawLine != "" {
+			lines = append(lines, n.RawLine)
+		}
+	}
+	return lines, nil
+}
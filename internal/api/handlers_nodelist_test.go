@@ -1,12 +1,19 @@
 package api
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/parser"
+	"github.com/nodelistdb/internal/storage"
 )
 
 // setupNodelistArchive builds a two-network archive and points NODELIST_PATH at
@@ -115,3 +122,173 @@ func TestLatestNodelistUnknownNetwork(t *testing.T) {
 		t.Errorf("path traversal: status = %d, want 400", code)
 	}
 }
+
+// nodelistLinesOps serves one stored nodelist to the export handler.
+type nodelistLinesOps struct {
+	storage.Operations
+	nodes, text []database.NodelistLine
+}
+
+func (o *nodelistLinesOps) GetNodelistLines(ctx context.Context, domain string, date time.Time) ([]database.NodelistLine, []database.NodelistLine, error) {
+	if domain != "fidonet" || date.Format("2006-01-02") != "2022-07-01" {
+		return nil, nil, nil
+	}
+	return o.nodes, o.text, nil
+}
+
+// TestNodelistExportRebuildsFile drives the export through the real router, so
+// the {date}.txt pattern is covered along with the rebuild itself.
+func TestNodelistExportRebuildsFile(t *testing.T) {
+	file := parser.SetHeaderCRC([]string{
+		";A FidoNet Nodelist for Friday, July 1, 2022 -- Day number 182 : 00000",
+		";S a comment",
+		"Zone,2,Europe,Somewhere,Zone_Coordinator,-Unpublished-,300,CM",
+		",1,Node_One,Moscow,Sysop_One,-Unpublished-,300,IBN",
+	})
+	ops := &nodelistLinesOps{
+		nodes: []database.NodelistLine{
+			{LineNumber: 3, RawLine: file[2]},
+			{LineNumber: 4, RawLine: file[3]},
+		},
+		text: []database.NodelistLine{
+			{LineNumber: 1, RawLine: file[0]},
+			{LineNumber: 2, RawLine: file[1]},
+		},
+	}
+	router := New(ops).SetupRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/nodelist/fidonet/2022-07-01.txt", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Nodelist-Rebuild"); got != "exact" {
+		t.Errorf("X-Nodelist-Rebuild = %q, want exact", got)
+	}
+	lines, err := parser.ReadNodelistLines(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(lines) != 4 || lines[1] != ";S a comment" || lines[3] != ops.nodes[1].RawLine {
+		t.Fatalf("served lines = %q", lines)
+	}
+	if err := parser.VerifyNodelistCRC("export", lines); err != nil {
+		t.Errorf("served header CRC: %v", err)
+	}
+
+	for path, want := range map[string]int{
+		"/api/nodelist/fidonet/2022-07-08.txt":  http.StatusNotFound,
+		"/api/nodelist/fidonet/07-01-2022.txt":  http.StatusBadRequest,
+		"/api/nodelist/bad..net/2022-07-01.txt": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+
+	// A node row lost on import: the rebuild no longer matches the header's
+	// CRC, and the header must not be rewritten to hide that.
+	ops.nodes = ops.nodes[:1]
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/nodelist/fidonet/2022-07-01.txt", nil))
+	if got := rec.Header().Get("X-Nodelist-Rebuild"); got != "approximate" {
+		t.Errorf("rebuild missing a line: X-Nodelist-Rebuild = %q, want approximate", got)
+	}
+	if lines, _ = parser.ReadNodelistLines(rec.Body); len(lines) != 3 || lines[0] != file[0] {
+		t.Errorf("rebuild missing a line: served %q, want the original header kept", lines)
+	}
+}
+
+// segmentOps serves a two-net region 50 on 2022-07-01, with the nets listed
+// in the wrong order to make the handler put the region's own net first.
+type segmentOps struct {
+	storage.Operations
+}
+
+func (segmentOps) GetNearestAvailableDate(ctx context.Context, requested time.Time, domain string) (time.Time, error) {
+	return time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC), nil
+}
+
+func (segmentOps) GetBrowseNets(ctx context.Context, date time.Time, zone, region int, domain string) ([]storage.BrowseNet, error) {
+	if zone != 2 || region != 50 {
+		return nil, nil
+	}
+	return []storage.BrowseNet{{Net: 5020}, {Net: 50}}, nil
+}
+
+func (segmentOps) GetBrowseNodes(ctx context.Context, date time.Time, zone, net int, domain string) ([]database.Node, error) {
+	switch {
+	case zone == 2 && net == 50:
+		return []database.Node{
+			{Node: 0, RawLine: "Region,50,Russia,Moscow,Region_Coordinator,-Unpublished-,300,CM"},
+			{Node: 1, RawLine: ",1,Independent,Moscow,Sysop_One,-Unpublished-,300,IBN"},
+		}, nil
+	case zone == 2 && net == 5020:
+		return []database.Node{
+			{Node: 0, RawLine: "Host,5020,Moscow_Net,Moscow,Host_Sysop,-Unpublished-,300,CM"},
+			{Node: 1, RawLine: ",1,Node_One,Moscow,Sysop_Two,-Unpublished-,300,IBN"},
+			{Node: 2}, // stored before raw_line existed
+		}, nil
+	}
+	return nil, nil
+}
+
+func TestNodelistSegmentOrdersRegionAndNets(t *testing.T) {
+	router := New(segmentOps{}).SetupRouter()
+
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/nodelist/fidonet/2022-07-03/region/2/50.txt", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	if got := rec.Header().Get("X-Nodelist-Date"); got != "2022-07-01" {
+		t.Errorf("X-Nodelist-Date = %q, want 2022-07-01", got)
+	}
+	if got := rec.Header().Get("Content-Disposition"); got != `attachment; filename="region50.182"` {
+		t.Errorf("Content-Disposition = %q", got)
+	}
+	lines, err := parser.ReadNodelistLines(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := parser.VerifyNodelistCRC("segment", lines); err != nil {
+		t.Errorf("segment header CRC: %v", err)
+	}
+	if date, day, err := parser.New(false).HeaderDate(lines[0]); err != nil || day != 182 || date.Format("2006-01-02") != "2022-07-01" {
+		t.Errorf("HeaderDate(%q) = %v, %d, %v", lines[0], date, day, err)
+	}
+
+	var entries []string
+	for _, l := range lines {
+		if !strings.HasPrefix(l, ";") {
+			f := strings.SplitN(l, ",", 3)
+			entries = append(entries, f[0]+","+f[1])
+		}
+	}
+	want := []string{"Region,50", ",1", "Host,5020", ",1"}
+	if strings.Join(entries, " ") != strings.Join(want, " ") {
+		t.Errorf("segment entries = %q, want %q", entries, want)
+	}
+
+	rec = httptest.NewRecorder()
+	router.ServeHTTP(rec, httptest.NewRequest("GET", "/api/nodelist/fidonet/2022-07-01/net/2/5020.txt", nil))
+	lines, _ = parser.ReadNodelistLines(rec.Body)
+	if rec.Code != http.StatusOK || len(lines) != 5 || !strings.HasPrefix(lines[3], "Host,5020") {
+		t.Errorf("net segment: status %d, lines %q", rec.Code, lines)
+	}
+
+	for path, want := range map[string]int{
+		"/api/nodelist/fidonet/2022-07-01/net/2/9999.txt":   http.StatusNotFound,
+		"/api/nodelist/fidonet/2022-07-01/region/x/50.txt":  http.StatusBadRequest,
+		"/api/nodelist/fidonet/01-07-2022/region/2/50.txt":  http.StatusBadRequest,
+		"/api/nodelist/bad..net/2022-07-01/region/2/50.txt": http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, httptest.NewRequest("GET", path, nil))
+		if rec.Code != want {
+			t.Errorf("%s: status = %d, want %d", path, rec.Code, want)
+		}
+	}
+}
@@ -161,6 +161,33 @@ func (s *Server) GetNodeChangesHandler(w http.ResponseWriter, r *http.Request) {
 	WriteJSONSuccess(w, response)
 }
 
+// GetNodeTenanciesHandler returns a node's history split by occupant: one
+// entry per sysop who ran the address, oldest first.
+// GET /api/nodes/{zone}/{net}/{node}/tenancies
+func (s *Server) GetNodeTenanciesHandler(w http.ResponseWriter, r *http.Request) {
+	zone, net, node, _, ok := parse4DPathParams(w, r, false)
+	if !ok {
+		return
+	}
+
+	domain, availableDomains := s.resolveNodeDomain(r, zone, net, node)
+	tenancies, err := s.storage.GetNodeTenancies(r.Context(), zone, net, node, domain)
+	if err != nil {
+		writeStorageErrorf(w, "Failed to get node tenancies", err)
+		return
+	}
+	if len(tenancies) == 0 {
+		WriteJSONError(w, "Node not found", http.StatusNotFound)
+		return
+	}
+
+	response := addressEnvelope(zone, net, node, -1, domain, availableDomains)
+	response["tenancies"] = tenancies
+	response["count"] = len(tenancies)
+
+	WriteJSONSuccess(w, response)
+}
+
 // GetNodeTimelineHandler returns timeline data for visualization.
 // GET /api/nodes/{zone}/{net}/{node}/timeline
 func (s *Server) GetNodeTimelineHandler(w http.ResponseWriter, r *http.Request) {
@@ -9,32 +9,10 @@ import (
 // GET /api/stats?date=2023-01-01&domain=fidonet
 func (s *Server) StatsHandler(w http.ResponseWriter, r *http.Request) {
 	domain := domainOrDefault(r)
-
-	// Parse date parameter
 	dateStr := r.URL.Query().Get("date")
-	var date time.Time
-	var err error
-	var actualDate time.Time
-
-	if dateStr != "" {
-		date, err = time.Parse("2006-01-02", dateStr)
-		if err != nil {
-			WriteJSONError(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
-			return
-		}
-		// Find the nearest available date
-		actualDate, err = s.storage.GetNearestAvailableDate(r.Context(), date, domain)
-		if err != nil {
-			writeStorageErrorf(w, "Failed to find available date", err)
-			return
-		}
-	} else {
-		// Default to latest available date
-		actualDate, err = s.storage.GetLatestStatsDate(r.Context(), domain)
-		if err != nil {
-			writeStorageErrorf(w, "Failed to get latest date", err)
-			return
-		}
+	actualDate, ok := s.resolveStatsDate(w, r, dateStr, domain)
+	if !ok {
+		return
 	}
 
 	// Get statistics for the actual date
@@ -56,6 +34,62 @@ func (s *Server) StatsHandler(w http.ResponseWriter, r *http.Request) {
 	WriteJSONSuccess(w, response)
 }
 
+// NodeMapHandler places one nodelist's nodes by their geocoded Location
+// field, for any date the database holds.
+// GET /api/geo/map?date=1995-06-02&domain=fidonet
+func (s *Server) NodeMapHandler(w http.ResponseWriter, r *http.Request) {
+	domain := domainOrDefault(r)
+	dateStr := r.URL.Query().Get("date")
+	actualDate, ok := s.resolveStatsDate(w, r, dateStr, domain)
+	if !ok {
+		return
+	}
+
+	nodeMap, err := s.storage.GetNodeMap(r.Context(), actualDate, domain)
+	if err != nil {
+		writeStorageErrorf(w, "Failed to get node map", err)
+		return
+	}
+
+	response := map[string]interface{}{
+		"map":            nodeMap,
+		"domain":         domain,
+		"requested_date": dateStr,
+		"actual_date":    actualDate.Format("2006-01-02"),
+		"date_adjusted":  dateStr != "" && actualDate.Format("2006-01-02") != dateStr,
+	}
+
+	WriteJSONSuccess(w, response)
+}
+
+// resolveStatsDate turns the date parameter into the nodelist date to
+// answer for: the nearest one held, or the latest when none is given. On
+// failure it writes the error response and returns false.
+func (s *Server) resolveStatsDate(w http.ResponseWriter, r *http.Request, dateStr, domain string) (time.Time, bool) {
+	if dateStr == "" {
+		// Default to latest available date
+		actualDate, err := s.storage.GetLatestStatsDate(r.Context(), domain)
+		if err != nil {
+			writeStorageErrorf(w, "Failed to get latest date", err)
+			return time.Time{}, false
+		}
+		return actualDate, true
+	}
+
+	date, err := time.Parse("2006-01-02", dateStr)
+	if err != nil {
+		WriteJSONError(w, "Invalid date format. Use YYYY-MM-DD", http.StatusBadRequest)
+		return time.Time{}, false
+	}
+	// Find the nearest available date
+	actualDate, err := s.storage.GetNearestAvailableDate(r.Context(), date, domain)
+	if err != nil {
+		writeStorageErrorf(w, "Failed to find available date", err)
+		return time.Time{}, false
+	}
+	return actualDate, true
+}
+
 // NetworksHandler lists the FTN networks present in the database with their
 // latest nodelist date and node count.
 // GET /api/networks
@@ -0,0 +1,173 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+
+	"github.com/nodelistdb/internal/apiaccess"
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/notify"
+)
+
+// Node change subscriptions registered by API key holders. The parser sends
+// to them after each import, alongside the operator's subscriptions file
+// (see internal/notify). A subscription belongs to the key that registered
+// it: a key sees and deletes only its own, an admin key all of them.
+
+// maxSubscriptionBody bounds a POST /api/subscriptions body.
+const maxSubscriptionBody = 64 << 10
+
+// SubscriptionStore keeps the subscriptions registered through the API;
+// *storage.Storage provides it.
+type SubscriptionStore interface {
+	GetNotificationSubscriptions(ctx context.Context) ([]database.NotificationSubscription, error)
+	InsertNotificationSubscription(ctx context.Context, sub database.NotificationSubscription) error
+}
+
+// SetSubscriptionStore registers /api/subscriptions over store; domains are
+// checked against networks. The routes need API keys to tell owners apart,
+// so they are only registered together with SetAccessGuard. It must be
+// called before SetupRouter.
+func (s *Server) SetSubscriptionStore(store SubscriptionStore, networks []string) {
+	s.subscriptions = store
+	s.networks = networks
+}
+
+// subscriptionCaller returns the key a subscriptions request is made with,
+// or answers 401 and returns nil.
+func subscriptionCaller(w http.ResponseWriter, r *http.Request) *apiaccess.Key {
+	caller, _ := callerFromContext(r.Context())
+	if caller.Key == nil {
+		WriteJSONError(w, "an API key is required to manage subscriptions", http.StatusUnauthorized)
+		return nil
+	}
+	return caller.Key
+}
+
+// visibleSubscriptions returns the stored subscriptions key may see, secrets
+// blanked out.
+func (s *Server) visibleSubscriptions(ctx context.Context, key *apiaccess.Key) ([]notify.Subscription, error) {
+	rows, err := s.subscriptions.GetNotificationSubscriptions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	subs := []notify.Subscription{}
+	for _, row := range rows {
+		if row.Owner != key.ID && !key.Admin {
+			continue
+		}
+		sub := notify.SubscriptionFromStored(row)
+		sub.Secret = ""
+		subs = append(subs, sub)
+	}
+	return subs, nil
+}
+
+// ListSubscriptionsHandler lists the caller's subscriptions, or every one
+// for an admin key.
+// GET /api/subscriptions
+func (s *Server) ListSubscriptionsHandler(w http.ResponseWriter, r *http.Request) {
+	key := subscriptionCaller(w, r)
+	if key == nil {
+		return
+	}
+	subs, err := s.visibleSubscriptions(r.Context(), key)
+	if err != nil {
+		writeStorageErrorf(w, "Failed to get subscriptions", err)
+		return
+	}
+	WriteJSONSuccess(w, map[string]interface{}{
+		"subscriptions": subs,
+		"count":         len(subs),
+	})
+}
+
+// CreateSubscriptionHandler registers a subscription for the caller's key.
+// The ID is assigned here; the body is a subscription as in the
+// subscriptions file.
+// POST /api/subscriptions
+func (s *Server) CreateSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	key := subscriptionCaller(w, r)
+	if key == nil {
+		return
+	}
+
+	var sub notify.Subscription
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxSubscriptionBody))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&sub); err != nil {
+		WriteJSONError(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	id, err := notify.NewSubscriptionID()
+	if err != nil {
+		WriteJSONError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sub.ID, sub.Owner = id, key.ID
+	if err := sub.Validate(s.networks); err != nil {
+		WriteJSONError(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rows, err := s.subscriptions.GetNotificationSubscriptions(r.Context())
+	if err != nil {
+		writeStorageErrorf(w, "Failed to get subscriptions", err)
+		return
+	}
+	owned := 0
+	for _, row := range rows {
+		if row.Owner == key.ID {
+			owned++
+		}
+	}
+	if owned >= notify.MaxSubscriptionsPerKey {
+		WriteJSONError(w, fmt.Sprintf("API key %s already has %d subscriptions", key.ID, owned), http.StatusConflict)
+		return
+	}
+
+	if err := s.subscriptions.InsertNotificationSubscription(r.Context(), sub.Stored()); err != nil {
+		writeStorageErrorf(w, "Failed to store subscription", err)
+		return
+	}
+	sub.Secret = ""
+	WriteJSON(w, sub, http.StatusCreated)
+}
+
+// DeleteSubscriptionHandler deletes one of the caller's subscriptions; an
+// admin key may delete any. Another key's subscription is reported as not
+// found, so IDs cannot be probed.
+// DELETE /api/subscriptions/{id}
+func (s *Server) DeleteSubscriptionHandler(w http.ResponseWriter, r *http.Request) {
+	key := subscriptionCaller(w, r)
+	if key == nil {
+		return
+	}
+	id := chi.URLParam(r, "id")
+
+	subs, err := s.visibleSubscriptions(r.Context(), key)
+	if err != nil {
+		writeStorageErrorf(w, "Failed to get subscriptions", err)
+		return
+	}
+	for _, sub := range subs {
+		if sub.ID != id {
+			continue
+		}
+		row := database.NotificationSubscription{ID: sub.ID, Owner: sub.Owner, Domain: sub.Domain, Deleted: true}
+		if err := s.subscriptions.InsertNotificationSubscription(r.Context(), row); err != nil {
+			writeStorageErrorf(w, "Failed to delete subscription", err)
+			return
+		}
+		WriteJSONSuccess(w, map[string]interface{}{
+			"status":  "ok",
+			"message": "Subscription " + id + " deleted",
+		})
+		return
+	}
+	WriteJSONError(w, "subscription "+id+" not found", http.StatusNotFound)
+}
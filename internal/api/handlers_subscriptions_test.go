@@ -0,0 +1,156 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/nodelistdb/internal/apiaccess"
+	"github.com/nodelistdb/internal/database"
+)
+
+// fakeSubscriptions keeps rows the way notification_subscriptions does: the
+// newest row per ID wins.
+type fakeSubscriptions struct {
+	rows []database.NotificationSubscription
+}
+
+func (f *fakeSubscriptions) GetNotificationSubscriptions(context.Context) ([]database.NotificationSubscription, error) {
+	latest := map[string]database.NotificationSubscription{}
+	var order []string
+	for _, row := range f.rows {
+		if _, ok := latest[row.ID]; !ok {
+			order = append(order, row.ID)
+		}
+		latest[row.ID] = row
+	}
+	var live []database.NotificationSubscription
+	for _, id := range order {
+		if !latest[id].Deleted {
+			live = append(live, latest[id])
+		}
+	}
+	return live, nil
+}
+
+func (f *fakeSubscriptions) InsertNotificationSubscription(_ context.Context, sub database.NotificationSubscription) error {
+	f.rows = append(f.rows, sub)
+	return nil
+}
+
+// subscriptionsRouter is the API with keys "lab-secret", "other-secret" and
+// admin "ops-secret", and subscriptions kept in store.
+func subscriptionsRouter(t *testing.T, store *fakeSubscriptions) http.Handler {
+	t.Helper()
+	keys := apiaccess.NewKeyring(fakeKeys{
+		{ID: "lab", KeyHash: apiaccess.HashKey("lab-secret")},
+		{ID: "other", KeyHash: apiaccess.HashKey("other-secret")},
+		{ID: "ops", KeyHash: apiaccess.HashKey("ops-secret"), Admin: true},
+	}, apiaccess.Limit{PerMinute: 600, Burst: 100})
+	if err := keys.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	s := New(&fakeOps{})
+	s.SetAccessGuard(apiaccess.NewGuard(apiaccess.Config{Anonymous: apiaccess.Limit{PerMinute: 600, Burst: 100}}, keys, nil), nil)
+	s.SetSubscriptionStore(store, []string{"fidonet", "fsxnet"})
+	return s.SetupRouter()
+}
+
+func send(h http.Handler, method, target, body, key string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	if key != "" {
+		r.Header.Set("X-API-Key", key)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, r)
+	return rec
+}
+
+func TestCreateSubscription(t *testing.T) {
+	tests := []struct {
+		name   string
+		key    string
+		body   string
+		status int
+	}{
+		{"anonymous", "", `{"filter":{"net":"2:5020"},"webhook":"https://x.example/"}`, http.StatusUnauthorized},
+		{"no filter", "lab-secret", `{"webhook":"https://x.example/"}`, http.StatusBadRequest},
+		{"unknown network", "lab-secret", `{"domain":"fidonte","filter":{"net":"2:5020"},"webhook":"https://x.example/"}`, http.StatusBadRequest},
+		{"unknown field", "lab-secret", `{"filter":{"net":"2:5020"},"webhook":"https://x.example/","colour":"red"}`, http.StatusBadRequest},
+		{"registered", "lab-secret", `{"domain":"FsxNet","filter":{"flag":"IBN"},"webhook":"https://x.example/","secret":"s3cret"}`, http.StatusCreated},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &fakeSubscriptions{}
+			rec := send(subscriptionsRouter(t, store), "POST", "/api/subscriptions/", tt.body, tt.key)
+			if rec.Code != tt.status {
+				t.Fatalf("status %d, want %d: %s", rec.Code, tt.status, rec.Body.String())
+			}
+			if tt.status != http.StatusCreated {
+				if len(store.rows) != 0 {
+					t.Errorf("stored %+v", store.rows)
+				}
+				return
+			}
+			if strings.Contains(rec.Body.String(), "s3cret") {
+				t.Errorf("secret echoed: %s", rec.Body.String())
+			}
+			if len(store.rows) != 1 {
+				t.Fatalf("stored %+v", store.rows)
+			}
+			row := store.rows[0]
+			if !strings.HasPrefix(row.ID, "api-") || row.Owner != "lab" || row.Domain != "fsxnet" || row.Secret != "s3cret" {
+				t.Errorf("stored %+v", row)
+			}
+		})
+	}
+}
+
+func TestSubscriptionsBelongToTheirKey(t *testing.T) {
+	store := &fakeSubscriptions{}
+	h := subscriptionsRouter(t, store)
+
+	rec := send(h, "POST", "/api/subscriptions/", `{"filter":{"address":"2:5020/1"},"email":"sysop@example.net"}`, "lab-secret")
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: status %d: %s", rec.Code, rec.Body.String())
+	}
+	var created struct {
+		ID string `json:"id"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &created); err != nil || created.ID == "" {
+		t.Fatalf("create response %s: %v", rec.Body.String(), err)
+	}
+
+	count := func(key string) int {
+		rec := send(h, "GET", "/api/subscriptions/", "", key)
+		var body struct {
+			Count int `json:"count"`
+		}
+		if rec.Code != http.StatusOK || json.Unmarshal(rec.Body.Bytes(), &body) != nil {
+			t.Fatalf("list as %s: status %d: %s", key, rec.Code, rec.Body.String())
+		}
+		return body.Count
+	}
+	if got := count("lab-secret"); got != 1 {
+		t.Errorf("owner sees %d, want 1", got)
+	}
+	if got := count("other-secret"); got != 0 {
+		t.Errorf("other key sees %d, want 0", got)
+	}
+	if got := count("ops-secret"); got != 1 {
+		t.Errorf("admin sees %d, want 1", got)
+	}
+
+	if rec := send(h, "DELETE", "/api/subscriptions/"+created.ID, "", "other-secret"); rec.Code != http.StatusNotFound {
+		t.Errorf("delete by other key: status %d, want 404", rec.Code)
+	}
+	if rec := send(h, "DELETE", "/api/subscriptions/"+created.ID, "", "lab-secret"); rec.Code != http.StatusOK {
+		t.Errorf("delete by owner: status %d: %s", rec.Code, rec.Body.String())
+	}
+	if got := count("lab-secret"); got != 0 {
+		t.Errorf("owner sees %d after delete, want 0", got)
+	}
+}
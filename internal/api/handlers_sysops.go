@@ -3,22 +3,42 @@ package api
 import (
 	"net/http"
 	"net/url"
+	"regexp"
+	"strings"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/nodelistdb/internal/storage"
 )
 
 // SysopsHandler handles requests for listing sysops.
 // GET /api/sysops?name=John&limit=50&offset=0
+//
+// With fuzzy=1 the name matches other spellings of itself rather than as a
+// substring, and the sysops come back best match first, each with a score.
 func (s *Server) SysopsHandler(w http.ResponseWriter, r *http.Request) {
 	// Parse query parameters
 	query := r.URL.Query()
 	nameFilter := query.Get("name")
+	fuzzy, _ := parseBoolParam(query, "fuzzy")
 
 	// Parse pagination with custom limits for sysops
 	limit, offset := parsePaginationParams(query, 50, 200)
 
-	// Get unique sysops
-	sysops, err := s.storage.GetUniqueSysops(r.Context(), nameFilter, limit, offset)
+	var sysops []storage.SysopInfo
+	var err error
+	if fuzzy {
+		if strings.TrimSpace(nameFilter) == "" {
+			WriteJSONError(w, "A fuzzy search needs a name", http.StatusBadRequest)
+			return
+		}
+		if err := storage.CheckFuzzySysopName(nameFilter); err != nil {
+			WriteJSONError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sysops, err = s.storage.FuzzySearchSysops(r.Context(), nameFilter, limit, offset)
+	} else {
+		sysops, err = s.storage.GetUniqueSysops(r.Context(), nameFilter, limit, offset)
+	}
 	if err != nil {
 		writeStorageErrorf(w, "Failed to get sysops", err)
 		return
@@ -29,6 +49,7 @@ func (s *Server) SysopsHandler(w http.ResponseWriter, r *http.Request) {
 		"count":  len(sysops),
 		"filter": map[string]interface{}{
 			"name":   nameFilter,
+			"fuzzy":  fuzzy,
 			"limit":  limit,
 			"offset": offset,
 		},
@@ -73,3 +94,57 @@ func (s *Server) SysopNodesHandler(w http.ResponseWriter, r *http.Request) {
 
 	WriteJSONSuccess(w, response)
 }
+
+// validSysopID matches the IDs sysop identity resolution mints.
+var validSysopID = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// SysopIdentityHandler returns one person's whole FTN career: every node and
+// point they ran, in every network, under every spelling of their name.
+// GET /api/sysop/{id}
+//
+// An ID retired when two persons merged (or one split) still answers, with
+// the identity it was folded into; the response's id says which.
+func (s *Server) SysopIdentityHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+	if !validSysopID.MatchString(id) {
+		WriteJSONError(w, "Invalid sysop ID", http.StatusBadRequest)
+		return
+	}
+
+	identity, err := s.storage.GetSysopIdentity(r.Context(), id)
+	if err != nil {
+		writeStorageErrorf(w, "Failed to get sysop", err)
+		return
+	}
+	if identity == nil {
+		WriteJSONError(w, "Sysop not found", http.StatusNotFound)
+		return
+	}
+
+	WriteJSONSuccess(w, identity)
+}
+
+// GetNodeSysopsHandler returns the persons who ran a node, first one first.
+// GET /api/nodes/{zone}/{net}/{node}/sysops
+func (s *Server) GetNodeSysopsHandler(w http.ResponseWriter, r *http.Request) {
+	zone, net, node, _, ok := parse4DPathParams(w, r, false)
+	if !ok {
+		return
+	}
+
+	domain, availableDomains := s.resolveNodeDomain(r, zone, net, node)
+	sysops, err := s.storage.GetNodeSysopIdentities(r.Context(), zone, net, node, domain)
+	if err != nil {
+		writeStorageErrorf(w, "Failed to get node sysops", err)
+		return
+	}
+	if sysops == nil {
+		sysops = []storage.SysopIdentityRef{}
+	}
+
+	response := addressEnvelope(zone, net, node, -1, domain, availableDomains)
+	response["sysops"] = sysops
+	response["count"] = len(sysops)
+
+	WriteJSONSuccess(w, response)
+}
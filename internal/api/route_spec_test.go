@@ -8,6 +8,7 @@ import (
 	"testing"
 
 	"github.com/go-chi/chi/v5"
+	"github.com/nodelistdb/internal/apiaccess"
 	"github.com/nodelistdb/internal/config"
 	"gopkg.in/yaml.v3"
 )
@@ -19,8 +20,9 @@ import (
 // renders.
 //
 // The router is built with every optional dependency installed. router.go
-// registers the cache-stats, FTP-stats and modem routes only when the matching
-// field is non-nil, and those are exactly the routes that went missing.
+// registers the cache-stats, FTP-stats, modem, usage and subscriptions routes
+// only when the matching field is non-nil, and those are exactly the routes
+// that went missing.
 func TestRoutesMatchTheSpec(t *testing.T) {
 	body, err := os.ReadFile("openapi.yaml")
 	if err != nil {
@@ -37,6 +39,8 @@ func TestRoutesMatchTheSpec(t *testing.T) {
 	s.SetCacheStatsHandler(func(http.ResponseWriter, *http.Request) {})
 	s.SetFTPStatsHandler(func(http.ResponseWriter, *http.Request) {})
 	s.SetModemHandler(NewModemHandler(&config.ModemAPIConfig{MaxBodySizeMB: 1}, nil))
+	s.SetAccessGuard(apiaccess.NewGuard(apiaccess.Config{}, apiaccess.NewKeyring(nil, apiaccess.Limit{}), nil), nil)
+	s.SetSubscriptionStore(&fakeSubscriptions{}, []string{"fidonet"})
 	router := s.SetupRouter()
 
 	live := map[string]bool{}
@@ -29,6 +29,11 @@ func (s *Server) SetupRouter() http.Handler {
 	// same question, and its rewrite is unconditional - a forged
 	// X-Forwarded-For became indistinguishable from a real peer address.
 	r.Use(middleware.Recoverer)
+	// Keys and rate limits go ahead of everything that costs anything, the
+	// query budgets included; they are what a scraper would otherwise use up.
+	if s.access != nil {
+		r.Use(s.accessMiddleware(r))
+	}
 	r.Use(middleware.Compress(5))
 
 	// Note: LoggingMiddleware is now applied at the top level in cmd/server/main.go
@@ -47,8 +52,10 @@ func (s *Server) SetupRouter() http.Handler {
 		r.Get("/{zone}/{net}/{node}", s.GetNodeHandler)
 		r.Get("/{zone}/{net}/{node}/history", s.GetNodeHistoryHandler)
 		r.Get("/{zone}/{net}/{node}/changes", s.GetNodeChangesHandler)
+		r.Get("/{zone}/{net}/{node}/tenancies", s.GetNodeTenanciesHandler)
 		r.Get("/{zone}/{net}/{node}/timeline", s.GetNodeTimelineHandler)
 		r.Get("/{zone}/{net}/{node}/points", s.GetNodePointsHandler)
+		r.Get("/{zone}/{net}/{node}/sysops", s.GetNodeSysopsHandler)
 	})
 
 	// Point (FTS-5002 pointlist) routes
@@ -59,6 +66,14 @@ func (s *Server) SetupRouter() http.Handler {
 		r.Get("/{zone}/{net}/{node}/{point}/history", s.GetPointHistoryHandler)
 	})
 
+	// Bulk export routes. Deliberately without a budget: an export runs as
+	// long as its data does, and export.go keeps the write deadline moving.
+	r.Route("/api/export", func(r chi.Router) {
+		r.Get("/nodes", s.ExportNodesHandler)
+		r.Get("/points", s.ExportPointsHandler)
+		r.Get("/test-results", s.ExportTestResultsHandler)
+	})
+
 	// Pointlist metadata routes
 	r.Route("/api/pointlists", func(r chi.Router) {
 		r.Use(read)
@@ -72,6 +87,8 @@ func (s *Server) SetupRouter() http.Handler {
 	// Statistics routes
 	r.With(read).Get("/api/stats", s.StatsHandler)
 	r.With(read).Get("/api/stats/dates", s.GetAvailableDatesHandler)
+	r.With(read).Get("/api/geo/map", s.NodeMapHandler)
+	r.With(read).Get("/api/browse/diff", s.BrowseDiffHandler)
 
 	// Sysop routes
 	r.Route("/api/sysops", func(r chi.Router) {
@@ -79,6 +96,7 @@ func (s *Server) SetupRouter() http.Handler {
 		r.Get("/", s.SysopsHandler)
 		r.Get("/{name}/nodes", s.SysopNodesHandler)
 	})
+	r.With(read).Get("/api/sysop/{id}", s.SysopIdentityHandler)
 
 	// Software analytics routes
 	r.Route("/api/software", func(r chi.Router) {
@@ -86,6 +104,7 @@ func (s *Server) SetupRouter() http.Handler {
 		r.Get("/binkp", s.GetBinkPSoftwareStats)
 		r.Get("/ifcico", s.GetIFCICOSoftwareStats)
 		r.Get("/binkd", s.GetBinkdDetailedStats)
+		r.Get("/binkp-options", s.GetBinkPOptionStats)
 	})
 
 	// Geographic analytics routes
@@ -94,6 +113,12 @@ func (s *Server) SetupRouter() http.Handler {
 		r.Get("/geo-hosting", s.GetGeoHostingStats)
 	})
 
+	// GraphQL. No group budget here: the handler picks the read or the
+	// analytics budget per query, and a deadline set out here would cap both.
+	r.Get("/api/graphql", s.GraphQLHandler)
+	r.Post("/api/graphql", s.GraphQLHandler)
+	r.Get("/api/graphql/schema", s.GraphQLSchemaHandler)
+
 	// Documentation routes
 	r.Get("/api/flags", s.FlagsDocumentationHandler)
 	r.Get("/api/openapi.yaml", s.OpenAPISpecHandler)
@@ -101,12 +126,30 @@ func (s *Server) SetupRouter() http.Handler {
 
 	// Nodelist routes
 	r.Get("/api/nodelist/latest", s.LatestNodelistAPIHandler)
+	r.With(read).Get("/api/nodelist/{domain}/{date}.txt", s.NodelistExportHandler)
+	r.With(read).Get("/api/nodelist/{domain}/{date}/region/{zone}/{region}.txt", s.NodelistSegmentHandler)
+	r.With(read).Get("/api/nodelist/{domain}/{date}/net/{zone}/{net}.txt", s.NodelistSegmentHandler)
 
 	// Cache stats endpoint (if configured)
 	if s.cacheStatsHandler != nil {
 		r.Get("/api/cache/stats", s.cacheStatsHandler)
 	}
 
+	// API usage report (if API access control is configured)
+	if s.access != nil {
+		r.With(read).Get("/api/usage", s.UsageHandler)
+	}
+
+	// Change notification subscriptions (if configured; they belong to API keys)
+	if s.access != nil && s.subscriptions != nil {
+		r.Route("/api/subscriptions", func(r chi.Router) {
+			r.Use(read)
+			r.Get("/", s.ListSubscriptionsHandler)
+			r.Post("/", s.CreateSubscriptionHandler)
+			r.Delete("/{id}", s.DeleteSubscriptionHandler)
+		})
+	}
+
 	// FTP stats endpoint (if configured)
 	if s.ftpStatsHandler != nil {
 		r.Get("/api/ftp/stats", s.ftpStatsHandler)
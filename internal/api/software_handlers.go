@@ -32,6 +32,20 @@ func (s *Server) GetIFCICOSoftwareStats(w http.ResponseWriter, r *http.Request)
 	WriteJSONSuccess(w, dist)
 }
 
+// GetBinkPOptionStats returns, per BinkP mailer, the verdicts on the options
+// its nodes advertise
+func (s *Server) GetBinkPOptionStats(w http.ResponseWriter, r *http.Request) {
+	days := parseDaysParam(r.URL.Query(), 365)
+
+	support, err := s.storage.GetBinkPOptionSupport(r.Context(), days, domainOrAll(r))
+	if err != nil {
+		writeStorageError(w, "Failed to get BinkP option support", err)
+		return
+	}
+
+	WriteJSONSuccess(w, support)
+}
+
 // GetBinkdDetailedStats returns detailed binkd statistics
 func (s *Server) GetBinkdDetailedStats(w http.ResponseWriter, r *http.Request) {
 	days := parseDaysParam(r.URL.Query(), 365)
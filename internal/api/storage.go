@@ -14,9 +14,9 @@ import (
 // storage.Operations carries 89 methods because it is the union of everything
 // every consumer wants. Depending on it here made two things worse: a reader
 // could not tell which of the 89 the API actually calls, and a test double had
-// to satisfy all of them. Splitting it into five per-subject readers costs
+// to satisfy all of them. Splitting it into seven per-subject readers costs
 // nothing at the call site - *storage.CachedStorage satisfies them all without
-// being told - and makes the API's storage footprint the twenty-seven methods
+// being told - and makes the API's storage footprint the thirty-five methods
 // listed below.
 
 // NodeReader is the nodelist itself: what a node is, was, and which networks
@@ -27,7 +27,9 @@ type NodeReader interface {
 	GetNodeDateRange(ctx context.Context, zone, net, node int, domain string) (firstDate, lastDate time.Time, err error)
 	GetNodeDomains(ctx context.Context, zone, net, node int) ([]string, error)
 	GetNodeChanges(ctx context.Context, zone, net, node int, domain string) ([]database.NodeChange, error)
+	GetNodeTenancies(ctx context.Context, zone, net, node int, domain string) ([]storage.NodeTenancy, error)
 	GetDomains(ctx context.Context) ([]storage.DomainInfo, error)
+	GetNodelistLines(ctx context.Context, domain string, date time.Time) (nodes, text []database.NodelistLine, err error)
 }
 
 // PointReader is the pointlist side: points under a boss, one point's history,
@@ -47,11 +49,18 @@ type StatsReader interface {
 	GetLatestStatsDate(ctx context.Context, domain string) (time.Time, error)
 	GetAvailableDates(ctx context.Context, domain string) ([]time.Time, error)
 	GetNearestAvailableDate(ctx context.Context, requestedDate time.Time, domain string) (time.Time, error)
+	GetBrowseNets(ctx context.Context, date time.Time, zone, region int, domain string) ([]storage.BrowseNet, error)
+	GetBrowseNodes(ctx context.Context, date time.Time, zone, net int, domain string) ([]database.Node, error)
+	GetNodeMap(ctx context.Context, date time.Time, domain string) (*storage.NodeMap, error)
+	GetBrowseDiff(ctx context.Context, from, to time.Time, scope storage.BrowseDiffScope, domain string) (*storage.BrowseDiff, error)
 }
 
 // SysopReader answers questions about operators rather than nodes.
 type SysopReader interface {
 	GetUniqueSysops(ctx context.Context, nameFilter string, limit, offset int) ([]storage.SysopInfo, error)
+	FuzzySearchSysops(ctx context.Context, name string, limit, offset int) ([]storage.SysopInfo, error)
+	GetSysopIdentity(ctx context.Context, id string) (*storage.SysopIdentity, error)
+	GetNodeSysopIdentities(ctx context.Context, zone, net, node int, domain string) ([]storage.SysopIdentityRef, error)
 	GetNodesBySysop(ctx context.Context, sysopName string, limit int) ([]database.Node, error)
 }
 
@@ -60,9 +69,25 @@ type AnalyticsReader interface {
 	GetBinkPSoftwareDistribution(ctx context.Context, days int, domain string) (*storage.SoftwareDistribution, error)
 	GetIFCICOSoftwareDistribution(ctx context.Context, days int, domain string) (*storage.SoftwareDistribution, error)
 	GetBinkdDetailedStats(ctx context.Context, days int, domain string) (*storage.SoftwareDistribution, error)
+	GetBinkPOptionSupport(ctx context.Context, days int, domain string) (*storage.BinkPOptionSupport, error)
 	GetGeoHostingDistribution(ctx context.Context, days int, domain string) (*storage.GeoHostingDistribution, error)
 }
 
+// TestResultReader is one node's record from the testdaemon. Only the GraphQL
+// endpoint reads it; the REST API has no per-node test routes.
+type TestResultReader interface {
+	GetNodeTestHistory(ctx context.Context, zone, net, node int, days int, domain string) ([]storage.NodeTestResult, error)
+	GetNodeReachabilityStats(ctx context.Context, zone, net, node int, days int, domain string) (*storage.NodeReachabilityStats, error)
+}
+
+// ExportReader streams rows for the bulk export endpoints, one callback per
+// row, so a download of years of history never sits in memory as a slice.
+type ExportReader interface {
+	StreamNodes(ctx context.Context, filter database.NodeFilter, fn func(database.Node) error) error
+	StreamPoints(ctx context.Context, filter database.PointFilter, fn func(database.Point) error) error
+	StreamTestResults(ctx context.Context, filter database.NodeFilter, fn func(storage.NodeTestResult) error) error
+}
+
 // PSTNStore is the only writable surface the API has: the modem tester's
 // record of which phone numbers answer.
 type PSTNStore interface {
@@ -80,6 +105,8 @@ type Storage interface {
 	StatsReader
 	SysopReader
 	AnalyticsReader
+	TestResultReader
+	ExportReader
 	PSTNStore
 }
 
@@ -0,0 +1,155 @@
+package apiaccess
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrUnknownKey is a key that is not in the keyring, or is disabled.
+	ErrUnknownKey = errors.New("invalid API key")
+	// ErrKeyRequired is an anonymous request when keys are required.
+	ErrKeyRequired = errors.New("API key required")
+)
+
+// Config is the Guard's policy.
+type Config struct {
+	Anonymous      Limit // per client address
+	RequireKey     bool
+	TrustedProxies []netip.Prefix
+}
+
+// Caller is who a request came from. Key is nil for anonymous callers.
+type Caller struct {
+	Key      *Key
+	ClientIP string
+}
+
+// KeyID is the caller's key id, or "" when anonymous.
+func (c Caller) KeyID() string {
+	if c.Key == nil {
+		return ""
+	}
+	return c.Key.ID
+}
+
+// Guard identifies, limits and accounts API callers.
+type Guard struct {
+	cfg     Config
+	keys    *Keyring
+	limiter *Limiter
+	usage   *Recorder
+	now     func() time.Time
+}
+
+// NewGuard creates a guard. usage may be nil to count nothing.
+func NewGuard(cfg Config, keys *Keyring, usage *Recorder) *Guard {
+	return &Guard{cfg: cfg, keys: keys, limiter: NewLimiter(), usage: usage, now: time.Now}
+}
+
+// Identify resolves the caller of r, which presented key (empty for none).
+// A key that does not resolve is an error rather than a fall back to
+// anonymous: a client with a mistyped key would otherwise run into the
+// anonymous limit and never learn why.
+func (g *Guard) Identify(r *http.Request, key string) (Caller, error) {
+	c := Caller{ClientIP: ClientIP(r, g.cfg.TrustedProxies)}
+	if key == "" {
+		if g.cfg.RequireKey {
+			return c, ErrKeyRequired
+		}
+		return c, nil
+	}
+	if c.Key = g.keys.Lookup(key); c.Key == nil {
+		return c, ErrUnknownKey
+	}
+	return c, nil
+}
+
+// Allow takes a token from the caller's bucket and reports the limit it was
+// judged against.
+func (g *Guard) Allow(c Caller) (Decision, Limit) {
+	if c.Key != nil {
+		return g.limiter.Allow("key:"+c.Key.ID, c.Key.Limit, g.now()), c.Key.Limit
+	}
+	return g.limiter.Allow("ip:"+c.ClientIP, g.cfg.Anonymous, g.now()), g.cfg.Anonymous
+}
+
+// Record counts one answered request.
+func (g *Guard) Record(c Caller, method, route string, status int, bytes int64, d time.Duration) {
+	if g.usage == nil {
+		return
+	}
+	g.usage.Record(Hit{
+		KeyID: c.KeyID(), ClientIP: c.ClientIP,
+		Method: method, Route: route, Status: status,
+		Bytes: bytes, Duration: d, At: g.now(),
+	})
+}
+
+// ClientIP is the address a request is limited and counted under.
+//
+// The peer address is used unless it is a trusted proxy. Then
+// X-Forwarded-For is read from the right, skipping further trusted proxies,
+// and the first address that is not one is the client. Reading it from the
+// left, as the log lines do, would let any client pick its own bucket by
+// sending a made-up first entry.
+func ClientIP(r *http.Request, trusted []netip.Prefix) string {
+	peer := hostAddr(r.RemoteAddr)
+	if !isTrusted(peer, trusted) {
+		return addrString(peer, r.RemoteAddr)
+	}
+
+	hops := strings.Split(strings.Join(r.Header.Values("X-Forwarded-For"), ","), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		addr, err := netip.ParseAddr(hop)
+		if err != nil {
+			// Garbage from beyond the last trusted proxy; it cannot be
+			// believed, and neither can anything to its left.
+			break
+		}
+		if !isTrusted(addr.Unmap(), trusted) {
+			return addr.Unmap().String()
+		}
+		peer = addr.Unmap()
+	}
+	return addrString(peer, r.RemoteAddr)
+}
+
+func hostAddr(remote string) netip.Addr {
+	host, _, err := net.SplitHostPort(remote)
+	if err != nil {
+		host = remote
+	}
+	addr, err := netip.ParseAddr(host)
+	if err != nil {
+		return netip.Addr{}
+	}
+	return addr.Unmap()
+}
+
+func isTrusted(addr netip.Addr, trusted []netip.Prefix) bool {
+	if !addr.IsValid() {
+		return false
+	}
+	for _, p := range trusted {
+		if p.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func addrString(addr netip.Addr, fallback string) string {
+	if addr.IsValid() {
+		return addr.String()
+	}
+	return fallback
+}
@@ -0,0 +1,100 @@
+package apiaccess
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"net/netip"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+func TestClientIP(t *testing.T) {
+	trusted := []netip.Prefix{netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("::1/128")}
+	for _, tc := range []struct {
+		name   string
+		remote string
+		xff    []string
+		want   string
+	}{
+		{"direct", "192.0.2.7:5123", nil, "192.0.2.7"},
+		{"forged header from an untrusted peer", "192.0.2.7:5123", []string{"198.51.100.1"}, "192.0.2.7"},
+		{"through a trusted proxy", "10.0.0.2:443", []string{"198.51.100.1"}, "198.51.100.1"},
+		{"client-supplied entry to the left is ignored", "10.0.0.2:443", []string{"203.0.113.9, 198.51.100.1"}, "198.51.100.1"},
+		{"chain of trusted proxies", "10.0.0.2:443", []string{"198.51.100.1, 10.1.1.1", "10.2.2.2"}, "198.51.100.1"},
+		{"trusted proxy without the header", "10.0.0.2:443", nil, "10.0.0.2"},
+		{"garbage hop stops the walk", "10.0.0.2:443", []string{"198.51.100.1, unknown"}, "10.0.0.2"},
+		{"IPv6 loopback proxy, mapped client", "[::1]:443", []string{"::ffff:198.51.100.1"}, "198.51.100.1"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			r := httptest.NewRequest("GET", "/api/nodes", nil)
+			r.RemoteAddr = tc.remote
+			for _, v := range tc.xff {
+				r.Header.Add("X-Forwarded-For", v)
+			}
+			if got := ClientIP(r, trusted); got != tc.want {
+				t.Errorf("ClientIP = %q, want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestGuard(t *testing.T) {
+	keys := NewKeyring(staticKeys{{ID: "lab", KeyHash: HashKey("secret"), RequestsPerMinute: 120, Burst: 2}}, Limit{PerMinute: 600, Burst: 100})
+	if err := keys.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sink := &memorySink{}
+	usage := NewRecorder(sink)
+	g := NewGuard(Config{Anonymous: Limit{PerMinute: 60, Burst: 1}}, keys, usage)
+	now := time.Date(2026, 7, 20, 12, 0, 30, 0, time.UTC)
+	g.now = func() time.Time { return now }
+
+	r := httptest.NewRequest("GET", "/api/nodes", nil)
+	if _, err := g.Identify(r, "wrong"); !errors.Is(err, ErrUnknownKey) {
+		t.Errorf("unknown key: err = %v", err)
+	}
+
+	keyed, err := g.Identify(r, "secret")
+	if err != nil || keyed.KeyID() != "lab" {
+		t.Fatalf("Identify = %+v, %v", keyed, err)
+	}
+	anon, err := g.Identify(r, "")
+	if err != nil || anon.Key != nil || anon.ClientIP != "192.0.2.1" {
+		t.Fatalf("anonymous Identify = %+v, %v", anon, err)
+	}
+
+	// The key's bucket and the address's bucket are separate.
+	if d, lim := g.Allow(keyed); !d.Allowed || lim.PerMinute != 120 {
+		t.Errorf("keyed: %+v %+v", d, lim)
+	}
+	if d, _ := g.Allow(anon); !d.Allowed {
+		t.Errorf("first anonymous request refused: %+v", d)
+	}
+	if d, _ := g.Allow(anon); d.Allowed {
+		t.Error("anonymous burst of 1 allowed a second request")
+	}
+
+	g.Record(keyed, "GET", "/api/nodes", 200, 100, time.Second)
+	g.Record(anon, "GET", "/api/nodes", 429, 0, 0)
+	if err := usage.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(sink.rows) != 2 {
+		t.Fatalf("flushed %d rows, want 2", len(sink.rows))
+	}
+
+	g = NewGuard(Config{RequireKey: true}, keys, nil)
+	if _, err := g.Identify(r, ""); !errors.Is(err, ErrKeyRequired) {
+		t.Errorf("anonymous with keys required: err = %v", err)
+	}
+}
+
+type memorySink struct{ rows []database.APIUsage }
+
+func (m *memorySink) InsertAPIUsage(_ context.Context, usage []database.APIUsage) error {
+	m.rows = append(m.rows, usage...)
+	return nil
+}
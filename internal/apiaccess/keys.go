@@ -0,0 +1,206 @@
+// Package apiaccess decides who may use the public API and how often, and
+// counts what they did with it.
+//
+// A caller is either an API key or, without one, the client address. Each
+// gets a token bucket (Limiter); every request, refused ones included, is
+// added to per-minute usage counters (Recorder) that are flushed to the
+// api_usage table. Guard ties the three together for the HTTP middleware in
+// internal/api.
+package apiaccess
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/logging"
+)
+
+// Limit is a token bucket's shape: PerMinute requests sustained, Burst at once.
+type Limit struct {
+	PerMinute int
+	Burst     int
+}
+
+// Key is an enabled API key with its limit resolved.
+type Key struct {
+	ID    string
+	Owner string
+	Limit Limit
+	Admin bool
+}
+
+// KeySource supplies the key list: *storage.Storage for the api_keys table,
+// FileKeys for a YAML file.
+type KeySource interface {
+	GetAPIKeys(ctx context.Context) ([]database.APIKey, error)
+}
+
+// FileKeys reads keys from a YAML file laid out like api_keys.example.yaml.
+type FileKeys string
+
+// GetAPIKeys reads the file.
+func (f FileKeys) GetAPIKeys(context.Context) ([]database.APIKey, error) {
+	data, err := os.ReadFile(string(f))
+	if err != nil {
+		return nil, err
+	}
+	var file struct {
+		Keys []struct {
+			ID                string `yaml:"id"`
+			Owner             string `yaml:"owner"`
+			KeyHash           string `yaml:"key_hash"`
+			RequestsPerMinute int    `yaml:"requests_per_minute"`
+			Burst             int    `yaml:"burst"`
+			Admin             bool   `yaml:"admin"`
+			Disabled          bool   `yaml:"disabled"`
+		} `yaml:"keys"`
+	}
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", string(f), err)
+	}
+	keys := make([]database.APIKey, 0, len(file.Keys))
+	for _, k := range file.Keys {
+		keys = append(keys, database.APIKey{
+			ID: k.ID, Owner: k.Owner, KeyHash: k.KeyHash,
+			RequestsPerMinute: k.RequestsPerMinute, Burst: k.Burst,
+			Admin: k.Admin, Disabled: k.Disabled,
+		})
+	}
+	return keys, nil
+}
+
+// HashKey is the stored form of a key: "sha256:" and the hex digest, as the
+// modem API callers' keys are stored.
+func HashKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// Keyring is the current set of enabled keys, indexed by hash. It is
+// replaced whole on every reload, so a lookup never sees half a reload.
+type Keyring struct {
+	source   KeySource
+	defaults Limit
+
+	mu     sync.RWMutex
+	byHash map[string]*Key
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewKeyring creates an empty keyring over source. Keys that set no limit of
+// their own get defaults. Call Reload before serving.
+func NewKeyring(source KeySource, defaults Limit) *Keyring {
+	return &Keyring{source: source, defaults: defaults, byHash: map[string]*Key{}}
+}
+
+// Reload re-reads the source. A list with a bad entry is refused whole and
+// the keys already loaded stay in force: dropping every key because one line
+// of the file was mistyped would lock every client out at once.
+func (k *Keyring) Reload(ctx context.Context) error {
+	keys, err := k.source.GetAPIKeys(ctx)
+	if err != nil {
+		return fmt.Errorf("loading API keys: %w", err)
+	}
+
+	byHash := make(map[string]*Key, len(keys))
+	seen := make(map[string]bool, len(keys))
+	for i, key := range keys {
+		if key.ID == "" {
+			return fmt.Errorf("API key %d has no id", i+1)
+		}
+		if seen[key.ID] {
+			return fmt.Errorf("API key id %q is duplicated", key.ID)
+		}
+		seen[key.ID] = true
+
+		hash := strings.ToLower(strings.TrimSpace(key.KeyHash))
+		if digest, ok := strings.CutPrefix(hash, "sha256:"); !ok || len(digest) != 2*sha256.Size || !isHex(digest) {
+			return fmt.Errorf("API key %q: key_hash must be sha256:<64 hex digits>", key.ID)
+		}
+		if key.RequestsPerMinute < 0 || key.Burst < 0 {
+			return fmt.Errorf("API key %q: negative limit", key.ID)
+		}
+		if key.Disabled {
+			continue
+		}
+		if _, dup := byHash[hash]; dup {
+			return fmt.Errorf("API key %q has the same hash as another key", key.ID)
+		}
+
+		limit := k.defaults
+		if key.RequestsPerMinute > 0 {
+			limit.PerMinute = key.RequestsPerMinute
+		}
+		if key.Burst > 0 {
+			limit.Burst = key.Burst
+		}
+		byHash[hash] = &Key{ID: key.ID, Owner: key.Owner, Limit: limit, Admin: key.Admin}
+	}
+
+	k.mu.Lock()
+	k.byHash = byHash
+	k.mu.Unlock()
+	return nil
+}
+
+// Lookup returns the enabled key whose hash matches, or nil.
+func (k *Keyring) Lookup(key string) *Key {
+	hash := HashKey(key)
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return k.byHash[hash]
+}
+
+// Len is the number of enabled keys.
+func (k *Keyring) Len() int {
+	k.mu.RLock()
+	defer k.mu.RUnlock()
+	return len(k.byHash)
+}
+
+// Start reloads the keyring every interval until Stop.
+func (k *Keyring) Start(interval time.Duration) {
+	k.stop, k.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(k.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-k.stop:
+				return
+			case <-ticker.C:
+				ctx, cancel := context.WithTimeout(context.Background(), interval)
+				if err := k.Reload(ctx); err != nil {
+					logging.Warn("API key reload failed; keeping the previous keys", "error", err)
+				}
+				cancel()
+			}
+		}
+	}()
+}
+
+// Stop ends the reloads Start began.
+func (k *Keyring) Stop() {
+	if k.stop == nil {
+		return
+	}
+	close(k.stop)
+	<-k.done
+}
+
+func isHex(s string) bool {
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
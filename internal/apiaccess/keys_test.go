@@ -0,0 +1,98 @@
+package apiaccess
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+type staticKeys []database.APIKey
+
+func (s staticKeys) GetAPIKeys(context.Context) ([]database.APIKey, error) { return s, nil }
+
+func TestKeyringReload(t *testing.T) {
+	defaults := Limit{PerMinute: 600, Burst: 100}
+	k := NewKeyring(staticKeys{
+		{ID: "lab", KeyHash: HashKey("lab-secret"), RequestsPerMinute: 1200},
+		{ID: "ops", KeyHash: strings.ToUpper(HashKey("ops-secret")), Admin: true},
+		{ID: "old", KeyHash: HashKey("old-secret"), Disabled: true},
+	}, defaults)
+	if err := k.Reload(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	lab := k.Lookup("lab-secret")
+	if lab == nil || lab.ID != "lab" || lab.Limit != (Limit{PerMinute: 1200, Burst: 100}) {
+		t.Errorf("lab = %+v, want its own rate and the default burst", lab)
+	}
+	if ops := k.Lookup("ops-secret"); ops == nil || !ops.Admin {
+		t.Errorf("ops = %+v (hash case must not matter)", ops)
+	}
+	if k.Lookup("old-secret") != nil || k.Lookup("nobody") != nil {
+		t.Error("disabled or unknown key resolved")
+	}
+	if k.Len() != 2 {
+		t.Errorf("Len = %d, want 2", k.Len())
+	}
+}
+
+// TestKeyringKeepsKeysOnBadReload: one bad entry must not lock every client
+// out.
+func TestKeyringKeepsKeysOnBadReload(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		keys    staticKeys
+		wantErr string
+	}{
+		{"malformed hash", staticKeys{{ID: "x", KeyHash: "md5:abc"}}, "sha256:<64 hex digits>"},
+		{"duplicate id", staticKeys{{ID: "x", KeyHash: HashKey("1")}, {ID: "x", KeyHash: HashKey("2")}}, "duplicated"},
+		{"shared hash", staticKeys{{ID: "x", KeyHash: HashKey("1")}, {ID: "y", KeyHash: HashKey("1")}}, "same hash"},
+		{"no id", staticKeys{{KeyHash: HashKey("1")}}, "no id"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			src := &mutableKeys{keys: staticKeys{{ID: "lab", KeyHash: HashKey("lab-secret")}}}
+			k := NewKeyring(src, Limit{PerMinute: 60, Burst: 10})
+			if err := k.Reload(context.Background()); err != nil {
+				t.Fatal(err)
+			}
+			src.keys = tc.keys
+			if err := k.Reload(context.Background()); err == nil || !strings.Contains(err.Error(), tc.wantErr) {
+				t.Fatalf("Reload = %v, want an error containing %q", err, tc.wantErr)
+			}
+			if k.Lookup("lab-secret") == nil {
+				t.Error("previous keys dropped by a failed reload")
+			}
+		})
+	}
+}
+
+type mutableKeys struct{ keys staticKeys }
+
+func (m *mutableKeys) GetAPIKeys(ctx context.Context) ([]database.APIKey, error) {
+	return m.keys.GetAPIKeys(ctx)
+}
+
+func TestFileKeys(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api_keys.yaml")
+	body := "keys:\n  - id: lab\n    owner: jane@example.org\n    key_hash: " + HashKey("s") + "\n    burst: 5\n    admin: true\n"
+	if err := os.WriteFile(path, []byte(body), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	keys, err := FileKeys(path).GetAPIKeys(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 1 || keys[0].ID != "lab" || keys[0].Owner != "jane@example.org" || keys[0].Burst != 5 || !keys[0].Admin {
+		t.Errorf("keys = %+v", keys)
+	}
+
+	// The example shipped with the repository must load.
+	k := NewKeyring(FileKeys("../../api_keys.example.yaml"), Limit{PerMinute: 60, Burst: 10})
+	if err := k.Reload(context.Background()); err != nil {
+		t.Fatalf("api_keys.example.yaml: %v", err)
+	}
+}
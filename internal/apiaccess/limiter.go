@@ -0,0 +1,89 @@
+package apiaccess
+
+import (
+	"math"
+	"sync"
+	"time"
+)
+
+// sweepInterval is how often idle buckets are dropped.
+const sweepInterval = time.Minute
+
+// Decision is the Limiter's answer to one request.
+type Decision struct {
+	Allowed    bool
+	Remaining  int           // whole tokens left after this request
+	RetryAfter time.Duration // until the next token, when refused
+}
+
+// Limiter holds one token bucket per caller.
+//
+// A bucket that has refilled to its burst is indistinguishable from a new
+// one, so such buckets are dropped once a minute. That bounds the map by the
+// callers seen within the last few minutes rather than by every address that
+// ever connected.
+type Limiter struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+}
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	limit  Limit
+}
+
+// NewLimiter creates an empty limiter.
+func NewLimiter() *Limiter {
+	return &Limiter{buckets: map[string]*bucket{}}
+}
+
+// Allow takes a token from id's bucket, shaped by limit. A bucket whose
+// limit changed since the last request (a key edited on reload) keeps its
+// tokens, capped at the new burst.
+func (l *Limiter) Allow(id string, limit Limit, now time.Time) Decision {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if now.Sub(l.lastSweep) >= sweepInterval {
+		l.sweep(now)
+	}
+
+	b, ok := l.buckets[id]
+	if !ok {
+		b = &bucket{tokens: float64(limit.Burst), last: now, limit: limit}
+		l.buckets[id] = b
+	}
+	b.limit = limit
+	b.refill(now)
+
+	if b.tokens >= 1 {
+		b.tokens--
+		return Decision{Allowed: true, Remaining: int(b.tokens)}
+	}
+	wait := time.Duration(math.Ceil((1 - b.tokens) * float64(time.Minute) / float64(limit.PerMinute)))
+	return Decision{RetryAfter: wait}
+}
+
+func (b *bucket) refill(now time.Time) {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += float64(elapsed) * b.limit.perNanosecond()
+		b.last = now
+	}
+	b.tokens = math.Min(b.tokens, float64(b.limit.Burst))
+}
+
+func (l *Limiter) sweep(now time.Time) {
+	for id, b := range l.buckets {
+		b.refill(now)
+		if b.tokens >= float64(b.limit.Burst) {
+			delete(l.buckets, id)
+		}
+	}
+	l.lastSweep = now
+}
+
+func (lim Limit) perNanosecond() float64 {
+	return float64(lim.PerMinute) / float64(time.Minute)
+}
@@ -0,0 +1,59 @@
+package apiaccess
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterBurstThenRate(t *testing.T) {
+	l := NewLimiter()
+	limit := Limit{PerMinute: 60, Burst: 3}
+	t0 := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+
+	for i := 2; i >= 0; i-- {
+		d := l.Allow("a", limit, t0)
+		if !d.Allowed || d.Remaining != i {
+			t.Fatalf("burst request: %+v, want allowed with %d left", d, i)
+		}
+	}
+	d := l.Allow("a", limit, t0)
+	if d.Allowed || d.RetryAfter != time.Second {
+		t.Fatalf("over the burst: %+v, want refused with RetryAfter 1s", d)
+	}
+
+	// Another caller has a bucket of its own.
+	if d := l.Allow("b", limit, t0); !d.Allowed {
+		t.Fatalf("second caller refused: %+v", d)
+	}
+
+	// One token per second comes back, never more than the burst.
+	if d := l.Allow("a", limit, t0.Add(1500*time.Millisecond)); !d.Allowed || d.Remaining != 0 {
+		t.Errorf("after 1.5s: %+v, want one request allowed", d)
+	}
+	if d := l.Allow("a", limit, t0.Add(time.Hour)); !d.Allowed || d.Remaining != 2 {
+		t.Errorf("after an hour: %+v, want the full burst back", d)
+	}
+}
+
+func TestLimiterSweepsIdleBuckets(t *testing.T) {
+	l := NewLimiter()
+	limit := Limit{PerMinute: 60, Burst: 5}
+	t0 := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+
+	for _, id := range []string{"idle", "busy"} {
+		l.Allow(id, limit, t0)
+	}
+	for i := 0; i < 5; i++ {
+		l.Allow("busy", limit, t0.Add(2*time.Second))
+	}
+	// A minute on: "idle" refilled long ago, "busy" just drained again.
+	for i := 0; i < 5; i++ {
+		l.Allow("busy", limit, t0.Add(sweepInterval+2*time.Second))
+	}
+	if _, ok := l.buckets["idle"]; ok {
+		t.Error("full bucket not swept")
+	}
+	if _, ok := l.buckets["busy"]; !ok {
+		t.Error("bucket in use was swept")
+	}
+}
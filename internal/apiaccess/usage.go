@@ -0,0 +1,143 @@
+package apiaccess
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/logging"
+)
+
+// maxUsageRows caps the counters held between flushes. Past it, anonymous
+// traffic is counted under OverflowClientIP instead of per address, so a
+// flood from many addresses costs one row per route rather than memory.
+const maxUsageRows = 50000
+
+// OverflowClientIP stands for every anonymous address folded together once
+// the counters are full.
+const OverflowClientIP = "*"
+
+// UsageSink stores flushed counters; *storage.Storage writes api_usage.
+type UsageSink interface {
+	InsertAPIUsage(ctx context.Context, usage []database.APIUsage) error
+}
+
+// Hit is one answered request.
+type Hit struct {
+	KeyID    string // empty for anonymous callers
+	ClientIP string // kept for anonymous callers only
+	Method   string
+	Route    string
+	Status   int
+	Bytes    int64
+	Duration time.Duration
+	At       time.Time
+}
+
+type usageKey struct {
+	period                         time.Time
+	keyID, clientIP, method, route string
+	status                         int
+}
+
+// Recorder sums hits per caller, route, method, status and minute, and
+// flushes the sums to a UsageSink.
+type Recorder struct {
+	sink UsageSink
+
+	mu   sync.Mutex
+	rows map[usageKey]*database.APIUsage
+
+	stop chan struct{}
+	done chan struct{}
+}
+
+// NewRecorder creates a recorder writing to sink.
+func NewRecorder(sink UsageSink) *Recorder {
+	return &Recorder{sink: sink, rows: map[usageKey]*database.APIUsage{}}
+}
+
+// Record adds one hit.
+func (r *Recorder) Record(h Hit) {
+	if h.KeyID != "" {
+		h.ClientIP = ""
+	}
+	k := usageKey{
+		period: h.At.UTC().Truncate(time.Minute),
+		keyID:  h.KeyID, clientIP: h.ClientIP, method: h.Method, route: h.Route,
+		status: h.Status,
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	row, ok := r.rows[k]
+	if !ok && len(r.rows) >= maxUsageRows && k.keyID == "" {
+		k.clientIP = OverflowClientIP
+		row, ok = r.rows[k]
+	}
+	if !ok {
+		row = &database.APIUsage{
+			Period: k.period, KeyID: k.keyID, ClientIP: k.clientIP,
+			Method: k.method, Route: k.route, Status: k.status,
+		}
+		r.rows[k] = row
+	}
+	row.Requests++
+	row.Bytes += h.Bytes
+	row.Duration += h.Duration
+}
+
+// Flush writes the counters gathered since the last flush. They are gone
+// from memory either way: a sink that is down for an hour must not turn
+// into an hour of counters held in the server.
+func (r *Recorder) Flush(ctx context.Context) error {
+	r.mu.Lock()
+	rows := r.rows
+	r.rows = make(map[usageKey]*database.APIUsage, len(rows))
+	r.mu.Unlock()
+
+	if len(rows) == 0 {
+		return nil
+	}
+	usage := make([]database.APIUsage, 0, len(rows))
+	for _, row := range rows {
+		usage = append(usage, *row)
+	}
+	return r.sink.InsertAPIUsage(ctx, usage)
+}
+
+// Start flushes every interval until Stop.
+func (r *Recorder) Start(interval time.Duration) {
+	r.stop, r.done = make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-r.stop:
+				return
+			case <-ticker.C:
+				r.flushLogged(interval)
+			}
+		}
+	}()
+}
+
+// Stop ends the flushes Start began and writes what is left.
+func (r *Recorder) Stop() {
+	if r.stop != nil {
+		close(r.stop)
+		<-r.done
+	}
+	r.flushLogged(10 * time.Second)
+}
+
+func (r *Recorder) flushLogged(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	if err := r.Flush(ctx); err != nil {
+		logging.Warn("API usage flush failed; counters dropped", "error", err)
+	}
+}
@@ -0,0 +1,81 @@
+package apiaccess
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"testing"
+	"time"
+)
+
+func TestRecorderSumsPerMinute(t *testing.T) {
+	sink := &memorySink{}
+	r := NewRecorder(sink)
+	t0 := time.Date(2026, 7, 20, 12, 0, 10, 0, time.UTC)
+
+	hit := Hit{KeyID: "lab", ClientIP: "192.0.2.1", Method: "GET", Route: "/api/nodes", Status: 200, Bytes: 10, Duration: time.Second, At: t0}
+	r.Record(hit)
+	hit.At = t0.Add(40 * time.Second) // same minute
+	r.Record(hit)
+	hit.At = t0.Add(time.Minute) // next minute
+	r.Record(hit)
+	r.Record(Hit{ClientIP: "192.0.2.1", Method: "GET", Route: "/api/nodes", Status: 429, At: t0})
+
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	sort.Slice(sink.rows, func(i, j int) bool {
+		a, b := sink.rows[i], sink.rows[j]
+		if !a.Period.Equal(b.Period) {
+			return a.Period.Before(b.Period)
+		}
+		return a.KeyID < b.KeyID
+	})
+	if len(sink.rows) != 3 {
+		t.Fatalf("rows = %+v", sink.rows)
+	}
+	anon, keyed := sink.rows[0], sink.rows[1]
+	if anon.KeyID != "" || anon.ClientIP != "192.0.2.1" || anon.Status != 429 || anon.Requests != 1 {
+		t.Errorf("anonymous row = %+v", anon)
+	}
+	if keyed.ClientIP != "" || keyed.Requests != 2 || keyed.Bytes != 20 || keyed.Duration != 2*time.Second ||
+		!keyed.Period.Equal(time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("keyed row = %+v, want two requests summed, no client address", keyed)
+	}
+
+	// A flush empties the counters.
+	sink.rows = nil
+	if err := r.Flush(context.Background()); err != nil || len(sink.rows) != 0 {
+		t.Errorf("second flush wrote %d rows, err %v", len(sink.rows), err)
+	}
+}
+
+func TestRecorderFoldsAddressesWhenFull(t *testing.T) {
+	sink := &memorySink{}
+	r := NewRecorder(sink)
+	t0 := time.Date(2026, 7, 20, 12, 0, 0, 0, time.UTC)
+
+	for i := 0; i < maxUsageRows+10; i++ {
+		r.Record(Hit{ClientIP: fmt.Sprintf("10.%d.%d.%d", i>>16&255, i>>8&255, i&255), Method: "GET", Route: "/api/nodes", Status: 200, At: t0})
+	}
+	r.Record(Hit{KeyID: "lab", Method: "GET", Route: "/api/nodes", Status: 200, At: t0})
+	if err := r.Flush(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	var folded, keyed int64
+	for _, row := range sink.rows {
+		switch {
+		case row.ClientIP == OverflowClientIP:
+			folded += row.Requests
+		case row.KeyID == "lab":
+			keyed += row.Requests
+		}
+	}
+	if folded != 10 {
+		t.Errorf("%d requests folded, want 10", folded)
+	}
+	if keyed != 1 {
+		t.Errorf("keyed traffic lost past the cap: %d", keyed)
+	}
+}
@@ -30,6 +30,11 @@ func (kg *KeyGenerator) NodeHistoryKey(zone, net, node int) string {
 	return fmt.Sprintf("%s:history:%d:%d:%d", kg.Prefix, zone, net, node)
 }
 
+// NodeTenanciesKey shares the history namespace: tenancies are cut from it
+func (kg *KeyGenerator) NodeTenanciesKey(zone, net, node int, domain string) string {
+	return fmt.Sprintf("%s:history:tenancies:%s:%d:%d:%d", kg.Prefix, domain, zone, net, node)
+}
+
 func (kg *KeyGenerator) NodeChangesKey(zone, net, node int, filterHash string) string {
 	return fmt.Sprintf("%s:changes:%d:%d:%d:%s", kg.Prefix, zone, net, node, filterHash)
 }
@@ -68,6 +73,21 @@ func (kg *KeyGenerator) UniqueSysopsKey(nameFilter string, limit, offset int) st
 	return fmt.Sprintf("%s:sysops:%s:%d:%d", kg.Prefix, filterHash, limit, offset)
 }
 
+// FuzzySysopsKey shares the sysops namespace so InvalidateSysops clears it too
+func (kg *KeyGenerator) FuzzySysopsKey(name string, limit, offset int) string {
+	hash := md5.Sum([]byte(name))
+	return fmt.Sprintf("%s:sysops:fuzzy:%s:%d:%d", kg.Prefix, hex.EncodeToString(hash[:8]), limit, offset)
+}
+
+// SysopIdentityKey and NodeSysopIdentitiesKey share the sysops namespace too
+func (kg *KeyGenerator) SysopIdentityKey(id string) string {
+	return fmt.Sprintf("%s:sysops:identity:%s", kg.Prefix, id)
+}
+
+func (kg *KeyGenerator) NodeSysopIdentitiesKey(zone, net, node int, domain string) string {
+	return fmt.Sprintf("%s:sysops:identity:node:%s:%d:%d:%d", kg.Prefix, domain, zone, net, node)
+}
+
 func (kg *KeyGenerator) NodesBySysopKey(sysopName string, limit int) string {
 	hash := md5.Sum([]byte(sysopName))
 	return fmt.Sprintf("%s:bysysop:%s:%d", kg.Prefix, hex.EncodeToString(hash[:8]), limit)
@@ -25,6 +25,7 @@ type Result struct {
 	Duration     time.Duration
 	NodesCount   int
 	NodelistDate time.Time // Date of the nodelist for flag statistics updates
+	Quarantined  bool      // Refused by the CRC check; not an error
 }
 
 // StorageInterface defines the interface for storage operations needed by concurrent processing.
@@ -33,6 +34,8 @@ type StorageInterface interface {
 	IsNodelistProcessed(time.Time, string) (bool, error)
 	FindConflictingNode(int, int, int, time.Time, string) (bool, error)
 	UpdateFlagStatistics(time.Time, string) error
+	RegisterNodelistFile(database.NodelistFile) error
+	InsertNodelistLines([]database.NodelistLine) error
 }
 
 // NodeOperations defines the node CRUD operations required by the adapter.
@@ -40,6 +43,8 @@ type NodeOperations interface {
 	InsertNodes([]database.Node) error
 	IsNodelistProcessed(time.Time, string) (bool, error)
 	FindConflictingNode(int, int, int, time.Time, string) (bool, error)
+	RegisterNodelistFile(database.NodelistFile) error
+	InsertNodelistLines([]database.NodelistLine) error
 }
 
 // FlagStatisticsUpdater defines the flag statistics update operation.
@@ -74,6 +79,14 @@ func (sa *StorageAdapter) FindConflictingNode(zone, net, node int, date time.Tim
 	return sa.nodeOps.FindConflictingNode(zone, net, node, date, domain)
 }
 
+func (sa *StorageAdapter) RegisterNodelistFile(file database.NodelistFile) error {
+	return sa.nodeOps.RegisterNodelistFile(file)
+}
+
+func (sa *StorageAdapter) InsertNodelistLines(lines []database.NodelistLine) error {
+	return sa.nodeOps.InsertNodelistLines(lines)
+}
+
 func (sa *StorageAdapter) UpdateFlagStatistics(date time.Time, domain string) error {
 	return sa.storage.UpdateFlagStatistics(date, domain)
 }
@@ -86,6 +99,9 @@ type MultiProcessor struct {
 	verbose    bool
 	quiet      bool
 	domain     string // FTN network the processed nodelists belong to
+
+	crcCheck      parser.CRCCheckMode
+	quarantineDir string
 }
 
 // SetDomain sets the FTN network stamped on parsed nodes and used for the
@@ -94,6 +110,14 @@ func (p *MultiProcessor) SetDomain(domain string) {
 	p.domain = domain
 }
 
+// SetCRCCheck sets how files whose computed CRC disagrees with their header
+// are treated, as the parser's -crc-check and -quarantine-dir flags do for the
+// sequential import. Defaults to warn when unset.
+func (p *MultiProcessor) SetCRCCheck(mode parser.CRCCheckMode, quarantineDir string) {
+	p.crcCheck = mode
+	p.quarantineDir = quarantineDir
+}
+
 // effectiveDomain returns the configured domain or the default network.
 func (p *MultiProcessor) effectiveDomain() string {
 	if p.domain == "" {
@@ -110,6 +134,7 @@ func NewMultiProcessor(storage StorageInterface, numWorkers int, batchSize int,
 		batchSize:  batchSize,
 		verbose:    verbose,
 		quiet:      quiet,
+		crcCheck:   parser.CRCCheckWarn,
 	}
 }
 
@@ -151,6 +176,7 @@ func (p *MultiProcessor) ProcessFiles(ctx context.Context, files []string) error
 	// Process results
 	totalNodes := 0
 	processedFiles := 0
+	quarantinedFiles := 0
 	var errors []error
 
 	// Track unique nodelist dates for flag statistics updates
@@ -164,6 +190,10 @@ func (p *MultiProcessor) ProcessFiles(ctx context.Context, files []string) error
 			errors = append(errors, result.Error)
 			continue
 		}
+		if result.Quarantined {
+			quarantinedFiles++
+			continue
+		}
 
 		totalNodes += result.NodesCount
 		processedFiles++
@@ -196,6 +226,9 @@ func (p *MultiProcessor) ProcessFiles(ctx context.Context, files []string) error
 		duration := time.Since(startTime)
 		fmt.Printf("\nConcurrent processing completed!\n")
 		fmt.Printf("Files processed: %d/%d\n", processedFiles, len(files))
+		if quarantinedFiles > 0 {
+			fmt.Printf("Files quarantined (CRC mismatch): %d\n", quarantinedFiles)
+		}
 		fmt.Printf("Total nodes imported: %d\n", totalNodes)
 		if totalNodes > 0 {
 			fmt.Printf("Average: %.2f nodes/second\n", float64(totalNodes)/duration.Seconds())
@@ -305,11 +338,18 @@ func (p *MultiProcessor) processFileWithParser(ctx context.Context, job Job, fil
 				fmt.Printf("  [%d] ALREADY IMPORTED: %s (date: %s)\n",
 					job.JobID, job.FilePath, nodelistDate.Format("2006-01-02"))
 			}
+			parser.RegisterNodelistFile(p.storage, p.effectiveDomain(), parseResult, true)
 			result.Duration = time.Since(startTime)
 			return result
 		}
 	}
 
+	if !p.crcPolicy().Admit(p.storage, p.effectiveDomain(), parseResult) {
+		result.Quarantined = true
+		result.Duration = time.Since(startTime)
+		return result
+	}
+
 	// Process nodes in batches
 	totalInserted := 0
 	for i := 0; i < len(nodes); i += p.batchSize {
@@ -336,8 +376,21 @@ func (p *MultiProcessor) processFileWithParser(ctx context.Context, job Job, fil
 		}
 	}
 
+	// Text lines are for rebuilding the file; losing them only degrades the
+	// export to an approximation, so this is a warning, not a failed file.
+	if err := p.storage.InsertNodelistLines(parseResult.TextLines); err != nil {
+		fmt.Printf("  Warning: Failed to store nodelist text lines for %s: %v\n", job.FilePath, err)
+	}
+	parser.RegisterNodelistFile(p.storage, p.effectiveDomain(), parseResult, true)
+
 	result.NodesCount = totalInserted
 	result.NodelistDate = parseResult.NodelistDate
 	result.Duration = time.Since(startTime)
 	return result
 }
+
+// crcPolicy is the CRC check the sequential import applies, with this
+// processor's settings.
+func (p *MultiProcessor) crcPolicy() parser.CRCPolicy {
+	return parser.CRCPolicy{Mode: p.crcCheck, QuarantineDir: p.quarantineDir, Quiet: p.quiet}
+}
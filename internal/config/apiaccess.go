@@ -0,0 +1,131 @@
+package config
+
+import (
+	"fmt"
+	"net/netip"
+	"strings"
+	"time"
+)
+
+// APIAccessConfig configures API keys, rate limits and usage accounting for
+// the public API (see internal/apiaccess). /api/health and /api/modem are
+// outside it: the first is for monitors, the second has its own callers.
+type APIAccessConfig struct {
+	Enabled bool `yaml:"enabled"`
+
+	// Keys come from a YAML file (KeysFile) or from the api_keys table, and
+	// are re-read every ReloadInterval, so a key can be added or revoked
+	// without a restart.
+	KeysSource     string `yaml:"keys_source"` // file or clickhouse
+	KeysFile       string `yaml:"keys_file,omitempty"`
+	ReloadInterval string `yaml:"reload_interval"`
+
+	// RequireKey refuses anonymous requests outright instead of limiting
+	// them per client address.
+	RequireKey bool            `yaml:"require_key,omitempty"`
+	Anonymous  RateLimitConfig `yaml:"anonymous"`
+	DefaultKey RateLimitConfig `yaml:"default_key"` // for keys that set no limit of their own
+
+	// TrustedProxies are the addresses (single IPs or CIDRs) whose
+	// X-Forwarded-For is believed when picking an anonymous client's
+	// address. Without them a client could pick its own rate-limit bucket.
+	TrustedProxies []string `yaml:"trusted_proxies,omitempty"`
+
+	// Usage counters are written to api_usage every UsageFlushInterval.
+	UsageFlushInterval string `yaml:"usage_flush_interval"`
+}
+
+// RateLimitConfig is one token bucket: RequestsPerMinute sustained, with
+// room for Burst requests at once.
+type RateLimitConfig struct {
+	RequestsPerMinute int `yaml:"requests_per_minute"`
+	Burst             int `yaml:"burst"`
+}
+
+// Durations parses ReloadInterval and UsageFlushInterval.
+func (a APIAccessConfig) Durations() (reload, flush time.Duration, err error) {
+	if reload, err = time.ParseDuration(a.ReloadInterval); err != nil {
+		return 0, 0, fmt.Errorf("api_access.reload_interval: %w", err)
+	}
+	if flush, err = time.ParseDuration(a.UsageFlushInterval); err != nil {
+		return 0, 0, fmt.Errorf("api_access.usage_flush_interval: %w", err)
+	}
+	if reload <= 0 || flush <= 0 {
+		return 0, 0, fmt.Errorf("api_access.reload_interval and usage_flush_interval must be positive")
+	}
+	return reload, flush, nil
+}
+
+// Proxies parses TrustedProxies. A bare address is a single-host prefix.
+func (a APIAccessConfig) Proxies() ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, p := range a.TrustedProxies {
+		if !strings.Contains(p, "/") {
+			addr, err := netip.ParseAddr(p)
+			if err != nil {
+				return nil, fmt.Errorf("api_access.trusted_proxies: %w", err)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(p)
+		if err != nil {
+			return nil, fmt.Errorf("api_access.trusted_proxies: %w", err)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
+// validateAPIAccess validates the API access configuration and sets
+// defaults. Nothing is checked while it is switched off.
+func (c *Config) validateAPIAccess() error {
+	a := &c.APIAccess
+	if !a.Enabled {
+		return nil
+	}
+
+	switch a.KeysSource {
+	case "", "file":
+		a.KeysSource = "file"
+		if a.KeysFile == "" {
+			return fmt.Errorf("api_access.keys_file is required when api_access.keys_source is file")
+		}
+	case "clickhouse":
+	default:
+		return fmt.Errorf("api_access.keys_source must be file or clickhouse, got %q", a.KeysSource)
+	}
+	if a.ReloadInterval == "" {
+		a.ReloadInterval = "1m"
+	}
+	if a.UsageFlushInterval == "" {
+		a.UsageFlushInterval = "1m"
+	}
+	if _, _, err := a.Durations(); err != nil {
+		return err
+	}
+
+	if err := a.Anonymous.defaults("anonymous", 60, 20); err != nil {
+		return err
+	}
+	if err := a.DefaultKey.defaults("default_key", 600, 100); err != nil {
+		return err
+	}
+	if _, err := a.Proxies(); err != nil {
+		return err
+	}
+	return nil
+}
+
+func (r *RateLimitConfig) defaults(name string, perMinute, burst int) error {
+	if r.RequestsPerMinute == 0 {
+		r.RequestsPerMinute = perMinute
+	}
+	if r.Burst == 0 {
+		r.Burst = burst
+	}
+	if r.RequestsPerMinute < 0 || r.Burst < 1 {
+		return fmt.Errorf("api_access.%s: requests_per_minute must be positive and burst at least 1", name)
+	}
+	return nil
+}
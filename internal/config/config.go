@@ -50,16 +50,19 @@ func (nc *NetworkConfig) Pattern() *regexp.Regexp {
 
 // Config represents the complete application configuration
 type Config struct {
-	ClickHouse        ClickHouseConfig  `yaml:"clickhouse"`
-	Cache             CacheConfig       `yaml:"cache"`
-	FTP               FTPConfig         `yaml:"ftp"`
-	ModemAPI          ModemAPIConfig    `yaml:"modem_api"`
-	Networks          []NetworkConfig   `yaml:"networks,omitempty"` // FTN networks (defaults to fidonet if absent)
-	LinksFile         string            `yaml:"links_file"`         // Path to links.yaml for external FidoNet links
-	QueryBudget       QueryBudgetConfig `yaml:"query_budget,omitempty"`
-	ServerLogging     LoggingConfig     `yaml:"server_logging"`
-	ParserLogging     LoggingConfig     `yaml:"parser_logging"`
-	TestdaemonLogging LoggingConfig     `yaml:"testdaemon_logging"`
+	ClickHouse        ClickHouseConfig    `yaml:"clickhouse"`
+	Cache             CacheConfig         `yaml:"cache"`
+	FTP               FTPConfig           `yaml:"ftp"`
+	ModemAPI          ModemAPIConfig      `yaml:"modem_api"`
+	Networks          []NetworkConfig     `yaml:"networks,omitempty"` // FTN networks (defaults to fidonet if absent)
+	LinksFile         string              `yaml:"links_file"`         // Path to links.yaml for external FidoNet links
+	QueryBudget       QueryBudgetConfig   `yaml:"query_budget,omitempty"`
+	Notifications     NotificationsConfig `yaml:"notifications,omitempty"`
+	FidoReports       FidoReportsConfig   `yaml:"fido_reports,omitempty"`
+	APIAccess         APIAccessConfig     `yaml:"api_access,omitempty"`
+	ServerLogging     LoggingConfig       `yaml:"server_logging"`
+	ParserLogging     LoggingConfig       `yaml:"parser_logging"`
+	TestdaemonLogging LoggingConfig       `yaml:"testdaemon_logging"`
 
 	// Deprecated: Use component-specific logging configs instead
 	Logging LoggingConfig `yaml:"logging,omitempty"`
@@ -75,6 +78,15 @@ func (c *Config) Network(name string) *NetworkConfig {
 	return nil
 }
 
+// NetworkNames returns the names of the configured networks.
+func (c *Config) NetworkNames() []string {
+	names := make([]string, len(c.Networks))
+	for i := range c.Networks {
+		names[i] = c.Networks[i].Name
+	}
+	return names
+}
+
 // QueryBudgetConfig bounds how long one HTTP request's database work may run.
 //
 // Off by default, and deliberately so: cancellation (which needs no deadline)
@@ -513,6 +525,21 @@ func (c *Config) validate() error {
 		return err
 	}
 
+	// Validate change notifications configuration
+	if err := c.validateNotifications(); err != nil {
+		return err
+	}
+
+	// Validate FidoNet reports configuration
+	if err := c.validateFidoReports(); err != nil {
+		return err
+	}
+
+	// Validate public API access configuration
+	if err := c.validateAPIAccess(); err != nil {
+		return err
+	}
+
 	// Validate networks configuration; inject the default fidonet entry when
 	// the section is absent so single-network installs keep working unchanged
 	if err := c.validateNetworks(); err != nil {
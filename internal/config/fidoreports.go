@@ -0,0 +1,61 @@
+package config
+
+import (
+	"fmt"
+
+	"github.com/nodelistdb/internal/ftnpkt"
+)
+
+// FidoReportsConfig configures cmd/fidoreport, which posts the analytics
+// reports into FidoNet as echomail or netmail. Packets are dropped into
+// Outbound, addressed to Uplink, for the local mailer to pick up; messages to
+// individual sysops are routed through the same uplink.
+type FidoReportsConfig struct {
+	Outbound       string `yaml:"outbound"`
+	FromAddress    string `yaml:"from_address"`
+	FromName       string `yaml:"from_name"`
+	Uplink         string `yaml:"uplink"`
+	PacketPassword string `yaml:"packet_password,omitempty"`
+
+	// EchoArea is where reports go when posted to an echo, addressed to
+	// EchoTo and signed with Origin.
+	EchoArea string `yaml:"echo_area,omitempty"`
+	EchoTo   string `yaml:"echo_to,omitempty"`
+	Origin   string `yaml:"origin,omitempty"`
+
+	// MaxMessageSize splits a report whose text is longer into several
+	// messages; many readers still choke on messages beyond a few dozen KB.
+	MaxMessageSize int `yaml:"max_message_size,omitempty"`
+}
+
+// validateFidoReports validates the FidoNet reports configuration and sets
+// defaults. Nothing is checked while no outbound directory is configured.
+func (c *Config) validateFidoReports() error {
+	f := &c.FidoReports
+	if f.Outbound == "" {
+		return nil
+	}
+
+	if _, err := ftnpkt.ParseAddress(f.FromAddress); err != nil {
+		return fmt.Errorf("fido_reports.from_address: %w", err)
+	}
+	if _, err := ftnpkt.ParseAddress(f.Uplink); err != nil {
+		return fmt.Errorf("fido_reports.uplink: %w", err)
+	}
+	if len(f.PacketPassword) > 8 {
+		return fmt.Errorf("fido_reports.packet_password must be at most 8 characters")
+	}
+	if f.FromName == "" {
+		f.FromName = "NodelistDB"
+	}
+	if f.EchoTo == "" {
+		f.EchoTo = "All"
+	}
+	if f.MaxMessageSize == 0 {
+		f.MaxMessageSize = 12000
+	}
+	if f.MaxMessageSize < 1000 {
+		return fmt.Errorf("fido_reports.max_message_size must be at least 1000, got %d", f.MaxMessageSize)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+// NotificationsConfig configures the node change notifications cmd/parser
+// sends after an import (see internal/notify). Who is told about what lives
+// in the subscriptions file, kept apart from this one so subscribers can be
+// added without touching the database credentials next to them, and in the
+// subscriptions API key holders register through /api/subscriptions.
+type NotificationsConfig struct {
+	Enabled           bool   `yaml:"enabled"`
+	SubscriptionsFile string `yaml:"subscriptions_file,omitempty"` // optional
+
+	// SigningSecret keys the HMAC-SHA256 signature on webhook payloads. A
+	// subscription with a secret of its own uses that one instead.
+	SigningSecret string `yaml:"signing_secret,omitempty"`
+
+	// A failed delivery is tried MaxAttempts times in all, waiting RetryDelay
+	// after the first failure and twice as long after each one after that.
+	MaxAttempts int    `yaml:"max_attempts"`
+	RetryDelay  string `yaml:"retry_delay"`
+	Timeout     string `yaml:"timeout"` // per attempt
+
+	SMTP SMTPConfig `yaml:"smtp,omitempty"`
+}
+
+// SMTPConfig is the relay change notifications are mailed through.
+type SMTPConfig struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Username string `yaml:"username,omitempty"`
+	Password string `yaml:"password,omitempty"`
+	From     string `yaml:"from"`
+}
+
+// Durations parses RetryDelay and Timeout.
+func (n NotificationsConfig) Durations() (retryDelay, timeout time.Duration, err error) {
+	if retryDelay, err = time.ParseDuration(n.RetryDelay); err != nil {
+		return 0, 0, fmt.Errorf("notifications.retry_delay: %w", err)
+	}
+	if timeout, err = time.ParseDuration(n.Timeout); err != nil {
+		return 0, 0, fmt.Errorf("notifications.timeout: %w", err)
+	}
+	return retryDelay, timeout, nil
+}
+
+// validateNotifications validates the notifications configuration and sets
+// defaults. Nothing is checked while it is switched off.
+func (c *Config) validateNotifications() error {
+	n := &c.Notifications
+	if !n.Enabled {
+		return nil
+	}
+
+	if n.MaxAttempts == 0 {
+		n.MaxAttempts = 5
+	}
+	if n.MaxAttempts < 1 || n.MaxAttempts > 20 {
+		return fmt.Errorf("notifications.max_attempts must be between 1 and 20, got %d", n.MaxAttempts)
+	}
+	if n.RetryDelay == "" {
+		n.RetryDelay = "10s"
+	}
+	if n.Timeout == "" {
+		n.Timeout = "15s"
+	}
+	if _, _, err := n.Durations(); err != nil {
+		return err
+	}
+
+	if n.SMTP.Host != "" {
+		if n.SMTP.Port == 0 {
+			n.SMTP.Port = 25
+		}
+		if n.SMTP.From == "" {
+			return fmt.Errorf("notifications.smtp.from is required when notifications.smtp.host is set")
+		}
+	}
+	return nil
+}
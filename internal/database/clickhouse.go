@@ -229,8 +229,9 @@ func (db *ClickHouseDB) CreateSchema() error {
 		-- FTS unique identifier
 		fts_id String,
 
-		-- Raw nodelist line
+		-- Raw nodelist line, and where in the file it was
 		raw_line String DEFAULT '',
+		line_number UInt32 DEFAULT 0,
 
 		-- Analytics optimization: materialized columns
 		year UInt16 MATERIALIZED toYear(nodelist_date),
@@ -372,6 +373,229 @@ func (db *ClickHouseDB) CreateSchema() error {
 		return fmt.Errorf("failed to create pointlist_files table: %w", err)
 	}
 
+	// Create nodelist_files registry: one row per nodelist file an import
+	// looked at, with its header and computed CRC-16 and the verdict
+	nodelistFilesSQL := `
+	CREATE TABLE IF NOT EXISTS nodelist_files (
+		domain          LowCardinality(String),
+		nodelist_date   Date,
+		day_number      Int32,
+		filename        String,
+		header_crc      UInt16,
+		has_header_crc  Bool,
+		computed_crc    UInt16,
+		crc_verdict     LowCardinality(String),
+		nodes_count     UInt32,
+		imported        Bool,
+		checked_at      DateTime DEFAULT now()
+	) ENGINE = ReplacingMergeTree(checked_at)
+	ORDER BY (domain, nodelist_date)
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, nodelistFilesSQL); err != nil {
+		return fmt.Errorf("failed to create nodelist_files table: %w", err)
+	}
+
+	// Create nodelist_lines: the lines of each imported nodelist that node
+	// rows do not reproduce, for rebuilding the file (see parser.RebuildNodelist)
+	nodelistLinesSQL := `
+	CREATE TABLE IF NOT EXISTS nodelist_lines (
+		domain          LowCardinality(String),
+		nodelist_date   Date,
+		line_number     UInt32,
+		raw_line        String
+	) ENGINE = ReplacingMergeTree
+	PARTITION BY toYear(nodelist_date)
+	ORDER BY (domain, nodelist_date, line_number)
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, nodelistLinesSQL); err != nil {
+		return fmt.Errorf("failed to create nodelist_lines table: %w", err)
+	}
+
+	// Create notification_deliveries: one row per attempt to deliver node
+	// change events to a subscriber (see internal/notify)
+	notificationDeliveriesSQL := `
+	CREATE TABLE IF NOT EXISTS notification_deliveries (
+		delivery_id      String,
+		subscription_id  String,
+		channel          LowCardinality(String),
+		target           String,
+		domain           LowCardinality(String),
+		nodelist_date    Date,
+		event_count      UInt32,
+		attempt          UInt8,
+		status           LowCardinality(String),
+		http_status      UInt16,
+		error            String,
+		duration_ms      UInt32,
+		attempted_at     DateTime
+	) ENGINE = MergeTree
+	PARTITION BY toYYYYMM(attempted_at)
+	ORDER BY (subscription_id, attempted_at)
+	TTL attempted_at + INTERVAL 1 YEAR
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, notificationDeliveriesSQL); err != nil {
+		return fmt.Errorf("failed to create notification_deliveries table: %w", err)
+	}
+
+	// Create notification_subscriptions: the subscriptions registered
+	// through the API (see internal/notify)
+	notificationSubscriptionsSQL := `
+	CREATE TABLE IF NOT EXISTS notification_subscriptions (
+		subscription_id  String,
+		owner            String,
+		domain           LowCardinality(String),
+		filter_address   String,
+		filter_net       String,
+		filter_sysop     String,
+		filter_flag      String,
+		events           Array(LowCardinality(String)),
+		webhook          String,
+		secret           String,
+		email            String,
+		deleted          Bool,
+		updated_at       DateTime64(3)
+	) ENGINE = ReplacingMergeTree(updated_at)
+	ORDER BY subscription_id
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, notificationSubscriptionsSQL); err != nil {
+		return fmt.Errorf("failed to create notification_subscriptions table: %w", err)
+	}
+
+	// Create api_keys: the public API keys, when the server reads them from
+	// ClickHouse rather than a file (see internal/apiaccess)
+	apiKeysSQL := `
+	CREATE TABLE IF NOT EXISTS api_keys (
+		key_id               String,
+		owner                String,
+		key_hash             String,
+		requests_per_minute  UInt32,
+		burst                UInt32,
+		admin                Bool,
+		disabled             Bool,
+		updated_at           DateTime DEFAULT now()
+	) ENGINE = ReplacingMergeTree(updated_at)
+	ORDER BY key_id
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, apiKeysSQL); err != nil {
+		return fmt.Errorf("failed to create api_keys table: %w", err)
+	}
+
+	// Create api_usage: public API requests per caller, route and status,
+	// summed per minute
+	apiUsageSQL := `
+	CREATE TABLE IF NOT EXISTS api_usage (
+		period       DateTime,
+		key_id       LowCardinality(String),
+		client_ip    String,
+		method       LowCardinality(String),
+		route        LowCardinality(String),
+		status       UInt16,
+		requests     UInt64,
+		bytes        UInt64,
+		duration_ms  UInt64
+	) ENGINE = SummingMergeTree((requests, bytes, duration_ms))
+	PARTITION BY toYYYYMM(period)
+	ORDER BY (period, key_id, client_ip, route, method, status)
+	TTL period + INTERVAL 1 YEAR
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, apiUsageSQL); err != nil {
+		return fmt.Errorf("failed to create api_usage table: %w", err)
+	}
+
+	// Create sysop_identities and sysop_identity_redirects: node and point
+	// tenures resolved into persons by the parser's -resolve-sysops mode
+	sysopIdentitiesSQL := `
+	CREATE TABLE IF NOT EXISTS sysop_identities (
+		sysop_id          String,
+		display_name      String,
+		domain            LowCardinality(String),
+		zone              Int32,
+		net               Int32,
+		node              Int32,
+		point             Int32,
+		is_point          Bool,
+		sysop_name        String,
+		system_name       String,
+		first_seen        Date,
+		last_seen         Date,
+		currently_listed  Bool,
+		evidence          Array(String),
+		resolved_at       DateTime
+	) ENGINE = MergeTree
+	ORDER BY (sysop_id, domain, zone, net, node, point, sysop_name)
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, sysopIdentitiesSQL); err != nil {
+		return fmt.Errorf("failed to create sysop_identities table: %w", err)
+	}
+
+	sysopIdentityRedirectsSQL := `
+	CREATE TABLE IF NOT EXISTS sysop_identity_redirects (
+		old_id       String,
+		sysop_id     String,
+		resolved_at  DateTime
+	) ENGINE = MergeTree
+	ORDER BY old_id
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, sysopIdentityRedirectsSQL); err != nil {
+		return fmt.Errorf("failed to create sysop_identity_redirects table: %w", err)
+	}
+
+	// Create node_tenancies: node histories cut by occupant by the parser's
+	// -detect-tenancies mode, joined by the node searches
+	nodeTenanciesSQL := `
+	CREATE TABLE IF NOT EXISTS node_tenancies (
+		domain        LowCardinality(String),
+		zone          Int32,
+		net           Int32,
+		node          Int32,
+		tenancy       UInt16,
+		tenancies     UInt16,
+		sysop_name    String,
+		system_name   String,
+		location      String,
+		first_date    Date,
+		last_date     Date,
+		entries       UInt32,
+		start_reason  LowCardinality(String),
+		detected_at   DateTime
+	) ENGINE = MergeTree
+	ORDER BY (domain, zone, net, node, first_date)
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, nodeTenanciesSQL); err != nil {
+		return fmt.Errorf("failed to create node_tenancies table: %w", err)
+	}
+
+	// Create location_geocodes: each distinct nodelist Location resolved with
+	// the bundled gazetteer by the parser's -geocode-locations mode, joined
+	// by the node map
+	locationGeocodesSQL := `
+	CREATE TABLE IF NOT EXISTS location_geocodes (
+		location      String,
+		place         String,
+		country_code  LowCardinality(String),
+		country       LowCardinality(String),
+		latitude      Float64,
+		longitude     Float64,
+		precision     LowCardinality(String),
+		gazetteer     LowCardinality(String),
+		geocoded_at   DateTime
+	) ENGINE = ReplacingMergeTree(geocoded_at)
+	ORDER BY location
+	SETTINGS index_granularity = 8192`
+
+	if err := db.execSQL(ctx, locationGeocodesSQL); err != nil {
+		return fmt.Errorf("failed to create location_geocodes table: %w", err)
+	}
+
 	return nil
 }
 
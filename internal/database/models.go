@@ -54,6 +54,22 @@ type Node struct {
 
 	// Raw nodelist line (original format from file)
 	RawLine string `json:"raw_line,omitempty"`
+
+	// LineNumber is the 1-based line of the nodelist file this entry came
+	// from. Zero for rows imported before line numbers were recorded.
+	LineNumber int `json:"line_number,omitempty"`
+}
+
+// NodelistLine is one line of a nodelist file that no node row reproduces
+// byte for byte: the header, comments, blank lines, inline point lines, lines
+// the parser could not read, and node lines whose raw text differs from the
+// stored raw_line. Together with the node rows' line numbers these rebuild
+// the file exactly.
+type NodelistLine struct {
+	Domain       string    `json:"domain"`
+	NodelistDate time.Time `json:"nodelist_date"`
+	LineNumber   int       `json:"line_number"`
+	RawLine      string    `json:"raw_line"`
 }
 
 // ComputeFtsId generates the FTS identifier for this node.
@@ -345,6 +361,116 @@ type PointlistFile struct {
 	ImportedAt    time.Time `json:"imported_at"`
 }
 
+// NodelistFile is one nodelist file's row in the nodelist_files registry: the
+// CRC its header declares, the CRC its contents compute to, and the verdict
+// the import reached. Unlike pointlist_files this is a record, not a gate -
+// the nodes table stays the authority on whether a date was imported - so a
+// refused file has a row too, with Imported false.
+type NodelistFile struct {
+	Domain       string    `json:"domain"`
+	NodelistDate time.Time `json:"nodelist_date"`
+	DayNumber    int       `json:"day_number"`
+	Filename     string    `json:"filename"`
+	HeaderCRC    uint16    `json:"header_crc"`
+	HasHeaderCRC bool      `json:"has_header_crc"`
+	ComputedCRC  uint16    `json:"computed_crc"`
+	CRCVerdict   string    `json:"crc_verdict"` // ok, mismatch or no_header
+	NodesCount   uint32    `json:"nodes_count"`
+	Imported     bool      `json:"imported"`
+	CheckedAt    time.Time `json:"checked_at"`
+}
+
+// CRCMismatch reports whether the file is known to be corrupt.
+func (f NodelistFile) CRCMismatch() bool {
+	return f.CRCVerdict == "mismatch"
+}
+
+// NotificationDelivery is one attempt to deliver a batch of node change
+// events to one subscription, as kept in notification_deliveries. A batch
+// that needed retries has one row per attempt, all sharing DeliveryID.
+type NotificationDelivery struct {
+	DeliveryID     string        `json:"delivery_id"`
+	SubscriptionID string        `json:"subscription_id"`
+	Channel        string        `json:"channel"` // webhook or email
+	Target         string        `json:"target"`  // URL or mail address
+	Domain         string        `json:"domain"`
+	NodelistDate   time.Time     `json:"nodelist_date"`
+	EventCount     int           `json:"event_count"`
+	Attempt        int           `json:"attempt"`
+	Status         string        `json:"status"` // delivered, retrying or failed
+	HTTPStatus     int           `json:"http_status,omitempty"`
+	Error          string        `json:"error,omitempty"`
+	Duration       time.Duration `json:"duration"`
+	AttemptedAt    time.Time     `json:"attempted_at"`
+}
+
+// NotificationSubscription is a change notification subscription registered
+// through the API, as kept in notification_subscriptions (see
+// internal/notify). The newest row per ID wins; a deleted subscription is a
+// newer row with Deleted set.
+type NotificationSubscription struct {
+	ID            string    `json:"id"`
+	Owner         string    `json:"owner"` // API key that registered it
+	Domain        string    `json:"domain"`
+	FilterAddress string    `json:"filter_address,omitempty"`
+	FilterNet     string    `json:"filter_net,omitempty"`
+	FilterSysop   string    `json:"filter_sysop,omitempty"`
+	FilterFlag    string    `json:"filter_flag,omitempty"`
+	Events        []string  `json:"events,omitempty"`
+	Webhook       string    `json:"webhook,omitempty"`
+	Secret        string    `json:"-"`
+	Email         string    `json:"email,omitempty"`
+	Deleted       bool      `json:"-"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+// APIKey is one public API key, as kept in api_keys or in the keys file (see
+// internal/apiaccess). Only the key's SHA-256 is stored, in the same
+// "sha256:<hex>" form the modem API callers use.
+type APIKey struct {
+	ID                string `json:"id"`
+	Owner             string `json:"owner"`
+	KeyHash           string `json:"-"`
+	RequestsPerMinute int    `json:"requests_per_minute"` // 0: the configured default
+	Burst             int    `json:"burst"`               // 0: the configured default
+	Admin             bool   `json:"admin"`               // may read /api/usage
+	Disabled          bool   `json:"disabled"`
+}
+
+// APIUsage is the public API traffic of one caller on one route, with one
+// status, in one minute, as kept in api_usage. A keyed caller is its KeyID;
+// an anonymous one has an empty KeyID and is told apart by ClientIP.
+type APIUsage struct {
+	Period   time.Time
+	KeyID    string
+	ClientIP string
+	Method   string
+	Route    string // chi route pattern, e.g. /api/nodes/{zone}/{net}/{node}
+	Status   int
+	Requests int64
+	Bytes    int64
+	Duration time.Duration // summed over Requests
+}
+
+// APIUsageFilter selects the api_usage rows a usage summary covers.
+type APIUsageFilter struct {
+	From, To time.Time // To is exclusive
+	KeyID    *string   // "" selects anonymous traffic
+	Limit    int
+}
+
+// APIUsageSummary is one caller's traffic on one route over a window.
+type APIUsageSummary struct {
+	KeyID         string  `json:"key_id"`
+	ClientIP      string  `json:"client_ip,omitempty"`
+	Route         string  `json:"route"`
+	Requests      int64   `json:"requests"`
+	Rejected      int64   `json:"rejected"` // answered 429
+	Errors        int64   `json:"errors"`   // answered 5xx
+	Bytes         int64   `json:"bytes"`
+	AvgDurationMs float64 `json:"avg_duration_ms"`
+}
+
 // NetworkStats represents aggregated network statistics
 // RegionInfo holds information about a region
 type RegionInfo struct {
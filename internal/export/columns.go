@@ -0,0 +1,322 @@
+package export
+
+import (
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// The column sets. Names are the ClickHouse column names. The files are flat,
+// so array columns are joined with commas - for flags that is the nodelist's
+// own spelling - and internet_config is its JSON text, null when a row has
+// none.
+
+// NodeColumns are the columns of a nodes export.
+var NodeColumns = []Column[database.Node]{
+	str("domain", func(n *database.Node) string { return n.Domain }),
+	integer("zone", func(n *database.Node) int64 { return int64(n.Zone) }),
+	integer("net", func(n *database.Node) int64 { return int64(n.Net) }),
+	integer("node", func(n *database.Node) int64 { return int64(n.Node) }),
+	date("nodelist_date", func(n *database.Node) time.Time { return n.NodelistDate }),
+	integer("day_number", func(n *database.Node) int64 { return int64(n.DayNumber) }),
+	str("node_type", func(n *database.Node) string { return n.NodeType }),
+	{Name: "region", Kind: Int, Value: func(n *database.Node) any {
+		if n.Region == nil {
+			return nil
+		}
+		return int64(*n.Region)
+	}},
+	str("system_name", func(n *database.Node) string { return n.SystemName }),
+	str("location", func(n *database.Node) string { return n.Location }),
+	str("sysop_name", func(n *database.Node) string { return n.SysopName }),
+	str("phone", func(n *database.Node) string { return n.Phone }),
+	integer("max_speed", func(n *database.Node) int64 { return int64(n.MaxSpeed) }),
+	boolean("is_cm", func(n *database.Node) bool { return n.IsCM }),
+	boolean("is_mo", func(n *database.Node) bool { return n.IsMO }),
+	boolean("has_inet", func(n *database.Node) bool { return n.HasInet }),
+	list("flags", func(n *database.Node) []string { return n.Flags }),
+	list("modem_flags", func(n *database.Node) []string { return n.ModemFlags }),
+	rawJSON("internet_config", func(n *database.Node) []byte { return n.InternetConfig }),
+	integer("conflict_sequence", func(n *database.Node) int64 { return int64(n.ConflictSequence) }),
+	boolean("has_conflict", func(n *database.Node) bool { return n.HasConflict }),
+	str("fts_id", func(n *database.Node) string { return n.FtsId }),
+	str("raw_line", func(n *database.Node) string { return n.RawLine }),
+}
+
+// PointColumns are the columns of a points export.
+var PointColumns = []Column[database.Point]{
+	str("domain", func(p *database.Point) string { return p.Domain }),
+	integer("zone", func(p *database.Point) int64 { return int64(p.Zone) }),
+	integer("net", func(p *database.Point) int64 { return int64(p.Net) }),
+	integer("node", func(p *database.Point) int64 { return int64(p.Node) }),
+	integer("point", func(p *database.Point) int64 { return int64(p.PointNum) }),
+	date("pointlist_date", func(p *database.Point) time.Time { return p.PointlistDate }),
+	integer("day_number", func(p *database.Point) int64 { return int64(p.DayNumber) }),
+	str("list_source", func(p *database.Point) string { return p.ListSource }),
+	integer("source_priority", func(p *database.Point) int64 { return int64(p.SourcePriority) }),
+	str("source_format", func(p *database.Point) string { return p.SourceFormat }),
+	str("system_name", func(p *database.Point) string { return p.SystemName }),
+	str("location", func(p *database.Point) string { return p.Location }),
+	str("sysop_name", func(p *database.Point) string { return p.SysopName }),
+	str("phone", func(p *database.Point) string { return p.Phone }),
+	integer("max_speed", func(p *database.Point) int64 { return int64(p.MaxSpeed) }),
+	boolean("is_cm", func(p *database.Point) bool { return p.IsCM }),
+	boolean("is_mo", func(p *database.Point) bool { return p.IsMO }),
+	boolean("has_inet", func(p *database.Point) bool { return p.HasInet }),
+	list("flags", func(p *database.Point) []string { return p.Flags }),
+	list("modem_flags", func(p *database.Point) []string { return p.ModemFlags }),
+	rawJSON("internet_config", func(p *database.Point) []byte { return p.InternetConfig }),
+	integer("conflict_sequence", func(p *database.Point) int64 { return int64(p.ConflictSequence) }),
+	boolean("has_conflict", func(p *database.Point) bool { return p.HasConflict }),
+	str("fts_id", func(p *database.Point) string { return p.FtsId }),
+	str("raw_line", func(p *database.Point) string { return p.RawLine }),
+}
+
+type result = storage.NodeTestResult
+
+// TestResultColumns are the columns of a node_test_results export: one row
+// per stored row, per-hostname and aggregated alike, which is_aggregated and
+// hostname_index tell apart.
+var TestResultColumns = []Column[result]{
+	timestamp("test_time", func(r *result) time.Time { return r.TestTime }),
+	integer("zone", func(r *result) int64 { return int64(r.Zone) }),
+	integer("net", func(r *result) int64 { return int64(r.Net) }),
+	integer("node", func(r *result) int64 { return int64(r.Node) }),
+	str("address", func(r *result) string { return r.Address }),
+	str("hostname", func(r *result) string { return r.Hostname }),
+	list("resolved_ipv4", func(r *result) []string { return r.ResolvedIPv4 }),
+	list("resolved_ipv6", func(r *result) []string { return r.ResolvedIPv6 }),
+	str("dns_error", func(r *result) string { return r.DNSError }),
+
+	// Geolocation
+	str("country", func(r *result) string { return r.Country }),
+	str("country_code", func(r *result) string { return r.CountryCode }),
+	str("city", func(r *result) string { return r.City }),
+	str("region", func(r *result) string { return r.Region }),
+	float("latitude", func(r *result) float64 { return float64(r.Latitude) }),
+	float("longitude", func(r *result) float64 { return float64(r.Longitude) }),
+	str("isp", func(r *result) string { return r.ISP }),
+	str("org", func(r *result) string { return r.Org }),
+	integer("asn", func(r *result) int64 { return int64(r.ASN) }),
+
+	// BinkP Test Results
+	boolean("binkp_tested", func(r *result) bool { return r.BinkPTested }),
+	boolean("binkp_success", func(r *result) bool { return r.BinkPSuccess }),
+	integer("binkp_response_ms", func(r *result) int64 { return int64(r.BinkPResponseMs) }),
+	str("binkp_system_name", func(r *result) string { return r.BinkPSystemName }),
+	str("binkp_sysop", func(r *result) string { return r.BinkPSysop }),
+	str("binkp_location", func(r *result) string { return r.BinkPLocation }),
+	str("binkp_version", func(r *result) string { return r.BinkPVersion }),
+	list("binkp_addresses", func(r *result) []string { return r.BinkPAddresses }),
+	list("binkp_capabilities", func(r *result) []string { return r.BinkPCapabilities }),
+	str("binkp_error", func(r *result) string { return r.BinkPError }),
+	str("binkp_auth_method", func(r *result) string { return r.BinkPAuthMethod }),
+	str("binkp_mail_exchange", func(r *result) string { return r.BinkPMailExchange }),
+	str("binkp_mail_detail", func(r *result) string { return r.BinkPMailDetail }),
+	str("binkp_opt_cram", func(r *result) string { return r.BinkPOptCRAM }),
+	str("binkp_opt_crypt", func(r *result) string { return r.BinkPOptCrypt }),
+	str("binkp_opt_plz", func(r *result) string { return r.BinkPOptPLZ }),
+	str("binkp_opt_gz", func(r *result) string { return r.BinkPOptGZ }),
+	str("binkp_opt_bz2", func(r *result) string { return r.BinkPOptBZ2 }),
+
+	// BinkP over TLS (IBNS)
+	boolean("binkps_tested", func(r *result) bool { return r.BinkPSTested }),
+	boolean("binkps_success", func(r *result) bool { return r.BinkPSSuccess }),
+	integer("binkps_response_ms", func(r *result) int64 { return int64(r.BinkPSResponseMs) }),
+	str("binkps_error", func(r *result) string { return r.BinkPSError }),
+	str("binkps_cert_subject", func(r *result) string { return r.BinkPSCertSubject }),
+	str("binkps_cert_issuer", func(r *result) string { return r.BinkPSCertIssuer }),
+	optionalTimestamp("binkps_cert_not_after", func(r *result) *time.Time { return r.BinkPSCertNotAfter }),
+	boolean("binkps_cert_chain_valid", func(r *result) bool { return r.BinkPSCertChainValid }),
+	boolean("binkps_cert_self_signed", func(r *result) bool { return r.BinkPSCertSelfSigned }),
+	str("binkps_cert_error", func(r *result) string { return r.BinkPSCertError }),
+
+	// IFCICO Test Results
+	boolean("ifcico_tested", func(r *result) bool { return r.IfcicoTested }),
+	boolean("ifcico_success", func(r *result) bool { return r.IfcicoSuccess }),
+	integer("ifcico_response_ms", func(r *result) int64 { return int64(r.IfcicoResponseMs) }),
+	str("ifcico_mailer_info", func(r *result) string { return r.IfcicoMailerInfo }),
+	str("ifcico_system_name", func(r *result) string { return r.IfcicoSystemName }),
+	list("ifcico_addresses", func(r *result) []string { return r.IfcicoAddresses }),
+	str("ifcico_response_type", func(r *result) string { return r.IfcicoResponseType }),
+	str("ifcico_error", func(r *result) string { return r.IfcicoError }),
+
+	// Telnet Test Results
+	boolean("telnet_tested", func(r *result) bool { return r.TelnetTested }),
+	boolean("telnet_success", func(r *result) bool { return r.TelnetSuccess }),
+	integer("telnet_response_ms", func(r *result) int64 { return int64(r.TelnetResponseMs) }),
+	str("telnet_error", func(r *result) string { return r.TelnetError }),
+	str("telnet_mailer_info", func(r *result) string { return r.TelnetMailerInfo }),
+	str("telnet_system_name", func(r *result) string { return r.TelnetSystemName }),
+	str("telnet_sysop", func(r *result) string { return r.TelnetSysop }),
+	list("telnet_addresses", func(r *result) []string { return r.TelnetAddresses }),
+	str("telnet_response_type", func(r *result) string { return r.TelnetResponseType }),
+
+	// FTP Test Results
+	boolean("ftp_tested", func(r *result) bool { return r.FTPTested }),
+	boolean("ftp_success", func(r *result) bool { return r.FTPSuccess }),
+	integer("ftp_response_ms", func(r *result) int64 { return int64(r.FTPResponseMs) }),
+	str("ftp_error", func(r *result) string { return r.FTPError }),
+	optionalBool("ftp_anon_success", func(r *result) *bool { return r.FTPAnonSuccess }),
+
+	// VModem Test Results
+	boolean("vmodem_tested", func(r *result) bool { return r.VModemTested }),
+	boolean("vmodem_success", func(r *result) bool { return r.VModemSuccess }),
+	integer("vmodem_response_ms", func(r *result) int64 { return int64(r.VModemResponseMs) }),
+	str("vmodem_error", func(r *result) string { return r.VModemError }),
+	str("vmodem_variant", func(r *result) string { return r.VModemVariant }),
+	boolean("vmodem_conformant", func(r *result) bool { return r.VModemConformant }),
+	str("vmodem_software", func(r *result) string { return r.VModemSoftware }),
+	str("vmodem_system_name", func(r *result) string { return r.VModemSystemName }),
+	str("vmodem_sysop", func(r *result) string { return r.VModemSysop }),
+	str("vmodem_location", func(r *result) string { return r.VModemLocation }),
+	list("vmodem_addresses", func(r *result) []string { return r.VModemAddresses }),
+	str("vmodem_detail", func(r *result) string { return r.VModemDetail }),
+	str("vmodem_call_outcome", func(r *result) string { return r.VModemCallOutcome }),
+	str("vmodem_banner", func(r *result) string { return r.VModemBanner }),
+
+	// IPv4-specific Test Results
+	boolean("binkp_ipv4_tested", func(r *result) bool { return r.BinkPIPv4Tested }),
+	boolean("binkp_ipv4_success", func(r *result) bool { return r.BinkPIPv4Success }),
+	integer("binkp_ipv4_response_ms", func(r *result) int64 { return int64(r.BinkPIPv4ResponseMs) }),
+	str("binkp_ipv4_address", func(r *result) string { return r.BinkPIPv4Address }),
+	str("binkp_ipv4_error", func(r *result) string { return r.BinkPIPv4Error }),
+	boolean("ifcico_ipv4_tested", func(r *result) bool { return r.IfcicoIPv4Tested }),
+	boolean("ifcico_ipv4_success", func(r *result) bool { return r.IfcicoIPv4Success }),
+	integer("ifcico_ipv4_response_ms", func(r *result) int64 { return int64(r.IfcicoIPv4ResponseMs) }),
+	str("ifcico_ipv4_address", func(r *result) string { return r.IfcicoIPv4Address }),
+	str("ifcico_ipv4_error", func(r *result) string { return r.IfcicoIPv4Error }),
+	boolean("telnet_ipv4_tested", func(r *result) bool { return r.TelnetIPv4Tested }),
+	boolean("telnet_ipv4_success", func(r *result) bool { return r.TelnetIPv4Success }),
+	integer("telnet_ipv4_response_ms", func(r *result) int64 { return int64(r.TelnetIPv4ResponseMs) }),
+	str("telnet_ipv4_address", func(r *result) string { return r.TelnetIPv4Address }),
+	str("telnet_ipv4_error", func(r *result) string { return r.TelnetIPv4Error }),
+	boolean("ftp_ipv4_tested", func(r *result) bool { return r.FTPIPv4Tested }),
+	boolean("ftp_ipv4_success", func(r *result) bool { return r.FTPIPv4Success }),
+	integer("ftp_ipv4_response_ms", func(r *result) int64 { return int64(r.FTPIPv4ResponseMs) }),
+	str("ftp_ipv4_address", func(r *result) string { return r.FTPIPv4Address }),
+	str("ftp_ipv4_error", func(r *result) string { return r.FTPIPv4Error }),
+	boolean("vmodem_ipv4_tested", func(r *result) bool { return r.VModemIPv4Tested }),
+	boolean("vmodem_ipv4_success", func(r *result) bool { return r.VModemIPv4Success }),
+	integer("vmodem_ipv4_response_ms", func(r *result) int64 { return int64(r.VModemIPv4ResponseMs) }),
+	str("vmodem_ipv4_address", func(r *result) string { return r.VModemIPv4Address }),
+	str("vmodem_ipv4_error", func(r *result) string { return r.VModemIPv4Error }),
+
+	// IPv6-specific Test Results
+	boolean("binkp_ipv6_tested", func(r *result) bool { return r.BinkPIPv6Tested }),
+	boolean("binkp_ipv6_success", func(r *result) bool { return r.BinkPIPv6Success }),
+	integer("binkp_ipv6_response_ms", func(r *result) int64 { return int64(r.BinkPIPv6ResponseMs) }),
+	str("binkp_ipv6_address", func(r *result) string { return r.BinkPIPv6Address }),
+	str("binkp_ipv6_error", func(r *result) string { return r.BinkPIPv6Error }),
+	boolean("ifcico_ipv6_tested", func(r *result) bool { return r.IfcicoIPv6Tested }),
+	boolean("ifcico_ipv6_success", func(r *result) bool { return r.IfcicoIPv6Success }),
+	integer("ifcico_ipv6_response_ms", func(r *result) int64 { return int64(r.IfcicoIPv6ResponseMs) }),
+	str("ifcico_ipv6_address", func(r *result) string { return r.IfcicoIPv6Address }),
+	str("ifcico_ipv6_error", func(r *result) string { return r.IfcicoIPv6Error }),
+	boolean("telnet_ipv6_tested", func(r *result) bool { return r.TelnetIPv6Tested }),
+	boolean("telnet_ipv6_success", func(r *result) bool { return r.TelnetIPv6Success }),
+	integer("telnet_ipv6_response_ms", func(r *result) int64 { return int64(r.TelnetIPv6ResponseMs) }),
+	str("telnet_ipv6_address", func(r *result) string { return r.TelnetIPv6Address }),
+	str("telnet_ipv6_error", func(r *result) string { return r.TelnetIPv6Error }),
+	boolean("ftp_ipv6_tested", func(r *result) bool { return r.FTPIPv6Tested }),
+	boolean("ftp_ipv6_success", func(r *result) bool { return r.FTPIPv6Success }),
+	integer("ftp_ipv6_response_ms", func(r *result) int64 { return int64(r.FTPIPv6ResponseMs) }),
+	str("ftp_ipv6_address", func(r *result) string { return r.FTPIPv6Address }),
+	str("ftp_ipv6_error", func(r *result) string { return r.FTPIPv6Error }),
+	boolean("vmodem_ipv6_tested", func(r *result) bool { return r.VModemIPv6Tested }),
+	boolean("vmodem_ipv6_success", func(r *result) bool { return r.VModemIPv6Success }),
+	integer("vmodem_ipv6_response_ms", func(r *result) int64 { return int64(r.VModemIPv6ResponseMs) }),
+	str("vmodem_ipv6_address", func(r *result) string { return r.VModemIPv6Address }),
+	str("vmodem_ipv6_error", func(r *result) string { return r.VModemIPv6Error }),
+
+	boolean("is_operational", func(r *result) bool { return r.IsOperational }),
+	boolean("has_connectivity_issues", func(r *result) bool { return r.HasConnectivityIssues }),
+	boolean("address_validated", func(r *result) bool { return r.AddressValidated }),
+
+	// Path probe of a node that failed every protocol
+	str("path_tcp", func(r *result) string { return r.PathTCP }),
+	list("path_hops", func(r *result) []string { return r.PathHops }),
+	str("path_last_hop", func(r *result) string { return r.PathLastHop }),
+	integer("path_last_hop_ttl", func(r *result) int64 { return int64(r.PathLastHopTTL) }),
+	integer("path_last_hop_asn", func(r *result) int64 { return int64(r.PathLastHopASN) }),
+	str("path_last_hop_as", func(r *result) string { return r.PathLastHopAS }),
+	str("path_verdict", func(r *result) string { return r.PathVerdict }),
+
+	// Learned uptime window
+	str("uptime_window", func(r *result) string { return r.UptimeWindow }),
+	boolean("intermittent_by_schedule", func(r *result) bool { return r.IntermittentBySchedule }),
+
+	// Testing from several locations
+	integer("vantage_points_tested", func(r *result) int64 { return int64(r.VantagePointsTested) }),
+	integer("vantage_points_reachable", func(r *result) int64 { return int64(r.VantagePointsReachable) }),
+	str("vantage_verdict", func(r *result) string { return r.VantageVerdict }),
+
+	// Multi-network identity and AKA-derivation provenance
+	str("domain", func(r *result) string { return r.Domain }),
+	str("derived_from_address", func(r *result) string { return r.DerivedFromAddress }),
+
+	// Per-hostname testing
+	str("tested_hostname", func(r *result) string { return r.TestedHostname }),
+	integer("hostname_index", func(r *result) int64 { return int64(r.HostnameIndex) }),
+	boolean("is_aggregated", func(r *result) bool { return r.IsAggregated }),
+	integer("total_hostnames", func(r *result) int64 { return int64(r.TotalHostnames) }),
+	integer("hostnames_tested", func(r *result) int64 { return int64(r.HostnamesTested) }),
+	integer("hostnames_operational", func(r *result) int64 { return int64(r.HostnamesOperational) }),
+}
+
+func str[T any](name string, get func(*T) string) Column[T] {
+	return Column[T]{Name: name, Kind: String, Value: func(r *T) any { return get(r) }}
+}
+
+func integer[T any](name string, get func(*T) int64) Column[T] {
+	return Column[T]{Name: name, Kind: Int, Value: func(r *T) any { return get(r) }}
+}
+
+func float[T any](name string, get func(*T) float64) Column[T] {
+	return Column[T]{Name: name, Kind: Float, Value: func(r *T) any { return get(r) }}
+}
+
+func boolean[T any](name string, get func(*T) bool) Column[T] {
+	return Column[T]{Name: name, Kind: Bool, Value: func(r *T) any { return get(r) }}
+}
+
+func date[T any](name string, get func(*T) time.Time) Column[T] {
+	return Column[T]{Name: name, Kind: Date, Value: func(r *T) any { return get(r) }}
+}
+
+func timestamp[T any](name string, get func(*T) time.Time) Column[T] {
+	return Column[T]{Name: name, Kind: Time, Value: func(r *T) any { return get(r) }}
+}
+
+func list[T any](name string, get func(*T) []string) Column[T] {
+	return Column[T]{Name: name, Kind: String, Value: func(r *T) any { return strings.Join(get(r), ",") }}
+}
+
+func optionalBool[T any](name string, get func(*T) *bool) Column[T] {
+	return Column[T]{Name: name, Kind: Bool, Value: func(r *T) any {
+		if b := get(r); b != nil {
+			return *b
+		}
+		return nil
+	}}
+}
+
+func optionalTimestamp[T any](name string, get func(*T) *time.Time) Column[T] {
+	return Column[T]{Name: name, Kind: Time, Value: func(r *T) any {
+		if t := get(r); t != nil {
+			return *t
+		}
+		return nil
+	}}
+}
+
+func rawJSON[T any](name string, get func(*T) []byte) Column[T] {
+	return Column[T]{Name: name, Kind: String, Value: func(r *T) any {
+		if b := get(r); len(b) > 0 {
+			return string(b)
+		}
+		return nil
+	}}
+}
@@ -0,0 +1,57 @@
+package export
+
+import (
+	"encoding/csv"
+	"io"
+)
+
+// csvWriter writes a header line and one record per row. A missing value is
+// an empty field.
+type csvWriter[T any] struct {
+	w       *csv.Writer
+	columns []Column[T]
+	record  []string
+	started bool
+}
+
+func newCSVWriter[T any](w io.Writer, columns []Column[T]) *csvWriter[T] {
+	return &csvWriter[T]{w: csv.NewWriter(w), columns: columns, record: make([]string, len(columns))}
+}
+
+func (c *csvWriter[T]) header() error {
+	if c.started {
+		return nil
+	}
+	c.started = true
+	for i, col := range c.columns {
+		c.record[i] = col.Name
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter[T]) Write(row *T) error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	for i, col := range c.columns {
+		v := col.Value(row)
+		if v == nil {
+			c.record[i] = ""
+			continue
+		}
+		s, ok := text(col.Kind, v)
+		if !ok {
+			return typeError(col.Name, col.Kind, v)
+		}
+		c.record[i] = s
+	}
+	return c.w.Write(c.record)
+}
+
+func (c *csvWriter[T]) Close() error {
+	if err := c.header(); err != nil {
+		return err
+	}
+	c.w.Flush()
+	return c.w.Error()
+}
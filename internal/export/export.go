@@ -0,0 +1,146 @@
+// Package export writes rows streamed out of storage as CSV, JSON Lines or
+// Parquet.
+//
+// Every format is driven by the same column list, so a file's columns, their
+// order and their types do not depend on which format was asked for. A writer
+// holds at most one row (CSV, JSONL) or one Parquet row group in memory, and
+// nothing reaches the underlying io.Writer until the first row has been
+// written or the writer is closed - a caller that fails before that point can
+// still answer with an error instead of half a file.
+//
+// There is no Parquet library in the dependency tree and the subset needed
+// here is small - flat, optional, PLAIN-encoded columns - so parquet.go writes
+// the format directly.
+package export
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Format is an output format.
+type Format string
+
+const (
+	CSV     Format = "csv"
+	JSONL   Format = "jsonl"
+	Parquet Format = "parquet"
+)
+
+// ParseFormat accepts a format name, case-insensitively. An empty name is CSV.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(strings.ToLower(strings.TrimSpace(s))); f {
+	case "":
+		return CSV, nil
+	case CSV, JSONL, Parquet:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown export format %q (want csv, jsonl or parquet)", s)
+}
+
+// ContentType is the MIME type served with the format.
+func (f Format) ContentType() string {
+	switch f {
+	case JSONL:
+		return "application/x-ndjson"
+	case Parquet:
+		return "application/vnd.apache.parquet"
+	}
+	return "text/csv; charset=utf-8"
+}
+
+// Extension is the file name extension for the format, without the dot.
+func (f Format) Extension() string {
+	return string(f)
+}
+
+// Kind is a column's value type.
+type Kind int
+
+const (
+	String Kind = iota // string
+	Int                // int64
+	Float              // float64
+	Bool               // bool
+	Date               // time.Time, day precision
+	Time               // time.Time, stored in UTC
+)
+
+// Column is one output column over rows of type T. Value returns the Go type
+// its Kind names, or nil for a missing value.
+type Column[T any] struct {
+	Name  string
+	Kind  Kind
+	Value func(*T) any
+}
+
+// Writer writes rows. Close flushes whatever is buffered and, for Parquet,
+// writes the footer; it does not close the underlying io.Writer.
+type Writer[T any] interface {
+	Write(row *T) error
+	Close() error
+}
+
+// NewWriter returns a writer of format f over columns.
+func NewWriter[T any](f Format, w io.Writer, columns []Column[T]) (Writer[T], error) {
+	switch f {
+	case CSV:
+		return newCSVWriter(w, columns), nil
+	case JSONL:
+		return newJSONLWriter(w, columns), nil
+	case Parquet:
+		return newParquetWriter(w, columns), nil
+	}
+	return nil, fmt.Errorf("unknown export format %q", f)
+}
+
+// typeError reports a Value func returning something other than its Kind.
+func typeError(name string, k Kind, v any) error {
+	return fmt.Errorf("export column %s: got %T, want %s", name, v, k)
+}
+
+func (k Kind) String() string {
+	switch k {
+	case String:
+		return "string"
+	case Int:
+		return "int"
+	case Float:
+		return "float"
+	case Bool:
+		return "bool"
+	case Date:
+		return "date"
+	case Time:
+		return "time"
+	}
+	return fmt.Sprintf("Kind(%d)", int(k))
+}
+
+// text renders a value for the text formats. Strings come back unquoted; the
+// caller quotes them as its format requires.
+func text(k Kind, v any) (string, bool) {
+	switch k {
+	case String:
+		s, ok := v.(string)
+		return s, ok
+	case Int:
+		i, ok := v.(int64)
+		return fmt.Sprint(i), ok
+	case Float:
+		f, ok := v.(float64)
+		return fmt.Sprint(f), ok
+	case Bool:
+		b, ok := v.(bool)
+		return fmt.Sprint(b), ok
+	case Date:
+		t, ok := v.(time.Time)
+		return t.Format(time.DateOnly), ok
+	case Time:
+		t, ok := v.(time.Time)
+		return t.UTC().Format(time.RFC3339), ok
+	}
+	return "", false
+}
@@ -0,0 +1,100 @@
+package export
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseFormat(t *testing.T) {
+	for _, tc := range []struct {
+		in   string
+		want Format
+		ok   bool
+	}{
+		{"", CSV, true},
+		{"csv", CSV, true},
+		{"JSONL", JSONL, true},
+		{" parquet ", Parquet, true},
+		{"xlsx", "", false},
+	} {
+		got, err := ParseFormat(tc.in)
+		if (err == nil) != tc.ok || got != tc.want {
+			t.Errorf("ParseFormat(%q) = %q, %v", tc.in, got, err)
+		}
+	}
+}
+
+func sampleRows() []sample {
+	seven := int64(7)
+	at := time.Date(2026, 7, 21, 12, 30, 0, 0, time.FixedZone("MSK", 3*3600))
+	return []sample{
+		{name: `Moscow, "Central" & <Region>`, count: &seven, ratio: 0.25, ok: true, day: at, at: at},
+		{name: "", ratio: 1, day: at, at: at},
+	}
+}
+
+func write(t *testing.T, f Format, rows []sample) string {
+	t.Helper()
+	var buf bytes.Buffer
+	w, err := NewWriter(f, &buf, sampleColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range rows {
+		if err := w.Write(&rows[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestCSV(t *testing.T) {
+	want := "name,count,ratio,ok,day,at\n" +
+		`"Moscow, ""Central"" & <Region>",7,0.25,true,2026-07-21,2026-07-21T09:30:00Z` + "\n" +
+		",,1,false,2026-07-21,2026-07-21T09:30:00Z\n"
+	if got := write(t, CSV, sampleRows()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	// No rows still names the columns.
+	if got := write(t, CSV, nil); got != "name,count,ratio,ok,day,at\n" {
+		t.Errorf("empty export = %q", got)
+	}
+}
+
+func TestJSONL(t *testing.T) {
+	want := `{"name":"Moscow, \"Central\" & <Region>","count":7,"ratio":0.25,"ok":true,"day":"2026-07-21","at":"2026-07-21T09:30:00Z"}` + "\n" +
+		`{"name":"","count":null,"ratio":1,"ok":false,"day":"2026-07-21","at":"2026-07-21T09:30:00Z"}` + "\n"
+	if got := write(t, JSONL, sampleRows()); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+// TestNothingWrittenBeforeTheFirstRow is the contract the HTTP handler relies
+// on to still answer a failed query with an error status.
+func TestNothingWrittenBeforeTheFirstRow(t *testing.T) {
+	for _, f := range []Format{CSV, JSONL, Parquet} {
+		var buf bytes.Buffer
+		if _, err := NewWriter(f, &buf, sampleColumns); err != nil {
+			t.Fatal(err)
+		}
+		if buf.Len() != 0 {
+			t.Errorf("%s: %d bytes written by NewWriter", f, buf.Len())
+		}
+	}
+}
+
+func TestWrongValueType(t *testing.T) {
+	bad := []Column[sample]{{Name: "count", Kind: Int, Value: func(*sample) any { return 7 }}}
+	for _, f := range []Format{CSV, JSONL, Parquet} {
+		w, _ := NewWriter(f, &bytes.Buffer{}, bad)
+		err := w.Write(&sample{})
+		if err == nil || !strings.Contains(err.Error(), "count: got int, want int") {
+			t.Errorf("%s: err = %v", f, err)
+		}
+	}
+}
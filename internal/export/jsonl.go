@@ -0,0 +1,75 @@
+package export
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"io"
+)
+
+// jsonlWriter writes one JSON object per line, keys in column order. A
+// missing value is null; dates and times are strings as in the CSV.
+type jsonlWriter[T any] struct {
+	w       *bufio.Writer
+	columns []Column[T]
+	line    bytes.Buffer
+	enc     *json.Encoder
+}
+
+func newJSONLWriter[T any](w io.Writer, columns []Column[T]) *jsonlWriter[T] {
+	j := &jsonlWriter[T]{w: bufio.NewWriter(w), columns: columns}
+	j.enc = json.NewEncoder(&j.line)
+	// Raw nodelist lines carry '&' and '<' often enough that escaping them as
+	// \u0026 and \u003c would make the output needlessly hard to read.
+	j.enc.SetEscapeHTML(false)
+	return j
+}
+
+func (j *jsonlWriter[T]) Write(row *T) error {
+	j.line.Reset()
+	j.line.WriteByte('{')
+	for i, col := range j.columns {
+		if i > 0 {
+			j.line.WriteByte(',')
+		}
+		if err := j.encode(col.Name); err != nil {
+			return err
+		}
+		j.line.WriteByte(':')
+
+		v := col.Value(row)
+		if v == nil {
+			j.line.WriteString("null")
+			continue
+		}
+		s, ok := text(col.Kind, v)
+		if !ok {
+			return typeError(col.Name, col.Kind, v)
+		}
+		switch col.Kind {
+		case Int, Float, Bool:
+			j.line.WriteString(s)
+		default:
+			if err := j.encode(s); err != nil {
+				return err
+			}
+		}
+	}
+	j.line.WriteString("}\n")
+	_, err := j.w.Write(j.line.Bytes())
+	return err
+}
+
+// encode appends s as a JSON string. Encoder.Encode terminates every value
+// with a newline, which is trimmed back off.
+func (j *jsonlWriter[T]) encode(s string) error {
+	if err := j.enc.Encode(s); err != nil {
+		return err
+	}
+	j.line.Truncate(j.line.Len() - 1)
+	return nil
+}
+
+func (j *jsonlWriter[T]) Close() error {
+	return j.w.Flush()
+}
@@ -0,0 +1,470 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"io"
+	"math"
+	"time"
+)
+
+// The subset of the Parquet format written here: every column is a flat
+// OPTIONAL leaf of the root, each row group holds one v1 data page per
+// column, values are PLAIN-encoded, definition levels are RLE, and pages are
+// gzip-compressed. That is enough for pyarrow, DuckDB, Spark and ClickHouse's
+// own Parquet input to read the files, and it keeps the writer small enough
+// to read in one sitting. The numbers below are from parquet.thrift.
+const (
+	parquetMagic = "PAR1"
+
+	ptBoolean   = 0
+	ptInt32     = 1
+	ptInt64     = 2
+	ptDouble    = 5
+	ptByteArray = 6
+
+	convertedUTF8            = 0
+	convertedDate            = 6
+	convertedTimestampMicros = 10
+
+	repetitionOptional = 1
+	encodingPlain      = 0
+	encodingRLE        = 3
+	codecGzip          = 2
+	pageTypeData       = 0
+)
+
+// parquetRowGroupBytes is roughly how much encoded data a writer buffers
+// before it writes a row group. It is what bounds an export's memory: the
+// result set streams through in groups of this size, however large it is.
+const parquetRowGroupBytes = 32 << 20
+
+// parquetColumnBuffer holds one column's share of the row group being built.
+type parquetColumnBuffer struct {
+	present []bool       // definition level per row
+	values  bytes.Buffer // PLAIN encoding of the present values
+	bools   []bool       // BOOLEAN values, bit-packed when the page is written
+}
+
+type parquetChunk struct {
+	offset, compressed, uncompressed, values int64
+}
+
+type parquetRowGroup struct {
+	chunks []parquetChunk
+	rows   int64
+	bytes  int64
+}
+
+type parquetWriter[T any] struct {
+	w          io.Writer
+	columns    []Column[T]
+	groupBytes int
+
+	buf      []parquetColumnBuffer
+	rows     int64 // rows in the group being built
+	buffered int   // bytes buffered for it
+	scratch  []any
+
+	offset  int64 // bytes written so far
+	groups  []parquetRowGroup
+	total   int64
+	started bool
+
+	page bytes.Buffer
+	gz   *gzip.Writer
+}
+
+func newParquetWriter[T any](w io.Writer, columns []Column[T]) *parquetWriter[T] {
+	return &parquetWriter[T]{
+		w:          w,
+		columns:    columns,
+		groupBytes: parquetRowGroupBytes,
+		buf:        make([]parquetColumnBuffer, len(columns)),
+		scratch:    make([]any, len(columns)),
+	}
+}
+
+// Write buffers one row. The row's values are all checked before any is
+// buffered, so a type error cannot leave the columns at different lengths.
+func (p *parquetWriter[T]) Write(row *T) error {
+	for i, col := range p.columns {
+		v := col.Value(row)
+		if v != nil && !kindMatches(col.Kind, v) {
+			return typeError(col.Name, col.Kind, v)
+		}
+		p.scratch[i] = v
+	}
+
+	var le [8]byte
+	for i, col := range p.columns {
+		b := &p.buf[i]
+		v := p.scratch[i]
+		b.present = append(b.present, v != nil)
+		p.buffered++
+		if v == nil {
+			continue
+		}
+		switch col.Kind {
+		case String:
+			s := v.(string)
+			binary.LittleEndian.PutUint32(le[:4], uint32(len(s)))
+			b.values.Write(le[:4])
+			b.values.WriteString(s)
+			p.buffered += 4 + len(s)
+		case Int:
+			binary.LittleEndian.PutUint64(le[:], uint64(v.(int64)))
+			b.values.Write(le[:])
+			p.buffered += 8
+		case Float:
+			binary.LittleEndian.PutUint64(le[:], math.Float64bits(v.(float64)))
+			b.values.Write(le[:])
+			p.buffered += 8
+		case Bool:
+			b.bools = append(b.bools, v.(bool))
+		case Date:
+			binary.LittleEndian.PutUint32(le[:4], uint32(int32(daysSinceEpoch(v.(time.Time)))))
+			b.values.Write(le[:4])
+			p.buffered += 4
+		case Time:
+			binary.LittleEndian.PutUint64(le[:], uint64(v.(time.Time).UnixMicro()))
+			b.values.Write(le[:])
+			p.buffered += 8
+		}
+	}
+	p.rows++
+
+	if p.buffered >= p.groupBytes {
+		return p.flush()
+	}
+	return nil
+}
+
+// Close writes the last row group and the footer.
+func (p *parquetWriter[T]) Close() error {
+	if err := p.flush(); err != nil {
+		return err
+	}
+	if err := p.start(); err != nil {
+		return err
+	}
+	footer := p.footer()
+	var n [4]byte
+	binary.LittleEndian.PutUint32(n[:], uint32(len(footer)))
+	footer = append(append(footer, n[:]...), parquetMagic...)
+	return p.write(footer)
+}
+
+func (p *parquetWriter[T]) start() error {
+	if p.started {
+		return nil
+	}
+	p.started = true
+	return p.write([]byte(parquetMagic))
+}
+
+func (p *parquetWriter[T]) write(b []byte) error {
+	n, err := p.w.Write(b)
+	p.offset += int64(n)
+	return err
+}
+
+// flush writes the buffered rows as one row group.
+func (p *parquetWriter[T]) flush() error {
+	if p.rows == 0 {
+		return nil
+	}
+	if err := p.start(); err != nil {
+		return err
+	}
+
+	group := parquetRowGroup{rows: p.rows}
+	for i, col := range p.columns {
+		chunk, err := p.writePage(col.Kind, &p.buf[i])
+		if err != nil {
+			return err
+		}
+		group.chunks = append(group.chunks, chunk)
+		group.bytes += chunk.uncompressed
+		p.buf[i] = parquetColumnBuffer{}
+	}
+	p.groups = append(p.groups, group)
+	p.total += p.rows
+	p.rows, p.buffered = 0, 0
+	return nil
+}
+
+// writePage writes one column's buffered values as a single data page.
+func (p *parquetWriter[T]) writePage(kind Kind, b *parquetColumnBuffer) (parquetChunk, error) {
+	body := appendLevels(nil, b.present)
+	if kind == Bool {
+		body = appendBitPacked(body, b.bools)
+	} else {
+		body = append(body, b.values.Bytes()...)
+	}
+
+	p.page.Reset()
+	if p.gz == nil {
+		p.gz = gzip.NewWriter(&p.page)
+	} else {
+		p.gz.Reset(&p.page)
+	}
+	if _, err := p.gz.Write(body); err != nil {
+		return parquetChunk{}, err
+	}
+	if err := p.gz.Close(); err != nil {
+		return parquetChunk{}, err
+	}
+
+	var h thriftWriter
+	h.i32(1, pageTypeData)
+	h.i32(2, int32(len(body)))
+	h.i32(3, int32(p.page.Len()))
+	h.beginStruct(5) // DataPageHeader
+	h.i32(1, int32(len(b.present)))
+	h.i32(2, encodingPlain)
+	h.i32(3, encodingRLE)
+	h.i32(4, encodingRLE)
+	h.end()
+	h.stop()
+
+	chunk := parquetChunk{
+		offset:       p.offset,
+		compressed:   int64(len(h.b) + p.page.Len()),
+		uncompressed: int64(len(h.b) + len(body)),
+		values:       int64(len(b.present)),
+	}
+	if err := p.write(h.b); err != nil {
+		return chunk, err
+	}
+	return chunk, p.write(p.page.Bytes())
+}
+
+// footer encodes the FileMetaData struct.
+func (p *parquetWriter[T]) footer() []byte {
+	var t thriftWriter
+	t.i32(1, 1) // version
+
+	t.list(2, thriftStruct, 1+len(p.columns))
+	t.beginElement()
+	t.binary(4, "schema")
+	t.i32(5, int32(len(p.columns)))
+	t.end()
+	for _, col := range p.columns {
+		physical, converted := parquetType(col.Kind)
+		t.beginElement()
+		t.i32(1, physical)
+		t.i32(3, repetitionOptional)
+		t.binary(4, col.Name)
+		if converted >= 0 {
+			t.i32(6, converted)
+		}
+		t.end()
+	}
+
+	t.i64(3, p.total)
+
+	t.list(4, thriftStruct, len(p.groups))
+	for _, g := range p.groups {
+		t.beginElement()
+		t.list(1, thriftStruct, len(g.chunks))
+		for i, c := range g.chunks {
+			physical, _ := parquetType(p.columns[i].Kind)
+			t.beginElement() // ColumnChunk
+			t.i64(2, c.offset)
+			t.beginStruct(3) // ColumnMetaData
+			t.i32(1, physical)
+			t.list(2, thriftI32, 2)
+			t.listI32(encodingPlain, encodingRLE)
+			t.list(3, thriftBinary, 1)
+			t.listBinary(p.columns[i].Name)
+			t.i32(4, codecGzip)
+			t.i64(5, c.values)
+			t.i64(6, c.uncompressed)
+			t.i64(7, c.compressed)
+			t.i64(9, c.offset)
+			t.end()
+			t.end()
+		}
+		t.i64(2, g.bytes)
+		t.i64(3, g.rows)
+		t.end()
+	}
+
+	t.binary(6, "nodelistdb")
+	t.stop()
+	return t.b
+}
+
+// parquetType maps a Kind to its physical and converted type; -1 is no
+// converted type.
+func parquetType(k Kind) (physical, converted int32) {
+	switch k {
+	case Int:
+		return ptInt64, -1
+	case Float:
+		return ptDouble, -1
+	case Bool:
+		return ptBoolean, -1
+	case Date:
+		return ptInt32, convertedDate
+	case Time:
+		return ptInt64, convertedTimestampMicros
+	}
+	return ptByteArray, convertedUTF8
+}
+
+func kindMatches(k Kind, v any) bool {
+	switch k {
+	case String:
+		_, ok := v.(string)
+		return ok
+	case Int:
+		_, ok := v.(int64)
+		return ok
+	case Float:
+		_, ok := v.(float64)
+		return ok
+	case Bool:
+		_, ok := v.(bool)
+		return ok
+	case Date, Time:
+		_, ok := v.(time.Time)
+		return ok
+	}
+	return false
+}
+
+func daysSinceEpoch(t time.Time) int64 {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 0, 0, 0, 0, time.UTC).Unix() / 86400
+}
+
+// appendLevels appends definition levels (bit width 1) in the RLE/bit-packed
+// hybrid encoding with its 4-byte length prefix. Only RLE runs are emitted:
+// present/absent comes in long runs in this data, and a run is two bytes.
+func appendLevels(dst []byte, present []bool) []byte {
+	start := len(dst)
+	dst = append(dst, 0, 0, 0, 0)
+	for i := 0; i < len(present); {
+		j := i + 1
+		for j < len(present) && present[j] == present[i] {
+			j++
+		}
+		dst = binary.AppendUvarint(dst, uint64(j-i)<<1)
+		if present[i] {
+			dst = append(dst, 1)
+		} else {
+			dst = append(dst, 0)
+		}
+		i = j
+	}
+	binary.LittleEndian.PutUint32(dst[start:], uint32(len(dst)-start-4))
+	return dst
+}
+
+// appendBitPacked appends PLAIN BOOLEAN values: one bit each, LSB first.
+func appendBitPacked(dst []byte, bools []bool) []byte {
+	for i := 0; i < len(bools); i += 8 {
+		var b byte
+		for j := 0; j < 8 && i+j < len(bools); j++ {
+			if bools[i+j] {
+				b |= 1 << j
+			}
+		}
+		dst = append(dst, b)
+	}
+	return dst
+}
+
+// Thrift compact protocol type ids, as they appear in field and list headers.
+const (
+	thriftI32    = 5
+	thriftI64    = 6
+	thriftBinary = 8
+	thriftList   = 9
+	thriftStruct = 12
+)
+
+// thriftWriter encodes the thrift compact protocol - only the field types
+// the Parquet metadata above uses. Field ids are delta-encoded against the
+// previous field of the same struct, so each nested struct saves and
+// restores the last id.
+type thriftWriter struct {
+	b     []byte
+	last  int16
+	stack []int16
+}
+
+func (t *thriftWriter) field(id int16, typ byte) {
+	if d := id - t.last; d > 0 && d <= 15 {
+		t.b = append(t.b, byte(d)<<4|typ)
+	} else {
+		t.b = append(t.b, typ)
+		t.b = binary.AppendVarint(t.b, int64(id))
+	}
+	t.last = id
+}
+
+func (t *thriftWriter) i32(id int16, v int32) {
+	t.field(id, thriftI32)
+	t.b = binary.AppendVarint(t.b, int64(v))
+}
+
+func (t *thriftWriter) i64(id int16, v int64) {
+	t.field(id, thriftI64)
+	t.b = binary.AppendVarint(t.b, v)
+}
+
+func (t *thriftWriter) binary(id int16, s string) {
+	t.field(id, thriftBinary)
+	t.b = binary.AppendUvarint(t.b, uint64(len(s)))
+	t.b = append(t.b, s...)
+}
+
+func (t *thriftWriter) list(id int16, elem byte, n int) {
+	t.field(id, thriftList)
+	if n < 15 {
+		t.b = append(t.b, byte(n)<<4|elem)
+	} else {
+		t.b = append(t.b, 0xf0|elem)
+		t.b = binary.AppendUvarint(t.b, uint64(n))
+	}
+}
+
+func (t *thriftWriter) listI32(vs ...int32) {
+	for _, v := range vs {
+		t.b = binary.AppendVarint(t.b, int64(v))
+	}
+}
+
+func (t *thriftWriter) listBinary(vs ...string) {
+	for _, s := range vs {
+		t.b = binary.AppendUvarint(t.b, uint64(len(s)))
+		t.b = append(t.b, s...)
+	}
+}
+
+// beginStruct opens a struct-valued field; beginElement opens a struct that
+// is a list element and so has no field header. Both are closed by end.
+func (t *thriftWriter) beginStruct(id int16) {
+	t.field(id, thriftStruct)
+	t.beginElement()
+}
+
+func (t *thriftWriter) beginElement() {
+	t.stack = append(t.stack, t.last)
+	t.last = 0
+}
+
+func (t *thriftWriter) end() {
+	t.stop()
+	t.last = t.stack[len(t.stack)-1]
+	t.stack = t.stack[:len(t.stack)-1]
+}
+
+// stop terminates the struct being written.
+func (t *thriftWriter) stop() {
+	t.b = append(t.b, 0)
+}
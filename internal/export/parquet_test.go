@@ -0,0 +1,343 @@
+package export
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/binary"
+	"flag"
+	"io"
+	"math"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+var updateGolden = flag.Bool("update", false, "rewrite testdata/sample.parquet from the writer")
+
+// Most tests read the files back with the small reader below: what matters
+// is that a Parquet reader finds the schema, the row groups and the values
+// where the footer says they are. That reader shares the writer's reading of
+// the spec, so TestParquetGolden also pins the bytes to a file checked with
+// pyarrow.
+
+// thriftReader decodes the thrift compact protocol into maps keyed by field id.
+type thriftReader struct {
+	b []byte
+	i int
+}
+
+func (r *thriftReader) uvarint() uint64 {
+	v, n := binary.Uvarint(r.b[r.i:])
+	r.i += n
+	return v
+}
+
+func (r *thriftReader) varint() int64 {
+	v, n := binary.Varint(r.b[r.i:])
+	r.i += n
+	return v
+}
+
+func (r *thriftReader) readStruct() map[int16]any {
+	m := map[int16]any{}
+	var last int16
+	for {
+		h := r.b[r.i]
+		r.i++
+		if h == 0 {
+			return m
+		}
+		if d := int16(h >> 4); d != 0 {
+			last += d
+		} else {
+			last = int16(r.varint())
+		}
+		m[last] = r.value(h & 0x0f)
+	}
+}
+
+func (r *thriftReader) value(typ byte) any {
+	switch typ {
+	case thriftI32, thriftI64:
+		return r.varint()
+	case thriftBinary:
+		n := int(r.uvarint())
+		s := string(r.b[r.i : r.i+n])
+		r.i += n
+		return s
+	case thriftList:
+		h := r.b[r.i]
+		r.i++
+		n := int(h >> 4)
+		if n == 15 {
+			n = int(r.uvarint())
+		}
+		out := make([]any, n)
+		for i := range out {
+			out[i] = r.value(h & 0x0f)
+		}
+		return out
+	case thriftStruct:
+		return r.readStruct()
+	}
+	panic("unexpected thrift type")
+}
+
+// parquetFile is a decoded file: the footer, and every column's values by
+// name with nil for a null.
+type parquetFile struct {
+	meta    map[int16]any
+	columns map[string][]any
+	names   []string
+}
+
+func readParquet(t *testing.T, b []byte) parquetFile {
+	t.Helper()
+	if len(b) < 12 || string(b[:4]) != parquetMagic || string(b[len(b)-4:]) != parquetMagic {
+		t.Fatalf("not a parquet file: % x", b)
+	}
+	n := int(binary.LittleEndian.Uint32(b[len(b)-8:]))
+	footer := &thriftReader{b: b[len(b)-8-n : len(b)-8]}
+	f := parquetFile{meta: footer.readStruct(), columns: map[string][]any{}}
+	if footer.i != n {
+		t.Fatalf("footer is %d bytes, decoded %d", n, footer.i)
+	}
+
+	schema := f.meta[2].([]any)
+	types := map[string]int64{}
+	for _, el := range schema[1:] {
+		el := el.(map[int16]any)
+		name := el[4].(string)
+		f.names = append(f.names, name)
+		types[name] = el[1].(int64)
+	}
+
+	for _, g := range f.meta[4].([]any) {
+		for _, c := range g.(map[int16]any)[1].([]any) {
+			md := c.(map[int16]any)[3].(map[int16]any)
+			name := md[3].([]any)[0].(string)
+			page := &thriftReader{b: b[md[9].(int64):]}
+			header := page.readStruct()
+			zr, err := gzip.NewReader(bytes.NewReader(b[int(md[9].(int64))+page.i:][:header[3].(int64)]))
+			if err != nil {
+				t.Fatal(err)
+			}
+			body, err := io.ReadAll(zr)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if int64(len(body)) != header[2].(int64) {
+				t.Errorf("%s: page is %d bytes uncompressed, header says %d", name, len(body), header[2])
+			}
+			rows := int(header[5].(map[int16]any)[1].(int64))
+			f.columns[name] = append(f.columns[name], decodePage(t, types[name], body, rows)...)
+		}
+	}
+	return f
+}
+
+func decodePage(t *testing.T, typ int64, body []byte, rows int) []any {
+	t.Helper()
+	n := int(binary.LittleEndian.Uint32(body))
+	levels := &thriftReader{b: body[4 : 4+n]}
+	var present []bool
+	for levels.i < n {
+		h := levels.uvarint()
+		if h&1 != 0 {
+			t.Fatal("bit-packed levels are not written")
+		}
+		v := levels.b[levels.i] == 1
+		levels.i++
+		for range h >> 1 {
+			present = append(present, v)
+		}
+	}
+	if len(present) != rows {
+		t.Fatalf("%d definition levels for %d rows", len(present), rows)
+	}
+
+	values := body[4+n:]
+	var out []any
+	k := 0
+	for _, p := range present {
+		if !p {
+			out = append(out, nil)
+			continue
+		}
+		switch typ {
+		case ptBoolean:
+			out = append(out, values[k/8]&(1<<(k%8)) != 0)
+			k++
+		case ptInt32:
+			out = append(out, int64(int32(binary.LittleEndian.Uint32(values))))
+			values = values[4:]
+		case ptInt64:
+			out = append(out, int64(binary.LittleEndian.Uint64(values)))
+			values = values[8:]
+		case ptDouble:
+			out = append(out, math.Float64frombits(binary.LittleEndian.Uint64(values)))
+			values = values[8:]
+		case ptByteArray:
+			l := binary.LittleEndian.Uint32(values)
+			out = append(out, string(values[4:4+l]))
+			values = values[4+l:]
+		}
+	}
+	return out
+}
+
+type sample struct {
+	name  string
+	count *int64
+	ratio float64
+	ok    bool
+	day   time.Time
+	at    time.Time
+}
+
+var sampleColumns = []Column[sample]{
+	str("name", func(s *sample) string { return s.name }),
+	{Name: "count", Kind: Int, Value: func(s *sample) any {
+		if s.count == nil {
+			return nil
+		}
+		return *s.count
+	}},
+	float("ratio", func(s *sample) float64 { return s.ratio }),
+	boolean("ok", func(s *sample) bool { return s.ok }),
+	date("day", func(s *sample) time.Time { return s.day }),
+	timestamp("at", func(s *sample) time.Time { return s.at }),
+}
+
+var (
+	sampleDay = time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+	sampleAt  = time.Date(2026, 7, 21, 12, 30, 0, 0, time.UTC)
+)
+
+// writeSampleParquet writes the three sample rows the Parquet tests share.
+// testdata/sample.json holds the same rows as a Parquet reader returns them.
+func writeSampleParquet(t *testing.T) []byte {
+	t.Helper()
+	seven := int64(7)
+	rows := []sample{
+		{name: "Moscow & <Region>", count: &seven, ratio: 0.5, ok: true, day: sampleDay, at: sampleAt},
+		{name: "", ratio: -1, day: sampleDay, at: sampleAt},
+		{name: "Łódź", count: &seven, ok: true, day: sampleDay.AddDate(0, 0, 7), at: sampleAt},
+	}
+
+	var buf bytes.Buffer
+	w, err := NewWriter(Parquet, &buf, sampleColumns)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range rows {
+		if err := w.Write(&rows[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestParquetRoundTrip(t *testing.T) {
+	day, at := sampleDay, sampleAt
+	f := readParquet(t, writeSampleParquet(t))
+	if got := f.meta[3].(int64); got != 3 {
+		t.Errorf("num_rows = %d, want 3", got)
+	}
+	if want := []string{"name", "count", "ratio", "ok", "day", "at"}; !reflect.DeepEqual(f.names, want) {
+		t.Errorf("schema = %v, want %v", f.names, want)
+	}
+	epochDay := day.Unix() / 86400
+	want := map[string][]any{
+		"name":  {"Moscow & <Region>", "", "Łódź"},
+		"count": {int64(7), nil, int64(7)},
+		"ratio": {0.5, -1.0, 0.0},
+		"ok":    {true, false, true},
+		"day":   {epochDay, epochDay, epochDay + 7},
+		"at":    {at.UnixMicro(), at.UnixMicro(), at.UnixMicro()},
+	}
+	for name, w := range want {
+		if got := f.columns[name]; !reflect.DeepEqual(got, w) {
+			t.Errorf("%s = %v, want %v", name, got, w)
+		}
+	}
+}
+
+// TestParquetGolden compares the writer's output with testdata/sample.parquet.
+// The reader in this file only proves the writer agrees with itself; the
+// golden file is what ties it to the format. Whenever it is regenerated with
+// -update, run "make check-parquet-golden" - it reads the file with pyarrow
+// and compares the rows with testdata/sample.json - before committing it.
+func TestParquetGolden(t *testing.T) {
+	const golden = "testdata/sample.parquet"
+	got := writeSampleParquet(t)
+	if *updateGolden {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("writer output (%d bytes) differs from %s (%d bytes)", len(got), golden, len(want))
+	}
+}
+
+// TestParquetRowGroups checks that a writer over its byte budget starts a new
+// row group - that is what keeps a large export's memory flat - and that the
+// groups together still hold every row in order.
+func TestParquetRowGroups(t *testing.T) {
+	var buf bytes.Buffer
+	w := newParquetWriter(&buf, NodeColumns)
+	w.groupBytes = 200
+
+	var want []any
+	for i := range 25 {
+		n := database.Node{Zone: 2, Net: 5001, Node: i, SystemName: "Test_System", Flags: []string{"CM", "IBN"}}
+		if err := w.Write(&n); err != nil {
+			t.Fatal(err)
+		}
+		want = append(want, int64(i))
+	}
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+
+	f := readParquet(t, buf.Bytes())
+	if groups := len(f.meta[4].([]any)); groups < 2 {
+		t.Errorf("%d row group(s), want several", groups)
+	}
+	if got := f.columns["node"]; !reflect.DeepEqual(got, want) {
+		t.Errorf("node = %v, want %v", got, want)
+	}
+	if got := f.columns["flags"][0]; got != "CM,IBN" {
+		t.Errorf("flags = %v", got)
+	}
+	if got := f.columns["region"][0]; got != nil {
+		t.Errorf("region = %v, want null", got)
+	}
+	if len(f.names) != len(NodeColumns) {
+		t.Errorf("%d schema columns, want %d", len(f.names), len(NodeColumns))
+	}
+}
+
+// TestParquetEmpty: an export matching nothing is still a readable file.
+func TestParquetEmpty(t *testing.T) {
+	var buf bytes.Buffer
+	w, _ := NewWriter(Parquet, &buf, sampleColumns)
+	if err := w.Close(); err != nil {
+		t.Fatal(err)
+	}
+	f := readParquet(t, buf.Bytes())
+	if f.meta[3].(int64) != 0 || len(f.meta[4].([]any)) != 0 {
+		t.Errorf("empty file has rows: %v", f.meta)
+	}
+}
@@ -0,0 +1,45 @@
+#!/usr/bin/env python3
+"""Read sample.parquet with pyarrow and compare it with sample.json.
+
+TestParquetGolden pins the export writer to sample.parquet byte for byte; this
+script is what ties that file to a Parquet reader other than our own. Run it
+(make check-parquet-golden) whenever the golden file is regenerated.
+"""
+import datetime
+import json
+import os
+import sys
+
+import pyarrow.parquet as pq
+
+HERE = os.path.dirname(os.path.abspath(__file__))
+
+
+def normalise(value):
+    if isinstance(value, datetime.datetime):
+        if value.tzinfo is None:
+            value = value.replace(tzinfo=datetime.timezone.utc)
+        return value.astimezone(datetime.timezone.utc).strftime("%Y-%m-%dT%H:%M:%SZ")
+    if isinstance(value, datetime.date):
+        return value.isoformat()
+    return value
+
+
+def main():
+    table = pq.read_table(os.path.join(HERE, "sample.parquet"))
+    with open(os.path.join(HERE, "sample.json"), encoding="utf-8") as f:
+        want = json.load(f)
+
+    got = [{k: normalise(v) for k, v in row.items()} for row in table.to_pylist()]
+    if got != want:
+        print("sample.parquet does not match sample.json", file=sys.stderr)
+        print("schema:", table.schema, file=sys.stderr)
+        for row in got:
+            print("  got:", row, file=sys.stderr)
+        return 1
+    print("sample.parquet: %d rows match sample.json" % table.num_rows)
+    return 0
+
+
+if __name__ == "__main__":
+    sys.exit(main())
@@ -80,6 +80,11 @@ func GetFlagDescriptions() map[string]FlagInfo {
 		"INA": {Category: "internet", HasValue: true, Description: "Default Internet address for non-email flags (FTS-5001)"},
 		"IP":  {Category: "internet", HasValue: true, Description: "TCP/IP capable (for protocols not covered by other flags)"},
 
+		// TLS-wrapped variants of the connection flags, observed in real
+		// nodelists but defined by no FTSC document.
+		"IBNS": {Category: "internet", HasValue: true, Description: "BinkP over TLS, binkps (non-standard, default port 24553)"},
+		"ITS":  {Category: "internet", HasValue: true, Description: "Telnet over TLS, telnets (non-standard, default port 992)"},
+
 		// Email protocols (FTS-5001 rev 4 section "Email Flags").
 		// The email flags never carry a port number, and INA does not supply a
 		// default address for them -- IEM does.
@@ -0,0 +1,82 @@
+// Package ftnpkt writes FidoNet mail packets: FTS-0001 packed messages in a
+// type-2+ (FSC-0039) packet header, the format every FTN mailer and tosser
+// still picks up from an outbound directory.
+package ftnpkt
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Address is a 4D FTN address with an optional domain.
+type Address struct {
+	Zone   int
+	Net    int
+	Node   int
+	Point  int
+	Domain string
+}
+
+// ParseAddress parses "zone:net/node[.point][@domain]".
+func ParseAddress(s string) (Address, error) {
+	var a Address
+	rest := strings.TrimSpace(s)
+	if at := strings.IndexByte(rest, '@'); at >= 0 {
+		a.Domain = strings.ToLower(rest[at+1:])
+		rest = rest[:at]
+	}
+
+	colon := strings.IndexByte(rest, ':')
+	slash := strings.IndexByte(rest, '/')
+	if colon <= 0 || slash < colon {
+		return Address{}, fmt.Errorf("invalid FTN address %q", s)
+	}
+	nodePart := rest[slash+1:]
+	pointPart := ""
+	if dot := strings.IndexByte(nodePart, '.'); dot >= 0 {
+		nodePart, pointPart = nodePart[:dot], nodePart[dot+1:]
+	}
+
+	var err error
+	if a.Zone, err = addressPart(rest[:colon]); err != nil {
+		return Address{}, fmt.Errorf("invalid FTN address %q: %w", s, err)
+	}
+	if a.Net, err = addressPart(rest[colon+1 : slash]); err != nil {
+		return Address{}, fmt.Errorf("invalid FTN address %q: %w", s, err)
+	}
+	if a.Node, err = addressPart(nodePart); err != nil {
+		return Address{}, fmt.Errorf("invalid FTN address %q: %w", s, err)
+	}
+	if pointPart != "" {
+		if a.Point, err = addressPart(pointPart); err != nil {
+			return Address{}, fmt.Errorf("invalid FTN address %q: %w", s, err)
+		}
+	}
+	return a, nil
+}
+
+// addressPart parses one numeric component; every one of them is a 16-bit
+// field on the wire.
+func addressPart(s string) (int, error) {
+	n, err := strconv.Atoi(s)
+	if err != nil || n < 0 || n > 0xFFFF {
+		return 0, fmt.Errorf("bad component %q", s)
+	}
+	return n, nil
+}
+
+// String formats the address as "zone:net/node[.point]", without the domain:
+// that is the form INTL and MSGID kludges use.
+func (a Address) String() string {
+	s := fmt.Sprintf("%d:%d/%d", a.Zone, a.Net, a.Node)
+	if a.Point != 0 {
+		s += fmt.Sprintf(".%d", a.Point)
+	}
+	return s
+}
+
+// Node2D is the "net/node" form SEEN-BY and PATH lines use.
+func (a Address) Node2D() string {
+	return fmt.Sprintf("%d/%d", a.Net, a.Node)
+}
@@ -0,0 +1,319 @@
+package ftnpkt
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Message attribute bits (FTS-0001).
+const (
+	AttrPrivate  uint16 = 0x0001
+	AttrCrash    uint16 = 0x0002
+	AttrKillSent uint16 = 0x0080
+	AttrLocal    uint16 = 0x0100
+)
+
+// ProductCode is the FTSC product code written into packet headers: 0xFE is
+// the code set aside for programs that never registered one.
+const ProductCode uint16 = 0x00FE
+
+// Field limits of a packed message, terminating NUL included.
+const (
+	maxNameLen    = 36
+	maxSubjectLen = 72
+)
+
+// Header is the sending and receiving system of a packet. These are the
+// systems exchanging the packet, not necessarily those a message inside it
+// is from or to.
+type Header struct {
+	Orig     Address
+	Dest     Address
+	Password string // packet password, at most 8 characters
+	Created  time.Time
+}
+
+// Message is one netmail or echomail message.
+type Message struct {
+	Orig    Address
+	Dest    Address
+	From    string
+	To      string
+	Subject string
+	Date    time.Time
+	Attr    uint16
+
+	// PID names the program that wrote the message; empty leaves the kludge
+	// out.
+	PID string
+
+	// Body is the message text. Line breaks may be given as "\n"; they are
+	// written as the CR FTN messages use.
+	Body string
+
+	// Area makes the message echomail posted to that echo. Dest is then the
+	// uplink the packet goes to, not a recipient.
+	Area string
+	// Origin is the text of an echomail's Origin line; empty uses From.
+	Origin string
+}
+
+// Write writes a complete type-2+ packet holding msgs.
+func Write(w io.Writer, h Header, msgs []Message) error {
+	bw := bufio.NewWriter(w)
+	if err := writeHeader(bw, h); err != nil {
+		return err
+	}
+	for i := range msgs {
+		if err := writeMessage(bw, &msgs[i]); err != nil {
+			return fmt.Errorf("message %d: %w", i+1, err)
+		}
+	}
+	// A zero message type ends the packet.
+	if err := binary.Write(bw, binary.LittleEndian, uint16(0)); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// WriteFile writes a packet into dir under a fresh "<8 hex digits>.pkt"
+// name and returns the path.
+//
+// Mailers pick up anything named *.pkt the moment it appears, so the packet
+// is written under a temporary name first and only linked to its final name
+// once complete. Linking also refuses to replace an existing packet, which
+// is how two writers in the same second end up with different names.
+func WriteFile(dir string, h Header, msgs []Message) (string, error) {
+	tmp, err := os.CreateTemp(dir, ".pkt-*.tmp")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name())
+
+	if err := Write(tmp, h, msgs); err != nil {
+		tmp.Close()
+		return "", err
+	}
+	if err := tmp.Close(); err != nil {
+		return "", err
+	}
+
+	base := uint32(time.Now().Unix())
+	for i := uint32(0); i < 256; i++ {
+		name := filepath.Join(dir, fmt.Sprintf("%08x.pkt", base+i))
+		err := os.Link(tmp.Name(), name)
+		if err == nil {
+			return name, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return "", err
+		}
+	}
+	return "", fmt.Errorf("no free packet name in %s", dir)
+}
+
+// writeHeader writes the 58-byte FSC-0039 type-2+ header.
+func writeHeader(w io.Writer, h Header) error {
+	created := h.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+
+	var password [8]byte
+	copy(password[:], h.Password)
+
+	fields := []any{
+		uint16(h.Orig.Node),
+		uint16(h.Dest.Node),
+		uint16(created.Year()),
+		uint16(created.Month() - 1), // FTS-0001 months count from 0
+		uint16(created.Day()),
+		uint16(created.Hour()),
+		uint16(created.Minute()),
+		uint16(created.Second()),
+		uint16(0), // baud
+		uint16(2), // packet type
+		uint16(h.Orig.Net),
+		uint16(h.Dest.Net),
+		uint8(ProductCode & 0xFF),
+		uint8(0), // revision, major
+		password,
+		uint16(h.Orig.Zone), // QMail zone copies
+		uint16(h.Dest.Zone),
+		uint16(0),      // auxNet
+		uint16(0x0100), // capability word, byte-swapped validation copy
+		uint8(ProductCode >> 8),
+		uint8(0),       // revision, minor
+		uint16(0x0001), // capability word: type 2+
+		uint16(h.Orig.Zone),
+		uint16(h.Dest.Zone),
+		uint16(h.Orig.Point),
+		uint16(h.Dest.Point),
+		[4]byte{}, // product specific data
+	}
+	for _, f := range fields {
+		if err := binary.Write(w, binary.LittleEndian, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeMessage writes one FTS-0001 packed message.
+func writeMessage(w io.Writer, m *Message) error {
+	date := m.Date
+	if date.IsZero() {
+		date = time.Now()
+	}
+
+	fields := []any{
+		uint16(2), // message type
+		uint16(m.Orig.Node),
+		uint16(m.Dest.Node),
+		uint16(m.Orig.Net),
+		uint16(m.Dest.Net),
+		m.Attr,
+		uint16(0), // cost
+	}
+	for _, f := range fields {
+		if err := binary.Write(w, binary.LittleEndian, f); err != nil {
+			return err
+		}
+	}
+
+	// "DD Mon YY  HH:MM:SS": always 19 characters plus the NUL.
+	if _, err := io.WriteString(w, date.Format("02 Jan 06  15:04:05")+"\x00"); err != nil {
+		return err
+	}
+	for _, s := range []string{
+		truncate(m.To, maxNameLen-1),
+		truncate(m.From, maxNameLen-1),
+		truncate(m.Subject, maxSubjectLen-1),
+		messageText(m),
+	} {
+		if _, err := io.WriteString(w, s+"\x00"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// messageText builds the text of a message. A netmail starts with the
+// routing kludges tossers need to deliver it across zones and to points; an
+// echomail starts with its AREA line and ends with the tearline, Origin line,
+// SEEN-BY and PATH its uplink's tosser expects (FTS-0004).
+func messageText(m *Message) string {
+	var b strings.Builder
+	if m.Area != "" {
+		fmt.Fprintf(&b, "AREA:%s\r", strings.ToUpper(m.Area))
+	} else {
+		fmt.Fprintf(&b, "\x01INTL %d:%d/%d %d:%d/%d\r",
+			m.Dest.Zone, m.Dest.Net, m.Dest.Node, m.Orig.Zone, m.Orig.Net, m.Orig.Node)
+		if m.Dest.Point != 0 {
+			fmt.Fprintf(&b, "\x01TOPT %d\r", m.Dest.Point)
+		}
+		if m.Orig.Point != 0 {
+			fmt.Fprintf(&b, "\x01FMPT %d\r", m.Orig.Point)
+		}
+	}
+	fmt.Fprintf(&b, "\x01MSGID: %s %08x\r", msgidAddress(m.Orig), msgidSerial(m))
+	if m.PID != "" {
+		fmt.Fprintf(&b, "\x01PID: %s\r", m.PID)
+	}
+	b.WriteString("\x01CHRS: UTF-8 4\r")
+
+	body := strings.ReplaceAll(m.Body, "\r\n", "\r")
+	body = strings.ReplaceAll(body, "\n", "\r")
+	// A NUL would end the message early on the reading side.
+	body = strings.ReplaceAll(body, "\x00", "")
+	b.WriteString(body)
+	if !strings.HasSuffix(body, "\r") {
+		b.WriteString("\r")
+	}
+	if m.Area != "" {
+		writeEchoTrailer(&b, m)
+	}
+	return b.String()
+}
+
+// writeEchoTrailer writes the lines that close an echomail. SEEN-BY and PATH
+// carry 2D addresses only; a point is represented by its boss node.
+func writeEchoTrailer(b *strings.Builder, m *Message) {
+	b.WriteString("---")
+	if m.PID != "" {
+		b.WriteString(" " + m.PID)
+	}
+	b.WriteString("\r")
+
+	origin := m.Origin
+	if origin == "" {
+		origin = m.From
+	}
+	// The whole Origin line must fit in 79 characters.
+	addr := " (" + m.Orig.String() + ")"
+	origin = truncate(origin, 79-len(" * Origin: ")-len(addr))
+	fmt.Fprintf(b, " * Origin: %s%s\r", origin, addr)
+
+	seenBy := m.Orig.Node2D()
+	if m.Dest.Zone == m.Orig.Zone && m.Dest.Node2D() != m.Orig.Node2D() {
+		seenBy = seenByLine(m.Orig, m.Dest)
+	}
+	fmt.Fprintf(b, "SEEN-BY: %s\r", seenBy)
+	fmt.Fprintf(b, "\x01PATH: %s\r", m.Orig.Node2D())
+}
+
+// seenByLine lists two nodes of one zone in the sorted, net-compressed form
+// SEEN-BY lines use ("5020/1 1042" for two nodes of net 5020).
+func seenByLine(a, b Address) string {
+	if b.Net < a.Net || (b.Net == a.Net && b.Node < a.Node) {
+		a, b = b, a
+	}
+	if a.Net == b.Net {
+		return fmt.Sprintf("%d/%d %d", a.Net, a.Node, b.Node)
+	}
+	return a.Node2D() + " " + b.Node2D()
+}
+
+// msgidAddress is the MSGID origin: the full address, with the domain when
+// one is known (FTS-0009).
+func msgidAddress(a Address) string {
+	if a.Domain != "" {
+		return a.String() + "@" + a.Domain
+	}
+	return a.String()
+}
+
+// msgidSerial derives the MSGID serial from the message time and text, so two
+// messages written in the same second still get different serials.
+func msgidSerial(m *Message) uint32 {
+	// FNV-1a over the fields that tell messages apart.
+	h := uint32(2166136261)
+	for _, s := range []string{m.To, m.Subject, m.Body} {
+		for i := 0; i < len(s); i++ {
+			h ^= uint32(s[i])
+			h *= 16777619
+		}
+	}
+	return h ^ uint32(m.Date.UnixNano()>>10)
+}
+
+// truncate cuts s to at most n bytes without splitting a UTF-8 sequence, and
+// drops NULs, which would end the field early.
+func truncate(s string, n int) string {
+	s = strings.ReplaceAll(s, "\x00", "")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
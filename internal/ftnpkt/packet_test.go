@@ -0,0 +1,252 @@
+package ftnpkt
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustAddr(t *testing.T, s string) Address {
+	t.Helper()
+	a, err := ParseAddress(s)
+	if err != nil {
+		t.Fatalf("ParseAddress(%q): %v", s, err)
+	}
+	return a
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Address
+		wantErr bool
+	}{
+		{in: "2:5020/1042", want: Address{Zone: 2, Net: 5020, Node: 1042}},
+		{in: "2:5020/1042.7", want: Address{Zone: 2, Net: 5020, Node: 1042, Point: 7}},
+		{in: "21:1/100@FSXNet", want: Address{Zone: 21, Net: 1, Node: 100, Domain: "fsxnet"}},
+		{in: " 1:2/3 ", want: Address{Zone: 1, Net: 2, Node: 3}},
+		{in: "5020/1042", wantErr: true},
+		{in: "2:5020", wantErr: true},
+		{in: "2:x/1", wantErr: true},
+		{in: "2:70000/1", wantErr: true},
+		{in: "", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseAddress(tt.in)
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("ParseAddress(%q) = %+v, want error", tt.in, got)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("ParseAddress(%q) = %+v, %v; want %+v", tt.in, got, err, tt.want)
+		}
+	}
+
+	if s := (Address{Zone: 2, Net: 5020, Node: 1042, Point: 7}).String(); s != "2:5020/1042.7" {
+		t.Errorf("String() = %q", s)
+	}
+}
+
+func TestWriteHeader(t *testing.T) {
+	h := Header{
+		Orig:     mustAddr(t, "2:5020/1042.3"),
+		Dest:     mustAddr(t, "2:5020/1"),
+		Password: "SECRET",
+		Created:  time.Date(2026, time.March, 9, 14, 5, 6, 0, time.UTC),
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, h, nil); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()
+	if len(b) != 58+2 {
+		t.Fatalf("empty packet is %d bytes, want 60", len(b))
+	}
+	u16 := func(off int) uint16 { return binary.LittleEndian.Uint16(b[off:]) }
+
+	checks := []struct {
+		name string
+		off  int
+		want uint16
+	}{
+		{"origNode", 0, 1042},
+		{"destNode", 2, 1},
+		{"year", 4, 2026},
+		{"month", 6, 2},
+		{"day", 8, 9},
+		{"hour", 10, 14},
+		{"packet type", 18, 2},
+		{"origNet", 20, 5020},
+		{"destNet", 22, 5020},
+		{"qOrigZone", 34, 2},
+		{"capValid", 40, 0x0100},
+		{"capWord", 44, 0x0001},
+		{"origZone", 46, 2},
+		{"destZone", 48, 2},
+		{"origPoint", 50, 3},
+		{"destPoint", 52, 0},
+		{"terminator", 58, 0},
+	}
+	for _, c := range checks {
+		if got := u16(c.off); got != c.want {
+			t.Errorf("%s at %d = %d, want %d", c.name, c.off, got, c.want)
+		}
+	}
+	if b[24] != 0xFE {
+		t.Errorf("product code low byte = %#x, want 0xfe", b[24])
+	}
+	if pw := string(bytes.TrimRight(b[26:34], "\x00")); pw != "SECRET" {
+		t.Errorf("password = %q", pw)
+	}
+}
+
+func TestWriteMessage(t *testing.T) {
+	msg := Message{
+		Orig:    mustAddr(t, "2:5020/1042@fidonet"),
+		Dest:    mustAddr(t, "1:234/5.6"),
+		From:    "NodelistDB",
+		To:      strings.Repeat("x", 50),
+		Subject: "Node 1:234/5 unreachable",
+		Date:    time.Date(2026, time.October, 1, 8, 30, 0, 0, time.UTC),
+		Attr:    AttrPrivate | AttrLocal,
+		PID:     "nodelistdb",
+		Body:    "line one\nline two",
+	}
+	var buf bytes.Buffer
+	if err := Write(&buf, Header{Orig: msg.Orig, Dest: msg.Dest}, []Message{msg}); err != nil {
+		t.Fatal(err)
+	}
+	b := buf.Bytes()[58:]
+
+	if typ := binary.LittleEndian.Uint16(b); typ != 2 {
+		t.Fatalf("message type = %d", typ)
+	}
+	if attr := binary.LittleEndian.Uint16(b[10:]); attr != AttrPrivate|AttrLocal {
+		t.Errorf("attribute = %#x", attr)
+	}
+	strs := bytes.Split(b[14:], []byte{0})
+	if got := string(strs[0]); got != "01 Oct 26  08:30:00" {
+		t.Errorf("date = %q", got)
+	}
+	if got := string(strs[1]); len(got) != 35 {
+		t.Errorf("to name is %d bytes, want it cut to 35", len(got))
+	}
+	if got := string(strs[2]); got != "NodelistDB" {
+		t.Errorf("from = %q", got)
+	}
+	text := string(strs[4])
+	for _, want := range []string{
+		"\x01INTL 1:234/5 2:5020/1042\r",
+		"\x01TOPT 6\r",
+		"\x01MSGID: 2:5020/1042@fidonet ",
+		"\x01PID: nodelistdb\r",
+		"line one\rline two\r",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("message text lacks %q:\n%q", want, text)
+		}
+	}
+	if strings.Contains(text, "FMPT") {
+		t.Errorf("FMPT written for a message not from a point")
+	}
+	// Text NUL, then the packet terminator.
+	if tail := buf.Bytes()[buf.Len()-2:]; !bytes.Equal(tail, []byte{0, 0}) {
+		t.Errorf("packet ends % x, want 00 00", tail)
+	}
+}
+
+func TestTruncateKeepsRunesWhole(t *testing.T) {
+	s := strings.Repeat("a", 34) + "ü"
+	if got := truncate(s, 35); got != strings.Repeat("a", 34) {
+		t.Errorf("truncate split a rune: %q", got)
+	}
+}
+
+func TestWriteFile(t *testing.T) {
+	dir := t.TempDir()
+	h := Header{Orig: mustAddr(t, "2:5020/1042"), Dest: mustAddr(t, "2:5020/1")}
+
+	first, err := WriteFile(dir, h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := WriteFile(dir, h, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatalf("two packets written to the same name %s", first)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, e := range entries {
+		if filepath.Ext(e.Name()) != ".pkt" || len(e.Name()) != 12 {
+			t.Errorf("unexpected file %s left in outbound", e.Name())
+		}
+	}
+	if len(entries) != 2 {
+		t.Errorf("outbound holds %d files, want 2", len(entries))
+	}
+}
+
+func TestWriteEchomail(t *testing.T) {
+	msg := Message{
+		Orig:    mustAddr(t, "2:5020/1042"),
+		Dest:    mustAddr(t, "2:5020/1"),
+		From:    "NodelistDB",
+		To:      "All",
+		Subject: "IPv6 weekly news",
+		Date:    time.Date(2026, time.October, 1, 8, 30, 0, 0, time.UTC),
+		PID:     "NodelistDB fidoreport",
+		Body:    "report\n",
+		Area:    "nodelist.stats",
+		Origin:  "NodelistDB - nodelist.example.net",
+	}
+	text := messageText(&msg)
+	want := "AREA:NODELIST.STATS\r" +
+		"\x01MSGID: 2:5020/1042 "
+	if !strings.HasPrefix(text, want) {
+		t.Errorf("echomail starts %q, want %q", text, want)
+	}
+	for _, want := range []string{
+		"report\r--- NodelistDB fidoreport\r",
+		" * Origin: NodelistDB - nodelist.example.net (2:5020/1042)\r",
+		"SEEN-BY: 5020/1 1042\r",
+		"\x01PATH: 5020/1042\r",
+	} {
+		if !strings.Contains(text, want) {
+			t.Errorf("echomail lacks %q:\n%q", want, text)
+		}
+	}
+	if strings.Contains(text, "INTL") {
+		t.Error("INTL kludge written into echomail")
+	}
+
+	msg.Origin = strings.Repeat("o", 100)
+	for _, line := range strings.Split(messageText(&msg), "\r") {
+		if strings.HasPrefix(line, " * Origin: ") && len(line) > 79 {
+			t.Errorf("Origin line is %d characters, want at most 79", len(line))
+		}
+	}
+}
+
+func TestSeenByLine(t *testing.T) {
+	tests := []struct{ a, b, want string }{
+		{"2:5020/1042", "2:5020/1", "5020/1 1042"},
+		{"2:5030/1", "2:5020/1042", "5020/1042 5030/1"},
+	}
+	for _, tt := range tests {
+		if got := seenByLine(mustAddr(t, tt.a), mustAddr(t, tt.b)); got != tt.want {
+			t.Errorf("seenByLine(%s, %s) = %q, want %q", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
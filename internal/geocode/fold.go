@@ -0,0 +1,43 @@
+package geocode
+
+import "github.com/nodelistdb/internal/translit"
+
+// abbreviations expands the words place names are commonly shortened to, so
+// "St.Petersburg", "Sankt-Peterburg" and "Saint Petersburg" fold alike.
+var abbreviations = map[string]string{
+	"st": "saint", "sankt": "saint", "ste": "sainte", "ft": "fort", "mt": "mount",
+	"nth": "north", "sth": "south",
+}
+
+// fold reduces a place name or a whole Location field to lowercase ASCII
+// words: translit.Words, then runs of single letters are joined ("U.S.A."
+// is "usa", "D.C." is "dc") and common abbreviations are expanded.
+// Gazetteer names and nodelist locations go through the same fold, so any
+// spelling the gazetteer lists matches however it is punctuated.
+func fold(s string) []string {
+	words := translit.Words(s)
+	out := words[:0]
+	for i := 0; i < len(words); i++ {
+		w := words[i]
+		if len(w) == 1 && i+1 < len(words) && len(words[i+1]) == 1 {
+			for i+1 < len(words) && len(words[i+1]) == 1 {
+				i++
+				w += words[i]
+			}
+		} else if long, ok := abbreviations[w]; ok {
+			w = long
+		}
+		out = append(out, w)
+	}
+	return out
+}
+
+// isDigits reports whether w is a number, such as a postal code.
+func isDigits(w string) bool {
+	for _, r := range w {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return w != ""
+}
@@ -0,0 +1,191 @@
+package geocode
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// country is one entry of countries.tsv.
+type country struct {
+	code     string
+	name     string
+	lat, lon float64
+}
+
+// regionKey identifies a first-level division: a US state, Canadian
+// province or Australian state.
+type regionKey struct {
+	country, code string
+}
+
+// region is one entry of admin1.tsv.
+type region struct {
+	regionKey
+	name     string
+	lat, lon float64
+}
+
+// maxRegionCodeLen is the longest single-word alternate of a division that
+// is treated as a postal-style abbreviation ("Calif", "Ont", "Qld") rather
+// than a name. Abbreviations are only recognised at the end of a location.
+const maxRegionCodeLen = 5
+
+// load reads the three gazetteer files into g and hashes them into
+// g.version.
+func (g *Geocoder) load(cities, countries, admin1 io.Reader) error {
+	h := sha256.New()
+
+	err := readTSV(h, countries, "countries", 5, func(f []string) error {
+		lat, lon, err := parseLatLon(f[3], f[4])
+		if err != nil {
+			return err
+		}
+		c := country{code: strings.ToUpper(f[0]), name: f[1], lat: lat, lon: lon}
+		if len(c.code) != 2 {
+			return fmt.Errorf("invalid country code %q", f[0])
+		}
+		g.countries[c.code] = c
+		for _, name := range append([]string{c.name}, splitAlternates(f[2])...) {
+			n := g.addName(name, func(k string) { g.countryNames[k] = appendUnique(g.countryNames[k], c.code) })
+			g.maxNameWords = max(g.maxNameWords, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = readTSV(h, admin1, "admin1", 6, func(f []string) error {
+		lat, lon, err := parseLatLon(f[4], f[5])
+		if err != nil {
+			return err
+		}
+		r := region{regionKey: regionKey{country: strings.ToUpper(f[0]), code: strings.ToUpper(f[1])}, name: f[2], lat: lat, lon: lon}
+		if _, ok := g.countries[r.country]; !ok {
+			return fmt.Errorf("division %s of unknown country %s", r.code, r.country)
+		}
+		g.regions[r.regionKey] = r
+		g.addRegionCode(r.code, r.regionKey)
+		addRegionName := func(k string) { g.regionNames[k] = appendUnique(g.regionNames[k], r.regionKey) }
+		g.maxNameWords = max(g.maxNameWords, g.addName(r.name, addRegionName))
+		for _, alt := range splitAlternates(f[3]) {
+			if words := fold(alt); len(words) == 1 && len(words[0]) <= maxRegionCodeLen {
+				g.addRegionCode(alt, r.regionKey)
+				continue
+			}
+			g.maxNameWords = max(g.maxNameWords, g.addName(alt, addRegionName))
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	err = readTSV(h, cities, "cities", 7, func(f []string) error {
+		lat, lon, err := parseLatLon(f[2], f[3])
+		if err != nil {
+			return err
+		}
+		p := Place{Name: f[0], CountryCode: strings.ToUpper(f[4]), Admin1: strings.ToUpper(f[5]), Lat: lat, Lon: lon}
+		if _, ok := g.countries[p.CountryCode]; !ok {
+			return fmt.Errorf("%s: unknown country %s", p.Name, p.CountryCode)
+		}
+		if p.Admin1 != "" {
+			if _, ok := g.regions[regionKey{p.CountryCode, p.Admin1}]; !ok {
+				return fmt.Errorf("%s: unknown division %s-%s", p.Name, p.CountryCode, p.Admin1)
+			}
+		}
+		if f[6] != "" {
+			if p.Population, err = strconv.Atoi(f[6]); err != nil {
+				return fmt.Errorf("%s: invalid population %q", p.Name, f[6])
+			}
+		}
+		idx := len(g.places)
+		g.places = append(g.places, p)
+		for _, name := range append([]string{p.Name}, splitAlternates(f[1])...) {
+			n := g.addName(name, func(k string) { g.cityNames[k] = appendUnique(g.cityNames[k], idx) })
+			g.maxCityWords = max(g.maxCityWords, n)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	g.version = hex.EncodeToString(h.Sum(nil))[:12]
+	return nil
+}
+
+// addName folds name and passes the key to add. It returns the number of
+// words in the key.
+func (g *Geocoder) addName(name string, add func(key string)) int {
+	words := fold(name)
+	if len(words) == 0 {
+		return 0
+	}
+	add(strings.Join(words, " "))
+	return len(words)
+}
+
+func (g *Geocoder) addRegionCode(code string, k regionKey) {
+	if words := fold(code); len(words) == 1 {
+		g.regionCodes[words[0]] = appendUnique(g.regionCodes[words[0]], k)
+	}
+}
+
+// readTSV calls fn with the fields of every data line of r, skipping blank
+// lines and # comments, and writes everything read to h.
+func readTSV(h hash.Hash, r io.Reader, file string, fields int, fn func([]string) error) error {
+	sc := bufio.NewScanner(io.TeeReader(r, h))
+	for line := 1; sc.Scan(); line++ {
+		text := sc.Text()
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		f := strings.Split(text, "\t")
+		if len(f) != fields {
+			return fmt.Errorf("%s line %d: %d fields, want %d", file, line, len(f), fields)
+		}
+		if err := fn(f); err != nil {
+			return fmt.Errorf("%s line %d: %w", file, line, err)
+		}
+	}
+	if err := sc.Err(); err != nil {
+		return fmt.Errorf("failed to read %s: %w", file, err)
+	}
+	return nil
+}
+
+func parseLatLon(latStr, lonStr string) (float64, float64, error) {
+	lat, err := strconv.ParseFloat(latStr, 64)
+	if err != nil || lat < -90 || lat > 90 {
+		return 0, 0, fmt.Errorf("invalid latitude %q", latStr)
+	}
+	lon, err := strconv.ParseFloat(lonStr, 64)
+	if err != nil || lon < -180 || lon > 180 {
+		return 0, 0, fmt.Errorf("invalid longitude %q", lonStr)
+	}
+	return lat, lon, nil
+}
+
+func splitAlternates(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
+
+func appendUnique[T comparable](s []T, v T) []T {
+	for _, x := range s {
+		if x == v {
+			return s
+		}
+	}
+	return append(s, v)
+}
@@ -0,0 +1,338 @@
+// Package geocode resolves the free-text Location field of nodelist entries
+// ("Moscow", "St.Petersburg", "Portland, ME", "Smalltown, Germany") to
+// coordinates using a small gazetteer bundled with the binary, so nodes can
+// be mapped for any nodelist date without a network lookup.
+//
+// The gazetteer is a GeoNames-style subset: populated places with their
+// alternate spellings, countries, and the first-level divisions that
+// nodelists customarily write after the city (US states, Canadian provinces,
+// Australian states). A location resolves to a city when one is named and
+// agrees with any country or division the location also names, otherwise
+// to the division or country it names, and otherwise not at all.
+package geocode
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+//go:embed data/*.tsv
+var data embed.FS
+
+// Precision says what a location resolved to.
+type Precision string
+
+const (
+	PrecisionCity    Precision = "city"
+	PrecisionRegion  Precision = "region"  // a US state, Canadian province or Australian state
+	PrecisionCountry Precision = "country" // plotted at the capital or a central city
+)
+
+// Place is a populated place of the gazetteer.
+type Place struct {
+	Name        string
+	CountryCode string // ISO 3166-1 alpha-2
+	Admin1      string // division code, only for countries with divisions
+	Lat, Lon    float64
+	Population  int
+}
+
+// Result is a resolved location.
+type Result struct {
+	Place       string // the gazetteer's name for the city, division or country
+	CountryCode string
+	Lat, Lon    float64
+	Precision   Precision
+}
+
+// Geocoder resolves locations against one gazetteer. It is safe for
+// concurrent use.
+type Geocoder struct {
+	places    []Place
+	countries map[string]country
+	regions   map[regionKey]region
+
+	cityNames    map[string][]int       // folded name -> indexes into places
+	countryNames map[string][]string    // folded name -> country codes
+	regionNames  map[string][]regionKey // folded name -> divisions
+	regionCodes  map[string][]regionKey // folded abbreviation -> divisions
+	maxCityWords int
+	maxNameWords int // longest country or division name
+
+	version string
+}
+
+var (
+	defaultOnce     sync.Once
+	defaultGeocoder *Geocoder
+	defaultErr      error
+)
+
+// Default returns the geocoder for the bundled gazetteer, loading it on
+// first use.
+func Default() (*Geocoder, error) {
+	defaultOnce.Do(func() {
+		var files [3][]byte
+		for i, name := range []string{"cities", "countries", "admin1"} {
+			if files[i], defaultErr = data.ReadFile("data/" + name + ".tsv"); defaultErr != nil {
+				return
+			}
+		}
+		defaultGeocoder, defaultErr = New(bytes.NewReader(files[0]), bytes.NewReader(files[1]), bytes.NewReader(files[2]))
+	})
+	return defaultGeocoder, defaultErr
+}
+
+// New builds a geocoder from tab-separated gazetteer files in the layout of
+// the bundled ones under data/.
+func New(cities, countries, admin1 io.Reader) (*Geocoder, error) {
+	g := &Geocoder{
+		countries:    make(map[string]country),
+		regions:      make(map[regionKey]region),
+		cityNames:    make(map[string][]int),
+		countryNames: make(map[string][]string),
+		regionNames:  make(map[string][]regionKey),
+		regionCodes:  make(map[string][]regionKey),
+	}
+	if err := g.load(cities, countries, admin1); err != nil {
+		return nil, fmt.Errorf("failed to load gazetteer: %w", err)
+	}
+	return g, nil
+}
+
+// Version identifies the gazetteer's contents. Stored geocodes record it so
+// that locations are resolved again when the gazetteer changes.
+func (g *Geocoder) Version() string { return g.version }
+
+// Places returns every populated place of the gazetteer.
+func (g *Geocoder) Places() []Place {
+	return append([]Place(nil), g.places...)
+}
+
+// CountryName returns the English name of an ISO country code, or the code
+// itself when the gazetteer does not list it.
+func (g *Geocoder) CountryName(code string) string {
+	if c, ok := g.countries[strings.ToUpper(code)]; ok {
+		return c.name
+	}
+	return code
+}
+
+// hints are the countries and divisions a location names besides its city.
+// A country named in full is explicit; one only written as a two-letter code
+// is not, since "CA" and "DE" are also US states.
+type hints struct {
+	countries map[string]bool // code -> explicit
+	regions   map[regionKey]bool
+}
+
+func (h hints) none() bool { return len(h.countries) == 0 && len(h.regions) == 0 }
+
+// admits reports whether a place in country cc and division admin agrees
+// with the hints.
+func (h hints) admits(cc, admin string) bool {
+	if h.none() {
+		return true
+	}
+	_, ok := h.countries[cc]
+	return ok || h.regions[regionKey{cc, admin}]
+}
+
+// Resolve geocodes a nodelist Location field. It reports false when the
+// location names nothing the gazetteer knows, or names it ambiguously.
+func (g *Geocoder) Resolve(location string) (Result, bool) {
+	words := fold(location)
+	if len(words) == 0 {
+		return Result{}, false
+	}
+	h := g.hints(words)
+
+	if p, ok := g.bestCity(words, h); ok {
+		return Result{Place: p.Name, CountryCode: p.CountryCode, Lat: p.Lat, Lon: p.Lon, Precision: PrecisionCity}, true
+	}
+	if r, ok := g.hintedRegion(h); ok {
+		return Result{Place: r.name, CountryCode: r.country, Lat: r.lat, Lon: r.lon, Precision: PrecisionRegion}, true
+	}
+	if c, ok := g.hintedCountry(h); ok {
+		return Result{Place: c.name, CountryCode: c.code, Lat: c.lat, Lon: c.lon, Precision: PrecisionCountry}, true
+	}
+	return Result{}, false
+}
+
+// hints collects the countries and divisions named in words. Full names
+// are matched anywhere, longest first, except inside a longer city name
+// ("Port of Spain", "Kansas City"). Codes and abbreviations ("DE", "WA",
+// "Ont") are short enough to be ordinary words, so they are only taken from
+// the end of the location, after the place they qualify.
+func (g *Geocoder) hints(words []string) hints {
+	h := hints{countries: make(map[string]bool), regions: make(map[regionKey]bool)}
+	used := make([]bool, len(words))
+
+	var citySpans [][2]int
+	for i := range words {
+		for n := 2; n <= g.maxCityWords && i+n <= len(words); n++ {
+			if len(g.cityNames[strings.Join(words[i:i+n], " ")]) > 0 {
+				citySpans = append(citySpans, [2]int{i, i + n})
+			}
+		}
+	}
+	insideCity := func(i, n int) bool {
+		for _, s := range citySpans {
+			if s[0] <= i && i+n <= s[1] && s[1]-s[0] > n {
+				return true
+			}
+		}
+		return false
+	}
+
+	for i := 0; i < len(words); {
+		n := min(g.maxNameWords, len(words)-i)
+		for ; n > 0; n-- {
+			key := strings.Join(words[i:i+n], " ")
+			ccs, regs := g.countryNames[key], g.regionNames[key]
+			if len(ccs) == 0 && len(regs) == 0 || insideCity(i, n) {
+				continue
+			}
+			for _, cc := range ccs {
+				h.countries[cc] = true
+			}
+			for _, r := range regs {
+				h.regions[r] = true
+			}
+			for j := i; j < i+n; j++ {
+				used[j] = true
+			}
+			break
+		}
+		i += max(n, 1)
+	}
+
+	// A location made only of codes ("AL FL GA", "AU (SG)") is a region
+	// coordinator's list or a note, not a place, and names no hint at all.
+	codes := hints{countries: make(map[string]bool), regions: make(map[regionKey]bool)}
+	named := false
+	i := len(words) - 1
+	for ; i >= 0; i-- {
+		w := words[i]
+		if used[i] {
+			named = true
+			continue
+		}
+		if isDigits(w) {
+			continue
+		}
+		cc := strings.ToUpper(w)
+		_, isCountry := g.countries[cc]
+		isCountry = isCountry && len(cc) == 2
+		regs := g.regionCodes[w]
+		if !isCountry && len(regs) == 0 {
+			break
+		}
+		if isCountry {
+			codes.countries[cc] = false
+		}
+		for _, r := range regs {
+			codes.regions[r] = true
+		}
+	}
+	if i < 0 && !named {
+		return h
+	}
+	for cc := range codes.countries {
+		if _, ok := h.countries[cc]; !ok {
+			h.countries[cc] = false
+		}
+	}
+	for r := range codes.regions {
+		h.regions[r] = true
+	}
+	return h
+}
+
+// bestCity returns the city named in words that agrees with the hints,
+// preferring one in a named division, then one in a named country, then
+// the longest name and finally the most populous place.
+func (g *Geocoder) bestCity(words []string, h hints) (Place, bool) {
+	type candidate struct {
+		idx, words          int
+		inRegion, inCountry bool
+	}
+	var best *candidate
+	better := func(a, b candidate) bool {
+		switch {
+		case a.inRegion != b.inRegion:
+			return a.inRegion
+		case a.inCountry != b.inCountry:
+			return a.inCountry
+		case a.words != b.words:
+			return a.words > b.words
+		case g.places[a.idx].Population != g.places[b.idx].Population:
+			return g.places[a.idx].Population > g.places[b.idx].Population
+		}
+		return a.idx < b.idx
+	}
+	for i := range words {
+		for n := 1; n <= g.maxCityWords && i+n <= len(words); n++ {
+			for _, idx := range g.cityNames[strings.Join(words[i:i+n], " ")] {
+				p := g.places[idx]
+				if !h.admits(p.CountryCode, p.Admin1) {
+					continue
+				}
+				_, inCountry := h.countries[p.CountryCode]
+				c := candidate{idx: idx, words: n, inRegion: h.regions[regionKey{p.CountryCode, p.Admin1}], inCountry: inCountry}
+				if best == nil || better(c, *best) {
+					best = &c
+				}
+			}
+		}
+	}
+	if best == nil {
+		return Place{}, false
+	}
+	return g.places[best.idx], true
+}
+
+// hintedRegion returns the division the hints name, if they name exactly
+// one that is not contradicted by a country named in full.
+func (g *Geocoder) hintedRegion(h hints) (region, bool) {
+	var explicit bool
+	for _, e := range h.countries {
+		explicit = explicit || e
+	}
+	var found []regionKey
+	for k := range h.regions {
+		if !explicit || h.countries[k.country] {
+			found = append(found, k)
+		}
+	}
+	if len(found) != 1 {
+		return region{}, false
+	}
+	return g.regions[found[0]], true
+}
+
+// hintedCountry returns the country the hints name: the one named in full,
+// else the one given by code, provided there is exactly one.
+func (g *Geocoder) hintedCountry(h hints) (country, bool) {
+	var named, coded []string
+	for cc, explicit := range h.countries {
+		if explicit {
+			named = append(named, cc)
+		} else {
+			coded = append(coded, cc)
+		}
+	}
+	sort.Strings(named)
+	switch {
+	case len(named) == 1:
+		return g.countries[named[0]], true
+	case len(named) == 0 && len(coded) == 1:
+		return g.countries[coded[0]], true
+	}
+	return country{}, false
+}
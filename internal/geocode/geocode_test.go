@@ -0,0 +1,108 @@
+package geocode
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFold(t *testing.T) {
+	tests := map[string][]string{
+		"St.Petersburg":        {"saint", "petersburg"},
+		"Sankt-Peterburg":      {"saint", "peterburg"},
+		"Санкт-Петербург":      {"saint", "peterburg"},
+		"Düsseldorf":           {"dusseldorf"},
+		"Washington, D.C.":     {"washington", "dc"},
+		"U.S.A.":               {"usa"},
+		"Kraków":               {"krakow"},
+		"Frankfurt_am_Main":    {"frankfurt", "am", "main"},
+		"Ft. Lauderdale, Fla.": {"fort", "lauderdale", "fla"},
+		"  -- ":                {},
+	}
+	for in, want := range tests {
+		if got := fold(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("fold(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	g, err := Default()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		location  string
+		place     string
+		country   string
+		precision Precision
+	}{
+		{"Moscow", "Moscow", "RU", PrecisionCity},
+		{"Москва", "Moscow", "RU", PrecisionCity},
+		{"St.Petersburg", "Saint Petersburg", "RU", PrecisionCity},
+		{"St. Petersburg, FL", "Saint Petersburg", "US", PrecisionCity},
+		{"Kiev, Ukraine", "Kyiv", "UA", PrecisionCity},
+		{"Seattle WA", "Seattle", "US", PrecisionCity},
+		{"Portland, ME", "Portland", "US", PrecisionCity},
+		{"Perth, Western Australia", "Perth", "AU", PrecisionCity},
+		{"Hamilton, New Zealand", "Hamilton", "NZ", PrecisionCity},
+		{"Washington DC", "Washington", "US", PrecisionCity},
+		{"Port of Spain", "Port of Spain", "TT", PrecisionCity},
+		{"Berlin DE", "Berlin", "DE", PrecisionCity},
+		{"Dover DE", "Dover", "US", PrecisionCity},
+		{"119415 Moscow, Russia", "Moscow", "RU", PrecisionCity},
+		{"Smalltown, Germany", "Germany", "DE", PrecisionCountry},
+		{"Georgia", "Georgia", "GE", PrecisionCountry},
+		{"Texas", "Texas", "US", PrecisionRegion},
+		{"Anytown, CA", "California", "US", PrecisionRegion},
+		{"Victoria, Australia", "Victoria", "AU", PrecisionRegion},
+		{"Victoria BC", "Victoria", "CA", PrecisionCity},
+		{"Elizabeth Downs Sth Australia", "South Australia", "AU", PrecisionRegion},
+	}
+	for _, tt := range tests {
+		t.Run(tt.location, func(t *testing.T) {
+			got, ok := g.Resolve(tt.location)
+			if !ok {
+				t.Fatalf("Resolve(%q) did not resolve", tt.location)
+			}
+			if got.Place != tt.place || got.CountryCode != tt.country || got.Precision != tt.precision {
+				t.Errorf("Resolve(%q) = %s, %s (%s), want %s, %s (%s)",
+					tt.location, got.Place, got.CountryCode, got.Precision, tt.place, tt.country, tt.precision)
+			}
+		})
+	}
+
+	for _, location := range []string{"", "Somewhere", "In the middle of nowhere", "Anytown WA", "DE", "AL FL GA MS", "-Unpublished-"} {
+		if got, ok := g.Resolve(location); ok {
+			t.Errorf("Resolve(%q) = %+v, want no result", location, got)
+		}
+	}
+}
+
+func TestNewRejectsMalformedData(t *testing.T) {
+	countries := "# code\tname\talternates\tlat\tlon\nUS\tUnited States\tUSA\t39.8\t-98.6\n"
+	admin1 := "US\tTX\tTexas\tTex\t31.5\t-99.3\n"
+	tests := map[string]string{
+		"short line":      "Austin\t\t30.27\t-97.74\tUS\tTX\n",
+		"unknown country": "Paris\t\t48.85\t2.35\tFR\t\t2100000\n",
+		"unknown region":  "Reno\t\t39.53\t-119.81\tUS\tNV\t250000\n",
+		"bad latitude":    "Austin\t\t300\t-97.74\tUS\tTX\t960000\n",
+	}
+	for name, cities := range tests {
+		if _, err := New(strings.NewReader(cities), strings.NewReader(countries), strings.NewReader(admin1)); err == nil {
+			t.Errorf("%s: New succeeded", name)
+		}
+	}
+
+	g, err := New(strings.NewReader("Austin\t\t30.27\t-97.74\tUS\tTX\t960000\n"), strings.NewReader(countries), strings.NewReader(admin1))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, ok := g.Resolve("Austin, Tex."); !ok || got.Place != "Austin" {
+		t.Errorf("Resolve(Austin, Tex.) = %+v, %v", got, ok)
+	}
+	if len(g.Version()) != 12 {
+		t.Errorf("Version() = %q", g.Version())
+	}
+}
@@ -0,0 +1,770 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+)
+
+// Request is a GraphQL request as POSTed by clients.
+type Request struct {
+	Query         string         `json:"query"`
+	OperationName string         `json:"operationName,omitempty"`
+	Variables     map[string]any `json:"variables,omitempty"`
+}
+
+// Location is a position in the query text.
+type Location struct {
+	Line   int `json:"line"`
+	Column int `json:"column"`
+}
+
+// Error is a GraphQL error: a request that could not be parsed or validated,
+// or a field that failed during execution.
+type Error struct {
+	Message   string     `json:"message"`
+	Locations []Location `json:"locations,omitempty"`
+	Path      []any      `json:"path,omitempty"`
+
+	err error
+}
+
+func (e *Error) Error() string { return e.Message }
+
+// Unwrap returns the error a resolver returned, if any, so callers can tell
+// a context deadline from a plain failure.
+func (e *Error) Unwrap() error { return e.err }
+
+// Response is the result of a request. Data is absent when the request never
+// reached execution, and null when a non-null root field failed.
+type Response struct {
+	Data   any
+	Errors []*Error
+
+	executed bool
+}
+
+// MarshalJSON writes the response in the shape the spec requires: "data"
+// present exactly when execution started.
+func (r *Response) MarshalJSON() ([]byte, error) {
+	out := struct {
+		Errors []*Error `json:"errors,omitempty"`
+		Data   *any     `json:"data,omitempty"`
+	}{Errors: r.Errors}
+	if r.executed {
+		out.Data = &r.Data
+	}
+	return json.Marshal(out)
+}
+
+// Executed reports whether the request got past parsing and validation.
+func (r *Response) Executed() bool { return r.executed }
+
+// ErrTooExpensive is the error a field fails with once a query has used up
+// SchemaConfig.MaxResolves.
+var ErrTooExpensive = errors.New("query is too expensive")
+
+// maxSelections caps how many selections a query may expand to, fragments
+// spread out, in validation and in any one selection set at execution. A
+// short document can spread fragments into one another until it stands for
+// millions of fields; nothing that size is a reasonable query.
+const maxSelections = 10000
+
+// Prepared is a parsed and validated request, ready to execute.
+type Prepared struct {
+	schema   *Schema
+	doc      *document
+	op       *operation
+	vars     map[string]any
+	selected map[*Field]bool
+}
+
+// Execute prepares and executes a request.
+func (s *Schema) Execute(ctx context.Context, req Request) *Response {
+	p, errs := s.Prepare(ctx, req)
+	if errs != nil {
+		return &Response{Errors: errs}
+	}
+	return p.Execute(ctx)
+}
+
+// Prepare parses a request, picks its operation, coerces its variables and
+// validates it against the schema. Nothing is resolved, but validation still
+// gives up once ctx is done.
+func (s *Schema) Prepare(ctx context.Context, req Request) (*Prepared, []*Error) {
+	doc, err := parse(req.Query)
+	if err != nil {
+		return nil, []*Error{asError(err)}
+	}
+
+	var op *operation
+	switch {
+	case req.OperationName != "":
+		for _, o := range doc.operations {
+			if o.name == req.OperationName {
+				op = o
+			}
+		}
+		if op == nil {
+			return nil, []*Error{{Message: fmt.Sprintf("Unknown operation named %q.", req.OperationName)}}
+		}
+	case len(doc.operations) == 1:
+		op = doc.operations[0]
+	default:
+		return nil, []*Error{{Message: "Must provide operation name if query contains multiple operations."}}
+	}
+	if op.kind != "query" {
+		return nil, []*Error{{Message: fmt.Sprintf("Only queries are supported, not %ss.", op.kind), Locations: []Location{op.loc}}}
+	}
+
+	p := &Prepared{schema: s, doc: doc, op: op, selected: make(map[*Field]bool)}
+	if errs := p.coerceVariables(req.Variables); errs != nil {
+		return nil, errs
+	}
+	v := &validator{ctx: ctx, p: p, fragmentDepth: make(map[string]bool), validated: make(map[fragmentUse]bool)}
+	v.selections(s.cfg.Query, op.selections, 1)
+	if v.errs != nil {
+		return nil, v.errs
+	}
+	return p, nil
+}
+
+// Selects reports whether the operation selects any field match accepts,
+// anywhere in the query. Callers use it to pick a policy, such as a longer
+// deadline, before executing.
+func (p *Prepared) Selects(match func(*Field) bool) bool {
+	for f := range p.selected {
+		if match(f) {
+			return true
+		}
+	}
+	return false
+}
+
+// Execute runs the operation. Fields are resolved one at a time, in query
+// order.
+func (p *Prepared) Execute(ctx context.Context) *Response {
+	e := &executor{ctx: ctx, p: p}
+	data, err := e.selectionSet(p.schema.cfg.Query, nil, p.op.selections, nil)
+	resp := &Response{Errors: e.errors, executed: true}
+	if err != nil {
+		resp.Errors = append(resp.Errors, asError(err))
+		return resp
+	}
+	resp.Data = data
+	return resp
+}
+
+func (p *Prepared) coerceVariables(given map[string]any) []*Error {
+	p.vars = make(map[string]any)
+	var errs []*Error
+	for _, vd := range p.op.vars {
+		t, err := p.schema.inputType(vd.typ)
+		if err != nil {
+			errs = append(errs, &Error{Message: err.Error(), Locations: []Location{vd.loc}})
+			continue
+		}
+		raw, ok := given[vd.name]
+		if !ok {
+			if vd.defValue == nil {
+				if _, nonNull := t.(*NonNull); nonNull {
+					errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" of required type %q was not provided.", vd.name, vd.typ), Locations: []Location{vd.loc}})
+				}
+				continue
+			}
+			raw = vd.defValue
+		}
+		v, err := coerceInput(t, raw, nil)
+		if err != nil {
+			errs = append(errs, &Error{Message: fmt.Sprintf("Variable \"$%s\" got invalid value: %v", vd.name, err), Locations: []Location{vd.loc}})
+			continue
+		}
+		p.vars[vd.name] = v
+	}
+	return errs
+}
+
+// inputType resolves a variable's declared type.
+func (s *Schema) inputType(ref typeRef) (Type, error) {
+	var t Type
+	if ref.elem != nil {
+		elem, err := s.inputType(*ref.elem)
+		if err != nil {
+			return nil, err
+		}
+		t = ListOf(elem)
+	} else {
+		sc, ok := s.scalars[ref.name]
+		if !ok {
+			return nil, fmt.Errorf("Unknown input type %q.", ref.name)
+		}
+		t = sc
+	}
+	if ref.nonNull {
+		t = NonNullOf(t)
+	}
+	return t, nil
+}
+
+// coerceInput coerces a literal, a variable's JSON value or a default to an
+// input type. vars resolves variables inside literals; nil when coercing a
+// variable's own value.
+func coerceInput(t Type, v any, vars map[string]any) (any, error) {
+	if name, ok := v.(variable); ok {
+		// Variables are already coerced to their own type; coercing again to
+		// the argument's type is idempotent but still wraps a single value
+		// in a list where the argument expects one.
+		v = vars[string(name)]
+		vars = nil
+	}
+	switch t := t.(type) {
+	case *NonNull:
+		if v == nil {
+			return nil, fmt.Errorf("expected non-null %s", t.OfType)
+		}
+		out, err := coerceInput(t.OfType, v, vars)
+		if err == nil && out == nil {
+			err = fmt.Errorf("expected non-null %s", t.OfType)
+		}
+		return out, err
+	}
+	if v == nil {
+		return nil, nil
+	}
+	switch t := t.(type) {
+	case *List:
+		items, ok := v.([]any)
+		if !ok {
+			// A single value stands for a list of one.
+			item, err := coerceInput(t.OfType, v, vars)
+			if err != nil {
+				return nil, err
+			}
+			return []any{item}, nil
+		}
+		out := make([]any, len(items))
+		for i, item := range items {
+			c, err := coerceInput(t.OfType, item, vars)
+			if err != nil {
+				return nil, fmt.Errorf("at index %d: %w", i, err)
+			}
+			out[i] = c
+		}
+		return out, nil
+	case *Scalar:
+		switch v.(type) {
+		case enumValue, objectValue, map[string]any, []any:
+			return nil, fmt.Errorf("%s cannot represent %v", t.Name, v)
+		}
+		if t.ParseValue == nil {
+			return v, nil
+		}
+		return t.ParseValue(v)
+	}
+	return nil, fmt.Errorf("%s is not an input type", t)
+}
+
+// coerceArgs builds a field's argument map.
+func coerceArgs(def *Field, args []*argument, vars map[string]any) (map[string]any, error) {
+	out := make(map[string]any, len(def.Args))
+	given := make(map[string]*argument, len(args))
+	for _, a := range args {
+		given[a.name] = a
+	}
+	for _, ad := range def.Args {
+		a, ok := given[ad.Name]
+		if ok {
+			// A variable that was not provided counts as an absent argument.
+			if name, isVar := a.value.(variable); isVar {
+				if _, provided := vars[string(name)]; !provided {
+					ok = false
+				}
+			}
+		}
+		if !ok {
+			if ad.Default != nil {
+				out[ad.Name] = ad.Default
+			} else if _, nonNull := ad.Type.(*NonNull); nonNull {
+				return nil, fmt.Errorf("argument %q of type %q is required", ad.Name, ad.Type)
+			}
+			continue
+		}
+		v, err := coerceInput(ad.Type, a.value, vars)
+		if err != nil {
+			return nil, fmt.Errorf("argument %q: %v", ad.Name, err)
+		}
+		out[ad.Name] = v
+	}
+	return out, nil
+}
+
+// validator checks a document against the schema before anything runs.
+type validator struct {
+	ctx           context.Context
+	p             *Prepared
+	errs          []*Error
+	fragmentDepth map[string]bool // fragments being expanded, for cycles
+	// validated holds the fragment uses already checked. A fragment spread
+	// twice at the same depth checks the same way twice, so it is expanded
+	// once; without this, fragments that each spread the next twice cost
+	// time exponential in their number.
+	validated map[fragmentUse]bool
+	expanded  int  // selections visited, against maxSelections
+	stopped   bool // validation gave up: too large, or ctx done
+}
+
+// fragmentUse is a fragment spread at a depth. Its type condition has to be
+// the enclosing object's type, so the name and depth decide the outcome.
+type fragmentUse struct {
+	name  string
+	depth int
+}
+
+func (v *validator) errorf(loc Location, format string, args ...any) {
+	v.errs = append(v.errs, &Error{Message: fmt.Sprintf(format, args...), Locations: []Location{loc}})
+}
+
+func (v *validator) selections(obj *Object, sels []selection, depth int) {
+	for _, sel := range sels {
+		if v.stopped {
+			return
+		}
+		if v.expanded++; v.expanded > maxSelections {
+			v.errorf(sel.location(), "Query is too large: more than %d selections once fragments are expanded.", maxSelections)
+			v.stopped = true
+			return
+		}
+		switch sel := sel.(type) {
+		case *field:
+			v.field(obj, sel, depth)
+		case *inlineFragment:
+			if sel.typeCond != "" && sel.typeCond != obj.Name {
+				v.errorf(sel.loc, "Fragment cannot be spread here as objects of type %q can never be of type %q.", obj.Name, sel.typeCond)
+				continue
+			}
+			v.directives(sel.directives)
+			v.selections(obj, sel.selections, depth)
+		case *fragmentSpread:
+			frag, ok := v.p.doc.fragments[sel.name]
+			if !ok {
+				v.errorf(sel.loc, "Unknown fragment %q.", sel.name)
+				continue
+			}
+			if frag.typeCond != obj.Name {
+				v.errorf(sel.loc, "Fragment %q cannot be spread here as objects of type %q can never be of type %q.", sel.name, obj.Name, frag.typeCond)
+				continue
+			}
+			if v.fragmentDepth[sel.name] {
+				v.errorf(sel.loc, "Cannot spread fragment %q within itself.", sel.name)
+				continue
+			}
+			v.directives(sel.directives)
+			use := fragmentUse{name: sel.name, depth: depth}
+			if v.validated[use] {
+				continue
+			}
+			if err := v.ctx.Err(); err != nil {
+				v.errs = append(v.errs, &Error{Message: err.Error(), err: err})
+				v.stopped = true
+				return
+			}
+			v.validated[use] = true
+			v.fragmentDepth[sel.name] = true
+			v.selections(obj, frag.selections, depth)
+			delete(v.fragmentDepth, sel.name)
+		}
+	}
+}
+
+func (v *validator) field(obj *Object, f *field, depth int) {
+	if max := v.p.schema.cfg.MaxDepth; max > 0 && depth > max {
+		v.errorf(f.loc, "Query is nested too deeply: more than %d levels.", max)
+		return
+	}
+	v.directives(f.directives)
+	if f.name == "__typename" {
+		if f.selections != nil {
+			v.errorf(f.loc, "Field \"__typename\" must not have a selection.")
+		}
+		return
+	}
+	def := obj.field(f.name)
+	if def == nil {
+		v.errorf(f.loc, "Cannot query field %q on type %q.", f.name, obj.Name)
+		return
+	}
+	v.p.selected[def] = true
+
+	for _, a := range f.args {
+		found := false
+		for _, ad := range def.Args {
+			found = found || ad.Name == a.name
+		}
+		if !found {
+			v.errorf(a.loc, "Unknown argument %q on field %q.", a.name, obj.Name+"."+f.name)
+		}
+	}
+	if _, err := coerceArgs(def, f.args, v.p.vars); err != nil {
+		v.errorf(f.loc, "Field %q: %v.", f.name, err)
+	}
+
+	switch t := namedType(def.Type).(type) {
+	case *Object:
+		if f.selections == nil {
+			v.errorf(f.loc, "Field %q of type %q must have a selection of subfields.", f.name, def.Type)
+			return
+		}
+		v.selections(t, f.selections, depth+1)
+	default:
+		if f.selections != nil {
+			v.errorf(f.loc, "Field %q must not have a selection since type %q has no subfields.", f.name, def.Type)
+		}
+	}
+}
+
+func (v *validator) directives(dirs []*directive) {
+	for _, d := range dirs {
+		if d.name != "skip" && d.name != "include" {
+			v.errorf(d.loc, "Unknown directive \"@%s\".", d.name)
+			continue
+		}
+		if _, err := directiveIf(d, v.p.vars); err != nil {
+			v.errorf(d.loc, "Directive \"@%s\": %v.", d.name, err)
+		}
+	}
+}
+
+var directiveDef = &Field{Args: []*Argument{{Name: "if", Type: NonNullOf(Boolean)}}}
+
+func directiveIf(d *directive, vars map[string]any) (bool, error) {
+	args, err := coerceArgs(directiveDef, d.args, vars)
+	if err != nil {
+		return false, err
+	}
+	b, _ := args["if"].(bool)
+	return b, nil
+}
+
+// included applies @skip and @include.
+func included(dirs []*directive, vars map[string]any) bool {
+	for _, d := range dirs {
+		b, _ := directiveIf(d, vars)
+		if (d.name == "skip" && b) || (d.name == "include" && !b) {
+			return false
+		}
+	}
+	return true
+}
+
+// executor runs one prepared operation.
+type executor struct {
+	ctx      context.Context
+	p        *Prepared
+	errors   []*Error
+	resolves int
+	// stopped is the first error that ends all further resolving: the
+	// context expiring or the query running out of resolves. Each is
+	// reported once, not once per remaining field.
+	stopped error
+}
+
+// orderedMap is a JSON object that keeps the query's field order.
+type orderedMap struct {
+	keys   []string
+	values map[string]any
+}
+
+func (m *orderedMap) set(k string, v any) {
+	if _, ok := m.values[k]; !ok {
+		m.keys = append(m.keys, k)
+	}
+	m.values[k] = v
+}
+
+// Get returns the value under a response key, for tests and callers that
+// post-process a result.
+func (m *orderedMap) Get(k string) any { return m.values[k] }
+
+func (m *orderedMap) MarshalJSON() ([]byte, error) {
+	var b strings.Builder
+	b.WriteByte('{')
+	for i, k := range m.keys {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		kb, _ := json.Marshal(k)
+		b.Write(kb)
+		b.WriteByte(':')
+		vb, err := json.Marshal(m.values[k])
+		if err != nil {
+			return nil, err
+		}
+		b.Write(vb)
+	}
+	b.WriteByte('}')
+	return []byte(b.String()), nil
+}
+
+// errTooManySelections fails a selection set that expands past
+// maxSelections. Validation rejects such queries first; this keeps execution
+// bounded on its own.
+var errTooManySelections = fmt.Errorf("%w: more than %d selections in one selection set", ErrTooExpensive, maxSelections)
+
+// collectFields groups a selection set's fields by response key, expanding
+// fragments and applying directives. n counts the selections visited.
+func (e *executor) collectFields(sels []selection, keys *[]string, groups map[string][]*field, visited map[string]bool, n *int) error {
+	vars := e.p.vars
+	for _, sel := range sels {
+		if *n++; *n > maxSelections {
+			return errTooManySelections
+		}
+		switch sel := sel.(type) {
+		case *field:
+			if !included(sel.directives, vars) {
+				continue
+			}
+			k := sel.responseKey()
+			if _, ok := groups[k]; !ok {
+				*keys = append(*keys, k)
+			}
+			groups[k] = append(groups[k], sel)
+		case *inlineFragment:
+			if !included(sel.directives, vars) {
+				continue
+			}
+			if err := e.collectFields(sel.selections, keys, groups, visited, n); err != nil {
+				return err
+			}
+		case *fragmentSpread:
+			if visited[sel.name] || !included(sel.directives, vars) {
+				continue
+			}
+			visited[sel.name] = true
+			if err := e.collectFields(e.p.doc.fragments[sel.name].selections, keys, groups, visited, n); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (e *executor) selectionSet(obj *Object, source any, sels []selection, path []any) (*orderedMap, error) {
+	var keys []string
+	groups := make(map[string][]*field)
+	n := 0
+	if err := e.collectFields(sels, &keys, groups, make(map[string]bool), &n); err != nil {
+		return nil, err
+	}
+
+	out := &orderedMap{values: make(map[string]any, len(keys))}
+	for _, k := range keys {
+		asts := groups[k]
+		fieldPath := appendPath(path, k)
+		if asts[0].name == "__typename" {
+			out.set(k, obj.Name)
+			continue
+		}
+		def := obj.field(asts[0].name)
+		v, err := e.field(def, source, asts, fieldPath)
+		if err != nil {
+			if _, nonNull := def.Type.(*NonNull); nonNull {
+				return nil, err
+			}
+			e.record(err)
+			v = nil
+		}
+		out.set(k, v)
+	}
+	return out, nil
+}
+
+func (e *executor) field(def *Field, source any, asts []*field, path []any) (any, error) {
+	ast := asts[0]
+	args, err := coerceArgs(def, ast.args, e.p.vars)
+	if err != nil {
+		return nil, e.fieldError(err, ast, path)
+	}
+
+	var v any
+	if def.Resolve != nil {
+		if e.stopped == nil {
+			if err := e.ctx.Err(); err != nil {
+				e.stopped = err
+			} else if max := e.p.schema.cfg.MaxResolves; max > 0 && e.resolves >= max {
+				e.stopped = fmt.Errorf("%w: more than %d lookups", ErrTooExpensive, max)
+			}
+		}
+		if e.stopped != nil {
+			return nil, e.fieldError(e.stopped, ast, path)
+		}
+		e.resolves++
+		v, err = def.Resolve(ResolveParams{Context: e.ctx, Source: source, Args: args})
+		if err != nil {
+			return nil, e.fieldError(err, ast, path)
+		}
+	} else if def.Derive != nil {
+		v = def.Derive(source)
+	} else {
+		v = defaultResolve(source, def.Name)
+	}
+	return e.complete(def.Type, asts, v, path)
+}
+
+func (e *executor) fieldError(err error, ast *field, path []any) *Error {
+	return &Error{Message: err.Error(), Locations: []Location{ast.loc}, Path: path, err: err}
+}
+
+// complete turns a resolved value into its response form. A returned error
+// means the value is null where null is not allowed; the caller decides
+// whether that makes its own value null or has to pass it further up.
+func (e *executor) complete(t Type, asts []*field, v any, path []any) (any, error) {
+	if nn, ok := t.(*NonNull); ok {
+		out, err := e.complete(nn.OfType, asts, v, path)
+		if err != nil {
+			return nil, err
+		}
+		if out == nil {
+			return nil, &Error{
+				Message:   fmt.Sprintf("Cannot return null for non-nullable field %s.", asts[0].name),
+				Locations: []Location{asts[0].loc},
+				Path:      path,
+			}
+		}
+		return out, nil
+	}
+
+	rv := reflect.ValueOf(v)
+	for rv.IsValid() && (rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface) {
+		if rv.IsNil() {
+			return nil, nil
+		}
+		if _, isObject := t.(*Object); isObject && rv.Kind() == reflect.Pointer {
+			break // objects are resolved against the pointer as given
+		}
+		rv = rv.Elem()
+	}
+	if !rv.IsValid() {
+		return nil, nil
+	}
+
+	switch t := t.(type) {
+	case *List:
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+			return nil, &Error{Message: fmt.Sprintf("Expected a list for field %s, got %T.", asts[0].name, v), Locations: []Location{asts[0].loc}, Path: path}
+		}
+		_, itemNonNull := t.OfType.(*NonNull)
+		out := make([]any, rv.Len())
+		for i := range out {
+			item, err := e.complete(t.OfType, asts, rv.Index(i).Interface(), appendPath(path, i))
+			if err != nil {
+				if itemNonNull {
+					return nil, err
+				}
+				e.record(err)
+			}
+			out[i] = item
+		}
+		return out, nil
+	case *Scalar:
+		out, err := t.Serialize(rv.Interface())
+		if err != nil {
+			return nil, &Error{Message: err.Error(), Locations: []Location{asts[0].loc}, Path: path}
+		}
+		return out, nil
+	case *Object:
+		var sels []selection
+		for _, a := range asts {
+			sels = append(sels, a.selections...)
+		}
+		m, err := e.selectionSet(t, rv.Interface(), sels, path)
+		if err != nil {
+			return nil, err
+		}
+		return m, nil
+	}
+	return nil, fmt.Errorf("graphql: cannot complete %s", t)
+}
+
+// record keeps a field error for the response, reporting the error that
+// stopped execution only once.
+func (e *executor) record(err error) {
+	ge := asError(err)
+	if e.stopped != nil && errors.Is(ge, e.stopped) {
+		for _, prev := range e.errors {
+			if errors.Is(prev, e.stopped) {
+				return
+			}
+		}
+	}
+	e.errors = append(e.errors, ge)
+}
+
+func asError(err error) *Error {
+	var ge *Error
+	if errors.As(err, &ge) {
+		return ge
+	}
+	return &Error{Message: err.Error(), err: err}
+}
+
+func appendPath(path []any, elem any) []any {
+	out := make([]any, len(path), len(path)+1)
+	copy(out, path)
+	return append(out, elem)
+}
+
+// defaultResolve reads a field off its parent: a map entry, or the struct
+// field whose name or json tag matches once case and underscores are
+// ignored.
+func defaultResolve(source any, name string) any {
+	if m, ok := source.(map[string]any); ok {
+		return m[name]
+	}
+	rv := reflect.ValueOf(source)
+	for rv.Kind() == reflect.Pointer || rv.Kind() == reflect.Interface {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+	if rv.Kind() != reflect.Struct {
+		return nil
+	}
+	idx, ok := structFields(rv.Type())[foldName(name)]
+	if !ok {
+		return nil
+	}
+	return rv.FieldByIndex(idx).Interface()
+}
+
+var fieldCache sync.Map // reflect.Type -> map[string][]int
+
+// structFields indexes a struct's exported fields, embedded ones included,
+// under their folded Go names and json tag names.
+func structFields(t reflect.Type) map[string][]int {
+	if cached, ok := fieldCache.Load(t); ok {
+		return cached.(map[string][]int)
+	}
+	out := make(map[string][]int)
+	for _, f := range reflect.VisibleFields(t) {
+		if !f.IsExported() || f.Anonymous {
+			continue
+		}
+		if tag, _, _ := strings.Cut(f.Tag.Get("json"), ","); tag != "" && tag != "-" {
+			out[foldName(tag)] = f.Index
+		}
+		if _, taken := out[foldName(f.Name)]; !taken {
+			out[foldName(f.Name)] = f.Index
+		}
+	}
+	fieldCache.Store(t, out)
+	return out
+}
+
+func foldName(s string) string {
+	return strings.ToLower(strings.ReplaceAll(s, "_", ""))
+}
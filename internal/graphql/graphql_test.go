@@ -0,0 +1,425 @@
+package graphql
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+type testNode struct {
+	Zone       int       `json:"zone"`
+	Net        int       `json:"net"`
+	Node       int       `json:"node"`
+	SystemName string    `json:"system_name"`
+	Updated    time.Time `json:"updated"`
+	Region     *int      `json:"region"`
+}
+
+// testSchema is a node lookup with a self-referencing "peers" field and a
+// field that always fails, enough to exercise execution end to end.
+func testSchema(t *testing.T, cfg SchemaConfig) *Schema {
+	t.Helper()
+	region := 50
+	nodes := []*testNode{
+		{Zone: 2, Net: 5020, Node: 1, SystemName: "One", Updated: time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC), Region: &region},
+		{Zone: 2, Net: 5020, Node: 2, SystemName: "Two"},
+	}
+
+	node := &Object{Name: "Node", Description: "A node.", Fields: []*Field{
+		{Name: "zone", Type: NonNullOf(Int)},
+		{Name: "net", Type: NonNullOf(Int)},
+		{Name: "node", Type: NonNullOf(Int)},
+		{Name: "systemName", Type: String},
+		{Name: "updated", Type: String},
+		{Name: "region", Type: Int},
+		{Name: "address", Type: NonNullOf(String), Derive: func(src any) any {
+			n := src.(*testNode)
+			return fmt.Sprintf("%d:%d/%d", n.Zone, n.Net, n.Node)
+		}},
+		{Name: "broken", Type: String, Resolve: func(ResolveParams) (any, error) {
+			return nil, errors.New("broken on purpose")
+		}},
+		{Name: "brokenNonNull", Type: NonNullOf(String), Resolve: func(ResolveParams) (any, error) {
+			return nil, nil
+		}},
+	}}
+	node.Fields = append(node.Fields, &Field{
+		Name: "peers",
+		Type: NonNullOf(ListOf(NonNullOf(node))),
+		Resolve: func(p ResolveParams) (any, error) {
+			self := p.Source.(*testNode)
+			var out []*testNode
+			for _, n := range nodes {
+				if n != self {
+					out = append(out, n)
+				}
+			}
+			return out, nil
+		},
+	})
+
+	cfg.Query = &Object{Name: "Query", Fields: []*Field{
+		{
+			Name: "node",
+			Type: node,
+			Args: []*Argument{
+				{Name: "net", Type: NonNullOf(Int)},
+				{Name: "node", Type: NonNullOf(Int)},
+				{Name: "zone", Type: Int, Default: 2},
+			},
+			Resolve: func(p ResolveParams) (any, error) {
+				zone, _ := p.Int("zone")
+				net, _ := p.Int("net")
+				num, _ := p.Int("node")
+				for _, n := range nodes {
+					if n.Zone == zone && n.Net == net && n.Node == num {
+						return n, nil
+					}
+				}
+				return nil, nil
+			},
+		},
+		{
+			Name: "nodes",
+			Type: ListOf(node),
+			Args: []*Argument{{Name: "nodes", Type: ListOf(NonNullOf(Int))}},
+			Resolve: func(p ResolveParams) (any, error) {
+				want, _ := p.Args["nodes"].([]any)
+				var out []*testNode
+				for _, n := range nodes {
+					for _, w := range want {
+						if w == n.Node {
+							out = append(out, n)
+						}
+					}
+				}
+				return out, nil
+			},
+		},
+		{Name: "echo", Type: String, Args: []*Argument{{Name: "text", Type: String}},
+			Resolve: func(p ResolveParams) (any, error) {
+				s, ok := p.String("text")
+				if !ok {
+					return "<unset>", nil
+				}
+				return s, nil
+			}},
+		{Name: "mustNode", Type: NonNullOf(node), Resolve: func(ResolveParams) (any, error) {
+			return nil, errors.New("no such node")
+		}},
+	}}
+	s, err := NewSchema(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func run(t *testing.T, s *Schema, query string, vars map[string]any) string {
+	t.Helper()
+	out, err := json.Marshal(s.Execute(context.Background(), Request{Query: query, Variables: vars}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestExecute(t *testing.T) {
+	s := testSchema(t, SchemaConfig{})
+	tests := []struct {
+		name  string
+		query string
+		vars  map[string]any
+		want  string
+	}{
+		{
+			"default resolution and field order",
+			`{ node(net: 5020, node: 1) { systemName zone node updated region } }`, nil,
+			`{"data":{"node":{"systemName":"One","zone":2,"node":1,"updated":"2026-01-02T03:04:05Z","region":50}}}`,
+		},
+		{
+			"zero time and nil pointer are null",
+			`{ node(net: 5020, node: 2) { updated region } }`, nil,
+			`{"data":{"node":{"updated":null,"region":null}}}`,
+		},
+		{
+			"missing object is null",
+			`{ node(net: 1, node: 1) { zone } }`, nil,
+			`{"data":{"node":null}}`,
+		},
+		{
+			"aliases and __typename",
+			`query Q { a: node(net: 5020, node: 1) { __typename n: node } b: node(net: 5020, node: 2) { n: node } }`, nil,
+			`{"data":{"a":{"__typename":"Node","n":1},"b":{"n":2}}}`,
+		},
+		{
+			"fragments merge by response key",
+			`{ node(net: 5020, node: 1) { zone ...F ... on Node { net } } } fragment F on Node { zone node }`, nil,
+			`{"data":{"node":{"zone":2,"node":1,"net":5020}}}`,
+		},
+		{
+			"nested lists",
+			`{ node(net: 5020, node: 1) { peers { node peers { node } } } }`, nil,
+			`{"data":{"node":{"peers":[{"node":2,"peers":[{"node":1}]}]}}}`,
+		},
+		{
+			"variables and list coercion",
+			`query($ns: [Int!], $one: Int!) { nodes(nodes: $ns) { node } single: nodes(nodes: $one) { node } }`,
+			map[string]any{"ns": []any{float64(2), float64(1)}, "one": float64(2)},
+			`{"data":{"nodes":[{"node":1},{"node":2}],"single":[{"node":2}]}}`,
+		},
+		{
+			"unset variable leaves the argument unset",
+			`query($t: String) { echo(text: $t) }`, nil,
+			`{"data":{"echo":"\u003cunset\u003e"}}`,
+		},
+		{
+			"explicit null is set",
+			`{ echo(text: null) }`, nil,
+			`{"data":{"echo":"\u003cunset\u003e"}}`,
+		},
+		{
+			"skip and include",
+			`query($yes: Boolean!) { node(net: 5020, node: 1) { zone @skip(if: $yes) net @include(if: $yes) node @include(if: false) } }`,
+			map[string]any{"yes": true},
+			`{"data":{"node":{"net":5020}}}`,
+		},
+		{
+			"resolver error nulls the field",
+			`{ node(net: 5020, node: 1) { node broken } }`, nil,
+			`{"errors":[{"message":"broken on purpose","locations":[{"line":1,"column":35}],"path":["node","broken"]}],"data":{"node":{"node":1,"broken":null}}}`,
+		},
+		{
+			"non-null error propagates to the nearest nullable parent",
+			`{ node(net: 5020, node: 1) { node brokenNonNull } }`, nil,
+			`{"errors":[{"message":"Cannot return null for non-nullable field brokenNonNull.","locations":[{"line":1,"column":35}],"path":["node","brokenNonNull"]}],"data":{"node":null}}`,
+		},
+		{
+			"non-null root error nulls data",
+			`{ mustNode { zone } }`, nil,
+			`{"errors":[{"message":"no such node","locations":[{"line":1,"column":3}],"path":["mustNode"]}],"data":null}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := run(t, s, tt.query, tt.vars); got != tt.want {
+				t.Errorf("got  %s\nwant %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequestErrors(t *testing.T) {
+	s := testSchema(t, SchemaConfig{MaxDepth: 3})
+	tests := []struct {
+		name string
+		req  Request
+		want string
+	}{
+		{"syntax", Request{Query: `{ node(net: 1 }`}, "Syntax Error"},
+		{"empty", Request{Query: `  `}, "no operation"},
+		{"unknown field", Request{Query: `{ nope }`}, `Cannot query field "nope" on type "Query".`},
+		{"unknown argument", Request{Query: `{ node(net: 1, node: 1, point: 0) { zone } }`}, `Unknown argument "point"`},
+		{"missing argument", Request{Query: `{ node(net: 1) { zone } }`}, `argument "node" of type "Int!" is required`},
+		{"wrong argument type", Request{Query: `{ node(net: "1", node: 1) { zone } }`}, `Int cannot represent "1"`},
+		{"leaf with selection", Request{Query: `{ node(net: 1, node: 1) { zone { x } } }`}, "must not have a selection"},
+		{"object without selection", Request{Query: `{ node(net: 1, node: 1) }`}, "must have a selection of subfields"},
+		{"unknown fragment", Request{Query: `{ node(net: 1, node: 1) { ...F } }`}, `Unknown fragment "F".`},
+		{"fragment cycle", Request{Query: `{ node(net: 1, node: 1) { ...F } } fragment F on Node { ...F }`}, "within itself"},
+		{"wrong type condition", Request{Query: `{ node(net: 1, node: 1) { ... on Query { echo } } }`}, "can never be of type"},
+		{"too deep", Request{Query: `{ node(net: 1, node: 1) { peers { peers { node } } } }`}, "nested too deeply"},
+		{"mutation", Request{Query: `mutation { echo }`}, "Only queries are supported"},
+		{"unknown directive", Request{Query: `{ echo @live }`}, `Unknown directive "@live".`},
+		{"missing variable", Request{Query: `query($n: Int!) { echo }`}, `Variable "$n" of required type "Int!" was not provided.`},
+		{"bad variable", Request{Query: `query($n: Int) { echo }`, Variables: map[string]any{"n": 1.5}}, `Variable "$n" got invalid value`},
+		{"unknown variable type", Request{Query: `query($n: Node) { echo }`}, `Unknown input type "Node".`},
+		{"ambiguous operation", Request{Query: `query A { echo } query B { echo }`}, "Must provide operation name"},
+		{"unknown operation", Request{Query: `query A { echo }`, OperationName: "B"}, `Unknown operation named "B".`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := s.Execute(context.Background(), tt.req)
+			if resp.Executed() || len(resp.Errors) == 0 {
+				t.Fatalf("request ran: %+v", resp)
+			}
+			if !strings.Contains(resp.Errors[0].Message, tt.want) {
+				t.Errorf("error %q, want it to contain %q", resp.Errors[0].Message, tt.want)
+			}
+			out, _ := json.Marshal(resp)
+			if strings.Contains(string(out), `"data"`) {
+				t.Errorf("request error response has data: %s", out)
+			}
+		})
+	}
+}
+
+func TestOperationName(t *testing.T) {
+	s := testSchema(t, SchemaConfig{})
+	resp := s.Execute(context.Background(), Request{
+		Query:         `query A { a: echo(text: "a") } query B { b: echo(text: "b") }`,
+		OperationName: "B",
+	})
+	if out, _ := json.Marshal(resp); string(out) != `{"data":{"b":"b"}}` {
+		t.Errorf("got %s", out)
+	}
+}
+
+func TestMaxResolves(t *testing.T) {
+	s := testSchema(t, SchemaConfig{MaxResolves: 3})
+	// node, its peers, and the one peer's peers make three lookups; the
+	// fourth, the next level down, is refused.
+	// Derived fields are free.
+	resp := s.Execute(context.Background(), Request{Query: `{ node(net: 5020, node: 1) { address peers { address peers { node address } } } x: echo y: echo }`})
+	if len(resp.Errors) != 1 || !errors.Is(resp.Errors[0], ErrTooExpensive) {
+		t.Fatalf("errors %v, want one ErrTooExpensive", resp.Errors)
+	}
+	out, _ := json.Marshal(resp.Data)
+	if string(out) != `{"node":{"address":"2:5020/1","peers":[{"address":"2:5020/2","peers":[{"node":1,"address":"2:5020/1"}]}]},"x":null,"y":null}` {
+		t.Errorf("data %s", out)
+	}
+}
+
+func TestCanceledContext(t *testing.T) {
+	s := testSchema(t, SchemaConfig{})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	resp := s.Execute(ctx, Request{Query: `{ a: echo b: echo }`})
+	if len(resp.Errors) != 1 || !errors.Is(resp.Errors[0], context.Canceled) {
+		t.Fatalf("errors %v, want one context.Canceled", resp.Errors)
+	}
+}
+
+func TestFragmentChains(t *testing.T) {
+	s := testSchema(t, SchemaConfig{})
+	// Each fragment spreads the next twice, flat and one level down, so the
+	// document stands for 2^40 selections; expanding it that way never
+	// finishes.
+	for _, nested := range []bool{false, true} {
+		var q strings.Builder
+		q.WriteString(`{ node(net: 5020, node: 1) { ...F0 } }`)
+		const chain = 40
+		for i := 0; i < chain; i++ {
+			if nested {
+				fmt.Fprintf(&q, " fragment F%d on Node { zone peers { ...F%d } peers { ...F%d } }", i, i+1, i+1)
+			} else {
+				fmt.Fprintf(&q, " fragment F%d on Node { zone ...F%d ...F%d }", i, i+1, i+1)
+			}
+		}
+		fmt.Fprintf(&q, " fragment F%d on Node { node }", chain)
+		if _, errs := s.Prepare(context.Background(), Request{Query: q.String()}); errs != nil {
+			t.Errorf("nested %v: %v", nested, errs)
+		}
+	}
+
+	wide := "{ " + strings.Repeat("echo ", maxSelections+1) + "}"
+	resp := s.Execute(context.Background(), Request{Query: wide})
+	if resp.Executed() || len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "too large") {
+		t.Errorf("%d selections: errors %v, want one saying the query is too large", maxSelections+1, resp.Errors)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, errs := s.Prepare(ctx, Request{Query: `{ node(net: 1, node: 1) { ...F } } fragment F on Node { zone }`})
+	if len(errs) != 1 || !errors.Is(errs[0], context.Canceled) {
+		t.Errorf("canceled prepare: errors %v, want one context.Canceled", errs)
+	}
+}
+
+func TestSelects(t *testing.T) {
+	s := testSchema(t, SchemaConfig{})
+	isPeers := func(f *Field) bool { return f.Name == "peers" }
+	tests := []struct {
+		query string
+		want  bool
+	}{
+		{`{ node(net: 1, node: 1) { zone } }`, false},
+		{`{ node(net: 1, node: 1) { ...F } } fragment F on Node { peers { zone } }`, true},
+		// Selected but skipped still counts: the policy is picked before
+		// variables decide what runs.
+		{`{ node(net: 1, node: 1) { peers @skip(if: true) { zone } } }`, true},
+	}
+	for _, tt := range tests {
+		p, errs := s.Prepare(context.Background(), Request{Query: tt.query})
+		if errs != nil {
+			t.Fatalf("%s: %v", tt.query, errs)
+		}
+		if got := p.Selects(isPeers); got != tt.want {
+			t.Errorf("%s: Selects = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestParseValues(t *testing.T) {
+	doc, err := parse(`query($v: [Int] = [1, 2]) { f(a: -1.5e3, b: "x\u00e9\n", c: """ raw "q" """, d: ENUM, e: {k: $v}, f: true, g: null) }`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	op := doc.operations[0]
+	if got := fmt.Sprint(op.vars[0].defValue); got != "[1 2]" {
+		t.Errorf("default %s", got)
+	}
+	if op.vars[0].typ.String() != "[Int]" {
+		t.Errorf("variable type %s", op.vars[0].typ)
+	}
+	args := op.selections[0].(*field).args
+	want := []any{-1500.0, "xé\n", ` raw "q" `, enumValue("ENUM"), nil, true, nil}
+	for i, w := range want {
+		if i == 4 {
+			obj, ok := args[i].value.(objectValue)
+			if !ok || obj[0].name != "k" || obj[0].value != variable("v") {
+				t.Errorf("object argument %#v", args[i].value)
+			}
+			continue
+		}
+		if args[i].value != w {
+			t.Errorf("argument %s = %#v, want %#v", args[i].name, args[i].value, w)
+		}
+	}
+}
+
+func TestNewSchemaRejects(t *testing.T) {
+	obj := &Object{Name: "O", Fields: []*Field{{Name: "x", Type: Int}}}
+	tests := []struct {
+		name string
+		cfg  SchemaConfig
+	}{
+		{"no query", SchemaConfig{}},
+		{"duplicate field", SchemaConfig{Query: &Object{Name: "Q", Fields: []*Field{{Name: "a", Type: Int}, {Name: "a", Type: Int}}}}},
+		{"object argument", SchemaConfig{Query: &Object{Name: "Q", Fields: []*Field{{Name: "a", Type: Int, Args: []*Argument{{Name: "o", Type: obj}}}}}}},
+		{"two types one name", SchemaConfig{Query: &Object{Name: "Q", Fields: []*Field{
+			{Name: "a", Type: obj},
+			{Name: "b", Type: &Object{Name: "O", Fields: []*Field{{Name: "y", Type: Int}}}},
+		}}}},
+		{"scalar without Serialize", SchemaConfig{Query: &Object{Name: "Q", Fields: []*Field{{Name: "a", Type: &Scalar{Name: "S"}}}}}},
+		{"Resolve and Derive", SchemaConfig{Query: &Object{Name: "Q", Fields: []*Field{{Name: "a", Type: Int,
+			Resolve: func(ResolveParams) (any, error) { return 1, nil },
+			Derive:  func(any) any { return 1 },
+		}}}}},
+	}
+	for _, tt := range tests {
+		if _, err := NewSchema(tt.cfg); err == nil {
+			t.Errorf("%s: NewSchema accepted it", tt.name)
+		}
+	}
+}
+
+func TestSDL(t *testing.T) {
+	s := testSchema(t, SchemaConfig{})
+	sdl := s.SDL()
+	for _, want := range []string{
+		"type Query {\n  node(net: Int!, node: Int!, zone: Int = 2): Node\n",
+		"\"A node.\"\ntype Node {\n",
+		"  peers: [Node!]!\n",
+	} {
+		if !strings.Contains(sdl, want) {
+			t.Errorf("SDL lacks %q:\n%s", want, sdl)
+		}
+	}
+	if !strings.HasPrefix(sdl, "type Query {") {
+		t.Errorf("SDL does not start with the query type:\n%s", sdl)
+	}
+}
@@ -0,0 +1,499 @@
+// Package graphql is a small GraphQL executor: enough of the language to
+// serve read-only queries over Go values, and nothing that needs a code
+// generator or a dependency.
+//
+// A schema is built by hand from Object, Scalar, List and NonNull values.
+// Fields either carry a Resolve function or are read straight off the parent
+// value: a map key, or a struct field whose name or json tag matches the
+// GraphQL field name once case and underscores are ignored, so
+// "binkpSuccessRate" finds `json:"binkp_success_rate"`.
+//
+// Supported: queries with variables, aliases, arguments, named and inline
+// fragments, @skip and @include, and __typename. Not supported: mutations,
+// subscriptions, interfaces, unions, enums, input objects and introspection;
+// the schema is published as SDL text instead (Schema.SDL).
+package graphql
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Type is a GraphQL type: *Scalar, *Object, *List or *NonNull.
+type Type interface {
+	String() string
+	isType()
+}
+
+// Scalar is a leaf type.
+type Scalar struct {
+	Name        string
+	Description string
+	// Serialize turns a resolved Go value into its JSON form.
+	Serialize func(v any) (any, error)
+	// ParseValue coerces an argument or variable: int64, float64, string or
+	// bool from a literal, or whatever encoding/json decoded.
+	ParseValue func(v any) (any, error)
+}
+
+// Object is an output type with fields. Fields may be appended after the
+// object is created, which is how two types refer to each other.
+type Object struct {
+	Name        string
+	Description string
+	Fields      []*Field
+}
+
+// Field is one field of an Object.
+type Field struct {
+	Name        string
+	Description string
+	Type        Type
+	Args        []*Argument
+	// Resolve computes the field, typically with a storage read. Nil reads
+	// it off the parent value.
+	Resolve ResolveFunc
+	// Derive computes the field from the parent value alone, such as an
+	// address formatted from its parts. Unlike Resolve it is not counted
+	// against SchemaConfig.MaxResolves. At most one of the two is set.
+	Derive func(source any) any
+}
+
+// Argument is one argument of a Field.
+type Argument struct {
+	Name        string
+	Description string
+	Type        Type // a Scalar, or a List or NonNull of one
+	Default     any  // used when the argument is absent; nil for none
+}
+
+// List is a list of another type.
+type List struct{ OfType Type }
+
+// NonNull is another type that may not be null.
+type NonNull struct{ OfType Type }
+
+// ListOf returns a list of t.
+func ListOf(t Type) *List { return &List{OfType: t} }
+
+// NonNullOf returns t made non-null.
+func NonNullOf(t Type) *NonNull { return &NonNull{OfType: t} }
+
+func (s *Scalar) String() string  { return s.Name }
+func (o *Object) String() string  { return o.Name }
+func (l *List) String() string    { return "[" + l.OfType.String() + "]" }
+func (n *NonNull) String() string { return n.OfType.String() + "!" }
+
+func (*Scalar) isType()  {}
+func (*Object) isType()  {}
+func (*List) isType()    {}
+func (*NonNull) isType() {}
+
+// field looks a field up by name.
+func (o *Object) field(name string) *Field {
+	for _, f := range o.Fields {
+		if f.Name == name {
+			return f
+		}
+	}
+	return nil
+}
+
+// ResolveFunc computes a field's value.
+type ResolveFunc func(p ResolveParams) (any, error)
+
+// ResolveParams is what a resolver is given.
+type ResolveParams struct {
+	Context context.Context
+	// Source is the parent value: nil for fields of the query root.
+	Source any
+	// Args holds every argument that was given or has a default, coerced to
+	// int, float64, string, bool or []any. An argument given as null is
+	// present with a nil value.
+	Args map[string]any
+}
+
+// Int returns an Int argument and whether it is set.
+func (p ResolveParams) Int(name string) (int, bool) {
+	v, ok := p.Args[name].(int)
+	return v, ok
+}
+
+// String returns a String argument and whether it is set.
+func (p ResolveParams) String(name string) (string, bool) {
+	v, ok := p.Args[name].(string)
+	return v, ok
+}
+
+// Bool returns a Boolean argument and whether it is set.
+func (p ResolveParams) Bool(name string) (bool, bool) {
+	v, ok := p.Args[name].(bool)
+	return v, ok
+}
+
+// SchemaConfig describes a schema.
+type SchemaConfig struct {
+	Query *Object
+
+	// MaxDepth rejects queries nested deeper than this many fields.
+	// Zero means no limit.
+	MaxDepth int
+	// MaxResolves stops a query once it has called this many Resolve
+	// functions, which is what bounds the number of storage round trips a
+	// single request can make. Zero means no limit.
+	MaxResolves int
+}
+
+// Schema is a validated set of types rooted at a query type.
+type Schema struct {
+	cfg     SchemaConfig
+	objects map[string]*Object
+	scalars map[string]*Scalar
+}
+
+// NewSchema checks a schema for consistency.
+func NewSchema(cfg SchemaConfig) (*Schema, error) {
+	if cfg.Query == nil {
+		return nil, fmt.Errorf("graphql: schema has no query type")
+	}
+	s := &Schema{
+		cfg:     cfg,
+		objects: make(map[string]*Object),
+		scalars: make(map[string]*Scalar),
+	}
+	for _, sc := range []*Scalar{Int, Float, String, Boolean, ID} {
+		s.scalars[sc.Name] = sc
+	}
+	if err := s.collect(cfg.Query); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// collect registers t and everything reachable from it.
+func (s *Schema) collect(t Type) error {
+	switch t := t.(type) {
+	case *List:
+		return s.collect(t.OfType)
+	case *NonNull:
+		if _, nested := t.OfType.(*NonNull); nested {
+			return fmt.Errorf("graphql: %s is non-null twice", t)
+		}
+		return s.collect(t.OfType)
+	case *Scalar:
+		if prev, ok := s.scalars[t.Name]; ok && prev != t {
+			return fmt.Errorf("graphql: two types named %s", t.Name)
+		}
+		if t.Serialize == nil {
+			return fmt.Errorf("graphql: scalar %s has no Serialize", t.Name)
+		}
+		s.scalars[t.Name] = t
+		return nil
+	case *Object:
+		if prev, ok := s.objects[t.Name]; ok {
+			if prev != t {
+				return fmt.Errorf("graphql: two types named %s", t.Name)
+			}
+			return nil
+		}
+		if _, ok := s.scalars[t.Name]; ok {
+			return fmt.Errorf("graphql: two types named %s", t.Name)
+		}
+		if len(t.Fields) == 0 {
+			return fmt.Errorf("graphql: type %s has no fields", t.Name)
+		}
+		s.objects[t.Name] = t
+		seen := make(map[string]bool)
+		for _, f := range t.Fields {
+			if seen[f.Name] {
+				return fmt.Errorf("graphql: %s.%s is defined twice", t.Name, f.Name)
+			}
+			seen[f.Name] = true
+			if f.Type == nil {
+				return fmt.Errorf("graphql: %s.%s has no type", t.Name, f.Name)
+			}
+			if f.Resolve != nil && f.Derive != nil {
+				return fmt.Errorf("graphql: %s.%s has both Resolve and Derive", t.Name, f.Name)
+			}
+			for _, a := range f.Args {
+				if !isInputType(a.Type) {
+					return fmt.Errorf("graphql: argument %s of %s.%s is not an input type", a.Name, t.Name, f.Name)
+				}
+				if err := s.collect(a.Type); err != nil {
+					return err
+				}
+			}
+			if err := s.collect(f.Type); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return fmt.Errorf("graphql: unknown type %T", t)
+}
+
+func isInputType(t Type) bool {
+	switch t := t.(type) {
+	case *Scalar:
+		return true
+	case *List:
+		return isInputType(t.OfType)
+	case *NonNull:
+		return isInputType(t.OfType)
+	}
+	return false
+}
+
+// namedType strips List and NonNull wrappers.
+func namedType(t Type) Type {
+	for {
+		switch w := t.(type) {
+		case *List:
+			t = w.OfType
+		case *NonNull:
+			t = w.OfType
+		default:
+			return t
+		}
+	}
+}
+
+// SDL renders the schema in the GraphQL schema definition language, for
+// clients to read in place of introspection.
+func (s *Schema) SDL() string {
+	var b strings.Builder
+
+	names := make([]string, 0, len(s.objects))
+	for n := range s.objects {
+		if n != s.cfg.Query.Name {
+			names = append(names, n)
+		}
+	}
+	sort.Strings(names)
+	names = append([]string{s.cfg.Query.Name}, names...)
+
+	for i, n := range names {
+		if i > 0 {
+			b.WriteString("\n")
+		}
+		o := s.objects[n]
+		writeDescription(&b, "", o.Description)
+		fmt.Fprintf(&b, "type %s {\n", o.Name)
+		for _, f := range o.Fields {
+			writeDescription(&b, "  ", f.Description)
+			b.WriteString("  " + f.Name)
+			if len(f.Args) > 0 {
+				parts := make([]string, 0, len(f.Args))
+				for _, a := range f.Args {
+					p := a.Name + ": " + a.Type.String()
+					if a.Default != nil {
+						p += " = " + formatDefault(a.Default)
+					}
+					parts = append(parts, p)
+				}
+				b.WriteString("(" + strings.Join(parts, ", ") + ")")
+			}
+			b.WriteString(": " + f.Type.String() + "\n")
+		}
+		b.WriteString("}\n")
+	}
+
+	var custom []string
+	for n := range s.scalars {
+		switch n {
+		case "Int", "Float", "String", "Boolean", "ID":
+		default:
+			custom = append(custom, n)
+		}
+	}
+	sort.Strings(custom)
+	for _, n := range custom {
+		b.WriteString("\n")
+		writeDescription(&b, "", s.scalars[n].Description)
+		fmt.Fprintf(&b, "scalar %s\n", n)
+	}
+	return b.String()
+}
+
+func writeDescription(b *strings.Builder, indent, desc string) {
+	if desc == "" {
+		return
+	}
+	if !strings.Contains(desc, "\n") {
+		fmt.Fprintf(b, "%s%s\n", indent, strconv.Quote(desc))
+		return
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+	for _, line := range strings.Split(desc, "\n") {
+		fmt.Fprintf(b, "%s%s\n", indent, line)
+	}
+	fmt.Fprintf(b, "%s\"\"\"\n", indent)
+}
+
+func formatDefault(v any) string {
+	switch v := v.(type) {
+	case string:
+		return strconv.Quote(v)
+	case []any:
+		parts := make([]string, len(v))
+		for i, e := range v {
+			parts[i] = formatDefault(e)
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	}
+	return fmt.Sprint(v)
+}
+
+// Built-in scalars.
+var (
+	Int = &Scalar{
+		Name:        "Int",
+		Description: "A signed 32-bit integer.",
+		Serialize:   serializeInt,
+		ParseValue:  parseInt,
+	}
+	Float = &Scalar{
+		Name:        "Float",
+		Description: "A double-precision number.",
+		Serialize:   serializeFloat,
+		ParseValue:  serializeFloat,
+	}
+	String = &Scalar{
+		Name:        "String",
+		Description: "UTF-8 text. Times are RFC 3339.",
+		Serialize:   serializeString,
+		ParseValue:  parseString,
+	}
+	Boolean = &Scalar{
+		Name:        "Boolean",
+		Description: "true or false.",
+		Serialize:   parseBool,
+		ParseValue:  parseBool,
+	}
+	ID = &Scalar{
+		Name:        "ID",
+		Description: "An opaque identifier, serialized as a string.",
+		Serialize:   serializeString,
+		ParseValue:  parseID,
+	}
+)
+
+func serializeInt(v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	var n int64
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n = rv.Int()
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if rv.Uint() > math.MaxInt32 {
+			return nil, fmt.Errorf("Int cannot represent %v", v)
+		}
+		n = int64(rv.Uint())
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if f != math.Trunc(f) {
+			return nil, fmt.Errorf("Int cannot represent non-integer %v", v)
+		}
+		n = int64(f)
+	case reflect.Bool:
+		if rv.Bool() {
+			return 1, nil
+		}
+		return 0, nil
+	default:
+		return nil, fmt.Errorf("Int cannot represent %T", v)
+	}
+	if n < math.MinInt32 || n > math.MaxInt32 {
+		return nil, fmt.Errorf("Int cannot represent %d", n)
+	}
+	return int(n), nil
+}
+
+func parseInt(v any) (any, error) {
+	switch v.(type) {
+	case bool, string:
+		return nil, fmt.Errorf("Int cannot represent %v", formatDefault(v))
+	}
+	return serializeInt(v)
+}
+
+func serializeFloat(v any) (any, error) {
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(rv.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(rv.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		f := rv.Float()
+		if math.IsNaN(f) || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("Float cannot represent %v", f)
+		}
+		return f, nil
+	}
+	return nil, fmt.Errorf("Float cannot represent %T", v)
+}
+
+func serializeString(v any) (any, error) {
+	switch v := v.(type) {
+	case string:
+		return v, nil
+	case time.Time:
+		if v.IsZero() {
+			return nil, nil
+		}
+		return v.UTC().Format(time.RFC3339), nil
+	case fmt.Stringer:
+		return v.String(), nil
+	case []byte:
+		return string(v), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String:
+		return rv.String(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(rv.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(rv.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(rv.Float(), 'g', -1, 64), nil
+	}
+	return nil, fmt.Errorf("String cannot represent %T", v)
+}
+
+func parseString(v any) (any, error) {
+	if s, ok := v.(string); ok {
+		return s, nil
+	}
+	return nil, fmt.Errorf("String cannot represent %v", formatDefault(v))
+}
+
+func parseID(v any) (any, error) {
+	switch v.(type) {
+	case string:
+		return v, nil
+	case int, int64:
+		return fmt.Sprint(v), nil
+	case float64:
+		if n, err := parseInt(v); err == nil {
+			return fmt.Sprint(n), nil
+		}
+	}
+	return nil, fmt.Errorf("ID cannot represent %v", formatDefault(v))
+}
+
+func parseBool(v any) (any, error) {
+	if b, ok := v.(bool); ok {
+		return b, nil
+	}
+	return nil, fmt.Errorf("Boolean cannot represent %v", formatDefault(v))
+}
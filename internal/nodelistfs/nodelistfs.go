@@ -475,3 +475,26 @@ func FindLatest(network string) (*NodelistFile, error) {
 
 	return nil, fmt.Errorf("no nodelist files found")
 }
+
+// FindByDate finds a network's archived nodelist for one date. The date is the
+// one the filename carries (year directory plus day number), which is the
+// date the nodelist header states.
+func FindByDate(network string, date time.Time) (*NodelistFile, error) {
+	years, err := ScanNetwork(network)
+	if err != nil {
+		return nil, err
+	}
+
+	year := strconv.Itoa(date.Year())
+	for _, y := range years {
+		if y.Year != year {
+			continue
+		}
+		for i := range y.Files {
+			if y.Files[i].DayNumber == date.YearDay() {
+				return &y.Files[i], nil
+			}
+		}
+	}
+	return nil, fmt.Errorf("no %s nodelist archived for %s", NormalizeNetwork(network), date.Format("2006-01-02"))
+}
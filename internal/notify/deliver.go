@@ -0,0 +1,353 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/nodelistdb/internal/config"
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/logging"
+)
+
+// Delivery statuses, as recorded in notification_deliveries.
+const (
+	StatusDelivered = "delivered"
+	StatusRetrying  = "retrying"
+	StatusFailed    = "failed"
+)
+
+// Webhook request headers. The signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>" under the subscription's secret, prefixed "sha256=";
+// the timestamp is in it so a captured request cannot be replayed later with
+// a fresh header.
+const (
+	HeaderDelivery  = "X-Nodelist-Delivery"
+	HeaderTimestamp = "X-Nodelist-Timestamp"
+	HeaderSignature = "X-Nodelist-Signature"
+)
+
+// Payload is the body of one webhook delivery: every change one
+// subscription asked about, from one nodelist.
+type Payload struct {
+	DeliveryID     string  `json:"delivery_id"`
+	SubscriptionID string  `json:"subscription_id"`
+	Domain         string  `json:"domain"`
+	NodelistDate   string  `json:"nodelist_date"`
+	PreviousDate   string  `json:"previous_date"`
+	Events         []Event `json:"events"`
+}
+
+// DeliveryLog is where every delivery attempt is recorded.
+type DeliveryLog interface {
+	InsertNotificationDelivery(ctx context.Context, d database.NotificationDelivery) error
+}
+
+// Outcome is how one subscription's batch ended, per channel.
+type Outcome struct {
+	SubscriptionID string
+	Channel        string
+	Events         int
+	Attempts       int
+	Err            error // nil when delivered
+}
+
+// Dispatcher sends batches to subscribers and records every attempt.
+type Dispatcher struct {
+	secret      string
+	maxAttempts int
+	retryDelay  time.Duration
+	smtp        config.SMTPConfig
+	client      *http.Client
+	log         DeliveryLog
+
+	// Swapped by tests.
+	sleep    func(ctx context.Context, d time.Duration) error
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+	now      func() time.Time
+}
+
+// NewDispatcher builds a dispatcher from a validated notifications config.
+func NewDispatcher(cfg config.NotificationsConfig, log DeliveryLog) (*Dispatcher, error) {
+	retryDelay, timeout, err := cfg.Durations()
+	if err != nil {
+		return nil, err
+	}
+	return &Dispatcher{
+		secret:      cfg.SigningSecret,
+		maxAttempts: cfg.MaxAttempts,
+		retryDelay:  retryDelay,
+		smtp:        cfg.SMTP,
+		client:      &http.Client{Timeout: timeout},
+		log:         log,
+		sleep:       sleepContext,
+		sendMail:    smtp.SendMail,
+		now:         time.Now,
+	}, nil
+}
+
+// Notify sends each subscription the events of one nodelist it asked about,
+// all subscriptions at once, and returns once every batch is delivered or out
+// of attempts. Subscriptions with nothing to hear get nothing - not even an
+// empty batch.
+func (d *Dispatcher) Notify(ctx context.Context, subs []Subscription, domain string, date, previous time.Time, events []Event) []Outcome {
+	var (
+		mu       sync.Mutex
+		outcomes []Outcome
+		wg       sync.WaitGroup
+	)
+	for i := range subs {
+		sub := &subs[i]
+		var matched []Event
+		for _, ev := range events {
+			if sub.Wants(domain, ev) {
+				matched = append(matched, ev)
+			}
+		}
+		if len(matched) == 0 {
+			continue
+		}
+
+		batch := Payload{
+			DeliveryID:     uuid.NewString(),
+			SubscriptionID: sub.ID,
+			Domain:         domain,
+			NodelistDate:   date.Format("2006-01-02"),
+			PreviousDate:   previous.Format("2006-01-02"),
+			Events:         matched,
+		}
+		for _, ch := range sub.channels() {
+			wg.Add(1)
+			go func(ch channel) {
+				defer wg.Done()
+				out := d.deliver(ctx, sub, ch, batch, date)
+				mu.Lock()
+				outcomes = append(outcomes, out)
+				mu.Unlock()
+			}(ch)
+		}
+	}
+	wg.Wait()
+	return outcomes
+}
+
+type channel struct {
+	name   string // webhook or email
+	target string
+}
+
+func (s *Subscription) channels() []channel {
+	var chs []channel
+	if s.Webhook != "" {
+		chs = append(chs, channel{"webhook", s.Webhook})
+	}
+	if s.Email != "" {
+		chs = append(chs, channel{"email", s.Email})
+	}
+	return chs
+}
+
+// permanentError is a failure retrying cannot fix: a 4xx from the webhook, a
+// 5xx reply from the mail relay.
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string { return e.err.Error() }
+func (e permanentError) Unwrap() error { return e.err }
+
+// deliver runs one batch on one channel through its attempts.
+func (d *Dispatcher) deliver(ctx context.Context, sub *Subscription, ch channel, batch Payload, date time.Time) Outcome {
+	out := Outcome{SubscriptionID: sub.ID, Channel: ch.name, Events: len(batch.Events)}
+	delay := d.retryDelay
+
+	for attempt := 1; attempt <= d.maxAttempts; attempt++ {
+		out.Attempts = attempt
+		start := d.now()
+		var httpStatus int
+		var err error
+		if ch.name == "webhook" {
+			httpStatus, err = d.postWebhook(ctx, sub, batch)
+		} else {
+			err = d.sendEmail(sub, batch)
+		}
+
+		var permanent permanentError
+		last := err == nil || errors.As(err, &permanent) || attempt == d.maxAttempts || ctx.Err() != nil
+		status := StatusDelivered
+		switch {
+		case err != nil && last:
+			status = StatusFailed
+		case err != nil:
+			status = StatusRetrying
+		}
+		d.record(ctx, database.NotificationDelivery{
+			DeliveryID:     batch.DeliveryID,
+			SubscriptionID: sub.ID,
+			Channel:        ch.name,
+			Target:         ch.target,
+			Domain:         batch.Domain,
+			NodelistDate:   date,
+			EventCount:     len(batch.Events),
+			Attempt:        attempt,
+			Status:         status,
+			HTTPStatus:     httpStatus,
+			Error:          errorText(err),
+			Duration:       d.now().Sub(start),
+			AttemptedAt:    start,
+		})
+
+		out.Err = err
+		if last {
+			return out
+		}
+		if err := d.sleep(ctx, delay); err != nil {
+			return out
+		}
+		delay *= 2
+	}
+	return out
+}
+
+// record writes one attempt to the delivery log. A log that cannot be
+// written must not stop the delivery it describes, so the failure is only
+// reported.
+func (d *Dispatcher) record(ctx context.Context, row database.NotificationDelivery) {
+	if d.log == nil {
+		return
+	}
+	if err := d.log.InsertNotificationDelivery(ctx, row); err != nil {
+		logging.Warn("Failed to record notification delivery",
+			"subscription", row.SubscriptionID, "delivery", row.DeliveryID, "error", err)
+	}
+}
+
+func errorText(err error) string {
+	if err == nil {
+		return ""
+	}
+	return err.Error()
+}
+
+// Sign returns the signature header value for a webhook body.
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// postWebhook makes one webhook attempt. Any 2xx is delivered; 408, 429 and
+// 5xx are worth retrying, other statuses are not.
+func (d *Dispatcher) postWebhook(ctx context.Context, sub *Subscription, batch Payload) (int, error) {
+	body, err := json.Marshal(batch)
+	if err != nil {
+		return 0, permanentError{fmt.Errorf("failed to encode payload: %w", err)}
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.Webhook, bytes.NewReader(body))
+	if err != nil {
+		return 0, permanentError{err}
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nodelistdb-notify")
+	req.Header.Set(HeaderDelivery, batch.DeliveryID)
+
+	secret := sub.Secret
+	if secret == "" {
+		secret = d.secret
+	}
+	if secret != "" {
+		ts := d.now().Unix()
+		req.Header.Set(HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(HeaderSignature, Sign(secret, ts, body))
+	}
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	switch code := resp.StatusCode; {
+	case code >= 200 && code < 300:
+		return code, nil
+	case code == http.StatusRequestTimeout || code == http.StatusTooManyRequests || code >= 500:
+		return code, fmt.Errorf("webhook answered %s", resp.Status)
+	default:
+		return code, permanentError{fmt.Errorf("webhook answered %s", resp.Status)}
+	}
+}
+
+// sendEmail makes one mail attempt through the configured relay.
+func (d *Dispatcher) sendEmail(sub *Subscription, batch Payload) error {
+	if d.smtp.Host == "" {
+		return permanentError{fmt.Errorf("no notifications.smtp relay is configured")}
+	}
+	var auth smtp.Auth
+	if d.smtp.Username != "" {
+		auth = smtp.PlainAuth("", d.smtp.Username, d.smtp.Password, d.smtp.Host)
+	}
+	addr := net.JoinHostPort(d.smtp.Host, strconv.Itoa(d.smtp.Port))
+	err := d.sendMail(addr, auth, d.smtp.From, []string{sub.Email}, d.mailMessage(sub, batch))
+
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return permanentError{err}
+	}
+	return err
+}
+
+// mailMessage renders a batch as a plain-text message, one line per event.
+func (d *Dispatcher) mailMessage(sub *Subscription, batch Payload) []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", d.smtp.From)
+	fmt.Fprintf(&b, "To: %s\r\n", sub.Email)
+	fmt.Fprintf(&b, "Subject: [nodelistdb] %d node change%s in %s, nodelist of %s\r\n",
+		len(batch.Events), plural(len(batch.Events)), batch.Domain, batch.NodelistDate)
+	fmt.Fprintf(&b, "Date: %s\r\n", d.now().Format(time.RFC1123Z))
+	fmt.Fprintf(&b, "%s: %s\r\n", HeaderDelivery, batch.DeliveryID)
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+
+	fmt.Fprintf(&b, "Changes in the %s nodelist of %s since %s\r\n", batch.Domain, batch.NodelistDate, batch.PreviousDate)
+	fmt.Fprintf(&b, "for subscription %s:\r\n\r\n", sub.ID)
+	for _, ev := range batch.Events {
+		b.WriteString(ev.Summary())
+		b.WriteString("\r\n")
+	}
+	return []byte(b.String())
+}
+
+func plural(n int) string {
+	if n == 1 {
+		return ""
+	}
+	return "s"
+}
+
+func sleepContext(ctx context.Context, d time.Duration) error {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
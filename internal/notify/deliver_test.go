@@ -0,0 +1,151 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"net/textproto"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/config"
+	"github.com/nodelistdb/internal/database"
+)
+
+type memoryLog struct {
+	mu   sync.Mutex
+	rows []database.NotificationDelivery
+}
+
+func (l *memoryLog) InsertNotificationDelivery(ctx context.Context, d database.NotificationDelivery) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rows = append(l.rows, d)
+	return nil
+}
+
+func testDispatcher(t *testing.T, smtpCfg config.SMTPConfig) (*Dispatcher, *memoryLog) {
+	t.Helper()
+	log := &memoryLog{}
+	d, err := NewDispatcher(config.NotificationsConfig{
+		SigningSecret: "s3cret", MaxAttempts: 3, RetryDelay: "1s", Timeout: "5s", SMTP: smtpCfg,
+	}, log)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d.sleep = func(context.Context, time.Duration) error { return nil }
+	return d, log
+}
+
+var (
+	testDate     = time.Date(2026, 10, 16, 0, 0, 0, 0, time.UTC)
+	testPrevious = time.Date(2026, 10, 9, 0, 0, 0, 0, time.UTC)
+	testEvents   = []Event{
+		{Type: EventAdded, Address: "2:5020/4", Zone: 2, Net: 5020, Node: 4},
+		{Type: EventRemoved, Address: "2:5030/1", Zone: 2, Net: 5030, Node: 1},
+	}
+)
+
+func TestWebhookIsSignedAndRetried(t *testing.T) {
+	var calls int
+	var got Payload
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		if calls == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(HeaderTimestamp), 10, 64)
+		if r.Header.Get(HeaderSignature) != Sign("s3cret", ts, body) {
+			t.Errorf("signature %q does not verify", r.Header.Get(HeaderSignature))
+		}
+		if err := json.Unmarshal(body, &got); err != nil {
+			t.Errorf("payload: %v", err)
+		}
+	}))
+	defer srv.Close()
+
+	d, log := testDispatcher(t, config.SMTPConfig{})
+	subs := []Subscription{{ID: "net5020", Domain: "fidonet", Webhook: srv.URL, Filter: Filter{Net: "2:5020"}}}
+	if err := subs[0].Validate([]string{"fidonet"}); err != nil {
+		t.Fatal(err)
+	}
+
+	outcomes := d.Notify(context.Background(), subs, "fidonet", testDate, testPrevious, testEvents)
+	if len(outcomes) != 1 || outcomes[0].Err != nil || outcomes[0].Attempts != 2 {
+		t.Fatalf("outcomes = %+v", outcomes)
+	}
+	if len(got.Events) != 1 || got.Events[0].Address != "2:5020/4" || got.PreviousDate != "2026-10-09" {
+		t.Errorf("payload = %+v", got)
+	}
+
+	if len(log.rows) != 2 || log.rows[0].Status != StatusRetrying || log.rows[0].HTTPStatus != 502 ||
+		log.rows[1].Status != StatusDelivered || log.rows[0].DeliveryID != log.rows[1].DeliveryID {
+		t.Errorf("delivery log = %+v", log.rows)
+	}
+}
+
+func TestWebhookClientErrorIsNotRetried(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusGone)
+	}))
+	defer srv.Close()
+
+	d, log := testDispatcher(t, config.SMTPConfig{})
+	subs := []Subscription{{ID: "gone", Domain: "fidonet", Webhook: srv.URL, Filter: Filter{Address: "2:5030/1"}}}
+	if err := subs[0].Validate([]string{"fidonet"}); err != nil {
+		t.Fatal(err)
+	}
+
+	outcomes := d.Notify(context.Background(), subs, "fidonet", testDate, testPrevious, testEvents)
+	if len(outcomes) != 1 || outcomes[0].Err == nil || outcomes[0].Attempts != 1 {
+		t.Fatalf("outcomes = %+v", outcomes)
+	}
+	if len(log.rows) != 1 || log.rows[0].Status != StatusFailed {
+		t.Errorf("delivery log = %+v", log.rows)
+	}
+}
+
+func TestEmailDeliveryAndPermanentReply(t *testing.T) {
+	d, log := testDispatcher(t, config.SMTPConfig{Host: "relay.example.net", Port: 25, From: "nodelistdb@example.net"})
+	var sent []string
+	d.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+		if to[0] == "nobody@example.net" {
+			return &textproto.Error{Code: 550, Msg: "no such user"}
+		}
+		sent = append(sent, string(msg))
+		return nil
+	}
+
+	subs := []Subscription{
+		{ID: "mail", Domain: "fidonet", Email: "sysop@example.net", Filter: Filter{Net: "2:5030"}},
+		{ID: "bounce", Domain: "fidonet", Email: "nobody@example.net", Filter: Filter{Net: "2:5030"}},
+	}
+	for i := range subs {
+		_ = subs[i].Validate([]string{"fidonet"})
+	}
+
+	outcomes := d.Notify(context.Background(), subs, "fidonet", testDate, testPrevious, testEvents)
+	if len(outcomes) != 2 {
+		t.Fatalf("outcomes = %+v", outcomes)
+	}
+	for _, out := range outcomes {
+		if (out.SubscriptionID == "mail") != (out.Err == nil) || out.Attempts != 1 {
+			t.Errorf("outcome %+v", out)
+		}
+	}
+	if len(sent) != 1 || !strings.Contains(sent[0], "Subject: [nodelistdb] 1 node change in fidonet") ||
+		!strings.Contains(sent[0], "removed        2:5030/1") {
+		t.Errorf("sent mail = %q", sent)
+	}
+	if len(log.rows) != 2 {
+		t.Errorf("delivery log = %+v", log.rows)
+	}
+}
@@ -0,0 +1,266 @@
+// Package notify tells subscribers when nodes change.
+//
+// GetNodeChanges answers "what happened to this node" for someone who asks.
+// This package is the other direction: after cmd/parser imports a nodelist
+// that moves a network forward, it compares that nodelist with the one before
+// it (Diff), picks out what each subscription asked to hear about
+// (Subscription.Wants), and sends each subscriber one batch per nodelist, by
+// webhook or by mail (Dispatcher).
+//
+// Subscriptions are read from a YAML file named in the configuration, not
+// from the database: who gets told is an operator's decision, and a file
+// under version control is where the rest of those decisions already live.
+// What was sent, and what failed, goes the other way - into the
+// notification_deliveries table, one row per attempt.
+//
+// # Why a nodelist-to-nodelist diff and not GetNodeChanges
+//
+// GetNodeChanges works one node at a time from its whole history; running it
+// for every node of a network after every import would be tens of thousands
+// of history queries to learn what two consecutive snapshots already say.
+// Diff needs the two snapshots and nothing else.
+package notify
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// EventType is what happened to a node between two nodelists.
+type EventType string
+
+const (
+	EventAdded        EventType = "added"
+	EventRemoved      EventType = "removed"
+	EventFlagsChanged EventType = "flags_changed"
+	EventDown         EventType = "down"
+	EventHold         EventType = "hold"
+)
+
+// EventTypes lists every event type, in the order batches report them.
+var EventTypes = []EventType{EventAdded, EventRemoved, EventFlagsChanged, EventDown, EventHold}
+
+func validEventType(t EventType) bool {
+	for _, known := range EventTypes {
+		if t == known {
+			return true
+		}
+	}
+	return false
+}
+
+// Event is one change to one node. For a removed node the descriptive fields
+// are what the node looked like when last listed.
+type Event struct {
+	Type       EventType `json:"type"`
+	Address    string    `json:"address"`
+	Zone       int       `json:"zone"`
+	Net        int       `json:"net"`
+	Node       int       `json:"node"`
+	SystemName string    `json:"system_name"`
+	Location   string    `json:"location"`
+	SysopName  string    `json:"sysop_name"`
+	NodeType   string    `json:"node_type"`
+
+	// PreviousType is set on down and hold events: what the node was before.
+	PreviousType string `json:"previous_type,omitempty"`
+
+	Flags        []string `json:"flags"`
+	AddedFlags   []string `json:"added_flags,omitempty"`
+	RemovedFlags []string `json:"removed_flags,omitempty"`
+
+	RawLine string `json:"raw_line,omitempty"`
+}
+
+// Summary is the one-line form of the event used in mail.
+func (e Event) Summary() string {
+	who := fmt.Sprintf("%s %s, %s (%s)", e.Address,
+		underscores(e.SystemName), underscores(e.Location), underscores(e.SysopName))
+	switch e.Type {
+	case EventFlagsChanged:
+		var parts []string
+		if len(e.AddedFlags) > 0 {
+			parts = append(parts, "+"+strings.Join(e.AddedFlags, ",+"))
+		}
+		if len(e.RemovedFlags) > 0 {
+			parts = append(parts, "-"+strings.Join(e.RemovedFlags, ",-"))
+		}
+		return fmt.Sprintf("flags changed  %s: %s", who, strings.Join(parts, " "))
+	case EventDown, EventHold:
+		return fmt.Sprintf("%-13s  %s (was %s)", strings.ToUpper(string(e.Type)), who, e.PreviousType)
+	default:
+		return fmt.Sprintf("%-13s  %s", e.Type, who)
+	}
+}
+
+func underscores(s string) string {
+	return strings.ReplaceAll(s, "_", " ")
+}
+
+type address struct{ zone, net, node int }
+
+// Diff compares two consecutive nodelists of one network and returns what
+// changed: nodes added and removed, nodes that went Down or Hold, and nodes
+// whose flags changed. Events come in the current nodelist's order, with the
+// removals after them in the previous one's.
+//
+// Only the first entry listed for an address counts. The nodes table keeps a
+// duplicate entry as a conflict row, and comparing conflict rows would report
+// a change every time a nodelist reorders its duplicates.
+func Diff(previous, current []database.Node) []Event {
+	prevByAddr := firstEntries(previous)
+	currByAddr := firstEntries(current)
+
+	var events []Event
+	seen := make(map[address]bool, len(current))
+	for i := range current {
+		curr := &current[i]
+		addr := address{curr.Zone, curr.Net, curr.Node}
+		if seen[addr] {
+			continue
+		}
+		seen[addr] = true
+
+		prev, ok := prevByAddr[addr]
+		if !ok {
+			events = append(events, newEvent(EventAdded, curr))
+			continue
+		}
+
+		if t := statusEvent(curr.NodeType); t != "" && !strings.EqualFold(prev.NodeType, curr.NodeType) {
+			ev := newEvent(t, curr)
+			ev.PreviousType = prev.NodeType
+			events = append(events, ev)
+		}
+
+		oldFlags, newFlags := comparableFlags(prev, curr)
+		if added, removed := setDiff(oldFlags, newFlags); len(added) > 0 || len(removed) > 0 {
+			ev := newEvent(EventFlagsChanged, curr)
+			ev.AddedFlags, ev.RemovedFlags = added, removed
+			events = append(events, ev)
+		}
+	}
+
+	gone := make(map[address]bool)
+	for i := range previous {
+		prev := &previous[i]
+		addr := address{prev.Zone, prev.Net, prev.Node}
+		if _, listed := currByAddr[addr]; listed || gone[addr] {
+			continue
+		}
+		gone[addr] = true
+		events = append(events, newEvent(EventRemoved, prev))
+	}
+	return events
+}
+
+// firstEntries indexes nodes by address, keeping the lowest conflict
+// sequence for each.
+func firstEntries(nodes []database.Node) map[address]*database.Node {
+	byAddr := make(map[address]*database.Node, len(nodes))
+	for i := range nodes {
+		n := &nodes[i]
+		addr := address{n.Zone, n.Net, n.Node}
+		if have, ok := byAddr[addr]; !ok || n.ConflictSequence < have.ConflictSequence {
+			byAddr[addr] = n
+		}
+	}
+	return byAddr
+}
+
+// statusEvent maps the node types that put a node out of service to their
+// event, and everything else to "".
+func statusEvent(nodeType string) EventType {
+	switch strings.ToLower(nodeType) {
+	case "down":
+		return EventDown
+	case "hold":
+		return EventHold
+	}
+	return ""
+}
+
+func newEvent(t EventType, n *database.Node) Event {
+	return Event{
+		Type:       t,
+		Address:    fmt.Sprintf("%d:%d/%d", n.Zone, n.Net, n.Node),
+		Zone:       n.Zone,
+		Net:        n.Net,
+		Node:       n.Node,
+		SystemName: n.SystemName,
+		Location:   n.Location,
+		SysopName:  n.SysopName,
+		NodeType:   n.NodeType,
+		Flags:      nodeFlags(n, n.RawLine != ""),
+		RawLine:    n.RawLine,
+	}
+}
+
+// comparableFlags returns both nodes' flags from the same source: the raw
+// lines when both have one, the parsed flag arrays otherwise. Mixing the two
+// would report every node of a legacy import as changed.
+func comparableFlags(prev, curr *database.Node) (oldFlags, newFlags []string) {
+	raw := prev.RawLine != "" && curr.RawLine != ""
+	return nodeFlags(prev, raw), nodeFlags(curr, raw)
+}
+
+// nodeFlags returns a node's flags as published: the fields after the baud
+// rate of its raw line, or the parsed flag arrays when raw is false.
+func nodeFlags(n *database.Node, raw bool) []string {
+	if raw {
+		fields := strings.Split(n.RawLine, ",")
+		if len(fields) <= 7 {
+			return nil
+		}
+		var flags []string
+		for _, f := range fields[7:] {
+			if f = strings.TrimSpace(f); f != "" {
+				flags = append(flags, f)
+			}
+		}
+		return flags
+	}
+	flags := make([]string, 0, len(n.Flags)+len(n.ModemFlags))
+	flags = append(flags, n.Flags...)
+	return append(flags, n.ModemFlags...)
+}
+
+// setDiff returns the flags only in b and the flags only in a, each sorted.
+// Order on the line does not count as a change.
+func setDiff(a, b []string) (added, removed []string) {
+	inA := make(map[string]bool, len(a))
+	for _, f := range a {
+		inA[f] = true
+	}
+	inB := make(map[string]bool, len(b))
+	for _, f := range b {
+		inB[f] = true
+		if !inA[f] {
+			added = append(added, f)
+		}
+	}
+	for _, f := range a {
+		if !inB[f] {
+			removed = append(removed, f)
+		}
+	}
+	sort.Strings(added)
+	sort.Strings(removed)
+	return dedupe(added), dedupe(removed)
+}
+
+func dedupe(sorted []string) []string {
+	if len(sorted) < 2 {
+		return sorted
+	}
+	out := sorted[:1]
+	for _, f := range sorted[1:] {
+		if f != out[len(out)-1] {
+			out = append(out, f)
+		}
+	}
+	return out
+}
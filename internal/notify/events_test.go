@@ -0,0 +1,165 @@
+package notify
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+func node(zone, net, num int, nodeType, raw string) database.Node {
+	fields := strings.Split(raw, ",")
+	return database.Node{
+		Zone: zone, Net: net, Node: num, NodeType: nodeType,
+		SystemName: fields[2], Location: fields[3], SysopName: fields[4],
+		RawLine: raw,
+	}
+}
+
+func TestDiffReportsEachKindOfChange(t *testing.T) {
+	previous := []database.Node{
+		node(2, 5020, 0, "Host", "Host,5020,Moscow_Net,Moscow,Host_Sysop,-Unpublished-,300,CM"),
+		node(2, 5020, 1, "Node", ",1,Node_One,Moscow,Sysop_One,-Unpublished-,300,IBN,CM"),
+		node(2, 5020, 2, "Node", ",2,Node_Two,Moscow,Sysop_Two,-Unpublished-,300,IBN"),
+		node(2, 5020, 3, "Node", ",3,Node_Three,Moscow,Sysop_Three,-Unpublished-,300,IBN"),
+	}
+	// A conflict row must not count as the node.
+	conflict := node(2, 5020, 1, "Node", ",1,Impostor,Moscow,Other,-Unpublished-,300,ITN")
+	conflict.ConflictSequence = 1
+
+	current := []database.Node{
+		node(2, 5020, 0, "Host", "Host,5020,Moscow_Net,Moscow,Host_Sysop,-Unpublished-,300,CM"),
+		node(2, 5020, 1, "Node", ",1,Node_One,Moscow,Sysop_One,-Unpublished-,300,CM,IBN,INA:example.net"),
+		conflict,
+		node(2, 5020, 2, "Down", "Down,2,Node_Two,Moscow,Sysop_Two,-Unpublished-,300,IBN"),
+		node(2, 5020, 4, "Node", ",4,Node_Four,Moscow,Sysop_Four,-Unpublished-,300,IBN"),
+	}
+
+	var got []string
+	for _, ev := range Diff(previous, current) {
+		got = append(got, string(ev.Type)+" "+ev.Address)
+		if ev.Type == EventFlagsChanged && (!reflect.DeepEqual(ev.AddedFlags, []string{"INA:example.net"}) || len(ev.RemovedFlags) != 0) {
+			t.Errorf("flags_changed added %q removed %q", ev.AddedFlags, ev.RemovedFlags)
+		}
+		if ev.Type == EventDown && ev.PreviousType != "Node" {
+			t.Errorf("down event PreviousType = %q", ev.PreviousType)
+		}
+	}
+	want := []string{"flags_changed 2:5020/1", "down 2:5020/2", "added 2:5020/4", "removed 2:5020/3"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("Diff = %q, want %q", got, want)
+	}
+}
+
+func TestDiffWithoutRawLinesComparesParsedFlags(t *testing.T) {
+	prev := database.Node{Zone: 1, Net: 1, Node: 1, Flags: []string{"CM"}, ModemFlags: []string{"V34"}}
+	curr := database.Node{Zone: 1, Net: 1, Node: 1, Flags: []string{"CM"}, ModemFlags: []string{"V34"},
+		RawLine: ",1,Node,Place,Sysop,-Unpublished-,300,CM,V34"}
+	if events := Diff([]database.Node{prev}, []database.Node{curr}); len(events) != 0 {
+		t.Errorf("a raw line appearing alone reported %v", events)
+	}
+}
+
+// testNetworks are the configured networks subscriptions are checked against.
+var testNetworks = []string{"fidonet", "fsxnet"}
+
+func writeSubscriptions(t *testing.T, body string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "subscriptions.yaml")
+	if err := os.WriteFile(path, []byte(body), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestSubscriptionFilters(t *testing.T) {
+	subs, err := LoadSubscriptions(writeSubscriptions(t, `
+subscriptions:
+  - id: net5020
+    filter: {net: "2:5020"}
+    events: [added, removed]
+    webhook: https://hooks.example.net/nodelist
+  - id: one-node
+    filter: {address: "2:5020/2"}
+    email: sysop@example.net
+  - id: sysop
+    filter: {sysop: "sysop one"}
+    webhook: https://hooks.example.net/sysop
+  - id: binkp
+    domain: FSXNet
+    filter: {flag: IBN}
+    webhook: https://hooks.example.net/fsx
+`), testNetworks)
+	if err != nil {
+		t.Fatalf("LoadSubscriptions: %v", err)
+	}
+
+	added := Event{Type: EventAdded, Zone: 2, Net: 5020, Node: 4, Flags: []string{"IBN:24555"}}
+	down := Event{Type: EventDown, Zone: 2, Net: 5020, Node: 2, SysopName: "Sysop_Two"}
+	flags := Event{Type: EventFlagsChanged, Zone: 2, Net: 5030, Node: 1, SysopName: "Sysop_One", RemovedFlags: []string{"IBN"}}
+
+	for _, tc := range []struct {
+		sub    int
+		domain string
+		ev     Event
+		want   bool
+	}{
+		{0, "fidonet", added, true},
+		{0, "fidonet", down, false}, // event type not subscribed
+		{0, "fsxnet", added, false}, // other network
+		{1, "fidonet", down, true},
+		{1, "fidonet", added, false},
+		{2, "fidonet", flags, true}, // underscores and case ignored
+		{3, "fsxnet", added, true},  // IBN matches IBN:24555; domain case ignored
+		{3, "fsxnet", flags, true},  // a removed flag counts
+		{3, "fsxnet", down, false},
+	} {
+		if got := subs[tc.sub].Wants(tc.domain, tc.ev); got != tc.want {
+			t.Errorf("%s.Wants(%s, %s %d:%d/%d) = %t, want %t",
+				subs[tc.sub].ID, tc.domain, tc.ev.Type, tc.ev.Zone, tc.ev.Net, tc.ev.Node, got, tc.want)
+		}
+	}
+}
+
+func TestLoadSubscriptionsRejectsBadEntries(t *testing.T) {
+	for name, body := range map[string]string{
+		"no filter":    "subscriptions:\n  - id: a\n    webhook: https://x.example/\n",
+		"no target":    "subscriptions:\n  - id: a\n    filter: {flag: CM}\n",
+		"bad address":  "subscriptions:\n  - id: a\n    filter: {address: 2/5020}\n    webhook: https://x.example/\n",
+		"bad event":    "subscriptions:\n  - id: a\n    filter: {flag: CM}\n    events: [renamed]\n    webhook: https://x.example/\n",
+		"bad webhook":  "subscriptions:\n  - id: a\n    filter: {flag: CM}\n    webhook: ftp://x.example/\n",
+		"duplicate id": "subscriptions:\n  - id: a\n    filter: {flag: CM}\n    email: a@x.example\n  - id: a\n    filter: {flag: CM}\n    email: b@x.example\n",
+		"unknown net":  "subscriptions:\n  - id: a\n    domain: fidonte\n    filter: {flag: CM}\n    email: a@x.example\n",
+	} {
+		if _, err := LoadSubscriptions(writeSubscriptions(t, body), testNetworks); err == nil {
+			t.Errorf("%s: LoadSubscriptions accepted it", name)
+		}
+	}
+}
+
+func TestFromStored(t *testing.T) {
+	good := Subscription{ID: "api-1", Owner: "lab", Domain: "FidoNet", Filter: Filter{Net: "2:5020"},
+		Events: []EventType{EventDown}, Webhook: "https://hooks.example.net/", Secret: "s"}
+	if err := good.Validate(testNetworks); err != nil {
+		t.Fatal(err)
+	}
+	gone := Subscription{ID: "api-2", Domain: "othernet", Filter: Filter{Flag: "CM"}, Email: "a@x.example"}
+
+	subs, err := FromStored([]database.NotificationSubscription{good.Stored(), gone.Stored()}, testNetworks)
+	if err == nil || !strings.Contains(err.Error(), "api-2") {
+		t.Errorf("err = %v, want api-2 reported", err)
+	}
+	if len(subs) != 1 {
+		t.Fatalf("subs = %+v, want api-1 alone", subs)
+	}
+	got := subs[0]
+	if got.ID != "api-1" || got.Owner != "lab" || got.Domain != "fidonet" || got.Secret != "s" || len(got.Events) != 1 {
+		t.Errorf("round trip = %+v", got)
+	}
+	if !got.Wants("fidonet", Event{Type: EventDown, Zone: 2, Net: 5020, Node: 1}) {
+		t.Error("stored subscription lost its filter")
+	}
+}
@@ -0,0 +1,90 @@
+package notify
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// Subscriptions registered through the API are kept in ClickHouse, in
+// notification_subscriptions, next to the delivery log. The parser sends to
+// them and to the subscriptions file alike.
+
+// MaxSubscriptionsPerKey caps how many subscriptions one API key may
+// register.
+const MaxSubscriptionsPerKey = 100
+
+// NewSubscriptionID returns an ID for a subscription registered through the
+// API. The prefix keeps it clear of the IDs operators pick for the file.
+func NewSubscriptionID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("failed to generate subscription id: %w", err)
+	}
+	return "api-" + hex.EncodeToString(b), nil
+}
+
+// Stored returns the row that keeps s in notification_subscriptions.
+func (s *Subscription) Stored() database.NotificationSubscription {
+	events := make([]string, len(s.Events))
+	for i, t := range s.Events {
+		events[i] = string(t)
+	}
+	return database.NotificationSubscription{
+		ID:            s.ID,
+		Owner:         s.Owner,
+		Domain:        s.Domain,
+		FilterAddress: s.Filter.Address,
+		FilterNet:     s.Filter.Net,
+		FilterSysop:   s.Filter.Sysop,
+		FilterFlag:    s.Filter.Flag,
+		Events:        events,
+		Webhook:       s.Webhook,
+		Secret:        s.Secret,
+		Email:         s.Email,
+	}
+}
+
+// SubscriptionFromStored turns a stored row back into a subscription, not
+// yet validated.
+func SubscriptionFromStored(row database.NotificationSubscription) Subscription {
+	sub := Subscription{
+		ID:     row.ID,
+		Owner:  row.Owner,
+		Domain: row.Domain,
+		Filter: Filter{
+			Address: row.FilterAddress,
+			Net:     row.FilterNet,
+			Sysop:   row.FilterSysop,
+			Flag:    row.FilterFlag,
+		},
+		Webhook: row.Webhook,
+		Secret:  row.Secret,
+		Email:   row.Email,
+	}
+	for _, t := range row.Events {
+		sub.Events = append(sub.Events, EventType(t))
+	}
+	return sub
+}
+
+// FromStored turns stored rows back into subscriptions, validated against
+// the configured networks. Unlike the file, one bad row does not cost the
+// others their notifications: it is left out and reported in the error,
+// which is nil when every row made it.
+func FromStored(rows []database.NotificationSubscription, networks []string) ([]Subscription, error) {
+	subs := make([]Subscription, 0, len(rows))
+	var errs []error
+	for _, row := range rows {
+		sub := SubscriptionFromStored(row)
+		if err := sub.Validate(networks); err != nil {
+			errs = append(errs, fmt.Errorf("stored subscription: %w", err))
+			continue
+		}
+		subs = append(subs, sub)
+	}
+	return subs, errors.Join(errs...)
+}
@@ -0,0 +1,206 @@
+package notify
+
+import (
+	"fmt"
+	"net/mail"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// Subscription is one subscriber's standing request: which nodes, which
+// events, and where to send them. Webhook and Email may both be set; each
+// gets the batch.
+//
+// Subscriptions come from the operator's subscriptions file or are
+// registered through the API (see stored.go); the JSON form is the API's.
+type Subscription struct {
+	ID     string      `yaml:"id" json:"id"`
+	Domain string      `yaml:"domain,omitempty" json:"domain,omitempty"` // default fidonet
+	Filter Filter      `yaml:"filter" json:"filter"`
+	Events []EventType `yaml:"events,omitempty" json:"events,omitempty"` // default: all of them
+
+	Webhook string `yaml:"webhook,omitempty" json:"webhook,omitempty"`
+	Secret  string `yaml:"secret,omitempty" json:"secret,omitempty"` // overrides notifications.signing_secret
+	Email   string `yaml:"email,omitempty" json:"email,omitempty"`
+
+	// Owner is the API key that registered the subscription; empty for
+	// the subscriptions file.
+	Owner string `yaml:"-" json:"owner,omitempty"`
+}
+
+// Filter selects the nodes a subscription is about. Every criterion that is
+// set must match; at least one must be set, so that a typo cannot subscribe
+// someone to every change in the network.
+type Filter struct {
+	Address string `yaml:"address,omitempty" json:"address,omitempty"` // 2:5020/1
+	Net     string `yaml:"net,omitempty" json:"net,omitempty"`         // 2:5020
+	Sysop   string `yaml:"sysop,omitempty" json:"sysop,omitempty"`     // spaces and underscores are the same
+	Flag    string `yaml:"flag,omitempty" json:"flag,omitempty"`       // IBN, CM, ...; before or after the change
+
+	// Parsed by Validate.
+	addr *address
+	net  *address
+}
+
+// subscriptionsFile is the layout of the subscriptions file.
+type subscriptionsFile struct {
+	Subscriptions []Subscription `yaml:"subscriptions"`
+}
+
+// LoadSubscriptions reads and validates a subscriptions file against the
+// configured networks. One bad entry fails the whole file: a half-loaded list
+// would silently drop subscribers.
+func LoadSubscriptions(path string, networks []string) ([]Subscription, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read subscriptions file: %w", err)
+	}
+	var file subscriptionsFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("failed to parse subscriptions file %s: %w", path, err)
+	}
+
+	seen := make(map[string]bool, len(file.Subscriptions))
+	for i := range file.Subscriptions {
+		sub := &file.Subscriptions[i]
+		if err := sub.Validate(networks); err != nil {
+			return nil, fmt.Errorf("%s: subscriptions[%d]: %w", path, i, err)
+		}
+		if seen[sub.ID] {
+			return nil, fmt.Errorf("%s: subscriptions[%d]: id %q is duplicated", path, i, sub.ID)
+		}
+		seen[sub.ID] = true
+	}
+	return file.Subscriptions, nil
+}
+
+// Validate checks a subscription and prepares its filter for Wants. The
+// domain is lower-cased, as network names are, and has to be one of
+// networks: a subscription to a network that is never imported would never
+// hear anything.
+func (s *Subscription) Validate(networks []string) error {
+	if s.ID == "" {
+		return fmt.Errorf("id is required")
+	}
+	s.Domain = strings.ToLower(strings.TrimSpace(s.Domain))
+	if s.Domain == "" {
+		s.Domain = database.DefaultDomain
+	}
+	if !slices.Contains(networks, s.Domain) {
+		return fmt.Errorf("%s: domain %q is not a configured network (want one of %v)", s.ID, s.Domain, networks)
+	}
+	if s.Webhook == "" && s.Email == "" {
+		return fmt.Errorf("%s: a webhook or an email target is required", s.ID)
+	}
+	if s.Webhook != "" {
+		u, err := url.Parse(s.Webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return fmt.Errorf("%s: webhook must be an http or https URL", s.ID)
+		}
+	}
+	if s.Email != "" {
+		if _, err := mail.ParseAddress(s.Email); err != nil {
+			return fmt.Errorf("%s: email: %w", s.ID, err)
+		}
+	}
+	for _, t := range s.Events {
+		if !validEventType(t) {
+			return fmt.Errorf("%s: unknown event %q (want one of %v)", s.ID, t, EventTypes)
+		}
+	}
+
+	f := &s.Filter
+	if f.Address == "" && f.Net == "" && f.Sysop == "" && f.Flag == "" {
+		return fmt.Errorf("%s: filter needs at least one of address, net, sysop or flag", s.ID)
+	}
+	if f.Address != "" {
+		addr, err := parseAddress(f.Address)
+		if err != nil {
+			return fmt.Errorf("%s: filter.address: %w", s.ID, err)
+		}
+		f.addr = &addr
+	}
+	if f.Net != "" {
+		net, err := parseAddress(f.Net + "/0")
+		if err != nil {
+			return fmt.Errorf("%s: filter.net: %w", s.ID, err)
+		}
+		f.net = &net
+	}
+	return nil
+}
+
+// parseAddress parses zone:net/node.
+func parseAddress(s string) (address, error) {
+	zone, rest, ok := strings.Cut(strings.TrimSpace(s), ":")
+	if !ok {
+		return address{}, fmt.Errorf("%q is not zone:net/node", s)
+	}
+	net, node, ok := strings.Cut(rest, "/")
+	if !ok {
+		return address{}, fmt.Errorf("%q is not zone:net/node", s)
+	}
+	var a address
+	var errs [3]error
+	a.zone, errs[0] = strconv.Atoi(zone)
+	a.net, errs[1] = strconv.Atoi(net)
+	a.node, errs[2] = strconv.Atoi(node)
+	for _, err := range errs {
+		if err != nil {
+			return address{}, fmt.Errorf("%q is not zone:net/node", s)
+		}
+	}
+	return a, nil
+}
+
+// Wants reports whether the subscription asked to hear about ev, an event in
+// the given network.
+func (s *Subscription) Wants(domain string, ev Event) bool {
+	if !strings.EqualFold(s.Domain, domain) {
+		return false
+	}
+	if len(s.Events) > 0 {
+		wanted := false
+		for _, t := range s.Events {
+			wanted = wanted || t == ev.Type
+		}
+		if !wanted {
+			return false
+		}
+	}
+	return s.Filter.matches(ev)
+}
+
+func (f *Filter) matches(ev Event) bool {
+	if f.addr != nil && *f.addr != (address{ev.Zone, ev.Net, ev.Node}) {
+		return false
+	}
+	if f.net != nil && (f.net.zone != ev.Zone || f.net.net != ev.Net) {
+		return false
+	}
+	if f.Sysop != "" && !strings.EqualFold(underscores(f.Sysop), underscores(ev.SysopName)) {
+		return false
+	}
+	if f.Flag != "" && !hasFlag(ev.Flags, f.Flag) && !hasFlag(ev.RemovedFlags, f.Flag) {
+		return false
+	}
+	return true
+}
+
+// hasFlag matches a flag by name, so IBN finds IBN:24555 as well.
+func hasFlag(flags []string, name string) bool {
+	for _, f := range flags {
+		flagName, _, _ := strings.Cut(f, ":")
+		if strings.EqualFold(flagName, name) || strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
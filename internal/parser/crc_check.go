@@ -0,0 +1,173 @@
+package parser
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// CRC verdicts recorded per imported nodelist in nodelist_files.
+const (
+	CRCVerdictOK       = "ok"        // computed CRC equals the header's
+	CRCVerdictMismatch = "mismatch"  // header declares a different CRC
+	CRCVerdictNoHeader = "no_header" // header declares no CRC at all
+)
+
+// CRCVerdict classifies a parsed file by its header and computed CRCs.
+func (r *ParseResult) CRCVerdict() string {
+	switch {
+	case !r.HasHeaderCRC:
+		return CRCVerdictNoHeader
+	case r.HeaderCRC != r.ComputedCRC:
+		return CRCVerdictMismatch
+	default:
+		return CRCVerdictOK
+	}
+}
+
+// NodelistFileRecord builds the nodelist_files registry row for this result.
+// imported says whether its nodes went into the database.
+func (r *ParseResult) NodelistFileRecord(domain string, imported bool) database.NodelistFile {
+	return database.NodelistFile{
+		Domain:       domain,
+		NodelistDate: r.NodelistDate,
+		DayNumber:    r.DayNumber,
+		Filename:     filepath.Base(r.FilePath),
+		HeaderCRC:    r.HeaderCRC,
+		HasHeaderCRC: r.HasHeaderCRC,
+		ComputedCRC:  r.ComputedCRC,
+		CRCVerdict:   r.CRCVerdict(),
+		NodesCount:   uint32(len(r.Nodes)),
+		Imported:     imported,
+	}
+}
+
+// CRCCheckMode is how an import treats a nodelist whose computed CRC disagrees
+// with its header.
+type CRCCheckMode string
+
+const (
+	// CRCCheckStrict refuses the file and copies it to the quarantine directory.
+	CRCCheckStrict CRCCheckMode = "strict"
+	// CRCCheckWarn imports the file and reports the mismatch.
+	CRCCheckWarn CRCCheckMode = "warn"
+	// CRCCheckOff imports the file silently. The verdict is still recorded:
+	// the mode decides what the import does, not what is known about the file.
+	CRCCheckOff CRCCheckMode = "off"
+)
+
+// ParseCRCCheckMode validates a -crc-check value.
+func ParseCRCCheckMode(s string) (CRCCheckMode, error) {
+	switch mode := CRCCheckMode(strings.ToLower(strings.TrimSpace(s))); mode {
+	case CRCCheckStrict, CRCCheckWarn, CRCCheckOff:
+		return mode, nil
+	}
+	return "", fmt.Errorf("invalid CRC check mode %q (want strict, warn or off)", s)
+}
+
+// Refuses reports whether the mode keeps a file with this verdict out of the
+// database. Only a positive mismatch is refused: a header without a CRC is the
+// norm for the earliest nodelists and proves nothing either way.
+func (m CRCCheckMode) Refuses(verdict string) bool {
+	return m == CRCCheckStrict && verdict == CRCVerdictMismatch
+}
+
+// NodelistFileRegistrar is the one storage call the CRC policy needs.
+type NodelistFileRegistrar interface {
+	RegisterNodelistFile(file database.NodelistFile) error
+}
+
+// CRCPolicy is what an import does with a nodelist whose CRC does not match:
+// the -crc-check mode, the -quarantine-dir and whether warnings are printed.
+type CRCPolicy struct {
+	Mode          CRCCheckMode
+	QuarantineDir string
+	Quiet         bool
+}
+
+// Admit applies the policy to a parsed nodelist before its nodes are
+// inserted, and reports whether the import may go ahead.
+//
+// A refused file is copied to the quarantine directory (when one is set) and
+// recorded in nodelist_files with imported = false, so the download pages can
+// flag the archive copy the hubs still serve. An admitted file is registered
+// by the caller once its nodes are in - see RegisterNodelistFile.
+func (p CRCPolicy) Admit(registrar NodelistFileRegistrar, domain string, result *ParseResult) bool {
+	verdict := result.CRCVerdict()
+	if verdict != CRCVerdictMismatch {
+		return true
+	}
+
+	if !p.Mode.Refuses(verdict) {
+		if p.Mode == CRCCheckWarn && !p.Quiet {
+			fmt.Printf("  WARNING: %s: CRC mismatch (header %05d, computed %05d); importing anyway\n",
+				result.FilePath, result.HeaderCRC, result.ComputedCRC)
+		}
+		return true
+	}
+
+	where := "file left in place, no quarantine directory"
+	if p.QuarantineDir != "" {
+		dest, err := QuarantineFile(result.FilePath, p.QuarantineDir)
+		if err != nil {
+			where = fmt.Sprintf("quarantine copy failed: %v", err)
+		} else {
+			where = "copied to " + dest
+		}
+	}
+	// Greppable marker, as for pointlists: the bulk import script collects
+	// these lines into its quarantine report.
+	fmt.Printf("  QUARANTINED: %s — CRC mismatch (header %05d, computed %05d); %s\n",
+		result.FilePath, result.HeaderCRC, result.ComputedCRC, where)
+
+	RegisterNodelistFile(registrar, domain, result, false)
+	return false
+}
+
+// RegisterNodelistFile records the file's CRC verdict in nodelist_files. The
+// registry is informational, so a failure is reported and the import goes on.
+func RegisterNodelistFile(registrar NodelistFileRegistrar, domain string, result *ParseResult, imported bool) {
+	if result.NodelistDate.IsZero() {
+		return
+	}
+	if err := registrar.RegisterNodelistFile(result.NodelistFileRecord(domain, imported)); err != nil {
+		fmt.Printf("  Warning: Failed to register nodelist CRC verdict for %s: %v\n", result.FilePath, err)
+	}
+}
+
+// QuarantineFile copies a refused nodelist into dir and returns the copy's
+// path. It copies rather than moves: the source is often the download archive
+// itself, which keeps serving the file - flagged as known-bad from the
+// registry - rather than losing it.
+func QuarantineFile(filePath, dir string) (string, error) {
+	if dir == "" {
+		return "", fmt.Errorf("no quarantine directory configured")
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", NewFileError(dir, "mkdir", "failed to create quarantine directory", err)
+	}
+
+	src, err := os.Open(filePath)
+	if err != nil {
+		return "", NewFileError(filePath, "open", "failed to open file", err)
+	}
+	defer src.Close()
+
+	dest := filepath.Join(dir, filepath.Base(filePath))
+	dst, err := os.Create(dest)
+	if err != nil {
+		return "", NewFileError(dest, "create", "failed to create quarantine copy", err)
+	}
+	if _, err := io.Copy(dst, src); err != nil {
+		dst.Close()
+		return "", NewFileError(dest, "write", "failed to write quarantine copy", err)
+	}
+	if err := dst.Close(); err != nil {
+		return "", NewFileError(dest, "write", "failed to write quarantine copy", err)
+	}
+	return dest, nil
+}
@@ -0,0 +1,160 @@
+package parser
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// writeNodelist writes lines in distribution form to dir/name.
+func writeNodelist(t *testing.T, dir, name string, lines []string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteNodelistLines(f, lines); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestParseFileWithCRCComputesArchivedCRC(t *testing.T) {
+	result, err := New(false).ParseFileWithCRC("../../test_nodelists/nodelist.216")
+	if err != nil {
+		t.Fatalf("ParseFileWithCRC: %v", err)
+	}
+	if !result.HasHeaderCRC || result.HeaderCRC != 16784 || result.ComputedCRC != 16784 {
+		t.Errorf("CRC header %d (present %t), computed %d; want 16784 both",
+			result.HeaderCRC, result.HasHeaderCRC, result.ComputedCRC)
+	}
+	if v := result.CRCVerdict(); v != CRCVerdictOK {
+		t.Errorf("CRCVerdict = %q, want %q", v, CRCVerdictOK)
+	}
+}
+
+func TestParseFileWithCRCDetectsMismatch(t *testing.T) {
+	lines := withHeaderCRC(";A FidoNet Nodelist for Friday, July 1, 2022 -- Day number 182", sampleBody())
+	lines[5] = strings.Replace(lines[5], "Node_One", "Node_Uno", 1)
+	path := writeNodelist(t, t.TempDir(), "nodelist.182", lines)
+
+	result, err := New(false).ParseFileWithCRC(path)
+	if err != nil {
+		t.Fatalf("ParseFileWithCRC: %v", err)
+	}
+	if v := result.CRCVerdict(); v != CRCVerdictMismatch {
+		t.Fatalf("CRCVerdict = %q, want %q", v, CRCVerdictMismatch)
+	}
+	if result.ComputedCRC != NodelistCRC(lines) {
+		t.Errorf("ComputedCRC = %d, want %d", result.ComputedCRC, NodelistCRC(lines))
+	}
+
+	record := result.NodelistFileRecord("fidonet", false)
+	if record.Filename != "nodelist.182" || record.CRCVerdict != CRCVerdictMismatch || record.Imported {
+		t.Errorf("NodelistFileRecord = %+v", record)
+	}
+}
+
+func TestCRCCheckModeRefusesOnlyStrictMismatch(t *testing.T) {
+	for _, tt := range []struct {
+		mode    string
+		verdict string
+		refuse  bool
+	}{
+		{"strict", CRCVerdictMismatch, true},
+		{"STRICT", CRCVerdictNoHeader, false},
+		{"strict", CRCVerdictOK, false},
+		{"warn", CRCVerdictMismatch, false},
+		{"off", CRCVerdictMismatch, false},
+	} {
+		mode, err := ParseCRCCheckMode(tt.mode)
+		if err != nil {
+			t.Fatalf("ParseCRCCheckMode(%q): %v", tt.mode, err)
+		}
+		if got := mode.Refuses(tt.verdict); got != tt.refuse {
+			t.Errorf("%s.Refuses(%s) = %t, want %t", tt.mode, tt.verdict, got, tt.refuse)
+		}
+	}
+	if _, err := ParseCRCCheckMode("fail"); err == nil {
+		t.Error("ParseCRCCheckMode accepted an unknown mode")
+	}
+}
+
+func TestQuarantineFileCopies(t *testing.T) {
+	src := writeNodelist(t, t.TempDir(), "nodelist.182", []string{";A header", ";S body"})
+	dest, err := QuarantineFile(src, filepath.Join(t.TempDir(), "quarantine"))
+	if err != nil {
+		t.Fatalf("QuarantineFile: %v", err)
+	}
+	want, _ := os.ReadFile(src)
+	got, err := os.ReadFile(dest)
+	if err != nil || string(got) != string(want) {
+		t.Errorf("quarantine copy = %q, %v; want %q", got, err, want)
+	}
+	if _, err := os.Stat(src); err != nil {
+		t.Errorf("source gone after quarantine: %v", err)
+	}
+}
+
+// fakeRegistrar records the nodelist_files rows a CRC policy writes.
+type fakeRegistrar struct {
+	files []database.NodelistFile
+}
+
+func (r *fakeRegistrar) RegisterNodelistFile(file database.NodelistFile) error {
+	r.files = append(r.files, file)
+	return nil
+}
+
+func TestCRCPolicyAdmit(t *testing.T) {
+	lines := withHeaderCRC(";A FidoNet Nodelist for Friday, July 1, 2022 -- Day number 182", sampleBody())
+	lines[5] = strings.Replace(lines[5], "Node_One", "Node_Uno", 1)
+	path := writeNodelist(t, t.TempDir(), "nodelist.182", lines)
+	result, err := New(false).ParseFileWithCRC(path)
+	if err != nil {
+		t.Fatalf("ParseFileWithCRC: %v", err)
+	}
+
+	tests := []struct {
+		mode       CRCCheckMode
+		quarantine bool
+		admit      bool
+	}{
+		{CRCCheckOff, true, true},
+		{CRCCheckWarn, true, true},
+		{CRCCheckStrict, false, false},
+		{CRCCheckStrict, true, false},
+	}
+	for _, tt := range tests {
+		policy := CRCPolicy{Mode: tt.mode, Quiet: true}
+		if tt.quarantine {
+			policy.QuarantineDir = filepath.Join(t.TempDir(), "quarantine")
+		}
+		registrar := &fakeRegistrar{}
+		if got := policy.Admit(registrar, "fidonet", result); got != tt.admit {
+			t.Errorf("%s: Admit = %t, want %t", tt.mode, got, tt.admit)
+		}
+		if tt.admit {
+			// Admitted files are registered by the caller after the insert.
+			if len(registrar.files) != 0 {
+				t.Errorf("%s: admitted file registered early: %+v", tt.mode, registrar.files)
+			}
+			continue
+		}
+		if len(registrar.files) != 1 || registrar.files[0].Imported || registrar.files[0].Domain != "fidonet" {
+			t.Errorf("%s: registered %+v, want one row with imported = false", tt.mode, registrar.files)
+		}
+		if tt.quarantine {
+			if _, err := os.Stat(filepath.Join(policy.QuarantineDir, "nodelist.182")); err != nil {
+				t.Errorf("%s: no quarantine copy: %v", tt.mode, err)
+			}
+		}
+	}
+}
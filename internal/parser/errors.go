@@ -210,3 +210,26 @@ func ParseUint32(field, value string) (uint32, error) {
 	}
 	return uint32(result), nil
 }
+
+// CRCError reports a nodelist whose computed CRC-16 disagrees with the value
+// its header line declares.
+type CRCError struct {
+	Source   string    `json:"source"`
+	Header   uint16    `json:"header_crc"`
+	Computed uint16    `json:"computed_crc"`
+	Occurred time.Time `json:"occurred"`
+}
+
+func (e *CRCError) Error() string {
+	return fmt.Sprintf("CRC mismatch in %s: header declares %05d, contents compute to %05d", e.Source, e.Header, e.Computed)
+}
+
+// NewCRCError creates a new CRCError
+func NewCRCError(source string, header, computed uint16) *CRCError {
+	return &CRCError{
+		Source:   source,
+		Header:   header,
+		Computed: computed,
+		Occurred: time.Now(),
+	}
+}
@@ -0,0 +1,318 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// NODEDIFF support (FTS-5000 section 3).
+//
+// A NODEDIFF turns last week's nodelist into this week's. Its first line is a
+// copy of the first line of the nodelist it applies to, which is how a tool
+// checks it has the right base; the rest is a stream of commands against that
+// base, starting at its first line:
+//
+//	Ann   add the nn lines that follow the command
+//	Cnn   copy the next nn lines of the base
+//	Dnn   delete (skip) the next nn lines of the base
+//
+// The commands must consume the base exactly, and the result carries its own
+// header CRC, so a wrong base or a damaged diff never goes unnoticed.
+
+// NodediffOp is one NODEDIFF command letter.
+type NodediffOp byte
+
+const (
+	NodediffAdd    NodediffOp = 'A'
+	NodediffCopy   NodediffOp = 'C'
+	NodediffDelete NodediffOp = 'D'
+)
+
+// NodediffCommand is one command of a NODEDIFF. Lines is only set for adds.
+type NodediffCommand struct {
+	Op    NodediffOp
+	Count int
+	Lines []string
+}
+
+// Nodediff is a parsed NODEDIFF file.
+type Nodediff struct {
+	// BaseHeader is the first line of the nodelist the diff applies to.
+	BaseHeader string
+	Commands   []NodediffCommand
+}
+
+// ErrNodediffBaseMismatch reports a NODEDIFF offered a base nodelist other
+// than the one it was made against.
+var ErrNodediffBaseMismatch = errors.New("nodediff does not apply to this nodelist")
+
+// ReadNodediffFile reads and parses a plain or gzipped NODEDIFF file.
+func ReadNodediffFile(filePath string) (*Nodediff, error) {
+	lines, err := ReadNodelistFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+	return ParseNodediff(filePath, lines)
+}
+
+// ParseNodediff parses the raw lines of a NODEDIFF. source only labels errors.
+func ParseNodediff(source string, lines []string) (*Nodediff, error) {
+	if len(lines) == 0 {
+		return nil, NewParseError(source, 0, "empty nodediff")
+	}
+
+	diff := &Nodediff{BaseHeader: lines[0]}
+	for i := 1; i < len(lines); i++ {
+		cmdLine := strings.TrimSpace(lines[i])
+		if cmdLine == "" {
+			continue
+		}
+		op := NodediffOp(cmdLine[0])
+		if op != NodediffAdd && op != NodediffCopy && op != NodediffDelete {
+			return nil, NewFieldParseError(source, i+1, "command", cmdLine, "unknown nodediff command")
+		}
+		count, err := strconv.Atoi(cmdLine[1:])
+		if err != nil || count < 0 {
+			return nil, NewFieldParseError(source, i+1, "count", cmdLine, "invalid nodediff line count")
+		}
+
+		cmd := NodediffCommand{Op: op, Count: count}
+		if op == NodediffAdd {
+			if i+count >= len(lines) {
+				return nil, NewFieldParseError(source, i+1, "count", cmdLine, "add command runs past the end of the nodediff")
+			}
+			cmd.Lines = lines[i+1 : i+1+count]
+			i += count
+		}
+		diff.Commands = append(diff.Commands, cmd)
+	}
+	return diff, nil
+}
+
+// Apply applies the diff to base and returns the new nodelist's lines. The
+// result's CRC is checked against its own header; a mismatch is returned as a
+// *CRCError together with the lines, so a caller can still inspect them.
+func (d *Nodediff) Apply(base []string) ([]string, error) {
+	if len(base) == 0 || base[0] != d.BaseHeader {
+		return nil, ErrNodediffBaseMismatch
+	}
+
+	result := make([]string, 0, len(base)+64)
+	pos := 0
+	for i, cmd := range d.Commands {
+		switch cmd.Op {
+		case NodediffAdd:
+			result = append(result, cmd.Lines...)
+		case NodediffCopy, NodediffDelete:
+			if pos+cmd.Count > len(base) {
+				return nil, fmt.Errorf("nodediff command %d (%c%d) runs past the end of the base nodelist (%d lines)",
+					i+1, cmd.Op, cmd.Count, len(base))
+			}
+			if cmd.Op == NodediffCopy {
+				result = append(result, base[pos:pos+cmd.Count]...)
+			}
+			pos += cmd.Count
+		}
+	}
+	if pos != len(base) {
+		return nil, fmt.Errorf("nodediff leaves %d line(s) of the base nodelist unaccounted for", len(base)-pos)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("nodediff produces an empty nodelist")
+	}
+
+	if err := VerifyNodelistCRC("nodediff result", result); err != nil {
+		return result, err
+	}
+	return result, nil
+}
+
+// WriteTo writes the diff in distribution form (CR/LF lines, trailing ^Z).
+func (d *Nodediff) WriteTo(w io.Writer) (int64, error) {
+	cw := &countingWriter{w: w}
+	lines := make([]string, 0, 1+len(d.Commands)*2)
+	lines = append(lines, d.BaseHeader)
+	for _, cmd := range d.Commands {
+		lines = append(lines, fmt.Sprintf("%c%d", cmd.Op, cmd.Count))
+		lines = append(lines, cmd.Lines...)
+	}
+	err := WriteNodelistLines(cw, lines)
+	return cw.n, err
+}
+
+// countingWriter counts the bytes passed through it for io.WriterTo.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// maxNodediffEdits bounds the edit distance GenerateNodediff searches for a
+// minimal diff. A weekly FidoNet diff is a few hundred edits; the bound only
+// matters for two unrelated lists, where the search memory grows with the
+// square of the distance. Past it the changed region is emitted as a plain
+// delete-and-add, which is still a correct diff, just not a small one.
+const maxNodediffEdits = 4096
+
+// GenerateNodediff builds the NODEDIFF that turns base into target, as MakeNL
+// would: a minimal line diff, expressed as runs of A/C/D commands.
+func GenerateNodediff(base, target []string) *Nodediff {
+	diff := &Nodediff{}
+	if len(base) > 0 {
+		diff.BaseHeader = base[0]
+	}
+
+	// Common prefix and suffix are copies; only the middle needs a search.
+	prefix := 0
+	for prefix < len(base) && prefix < len(target) && base[prefix] == target[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(base)-prefix && suffix < len(target)-prefix &&
+		base[len(base)-1-suffix] == target[len(target)-1-suffix] {
+		suffix++
+	}
+
+	var b diffBuilder
+	b.emit(NodediffCopy, prefix, nil)
+
+	midBase := base[prefix : len(base)-suffix]
+	midTarget := target[prefix : len(target)-suffix]
+	ops, ok := lineEditScript(midBase, midTarget, maxNodediffEdits)
+	if !ok {
+		b.emit(NodediffDelete, len(midBase), nil)
+		b.emit(NodediffAdd, len(midTarget), midTarget)
+	} else {
+		y := 0
+		for _, op := range ops {
+			switch op {
+			case NodediffCopy:
+				b.emit(NodediffCopy, 1, nil)
+				y++
+			case NodediffDelete:
+				b.emit(NodediffDelete, 1, nil)
+			case NodediffAdd:
+				b.emit(NodediffAdd, 1, midTarget[y:y+1])
+				y++
+			}
+		}
+	}
+
+	b.emit(NodediffCopy, suffix, nil)
+	diff.Commands = b.commands
+	return diff
+}
+
+// diffBuilder coalesces single-line operations into counted commands.
+type diffBuilder struct {
+	commands []NodediffCommand
+}
+
+func (b *diffBuilder) emit(op NodediffOp, count int, lines []string) {
+	if count == 0 {
+		return
+	}
+	if n := len(b.commands); n > 0 && b.commands[n-1].Op == op {
+		last := &b.commands[n-1]
+		last.Count += count
+		last.Lines = append(last.Lines, lines...)
+		return
+	}
+	b.commands = append(b.commands, NodediffCommand{Op: op, Count: count, Lines: append([]string(nil), lines...)})
+}
+
+// lineEditScript returns a shortest edit script turning a into b (Myers,
+// "An O(ND) Difference Algorithm"), one op per line. ok is false when the edit
+// distance exceeds maxEdits.
+//
+// Each round's frontier is kept for the backtrack, sized to that round only,
+// so memory is quadratic in the edit distance rather than in the file size.
+func lineEditScript(a, b []string, maxEdits int) (ops []NodediffOp, ok bool) {
+	n, m := len(a), len(b)
+	if maxEdits > n+m {
+		maxEdits = n + m
+	}
+
+	offset := maxEdits + 1
+	v := make([]int, 2*maxEdits+3)
+	var trace [][]int
+
+	for d := 0; d <= maxEdits; d++ {
+		for k := -d; k <= d; k += 2 {
+			var x int
+			if k == -d || (k != d && v[offset+k-1] < v[offset+k+1]) {
+				x = v[offset+k+1]
+			} else {
+				x = v[offset+k-1] + 1
+			}
+			y := x - k
+			for x < n && y < m && a[x] == b[y] {
+				x++
+				y++
+			}
+			v[offset+k] = x
+
+			if x >= n && y >= m {
+				trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+				return backtrackEditScript(trace, n, m), true
+			}
+		}
+		trace = append(trace, append([]int(nil), v[offset-d:offset+d+1]...))
+	}
+	return nil, false
+}
+
+// backtrackEditScript walks the saved frontiers from (n, m) back to the
+// origin. trace[d][k+d] is the furthest x reached on diagonal k in round d.
+func backtrackEditScript(trace [][]int, n, m int) []NodediffOp {
+	var ops []NodediffOp
+	x, y := n, m
+	for d := len(trace) - 1; d > 0; d-- {
+		prev := trace[d-1]
+		k := x - y
+
+		var prevK int
+		if k == -d || (k != d && prev[k-1+d-1] < prev[k+1+d-1]) {
+			prevK = k + 1
+		} else {
+			prevK = k - 1
+		}
+		prevX := prev[prevK+d-1]
+		prevY := prevX - prevK
+
+		// The snake that ended this round starts just past the edit.
+		startX := prevX + 1
+		if prevK == k+1 {
+			startX = prevX
+		}
+		for x > startX {
+			ops = append(ops, NodediffCopy)
+			x--
+			y--
+		}
+		if prevK == k+1 {
+			ops = append(ops, NodediffAdd)
+		} else {
+			ops = append(ops, NodediffDelete)
+		}
+		x, y = prevX, prevY
+	}
+	for x > 0 && y > 0 {
+		ops = append(ops, NodediffCopy)
+		x--
+		y--
+	}
+
+	for i, j := 0, len(ops)-1; i < j; i, j = i+1, j-1 {
+		ops[i], ops[j] = ops[j], ops[i]
+	}
+	return ops
+}
@@ -0,0 +1,211 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+// withHeaderCRC returns lines with a header that declares their correct CRC.
+func withHeaderCRC(header string, body []string) []string {
+	lines := append([]string{""}, body...)
+	lines[0] = fmt.Sprintf("%s : %05d", header, NodelistCRC(lines))
+	return lines
+}
+
+func sampleBody() []string {
+	return []string{
+		";A",
+		";S Test nodelist",
+		"Zone,2,Europe,Somewhere,Zone_Coordinator,-Unpublished-,300,CM",
+		"Host,5020,Moscow,Moscow,Host_Sysop,7-495-000-0000,9600,CM,IBN",
+		",1,Node_One,Moscow,Sysop_One,-Unpublished-,300,IBN",
+		",2,Node_Two,Moscow,Sysop_Two,-Unpublished-,300,IBN",
+		",3,Node_Three,Moscow,Sysop_Three,-Unpublished-,300,IBN",
+		"Hold,4,Node_Four,Moscow,Sysop_Four,-Unpublished-,300,IBN",
+	}
+}
+
+func TestNodelistCRCMatchesArchivedNodelist(t *testing.T) {
+	// A real 1989 nodelist whose header declares 16784.
+	lines, err := ReadNodelistFile("../../test_nodelists/nodelist.216")
+	if err != nil {
+		t.Fatalf("ReadNodelistFile: %v", err)
+	}
+	declared, ok := HeaderCRC(lines[0])
+	if !ok || declared != 16784 {
+		t.Fatalf("HeaderCRC = %d, %t; want 16784, true", declared, ok)
+	}
+	if got := NodelistCRC(lines); got != 16784 {
+		t.Errorf("NodelistCRC = %d, want 16784", got)
+	}
+	if err := VerifyNodelistCRC("nodelist.216", lines); err != nil {
+		t.Errorf("VerifyNodelistCRC: %v", err)
+	}
+}
+
+func TestVerifyNodelistCRCReportsMismatch(t *testing.T) {
+	lines := withHeaderCRC(";A Friday, 1 July 2022 -- Day number 182", sampleBody())
+	lines[4] = strings.Replace(lines[4], "Moscow", "Moskva", 1)
+
+	err := VerifyNodelistCRC("tampered", lines)
+	var crcErr *CRCError
+	if !errors.As(err, &crcErr) {
+		t.Fatalf("VerifyNodelistCRC = %v, want *CRCError", err)
+	}
+	if crcErr.Header == crcErr.Computed {
+		t.Errorf("CRCError reports equal header and computed CRC %d", crcErr.Header)
+	}
+}
+
+func TestHeaderCRCAbsent(t *testing.T) {
+	if _, ok := HeaderCRC(";A FidoNet Nodelist for Friday, January 2, 2009 -- Day number 002"); ok {
+		t.Error("HeaderCRC found a CRC in a header without one")
+	}
+}
+
+func TestNodediffRoundTrip(t *testing.T) {
+	oldList := withHeaderCRC(";A Friday, 24 June 2022 -- Day number 175", sampleBody())
+
+	body := sampleBody()
+	body = append(body[:5], body[6:]...)                                   // node 2 removed
+	body[5] = ",3,Node_Three,Moscow,Sysop_Three,-Unpublished-,300,IBN,INA" // flags changed
+	body = append(body, ",5,Node_Five,Moscow,Sysop_Five,-Unpublished-,300,IBN")
+	newList := withHeaderCRC(";A Friday, 1 July 2022 -- Day number 182", body)
+
+	diff := GenerateNodediff(oldList, newList)
+	if diff.BaseHeader != oldList[0] {
+		t.Fatalf("BaseHeader = %q, want %q", diff.BaseHeader, oldList[0])
+	}
+
+	var buf bytes.Buffer
+	if _, err := diff.WriteTo(&buf); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	raw, err := ReadNodelistLines(&buf)
+	if err != nil {
+		t.Fatalf("ReadNodelistLines: %v", err)
+	}
+	parsed, err := ParseNodediff("NODEDIFF.182", raw)
+	if err != nil {
+		t.Fatalf("ParseNodediff: %v", err)
+	}
+
+	got, err := parsed.Apply(oldList)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if strings.Join(got, "\n") != strings.Join(newList, "\n") {
+		t.Errorf("Apply reconstructed\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(newList, "\n"))
+	}
+}
+
+func TestNodediffApplyKnownDiff(t *testing.T) {
+	oldList := withHeaderCRC(";A Friday, 24 June 2022 -- Day number 175", sampleBody())
+	newBody := append(sampleBody()[:7:7], ",9,Node_Nine,Moscow,Sysop_Nine,-Unpublished-,300,IBN")
+	newList := withHeaderCRC(";A Friday, 1 July 2022 -- Day number 182", newBody)
+
+	raw := []string{
+		oldList[0],
+		"D1",
+		"A1",
+		newList[0],
+		"C7",
+		"D1",
+		"A1",
+		newBody[7],
+	}
+	diff, err := ParseNodediff("NODEDIFF.182", raw)
+	if err != nil {
+		t.Fatalf("ParseNodediff: %v", err)
+	}
+	got, err := diff.Apply(oldList)
+	if err != nil {
+		t.Fatalf("Apply: %v", err)
+	}
+	if strings.Join(got, "\n") != strings.Join(newList, "\n") {
+		t.Errorf("Apply = %q, want %q", got, newList)
+	}
+}
+
+func TestNodediffApplyRejectsWrongBase(t *testing.T) {
+	oldList := withHeaderCRC(";A Friday, 24 June 2022 -- Day number 175", sampleBody())
+	other := withHeaderCRC(";A Friday, 17 June 2022 -- Day number 168", sampleBody())
+	diff := GenerateNodediff(oldList, oldList)
+
+	if _, err := diff.Apply(other); !errors.Is(err, ErrNodediffBaseMismatch) {
+		t.Errorf("Apply on the wrong base = %v, want ErrNodediffBaseMismatch", err)
+	}
+}
+
+func TestNodediffApplyDetectsCRCMismatch(t *testing.T) {
+	oldList := withHeaderCRC(";A Friday, 24 June 2022 -- Day number 175", sampleBody())
+	raw := []string{oldList[0], "D1", "A1", ";A Friday, 1 July 2022 -- Day number 182 : 00001", fmt.Sprintf("C%d", len(oldList)-1)}
+	diff, err := ParseNodediff("NODEDIFF.182", raw)
+	if err != nil {
+		t.Fatalf("ParseNodediff: %v", err)
+	}
+	var crcErr *CRCError
+	if _, err := diff.Apply(oldList); !errors.As(err, &crcErr) {
+		t.Errorf("Apply = %v, want *CRCError", err)
+	}
+}
+
+func TestNodediffApplyRejectsUnconsumedBase(t *testing.T) {
+	oldList := withHeaderCRC(";A Friday, 24 June 2022 -- Day number 175", sampleBody())
+	diff, err := ParseNodediff("NODEDIFF.182", []string{oldList[0], "C3"})
+	if err != nil {
+		t.Fatalf("ParseNodediff: %v", err)
+	}
+	if _, err := diff.Apply(oldList); err == nil {
+		t.Error("Apply accepted a diff that leaves base lines unaccounted for")
+	}
+}
+
+func TestParseNodediffMalformed(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  []string
+	}{
+		{"unknown command", []string{";A header", "X3"}},
+		{"bad count", []string{";A header", "Cabc"}},
+		{"add past end", []string{";A header", "A2", "only one line"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseNodediff("NODEDIFF.001", tt.raw)
+			var pe *ParseError
+			if !errors.As(err, &pe) {
+				t.Errorf("ParseNodediff = %v, want *ParseError", err)
+			}
+		})
+	}
+}
+
+func TestGenerateNodediffRandomEdits(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for round := 0; round < 50; round++ {
+		base := make([]string, 1+rng.Intn(60))
+		for i := range base {
+			base[i] = fmt.Sprintf(",%d,line", rng.Intn(20))
+		}
+		target := make([]string, 1+rng.Intn(60))
+		for i := range target {
+			target[i] = fmt.Sprintf(",%d,line", rng.Intn(20))
+		}
+		target[0] += " : 00000"
+
+		diff := GenerateNodediff(base, target)
+		got, err := diff.Apply(base)
+		var crcErr *CRCError
+		if err != nil && !errors.As(err, &crcErr) {
+			t.Fatalf("round %d: Apply: %v", round, err)
+		}
+		if strings.Join(got, "\n") != strings.Join(target, "\n") {
+			t.Fatalf("round %d: Apply(Generate(base, target)) != target", round)
+		}
+	}
+}
@@ -0,0 +1,162 @@
+package parser
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Raw nodelist handling: a nodelist as the exact lines a hub distributed,
+// before any field is interpreted. Everything that has to reproduce or check a
+// file byte for byte - NODEDIFF application, CRC verification - works on this
+// form rather than on parsed nodes, which normalize whitespace and drop
+// comments.
+//
+// Lines are kept as Go strings holding the file's original bytes. Nodelists
+// are CP437/CP866 text, and transcoding them would change the CRC.
+
+// nodelistEOF is the DOS end-of-file marker MakeNL appends after the last line.
+const nodelistEOF = 0x1a
+
+// headerCRCPattern matches the decimal CRC-16 that ends an FTS-5000 header line:
+// ";A Friday, 29 November 2024 -- Day number 334 : 12345".
+var headerCRCPattern = regexp.MustCompile(`:\s*(\d{1,5})\s*$`)
+
+// ReadNodelistLines splits a nodelist into its raw lines. CR/LF and bare LF
+// endings are both accepted and stripped, exactly one of each, as
+// bufio.ScanLines does for ParseFileWithCRC; reading stops at the first ^Z, as
+// every FTN tool does.
+func ReadNodelistLines(r io.Reader) ([]string, error) {
+	reader := bufio.NewReaderSize(r, 64*1024)
+	var lines []string
+	for {
+		line, err := reader.ReadString('\n')
+		if idx := strings.IndexByte(line, nodelistEOF); idx >= 0 {
+			if line = line[:idx]; line != "" {
+				lines = append(lines, trimLineEnding(line))
+			}
+			return lines, nil
+		}
+		if line != "" {
+			lines = append(lines, trimLineEnding(line))
+		}
+		if err == io.EOF {
+			return lines, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// ReadNodelistFile reads a plain or gzipped nodelist file into raw lines.
+func ReadNodelistFile(filePath string) ([]string, error) {
+	reader, closeFunc, err := openFileReader(filePath)
+	if err != nil {
+		return nil, err
+	}
+	defer closeFunc()
+
+	lines, err := ReadNodelistLines(reader)
+	if err != nil {
+		return nil, NewFileError(filePath, "read", "error reading file", err)
+	}
+	return lines, nil
+}
+
+// trimLineEnding strips one LF and then one CR.
+func trimLineEnding(line string) string {
+	return strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+}
+
+// WriteNodelistLines writes raw lines in distribution form: CR/LF after every
+// line and a trailing ^Z.
+func WriteNodelistLines(w io.Writer, lines []string) error {
+	bw := bufio.NewWriter(w)
+	for _, line := range lines {
+		if _, err := bw.WriteString(line); err != nil {
+			return err
+		}
+		if _, err := bw.WriteString("\r\n"); err != nil {
+			return err
+		}
+	}
+	if err := bw.WriteByte(nodelistEOF); err != nil {
+		return err
+	}
+	return bw.Flush()
+}
+
+// NodelistCRC computes the FTS-5000 CRC of a nodelist: CRC-16/XMODEM
+// (polynomial 0x1021, initial value 0) over every line after the first, each
+// terminated by CR/LF. The header line carries the result, so it cannot be
+// part of the input.
+func NodelistCRC(lines []string) uint16 {
+	var crc uint16
+	for i := 1; i < len(lines); i++ {
+		crc = crc16Line(crc, lines[i])
+	}
+	return crc
+}
+
+// crc16Line folds one nodelist line and its CR/LF terminator into crc.
+func crc16Line(crc uint16, line string) uint16 {
+	for i := 0; i < len(line); i++ {
+		crc = crc16Byte(crc, line[i])
+	}
+	crc = crc16Byte(crc, '\r')
+	return crc16Byte(crc, '\n')
+}
+
+// crc16Byte is one step of CRC-16/XMODEM.
+func crc16Byte(crc uint16, b byte) uint16 {
+	crc ^= uint16(b) << 8
+	for i := 0; i < 8; i++ {
+		if crc&0x8000 != 0 {
+			crc = crc<<1 ^ 0x1021
+		} else {
+			crc <<= 1
+		}
+	}
+	return crc
+}
+
+// HeaderCRC extracts the CRC a nodelist's first line declares. ok is false
+// when the line carries none, which is normal for pre-1986 lists and for some
+// othernet nodelists.
+func HeaderCRC(headerLine string) (crc uint16, ok bool) {
+	m := headerCRCPattern.FindStringSubmatch(headerLine)
+	if m == nil {
+		return 0, false
+	}
+	v, err := strconv.ParseUint(m[1], 10, 16)
+	if err != nil {
+		return 0, false
+	}
+	return uint16(v), true
+}
+
+// VerifyNodelistCRC checks a nodelist's computed CRC against its header. A
+// header without a CRC verifies trivially; a mismatch is a *CRCError.
+func VerifyNodelistCRC(source string, lines []string) error {
+	if len(lines) == 0 {
+		return NewParseError(source, 0, "empty nodelist")
+	}
+	declared, ok := HeaderCRC(lines[0])
+	if !ok {
+		return nil
+	}
+	if computed := NodelistCRC(lines); computed != declared {
+		return NewCRCError(source, declared, computed)
+	}
+	return nil
+}
+
+// HeaderDate derives the nodelist date and day number from a header line, using
+// the same patterns ParseFileWithCRC applies to the ";A" lines of a full file.
+func (p *Parser) HeaderDate(headerLine string) (time.Time, int, error) {
+	return p.extractDateFromLine(headerLine)
+}
@@ -0,0 +1,96 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// Rebuilding a nodelist from the database.
+//
+// Since line numbers are recorded, an imported nodelist is stored as two
+// disjoint halves: node rows, each knowing its line, and the NodelistLine rows
+// holding every other line verbatim. Merging them by line number gives the
+// file back byte for byte. Nodelists imported before that carry no text lines
+// and no line numbers; they can only be approximated, from the node rows in
+// address order under a synthesized header.
+
+// RebuildNodelist assembles a nodelist's raw lines from its stored node rows
+// and text lines. exact is false when the date predates line-number
+// recording and the result is an approximation; header is then used as the
+// first line and the CRC is computed for it.
+//
+// A rebuild from stored lines is checked against the CRC its own header
+// declares. When they differ, lines were lost, trimmed or duplicated on the
+// way into the database; the header is left declaring the original CRC, so
+// the damage shows, and exact is false.
+//
+// Node rows must arrive in the order the approximation should use; rows with
+// a line number are placed by it regardless.
+func RebuildNodelist(nodes, text []database.NodelistLine, header string) (lines []string, exact bool) {
+	if len(text) == 0 {
+		lines = make([]string, 0, len(nodes)+1)
+		lines = append(lines, header)
+		for _, n := range nodes {
+			lines = append(lines, n.RawLine)
+		}
+		return SetHeaderCRC(lines), false
+	}
+
+	// A text line overrides a node row on the same line: it is the exact
+	// text where raw_line was stored trimmed.
+	byLine := make(map[int]string, len(nodes)+len(text))
+	for _, n := range nodes {
+		byLine[n.LineNumber] = n.RawLine
+	}
+	for _, t := range text {
+		byLine[t.LineNumber] = t.RawLine
+	}
+
+	numbers := make([]int, 0, len(byLine))
+	for num := range byLine {
+		numbers = append(numbers, num)
+	}
+	sort.Ints(numbers)
+
+	lines = make([]string, 0, len(numbers))
+	for _, num := range numbers {
+		lines = append(lines, byLine[num])
+	}
+	if declared, ok := HeaderCRC(lines[0]); ok && declared != NodelistCRC(lines) {
+		return lines, false
+	}
+	return lines, true
+}
+
+// SetHeaderCRC rewrites the CRC a nodelist's header declares to the CRC of its
+// lines, keeping the header's digit width, and returns lines. A header that
+// already declares the right CRC is left untouched, and so is one declaring
+// none at all: pre-1986 nodelists had no CRC, and inventing one would change
+// the file it is meant to reproduce.
+func SetHeaderCRC(lines []string) []string {
+	if len(lines) == 0 {
+		return lines
+	}
+	loc := headerCRCPattern.FindStringSubmatchIndex(lines[0])
+	if loc == nil {
+		return lines
+	}
+	computed := NodelistCRC(lines)
+	if declared, ok := HeaderCRC(lines[0]); ok && declared == computed {
+		return lines
+	}
+	start, end := loc[2], loc[3]
+	lines[0] = lines[0][:start] + fmt.Sprintf("%0*d", end-start, computed) + lines[0][end:]
+	return lines
+}
+
+// SyntheticHeader returns an FTS-5000 header line for a nodelist whose own
+// header was not stored, in MakeNL's wording so the parser dates it again on
+// re-import. The CRC is a placeholder for SetHeaderCRC to fill in.
+func SyntheticHeader(network string, date time.Time, dayNumber int) string {
+	return fmt.Sprintf(";A %s Nodelist for %s, %s %d, %d -- Day number %03d : 00000",
+		network, date.Weekday(), date.Month(), date.Day(), date.Year(), dayNumber)
+}
@@ -0,0 +1,128 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// storedRows splits a parse result into what the database keeps for it.
+func storedRows(result *ParseResult) (nodes, text []database.NodelistLine) {
+	for _, n := range result.Nodes {
+		nodes = append(nodes, database.NodelistLine{LineNumber: n.LineNumber, RawLine: n.RawLine})
+	}
+	return nodes, result.TextLines
+}
+
+func TestRebuildNodelistIsByteExact(t *testing.T) {
+	for _, name := range []string{"nodelist.216", "nodelist.002", "nodelist.276"} {
+		t.Run(name, func(t *testing.T) {
+			path := "../../test_nodelists/" + name
+			want, err := ReadNodelistFile(path)
+			if err != nil {
+				t.Fatalf("ReadNodelistFile: %v", err)
+			}
+			result, err := New(false).ParseFileWithCRC(path)
+			if err != nil {
+				t.Fatalf("ParseFileWithCRC: %v", err)
+			}
+
+			nodes, text := storedRows(result)
+			got, exact := RebuildNodelist(nodes, text, "")
+			if !exact {
+				t.Fatal("RebuildNodelist reported an approximation for a fully stored file")
+			}
+			if len(got) != len(want) {
+				t.Fatalf("rebuilt %d lines, want %d", len(got), len(want))
+			}
+			for i := range want {
+				if got[i] != want[i] {
+					t.Fatalf("line %d = %q, want %q", i+1, got[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestRebuildNodelistFailingItsCRCIsNotExact(t *testing.T) {
+	lines := withHeaderCRC(";A FidoNet Nodelist for Friday, July 1, 2022 -- Day number 182", sampleBody())
+	path := writeNodelist(t, t.TempDir(), "nodelist.182", lines)
+	result, err := New(false).ParseFileWithCRC(path)
+	if err != nil {
+		t.Fatalf("ParseFileWithCRC: %v", err)
+	}
+	nodes, text := storedRows(result)
+
+	tests := map[string][]database.NodelistLine{
+		"a line lost":       nodes[1:],
+		"a line duplicated": append(append([]database.NodelistLine{}, nodes...), database.NodelistLine{LineNumber: 1000, RawLine: nodes[0].RawLine}),
+	}
+	for name, damaged := range tests {
+		got, exact := RebuildNodelist(damaged, text, "")
+		if exact {
+			t.Errorf("%s: reported exact", name)
+		}
+		if got[0] != lines[0] {
+			t.Errorf("%s: header rewritten to %q, want %q", name, got[0], lines[0])
+		}
+	}
+}
+
+func TestRebuildNodelistKeepsUntrimmedNodeLines(t *testing.T) {
+	lines := withHeaderCRC(";A FidoNet Nodelist for Friday, July 1, 2022 -- Day number 182", sampleBody())
+	lines[5] += "  " // trailing blanks a parser trims from raw_line
+	path := writeNodelist(t, t.TempDir(), "nodelist.182", SetHeaderCRC(lines))
+
+	result, err := New(false).ParseFileWithCRC(path)
+	if err != nil {
+		t.Fatalf("ParseFileWithCRC: %v", err)
+	}
+	nodes, text := storedRows(result)
+	got, _ := RebuildNodelist(nodes, text, "")
+	if strings.Join(got, "\n") != strings.Join(lines, "\n") {
+		t.Errorf("rebuilt\n%s\nwant\n%s", strings.Join(got, "\n"), strings.Join(lines, "\n"))
+	}
+}
+
+func TestRebuildNodelistApproximatesLegacyDates(t *testing.T) {
+	date := time.Date(2022, 7, 1, 0, 0, 0, 0, time.UTC)
+	nodes := []database.NodelistLine{
+		{RawLine: "Zone,2,Europe,Somewhere,Zone_Coordinator,-Unpublished-,300,CM"},
+		{RawLine: ",1,Node_One,Moscow,Sysop_One,-Unpublished-,300,IBN"},
+	}
+	got, exact := RebuildNodelist(nodes, nil, SyntheticHeader("FidoNet", date, 182))
+	if exact {
+		t.Error("RebuildNodelist reported an exact rebuild without text lines")
+	}
+	if len(got) != 3 || got[1] != nodes[0].RawLine {
+		t.Fatalf("RebuildNodelist = %q", got)
+	}
+	if err := VerifyNodelistCRC("rebuilt", got); err != nil {
+		t.Errorf("rebuilt header CRC: %v", err)
+	}
+	gotDate, day, err := New(false).HeaderDate(got[0])
+	if err != nil || !gotDate.Equal(date) || day != 182 {
+		t.Errorf("HeaderDate(%q) = %v, %d, %v; want %v, 182", got[0], gotDate, day, err, date)
+	}
+}
+
+func TestSetHeaderCRC(t *testing.T) {
+	lines := withHeaderCRC(";A FidoNet Nodelist for Friday, July 1, 2022 -- Day number 182", sampleBody())
+	header := lines[0]
+	if SetHeaderCRC(lines); lines[0] != header {
+		t.Errorf("correct header rewritten to %q", lines[0])
+	}
+
+	lines[3] += ",XA"
+	SetHeaderCRC(lines)
+	if err := VerifyNodelistCRC("edited", lines); err != nil {
+		t.Errorf("after SetHeaderCRC: %v", err)
+	}
+
+	noCRC := []string{";A FidoNet Nodelist for Friday, January 4, 1985", ",1,Node"}
+	if SetHeaderCRC(noCRC); noCRC[0] != ";A FidoNet Nodelist for Friday, January 4, 1985" {
+		t.Errorf("header without a CRC rewritten to %q", noCRC[0])
+	}
+}
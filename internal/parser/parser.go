@@ -35,6 +35,17 @@ type ParseResult struct {
 	DayNumber     int
 	FileCRC       uint16
 	ProcessedDate time.Time
+
+	// HeaderCRC is the FTS-5000 CRC the first line declares (";A ... : NNNNN"),
+	// valid only when HasHeaderCRC. ComputedCRC is what the file's contents
+	// actually hash to; see CRCVerdict.
+	HeaderCRC    uint16
+	HasHeaderCRC bool
+	ComputedCRC  uint16
+
+	// TextLines are the file's lines that no node row reproduces; with the
+	// nodes' LineNumber they rebuild the file (see RebuildNodelist).
+	TextLines []database.NodelistLine
 }
 
 // NodelistPointSource is the list_source stamped on points extracted from
@@ -210,14 +221,14 @@ func (p *Parser) ParseFileWithCRC(filePath string) (*ParseResult, error) {
 	}
 
 	// Open file and create reader (with gzip support)
-	reader, closeFunc, err := p.openFileReader(filePath)
+	reader, closeFunc, err := openFileReader(filePath)
 	if err != nil {
 		return nil, err
 	}
 	defer closeFunc()
 
 	// Parse the file content
-	nodes, points, nodelistDate, dayNumber, fileCRC, err := p.parseFileContent(reader, filePath, estimatedNodes)
+	nodes, points, nodelistDate, dayNumber, fileCRC, crc, text, err := p.parseFileContent(reader, filePath, estimatedNodes)
 	if err != nil {
 		return nil, err
 	}
@@ -235,6 +246,10 @@ func (p *Parser) ParseFileWithCRC(filePath string) (*ParseResult, error) {
 	for i := range nodes {
 		nodes[i].Domain = domain
 	}
+	for i := range text.lines {
+		text.lines[i].Domain = domain
+		text.lines[i].NodelistDate = nodelistDate
+	}
 
 	// Stamp identity on inline points: the nodelist itself is their source
 	for i := range points {
@@ -263,6 +278,10 @@ func (p *Parser) ParseFileWithCRC(filePath string) (*ParseResult, error) {
 		DayNumber:     dayNumber,
 		FileCRC:       fileCRC,
 		ProcessedDate: time.Now(),
+		HeaderCRC:     crc.header,
+		HasHeaderCRC:  crc.hasHeader,
+		ComputedCRC:   crc.computed,
+		TextLines:     text.lines,
 	}, nil
 }
 
@@ -272,7 +291,7 @@ const MaxDecompressedSize = 500 * 1024 * 1024
 
 // openFileReader opens a file and returns a reader that handles both regular and gzipped files.
 // For gzipped files, decompression is limited to MaxDecompressedSize to prevent gzip bombs.
-func (p *Parser) openFileReader(filePath string) (io.Reader, func(), error) {
+func openFileReader(filePath string) (io.Reader, func(), error) {
 	file, err := os.Open(filePath)
 	if err != nil {
 		return nil, nil, NewFileError(filePath, "open", "failed to open file", err)
@@ -300,8 +319,24 @@ func (p *Parser) openFileReader(filePath string) (io.Reader, func(), error) {
 	return reader, closeFunc, nil
 }
 
+// contentCRC is the CRC state parseFileContent gathers on its single pass.
+type contentCRC struct {
+	header    uint16
+	hasHeader bool
+	computed  uint16
+}
+
+// contentText collects the lines of a file that node rows do not reproduce.
+type contentText struct {
+	lines []database.NodelistLine
+}
+
+func (t *contentText) add(lineNum int, rawLine string) {
+	t.lines = append(t.lines, database.NodelistLine{LineNumber: lineNum, RawLine: rawLine})
+}
+
 // parseFileContent reads and parses the content of a nodelist file.
-func (p *Parser) parseFileContent(reader io.Reader, filePath string, estimatedNodes int) ([]database.Node, []database.Point, time.Time, int, uint16, error) {
+func (p *Parser) parseFileContent(reader io.Reader, filePath string, estimatedNodes int) ([]database.Node, []database.Point, time.Time, int, uint16, contentCRC, contentText, error) {
 	// Pre-allocate nodes slice with estimated capacity for better performance
 	nodes := make([]database.Node, 0, estimatedNodes)
 	scanner := bufio.NewScanner(reader)
@@ -311,6 +346,8 @@ func (p *Parser) parseFileContent(reader io.Reader, filePath string, estimatedNo
 	var dayNumber int
 	var fileCRC uint16
 	var firstNodeLine string
+	var crc contentCRC
+	var text contentText
 	headerParsed := false
 
 	// Inline point collection (CollectPoints): a "Point," line belongs to the
@@ -332,13 +369,28 @@ func (p *Parser) parseFileContent(reader io.Reader, filePath string, estimatedNo
 		rawLine := scanner.Text()
 		line := strings.TrimSpace(rawLine)
 
-		// Check for EOF markers (^Z, Ctrl+Z)
-		if strings.Contains(rawLine, "\x1a") || strings.Contains(rawLine, "\u001a") {
+		// Check for EOF markers (^Z, Ctrl+Z). Whatever precedes the marker on
+		// its line is still part of the file for the CRC.
+		if idx := strings.IndexByte(rawLine, nodelistEOF); idx >= 0 {
+			if lineNum > 1 && idx > 0 {
+				crc.computed = crc16Line(crc.computed, rawLine[:idx])
+			}
+			if idx > 0 {
+				text.add(lineNum, rawLine[:idx])
+			}
 			break
 		}
 
+		// The first line carries the CRC of everything after it
+		if lineNum == 1 {
+			crc.header, crc.hasHeader = HeaderCRC(rawLine)
+		} else {
+			crc.computed = crc16Line(crc.computed, rawLine)
+		}
+
 		// Skip empty lines
 		if line == "" {
+			text.add(lineNum, rawLine)
 			continue
 		}
 
@@ -362,11 +414,13 @@ func (p *Parser) parseFileContent(reader io.Reader, filePath string, estimatedNo
 					fileCRC = uint16(crc)
 				}
 			}
+			text.add(lineNum, rawLine)
 			continue
 		}
 
 		// Skip other comment lines
 		if strings.HasPrefix(line, ";") {
+			text.add(lineNum, rawLine)
 			continue
 		}
 
@@ -382,6 +436,7 @@ func (p *Parser) parseFileContent(reader io.Reader, filePath string, estimatedNo
 		// Inline point line: attach to the immediately preceding node
 		if p.CollectPoints {
 			if fields := strings.Split(line, ","); strings.EqualFold(strings.TrimSpace(fields[0]), "Point") {
+				text.add(lineNum, rawLine)
 				if len(nodes) == 0 {
 					if p.verbose {
 						fmt.Printf("Warning: Point line %d in %s before any node line, skipped\n", lineNum, filepath.Base(filePath))
@@ -422,20 +477,28 @@ func (p *Parser) parseFileContent(reader io.Reader, filePath string, estimatedNo
 			if p.verbose {
 				fmt.Printf("Warning: Failed to parse line %d in %s: %v\n", lineNum, filepath.Base(filePath), err)
 			}
+			text.add(lineNum, rawLine)
 			continue // Skip malformed lines
 		}
 
-		if node != nil {
-			p.trackDuplicates(node, &nodes, &duplicateStats, lineNum, filePath)
-			nodes = append(nodes, *node)
+		if node == nil {
+			text.add(lineNum, rawLine)
+			continue
+		}
+		node.LineNumber = lineNum
+		if node.RawLine != rawLine {
+			// raw_line is stored trimmed; the exact text overrides it on rebuild
+			text.add(lineNum, rawLine)
 		}
+		p.trackDuplicates(node, &nodes, &duplicateStats, lineNum, filePath)
+		nodes = append(nodes, *node)
 	}
 
 	if err := scanner.Err(); err != nil {
-		return nil, nil, time.Time{}, 0, 0, NewFileError(filePath, "read", "error reading file", err)
+		return nil, nil, time.Time{}, 0, 0, contentCRC{}, contentText{}, NewFileError(filePath, "read", "error reading file", err)
 	}
 
-	return nodes, points, nodelistDate, dayNumber, fileCRC, nil
+	return nodes, points, nodelistDate, dayNumber, fileCRC, crc, text, nil
 }
 
 // trackDuplicates checks for and tracks duplicate node entries within a file.
@@ -10,10 +10,12 @@ import (
 
 // defaultPorts contains the standard port numbers for FidoNet internet protocols
 var defaultPorts = map[string]int{
-	"IBN": 24554, // BinkP
-	"ITN": 23,    // Telnet
-	"IFC": 60179, // EMSI over TCP
-	"IFT": 21,    // FTP
+	"IBN":  24554, // BinkP
+	"ITN":  23,    // Telnet
+	"IFC":  60179, // EMSI over TCP
+	"IFT":  21,    // FTP
+	"IBNS": 24553, // BinkP over TLS (binkps)
+	"ITS":  992,   // Telnet over TLS (telnets)
 }
 
 // parseFlagsWithConfig extracts flags and builds structured internet configuration.
@@ -67,8 +69,11 @@ func (p *Parser) parseFlagWithValue(
 	flagValue := strings.TrimSpace(part[colonIndex+1:])
 
 	switch flagName {
-	// Connection protocols
-	case "IBN", "IFC", "ITN", "IVM", "IFT":
+	// Connection protocols. IBNS and ITS are not FTSC flags: they are how
+	// nodes running BinkP and telnet behind TLS announce it, and they get
+	// protocol entries of their own so the plain-text ones keep meaning
+	// plain text.
+	case "IBN", "IFC", "ITN", "IVM", "IFT", "IBNS", "ITS":
 		p.addProtocolDetail(flagName, flagValue, protocols)
 
 	// Default internet address
@@ -123,7 +128,7 @@ func (p *Parser) parseFlagWithoutValue(
 ) {
 	switch part {
 	// Connection protocol flags without values
-	case "IBN", "IFC", "ITN", "IVM", "IFT", "INA", "IP":
+	case "IBN", "IFC", "ITN", "IVM", "IFT", "IBNS", "ITS", "INA", "IP":
 		detail := database.InternetProtocolDetail{}
 		if defaultPort, ok := defaultPorts[part]; ok {
 			detail.Port = defaultPort
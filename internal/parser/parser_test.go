@@ -1174,6 +1174,28 @@ func TestParseFlagsWithConfigJSONContent(t *testing.T) {
 		}
 	})
 
+	t.Run("TLS flags kept apart from the plain ones", func(t *testing.T) {
+		flags, config := p.parseFlagsWithConfig("IBN,IBNS:bbs.example.com,ITS")
+
+		var internetConfig database.InternetConfiguration
+		if err := json.Unmarshal(config, &internetConfig); err != nil {
+			t.Fatalf("failed to unmarshal config: %v", err)
+		}
+
+		if len(flags) != 0 {
+			t.Errorf("flags = %v, want none", flags)
+		}
+		if got := internetConfig.Protocols["IBN"]; len(got) != 1 || got[0].Port != 24554 {
+			t.Errorf("IBN = %+v, want one entry on 24554", got)
+		}
+		if got := internetConfig.Protocols["IBNS"]; len(got) != 1 || got[0].Address != "bbs.example.com" || got[0].Port != 24553 {
+			t.Errorf("IBNS = %+v, want bbs.example.com on 24553", got)
+		}
+		if got := internetConfig.Protocols["ITS"]; len(got) != 1 || got[0].Port != 992 {
+			t.Errorf("ITS = %+v, want one entry on 992", got)
+		}
+	})
+
 	t.Run("info flags in JSON", func(t *testing.T) {
 		_, config := p.parseFlagsWithConfig("INO4,ICM")
 
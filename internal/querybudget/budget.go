@@ -79,8 +79,19 @@ func (b Budget) Wrap(next http.Handler) http.Handler {
 		return next
 	}
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		ctx, cancel := context.WithTimeout(r.Context(), b.d)
+		ctx, cancel := b.Apply(r.Context())
 		defer cancel()
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// Apply is Wrap for a handler that can only pick its budget once it has read
+// the request, as the GraphQL endpoint does. A zero Budget returns ctx
+// unchanged. The same composition rule holds: ctx must not already carry a
+// shorter deadline, or this one is silently ignored.
+func (b Budget) Apply(ctx context.Context) (context.Context, context.CancelFunc) {
+	if b.d <= 0 {
+		return ctx, func() {}
+	}
+	return context.WithTimeout(ctx, b.d)
+}
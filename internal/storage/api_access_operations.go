@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// APIAccessOperations keeps the public API's keys and its usage log (see
+// internal/apiaccess).
+type APIAccessOperations struct {
+	db database.DatabaseInterface
+}
+
+// NewAPIAccessOperations creates a new APIAccessOperations instance
+func NewAPIAccessOperations(db database.DatabaseInterface) *APIAccessOperations {
+	return &APIAccessOperations{db: db}
+}
+
+// GetAPIKeys returns every key in api_keys, disabled ones included: the
+// caller has to tell a revoked key from an unknown one.
+func (a *APIAccessOperations) GetAPIKeys(ctx context.Context) ([]database.APIKey, error) {
+	query := `SELECT key_id, owner, key_hash, requests_per_minute, burst, admin, disabled
+		FROM api_keys FINAL
+		ORDER BY key_id`
+
+	rows, err := a.db.Conn().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API keys: %w", err)
+	}
+	defer rows.Close()
+
+	var keys []database.APIKey
+	for rows.Next() {
+		var k database.APIKey
+		var perMinute, burst uint32
+		if err := rows.Scan(&k.ID, &k.Owner, &k.KeyHash, &perMinute, &burst, &k.Admin, &k.Disabled); err != nil {
+			return nil, fmt.Errorf("failed to scan API key row: %w", err)
+		}
+		k.RequestsPerMinute, k.Burst = int(perMinute), int(burst)
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
+
+// InsertAPIUsage writes one flush of the usage counters.
+func (a *APIAccessOperations) InsertAPIUsage(ctx context.Context, usage []database.APIUsage) error {
+	if len(usage) == 0 {
+		return nil
+	}
+
+	tx, err := a.db.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO api_usage
+		(period, key_id, client_ip, method, route, status, requests, bytes, duration_ms)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare API usage insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, u := range usage {
+		if _, err := stmt.ExecContext(ctx, u.Period, u.KeyID, u.ClientIP, u.Method, u.Route, uint16(u.Status),
+			uint64(u.Requests), uint64(u.Bytes), uint64(u.Duration.Milliseconds())); err != nil {
+			return fmt.Errorf("failed to insert API usage row: %w", err)
+		}
+	}
+	return tx.Commit()
+}
+
+// GetAPIUsage sums api_usage per caller and route over the filter's window,
+// busiest first.
+func (a *APIAccessOperations) GetAPIUsage(ctx context.Context, filter database.APIUsageFilter) ([]database.APIUsageSummary, error) {
+	keyID, anyKey := "", true
+	if filter.KeyID != nil {
+		keyID, anyKey = *filter.KeyID, false
+	}
+	limit := filter.Limit
+	if limit <= 0 {
+		limit = 100
+	}
+
+	query := `SELECT key_id, client_ip, route,
+			sum(requests) AS total,
+			sumIf(requests, status = 429),
+			sumIf(requests, status >= 500),
+			sum(bytes),
+			sum(duration_ms)
+		FROM api_usage
+		WHERE period >= ? AND period < ? AND (? OR key_id = ?)
+		GROUP BY key_id, client_ip, route
+		ORDER BY total DESC, key_id, client_ip, route
+		LIMIT ?`
+
+	rows, err := a.db.Conn().QueryContext(ctx, query, filter.From, filter.To, anyKey, keyID, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query API usage: %w", err)
+	}
+	defer rows.Close()
+
+	var out []database.APIUsageSummary
+	for rows.Next() {
+		var s database.APIUsageSummary
+		var requests, rejected, errors, bytes, durationMs uint64
+		if err := rows.Scan(&s.KeyID, &s.ClientIP, &s.Route, &requests, &rejected, &errors, &bytes, &durationMs); err != nil {
+			return nil, fmt.Errorf("failed to scan API usage row: %w", err)
+		}
+		s.Requests, s.Rejected, s.Errors, s.Bytes = int64(requests), int64(rejected), int64(errors), int64(bytes)
+		if requests > 0 {
+			s.AvgDurationMs = float64(durationMs) / float64(requests)
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+var (
+	diffFrom = time.Date(1996, 3, 1, 0, 0, 0, 0, time.UTC)
+	diffTo   = time.Date(1997, 3, 7, 0, 0, 0, 0, time.UTC)
+)
+
+// diffEntry builds the primary nodelist entry of zone 2 address net/node.
+func diffEntry(date time.Time, region, net, node int, nodeType, system string) database.Node {
+	return database.Node{
+		Zone: 2, Net: net, Node: node, Region: &region, NodelistDate: date,
+		NodeType: nodeType, SystemName: system, SysopName: "Sysop_" + system,
+	}
+}
+
+// diffFixture is region 50 and 46 of zone 2 on two dates: net 5020 gains a
+// node, loses one and sees one renamed, and net 5030 moves from region 50 to
+// region 46 unchanged.
+func diffFixture() []database.Node {
+	return []database.Node{
+		diffEntry(diffFrom, 46, 46, 0, "Region", "R46_Old"),
+		diffEntry(diffTo, 46, 46, 0, "Region", "R46"),
+		diffEntry(diffFrom, 50, 5020, 0, "Host", "Moscow_Host"),
+		diffEntry(diffTo, 50, 5020, 0, "Host", "Moscow_Host"),
+		diffEntry(diffFrom, 50, 5020, 1, "", "Gone"),
+		diffEntry(diffFrom, 50, 5020, 2, "", "Old_Name"),
+		diffEntry(diffTo, 50, 5020, 2, "", "New_Name"),
+		diffEntry(diffTo, 50, 5020, 3, "", "Newcomer"),
+		diffEntry(diffFrom, 50, 5030, 0, "Host", "Moved"),
+		diffEntry(diffTo, 46, 5030, 0, "Host", "Moved"),
+	}
+}
+
+func TestDiffBrowseGroups(t *testing.T) {
+	so := &SearchOperations{}
+	diff := so.diffBrowse(diffFrom, diffTo, BrowseDiffScope{Level: BrowseLevelRegions, Zone: 2}, "fidonet", diffFixture())
+
+	if want := (BrowseDiffCounts{Added: 1, Removed: 1, Modified: 2, Unchanged: 2}); diff.Totals != want {
+		t.Errorf("totals = %+v, want %+v", diff.Totals, want)
+	}
+	want := []BrowseDiffGroup{
+		{Number: 46, Name: "R46", FromNodes: 1, ToNodes: 2, BrowseDiffCounts: BrowseDiffCounts{Added: 1, Modified: 1}},
+		{Number: 50, FromNodes: 4, ToNodes: 3, BrowseDiffCounts: BrowseDiffCounts{Added: 1, Removed: 2, Modified: 1, Unchanged: 1}},
+	}
+	if !reflect.DeepEqual(diff.Groups, want) {
+		t.Errorf("groups =\n%+v\nwant\n%+v", diff.Groups, want)
+	}
+	if diff.Changes != nil {
+		t.Errorf("regions level listed %d node changes", len(diff.Changes))
+	}
+}
+
+func TestDiffBrowseNodes(t *testing.T) {
+	var net5020 []database.Node
+	for _, n := range diffFixture() {
+		if n.Net == 5020 {
+			net5020 = append(net5020, n)
+		}
+	}
+	so := &SearchOperations{}
+	diff := so.diffBrowse(diffFrom, diffTo, BrowseDiffScope{Level: BrowseLevelNodes, Zone: 2, Net: 5020}, "fidonet", net5020)
+
+	var got []string
+	for _, c := range diff.Changes {
+		node := c.NewNode
+		if node == nil {
+			node = c.OldNode
+		}
+		got = append(got, c.ChangeType+" "+node.SystemName)
+		if !c.Date.Equal(diffTo) {
+			t.Errorf("%s change dated %v, want %v", node.SystemName, c.Date, diffTo)
+		}
+	}
+	if want := []string{"removed Gone", "modified New_Name", "added Newcomer"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("changes = %q, want %q", got, want)
+	}
+	if name := diff.Changes[1].Changes["name"]; name != "Old_Name → New_Name" {
+		t.Errorf("modified node's name change = %q", name)
+	}
+	if diff.Totals.Unchanged != 1 || diff.Groups != nil {
+		t.Errorf("totals = %+v, groups = %v", diff.Totals, diff.Groups)
+	}
+}
+
+func TestDiffBrowseSameDate(t *testing.T) {
+	var nodes []database.Node
+	for _, n := range diffFixture() {
+		if n.NodelistDate.Equal(diffTo) {
+			nodes = append(nodes, n)
+		}
+	}
+	so := &SearchOperations{}
+	diff := so.diffBrowse(diffTo, diffTo, BrowseDiffScope{Level: BrowseLevelZones}, "fidonet", nodes)
+	if want := (BrowseDiffCounts{Unchanged: len(nodes)}); diff.Totals != want {
+		t.Errorf("a nodelist compared with itself: totals = %+v, want %+v", diff.Totals, want)
+	}
+}
@@ -49,6 +49,13 @@ func (cs *CachedStorage) GetNodeChanges(ctx context.Context, zone, net, node int
 	})
 }
 
+// GetNodeTenancies with caching
+func (cs *CachedStorage) GetNodeTenancies(ctx context.Context, zone, net, node int, domain string) ([]NodeTenancy, error) {
+	return cachedFetch(cs, cs.keyGen.NodeTenanciesKey(zone, net, node, domain), cs.config.NodeTTL, func() ([]NodeTenancy, error) {
+		return cs.Storage.NodeTenancyOps().GetNodeTenancies(ctx, zone, net, node, domain)
+	})
+}
+
 // GetUniqueSysops with caching
 func (cs *CachedStorage) GetUniqueSysops(ctx context.Context, nameFilter string, limit, offset int) ([]SysopInfo, error) {
 	return cachedFetch(cs, cs.keyGen.UniqueSysopsKey(nameFilter, limit, offset), cs.config.SearchTTL, func() ([]SysopInfo, error) {
@@ -63,6 +70,27 @@ func (cs *CachedStorage) GetNodesBySysop(ctx context.Context, sysopName string,
 	})
 }
 
+// FuzzySearchSysops with caching
+func (cs *CachedStorage) FuzzySearchSysops(ctx context.Context, name string, limit, offset int) ([]SysopInfo, error) {
+	return cachedFetch(cs, cs.keyGen.FuzzySysopsKey(name, limit, offset), cs.config.SearchTTL, func() ([]SysopInfo, error) {
+		return cs.Storage.SearchOps().FuzzySearchSysops(ctx, name, limit, offset)
+	})
+}
+
+// GetSysopIdentity with caching
+func (cs *CachedStorage) GetSysopIdentity(ctx context.Context, id string) (*SysopIdentity, error) {
+	return cachedFetchPtr(cs, cs.keyGen.SysopIdentityKey(id), cs.config.SearchTTL, func() (*SysopIdentity, error) {
+		return cs.Storage.SysopIdentityOps().GetSysopIdentity(ctx, id)
+	})
+}
+
+// GetNodeSysopIdentities with caching
+func (cs *CachedStorage) GetNodeSysopIdentities(ctx context.Context, zone, net, node int, domain string) ([]SysopIdentityRef, error) {
+	return cachedFetch(cs, cs.keyGen.NodeSysopIdentitiesKey(zone, net, node, domain), cs.config.SearchTTL, func() ([]SysopIdentityRef, error) {
+		return cs.Storage.SysopIdentityOps().GetNodeSysopIdentities(ctx, zone, net, node, domain)
+	})
+}
+
 // Pass-through methods (not cached)
 
 // GetNodeDateRange returns the first and last date a node appears in nodelists
@@ -77,6 +105,13 @@ func (cs *CachedStorage) SearchNodesBySysop(ctx context.Context, sysopName strin
 	return cs.Storage.SearchOps().SearchNodesBySysop(ctx, sysopName, limit, domain)
 }
 
+// SearchNodesBySysopFuzzy searches for nodes by a sysop name spelled any of
+// several ways
+func (cs *CachedStorage) SearchNodesBySysopFuzzy(ctx context.Context, sysopName string, limit int, domain string) ([]NodeSummary, error) {
+	// Not cached, like SearchNodesBySysop
+	return cs.Storage.SearchOps().SearchNodesBySysopFuzzy(ctx, sysopName, limit, domain)
+}
+
 // SearchNodesWithLifetime searches for nodes with lifetime information
 func (cs *CachedStorage) SearchNodesWithLifetime(ctx context.Context, filter database.NodeFilter) ([]NodeSummary, error) {
 	// Not cached as this is similar to GetNodes but with extra processing
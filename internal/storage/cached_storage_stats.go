@@ -2,6 +2,7 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"time"
 
 	"github.com/nodelistdb/internal/database"
@@ -54,6 +55,33 @@ func (cs *CachedStorage) CountNodes(ctx context.Context, date time.Time, domain
 	})
 }
 
+// GetNodeMap with caching. Like the node count it answers for one nodelist
+// date; a geocoding run shows up once the entry expires.
+func (cs *CachedStorage) GetNodeMap(ctx context.Context, date time.Time, domain string) (*NodeMap, error) {
+	return cachedFetch(cs, cs.keyGen.StatsKey(date)+":map:"+domain, cs.config.StatsTTL, func() (*NodeMap, error) {
+		return cs.Storage.LocationGeocodeOps().GetNodeMap(ctx, date, domain)
+	})
+}
+
+// GetBrowseDiff with caching. The key hangs off the later date's stats key:
+// both dates are imported nodelists, and an import evicts the stats.
+func (cs *CachedStorage) GetBrowseDiff(ctx context.Context, from, to time.Time, scope BrowseDiffScope, domain string) (*BrowseDiff, error) {
+	key := fmt.Sprintf("%s:diff:%s:%s:%d:%d:%d:%s", cs.keyGen.StatsKey(to), from.Format("2006-01-02"),
+		scope.Level, scope.Zone, scope.Region, scope.Net, domain)
+	return cachedFetch(cs, key, cs.config.StatsTTL, func() (*BrowseDiff, error) {
+		return cs.Storage.SearchOps().GetBrowseDiff(ctx, from, to, scope, domain)
+	})
+}
+
+// GetNodelistFiles returns the nodelist CRC registry (cached). Like the
+// dates above it only changes on import.
+func (cs *CachedStorage) GetNodelistFiles(ctx context.Context, domain string) ([]database.NodelistFile, error) {
+	key := cs.keyGen.StatsKey(time.Time{}) + ":nodelist-files:" + domain
+	return cachedFetchSlice(cs, key, cs.config.StatsTTL, func() ([]database.NodelistFile, error) {
+		return cs.Storage.NodeOps().GetNodelistFiles(ctx, domain)
+	})
+}
+
 // Pass-through methods (not cached)
 
 // IsNodelistProcessed checks if a nodelist for a specific date has been processed
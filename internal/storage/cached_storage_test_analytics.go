@@ -66,6 +66,13 @@ func (cs *CachedStorage) GetVModemUnconfirmedNodes(ctx context.Context, limit in
 	})
 }
 
+// GetBinkPSCertificateIssues returns nodes whose binkps certificate is self-signed or expired (cached)
+func (cs *CachedStorage) GetBinkPSCertificateIssues(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error) {
+	return cachedFetchSlice(cs, cs.analyticsKey("binkps:certificates", limit, days, includeZeroNodes, domain), cs.config.TestAnalyticsTTL, func() ([]NodeTestResult, error) {
+		return cs.Storage.GetBinkPSCertificateIssues(ctx, limit, days, includeZeroNodes, domain)
+	})
+}
+
 // GetFTPEnabledNodes returns nodes with working FTP protocol (cached)
 func (cs *CachedStorage) GetFTPEnabledNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error) {
 	return cachedFetchSlice(cs, cs.analyticsKey("ftp:enabled", limit, days, includeZeroNodes, domain), cs.config.TestAnalyticsTTL, func() ([]NodeTestResult, error) {
@@ -94,6 +101,13 @@ func (cs *CachedStorage) GetBinkdDetailedStats(ctx context.Context, days int, do
 	})
 }
 
+// GetBinkPOptionSupport returns the verdicts on advertised BinkP options per mailer (cached)
+func (cs *CachedStorage) GetBinkPOptionSupport(ctx context.Context, days int, domain string) (*BinkPOptionSupport, error) {
+	return cachedFetchPtr(cs, cs.analyticsKey("binkp:options", days, domain), cs.config.AnalyticsTTL, func() (*BinkPOptionSupport, error) {
+		return cs.Storage.GetBinkPOptionSupport(ctx, days, domain)
+	})
+}
+
 // GetIPv6WeeklyNews returns weekly IPv6 connectivity changes (cached)
 // This is accessed via GetIPv6WeeklyNews(domain) in handlers,
 // but we provide a direct cached wrapper for it
@@ -131,6 +145,13 @@ func (cs *CachedStorage) GetGeoHostingDistribution(ctx context.Context, days int
 	})
 }
 
+// GetUnreachableByASN returns unreachable nodes grouped by last-hop ASN (cached)
+func (cs *CachedStorage) GetUnreachableByASN(ctx context.Context, days int, domain string) ([]UnreachableASNStats, error) {
+	return cachedFetchSlice(cs, cs.analyticsKey("geo:unreachable-asn", days, domain), cs.config.LongAnalyticsTTL, func() ([]UnreachableASNStats, error) {
+		return cs.Storage.GetUnreachableByASN(ctx, days, domain)
+	})
+}
+
 // GetNodesByCountry returns nodes for a specific country (cached)
 func (cs *CachedStorage) GetNodesByCountry(ctx context.Context, countryCode string, days int, domain string) ([]NodeTestResult, error) {
 	return cachedGeoDrilldown(cs, cs.analyticsKey("geo:country:v2", countryCode, days, domain), func() ([]NodeTestResult, error) {
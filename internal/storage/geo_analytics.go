@@ -190,6 +190,67 @@ func (gao *GeoAnalyticsOperations) GetGeoHostingDistribution(ctx context.Context
 	}, nil
 }
 
+// GetUnreachableByASN groups the nodes that are down, going by their latest
+// direct test, by the network their path probe last got an answer from.
+// Nodes probed before the probe existed, or whose last hop has no known
+// ASN, are left out. An empty domain means all FTN networks.
+func (gao *GeoAnalyticsOperations) GetUnreachableByASN(ctx context.Context, days int, domain string) ([]UnreachableASNStats, error) {
+	gao.mu.RLock()
+	defer gao.mu.RUnlock()
+
+	domainFilter := domainFilterSQL(domain, "")
+	query := fmt.Sprintf(`
+		SELECT
+			path_last_hop_asn,
+			any(path_last_hop_as) AS as_name,
+			count() AS nodes,
+			countIf(path_verdict = 'host-down') AS host_down,
+			countIf(path_verdict = 'path-broken') AS path_broken
+		FROM (
+			SELECT
+				domain, zone, net, node,
+				argMax(is_operational, test_time) AS operational,
+				argMax(path_verdict, test_time) AS path_verdict,
+				argMax(path_last_hop_asn, test_time) AS path_last_hop_asn,
+				argMax(path_last_hop_as, test_time) AS path_last_hop_as
+			FROM node_test_results
+			WHERE test_date >= today() - ?
+				AND derived_from_address = ''
+				{{NODELIST_GATE}}
+				%s
+			GROUP BY domain, zone, net, node
+			HAVING NOT operational
+				AND path_verdict IN ('host-down', 'path-broken')
+				AND path_last_hop_asn != 0
+		) AS latest_unreachable_nodes
+		GROUP BY path_last_hop_asn
+		ORDER BY nodes DESC, path_last_hop_asn
+		LIMIT 50
+	`, domainFilter)
+	query = applyNodelistGate(query, "", domainFilter, "")
+
+	rows, err := gao.db.Conn().QueryContext(ctx, query, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query unreachable nodes by ASN: %w", err)
+	}
+	defer rows.Close()
+
+	stats := []UnreachableASNStats{}
+	for rows.Next() {
+		var st UnreachableASNStats
+		var nodes, hostDown, pathBroken uint64
+		if err := rows.Scan(&st.ASN, &st.Name, &nodes, &hostDown, &pathBroken); err != nil {
+			return nil, fmt.Errorf("failed to scan unreachable nodes by ASN: %w", err)
+		}
+		st.Nodes, st.HostDown, st.PathBroken = int(nodes), int(hostDown), int(pathBroken)
+		stats = append(stats, st)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read unreachable nodes by ASN: %w", err)
+	}
+	return stats, nil
+}
+
 // GetNodesByCountry returns all operational nodes for a specific country.
 // An empty domain means all FTN networks; otherwise results are scoped to that network.
 func (gao *GeoAnalyticsOperations) GetNodesByCountry(ctx context.Context, countryCode string, days int, domain string) ([]NodeTestResult, error) {
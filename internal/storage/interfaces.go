@@ -25,6 +25,7 @@ import (
 type Operations interface {
 	// Node operations
 	GetNodes(ctx context.Context, filter database.NodeFilter) ([]database.Node, error)
+	StreamNodes(ctx context.Context, filter database.NodeFilter, fn func(database.Node) error) error
 	GetNodeHistory(ctx context.Context, zone, net, node int, domain string) ([]database.Node, error)
 	GetNodeDateRange(ctx context.Context, zone, net, node int, domain string) (firstDate, lastDate time.Time, err error)
 	GetNodeDomains(ctx context.Context, zone, net, node int) ([]string, error)
@@ -35,6 +36,7 @@ type Operations interface {
 	GetPointsByBoss(ctx context.Context, domain string, zone, net, node int, asOf *time.Time) ([]database.Point, error)
 	GetPointHistory(ctx context.Context, domain string, zone, net, node, point int) ([]database.Point, error)
 	SearchPoints(ctx context.Context, filter database.PointFilter) ([]database.Point, error)
+	StreamPoints(ctx context.Context, filter database.PointFilter, fn func(database.Point) error) error
 	SearchPointsWithLifetime(ctx context.Context, filter database.PointFilter) ([]PointSummary, error)
 	GetPointStats(ctx context.Context, domain string, asOf *time.Time) (*PointStats, error)
 	GetPointCountsByNet(ctx context.Context, domain string, zone, net int, asOf *time.Time) (map[int]uint64, error)
@@ -46,8 +48,13 @@ type Operations interface {
 	// Search operations
 	SearchNodesBySysop(ctx context.Context, sysopName string, limit int, domain string) ([]NodeSummary, error)
 	GetNodeChanges(ctx context.Context, zone, net, node int, domain string) ([]database.NodeChange, error)
+	GetNodeTenancies(ctx context.Context, zone, net, node int, domain string) ([]NodeTenancy, error)
 	GetUniqueSysops(ctx context.Context, nameFilter string, limit, offset int) ([]SysopInfo, error)
 	GetNodesBySysop(ctx context.Context, sysopName string, limit int) ([]database.Node, error)
+	FuzzySearchSysops(ctx context.Context, name string, limit, offset int) ([]SysopInfo, error)
+	SearchNodesBySysopFuzzy(ctx context.Context, sysopName string, limit int, domain string) ([]NodeSummary, error)
+	GetSysopIdentity(ctx context.Context, id string) (*SysopIdentity, error)
+	GetNodeSysopIdentities(ctx context.Context, zone, net, node int, domain string) ([]SysopIdentityRef, error)
 	SearchNodesWithLifetime(ctx context.Context, filter database.NodeFilter) ([]NodeSummary, error)
 
 	// Analytics operations
@@ -61,16 +68,21 @@ type Operations interface {
 	GetAvailableDates(ctx context.Context, domain string) ([]time.Time, error)
 	GetNearestAvailableDate(ctx context.Context, requestedDate time.Time, domain string) (time.Time, error)
 	GetNodeCountHistory(ctx context.Context, domain string) ([]NodeCountByDate, error)
+	GetNodeMap(ctx context.Context, date time.Time, domain string) (*NodeMap, error)
 
 	// Hierarchy browser operations
 	GetBrowseZones(ctx context.Context, date time.Time, domain string) ([]BrowseZone, error)
 	GetBrowseRegions(ctx context.Context, date time.Time, zone int, domain string) ([]BrowseRegion, error)
 	GetBrowseNets(ctx context.Context, date time.Time, zone, region int, domain string) ([]BrowseNet, error)
 	GetBrowseNodes(ctx context.Context, date time.Time, zone, net int, domain string) ([]database.Node, error)
+	GetBrowseDiff(ctx context.Context, from, to time.Time, scope BrowseDiffScope, domain string) (*BrowseDiff, error)
 
 	// Test operations
 	GetNodeTestHistory(ctx context.Context, zone, net, node int, days int, domain string) ([]NodeTestResult, error)
+	StreamTestResults(ctx context.Context, filter database.NodeFilter, fn func(NodeTestResult) error) error
 	GetDetailedTestResult(ctx context.Context, zone, net, node int, testTime string, domain string) (*NodeTestResult, error)
+	GetTestTranscripts(ctx context.Context, zone, net, node int, testTime string, domain string) ([]TestTranscript, error)
+	GetVantageResults(ctx context.Context, zone, net, node int, testTime string, domain string) ([]VantageResult, error)
 	GetNodeReachabilityStats(ctx context.Context, zone, net, node int, days int, domain string) (*NodeReachabilityStats, error)
 	GetReachabilityTrends(ctx context.Context, days int, domain string) ([]ReachabilityTrend, error)
 	GetReachabilityTrendsAllTime(ctx context.Context, domain string) ([]ReachabilityTrend, error)
@@ -86,6 +98,7 @@ type Operations interface {
 	GetTelnetEnabledNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error)
 	GetVModemEnabledNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error)
 	GetVModemUnconfirmedNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error)
+	GetBinkPSCertificateIssues(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error)
 	GetFTPEnabledNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error)
 	GetAKAMismatchNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error)
 	GetIPv6IncorrectIPv4CorrectNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]AKAIPVersionMismatchNode, error)
@@ -95,7 +108,9 @@ type Operations interface {
 	GetBinkPSoftwareDistribution(ctx context.Context, days int, domain string) (*SoftwareDistribution, error)
 	GetIFCICOSoftwareDistribution(ctx context.Context, days int, domain string) (*SoftwareDistribution, error)
 	GetBinkdDetailedStats(ctx context.Context, days int, domain string) (*SoftwareDistribution, error)
+	GetBinkPOptionSupport(ctx context.Context, days int, domain string) (*BinkPOptionSupport, error)
 	GetGeoHostingDistribution(ctx context.Context, days int, domain string) (*GeoHostingDistribution, error)
+	GetUnreachableByASN(ctx context.Context, days int, domain string) ([]UnreachableASNStats, error)
 	GetNodesByCountry(ctx context.Context, countryCode string, days int, domain string) ([]NodeTestResult, error)
 	GetNodesByProvider(ctx context.Context, provider string, days int, domain string) ([]NodeTestResult, error)
 	GetOnThisDayNodes(ctx context.Context, month, day, limit int, activeOnly bool, domain string) ([]OnThisDayNode, error)
@@ -123,6 +138,8 @@ type Operations interface {
 	FindConflictingNode(zone, net, node int, date time.Time, domain string) (bool, error)
 	GetMaxNodelistDate(ctx context.Context, domain string) (time.Time, error)
 	GetDomains(ctx context.Context) ([]DomainInfo, error)
+	GetNodelistFiles(ctx context.Context, domain string) ([]database.NodelistFile, error)
+	GetNodelistLines(ctx context.Context, domain string, date time.Time) (nodes, text []database.NodelistLine, err error)
 
 	// Lifecycle
 	Close() error
@@ -138,6 +155,7 @@ type QueryBuilderInterface interface {
 	InsertNodesInChunks(db database.DatabaseInterface, nodes []database.Node) error
 	BuildNodesQuery(filter database.NodeFilter) (string, []interface{})
 	BuildFTSQuery(filter database.NodeFilter) (string, []interface{}, bool)
+	BuildNodesExportQuery(filter database.NodeFilter) (string, []interface{})
 
 	// Statistics queries
 	StatsSQL() string
@@ -152,11 +170,13 @@ type QueryBuilderInterface interface {
 	BrowseRegionsSQL() string
 	BrowseNetsSQL() string
 	BrowseNodesSQL() string
+	BrowseDiffNodesSQL(level string) string
 
 	// Node-specific queries
 	NodeHistorySQL() string
 	NodeDateRangeSQL() string
 	SysopSearchSQL() string
+	SysopSearchByNamesSQL() string
 	NodeSummarySearchSQL(activeOnly bool) string
 
 	// Utility queries
@@ -171,9 +191,24 @@ type QueryBuilderInterface interface {
 	ConsecutiveNodelistCheckSQL() string
 	NextNodelistDateSQL() string
 
+	// Nodelist CRC registry
+	RegisterNodelistFileSQL() string
+	NodelistFilesSQL() string
+
+	// Nodelist rebuild
+	InsertNodelistLineSQL() string
+	NodelistNodeLinesSQL() string
+	NodelistTextLinesSQL() string
+
+	// Change notifications
+	NodelistNodesSQL() string
+	InsertNotificationDeliverySQL() string
+
 	// Sysop queries
 	UniqueSysopsWithFilterSQL() string
 	UniqueSysopsSQL() string
+	UniqueSysopsByNamesSQL() string
+	SysopNamesSQL() string
 
 	// Analytics queries
 	FlagFirstAppearanceSQL() string
@@ -0,0 +1,167 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/geocode"
+)
+
+// LocationGeocodeOperations resolves the Location field of nodelist entries
+// with the bundled gazetteer and maps the nodes of any nodelist by it.
+//
+// Coordinates are stored once per distinct location string, not per node:
+// a few tens of thousands of strings cover the whole history, and each
+// nodelist date's map joins its nodes to them. Every stored row records the
+// gazetteer version it was resolved with, so a new gazetteer re-resolves
+// everything on the next run, unresolved strings included.
+type LocationGeocodeOperations struct {
+	db database.DatabaseInterface
+}
+
+// NewLocationGeocodeOperations creates a new LocationGeocodeOperations instance
+func NewLocationGeocodeOperations(db database.DatabaseInterface) *LocationGeocodeOperations {
+	return &LocationGeocodeOperations{db: db}
+}
+
+// GeocodeLocations resolves every location listed in the database that has
+// not been resolved with g's gazetteer yet.
+func (lo *LocationGeocodeOperations) GeocodeLocations(ctx context.Context, g *geocode.Geocoder) (*GeocodeRun, error) {
+	rows, err := lo.db.Conn().QueryContext(ctx, `
+		SELECT DISTINCT location
+		FROM nodes
+		WHERE location != ''
+			AND location NOT IN (SELECT location FROM location_geocodes FINAL WHERE gazetteer = ?)`,
+		g.Version())
+	if err != nil {
+		return nil, fmt.Errorf("failed to query locations to geocode: %w", err)
+	}
+	var locations []string
+	for rows.Next() {
+		var loc string
+		if err := rows.Scan(&loc); err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("failed to scan location: %w", err)
+		}
+		locations = append(locations, loc)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating locations: %w", err)
+	}
+
+	run := &GeocodeRun{
+		Locations:  len(locations),
+		Gazetteer:  g.Version(),
+		GeocodedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	if len(locations) == 0 {
+		return run, nil
+	}
+
+	tx, err := lo.db.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO location_geocodes
+		(location, place, country_code, country, latitude, longitude, precision, gazetteer, geocoded_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to prepare location geocode insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, loc := range locations {
+		r, ok := g.Resolve(loc)
+		switch {
+		case !ok:
+			run.Unresolved++
+		case r.Precision == geocode.PrecisionCity:
+			run.City++
+		case r.Precision == geocode.PrecisionRegion:
+			run.Region++
+		default:
+			run.Country++
+		}
+		var country string
+		if ok {
+			country = g.CountryName(r.CountryCode)
+		}
+		if _, err := stmt.ExecContext(ctx, loc, r.Place, r.CountryCode, country,
+			r.Lat, r.Lon, string(r.Precision), run.Gazetteer, run.GeocodedAt); err != nil {
+			return nil, fmt.Errorf("failed to insert location geocode: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit location geocodes: %w", err)
+	}
+	return run, nil
+}
+
+// GetNodeMap places the nodes listed on date by their geocoded locations.
+// An empty domain covers every network.
+func (lo *LocationGeocodeOperations) GetNodeMap(ctx context.Context, date time.Time, domain string) (*NodeMap, error) {
+	rows, err := lo.db.Conn().QueryContext(ctx, `
+		SELECT g.place, g.country_code, g.country, g.latitude, g.longitude, g.precision, count() AS nodes
+		FROM nodes n
+		LEFT JOIN (
+			SELECT location, place, country_code, country, latitude, longitude, precision
+			FROM location_geocodes FINAL
+		) g ON n.location = g.location
+		WHERE n.nodelist_date = ? AND (? = '' OR n.domain = ?)
+		GROUP BY g.place, g.country_code, g.country, g.latitude, g.longitude, g.precision
+		ORDER BY nodes DESC, g.place`,
+		date, domain, domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node map: %w", err)
+	}
+	defer rows.Close()
+
+	m := &NodeMap{Date: date, Domain: domain, Places: []MapPlace{}}
+	countries := make(map[string]*MapCountry)
+	for rows.Next() {
+		var (
+			p       MapPlace
+			country string
+			nodes   uint64
+		)
+		if err := rows.Scan(&p.Place, &p.CountryCode, &country, &p.Latitude, &p.Longitude, &p.Precision, &nodes); err != nil {
+			return nil, fmt.Errorf("failed to scan node map place: %w", err)
+		}
+		p.Nodes = int(nodes)
+		if p.Precision == "" {
+			// not geocoded yet, or geocoded without a result
+			m.Unlocated += p.Nodes
+			continue
+		}
+		m.Located += p.Nodes
+		m.Places = append(m.Places, p)
+		c, ok := countries[p.CountryCode]
+		if !ok {
+			c = &MapCountry{CountryCode: p.CountryCode, Country: country}
+			countries[p.CountryCode] = c
+		}
+		c.Nodes += p.Nodes
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating node map places: %w", err)
+	}
+
+	m.Countries = make([]MapCountry, 0, len(countries))
+	for _, c := range countries {
+		m.Countries = append(m.Countries, *c)
+	}
+	sort.Slice(m.Countries, func(i, j int) bool {
+		if m.Countries[i].Nodes != m.Countries[j].Nodes {
+			return m.Countries[i].Nodes > m.Countries[j].Nodes
+		}
+		return m.Countries[i].Country < m.Countries[j].Country
+	})
+	return m, nil
+}
@@ -93,6 +93,40 @@ func (no *NodeOperations) GetNodes(ctx context.Context, filter database.NodeFilt
 	return nodes, nil
 }
 
+// StreamNodes calls fn for every node row matching filter, in sorting-key
+// order, without collecting them; see BuildNodesExportQuery for the shape. An
+// error from fn stops the stream and is returned as is.
+//
+// It does not take no.mu. An export can run for minutes, and a writer queued
+// behind a held read lock blocks every reader that arrives after it - one
+// bulk download would stall the whole process's node reads.
+func (no *NodeOperations) StreamNodes(ctx context.Context, filter database.NodeFilter, fn func(database.Node) error) error {
+	if err := no.resultParser.ValidateNodeFilter(filter); err != nil {
+		return fmt.Errorf("invalid filter: %w", err)
+	}
+
+	query, args := no.queryBuilder.BuildNodesExportQuery(filter)
+	rows, err := no.db.Conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query nodes: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		node, err := no.resultParser.ParseNodeRow(rows)
+		if err != nil {
+			return fmt.Errorf("failed to parse node row: %w", err)
+		}
+		if err := fn(node); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("error iterating rows: %w", err)
+	}
+	return nil
+}
+
 // GetNodeHistory retrieves all historical entries for a specific node.
 // An empty domain matches all networks.
 func (no *NodeOperations) GetNodeHistory(ctx context.Context, zone, net, node int, domain string) ([]database.Node, error) {
@@ -258,6 +292,132 @@ func (no *NodeOperations) IsNodelistProcessed(nodelistDate time.Time, domain str
 	return count > 0, nil
 }
 
+// RegisterNodelistFile records a nodelist file's CRC verdict in the
+// nodelist_files registry. Call it after the file's nodes are in (or, for a
+// refused file, instead of inserting them): a row claiming imported = true
+// must not precede the rows it vouches for.
+func (no *NodeOperations) RegisterNodelistFile(file database.NodelistFile) error {
+	no.mu.Lock()
+	defer no.mu.Unlock()
+
+	if file.Domain == "" {
+		file.Domain = database.DefaultDomain
+	}
+
+	_, err := no.db.Conn().Exec(no.queryBuilder.RegisterNodelistFileSQL(),
+		file.Domain, file.NodelistDate, int32(file.DayNumber), file.Filename,
+		file.HeaderCRC, file.HasHeaderCRC, file.ComputedCRC, file.CRCVerdict,
+		file.NodesCount, file.Imported)
+	if err != nil {
+		return fmt.Errorf("failed to register nodelist file: %w", err)
+	}
+	return nil
+}
+
+// GetNodelistFiles lists the nodelist_files registry, newest first. An empty
+// domain lists all networks.
+func (no *NodeOperations) GetNodelistFiles(ctx context.Context, domain string) ([]database.NodelistFile, error) {
+	no.mu.RLock()
+	defer no.mu.RUnlock()
+
+	rows, err := no.db.Conn().QueryContext(ctx, no.queryBuilder.NodelistFilesSQL(), domain, domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nodelist files: %w", err)
+	}
+	defer rows.Close()
+
+	var files []database.NodelistFile
+	for rows.Next() {
+		var f database.NodelistFile
+		var dayNumber int32
+		if err := rows.Scan(&f.Domain, &f.NodelistDate, &dayNumber, &f.Filename, &f.HeaderCRC, &f.HasHeaderCRC,
+			&f.ComputedCRC, &f.CRCVerdict, &f.NodesCount, &f.Imported, &f.CheckedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan nodelist file: %w", err)
+		}
+		f.DayNumber = int(dayNumber)
+		files = append(files, f)
+	}
+	return files, rows.Err()
+}
+
+// InsertNodelistLines stores the text lines of an imported nodelist - every
+// line its node rows do not reproduce - for RebuildNodelist.
+func (no *NodeOperations) InsertNodelistLines(lines []database.NodelistLine) error {
+	if len(lines) == 0 {
+		return nil
+	}
+
+	no.mu.Lock()
+	defer no.mu.Unlock()
+
+	tx, err := no.db.Conn().Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.Prepare(no.queryBuilder.InsertNodelistLineSQL())
+	if err != nil {
+		return fmt.Errorf("failed to prepare nodelist lines insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, line := range lines {
+		domain := line.Domain
+		if domain == "" {
+			domain = database.DefaultDomain
+		}
+		if _, err := stmt.Exec(domain, line.NodelistDate, uint32(line.LineNumber), line.RawLine); err != nil {
+			return fmt.Errorf("failed to insert nodelist line %d: %w", line.LineNumber, err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetNodelistLines returns what is stored of one nodelist: its node lines and
+// its text lines, each in file order. Both are empty when the date was never
+// imported; text is empty when it was imported before text lines were kept.
+func (no *NodeOperations) GetNodelistLines(ctx context.Context, domain string, date time.Time) (nodes, text []database.NodelistLine, err error) {
+	no.mu.RLock()
+	defer no.mu.RUnlock()
+
+	if domain == "" {
+		domain = database.DefaultDomain
+	}
+
+	nodes, err = no.queryNodelistLines(ctx, no.queryBuilder.NodelistNodeLinesSQL(), domain, date)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query nodelist node lines: %w", err)
+	}
+	text, err = no.queryNodelistLines(ctx, no.queryBuilder.NodelistTextLinesSQL(), domain, date)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query nodelist text lines: %w", err)
+	}
+	return nodes, text, nil
+}
+
+// queryNodelistLines runs a (line_number, raw_line) query for one date.
+func (no *NodeOperations) queryNodelistLines(ctx context.Context, query, domain string, date time.Time) ([]database.NodelistLine, error) {
+	rows, err := no.db.Conn().QueryContext(ctx, query, domain, date)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var lines []database.NodelistLine
+	for rows.Next() {
+		line := database.NodelistLine{Domain: domain, NodelistDate: date}
+		var lineNumber uint32
+		if err := rows.Scan(&lineNumber, &line.RawLine); err != nil {
+			return nil, err
+		}
+		line.LineNumber = int(lineNumber)
+		lines = append(lines, line)
+	}
+	return lines, rows.Err()
+}
+
 // GetMaxNodelistDate returns the most recent nodelist date in the database.
 // An empty domain returns the newest date across all networks.
 func (no *NodeOperations) GetMaxNodelistDate(ctx context.Context, domain string) (time.Time, error) {
@@ -348,3 +508,30 @@ func (no *NodeOperations) GetNodesByZone(ctx context.Context, zone int, limit in
 
 	return no.GetNodes(ctx, filter)
 }
+
+// GetNodelistNodes returns every node row of one imported nodelist, in
+// address order, conflicting entries included.
+func (no *NodeOperations) GetNodelistNodes(ctx context.Context, domain string, date time.Time) ([]database.Node, error) {
+	no.mu.RLock()
+	defer no.mu.RUnlock()
+
+	if domain == "" {
+		domain = database.DefaultDomain
+	}
+
+	rows, err := no.db.Conn().QueryContext(ctx, no.queryBuilder.NodelistNodesSQL(), domain, date)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query nodelist nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []database.Node
+	for rows.Next() {
+		node, err := no.resultParser.ParseNodeRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse nodelist node row: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+	return nodes, rows.Err()
+}
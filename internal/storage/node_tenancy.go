@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"strings"
+	"time"
+)
+
+// Node address reuse. An address outlives its sysops: 2:5020/113 may be one
+// sysop's system for a decade and someone else's after, and its history then
+// reads as one node when it is two. A node's history is cut into tenancies
+// where
+//
+//   - the sysop changes to a different person: a name that is not the same
+//     once spelling and word order are set aside, and not a near respelling
+//     either (the threshold the sysop identity handover uses);
+//   - the system changes while the sysop is unnamed ("Sysop", "Vacant"), so
+//     the system name is all there is to tell occupants apart;
+//   - the system changes after the address sat unlisted for over
+//     tenancyRelistGap: a net that reassigns an address usually waits first.
+//
+// A system renamed by the same sysop, or a sysop respelling their own name,
+// stays one tenancy.
+
+const (
+	tenancyStartSysop    = "sysop"
+	tenancyStartSystem   = "system"
+	tenancyStartRelisted = "relisted"
+)
+
+// tenancyRelistGap is how long an address must have been unlisted for a new
+// system name on its return to mean a new occupant.
+const tenancyRelistGap = 365 * 24 * time.Hour
+
+// nodeListingRun is a stretch of consecutive nodelists listing one address
+// with the same sysop and system name and no gap over tenancyRelistGap.
+type nodeListingRun struct {
+	Domain          string
+	Zone, Net, Node int
+	SysopName       string
+	SystemName      string
+	Location        string // as last listed
+	FirstDate       time.Time
+	LastDate        time.Time
+	Entries         int
+	MaxDate         time.Time // the network's newest nodelist
+}
+
+func (r nodeListingRun) sameAddress(o nodeListingRun) bool {
+	return r.Domain == o.Domain && r.Zone == o.Zone && r.Net == o.Net && r.Node == o.Node
+}
+
+// differentSysop reports whether two sysop names are two people. A
+// placeholder on either side cannot tell, so it is not.
+func differentSysop(a, b string) bool {
+	if a == b {
+		return false
+	}
+	wa, wb := sysopWords(a), sysopWords(b)
+	ka, kb := sysopNameKey(wa), sysopNameKey(wb)
+	if ka == "" || kb == "" || ka == kb {
+		return false
+	}
+	return !sysopNamesSimilar(wa, wb, minFuzzySysopScore)
+}
+
+// sameSystemName compares system names the way they are shown: ignoring
+// case and underscores.
+func sameSystemName(a, b string) bool {
+	norm := func(s string) string {
+		return strings.Join(strings.Fields(strings.ReplaceAll(strings.ToLower(s), "_", " ")), " ")
+	}
+	return norm(a) == norm(b)
+}
+
+// isPlaceholderSysop reports whether a sysop name names nobody.
+func isPlaceholderSysop(name string) bool {
+	return sysopNameKey(sysopWords(name)) == ""
+}
+
+// tenancyBreak returns why next starts a new tenancy after prev, or "" when
+// it continues it. named is the tenancy's last sysop name that was not a
+// placeholder, so a stretch of "Sysop" between two people still separates
+// them.
+func tenancyBreak(prev, next nodeListingRun, named string) string {
+	if differentSysop(named, next.SysopName) {
+		return tenancyStartSysop
+	}
+	if sameSystemName(prev.SystemName, next.SystemName) {
+		return ""
+	}
+	if isPlaceholderSysop(prev.SysopName) || isPlaceholderSysop(next.SysopName) {
+		return tenancyStartSystem
+	}
+	if next.FirstDate.Sub(prev.LastDate) > tenancyRelistGap {
+		return tenancyStartRelisted
+	}
+	return ""
+}
+
+// segmentNodeTenancies cuts runs into tenancies. runs must be grouped by
+// address and oldest first within one; the tenancies come out the same way,
+// numbered from 1 per address.
+func segmentNodeTenancies(runs []nodeListingRun) []NodeTenancy {
+	var tenancies []NodeTenancy
+	named := ""
+	for i, r := range runs {
+		newAddress := i == 0 || !r.sameAddress(runs[i-1])
+		reason := ""
+		if !newAddress {
+			reason = tenancyBreak(runs[i-1], r, named)
+		}
+		if newAddress || reason != "" {
+			named = ""
+		}
+		if !isPlaceholderSysop(r.SysopName) {
+			named = r.SysopName
+		}
+
+		if newAddress || reason != "" {
+			seq := 1
+			if !newAddress {
+				seq = tenancies[len(tenancies)-1].Tenancy + 1
+			}
+			tenancies = append(tenancies, NodeTenancy{
+				Domain: r.Domain, Zone: r.Zone, Net: r.Net, Node: r.Node,
+				Tenancy:     seq,
+				FirstDate:   r.FirstDate,
+				StartReason: reason,
+			})
+		}
+
+		t := &tenancies[len(tenancies)-1]
+		t.SysopName, t.SystemName, t.Location = r.SysopName, r.SystemName, r.Location
+		t.LastDate = r.LastDate
+		t.Entries += r.Entries
+		t.CurrentlyActive = r.LastDate.Equal(r.MaxDate)
+	}
+	return tenancies
+}
@@ -0,0 +1,184 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// NodeTenancyOperations cuts node histories into tenancies (see
+// node_tenancy.go), stores them for the node searches and reads one node's
+// back.
+//
+// Like the sysop identities, each detection is written whole under a new
+// detected_at and the previous one deleted afterwards; the searches only
+// join the newest detected_at.
+type NodeTenancyOperations struct {
+	db database.DatabaseInterface
+}
+
+// NewNodeTenancyOperations creates a new NodeTenancyOperations instance
+func NewNodeTenancyOperations(db database.DatabaseInterface) *NodeTenancyOperations {
+	return &NodeTenancyOperations{db: db}
+}
+
+// nodeListingRunsSQL collapses the nodes matching where (which takes its own
+// arguments) into nodeListingRuns: a new run starts wherever the sysop or
+// system name changes or the address was unlisted for more than the relist
+// gap. Runs come out grouped by address, oldest first.
+func nodeListingRunsSQL(where string) string {
+	return fmt.Sprintf(`
+	WITH
+	domain_max AS (
+		SELECT domain, max(nodelist_date) AS max_date FROM nodes GROUP BY domain
+	),
+	marked AS (
+		SELECT domain, zone, net, node, nodelist_date, sysop_name, system_name, location,
+			if(sysop_name != lagInFrame(sysop_name, 1, '') OVER seq
+				OR system_name != lagInFrame(system_name, 1, '') OVER seq
+				OR dateDiff('day', lagInFrame(nodelist_date, 1, toDate(0)) OVER seq, nodelist_date) > %d,
+				1, 0) AS new_run
+		FROM nodes
+		WHERE conflict_sequence = 0 AND %s
+		WINDOW seq AS (PARTITION BY domain, zone, net, node ORDER BY nodelist_date
+			ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW)
+	),
+	numbered AS (
+		SELECT *,
+			sum(new_run) OVER (PARTITION BY domain, zone, net, node ORDER BY nodelist_date
+				ROWS BETWEEN UNBOUNDED PRECEDING AND CURRENT ROW) AS run
+		FROM marked
+	)
+	SELECT r.domain, r.zone, r.net, r.node, r.sysop_name, r.system_name, r.location,
+		r.first_date, r.last_date, r.entries, dm.max_date
+	FROM (
+		SELECT domain, zone, net, node, run, sysop_name, system_name,
+			argMax(location, nodelist_date) AS location,
+			min(nodelist_date) AS first_date,
+			max(nodelist_date) AS last_date,
+			count() AS entries
+		FROM numbered
+		GROUP BY domain, zone, net, node, run, sysop_name, system_name
+	) r
+	JOIN domain_max dm ON r.domain = dm.domain
+	ORDER BY r.domain, r.zone, r.net, r.node, r.first_date`,
+		int(tenancyRelistGap/(24*time.Hour)), where)
+}
+
+// DetectNodeTenancies segments every node address in the database and
+// replaces the stored segmentation.
+func (to *NodeTenancyOperations) DetectNodeTenancies(ctx context.Context) (*TenancyDetection, error) {
+	runs, err := to.queryRuns(ctx, nodeListingRunsSQL("1 = 1"))
+	if err != nil {
+		return nil, err
+	}
+	tenancies := segmentNodeTenancies(runs)
+
+	result := &TenancyDetection{
+		Tenancies:  len(tenancies),
+		DetectedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	counts := tenancyCounts(tenancies)
+	for i, t := range tenancies {
+		if t.Tenancy == 1 {
+			result.Addresses++
+			if counts[i] > 1 {
+				result.Reused++
+			}
+		}
+	}
+
+	if err := to.insertTenancies(ctx, tenancies, counts, result.DetectedAt); err != nil {
+		return nil, err
+	}
+
+	// Only now is the previous segmentation unreachable and safe to drop
+	if _, err := to.db.Conn().ExecContext(ctx,
+		"DELETE FROM node_tenancies WHERE detected_at < ?", result.DetectedAt); err != nil {
+		return nil, fmt.Errorf("failed to delete the previous node tenancies: %w", err)
+	}
+	return result, nil
+}
+
+// GetNodeTenancies segments one node's history, oldest tenancy first. It
+// reads the history itself rather than the stored segmentation, so it is
+// current even for a node imported since the last detection. An empty
+// domain covers every network, each numbered on its own.
+func (to *NodeTenancyOperations) GetNodeTenancies(ctx context.Context, zone, net, node int, domain string) ([]NodeTenancy, error) {
+	runs, err := to.queryRuns(ctx,
+		nodeListingRunsSQL("zone = ? AND net = ? AND node = ? AND (? = '' OR domain = ?)"),
+		zone, net, node, domain, domain)
+	if err != nil {
+		return nil, err
+	}
+	return segmentNodeTenancies(runs), nil
+}
+
+func (to *NodeTenancyOperations) queryRuns(ctx context.Context, query string, args ...interface{}) ([]nodeListingRun, error) {
+	rows, err := to.db.Conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node listing runs: %w", err)
+	}
+	defer rows.Close()
+
+	var runs []nodeListingRun
+	for rows.Next() {
+		var (
+			r               nodeListingRun
+			zone, net, node int32
+			entries         uint64
+		)
+		if err := rows.Scan(&r.Domain, &zone, &net, &node, &r.SysopName, &r.SystemName, &r.Location,
+			&r.FirstDate, &r.LastDate, &entries, &r.MaxDate); err != nil {
+			return nil, fmt.Errorf("failed to scan node listing run: %w", err)
+		}
+		r.Zone, r.Net, r.Node, r.Entries = int(zone), int(net), int(node), int(entries)
+		runs = append(runs, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating node listing runs: %w", err)
+	}
+	return runs, nil
+}
+
+// tenancyCounts returns, for each tenancy, how many its address has.
+func tenancyCounts(tenancies []NodeTenancy) []int {
+	counts := make([]int, len(tenancies))
+	for end := len(tenancies) - 1; end >= 0; {
+		n := tenancies[end].Tenancy
+		for i := end; i > end-n; i-- {
+			counts[i] = n
+		}
+		end -= n
+	}
+	return counts
+}
+
+func (to *NodeTenancyOperations) insertTenancies(ctx context.Context, tenancies []NodeTenancy, counts []int, detectedAt time.Time) error {
+	tx, err := to.db.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO node_tenancies
+		(domain, zone, net, node, tenancy, tenancies, sysop_name, system_name, location,
+		 first_date, last_date, entries, start_reason, detected_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare node tenancy insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for i, t := range tenancies {
+		if _, err := stmt.ExecContext(ctx, t.Domain, int32(t.Zone), int32(t.Net), int32(t.Node),
+			uint16(t.Tenancy), uint16(counts[i]), t.SysopName, t.SystemName, t.Location,
+			t.FirstDate, t.LastDate, uint32(t.Entries), t.StartReason, detectedAt); err != nil {
+			return fmt.Errorf("failed to insert node tenancy: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
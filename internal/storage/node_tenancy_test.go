@@ -0,0 +1,132 @@
+package storage
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+// listingRun builds a listing run at 2:5020/113 from one year to another, in a
+// network whose newest nodelist is in 2026.
+func listingRun(sysop, system string, from, to int) nodeListingRun {
+	return nodeListingRun{
+		Domain: "fidonet", Zone: 2, Net: 5020, Node: 113,
+		SysopName: sysop, SystemName: system,
+		FirstDate: time.Date(from, 1, 1, 0, 0, 0, 0, time.UTC),
+		LastDate:  time.Date(to, 1, 1, 0, 0, 0, 0, time.UTC),
+		Entries:   (to - from + 1) * 52,
+		MaxDate:   time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestSegmentNodeTenancies(t *testing.T) {
+	tests := []struct {
+		name    string
+		runs    []nodeListingRun
+		reasons []string // StartReason of each tenancy, oldest first
+	}{
+		{
+			name:    "one sysop throughout",
+			runs:    []nodeListingRun{listingRun("Boris_Paleev", "Minas_Anor", 1995, 2003)},
+			reasons: []string{""},
+		},
+		{
+			name: "a different sysop takes over",
+			runs: []nodeListingRun{
+				listingRun("Boris_Paleev", "Minas_Anor", 1995, 2003),
+				listingRun("Ivan_Petrov", "Station_7", 2003, 2010),
+			},
+			reasons: []string{"", "sysop"},
+		},
+		{
+			name: "a sysop respelling their name and renaming their system",
+			runs: []nodeListingRun{
+				listingRun("Sergey_Ivanov", "Minas_Anor", 1995, 1999),
+				listingRun("Сергей Иванов", "Minas_Anor", 1999, 2003),
+				listingRun("Sergei_Ivanov", "Minas Ithil", 2003, 2010),
+			},
+			reasons: []string{""},
+		},
+		{
+			name: "a new system under an unnamed sysop",
+			runs: []nodeListingRun{
+				listingRun("Sysop", "Minas_Anor", 1995, 1999),
+				listingRun("Sysop", "Station_7", 1999, 2003),
+			},
+			reasons: []string{"", "system"},
+		},
+		{
+			name: "an unnamed stretch between two people still separates them",
+			runs: []nodeListingRun{
+				listingRun("Boris_Paleev", "Minas_Anor", 1995, 1999),
+				listingRun("Vacant", "Minas_Anor", 1999, 2000),
+				listingRun("Ivan_Petrov", "Minas_Anor", 2000, 2003),
+			},
+			reasons: []string{"", "sysop"},
+		},
+		{
+			name: "the same sysop back with a new system after years unlisted",
+			runs: []nodeListingRun{
+				listingRun("Boris_Paleev", "Minas_Anor", 1995, 1999),
+				listingRun("Boris_Paleev", "Station_7", 2004, 2010),
+			},
+			reasons: []string{"", "relisted"},
+		},
+		{
+			name: "the same system back after years unlisted",
+			runs: []nodeListingRun{
+				listingRun("Boris_Paleev", "Minas_Anor", 1995, 1999),
+				listingRun("Boris_Paleev", "Minas_Anor", 2004, 2010),
+			},
+			reasons: []string{""},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := segmentNodeTenancies(tt.runs)
+			var reasons []string
+			for i, tn := range got {
+				reasons = append(reasons, tn.StartReason)
+				if tn.Tenancy != i+1 {
+					t.Errorf("tenancy %d numbered %d", i+1, tn.Tenancy)
+				}
+			}
+			if !reflect.DeepEqual(reasons, tt.reasons) {
+				t.Errorf("start reasons = %q, want %q", reasons, tt.reasons)
+			}
+		})
+	}
+}
+
+func TestSegmentNodeTenanciesSummarizesEachTenancy(t *testing.T) {
+	runs := []nodeListingRun{
+		listingRun("Boris_Paleev", "Minas_Anor", 1995, 1999),
+		listingRun("Boris_Paleev", "Minas_Ithil", 1999, 2003),
+		listingRun("Ivan_Petrov", "Station_7", 2009, 2026),
+	}
+	other := listingRun("Anna_Petrova", "Lorien", 2001, 2002)
+	other.Node = 114
+	runs = append(runs, other)
+
+	got := segmentNodeTenancies(runs)
+	if len(got) != 3 {
+		t.Fatalf("got %d tenancies, want 3: %+v", len(got), got)
+	}
+
+	first := got[0]
+	if first.SystemName != "Minas_Ithil" || !first.FirstDate.Equal(runs[0].FirstDate) ||
+		!first.LastDate.Equal(runs[1].LastDate) || first.Entries != runs[0].Entries+runs[1].Entries {
+		t.Errorf("first tenancy = %+v, want both of Boris's runs with the later system name", first)
+	}
+	if first.CurrentlyActive || !got[1].CurrentlyActive {
+		t.Errorf("only the tenancy listed in the newest nodelist is active: %v, %v",
+			first.CurrentlyActive, got[1].CurrentlyActive)
+	}
+	if next := got[2]; next.Node != 114 || next.Tenancy != 1 || next.StartReason != "" {
+		t.Errorf("the next address starts its own numbering: %+v", next)
+	}
+	if counts := tenancyCounts(got); !reflect.DeepEqual(counts, []int{2, 2, 1}) {
+		t.Errorf("tenancyCounts = %v, want [2 2 1]", counts)
+	}
+}
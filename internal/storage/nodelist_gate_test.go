@@ -35,6 +35,11 @@ func TestProtocolReportsGateOnAnnouncedFlag(t *testing.T) {
 		query := tqb.BuildVModemUnconfirmedQuery("AND node != 0", domainFilterSQL("fidonet", ""), 30)
 		assertGate(t, query, "IVM")
 	})
+
+	t.Run("binkps-certificates", func(t *testing.T) {
+		query := tqb.BuildBinkPSCertificateIssuesQuery("AND node != 0", domainFilterSQL("fidonet", ""), 30)
+		assertGate(t, query, "")
+	})
 }
 
 // assertGate checks the shape of a report's candidate gate. A non-empty flag
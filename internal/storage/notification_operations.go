@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// NotificationOperations keeps the log of change notifications sent to
+// subscribers, and the subscriptions registered through the API (see
+// internal/notify).
+type NotificationOperations struct {
+	db           database.DatabaseInterface
+	queryBuilder QueryBuilderInterface
+}
+
+// NewNotificationOperations creates a new NotificationOperations instance
+func NewNotificationOperations(db database.DatabaseInterface, queryBuilder QueryBuilderInterface) *NotificationOperations {
+	return &NotificationOperations{db: db, queryBuilder: queryBuilder}
+}
+
+// InsertDelivery records one delivery attempt. Every attempt gets its own
+// row, failed ones included, so the log shows what a retried batch went
+// through and not only how it ended.
+func (no *NotificationOperations) InsertDelivery(ctx context.Context, d database.NotificationDelivery) error {
+	_, err := no.db.Conn().ExecContext(ctx, no.queryBuilder.InsertNotificationDeliverySQL(),
+		d.DeliveryID, d.SubscriptionID, d.Channel, d.Target, d.Domain, d.NodelistDate,
+		uint32(d.EventCount), uint8(d.Attempt), d.Status, uint16(d.HTTPStatus), d.Error,
+		uint32(d.Duration.Milliseconds()), d.AttemptedAt)
+	if err != nil {
+		return fmt.Errorf("failed to record notification delivery: %w", err)
+	}
+	return nil
+}
+
+// GetNotificationSubscriptions returns the live subscriptions registered
+// through the API, deleted ones left out.
+func (no *NotificationOperations) GetNotificationSubscriptions(ctx context.Context) ([]database.NotificationSubscription, error) {
+	query := `SELECT subscription_id, owner, domain, filter_address, filter_net, filter_sysop,
+			filter_flag, events, webhook, secret, email, updated_at
+		FROM notification_subscriptions FINAL
+		WHERE NOT deleted
+		ORDER BY subscription_id`
+
+	rows, err := no.db.Conn().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notification subscriptions: %w", err)
+	}
+	defer rows.Close()
+
+	var subs []database.NotificationSubscription
+	for rows.Next() {
+		var s database.NotificationSubscription
+		if err := rows.Scan(&s.ID, &s.Owner, &s.Domain, &s.FilterAddress, &s.FilterNet, &s.FilterSysop,
+			&s.FilterFlag, &s.Events, &s.Webhook, &s.Secret, &s.Email, &s.UpdatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification subscription row: %w", err)
+		}
+		subs = append(subs, s)
+	}
+	return subs, rows.Err()
+}
+
+// InsertNotificationSubscription stores a subscription, or with Deleted set
+// deletes the one with its ID: the newest row per ID wins.
+func (no *NotificationOperations) InsertNotificationSubscription(ctx context.Context, s database.NotificationSubscription) error {
+	events := s.Events
+	if events == nil {
+		events = []string{}
+	}
+	updatedAt := s.UpdatedAt
+	if updatedAt.IsZero() {
+		updatedAt = time.Now().UTC()
+	}
+	_, err := no.db.Conn().ExecContext(ctx, `INSERT INTO notification_subscriptions
+		(subscription_id, owner, domain, filter_address, filter_net, filter_sysop, filter_flag,
+		 events, webhook, secret, email, deleted, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`,
+		s.ID, s.Owner, s.Domain, s.FilterAddress, s.FilterNet, s.FilterSysop, s.FilterFlag,
+		events, s.Webhook, s.Secret, s.Email, s.Deleted, updatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to store notification subscription: %w", err)
+	}
+	return nil
+}
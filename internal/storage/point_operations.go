@@ -431,6 +431,63 @@ func (po *PointOperations) searchPoints(ctx context.Context, filter database.Poi
 	return po.queryPoints(ctx, po.queryBuilder.SearchPointsHistorySQL(where), args...)
 }
 
+// StreamPoints calls fn for every point row matching filter without
+// collecting them. LatestOnly streams the snapshot exactly as SearchPoints
+// builds it; otherwise every stored row of a fully imported issue is sent, in
+// address then date order. Limit and Offset are ignored. An error from fn
+// stops the stream and is returned as is.
+//
+// Like NodeOperations.StreamNodes it does not hold po.mu for the duration.
+func (po *PointOperations) StreamPoints(ctx context.Context, filter database.PointFilter, fn func(database.Point) error) error {
+	domain := ""
+	if filter.Domain != nil {
+		domain = *filter.Domain
+	}
+
+	var query string
+	var args []interface{}
+	if filter.LatestOnly != nil && *filter.LatestOnly {
+		anchor, found, err := po.resolveAsOf(ctx, domain, filter.DateTo)
+		if err != nil || !found {
+			return err
+		}
+		snapFilter := filter
+		snapFilter.DateTo = nil
+		identityWhere, identityArgs, attrWhere, attrArgs := po.queryBuilder.BuildPointFilterConditions(snapFilter)
+		query = po.queryBuilder.PointSnapshotSQL(identityWhere, attrWhere, false)
+		args = append(snapshotArgs(domain, anchor, identityArgs...), attrArgs...)
+	} else {
+		identityWhere, identityArgs, attrWhere, attrArgs := po.queryBuilder.BuildPointFilterConditions(filter)
+		where := identityWhere
+		if attrWhere != "" {
+			if where != "" {
+				where += " AND " + attrWhere
+			} else {
+				where = attrWhere
+			}
+		}
+		query = po.queryBuilder.ExportPointsSQL(where)
+		args = append(append([]interface{}{domain, domain}, identityArgs...), attrArgs...)
+	}
+
+	rows, err := po.db.Conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query points: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		point, err := po.resultParser.ParsePointRow(rows)
+		if err != nil {
+			return err
+		}
+		if err := fn(point); err != nil {
+			return err
+		}
+	}
+	return rows.Err()
+}
+
 // SearchPointsWithLifetime searches points and returns one summary per 4-D
 // address. LatestOnly restricts to the current snapshot (listed period =
 // snapshot issue date); otherwise the whole history is aggregated, with
@@ -59,3 +59,72 @@ func (qb *QueryBuilder) ConsecutiveNodelistCheckSQL() string {
 func (qb *QueryBuilder) NextNodelistDateSQL() string {
 	return "SELECT MIN(nodelist_date) FROM nodes WHERE nodelist_date > ? AND " + optionalDomainSQL
 }
+
+// RegisterNodelistFileSQL records one nodelist file's CRC verdict.
+// Binds: domain, nodelist_date, day_number, filename, header_crc,
+// has_header_crc, computed_crc, crc_verdict, nodes_count, imported.
+func (qb *QueryBuilder) RegisterNodelistFileSQL() string {
+	return `INSERT INTO nodelist_files
+		(domain, nodelist_date, day_number, filename, header_crc, has_header_crc, computed_crc, crc_verdict, nodes_count, imported)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+}
+
+// NodelistFilesSQL lists registry rows, newest first. Binds: domain, domain.
+func (qb *QueryBuilder) NodelistFilesSQL() string {
+	return `SELECT domain, nodelist_date, day_number, filename, header_crc, has_header_crc,
+			computed_crc, crc_verdict, nodes_count, imported, checked_at
+		FROM nodelist_files FINAL
+		WHERE ` + optionalDomainSQL + `
+		ORDER BY nodelist_date DESC`
+}
+
+// InsertNodelistLineSQL inserts one nodelist text line.
+// Binds: domain, nodelist_date, line_number, raw_line.
+func (qb *QueryBuilder) InsertNodelistLineSQL() string {
+	return `INSERT INTO nodelist_lines (domain, nodelist_date, line_number, raw_line) VALUES (?, ?, ?, ?)`
+}
+
+// NodelistNodeLinesSQL returns one date's node lines in file order, falling
+// back to address order - region blocks before the nets in them - for rows
+// imported without a line number. Binds: domain, nodelist_date.
+func (qb *QueryBuilder) NodelistNodeLinesSQL() string {
+	return `SELECT line_number, raw_line
+		FROM nodes
+		WHERE domain = ? AND nodelist_date = ?
+		ORDER BY line_number, zone, ifNull(region, 0), net, node, conflict_sequence`
+}
+
+// NodelistTextLinesSQL returns one date's text lines in file order.
+// Binds: domain, nodelist_date.
+func (qb *QueryBuilder) NodelistTextLinesSQL() string {
+	return `SELECT line_number, raw_line
+		FROM nodelist_lines FINAL
+		WHERE domain = ? AND nodelist_date = ?
+		ORDER BY line_number`
+}
+
+// NodelistNodesSQL returns every node row of one date, for comparing one
+// nodelist with the one before it. Column order matches
+// ClickHouseResultParser.ParseNodeRow. Binds: domain, nodelist_date.
+func (qb *QueryBuilder) NodelistNodesSQL() string {
+	return `SELECT
+		zone, net, node, nodelist_date, day_number,
+		system_name, location, sysop_name, phone, node_type, region, max_speed,
+		is_cm, is_mo,
+		flags, modem_flags,
+		conflict_sequence, has_conflict, has_inet, ` + internetConfigSelectSQL + `, fts_id, raw_line, domain
+	FROM nodes
+	WHERE domain = ? AND nodelist_date = ?
+	ORDER BY zone, net, node, conflict_sequence`
+}
+
+// InsertNotificationDeliverySQL records one delivery attempt of a change
+// notification. Binds: delivery_id, subscription_id, channel, target, domain,
+// nodelist_date, event_count, attempt, status, http_status, error,
+// duration_ms, attempted_at.
+func (qb *QueryBuilder) InsertNotificationDeliverySQL() string {
+	return `INSERT INTO notification_deliveries
+		(delivery_id, subscription_id, channel, target, domain, nodelist_date, event_count,
+		 attempt, status, http_status, error, duration_ms, attempted_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+}
@@ -83,7 +83,7 @@ func (qb *QueryBuilder) BuildDirectBatchInsertSQL(nodes []database.Node, rp *Res
 		system_name, location, sysop_name, phone, node_type, region, max_speed,
 		is_cm, is_mo,
 		flags, modem_flags,
-		conflict_sequence, has_conflict, has_inet, internet_config, fts_id, raw_line, domain
+		conflict_sequence, has_conflict, has_inet, internet_config, fts_id, raw_line, domain, line_number
 	) VALUES `)
 
 	for i, node := range nodes {
@@ -144,9 +144,9 @@ func (qb *QueryBuilder) BuildDirectBatchInsertSQL(nodes []database.Node, rp *Res
 			buf.WriteString("'{}',")
 		}
 
-		// FTS ID, raw line and domain
-		buf.WriteString(fmt.Sprintf("'%s','%s','%s')",
-			qb.escapeSQL(node.FtsId), qb.escapeSQL(node.RawLine), qb.escapeSQL(node.Domain)))
+		// FTS ID, raw line, domain and line number
+		buf.WriteString(fmt.Sprintf("'%s','%s','%s',%d)",
+			qb.escapeSQL(node.FtsId), qb.escapeSQL(node.RawLine), qb.escapeSQL(node.Domain), node.LineNumber))
 	}
 
 	return buf.String()
@@ -339,6 +339,30 @@ func (qb *QueryBuilder) BuildFTSQuery(filter database.NodeFilter) (string, []int
 	return query, args, true
 }
 
+// BuildNodesExportQuery builds the query behind StreamNodes.
+//
+// Search returns one row per node; an export of "2:50 during 2019" wants every
+// issue's row, so the default here is the flat history shape - identity and
+// attribute predicates ANDed directly onto nodes, with no per-node collapse.
+// LatestOnly keeps its search meaning and goes through BuildNodesQuery.
+//
+// The ORDER BY is the table's sorting key, so ClickHouse reads parts in order
+// and streams blocks as it goes instead of sorting the whole window first.
+// Limit and Offset are honoured when set, but exports normally leave them zero.
+func (qb *QueryBuilder) BuildNodesExportQuery(filter database.NodeFilter) (string, []interface{}) {
+	if filter.LatestOnly != nil && *filter.LatestOnly {
+		return qb.BuildNodesQuery(filter)
+	}
+
+	identity, identityArgs, attrs, attrArgs := qb.buildFilterConditions(filter)
+	sql := "\n\t\tSELECT " + nodeColumnsSQL + "\n\t\tFROM nodes"
+	if all := append(append([]string{}, identity...), attrs...); len(all) > 0 {
+		sql += "\n\t\tWHERE " + strings.Join(all, " AND ")
+	}
+	sql += "\n\t\tORDER BY zone, net, node, nodelist_date, conflict_sequence, domain"
+	return sql + paginationSQL(filter), append(identityArgs, attrArgs...)
+}
+
 // buildFilterConditions splits a NodeFilter into identity and attribute
 // predicates, mirroring BuildPointFilterConditions.
 //
@@ -488,127 +512,138 @@ func (qb *QueryBuilder) MarkConflictSQL() string {
 
 // SysopSearchSQL returns SQL for sysop search with window functions
 func (qb *QueryBuilder) SysopSearchSQL() string {
-	// ClickHouse-optimized sysop search using window functions to avoid aggregation
-	// issues. Node identity and "currently active" are evaluated per domain: each
-	// network has its own latest nodelist date.
-	return `
-	WITH
-	domain_max AS (
-		SELECT domain, MAX(nodelist_date) as max_date FROM nodes GROUP BY domain
-	),
-	ranked_nodes AS (
-		SELECT
-			domain, zone, net, node, nodelist_date, system_name, location, sysop_name,
-			row_number() OVER (PARTITION BY domain, zone, net, node ORDER BY nodelist_date DESC) as rn,
-			MIN(nodelist_date) OVER (PARTITION BY domain, zone, net, node) as first_date,
-			MAX(nodelist_date) OVER (PARTITION BY domain, zone, net, node) as last_date
-		FROM nodes
-		WHERE replaceAll(sysop_name, '_', ' ') ILIKE concat('%', replaceAll(?, '_', ' '), '%')
-			AND (? = '' OR domain = ?)
-	),
-	latest_per_node AS (
-		SELECT
-			domain, zone, net, node, system_name, location, sysop_name,
-			first_date, last_date, nodelist_date
-		FROM ranked_nodes
-		WHERE rn = 1
-	)
-	SELECT
-		lpn.zone, lpn.net, lpn.node, lpn.system_name, lpn.location, lpn.sysop_name,
-		lpn.first_date, lpn.last_date,
-		CASE WHEN lpn.last_date = dm.max_date THEN true ELSE false END as currently_active,
-		lpn.domain
-	FROM latest_per_node lpn
-	JOIN domain_max dm ON lpn.domain = dm.domain
-	ORDER BY lpn.first_date DESC
-	LIMIT ?`
+	return sysopNodesSQL("replaceAll(sysop_name, '_', ' ') ILIKE concat('%', replaceAll(?, '_', ' '), '%')")
 }
 
-// NodeSummarySearchSQL returns the web search's per-node summary query.
+// SysopSearchByNamesSQL returns SQL for the nodes of the sysops named in a
+// list, as found by the fuzzy sysop search
+func (qb *QueryBuilder) SysopSearchByNamesSQL() string {
+	return sysopNodesSQL("sysop_name IN (?)")
+}
+
+// sysopNodesSQL is the sysop search's per-tenancy summary query for the
+// nodes whose sysop matches match. It takes the sysop argument(s), the domain
+// twice and the limit.
+func sysopNodesSQL(match string) string {
+	return nodeSummarySQL(match, "", "ORDER BY pt.first_date DESC")
+}
+
+// NodeSummarySearchSQL returns the web search's per-tenancy summary query.
 //
-// activeOnly drops nodes whose last appearance predates their network's newest
-// nodelist. The predicate reuses currently_active, the same comparison this
-// query already returns as a per-row badge, so the filter and the badge shown
-// next to each result cannot disagree.
+// activeOnly drops tenancies whose last appearance predates their network's
+// newest nodelist. The predicate reuses currently_active, the same comparison
+// this query already returns as a per-row badge, so the filter and the badge
+// shown next to each result cannot disagree.
 //
-// It is applied after latest_per_node has collapsed each node to one row: "is
-// this node still listed" is a property of a node's final row, so filtering
-// earlier would keep a departed node alive on the strength of an older row.
+// It is applied after per_tenancy has collapsed each tenancy to one row: "is
+// this still listed" is a property of a tenancy's final row, so filtering
+// earlier would keep a departed occupant alive on the strength of an older
+// row.
 func (qb *QueryBuilder) NodeSummarySearchSQL(activeOnly bool) string {
 	activeOnlyClause := ""
 	if activeOnly {
-		activeOnlyClause = "\n\tWHERE lpn.last_date = dm.max_date"
+		activeOnlyClause = "\n\tWHERE pt.last_date = dm.max_date"
 	}
+	return nodeSummarySQL(`1=1
+			AND (? IS NULL OR zone = ?)
+			AND (? IS NULL OR net = ?)
+			AND (? IS NULL OR node = ?)
+			AND (? IS NULL OR system_name ILIKE ?)
+			AND (? IS NULL OR location ILIKE ?)
+			AND (? IS NULL OR replaceAll(sysop_name, '_', ' ') ILIKE replaceAll(?, '_', ' '))`,
+		activeOnlyClause, "ORDER BY pt.last_date DESC, pt.zone, pt.net, pt.node, pt.tenancy")
+}
+
+// nodeSummarySQL is the node searches' summary query: one row per tenancy
+// (see node_tenancy.go) with a row matching match, carrying the tenancy's
+// latest matching row and the span of its matching rows. An address reused
+// by several sysops therefore shows each one's lifetime, not a single span
+// across all of them. Rows are placed in tenancies by the stored
+// segmentation; an address it does not cover yet (nothing detected, or
+// imported since) comes back as one row with tenancy 0, as before
+// segmentation existed.
+//
+// Node identity and "currently active" are evaluated per domain: each network
+// has its own latest nodelist date. It takes match's arguments, the domain
+// twice and the limit.
+func nodeSummarySQL(match, activeOnlyClause, orderBy string) string {
 	return `
 	WITH
 	domain_max AS (
 		SELECT domain, MAX(nodelist_date) as max_date FROM nodes GROUP BY domain
 	),
-	ranked_nodes AS (
-		SELECT
-			domain, zone, net, node, nodelist_date, system_name, location, sysop_name,
-			row_number() OVER (PARTITION BY domain, zone, net, node ORDER BY nodelist_date DESC) as rn,
-			MIN(nodelist_date) OVER (PARTITION BY domain, zone, net, node) as first_date,
-			MAX(nodelist_date) OVER (PARTITION BY domain, zone, net, node) as last_date
+	matched AS (
+		SELECT domain, zone, net, node, nodelist_date, system_name, location, sysop_name
 		FROM nodes
-		WHERE 1=1
-			AND (? IS NULL OR zone = ?)
-			AND (? IS NULL OR net = ?)
-			AND (? IS NULL OR node = ?)
-			AND (? IS NULL OR system_name ILIKE ?)
-			AND (? IS NULL OR location ILIKE ?)
-			AND (? IS NULL OR replaceAll(sysop_name, '_', ' ') ILIKE replaceAll(?, '_', ' '))
+		WHERE ` + match + `
 			AND (? = '' OR domain = ?)
 	),
-	latest_per_node AS (
+	tenancy_rows AS (
 		SELECT
-			domain, zone, net, node, system_name, location, sysop_name,
-			first_date, last_date, nodelist_date
-		FROM ranked_nodes
-		WHERE rn = 1
+			m.domain AS domain, m.zone AS zone, m.net AS net, m.node AS node,
+			m.nodelist_date AS nodelist_date, m.system_name AS system_name,
+			m.location AS location, m.sysop_name AS sysop_name,
+			t.tenancy AS tenancy, t.tenancies AS tenancies
+		FROM matched AS m
+		ASOF LEFT JOIN (
+			SELECT domain, zone, net, node, first_date, tenancy, tenancies
+			FROM node_tenancies
+			WHERE detected_at = (SELECT max(detected_at) FROM node_tenancies)
+		) AS t
+		ON m.domain = t.domain AND m.zone = t.zone AND m.net = t.net AND m.node = t.node
+			AND m.nodelist_date >= t.first_date
+	),
+	per_tenancy AS (
+		SELECT
+			domain, zone, net, node, tenancy,
+			any(tenancies) AS tenancies,
+			argMax(system_name, nodelist_date) AS system_name,
+			argMax(location, nodelist_date) AS location,
+			argMax(sysop_name, nodelist_date) AS sysop_name,
+			MIN(nodelist_date) AS first_date,
+			MAX(nodelist_date) AS last_date
+		FROM tenancy_rows
+		GROUP BY domain, zone, net, node, tenancy
 	)
 	SELECT
-		lpn.zone, lpn.net, lpn.node, lpn.system_name, lpn.location, lpn.sysop_name,
-		lpn.first_date, lpn.last_date,
-		CASE WHEN lpn.last_date = dm.max_date THEN true ELSE false END as currently_active,
-		lpn.domain
-	FROM latest_per_node lpn
-	JOIN domain_max dm ON lpn.domain = dm.domain` + activeOnlyClause + `
-	ORDER BY lpn.last_date DESC, lpn.zone, lpn.net, lpn.node
+		pt.zone, pt.net, pt.node, pt.system_name, pt.location, pt.sysop_name,
+		pt.first_date, pt.last_date,
+		CASE WHEN pt.last_date = dm.max_date THEN true ELSE false END as currently_active,
+		pt.domain, pt.tenancy, pt.tenancies
+	FROM per_tenancy pt
+	JOIN domain_max dm ON pt.domain = dm.domain` + activeOnlyClause + `
+	` + orderBy + `
 	LIMIT ?`
 }
 
 // UniqueSysopsSQL returns SQL for getting unique sysops with statistics
 func (qb *QueryBuilder) UniqueSysopsSQL() string {
-	// ClickHouse-compatible unique sysops query
-	return `
-		WITH sysop_stats AS (
-			SELECT
-				sysop_name,
-				COUNT(DISTINCT concat(domain, '#', toString(zone), ':', toString(net), '/', toString(node))) as node_count,
-				COUNT(DISTINCT CASE WHEN (domain, nodelist_date) IN (SELECT domain, MAX(nodelist_date) FROM nodes GROUP BY domain) THEN concat(domain, '#', toString(zone), ':', toString(net), '/', toString(node)) END) as active_nodes,
-				MIN(nodelist_date) as first_seen,
-				MAX(nodelist_date) as last_seen,
-				arraySort(arrayDistinct(groupArray(zone))) as zones
-			FROM nodes
-			GROUP BY sysop_name
-		)
-		SELECT
-			sysop_name,
-			node_count,
-			active_nodes,
-			first_seen,
-			last_seen,
-			zones
-		FROM sysop_stats
-		ORDER BY node_count DESC, sysop_name
-		LIMIT ? OFFSET ?
-	`
+	return uniqueSysopsSQL("", "LIMIT ? OFFSET ?")
 }
 
 // UniqueSysopsWithFilterSQL returns SQL for getting unique sysops with filter
 func (qb *QueryBuilder) UniqueSysopsWithFilterSQL() string {
-	// ClickHouse-compatible unique sysops query with filter
+	return uniqueSysopsSQL(
+		"WHERE replaceAll(sysop_name, '_', ' ') ILIKE concat('%', replaceAll(?, '_', ' '), '%')",
+		"LIMIT ? OFFSET ?")
+}
+
+// UniqueSysopsByNamesSQL returns SQL for the statistics of the sysops named
+// in a list, as found by the fuzzy sysop search
+func (qb *QueryBuilder) UniqueSysopsByNamesSQL() string {
+	return uniqueSysopsSQL("WHERE sysop_name IN (?)", "")
+}
+
+// SysopNamesSQL returns SQL for every distinct sysop name, the candidates of
+// the fuzzy sysop search
+func (qb *QueryBuilder) SysopNamesSQL() string {
+	return `SELECT DISTINCT sysop_name FROM nodes WHERE sysop_name != ''`
+}
+
+// uniqueSysopsSQL is the per-sysop statistics query shared by the sysop
+// listings, with an optional WHERE clause on nodes and a LIMIT clause.
+func uniqueSysopsSQL(where, limit string) string {
+	// ClickHouse-compatible unique sysops query
 	return `
 		WITH sysop_stats AS (
 			SELECT
@@ -619,7 +654,7 @@ func (qb *QueryBuilder) UniqueSysopsWithFilterSQL() string {
 				MAX(nodelist_date) as last_seen,
 				arraySort(arrayDistinct(groupArray(zone))) as zones
 			FROM nodes
-			WHERE replaceAll(sysop_name, '_', ' ') ILIKE concat('%', replaceAll(?, '_', ' '), '%')
+			` + where + `
 			GROUP BY sysop_name
 		)
 		SELECT
@@ -631,6 +666,6 @@ func (qb *QueryBuilder) UniqueSysopsWithFilterSQL() string {
 			zones
 		FROM sysop_stats
 		ORDER BY node_count DESC, sysop_name
-		LIMIT ? OFFSET ?
+		` + limit + `
 	`
 }
@@ -199,6 +199,36 @@ func TestBuildFTSQueryDelegates(t *testing.T) {
 	}
 }
 
+// TestBuildNodesExportQuery pins the export shape: every historical row, no
+// per-node collapse, ordered by the sorting key so ClickHouse can stream it,
+// and one argument per placeholder for every filter shape.
+func TestBuildNodesExportQuery(t *testing.T) {
+	qb := NewQueryBuilder()
+
+	for name, filter := range nodeFilterShapes {
+		t.Run(name, func(t *testing.T) {
+			query, args := qb.BuildNodesExportQuery(filter)
+			if got, want := strings.Count(query, "?"), len(args); got != want {
+				t.Errorf("%d placeholders but %d args\n%s", got, want, query)
+			}
+			if filter.LatestOnly != nil && *filter.LatestOnly {
+				if want, _ := qb.BuildNodesQuery(filter); query != want {
+					t.Errorf("latest_only export must be the search query\n%s", query)
+				}
+				return
+			}
+			for _, collapse := range []string{"LIMIT 1 BY", "row_number()", "MAX(nodelist_date)"} {
+				if strings.Contains(query, collapse) {
+					t.Errorf("history export collapses rows with %s\n%s", collapse, query)
+				}
+			}
+			if !strings.Contains(query, "ORDER BY zone, net, node, nodelist_date, conflict_sequence") {
+				t.Errorf("export is not in sorting-key order\n%s", query)
+			}
+		})
+	}
+}
+
 // TestNodeSummarySearchActiveOnly guards the web search's "Include historical
 // data" checkbox. It was wired to LatestOnly, which this query never reads, so
 // unchecking it did nothing at all - a visible control with no effect.
@@ -208,19 +238,20 @@ func TestNodeSummarySearchActiveOnly(t *testing.T) {
 	off := qb.NodeSummarySearchSQL(false)
 	on := qb.NodeSummarySearchSQL(true)
 
-	if strings.Contains(off, "lpn.last_date = dm.max_date\n") {
+	if strings.Contains(off, "pt.last_date = dm.max_date\n") {
 		t.Errorf("unfiltered query must not restrict to currently-listed nodes\n%s", off)
 	}
-	if !strings.Contains(on, "WHERE lpn.last_date = dm.max_date") {
+	if !strings.Contains(on, "WHERE pt.last_date = dm.max_date") {
 		t.Errorf("activeOnly did not add the still-listed predicate\n%s", on)
 	}
-	// The predicate has to land after latest_per_node has collapsed each node
-	// to one row: applied earlier, an older row would keep a departed node in.
-	if strings.Index(on, "WHERE lpn.last_date") < strings.Index(on, "FROM latest_per_node") {
-		t.Errorf("predicate applied before per-node collapse\n%s", on)
+	// The predicate has to land after per_tenancy has collapsed each tenancy
+	// to one row: applied earlier, an older row would keep a departed
+	// occupant in.
+	if strings.Index(on, "WHERE pt.last_date") < strings.Index(on, "FROM per_tenancy") {
+		t.Errorf("predicate applied before per-tenancy collapse\n%s", on)
 	}
 	// Same comparison as the badge each row carries, so they cannot disagree.
-	if !strings.Contains(on, "lpn.last_date = dm.max_date THEN true") {
+	if !strings.Contains(on, "pt.last_date = dm.max_date THEN true") {
 		t.Errorf("expected currently_active badge to use the same comparison\n%s", on)
 	}
 	if strings.Count(on, "?") != strings.Count(off, "?") {
@@ -297,6 +297,21 @@ func (qb *QueryBuilder) SearchPointsHistorySQL(extraWhere string) string {
 	return q
 }
 
+// ExportPointsSQL is the history shape StreamPoints runs: every matching row
+// of fully imported issues, unpaged, in the table's sorting-key order so the
+// result streams without a full sort.
+// Binds: domain, domain, [extraWhere binds].
+func (qb *QueryBuilder) ExportPointsSQL(extraWhere string) string {
+	q := `SELECT ` + pointsColumnsSQL + ` FROM points
+	WHERE ` + optionalDomainSQL + ` AND ` + pointGatedIssuesSQL
+	if extraWhere != "" {
+		q += ` AND ` + extraWhere
+	}
+	q += `
+	ORDER BY domain, zone, net, node, point, pointlist_date, conflict_sequence, list_source`
+	return q
+}
+
 // SearchPointsLifetimeSQL aggregates every matching historical row into one
 // summary per 4-D address (mirrors the node search's lifetime view — raw
 // weekly rows would flood the result set). Identity fields come from the
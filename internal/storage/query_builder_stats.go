@@ -164,3 +164,30 @@ func (qb *QueryBuilder) BrowseNodesSQL() string {
 	WHERE nodelist_date = ? AND zone = ? AND net = ? AND (? = '' OR domain = ?)
 	ORDER BY node, conflict_sequence`
 }
+
+// BrowseDiffNodesSQL returns SQL listing the primary entry of every address
+// within the part of the hierarchy a browse diff covers, on two nodelist
+// dates. Column order matches ClickHouseResultParser.ParseNodeRow.
+// Binds: from, to, domain, domain, then zone (regions), zone and region
+// (nets) or zone and net (nodes).
+func (qb *QueryBuilder) BrowseDiffNodesSQL(level string) string {
+	var scope string
+	switch level {
+	case BrowseLevelRegions:
+		scope = " AND zone = ?"
+	case BrowseLevelNets:
+		scope = " AND zone = ? AND ifNull(region, 0) = ?"
+	case BrowseLevelNodes:
+		scope = " AND zone = ? AND net = ?"
+	}
+	return `
+	SELECT
+		zone, net, node, nodelist_date, day_number,
+		system_name, location, sysop_name, phone, node_type, region, max_speed,
+		is_cm, is_mo,
+		flags, modem_flags,
+		conflict_sequence, has_conflict, has_inet, ` + internetConfigSelectSQL + `, fts_id, raw_line, domain
+	FROM nodes
+	WHERE nodelist_date IN (?, ?) AND conflict_sequence = 0 AND (? = '' OR domain = ?)` + scope + `
+	ORDER BY zone, net, node, nodelist_date`
+}
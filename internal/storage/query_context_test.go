@@ -45,6 +45,8 @@ func TestServerReadsUseQueryContext(t *testing.T) {
 		"FindConflictingNode":   "nodelist import: duplicate-address check",
 		"IsNodelistProcessed":   "nodelist import: already-imported gate",
 		"UpdateFlagStatistics":  "nodelist import: post-import aggregation",
+		"RegisterNodelistFile":  "nodelist import: CRC registry row",
+		"InsertNodelistLines":   "nodelist import: text lines for rebuild",
 		"insertPointsSQL":       "pointlist import: bulk INSERT",
 		"IsPointlistImported":   "pointlist import: already-imported gate",
 		"RegisterPointlistFile": "pointlist import: gate registration",
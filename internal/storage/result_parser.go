@@ -59,6 +59,7 @@ func (rp *ResultParser) ParseNodeSummaryRow(scanner RowScanner) (NodeSummary, er
 		&ns.Zone, &ns.Net, &ns.Node,
 		&ns.SystemName, &ns.Location, &ns.SysopName,
 		&ns.FirstDate, &ns.LastDate, &ns.CurrentlyActive, &ns.Domain,
+		&ns.Tenancy, &ns.Tenancies,
 	)
 	if err != nil {
 		return ns, fmt.Errorf("failed to scan node summary: %w", err)
@@ -180,7 +181,8 @@ func (rp *ResultParser) parseStringArray(s string) []string {
 func (rp *ResultParser) ParseTestResultRow(scanner RowScanner, result *NodeTestResult) error {
 	var resolvedIPv4, resolvedIPv6 interface{}
 	var binkpAddresses, binkpCapabilities interface{}
-	var ifcicoAddresses interface{}
+	var ifcicoAddresses, telnetAddresses interface{}
+	var pathHops interface{}
 
 	// First try to scan with new fields (per-hostname testing)
 	err := scanner.Scan(
@@ -212,6 +214,24 @@ func (rp *ResultParser) ParseTestResultRow(scanner RowScanner, result *NodeTestR
 		&binkpAddresses,
 		&binkpCapabilities,
 		&result.BinkPError,
+		&result.BinkPAuthMethod,
+		&result.BinkPMailExchange,
+		&result.BinkPMailDetail,
+		&result.BinkPOptCRAM,
+		&result.BinkPOptCrypt,
+		&result.BinkPOptPLZ,
+		&result.BinkPOptGZ,
+		&result.BinkPOptBZ2,
+		&result.BinkPSTested,
+		&result.BinkPSSuccess,
+		&result.BinkPSResponseMs,
+		&result.BinkPSError,
+		&result.BinkPSCertSubject,
+		&result.BinkPSCertIssuer,
+		&result.BinkPSCertNotAfter,
+		&result.BinkPSCertChainValid,
+		&result.BinkPSCertSelfSigned,
+		&result.BinkPSCertError,
 		&result.IfcicoTested,
 		&result.IfcicoSuccess,
 		&result.IfcicoResponseMs,
@@ -224,6 +244,11 @@ func (rp *ResultParser) ParseTestResultRow(scanner RowScanner, result *NodeTestR
 		&result.TelnetSuccess,
 		&result.TelnetResponseMs,
 		&result.TelnetError,
+		&result.TelnetMailerInfo,
+		&result.TelnetSystemName,
+		&result.TelnetSysop,
+		&telnetAddresses,
+		&result.TelnetResponseType,
 		&result.FTPTested,
 		&result.FTPSuccess,
 		&result.FTPResponseMs,
@@ -295,6 +320,18 @@ func (rp *ResultParser) ParseTestResultRow(scanner RowScanner, result *NodeTestR
 		&result.IsOperational,
 		&result.HasConnectivityIssues,
 		&result.AddressValidated,
+		&result.PathTCP,
+		&pathHops,
+		&result.PathLastHop,
+		&result.PathLastHopTTL,
+		&result.PathLastHopASN,
+		&result.PathLastHopAS,
+		&result.PathVerdict,
+		&result.UptimeWindow,
+		&result.IntermittentBySchedule,
+		&result.VantagePointsTested,
+		&result.VantagePointsReachable,
+		&result.VantageVerdict,
 		&result.TestedHostname,
 		&result.HostnameIndex,
 		&result.IsAggregated,
@@ -317,6 +354,8 @@ func (rp *ResultParser) ParseTestResultRow(scanner RowScanner, result *NodeTestR
 	result.BinkPAddresses = rp.parseInterfaceToStringArray(binkpAddresses)
 	result.BinkPCapabilities = rp.parseInterfaceToStringArray(binkpCapabilities)
 	result.IfcicoAddresses = rp.parseInterfaceToStringArray(ifcicoAddresses)
+	result.TelnetAddresses = rp.parseInterfaceToStringArray(telnetAddresses)
+	result.PathHops = rp.parseInterfaceToStringArray(pathHops)
 
 	return nil
 }
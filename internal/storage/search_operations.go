@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"strings"
 	"sync"
+	"sync/atomic"
 
 	"github.com/nodelistdb/internal/database"
 )
@@ -16,6 +17,12 @@ type SearchOperations struct {
 	resultParser ResultParserInterface
 	nodeOps      *NodeOperations // Reference for getting node history
 	mu           sync.RWMutex
+
+	// Distinct sysop names for the fuzzy sysop search, reloaded after
+	// sysopIndexTTL. indexMu only serializes reloads; searches read the
+	// current index without it.
+	indexMu    sync.Mutex
+	sysopIndex atomic.Pointer[sysopNameIndex]
 }
 
 // NewSearchOperations creates a new SearchOperations instance
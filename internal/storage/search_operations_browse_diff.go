@@ -0,0 +1,210 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// browseCoordinatorTypes is the node type whose system name names a group
+// at each browse diff level.
+var browseCoordinatorTypes = map[string]string{
+	BrowseLevelZones:   "Zone",
+	BrowseLevelRegions: "Region",
+	BrowseLevelNets:    "Host",
+}
+
+// GetBrowseDiff compares the from and to nodelists of a network over the
+// part of the hierarchy scope selects. Both should be nodelist dates of the
+// network; from must not be after to. Passing the same date twice compares
+// a nodelist with itself and finds every address unchanged.
+func (so *SearchOperations) GetBrowseDiff(ctx context.Context, from, to time.Time, scope BrowseDiffScope, domain string) (*BrowseDiff, error) {
+	if from.After(to) {
+		return nil, fmt.Errorf("browse diff runs from %s to the earlier %s",
+			from.Format("2006-01-02"), to.Format("2006-01-02"))
+	}
+	args := []interface{}{from, to, domain, domain}
+	switch scope.Level {
+	case BrowseLevelZones:
+	case BrowseLevelRegions:
+		args = append(args, scope.Zone)
+	case BrowseLevelNets:
+		args = append(args, scope.Zone, scope.Region)
+	case BrowseLevelNodes:
+		args = append(args, scope.Zone, scope.Net)
+	default:
+		return nil, fmt.Errorf("unknown browse diff level %q", scope.Level)
+	}
+
+	rows, err := so.db.Conn().QueryContext(ctx, so.queryBuilder.BrowseDiffNodesSQL(scope.Level), args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query browse diff nodes: %w", err)
+	}
+	defer rows.Close()
+
+	var nodes []database.Node
+	for rows.Next() {
+		node, err := so.resultParser.ParseNodeRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse browse diff node row: %w", err)
+		}
+		nodes = append(nodes, node)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating browse diff nodes: %w", err)
+	}
+	return so.diffBrowse(from, to, scope, domain, nodes), nil
+}
+
+// diffBrowse pairs the entries of the two dates by address, classifies each
+// pair with the field comparison GetNodeChanges uses, and tallies the pairs
+// by group. nodes must be in address order, as BrowseDiffNodesSQL returns
+// them, for Changes to come out ordered.
+func (so *SearchOperations) diffBrowse(from, to time.Time, scope BrowseDiffScope, domain string, nodes []database.Node) *BrowseDiff {
+	diff := &BrowseDiff{Domain: domain, From: from, To: to, Level: scope.Level}
+	switch scope.Level {
+	case BrowseLevelRegions:
+		diff.Zone = scope.Zone
+	case BrowseLevelNets:
+		diff.Zone, diff.Region = scope.Zone, scope.Region
+	case BrowseLevelNodes:
+		diff.Zone, diff.Net = scope.Zone, scope.Net
+	}
+
+	type listing struct{ old, new *database.Node }
+	var (
+		order    [][3]int
+		listings = make(map[[3]int]*listing)
+		toDay    int
+	)
+	for i := range nodes {
+		n := &nodes[i]
+		addr := [3]int{n.Zone, n.Net, n.Node}
+		l := listings[addr]
+		if l == nil {
+			l = &listing{}
+			listings[addr] = l
+			order = append(order, addr)
+		}
+		if n.NodelistDate.Equal(from) {
+			l.old = n
+		}
+		if n.NodelistDate.Equal(to) {
+			l.new = n
+			toDay = n.DayNumber
+		}
+	}
+
+	groups := make(map[int]*BrowseDiffGroup)
+	coordinator := browseCoordinatorTypes[scope.Level]
+	groupOf := func(n *database.Node, latest bool) *BrowseDiffGroup {
+		number := n.Net
+		switch scope.Level {
+		case BrowseLevelZones:
+			number = n.Zone
+		case BrowseLevelRegions:
+			number = 0
+			if n.Region != nil {
+				number = *n.Region
+			}
+		}
+		g := groups[number]
+		if g == nil {
+			g = &BrowseDiffGroup{Number: number}
+			groups[number] = g
+		}
+		// the later date's coordinator names the group when it lists one
+		if n.NodeType == coordinator && n.SystemName != "" && (latest || g.Name == "") {
+			g.Name = n.SystemName
+		}
+		return g
+	}
+
+	for _, addr := range order {
+		l := listings[addr]
+		change := database.NodeChange{Date: to, DayNumber: toDay, OldNode: l.old, NewNode: l.new}
+		switch {
+		case l.old == nil:
+			change.ChangeType = "added"
+		case l.new == nil:
+			change.ChangeType = "removed"
+		default:
+			if fields := so.detectFieldChanges(l.old, l.new); len(fields) > 0 {
+				change.ChangeType = "modified"
+				change.Changes = fields
+			}
+		}
+		diff.Totals.tally(change.ChangeType)
+
+		if scope.Level == BrowseLevelNodes {
+			if change.ChangeType != "" {
+				if change.Changes == nil {
+					change.Changes = make(map[string]string)
+				}
+				diff.Changes = append(diff.Changes, change)
+			}
+			continue
+		}
+
+		var oldGroup, newGroup *BrowseDiffGroup
+		if l.old != nil {
+			oldGroup = groupOf(l.old, false)
+			oldGroup.FromNodes++
+		}
+		if l.new != nil {
+			newGroup = groupOf(l.new, true)
+			newGroup.ToNodes++
+		}
+		switch {
+		case oldGroup != nil && newGroup != nil && oldGroup != newGroup:
+			// the address's net moved between regions
+			oldGroup.Removed++
+			newGroup.Added++
+		case newGroup != nil:
+			newGroup.tally(change.ChangeType)
+		default:
+			oldGroup.tally(change.ChangeType)
+		}
+	}
+
+	if scope.Level != BrowseLevelNodes {
+		diff.Groups = make([]BrowseDiffGroup, 0, len(groups))
+		for _, g := range groups {
+			diff.Groups = append(diff.Groups, *g)
+		}
+		sort.Slice(diff.Groups, func(i, j int) bool { return diff.Groups[i].Number < diff.Groups[j].Number })
+	}
+	return diff
+}
+
+// tally counts one address under its GetNodeChanges change type; an empty
+// type is an unchanged address.
+func (c *BrowseDiffCounts) tally(changeType string) {
+	switch changeType {
+	case "added":
+		c.Added++
+	case "removed":
+		c.Removed++
+	case "modified":
+		c.Modified++
+	default:
+		c.Unchanged++
+	}
+}
+
+// PreviousNodelistDate returns the latest of dates before date, the default
+// earlier side of a browse diff. dates may be in any order, as
+// GetAvailableDates returns them newest first.
+func PreviousNodelistDate(dates []time.Time, date time.Time) (time.Time, bool) {
+	var prev time.Time
+	found := false
+	for _, d := range dates {
+		if d.Before(date) && (!found || d.After(prev)) {
+			prev, found = d, true
+		}
+	}
+	return prev, found
+}
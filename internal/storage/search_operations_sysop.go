@@ -2,8 +2,11 @@ package storage
 
 import (
 	"context"
+	"database/sql"
 	"fmt"
+	"sort"
 	"strings"
+	"time"
 
 	"github.com/nodelistdb/internal/database"
 )
@@ -89,6 +92,245 @@ func (so *SearchOperations) GetUniqueSysops(ctx context.Context, nameFilter stri
 	}
 	defer rows.Close()
 
+	return scanSysopRows(rows)
+}
+
+// sysopIndexTTL is how long the fuzzy sysop search works from one load of
+// the distinct sysop names. They change only when a nodelist is imported.
+const sysopIndexTTL = 15 * time.Minute
+
+// maxFuzzySysopNames caps how many matched names one fuzzy search looks up,
+// so a query of a single initial does not turn into the whole sysop table.
+const maxFuzzySysopNames = 500
+
+// FuzzySearchSysops finds sysops whose names resemble name: the same words
+// with other transliterations or diacritics, in another order, with small
+// typos or as initials. Results are ranked by how well they match, then by
+// node count, and carry their match score.
+func (so *SearchOperations) FuzzySearchSysops(ctx context.Context, name string, limit, offset int) ([]SysopInfo, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, fmt.Errorf("sysop name cannot be empty")
+	}
+	if err := CheckFuzzySysopName(name); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultSysopLimit
+	} else if limit > MaxSysopLimit {
+		limit = MaxSysopLimit
+	}
+
+	if offset < 0 {
+		offset = 0
+	}
+
+	matches, err := so.matchSysopNames(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return []SysopInfo{}, nil
+	}
+
+	so.mu.RLock()
+	defer so.mu.RUnlock()
+
+	rows, err := so.db.Conn().QueryContext(ctx, so.queryBuilder.UniqueSysopsByNamesSQL(), sysopMatchNames(matches))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query fuzzy sysops: %w", err)
+	}
+	defer rows.Close()
+
+	sysops, err := scanSysopRows(rows)
+	if err != nil {
+		return nil, err
+	}
+
+	scores := sysopMatchScores(matches)
+	for i := range sysops {
+		sysops[i].Score = scores[sysops[i].Name]
+	}
+	sort.SliceStable(sysops, func(i, j int) bool {
+		if sysops[i].Score != sysops[j].Score {
+			return sysops[i].Score > sysops[j].Score
+		}
+		if sysops[i].NodeCount != sysops[j].NodeCount {
+			return sysops[i].NodeCount > sysops[j].NodeCount
+		}
+		return sysops[i].Name < sysops[j].Name
+	})
+
+	if offset >= len(sysops) {
+		return []SysopInfo{}, nil
+	}
+	sysops = sysops[offset:]
+	if len(sysops) > limit {
+		sysops = sysops[:limit]
+	}
+	return sysops, nil
+}
+
+// SearchNodesBySysopFuzzy is SearchNodesBySysop with the name matched as
+// FuzzySearchSysops matches it. Nodes of the best matching sysops come first.
+// An empty domain searches all networks.
+func (so *SearchOperations) SearchNodesBySysopFuzzy(ctx context.Context, sysopName string, limit int, domain string) ([]NodeSummary, error) {
+	if strings.TrimSpace(sysopName) == "" {
+		return nil, fmt.Errorf("sysop name cannot be empty")
+	}
+	if err := CheckFuzzySysopName(sysopName); err != nil {
+		return nil, err
+	}
+
+	if limit <= 0 {
+		limit = DefaultSysopLimit
+	} else if limit > MaxSysopLimit {
+		limit = MaxSysopLimit
+	}
+
+	matches, err := so.matchSysopNames(ctx, sysopName)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) == 0 {
+		return nil, nil
+	}
+
+	so.mu.RLock()
+	defer so.mu.RUnlock()
+
+	query := so.queryBuilder.SysopSearchByNamesSQL()
+	rows, err := so.db.Conn().QueryContext(ctx, query, sysopMatchNames(matches), domain, domain, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to search nodes by sysop: %w", err)
+	}
+	defer rows.Close()
+
+	var results []NodeSummary
+	for rows.Next() {
+		ns, err := so.resultParser.ParseNodeSummaryRow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse node summary row: %w", err)
+		}
+		results = append(results, ns)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sysop search rows: %w", err)
+	}
+
+	// The query orders by first appearance; keep that within each sysop
+	scores := sysopMatchScores(matches)
+	sort.SliceStable(results, func(i, j int) bool {
+		return scores[results[i].SysopName] > scores[results[j].SysopName]
+	})
+	return results, nil
+}
+
+// matchSysopNames matches name against the sysop name index, loading or
+// reloading the index first when it is missing or stale.
+func (so *SearchOperations) matchSysopNames(ctx context.Context, name string) ([]sysopNameMatch, error) {
+	idx, err := so.currentSysopIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := idx.match(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	if len(matches) > maxFuzzySysopNames {
+		matches = matches[:maxFuzzySysopNames]
+	}
+	return matches, nil
+}
+
+// currentSysopIndex returns the sysop name index, loading it when it is
+// missing or stale. One search reloads while the others wait for it.
+func (so *SearchOperations) currentSysopIndex(ctx context.Context) (*sysopNameIndex, error) {
+	if idx := so.sysopIndex.Load(); idx != nil && time.Since(idx.loadedAt) <= sysopIndexTTL {
+		return idx, nil
+	}
+
+	so.indexMu.Lock()
+	defer so.indexMu.Unlock()
+	if idx := so.sysopIndex.Load(); idx != nil && time.Since(idx.loadedAt) <= sysopIndexTTL {
+		return idx, nil // reloaded while this search waited
+	}
+	names, err := so.loadSysopNames(ctx)
+	if err != nil {
+		return nil, err
+	}
+	idx := newSysopNameIndex(names)
+	so.sysopIndex.Store(idx)
+	return idx, nil
+}
+
+func (so *SearchOperations) loadSysopNames(ctx context.Context) ([]string, error) {
+	so.mu.RLock()
+	defer so.mu.RUnlock()
+
+	rows, err := so.db.Conn().QueryContext(ctx, so.queryBuilder.SysopNamesSQL())
+	if err != nil {
+		return nil, fmt.Errorf("failed to load sysop names: %w", err)
+	}
+	defer rows.Close()
+
+	var names []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan sysop name: %w", err)
+		}
+		names = append(names, name)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sysop names: %w", err)
+	}
+	return names, nil
+}
+
+func sysopMatchNames(matches []sysopNameMatch) []string {
+	names := make([]string, len(matches))
+	for i, m := range matches {
+		names[i] = m.Name
+	}
+	return names
+}
+
+func sysopMatchScores(matches []sysopNameMatch) map[string]float64 {
+	scores := make(map[string]float64, len(matches))
+	for _, m := range matches {
+		scores[m.Name] = m.Score
+	}
+	return scores
+}
+
+// GetNodesBySysop returns all nodes for a specific sysop
+func (so *SearchOperations) GetNodesBySysop(ctx context.Context, sysopName string, limit int) ([]database.Node, error) {
+	if sysopName == "" {
+		return nil, fmt.Errorf("sysop name cannot be empty")
+	}
+
+	// Convert spaces to underscores as that's how data is stored
+	sysopName = strings.ReplaceAll(sysopName, " ", "_")
+
+	if limit <= 0 {
+		limit = DefaultSearchLimit
+	} else if limit > MaxSearchLimit {
+		limit = MaxSearchLimit
+	}
+
+	// Use NodeFilter with exact match on sysop name
+	filter := database.NodeFilter{
+		SysopName: &sysopName,
+		Limit:     limit,
+	}
+
+	return so.nodeOps.GetNodes(ctx, filter)
+}
+
+// scanSysopRows reads the rows of the unique sysops queries.
+func scanSysopRows(rows *sql.Rows) ([]SysopInfo, error) {
 	var sysops []SysopInfo
 	for rows.Next() {
 		var info SysopInfo
@@ -143,27 +385,3 @@ func (so *SearchOperations) GetUniqueSysops(ctx context.Context, nameFilter stri
 
 	return sysops, nil
 }
-
-// GetNodesBySysop returns all nodes for a specific sysop
-func (so *SearchOperations) GetNodesBySysop(ctx context.Context, sysopName string, limit int) ([]database.Node, error) {
-	if sysopName == "" {
-		return nil, fmt.Errorf("sysop name cannot be empty")
-	}
-
-	// Convert spaces to underscores as that's how data is stored
-	sysopName = strings.ReplaceAll(sysopName, " ", "_")
-
-	if limit <= 0 {
-		limit = DefaultSearchLimit
-	} else if limit > MaxSearchLimit {
-		limit = MaxSearchLimit
-	}
-
-	// Use NodeFilter with exact match on sysop name
-	filter := database.NodeFilter{
-		SysopName: &sysopName,
-		Limit:     limit,
-	}
-
-	return so.nodeOps.GetNodes(ctx, filter)
-}
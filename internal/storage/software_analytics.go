@@ -308,6 +308,148 @@ func (sao *SoftwareAnalyticsOperations) GetBinkdDetailedStats(ctx context.Contex
 	return dist, nil
 }
 
+// binkpOptionColumns pairs the binkp_opt_* verdict columns with the option
+// names shown for them, in display order.
+var binkpOptionColumns = []struct{ column, option string }{
+	{"binkp_opt_cram", "CRAM-MD5"},
+	{"binkp_opt_crypt", "CRYPT"},
+	{"binkp_opt_plz", "PLZ"},
+	{"binkp_opt_gz", "GZ"},
+	{"binkp_opt_bz2", "BZ2"},
+}
+
+// binkpOptionVerdicts are the non-empty values of a binkp_opt_* column.
+var binkpOptionVerdicts = []string{"ok", "failed", "untested"}
+
+// binkpOptionRow is one binkp_version's verdict counts, indexed like
+// binkpOptionColumns and binkpOptionVerdicts.
+type binkpOptionRow struct {
+	version string
+	nodes   int
+	counts  [][]int
+}
+
+// GetBinkPOptionSupport reports, per BinkP mailer version, the latest verdict
+// on each option its nodes advertise, so that mailers claiming options they
+// cannot honour stand out. An empty domain covers all FTN networks.
+func (sao *SoftwareAnalyticsOperations) GetBinkPOptionSupport(ctx context.Context, days int, domain string) (*BinkPOptionSupport, error) {
+	sao.mu.RLock()
+	defer sao.mu.RUnlock()
+
+	conn := sao.db.Conn()
+
+	var latest, advertised, counts []string
+	for _, c := range binkpOptionColumns {
+		latest = append(latest, fmt.Sprintf("argMax(%[1]s, test_time) as %[1]s", c.column))
+		advertised = append(advertised, c.column+" <> ''")
+		for _, v := range binkpOptionVerdicts {
+			counts = append(counts, fmt.Sprintf("countIf(%s = '%s')", c.column, v))
+		}
+	}
+
+	// Latest test per node, as for the distribution; a node's verdicts
+	// come from the same test as its version.
+	query := fmt.Sprintf(`
+		SELECT
+			binkp_version,
+			COUNT(*) as nodes,
+			%s
+		FROM (
+			SELECT
+				domain, zone, net, node,
+				argMax(binkp_version, test_time) as binkp_version,
+				%s
+			FROM node_test_results
+			WHERE binkp_tested = true
+				AND binkp_success = true
+				AND test_date >= today() - ?
+				%s
+			GROUP BY domain, zone, net, node
+			HAVING binkp_version <> '' AND (%s)
+		) AS latest_tests
+		GROUP BY binkp_version
+	`, strings.Join(counts, ",\n\t\t\t"), strings.Join(latest, ",\n\t\t\t\t"),
+		domainFilterSQL(domain, ""), strings.Join(advertised, " OR "))
+
+	rows, err := conn.QueryContext(ctx, query, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query binkp option verdicts: %w", err)
+	}
+	defer rows.Close()
+
+	var results []binkpOptionRow
+	for rows.Next() {
+		row := binkpOptionRow{counts: make([][]int, len(binkpOptionColumns))}
+		dest := []any{&row.version, &row.nodes}
+		for i := range row.counts {
+			row.counts[i] = make([]int, len(binkpOptionVerdicts))
+			for j := range row.counts[i] {
+				dest = append(dest, &row.counts[i][j])
+			}
+		}
+		if err := rows.Scan(dest...); err != nil {
+			return nil, fmt.Errorf("failed to scan binkp option verdicts: %w", err)
+		}
+		results = append(results, row)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read binkp option verdicts: %w", err)
+	}
+
+	return &BinkPOptionSupport{
+		Software:    aggregateBinkPOptions(results),
+		LastUpdated: time.Now(),
+	}, nil
+}
+
+// aggregateBinkPOptions merges version strings that parse to the same
+// software and version, and orders mailers by failed verdicts, then by
+// node count.
+func aggregateBinkPOptions(rows []binkpOptionRow) []BinkPSoftwareOptions {
+	bySoftware := make(map[string]*BinkPSoftwareOptions)
+	for _, row := range rows {
+		info := parseBinkPVersion(row.version)
+		if info == nil {
+			continue
+		}
+		key := info.Software + " " + info.Version
+		sw := bySoftware[key]
+		if sw == nil {
+			sw = &BinkPSoftwareOptions{Software: info.Software, Version: info.Version}
+			for _, c := range binkpOptionColumns {
+				sw.Options = append(sw.Options, BinkPOptionVerdicts{Option: c.option})
+			}
+			bySoftware[key] = sw
+		}
+		sw.Nodes += row.nodes
+		for i, c := range row.counts {
+			opt := &sw.Options[i]
+			opt.OK += c[0]
+			opt.Failed += c[1]
+			opt.Untested += c[2]
+			sw.Failed += c[1]
+		}
+	}
+
+	result := make([]BinkPSoftwareOptions, 0, len(bySoftware))
+	for _, sw := range bySoftware {
+		result = append(result, *sw)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Failed != result[j].Failed {
+			return result[i].Failed > result[j].Failed
+		}
+		if result[i].Nodes != result[j].Nodes {
+			return result[i].Nodes > result[j].Nodes
+		}
+		if result[i].Software != result[j].Software {
+			return result[i].Software < result[j].Software
+		}
+		return result[i].Version < result[j].Version
+	})
+	return result
+}
+
 // Helper functions for parsing and converting
 
 func parseBinkPVersion(version string) *softwareInfo {
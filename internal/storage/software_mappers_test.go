@@ -297,3 +297,48 @@ func TestPercentageCalculationAccuracy(t *testing.T) {
 		})
 	}
 }
+
+func TestAggregateBinkPOptions(t *testing.T) {
+	// counts are [option][ok, failed, untested] in binkpOptionColumns order:
+	// CRAM-MD5, CRYPT, PLZ, GZ, BZ2.
+	row := func(version string, nodes int, counts ...[3]int) binkpOptionRow {
+		r := binkpOptionRow{version: version, nodes: nodes}
+		for i := range binkpOptionColumns {
+			c := [3]int{}
+			if i < len(counts) {
+				c = counts[i]
+			}
+			r.counts = append(r.counts, c[:])
+		}
+		return r
+	}
+
+	got := aggregateBinkPOptions([]binkpOptionRow{
+		row("binkd/1.1a-115/Linux binkp/1.1", 40, [3]int{2, 0, 38}, [3]int{1, 0, 5}),
+		row("binkd/1.1a-115/Win32 binkp/1.1", 10, [3]int{0, 0, 10}),
+		row("Mystic/1.12A48 binkp/1.0", 3, [3]int{0, 3, 0}),
+		row("BinkIT/2.41,JSBinkP/4,sbbs3.19c/Linux binkp/1.1", 5, [3]int{}, [3]int{}, [3]int{0, 0, 5}),
+		row("", 7, [3]int{7, 0, 0}),
+	})
+
+	if len(got) != 3 {
+		t.Fatalf("got %d mailers, want 3: %+v", len(got), got)
+	}
+	// Failed verdicts first, then by node count.
+	if got[0].Software != "Mystic" || got[0].Failed != 3 || got[0].Options[0].Failed != 3 {
+		t.Errorf("first = %+v, want Mystic with 3 failed CRAM-MD5", got[0])
+	}
+	binkd := got[1]
+	if binkd.Software != "binkd" || binkd.Version != "1.1a-115" || binkd.Nodes != 50 {
+		t.Errorf("second = %s %s with %d nodes, want binkd 1.1a-115 merged across OSes with 50", binkd.Software, binkd.Version, binkd.Nodes)
+	}
+	if want := (BinkPOptionVerdicts{Option: "CRAM-MD5", OK: 2, Untested: 48}); binkd.Options[0] != want {
+		t.Errorf("binkd CRAM-MD5 = %+v, want %+v", binkd.Options[0], want)
+	}
+	if want := (BinkPOptionVerdicts{Option: "CRYPT", OK: 1, Untested: 5}); binkd.Options[1] != want {
+		t.Errorf("binkd CRYPT = %+v, want %+v", binkd.Options[1], want)
+	}
+	if got[2].Options[2].Untested != 5 || got[2].Options[4].Option != "BZ2" {
+		t.Errorf("third = %+v, want 5 untested PLZ", got[2])
+	}
+}
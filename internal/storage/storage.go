@@ -28,6 +28,11 @@ type Storage struct {
 	analyticsOperations *AnalyticsOperations
 	whoisOperations     *WhoisOperations
 	pstnDeadOperations  *PSTNDeadOperations
+	notifyOperations    *NotificationOperations
+	apiAccessOperations *APIAccessOperations
+	sysopIdentityOps    *SysopIdentityOperations
+	nodeTenancyOps      *NodeTenancyOperations
+	locationGeocodeOps  *LocationGeocodeOperations
 
 	// Components over node_test_results, the daemon's log of what it probed.
 	testHistoryOperations   *TestHistoryOperations
@@ -78,6 +83,24 @@ func (s *Storage) PSTNDeadOps() *PSTNDeadOperations {
 	return s.pstnDeadOperations
 }
 
+// SysopIdentityOps returns the sysop identity component, which resolves
+// sysop names into persons
+func (s *Storage) SysopIdentityOps() *SysopIdentityOperations {
+	return s.sysopIdentityOps
+}
+
+// NodeTenancyOps returns the node tenancy component, which splits an
+// address's history by occupant
+func (s *Storage) NodeTenancyOps() *NodeTenancyOperations {
+	return s.nodeTenancyOps
+}
+
+// LocationGeocodeOps returns the location geocoding component, which places
+// nodes on the map by their Location field
+func (s *Storage) LocationGeocodeOps() *LocationGeocodeOperations {
+	return s.locationGeocodeOps
+}
+
 // New creates a new Storage instance with ClickHouse-specific components
 func New(db database.DatabaseInterface) (*Storage, error) {
 	// Always use ClickHouse components (only supported database type)
@@ -99,6 +122,11 @@ func New(db database.DatabaseInterface) (*Storage, error) {
 	storage.pstnDeadOperations = NewPSTNDeadOperations(db)
 	storage.analyticsOperations = NewAnalyticsOperations(db, queryBuilder, resultParser, storage.pstnDeadOperations)
 	storage.whoisOperations = NewWhoisOperations(db)
+	storage.notifyOperations = NewNotificationOperations(db, queryBuilder)
+	storage.apiAccessOperations = NewAPIAccessOperations(db)
+	storage.sysopIdentityOps = NewSysopIdentityOperations(db)
+	storage.nodeTenancyOps = NewNodeTenancyOperations(db)
+	storage.locationGeocodeOps = NewLocationGeocodeOperations(db)
 
 	testQueryBuilder := NewTestQueryBuilder()
 	storage.testHistoryOperations = NewTestHistoryOperations(db, testQueryBuilder, resultParser)
@@ -133,6 +161,10 @@ func (s *Storage) GetNodes(ctx context.Context, filter database.NodeFilter) ([]d
 	return s.nodeOperations.GetNodes(ctx, filter)
 }
 
+func (s *Storage) StreamNodes(ctx context.Context, filter database.NodeFilter, fn func(database.Node) error) error {
+	return s.nodeOperations.StreamNodes(ctx, filter, fn)
+}
+
 func (s *Storage) GetNodeHistory(ctx context.Context, zone, net, node int, domain string) ([]database.Node, error) {
 	return s.nodeOperations.GetNodeHistory(ctx, zone, net, node, domain)
 }
@@ -157,6 +189,50 @@ func (s *Storage) GetMaxNodelistDate(ctx context.Context, domain string) (time.T
 	return s.nodeOperations.GetMaxNodelistDate(ctx, domain)
 }
 
+func (s *Storage) RegisterNodelistFile(file database.NodelistFile) error {
+	return s.nodeOperations.RegisterNodelistFile(file)
+}
+
+func (s *Storage) GetNodelistFiles(ctx context.Context, domain string) ([]database.NodelistFile, error) {
+	return s.nodeOperations.GetNodelistFiles(ctx, domain)
+}
+
+func (s *Storage) InsertNodelistLines(lines []database.NodelistLine) error {
+	return s.nodeOperations.InsertNodelistLines(lines)
+}
+
+func (s *Storage) GetNodelistLines(ctx context.Context, domain string, date time.Time) (nodes, text []database.NodelistLine, err error) {
+	return s.nodeOperations.GetNodelistLines(ctx, domain, date)
+}
+
+func (s *Storage) GetNodelistNodes(ctx context.Context, domain string, date time.Time) ([]database.Node, error) {
+	return s.nodeOperations.GetNodelistNodes(ctx, domain, date)
+}
+
+func (s *Storage) InsertNotificationDelivery(ctx context.Context, d database.NotificationDelivery) error {
+	return s.notifyOperations.InsertDelivery(ctx, d)
+}
+
+func (s *Storage) GetNotificationSubscriptions(ctx context.Context) ([]database.NotificationSubscription, error) {
+	return s.notifyOperations.GetNotificationSubscriptions(ctx)
+}
+
+func (s *Storage) InsertNotificationSubscription(ctx context.Context, sub database.NotificationSubscription) error {
+	return s.notifyOperations.InsertNotificationSubscription(ctx, sub)
+}
+
+func (s *Storage) GetAPIKeys(ctx context.Context) ([]database.APIKey, error) {
+	return s.apiAccessOperations.GetAPIKeys(ctx)
+}
+
+func (s *Storage) InsertAPIUsage(ctx context.Context, usage []database.APIUsage) error {
+	return s.apiAccessOperations.InsertAPIUsage(ctx, usage)
+}
+
+func (s *Storage) GetAPIUsage(ctx context.Context, filter database.APIUsageFilter) ([]database.APIUsageSummary, error) {
+	return s.apiAccessOperations.GetAPIUsage(ctx, filter)
+}
+
 func (s *Storage) GetDomains(ctx context.Context) ([]DomainInfo, error) {
 	return s.nodeOperations.GetDomains(ctx)
 }
@@ -174,6 +250,10 @@ func (s *Storage) SearchPoints(ctx context.Context, filter database.PointFilter)
 	return s.pointOperations.SearchPoints(ctx, filter)
 }
 
+func (s *Storage) StreamPoints(ctx context.Context, filter database.PointFilter, fn func(database.Point) error) error {
+	return s.pointOperations.StreamPoints(ctx, filter, fn)
+}
+
 func (s *Storage) SearchPointsWithLifetime(ctx context.Context, filter database.PointFilter) ([]PointSummary, error) {
 	return s.pointOperations.SearchPointsWithLifetime(ctx, filter)
 }
@@ -229,6 +309,26 @@ func (s *Storage) GetNodesBySysop(ctx context.Context, sysopName string, limit i
 	return s.searchOperations.GetNodesBySysop(ctx, sysopName, limit)
 }
 
+func (s *Storage) GetSysopIdentity(ctx context.Context, id string) (*SysopIdentity, error) {
+	return s.sysopIdentityOps.GetSysopIdentity(ctx, id)
+}
+
+func (s *Storage) GetNodeSysopIdentities(ctx context.Context, zone, net, node int, domain string) ([]SysopIdentityRef, error) {
+	return s.sysopIdentityOps.GetNodeSysopIdentities(ctx, zone, net, node, domain)
+}
+
+func (s *Storage) GetNodeTenancies(ctx context.Context, zone, net, node int, domain string) ([]NodeTenancy, error) {
+	return s.nodeTenancyOps.GetNodeTenancies(ctx, zone, net, node, domain)
+}
+
+func (s *Storage) FuzzySearchSysops(ctx context.Context, name string, limit, offset int) ([]SysopInfo, error) {
+	return s.searchOperations.FuzzySearchSysops(ctx, name, limit, offset)
+}
+
+func (s *Storage) SearchNodesBySysopFuzzy(ctx context.Context, sysopName string, limit int, domain string) ([]NodeSummary, error) {
+	return s.searchOperations.SearchNodesBySysopFuzzy(ctx, sysopName, limit, domain)
+}
+
 func (s *Storage) SearchNodesWithLifetime(ctx context.Context, filter database.NodeFilter) ([]NodeSummary, error) {
 	return s.searchOperations.SearchNodesWithLifetime(ctx, filter)
 }
@@ -267,6 +367,10 @@ func (s *Storage) GetNearestAvailableDate(ctx context.Context, requestedDate tim
 	return s.statsOperations.GetNearestAvailableDate(ctx, requestedDate, domain)
 }
 
+func (s *Storage) GetNodeMap(ctx context.Context, date time.Time, domain string) (*NodeMap, error) {
+	return s.locationGeocodeOps.GetNodeMap(ctx, date, domain)
+}
+
 func (s *Storage) GetNodeCountHistory(ctx context.Context, domain string) ([]NodeCountByDate, error) {
 	return s.statsOperations.GetNodeCountHistory(ctx, domain)
 }
@@ -287,15 +391,31 @@ func (s *Storage) GetBrowseNodes(ctx context.Context, date time.Time, zone, net
 	return s.statsOperations.GetBrowseNodes(ctx, date, zone, net, domain)
 }
 
+func (s *Storage) GetBrowseDiff(ctx context.Context, from, to time.Time, scope BrowseDiffScope, domain string) (*BrowseDiff, error) {
+	return s.searchOperations.GetBrowseDiff(ctx, from, to, scope, domain)
+}
+
 // Test Operations delegated methods
 func (s *Storage) GetNodeTestHistory(ctx context.Context, zone, net, node int, days int, domain string) ([]NodeTestResult, error) {
 	return s.testHistoryOperations.GetNodeTestHistory(ctx, zone, net, node, days, domain)
 }
 
+func (s *Storage) StreamTestResults(ctx context.Context, filter database.NodeFilter, fn func(NodeTestResult) error) error {
+	return s.testHistoryOperations.StreamTestResults(ctx, filter, fn)
+}
+
 func (s *Storage) GetDetailedTestResult(ctx context.Context, zone, net, node int, testTime string, domain string) (*NodeTestResult, error) {
 	return s.testHistoryOperations.GetDetailedTestResult(ctx, zone, net, node, testTime, domain)
 }
 
+func (s *Storage) GetTestTranscripts(ctx context.Context, zone, net, node int, testTime string, domain string) ([]TestTranscript, error) {
+	return s.testHistoryOperations.GetTestTranscripts(ctx, zone, net, node, testTime, domain)
+}
+
+func (s *Storage) GetVantageResults(ctx context.Context, zone, net, node int, testTime string, domain string) ([]VantageResult, error) {
+	return s.testHistoryOperations.GetVantageResults(ctx, zone, net, node, testTime, domain)
+}
+
 func (s *Storage) GetNodeReachabilityStats(ctx context.Context, zone, net, node int, days int, domain string) (*NodeReachabilityStats, error) {
 	return s.reachabilityOperations.GetNodeReachabilityStats(ctx, zone, net, node, days, domain)
 }
@@ -360,6 +480,10 @@ func (s *Storage) GetVModemUnconfirmedNodes(ctx context.Context, limit int, days
 	return s.protocolOperations.GetVModemUnconfirmedNodes(ctx, limit, days, includeZeroNodes, domain)
 }
 
+func (s *Storage) GetBinkPSCertificateIssues(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error) {
+	return s.protocolOperations.GetBinkPSCertificateIssues(ctx, limit, days, includeZeroNodes, domain)
+}
+
 func (s *Storage) GetFTPEnabledNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error) {
 	return s.protocolOperations.GetFTPEnabledNodes(ctx, limit, days, includeZeroNodes, domain)
 }
@@ -396,10 +520,18 @@ func (s *Storage) GetBinkdDetailedStats(ctx context.Context, days int, domain st
 	return s.softwareOperations.GetBinkdDetailedStats(ctx, days, domain)
 }
 
+func (s *Storage) GetBinkPOptionSupport(ctx context.Context, days int, domain string) (*BinkPOptionSupport, error) {
+	return s.softwareOperations.GetBinkPOptionSupport(ctx, days, domain)
+}
+
 func (s *Storage) GetGeoHostingDistribution(ctx context.Context, days int, domain string) (*GeoHostingDistribution, error) {
 	return s.geoOperations.GetGeoHostingDistribution(ctx, days, domain)
 }
 
+func (s *Storage) GetUnreachableByASN(ctx context.Context, days int, domain string) ([]UnreachableASNStats, error) {
+	return s.geoOperations.GetUnreachableByASN(ctx, days, domain)
+}
+
 func (s *Storage) GetNodesByCountry(ctx context.Context, countryCode string, days int, domain string) ([]NodeTestResult, error) {
 	return s.geoOperations.GetNodesByCountry(ctx, countryCode, days, domain)
 }
@@ -0,0 +1,542 @@
+package storage
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Sysop identity resolution. A person's FTN career is scattered over many
+// records: a point that became a node, a node that moved nets, a second
+// system in fsxnet, a name written "Sergey_Ivanov" in one decade and
+// "Сергей Иванов" in the next. Each (address, sysop name) pair is a tenure;
+// tenures are joined into one person by
+//
+//   - name: the same name once transliteration, diacritics and word order
+//     are factored out, or a near spelling (see sysop_match.go), within one
+//     zone. Two people of the same name in one zone are rare enough; across
+//     zones they are not, so names alone never cross a zone.
+//   - identifier: a hostname or email address both advertised. Unlike a
+//     name this crosses zones and networks. An identifier used under more
+//     than a few names is a shared host or a hub's domain, not a person,
+//     and is ignored, as is a point advertising its boss node's host.
+//   - handover: one name following another at the same address without a
+//     gap, the two close enough to be a respelling rather than a new sysop.
+
+const (
+	// similarSysopScore is how alike two differently keyed names must be to
+	// be taken for one person. Stricter than the search's minFuzzySysopScore:
+	// a search shows its candidates, a resolution commits to them.
+	similarSysopScore = 0.85
+
+	// sysopHandoverGap is how long an address may sit unlisted between one
+	// spelling and the next for the second still to count as a respelling.
+	sysopHandoverGap = 60 * 24 * time.Hour
+
+	// maxIdentifierNames is how many different names may share a hostname or
+	// email address before it stops identifying a person.
+	maxIdentifierNames = 3
+)
+
+// placeholderSysopNames are what nodelists list when there is no sysop to
+// name. They link nobody.
+var placeholderSysopNames = map[string]bool{
+	"sysop": true, "unknown": true, "vacant": true, "none": true, "nobody": true,
+	"n a": true, "na": true, "tba": true, "tbd": true, "reserved": true,
+	"free": true, "unlisted": true, "various": true, "coordinator": true,
+}
+
+// sysopTenureKey identifies a tenure across resolutions.
+func sysopTenureKey(t SysopTenure) string {
+	return fmt.Sprintf("%s#%d:%d/%d.%d|%s", t.Domain, t.Zone, t.Net, t.Node, t.Point, t.SysopName)
+}
+
+// sysopNameKey is a name with spelling and word order factored out: the
+// sorted phonetic keys of its words. Placeholders have none.
+func sysopNameKey(words []sysopWord) string {
+	folded := make([]string, len(words))
+	keys := make([]string, len(words))
+	for i, w := range words {
+		folded[i], keys[i] = w.folded, w.key
+	}
+	if len(words) == 0 || placeholderSysopNames[strings.Join(folded, " ")] {
+		return ""
+	}
+	sort.Strings(keys)
+	return strings.Join(keys, " ")
+}
+
+// sysopNamesSimilar reports whether two names score at least min against
+// each other in both directions, so "John Smith" is not similar to "John
+// Q Smith Jr" merely because every word of the first is in the second.
+func sysopNamesSimilar(a, b []sysopWord, min float64) bool {
+	return scoreSysopName(a, b) >= min && scoreSysopName(b, a) >= min
+}
+
+// unionFind is a disjoint-set forest over tenure indexes.
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	uf := make(unionFind, n)
+	for i := range uf {
+		uf[i] = i
+	}
+	return uf
+}
+
+func (uf unionFind) find(i int) int {
+	for uf[i] != i {
+		uf[i] = uf[uf[i]]
+		i = uf[i]
+	}
+	return i
+}
+
+func (uf unionFind) union(a, b int) {
+	ra, rb := uf.find(a), uf.find(b)
+	if ra == rb {
+		return
+	}
+	// The lower index becomes the root so clusters come out in a stable order
+	if rb < ra {
+		ra, rb = rb, ra
+	}
+	uf[rb] = ra
+}
+
+// tenureLinker joins tenures and records why.
+type tenureLinker struct {
+	tenures  []SysopTenure
+	words    [][]sysopWord
+	nameKeys []string
+	uf       unionFind
+}
+
+func (l *tenureLinker) link(a, b int, evidence string) {
+	l.uf.union(a, b)
+	l.addEvidence(a, evidence)
+	l.addEvidence(b, evidence)
+}
+
+func (l *tenureLinker) addEvidence(i int, evidence string) {
+	for _, e := range l.tenures[i].Evidence {
+		if e == evidence {
+			return
+		}
+	}
+	l.tenures[i].Evidence = append(l.tenures[i].Evidence, evidence)
+}
+
+// clusterSysopTenures groups tenures into persons. Each person's tenures are
+// oldest first, and persons are ordered by their oldest tenure. The tenures
+// are copied, with Evidence filled in.
+func clusterSysopTenures(tenures []SysopTenure) [][]SysopTenure {
+	l := &tenureLinker{
+		tenures:  make([]SysopTenure, len(tenures)),
+		words:    make([][]sysopWord, len(tenures)),
+		nameKeys: make([]string, len(tenures)),
+		uf:       newUnionFind(len(tenures)),
+	}
+	copy(l.tenures, tenures)
+	sort.SliceStable(l.tenures, func(i, j int) bool {
+		if !l.tenures[i].FirstSeen.Equal(l.tenures[j].FirstSeen) {
+			return l.tenures[i].FirstSeen.Before(l.tenures[j].FirstSeen)
+		}
+		return sysopTenureKey(l.tenures[i]) < sysopTenureKey(l.tenures[j])
+	})
+	for i := range l.tenures {
+		l.tenures[i].Evidence = nil
+		l.words[i] = sysopWords(l.tenures[i].SysopName)
+		l.nameKeys[i] = sysopNameKey(l.words[i])
+	}
+
+	l.linkByName()
+	l.linkBySimilarName()
+	l.linkByIdentifiers()
+	l.linkByHandover()
+
+	groups := make(map[int][]SysopTenure)
+	var roots []int
+	for i, t := range l.tenures {
+		r := l.uf.find(i)
+		if _, ok := groups[r]; !ok {
+			roots = append(roots, r)
+		}
+		groups[r] = append(groups[r], t)
+	}
+	clusters := make([][]SysopTenure, len(roots))
+	for i, r := range roots {
+		clusters[i] = groups[r]
+	}
+	return clusters
+}
+
+// nameScope is where a name alone identifies a person: a zone for full
+// names, a single net for one-word names, which are far more often shared.
+func (l *tenureLinker) nameScope(i int) string {
+	t := l.tenures[i]
+	if len(l.words[i]) == 1 {
+		return fmt.Sprintf("%s#%d:%d|%s", t.Domain, t.Zone, t.Net, l.nameKeys[i])
+	}
+	return fmt.Sprintf("%s#%d|%s", t.Domain, t.Zone, l.nameKeys[i])
+}
+
+func (l *tenureLinker) linkByName() {
+	first := make(map[string]int)
+	for i, key := range l.nameKeys {
+		if key == "" {
+			continue
+		}
+		scope := l.nameScope(i)
+		if j, ok := first[scope]; ok {
+			l.link(j, i, "name")
+		} else {
+			first[scope] = i
+		}
+	}
+}
+
+func (l *tenureLinker) linkBySimilarName() {
+	// One representative per distinct full name and zone, blocked by zone,
+	// word count and the first sound of each word: a near spelling rarely
+	// changes those, and comparing within blocks keeps this far from
+	// quadratic in the number of names.
+	blocks := make(map[string][]int)
+	seen := make(map[string]bool)
+	for i, key := range l.nameKeys {
+		if key == "" || len(l.words[i]) < 2 {
+			continue
+		}
+		scope := l.nameScope(i)
+		if seen[scope] {
+			continue
+		}
+		seen[scope] = true
+
+		initials := make([]string, 0, len(l.words[i]))
+		for _, w := range l.words[i] {
+			initials = append(initials, w.key[:1])
+		}
+		sort.Strings(initials)
+		t := l.tenures[i]
+		block := fmt.Sprintf("%s#%d|%s", t.Domain, t.Zone, strings.Join(initials, ""))
+		blocks[block] = append(blocks[block], i)
+	}
+
+	for _, reps := range blocks {
+		for a := 0; a < len(reps); a++ {
+			for b := a + 1; b < len(reps); b++ {
+				i, j := reps[a], reps[b]
+				if sysopNamesSimilar(l.words[i], l.words[j], similarSysopScore) {
+					l.link(i, j, "similar-name")
+				}
+			}
+		}
+	}
+}
+
+func (l *tenureLinker) linkByIdentifiers() {
+	users := make(map[string][]int)
+	for i, t := range l.tenures {
+		for _, h := range t.Hosts {
+			if h = normalizeSysopHost(h); h != "" {
+				users["host:"+h] = append(users["host:"+h], i)
+			}
+		}
+		for _, e := range t.Emails {
+			if e = strings.ToLower(strings.TrimSpace(e)); strings.Contains(e, "@") {
+				users["email:"+e] = append(users["email:"+e], i)
+			}
+		}
+	}
+
+	ids := make([]string, 0, len(users))
+	for id := range users {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		members := l.identifierMembers(users[id])
+		if len(members) < 2 {
+			continue
+		}
+		evidence := id
+		if email, ok := strings.CutPrefix(id, "email:"); ok {
+			evidence = "email:" + redactSysopEmail(email)
+		}
+		for _, m := range members[1:] {
+			l.link(members[0], m, evidence)
+		}
+	}
+}
+
+// identifierMembers filters the tenures advertising one identifier down to
+// those it identifies: none when too many names share it, and never a point
+// whose boss node advertises it too.
+func (l *tenureLinker) identifierMembers(users []int) []int {
+	names := make(map[string]bool)
+	bosses := make(map[string]bool)
+	for _, i := range users {
+		key := l.nameKeys[i]
+		if key == "" {
+			key = strings.ToLower(l.tenures[i].SysopName)
+		}
+		names[key] = true
+		if t := l.tenures[i]; !t.IsPoint {
+			bosses[fmt.Sprintf("%s#%d:%d/%d", t.Domain, t.Zone, t.Net, t.Node)] = true
+		}
+	}
+	if len(names) > maxIdentifierNames {
+		return nil
+	}
+
+	members := make([]int, 0, len(users))
+	for _, i := range users {
+		t := l.tenures[i]
+		if t.IsPoint && bosses[fmt.Sprintf("%s#%d:%d/%d", t.Domain, t.Zone, t.Net, t.Node)] {
+			continue
+		}
+		if len(members) > 0 && members[len(members)-1] == i {
+			continue // the same tenure listing the identifier twice
+		}
+		members = append(members, i)
+	}
+	return members
+}
+
+func (l *tenureLinker) linkByHandover() {
+	byAddress := make(map[string][]int)
+	var addresses []string
+	for i, t := range l.tenures {
+		addr := fmt.Sprintf("%s#%d:%d/%d.%d", t.Domain, t.Zone, t.Net, t.Node, t.Point)
+		if _, ok := byAddress[addr]; !ok {
+			addresses = append(addresses, addr)
+		}
+		byAddress[addr] = append(byAddress[addr], i)
+	}
+
+	for _, addr := range addresses {
+		// Tenures are already oldest first
+		list := byAddress[addr]
+		for k := 1; k < len(list); k++ {
+			prev, next := list[k-1], list[k]
+			if l.nameKeys[prev] == "" || l.nameKeys[next] == "" || l.nameKeys[prev] == l.nameKeys[next] {
+				continue
+			}
+			if l.tenures[next].FirstSeen.After(l.tenures[prev].LastSeen.Add(sysopHandoverGap)) {
+				continue
+			}
+			if sysopNamesSimilar(l.words[prev], l.words[next], minFuzzySysopScore) {
+				l.link(prev, next, "handover")
+			}
+		}
+	}
+}
+
+// normalizeSysopHost lower-cases a hostname and drops what cannot identify
+// anyone: IP addresses, which ISPs hand from one customer to the next, and
+// values without a dot.
+func normalizeSysopHost(host string) string {
+	host = strings.ToLower(strings.TrimSuffix(strings.TrimSpace(host), "."))
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if !strings.Contains(host, ".") || net.ParseIP(strings.Trim(host, "[]")) != nil {
+		return ""
+	}
+	return host
+}
+
+// redactSysopEmail keeps an email address's domain and the first letter of
+// its mailbox: enough to show why two tenures were joined without putting
+// the address on a web page.
+func redactSysopEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return email
+	}
+	return email[:1] + "…" + email[at:]
+}
+
+// mintSysopID derives a new identity's ID from its oldest tenure, avoiding
+// every ID in taken.
+func mintSysopID(anchor string, taken map[string]bool) string {
+	for n := 0; ; n++ {
+		seed := anchor
+		if n > 0 {
+			seed = fmt.Sprintf("%s#%d", anchor, n)
+		}
+		sum := sha256.Sum256([]byte(seed))
+		id := hex.EncodeToString(sum[:6])
+		if !taken[id] {
+			return id
+		}
+	}
+}
+
+// assignSysopIDs gives each cluster an ID, keeping the IDs of the previous
+// resolution stable. previous maps tenure keys to the ID each had;
+// previousRedirects maps IDs retired before to their successors, or to ""
+// for those retired with none.
+//
+// A cluster keeps the old ID most of its tenures had, unless a cluster with
+// more of them claims it first. An old ID no cluster keeps (two persons
+// merged, or one split) becomes a redirect to the cluster holding most of its
+// tenures. One whose tenures are all gone, or whose redirect leads nowhere
+// any more, stays in redirects with an empty target, so retired IDs are
+// carried from run to run and never minted again.
+func assignSysopIDs(clusters [][]SysopTenure, previous, previousRedirects map[string]string) (ids []string, redirects map[string]string, kept int) {
+	type claim struct {
+		cluster int
+		id      string
+		votes   int
+	}
+	var claims []claim
+	votesFor := make(map[string]map[int]int) // old ID -> cluster -> votes
+	for c, tenures := range clusters {
+		votes := make(map[string]int)
+		for _, t := range tenures {
+			if old, ok := previous[sysopTenureKey(t)]; ok {
+				votes[old]++
+			}
+		}
+		for id, v := range votes {
+			claims = append(claims, claim{c, id, v})
+			if votesFor[id] == nil {
+				votesFor[id] = make(map[int]int)
+			}
+			votesFor[id][c] = v
+		}
+	}
+	sort.Slice(claims, func(i, j int) bool {
+		if claims[i].votes != claims[j].votes {
+			return claims[i].votes > claims[j].votes
+		}
+		if claims[i].cluster != claims[j].cluster {
+			return claims[i].cluster < claims[j].cluster
+		}
+		return claims[i].id < claims[j].id
+	})
+
+	ids = make([]string, len(clusters))
+	claimedBy := make(map[string]int)
+	for _, cl := range claims {
+		if ids[cl.cluster] != "" {
+			continue
+		}
+		if _, taken := claimedBy[cl.id]; taken {
+			continue
+		}
+		ids[cl.cluster] = cl.id
+		claimedBy[cl.id] = cl.cluster
+		kept++
+	}
+
+	taken := make(map[string]bool)
+	for _, id := range previous {
+		taken[id] = true
+	}
+	for id := range previousRedirects {
+		taken[id] = true
+	}
+	for c := range clusters {
+		if ids[c] == "" {
+			ids[c] = mintSysopID(sysopTenureKey(clusters[c][0]), taken)
+			taken[ids[c]] = true
+		}
+	}
+
+	redirects = make(map[string]string)
+	for old, votes := range votesFor {
+		if _, ok := claimedBy[old]; ok {
+			continue
+		}
+		best, bestVotes := -1, 0
+		for c, v := range votes {
+			if v > bestVotes || (v == bestVotes && c < best) {
+				best, bestVotes = c, v
+			}
+		}
+		redirects[old] = ids[best]
+	}
+	for old, target := range previousRedirects {
+		if _, ok := claimedBy[target]; ok {
+			redirects[old] = target
+		} else {
+			redirects[old] = redirects[target] // "" when the target is gone too
+		}
+	}
+	for _, old := range previous {
+		if _, ok := claimedBy[old]; !ok {
+			if _, ok := redirects[old]; !ok {
+				redirects[old] = ""
+			}
+		}
+	}
+	return ids, redirects, kept
+}
+
+// buildSysopIdentity summarizes a person's tenures, which must be oldest
+// first.
+func buildSysopIdentity(id string, tenures []SysopTenure) SysopIdentity {
+	identity := SysopIdentity{ID: id, Career: tenures}
+	if len(tenures) == 0 {
+		return identity
+	}
+
+	listed := make(map[string]time.Duration)
+	firstUse := make(map[string]int)
+	domains := make(map[string]bool)
+	identity.FirstSeen = tenures[0].FirstSeen
+	for i, t := range tenures {
+		listed[t.SysopName] += t.LastSeen.Sub(t.FirstSeen) + 24*time.Hour
+		if _, ok := firstUse[t.SysopName]; !ok {
+			firstUse[t.SysopName] = i
+			identity.Aliases = append(identity.Aliases, t.SysopName)
+		}
+		if !domains[t.Domain] {
+			domains[t.Domain] = true
+			identity.Domains = append(identity.Domains, t.Domain)
+		}
+		if t.LastSeen.After(identity.LastSeen) {
+			identity.LastSeen = t.LastSeen
+		}
+		if t.IsPoint {
+			identity.PointCount++
+		} else {
+			identity.NodeCount++
+		}
+		if t.CurrentlyListed {
+			identity.Active++
+		}
+	}
+
+	// The name is the spelling listed longest, the oldest on a tie
+	sort.SliceStable(identity.Aliases, func(i, j int) bool {
+		a, b := identity.Aliases[i], identity.Aliases[j]
+		if listed[a] != listed[b] {
+			return listed[a] > listed[b]
+		}
+		return firstUse[a] < firstUse[b]
+	})
+	identity.Name = identity.Aliases[0]
+	sort.Strings(identity.Domains)
+	return identity
+}
+
+// resolveSysopIdentities clusters tenures into identities with stable IDs;
+// see assignSysopIDs for previous and previousRedirects.
+func resolveSysopIdentities(tenures []SysopTenure, previous, previousRedirects map[string]string) ([]SysopIdentity, map[string]string, int) {
+	clusters := clusterSysopTenures(tenures)
+	ids, redirects, kept := assignSysopIDs(clusters, previous, previousRedirects)
+	identities := make([]SysopIdentity, len(clusters))
+	for i, c := range clusters {
+		identities[i] = buildSysopIdentity(ids[i], c)
+	}
+	return identities, redirects, kept
+}
@@ -0,0 +1,397 @@
+package storage
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/emailflags"
+)
+
+// SysopIdentityOperations resolves node and point records into persons (see
+// sysop_identity.go) and reads the result back.
+//
+// Each resolution is written whole under a new resolved_at and the previous
+// one deleted afterwards, so readers, which only ever look at the newest
+// resolved_at, never see a half-written one.
+type SysopIdentityOperations struct {
+	db database.DatabaseInterface
+}
+
+// NewSysopIdentityOperations creates a new SysopIdentityOperations instance
+func NewSysopIdentityOperations(db database.DatabaseInterface) *SysopIdentityOperations {
+	return &SysopIdentityOperations{db: db}
+}
+
+// maxTenureConfigs caps how many distinct internet configurations of one
+// tenure are read for its hostnames and email addresses.
+const maxTenureConfigs = 16
+
+const latestSysopIdentitiesSQL = `resolved_at = (SELECT max(resolved_at) FROM sysop_identities)`
+
+// ResolveSysopIdentities re-resolves every node and point tenure in the
+// database into persons and replaces the stored resolution. Persons keep the
+// IDs the previous resolution gave them wherever their tenures allow.
+func (so *SysopIdentityOperations) ResolveSysopIdentities(ctx context.Context) (*SysopResolution, error) {
+	tenures, err := so.loadNodeTenures(ctx)
+	if err != nil {
+		return nil, err
+	}
+	points, err := so.loadPointTenures(ctx)
+	if err != nil {
+		return nil, err
+	}
+	tenures = append(tenures, points...)
+
+	previous, previousRedirects, err := so.loadPreviousResolution(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	identities, redirects, kept := resolveSysopIdentities(tenures, previous, previousRedirects)
+
+	result := &SysopResolution{
+		Tenures:    len(tenures),
+		Identities: len(identities),
+		Kept:       kept,
+		ResolvedAt: time.Now().UTC().Truncate(time.Second),
+	}
+	for _, identity := range identities {
+		if len(identity.Career) > 1 {
+			result.Linked++
+		}
+	}
+	for _, target := range redirects {
+		if target != "" {
+			result.Redirects++
+		}
+	}
+
+	if err := so.insertResolution(ctx, identities, redirects, result.ResolvedAt); err != nil {
+		return nil, err
+	}
+
+	// Only now is the previous resolution unreachable and safe to drop
+	for _, table := range []string{"sysop_identities", "sysop_identity_redirects"} {
+		if _, err := so.db.Conn().ExecContext(ctx,
+			"DELETE FROM "+table+" WHERE resolved_at < ?", result.ResolvedAt); err != nil {
+			return nil, fmt.Errorf("failed to delete the previous resolution from %s: %w", table, err)
+		}
+	}
+	return result, nil
+}
+
+// loadNodeTenures reads one tenure per node address and sysop name.
+func (so *SysopIdentityOperations) loadNodeTenures(ctx context.Context) ([]SysopTenure, error) {
+	query := fmt.Sprintf(`
+		WITH domain_max AS (
+			SELECT domain, max(nodelist_date) AS max_date FROM nodes GROUP BY domain
+		)
+		SELECT t.domain, t.zone, t.net, t.node, toInt32(0), t.sysop_name, t.system_name,
+			t.first_seen, t.last_seen,
+			CASE WHEN t.last_seen = dm.max_date THEN true ELSE false END,
+			t.configs
+		FROM (
+			SELECT domain, zone, net, node, sysop_name,
+				argMax(system_name, nodelist_date) AS system_name,
+				min(nodelist_date) AS first_seen,
+				max(nodelist_date) AS last_seen,
+				groupUniqArrayIf(%d)(toString(internet_config), has_inet) AS configs
+			FROM nodes
+			WHERE conflict_sequence = 0 AND sysop_name != ''
+			GROUP BY domain, zone, net, node, sysop_name
+		) t
+		JOIN domain_max dm ON t.domain = dm.domain`, maxTenureConfigs)
+
+	return so.queryTenures(ctx, query, false)
+}
+
+// loadPointTenures reads one tenure per point address and sysop name. A
+// point counts as still listed while its last pointlist is inside the
+// snapshot staleness window of its network's newest one.
+func (so *SysopIdentityOperations) loadPointTenures(ctx context.Context) ([]SysopTenure, error) {
+	query := fmt.Sprintf(`
+		WITH domain_max AS (
+			SELECT domain, max(pointlist_date) AS max_date FROM pointlist_files FINAL GROUP BY domain
+		)
+		SELECT t.domain, t.zone, t.net, t.node, t.point, t.sysop_name, t.system_name,
+			t.first_seen, t.last_seen,
+			CASE WHEN t.last_seen > dm.max_date - INTERVAL %d DAY THEN true ELSE false END,
+			t.configs
+		FROM (
+			SELECT domain, zone, net, node, point, sysop_name,
+				argMax(system_name, pointlist_date) AS system_name,
+				min(pointlist_date) AS first_seen,
+				max(pointlist_date) AS last_seen,
+				groupUniqArrayIf(%d)(internet_config, internet_config != '') AS configs
+			FROM points
+			WHERE conflict_sequence = 0 AND sysop_name != ''
+			  AND %s
+			GROUP BY domain, zone, net, node, point, sysop_name
+		) t
+		JOIN domain_max dm ON t.domain = dm.domain`,
+		PointSnapshotStalenessDays, maxTenureConfigs, pointGatedIssuesSQL)
+
+	return so.queryTenures(ctx, query, true)
+}
+
+func (so *SysopIdentityOperations) queryTenures(ctx context.Context, query string, points bool) ([]SysopTenure, error) {
+	kind := "node"
+	if points {
+		kind = "point"
+	}
+
+	rows, err := so.db.Conn().QueryContext(ctx, query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query %s tenures: %w", kind, err)
+	}
+	defer rows.Close()
+
+	var tenures []SysopTenure
+	for rows.Next() {
+		var (
+			t                      SysopTenure
+			zone, net, node, point int32
+			configs                []string
+		)
+		if err := rows.Scan(&t.Domain, &zone, &net, &node, &point, &t.SysopName, &t.SystemName,
+			&t.FirstSeen, &t.LastSeen, &t.CurrentlyListed, &configs); err != nil {
+			return nil, fmt.Errorf("failed to scan %s tenure: %w", kind, err)
+		}
+		t.Zone, t.Net, t.Node, t.Point = int(zone), int(net), int(node), int(point)
+		t.IsPoint = points
+		t.Hosts, t.Emails = tenureIdentifiers(configs)
+		tenures = append(tenures, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating %s tenures: %w", kind, err)
+	}
+	return tenures, nil
+}
+
+// tenureIdentifiers collects the hostnames and email addresses advertised in
+// a tenure's internet configurations. One that will not decode contributes
+// nothing; it cannot link anyone.
+func tenureIdentifiers(configs []string) (hosts, emails []string) {
+	seen := make(map[string]bool)
+	add := func(list *[]string, value string) {
+		if value != "" && !seen[value] {
+			seen[value] = true
+			*list = append(*list, value)
+		}
+	}
+	for _, raw := range configs {
+		if raw == "" || raw == "{}" || raw == "null" {
+			continue
+		}
+		var ic database.InternetConfiguration
+		if err := json.Unmarshal([]byte(raw), &ic); err != nil {
+			continue
+		}
+		for _, h := range ic.Defaults["INA"] {
+			add(&hosts, h)
+		}
+		for _, details := range ic.Protocols {
+			for _, d := range details {
+				add(&hosts, d.Address)
+			}
+		}
+		for _, c := range emailflags.Extract(nil, &ic, emailflags.Options{}) {
+			for _, e := range c.Addresses {
+				add(&emails, e)
+			}
+		}
+	}
+	return hosts, emails
+}
+
+// loadPreviousResolution returns the stored resolution as tenure key -> ID
+// and its redirects as retired ID -> ID, "" for an ID retired with no
+// successor.
+func (so *SysopIdentityOperations) loadPreviousResolution(ctx context.Context) (map[string]string, map[string]string, error) {
+	rows, err := so.db.Conn().QueryContext(ctx, `
+		SELECT sysop_id, domain, zone, net, node, point, sysop_name
+		FROM sysop_identities
+		WHERE `+latestSysopIdentitiesSQL)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query previous sysop identities: %w", err)
+	}
+	defer rows.Close()
+
+	previous := make(map[string]string)
+	for rows.Next() {
+		var (
+			id                     string
+			t                      SysopTenure
+			zone, net, node, point int32
+		)
+		if err := rows.Scan(&id, &t.Domain, &zone, &net, &node, &point, &t.SysopName); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan previous sysop identity: %w", err)
+		}
+		t.Zone, t.Net, t.Node, t.Point = int(zone), int(net), int(node), int(point)
+		previous[sysopTenureKey(t)] = id
+	}
+	if err := rows.Err(); err != nil {
+		return nil, nil, fmt.Errorf("error iterating previous sysop identities: %w", err)
+	}
+
+	redirectRows, err := so.db.Conn().QueryContext(ctx, `SELECT old_id, sysop_id FROM sysop_identity_redirects`)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to query sysop identity redirects: %w", err)
+	}
+	defer redirectRows.Close()
+
+	redirects := make(map[string]string)
+	for redirectRows.Next() {
+		var old, id string
+		if err := redirectRows.Scan(&old, &id); err != nil {
+			return nil, nil, fmt.Errorf("failed to scan sysop identity redirect: %w", err)
+		}
+		redirects[old] = id
+	}
+	return previous, redirects, redirectRows.Err()
+}
+
+func (so *SysopIdentityOperations) insertResolution(ctx context.Context, identities []SysopIdentity, redirects map[string]string, resolvedAt time.Time) error {
+	tx, err := so.db.Conn().BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer func() { _ = tx.Rollback() }()
+
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO sysop_identities
+		(sysop_id, display_name, domain, zone, net, node, point, is_point, sysop_name,
+		 system_name, first_seen, last_seen, currently_listed, evidence, resolved_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`)
+	if err != nil {
+		return fmt.Errorf("failed to prepare sysop identity insert: %w", err)
+	}
+	defer func() { _ = stmt.Close() }()
+
+	for _, identity := range identities {
+		for _, t := range identity.Career {
+			evidence := t.Evidence
+			if evidence == nil {
+				evidence = []string{}
+			}
+			if _, err := stmt.ExecContext(ctx, identity.ID, identity.Name, t.Domain,
+				int32(t.Zone), int32(t.Net), int32(t.Node), int32(t.Point), t.IsPoint, t.SysopName,
+				t.SystemName, t.FirstSeen, t.LastSeen, t.CurrentlyListed, evidence, resolvedAt); err != nil {
+				return fmt.Errorf("failed to insert sysop identity row: %w", err)
+			}
+		}
+	}
+
+	if len(redirects) > 0 {
+		redirectStmt, err := tx.PrepareContext(ctx, `INSERT INTO sysop_identity_redirects
+			(old_id, sysop_id, resolved_at) VALUES (?, ?, ?)`)
+		if err != nil {
+			return fmt.Errorf("failed to prepare sysop identity redirect insert: %w", err)
+		}
+		defer func() { _ = redirectStmt.Close() }()
+
+		for old, id := range redirects {
+			if _, err := redirectStmt.ExecContext(ctx, old, id, resolvedAt); err != nil {
+				return fmt.Errorf("failed to insert sysop identity redirect: %w", err)
+			}
+		}
+	}
+
+	return tx.Commit()
+}
+
+// GetSysopIdentity returns the person with the given ID, following the
+// redirect of an ID a later resolution retired, so the result's ID may differ
+// from the one asked for. It returns nil when no person has the ID.
+func (so *SysopIdentityOperations) GetSysopIdentity(ctx context.Context, id string) (*SysopIdentity, error) {
+	identity, err := so.getSysopIdentity(ctx, id)
+	if identity != nil || err != nil {
+		return identity, err
+	}
+
+	var target string
+	err = so.db.Conn().QueryRowContext(ctx, `
+		SELECT sysop_id FROM sysop_identity_redirects
+		WHERE old_id = ?
+		ORDER BY resolved_at DESC
+		LIMIT 1`, id).Scan(&target)
+	if err != nil {
+		if err == sql.ErrNoRows {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("failed to query sysop identity redirect: %w", err)
+	}
+	if target == "" {
+		return nil, nil // retired with no successor
+	}
+	return so.getSysopIdentity(ctx, target)
+}
+
+func (so *SysopIdentityOperations) getSysopIdentity(ctx context.Context, id string) (*SysopIdentity, error) {
+	rows, err := so.db.Conn().QueryContext(ctx, `
+		SELECT domain, zone, net, node, point, is_point, sysop_name, system_name,
+			first_seen, last_seen, currently_listed, evidence, resolved_at
+		FROM sysop_identities
+		WHERE sysop_id = ? AND `+latestSysopIdentitiesSQL+`
+		ORDER BY first_seen, domain, zone, net, node, point, sysop_name`, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query sysop identity: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		tenures    []SysopTenure
+		resolvedAt time.Time
+	)
+	for rows.Next() {
+		var (
+			t                      SysopTenure
+			zone, net, node, point int32
+		)
+		if err := rows.Scan(&t.Domain, &zone, &net, &node, &point, &t.IsPoint, &t.SysopName, &t.SystemName,
+			&t.FirstSeen, &t.LastSeen, &t.CurrentlyListed, &t.Evidence, &resolvedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan sysop identity row: %w", err)
+		}
+		t.Zone, t.Net, t.Node, t.Point = int(zone), int(net), int(node), int(point)
+		tenures = append(tenures, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating sysop identity rows: %w", err)
+	}
+	if len(tenures) == 0 {
+		return nil, nil
+	}
+
+	identity := buildSysopIdentity(id, tenures)
+	identity.ResolvedAt = resolvedAt
+	return &identity, nil
+}
+
+// GetNodeSysopIdentities returns the persons who ran a node, in the order
+// they first ran it.
+func (so *SysopIdentityOperations) GetNodeSysopIdentities(ctx context.Context, zone, net, node int, domain string) ([]SysopIdentityRef, error) {
+	rows, err := so.db.Conn().QueryContext(ctx, `
+		SELECT sysop_id, any(display_name), min(first_seen) AS since
+		FROM sysop_identities
+		WHERE domain = ? AND zone = ? AND net = ? AND node = ? AND NOT is_point
+		  AND `+latestSysopIdentitiesSQL+`
+		GROUP BY sysop_id
+		ORDER BY since`, domain, zone, net, node)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query node sysop identities: %w", err)
+	}
+	defer rows.Close()
+
+	var refs []SysopIdentityRef
+	for rows.Next() {
+		var ref SysopIdentityRef
+		if err := rows.Scan(&ref.ID, &ref.Name, &ref.Since); err != nil {
+			return nil, fmt.Errorf("failed to scan node sysop identity: %w", err)
+		}
+		refs = append(refs, ref)
+	}
+	return refs, rows.Err()
+}
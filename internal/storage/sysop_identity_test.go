@@ -0,0 +1,322 @@
+package storage
+
+import (
+	"reflect"
+	"sort"
+	"testing"
+	"time"
+)
+
+// tenure builds a node tenure (point 0) or point tenure listed from one year
+// to another.
+func tenure(domain string, zone, net, node, point int, name string, from, to int) SysopTenure {
+	return SysopTenure{
+		Domain: domain, Zone: zone, Net: net, Node: node, Point: point, IsPoint: point > 0,
+		SysopName: name,
+		FirstSeen: time.Date(from, 1, 1, 0, 0, 0, 0, time.UTC),
+		LastSeen:  time.Date(to, 12, 31, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func withHosts(t SysopTenure, hosts ...string) SysopTenure {
+	t.Hosts = hosts
+	return t
+}
+
+// clusterNames reduces clusters to their sorted tenure keys, sorted, so a
+// test can state the expected persons in any order.
+func clusterNames(clusters [][]SysopTenure) [][]string {
+	out := make([][]string, 0, len(clusters))
+	for _, c := range clusters {
+		keys := make([]string, len(c))
+		for i, t := range c {
+			keys[i] = sysopTenureKey(t)
+		}
+		sort.Strings(keys)
+		out = append(out, keys)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i][0] < out[j][0] })
+	return out
+}
+
+func TestClusterSysopTenures(t *testing.T) {
+	tests := []struct {
+		name    string
+		tenures []SysopTenure
+		persons [][]int // indexes into tenures
+	}{
+		{
+			name: "transliterated and reordered names in one zone",
+			tenures: []SysopTenure{
+				tenure("fidonet", 2, 5020, 113, 0, "Sergey_Ivanov", 1995, 2003),
+				tenure("fidonet", 2, 5030, 7, 0, "Сергей Иванов", 2004, 2010),
+				tenure("fidonet", 2, 5020, 100, 12, "Ivanov_Sergey", 1993, 1995),
+			},
+			persons: [][]int{{0, 1, 2}},
+		},
+		{
+			name: "a near spelling in one zone",
+			tenures: []SysopTenure{
+				tenure("fidonet", 2, 2401, 113, 0, "Hans_Schmidt", 1995, 2003),
+				tenure("fidonet", 2, 2448, 1, 0, "Hans_Schmitt", 2004, 2010),
+			},
+			persons: [][]int{{0, 1}},
+		},
+		{
+			name: "the same name in two zones is two persons",
+			tenures: []SysopTenure{
+				tenure("fidonet", 1, 261, 38, 0, "John_Smith", 1990, 1999),
+				tenure("fidonet", 3, 640, 1, 0, "John_Smith", 1992, 1998),
+			},
+			persons: [][]int{{0}, {1}},
+		},
+		{
+			name: "a one-word name identifies only within its net",
+			tenures: []SysopTenure{
+				tenure("fidonet", 2, 5020, 1, 0, "Max", 1995, 1999),
+				tenure("fidonet", 2, 5020, 2, 0, "Max", 2000, 2001),
+				tenure("fidonet", 2, 450, 9, 0, "Max", 1995, 1999),
+			},
+			persons: [][]int{{0, 1}, {2}},
+		},
+		{
+			name: "a shared hostname crosses zones and networks",
+			tenures: []SysopTenure{
+				withHosts(tenure("fidonet", 1, 261, 38, 0, "John_Smith", 1990, 2020), "bbs.example.org"),
+				withHosts(tenure("fsxnet", 21, 1, 150, 0, "J_Smith", 2017, 2026), "BBS.Example.org:24554"),
+			},
+			persons: [][]int{{0, 1}},
+		},
+		{
+			name: "IP addresses identify nobody",
+			tenures: []SysopTenure{
+				withHosts(tenure("fidonet", 1, 261, 38, 0, "John_Smith", 1990, 2020), "192.0.2.1"),
+				withHosts(tenure("fsxnet", 21, 1, 150, 0, "Bob_Jones", 2017, 2026), "192.0.2.1"),
+			},
+			persons: [][]int{{0}, {1}},
+		},
+		{
+			name: "a host shared by many names is a hub, not a person",
+			tenures: []SysopTenure{
+				withHosts(tenure("fidonet", 2, 5020, 1, 0, "Anna_Petrova", 2000, 2005), "fido.example.ru"),
+				withHosts(tenure("fidonet", 2, 5020, 2, 0, "Boris_Orlov", 2000, 2005), "fido.example.ru"),
+				withHosts(tenure("fidonet", 2, 5020, 3, 0, "Victor_Lebedev", 2000, 2005), "fido.example.ru"),
+				withHosts(tenure("fidonet", 2, 5020, 4, 0, "Gleb_Sokolov", 2000, 2005), "fido.example.ru"),
+			},
+			persons: [][]int{{0}, {1}, {2}, {3}},
+		},
+		{
+			name: "a point reached through its boss's host is not the boss",
+			tenures: []SysopTenure{
+				withHosts(tenure("fidonet", 2, 5020, 113, 0, "Boris_Paleev", 1995, 2026), "bbs.example.org"),
+				withHosts(tenure("fidonet", 2, 5020, 113, 1, "Ivan_Petrov", 1998, 2026), "bbs.example.org"),
+			},
+			persons: [][]int{{0}, {1}},
+		},
+		{
+			name: "a respelling at the same address is a handover to oneself",
+			tenures: []SysopTenure{
+				tenure("fidonet", 2, 5020, 113, 0, "Jurij_Shchukin", 1995, 1999),
+				tenure("fidonet", 2, 5020, 113, 0, "Yury_Schukin", 2000, 2010),
+			},
+			persons: [][]int{{0, 1}},
+		},
+		{
+			name: "a new sysop at the same address is someone else",
+			tenures: []SysopTenure{
+				tenure("fidonet", 2, 5020, 113, 0, "Boris_Paleev", 1995, 1999),
+				tenure("fidonet", 2, 5020, 113, 0, "Ivan_Petrov", 2000, 2010),
+			},
+			persons: [][]int{{0}, {1}},
+		},
+		{
+			name: "placeholder names link nobody",
+			tenures: []SysopTenure{
+				tenure("fidonet", 2, 5020, 1, 0, "Sysop", 1995, 1999),
+				tenure("fidonet", 2, 5020, 2, 0, "Sysop", 1995, 1999),
+				tenure("fidonet", 2, 5020, 3, 0, "Unknown", 1995, 1999),
+			},
+			persons: [][]int{{0}, {1}, {2}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			want := make([][]SysopTenure, len(tt.persons))
+			for p, members := range tt.persons {
+				for _, i := range members {
+					want[p] = append(want[p], tt.tenures[i])
+				}
+			}
+			got := clusterSysopTenures(tt.tenures)
+			if !reflect.DeepEqual(clusterNames(got), clusterNames(want)) {
+				t.Errorf("persons = %q\nwant      %q", clusterNames(got), clusterNames(want))
+			}
+			for _, c := range got {
+				for _, tn := range c {
+					if (len(c) > 1) != (len(tn.Evidence) > 0) {
+						t.Errorf("%s in a person of %d has evidence %q", sysopTenureKey(tn), len(c), tn.Evidence)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestClusterSysopTenuresRecordsEvidence(t *testing.T) {
+	smyth := withHosts(tenure("fsxnet", 21, 1, 150, 0, "Jon_Smyth", 2017, 2026), "bbs.example.org")
+	smyth.Emails = []string{"john@example.org"}
+	clusters := clusterSysopTenures([]SysopTenure{
+		withHosts(tenure("fidonet", 1, 261, 38, 0, "John_Smith", 1990, 2020), "bbs.example.org"),
+		smyth,
+		{
+			Domain: "amiganet", Zone: 39, Net: 901, Node: 5, SysopName: "Johnny",
+			FirstSeen: time.Date(2019, 1, 1, 0, 0, 0, 0, time.UTC),
+			LastSeen:  time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC),
+			Emails:    []string{"John@Example.org"},
+		},
+	})
+	if len(clusters) != 1 {
+		t.Fatalf("got %d persons, want 1", len(clusters))
+	}
+	var evidence []string
+	for _, tn := range clusters[0] {
+		evidence = append(evidence, tn.Evidence...)
+	}
+	for _, want := range []string{"host:bbs.example.org", "email:j…@example.org"} {
+		found := false
+		for _, e := range evidence {
+			found = found || e == want
+		}
+		if !found {
+			t.Errorf("evidence %q missing %q", evidence, want)
+		}
+	}
+	for _, e := range evidence {
+		if e == "email:john@example.org" {
+			t.Error("an email address appears unredacted")
+		}
+	}
+}
+
+func TestAssignSysopIDs(t *testing.T) {
+	a1 := tenure("fidonet", 2, 5020, 1, 0, "Anna_Petrova", 1995, 1999)
+	a2 := tenure("fidonet", 2, 5020, 2, 0, "Anna_Petrova", 2000, 2005)
+	a3 := tenure("fidonet", 2, 5020, 3, 0, "Anna_Petrova", 2006, 2010)
+	b1 := tenure("fidonet", 2, 5030, 1, 0, "Boris_Orlov", 1996, 1999)
+	key := sysopTenureKey
+
+	t.Run("new persons get minted IDs", func(t *testing.T) {
+		ids, redirects, kept := assignSysopIDs([][]SysopTenure{{a1, a2}, {b1}}, nil, nil)
+		if kept != 0 || len(redirects) != 0 {
+			t.Errorf("kept %d, redirects %v; want none", kept, redirects)
+		}
+		if !validID(ids[0]) || !validID(ids[1]) || ids[0] == ids[1] {
+			t.Errorf("ids = %q, want two distinct 12-digit hex IDs", ids)
+		}
+		again, _, _ := assignSysopIDs([][]SysopTenure{{a1, a2}, {b1}}, nil, nil)
+		if !reflect.DeepEqual(ids, again) {
+			t.Errorf("minting is not deterministic: %q then %q", ids, again)
+		}
+	})
+
+	t.Run("a person keeps their ID as their career grows", func(t *testing.T) {
+		previous := map[string]string{key(a1): "aaaaaaaaaaaa", key(a2): "aaaaaaaaaaaa", key(b1): "bbbbbbbbbbbb"}
+		ids, redirects, kept := assignSysopIDs([][]SysopTenure{{a1, a2, a3}, {b1}}, previous, nil)
+		if !reflect.DeepEqual(ids, []string{"aaaaaaaaaaaa", "bbbbbbbbbbbb"}) || kept != 2 || len(redirects) != 0 {
+			t.Errorf("ids %q, kept %d, redirects %v", ids, kept, redirects)
+		}
+	})
+
+	t.Run("a merge keeps the larger ID and redirects the other", func(t *testing.T) {
+		previous := map[string]string{key(a1): "aaaaaaaaaaaa", key(a2): "aaaaaaaaaaaa", key(a3): "cccccccccccc"}
+		ids, redirects, kept := assignSysopIDs([][]SysopTenure{{a1, a2, a3}}, previous, nil)
+		if ids[0] != "aaaaaaaaaaaa" || kept != 1 {
+			t.Errorf("ids %q, kept %d; want the ID most tenures had", ids, kept)
+		}
+		if redirects["cccccccccccc"] != "aaaaaaaaaaaa" {
+			t.Errorf("redirects = %v, want the retired ID sent to the merged person", redirects)
+		}
+	})
+
+	t.Run("a split mints a new ID for the smaller part", func(t *testing.T) {
+		previous := map[string]string{key(a1): "aaaaaaaaaaaa", key(a2): "aaaaaaaaaaaa", key(b1): "aaaaaaaaaaaa"}
+		ids, redirects, _ := assignSysopIDs([][]SysopTenure{{a1, a2}, {b1}}, previous, nil)
+		if ids[0] != "aaaaaaaaaaaa" || ids[1] == "aaaaaaaaaaaa" || !validID(ids[1]) {
+			t.Errorf("ids = %q", ids)
+		}
+		if len(redirects) != 0 {
+			t.Errorf("redirects = %v, want none: the old ID still exists", redirects)
+		}
+	})
+
+	t.Run("redirects chain and retired IDs are never reissued", func(t *testing.T) {
+		// dddd was merged into cccc last time; now cccc is merged into aaaa.
+		previous := map[string]string{key(a1): "aaaaaaaaaaaa", key(a2): "aaaaaaaaaaaa", key(a3): "cccccccccccc"}
+		ids, redirects, _ := assignSysopIDs([][]SysopTenure{{a1, a2, a3}}, previous,
+			map[string]string{"dddddddddddd": "cccccccccccc"})
+		want := map[string]string{"cccccccccccc": "aaaaaaaaaaaa", "dddddddddddd": "aaaaaaaaaaaa"}
+		if ids[0] != "aaaaaaaaaaaa" || !reflect.DeepEqual(redirects, want) {
+			t.Errorf("ids %q, redirects %v; want %v", ids, redirects, want)
+		}
+
+		retired := mintSysopID(key(b1), nil)
+		ids, _, _ = assignSysopIDs([][]SysopTenure{{b1}}, nil, map[string]string{retired: "aaaaaaaaaaaa"})
+		if ids[0] == retired {
+			t.Errorf("minted retired ID %q again", retired)
+		}
+	})
+
+	t.Run("IDs retired with no successor stay retired", func(t *testing.T) {
+		// Last time b1's minted ID was merged into eeee, whose tenures have
+		// since gone, and ffff lost its only tenure.
+		minted := mintSysopID(key(b1), nil)
+		previous := map[string]string{key(a1): "aaaaaaaaaaaa", "gone": "ffffffffffff"}
+		_, redirects, _ := assignSysopIDs([][]SysopTenure{{a1}}, previous, map[string]string{minted: "eeeeeeeeeeee"})
+		want := map[string]string{minted: "", "ffffffffffff": ""}
+		if !reflect.DeepEqual(redirects, want) {
+			t.Errorf("redirects = %v, want %v", redirects, want)
+		}
+
+		ids, _, _ := assignSysopIDs([][]SysopTenure{{b1}}, nil, redirects)
+		if ids[0] == minted {
+			t.Errorf("minted retired ID %q again", minted)
+		}
+	})
+}
+
+func TestBuildSysopIdentity(t *testing.T) {
+	a := tenure("fidonet", 2, 5020, 100, 12, "Sergei_Ivanoff", 1993, 1994)
+	b := tenure("fidonet", 2, 5020, 113, 0, "Sergey_Ivanov", 1995, 2003)
+	c := tenure("fsxnet", 21, 1, 150, 0, "Sergei_Ivanoff", 2017, 2018)
+	c.CurrentlyListed = true
+
+	got := buildSysopIdentity("0123456789ab", []SysopTenure{a, b, c})
+	if got.Name != "Sergey_Ivanov" {
+		t.Errorf("Name = %q, want the spelling listed longest", got.Name)
+	}
+	if !reflect.DeepEqual(got.Aliases, []string{"Sergey_Ivanov", "Sergei_Ivanoff"}) {
+		t.Errorf("Aliases = %q", got.Aliases)
+	}
+	if !reflect.DeepEqual(got.Domains, []string{"fidonet", "fsxnet"}) {
+		t.Errorf("Domains = %q", got.Domains)
+	}
+	if got.NodeCount != 2 || got.PointCount != 1 || got.Active != 1 {
+		t.Errorf("nodes %d, points %d, active %d; want 2, 1, 1", got.NodeCount, got.PointCount, got.Active)
+	}
+	if !got.FirstSeen.Equal(a.FirstSeen) || !got.LastSeen.Equal(c.LastSeen) {
+		t.Errorf("span %v - %v", got.FirstSeen, got.LastSeen)
+	}
+}
+
+func validID(id string) bool {
+	if len(id) != 12 {
+		return false
+	}
+	for _, r := range id {
+		if !('0' <= r && r <= '9' || 'a' <= r && r <= 'f') {
+			return false
+		}
+	}
+	return true
+}
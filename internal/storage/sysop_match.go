@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/nodelistdb/internal/translit"
+)
+
+// Fuzzy sysop search. The same operator turns up as "Sergey_Ivanov",
+// "Sergei Ivanov" and "Сергей Иванов", or as "Jürgen" in one nodelist and
+// "Juergen" in the next, and a substring match finds only one of each. Names
+// are folded to plain lowercase Latin, then compared word by word: exactly,
+// by a phonetic key that absorbs the usual transliteration variants, as a
+// prefix, and finally by edit distance.
+
+// The longest name a fuzzy search takes. Every search scores the query
+// against every distinct sysop name, at a cost that grows with the query's
+// length, and no sysop name is longer than this.
+const (
+	MaxFuzzySysopRunes = 64
+	MaxFuzzySysopWords = 4
+)
+
+// CheckFuzzySysopName rejects a fuzzy search name longer than
+// MaxFuzzySysopRunes or MaxFuzzySysopWords.
+func CheckFuzzySysopName(name string) error {
+	if utf8.RuneCountInString(name) > MaxFuzzySysopRunes {
+		return fmt.Errorf("a fuzzy sysop search takes at most %d characters", MaxFuzzySysopRunes)
+	}
+	if len(foldSysopName(name)) > MaxFuzzySysopWords {
+		return fmt.Errorf("a fuzzy sysop search takes at most %d words", MaxFuzzySysopWords)
+	}
+	return nil
+}
+
+// minFuzzySysopScore is the weakest match worth returning.
+const minFuzzySysopScore = 0.6
+
+// Scores for the ways one query word can match one name word.
+const (
+	scoreExactWord    = 1.0
+	scorePhoneticWord = 0.9
+	scorePrefixWord   = 0.8
+	scoreInitial      = 0.7
+	scoreEditWord     = 0.85 // less 0.1 per edit
+)
+
+// foldSysopName reduces a sysop name to lowercase ASCII words: underscores
+// (how nodelists store spaces) and punctuation separate words, Cyrillic is
+// transliterated and diacritics are dropped.
+func foldSysopName(name string) []string {
+	return translit.Words(name)
+}
+
+// phoneticReplacements rewrite spellings that sound alike to one form,
+// longest first at each position. The upper-case letters are placeholders
+// that no later rule touches.
+var phoneticReplacements = []struct{ from, to string }{
+	{"shch", "S"}, {"tsch", "C"}, {"sch", "S"}, {"tch", "C"},
+	{"dzh", "J"}, {"sh", "S"}, {"ch", "C"}, {"zh", "Z"}, {"kh", "h"},
+	{"ts", "T"}, {"tz", "T"}, {"ph", "f"}, {"th", "t"}, {"gh", "g"},
+	{"ck", "k"}, {"ue", "u"}, {"oe", "o"}, {"ae", "a"}, {"ou", "u"},
+	{"dj", "J"},
+	{"x", "ks"}, {"q", "k"}, {"w", "v"}, {"j", "i"}, {"y", "i"},
+}
+
+// phoneticKey maps a folded word to a key shared by its common spellings:
+// Sergey, Sergei and Sergej; Juergen and Jurgen; Aleksei and Alexey.
+func phoneticKey(word string) string {
+	var b strings.Builder
+	for i := 0; i < len(word); {
+		matched := false
+		for _, r := range phoneticReplacements {
+			if strings.HasPrefix(word[i:], r.from) {
+				b.WriteString(r.to)
+				i += len(r.from)
+				matched = true
+				break
+			}
+		}
+		if matched {
+			continue
+		}
+		c := word[i]
+		// c is s before e, i and y and k otherwise, as in Cecil
+		if c == 'c' {
+			if i+1 < len(word) && strings.IndexByte("eiy", word[i+1]) >= 0 {
+				c = 's'
+			} else {
+				c = 'k'
+			}
+		}
+		b.WriteByte(c)
+		i++
+	}
+
+	// Doubled letters are one sound: Phillip and Philip, Yurii and Yuri
+	key := b.String()
+	var out strings.Builder
+	for i := 0; i < len(key); i++ {
+		if i == 0 || key[i] != key[i-1] {
+			out.WriteByte(key[i])
+		}
+	}
+	return out.String()
+}
+
+// editDistance is the Levenshtein distance between two ASCII strings.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+// allowedEdits is how many edits a word of n letters may differ by: none for
+// short words, where one edit is another name, and more as words grow.
+func allowedEdits(n int) int {
+	switch {
+	case n <= 3:
+		return 0
+	case n <= 6:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// sysopWord is one word of a name, folded and keyed once.
+type sysopWord struct {
+	folded string
+	key    string
+}
+
+func sysopWords(name string) []sysopWord {
+	folded := foldSysopName(name)
+	words := make([]sysopWord, len(folded))
+	for i, w := range folded {
+		words[i] = sysopWord{folded: w, key: phoneticKey(w)}
+	}
+	return words
+}
+
+// matchWord scores how well query word q matches name word w, 0 for not at
+// all.
+func matchWord(q, w sysopWord) float64 {
+	switch {
+	case q.folded == w.folded:
+		return scoreExactWord
+	case q.key == w.key:
+		return scorePhoneticWord
+	case len(q.folded) == 1:
+		// A lone letter is an initial
+		if strings.HasPrefix(w.folded, q.folded) {
+			return scoreInitial
+		}
+		return 0
+	case len(q.folded) >= 3 && (strings.HasPrefix(w.folded, q.folded) || strings.HasPrefix(w.key, q.key)):
+		return scorePrefixWord
+	}
+	longer := max(len(q.key), len(w.key))
+	if d := editDistance(q.key, w.key); d <= allowedEdits(longer) {
+		return scoreEditWord - 0.1*float64(d)
+	}
+	return 0
+}
+
+// scoreSysopName scores a sysop name against the words of a query, from 0
+// (no match) to 1 (the same words). Every query word must match a different
+// word of the name, in any order, so "Ivanov Sergey" finds "Sergey Ivanov".
+// Words of the name the query does not mention cost a little, so the exact
+// "John Smith" ranks above "John Q Smith".
+func scoreSysopName(query, name []sysopWord) float64 {
+	if len(query) == 0 || len(query) > len(name) {
+		return 0
+	}
+	used := make([]bool, len(name))
+	total := 0.0
+	for _, q := range query {
+		best, bestAt := 0.0, -1
+		for i, w := range name {
+			if used[i] {
+				continue
+			}
+			if s := matchWord(q, w); s > best {
+				best, bestAt = s, i
+			}
+		}
+		if bestAt < 0 {
+			return 0
+		}
+		used[bestAt] = true
+		total += best
+	}
+	score := total / float64(len(query))
+	return score * (1 - 0.02*float64(len(name)-len(query)))
+}
+
+// sysopNameMatch is a sysop name and how well it matched.
+type sysopNameMatch struct {
+	Name  string
+	Score float64
+}
+
+// sysopNameIndex holds every distinct sysop name, pre-folded for matching.
+// It is never modified once built, so searches share it without locking.
+type sysopNameIndex struct {
+	names    []string
+	words    [][]sysopWord
+	loadedAt time.Time
+}
+
+func newSysopNameIndex(names []string) *sysopNameIndex {
+	idx := &sysopNameIndex{names: names, words: make([][]sysopWord, len(names)), loadedAt: time.Now()}
+	for i, n := range names {
+		idx.words[i] = sysopWords(n)
+	}
+	return idx
+}
+
+// match returns the names scoring at least minFuzzySysopScore against query,
+// best first. It gives up with ctx's error once ctx is done.
+func (idx *sysopNameIndex) match(ctx context.Context, query string) ([]sysopNameMatch, error) {
+	q := sysopWords(query)
+	if len(q) == 0 {
+		return nil, nil
+	}
+	var matches []sysopNameMatch
+	for i, words := range idx.words {
+		if i%1024 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		if s := scoreSysopName(q, words); s >= minFuzzySysopScore {
+			matches = append(matches, sysopNameMatch{Name: idx.names[i], Score: s})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Score != matches[j].Score {
+			return matches[i].Score > matches[j].Score
+		}
+		return matches[i].Name < matches[j].Name
+	})
+	return matches, nil
+}
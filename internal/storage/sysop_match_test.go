@@ -0,0 +1,143 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestFoldSysopName(t *testing.T) {
+	tests := map[string][]string{
+		"Sergey_Ivanov":   {"sergey", "ivanov"},
+		"Сергей Иванов":   {"sergei", "ivanov"},
+		"Jürgen Müller":   {"jurgen", "muller"},
+		"Søren Løkke":     {"soren", "lokke"},
+		"Gerd-Peter Weiß": {"gerd", "peter", "weiss"},
+		"Юрий Щукин":      {"yurii", "shchukin"},
+		"  ":              {},
+	}
+	for in, want := range tests {
+		if got := foldSysopName(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("foldSysopName(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestPhoneticKeySharedBySpellings(t *testing.T) {
+	groups := [][]string{
+		{"sergey", "sergei", "sergej"},
+		{"juergen", "jurgen"},
+		{"aleksei", "alexey", "alexei"},
+		{"philip", "phillip", "filip"},
+		{"yuri", "yurii", "iurii"},
+		{"shchukin", "schukin"},
+	}
+	for _, g := range groups {
+		for _, w := range g[1:] {
+			if phoneticKey(w) != phoneticKey(g[0]) {
+				t.Errorf("phoneticKey(%q) = %q, phoneticKey(%q) = %q; want them equal",
+					w, phoneticKey(w), g[0], phoneticKey(g[0]))
+			}
+		}
+	}
+	if phoneticKey("ivan") == phoneticKey("ilvan") {
+		t.Error("a different name shares a phonetic key")
+	}
+}
+
+func TestSysopNameIndexMatch(t *testing.T) {
+	idx := newSysopNameIndex([]string{
+		"Sergey_Ivanov",
+		"Сергей Иванов",
+		"Sergei_Ivanoff",
+		"Ivan_Petrov",
+		"Jürgen_Schmidt",
+		"Juergen_Schmitt",
+		"John_Smith",
+		"John_Q_Smith",
+		"Serge_Dupont",
+	})
+
+	tests := []struct {
+		query string
+		want  []string // best first; names not listed must not match
+	}{
+		{"Sergey Ivanov", []string{"Sergey_Ivanov", "Сергей Иванов", "Sergei_Ivanoff"}},
+		{"Ivanov Sergey", []string{"Sergey_Ivanov", "Сергей Иванов", "Sergei_Ivanoff"}},
+		{"Сергей Иванов", []string{"Сергей Иванов", "Sergey_Ivanov", "Sergei_Ivanoff"}},
+		{"Juergen Schmidt", []string{"Jürgen_Schmidt", "Juergen_Schmitt"}},
+		{"John Smith", []string{"John_Smith", "John_Q_Smith"}},
+		{"J Smith", []string{"John_Smith", "John_Q_Smith", "Juergen_Schmitt"}},
+		{"Serge", []string{"Serge_Dupont", "Sergei_Ivanoff", "Sergey_Ivanov", "Сергей Иванов"}},
+		{"Petrov", []string{"Ivan_Petrov"}},
+		{"Ivan Smirnov", nil},
+		{"   ", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			var got []string
+			matches, err := idx.match(context.Background(), tt.query)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, m := range matches {
+				got = append(got, m.Name)
+				if m.Score < minFuzzySysopScore || m.Score > 1 {
+					t.Errorf("%q scored %v", m.Name, m.Score)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("match(%q) = %q, want %q", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSysopIndexMatchStopsWhenCanceled(t *testing.T) {
+	idx := newSysopNameIndex([]string{"Sergey_Ivanov", "Sergei_Ivanoff"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := idx.match(ctx, "Sergey Ivanov"); !errors.Is(err, context.Canceled) {
+		t.Errorf("match on a canceled context: err = %v, want context.Canceled", err)
+	}
+}
+
+func TestCheckFuzzySysopName(t *testing.T) {
+	tests := []struct {
+		name string
+		ok   bool
+	}{
+		{"Sergey Ivanov", true},
+		{"Jean Claude Van Damme", true},
+		{strings.Repeat("Щ", MaxFuzzySysopRunes), true},
+		{strings.Repeat("Щ", MaxFuzzySysopRunes+1), false},
+		{"a b c d e", false},
+		{"Anna_Maria_del_Carmen_Lopez", false},
+	}
+	for _, tt := range tests {
+		if err := CheckFuzzySysopName(tt.name); (err == nil) != tt.ok {
+			t.Errorf("CheckFuzzySysopName(%q) = %v, want ok %v", tt.name, err, tt.ok)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"smith", "smith", 0},
+		{"smith", "smyth", 1},
+		{"schmidt", "schmitt", 1},
+		{"ivanov", "ivanoff", 2},
+		{"", "abc", 3},
+	}
+	for _, tt := range tests {
+		if got := editDistance(tt.a, tt.b); got != tt.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
@@ -27,6 +27,11 @@ import (
 // same configuration, so folding them together is harmless.
 const testSessionWindowSeconds = 120
 
+// akaHandshakeSQL is the condition for a row whose test read the node's AKAs:
+// a BinkP or IFCICO handshake, or an EMSI mailer behind the telnet port. A
+// telnet success alone is usually a BBS login and says nothing about AKAs.
+const akaHandshakeSQL = "(%[1]sbinkp_success = true OR %[1]sifcico_success = true OR (%[1]stelnet_success = true AND length(%[1]stelnet_addresses) > 0))"
+
 // AKAMismatchOperations handles AKA address validation queries
 type AKAMismatchOperations struct {
 	db           database.DatabaseInterface
@@ -108,7 +113,7 @@ func (am *AKAMismatchOperations) buildAKAMismatchQuery(nodeFilter string, domain
 			WHERE test_time >= now() - INTERVAL ? DAY
 				AND is_aggregated = false
 				AND is_operational = true
-				AND (binkp_success = true OR ifcico_success = true)
+				AND %s
 				{{NODELIST_GATE}}
 				%s
 				%s
@@ -116,7 +121,7 @@ func (am *AKAMismatchOperations) buildAKAMismatchQuery(nodeFilter string, domain
 		),
 		-- Get the best non-aggregated result of the latest test cycle
 		-- Prioritize rows with address_validated=false (mismatched) first, then by hostname_index
-		-- Only consider operational rows with successful BinkP or IFCICO handshake
+		-- Only consider operational rows whose handshake read the node's AKAs
 		best_results AS (
 			SELECT
 				r.domain, r.zone, r.net, r.node, r.test_time, r.hostname_index,
@@ -129,7 +134,7 @@ func (am *AKAMismatchOperations) buildAKAMismatchQuery(nodeFilter string, domain
 			WHERE {{CYCLE_LT}}
 				AND r.is_aggregated = false
 				AND r.is_operational = true
-				AND (r.binkp_success = true OR r.ifcico_success = true)
+				AND %s
 				%s
 		)
 		SELECT
@@ -138,10 +143,10 @@ func (am *AKAMismatchOperations) buildAKAMismatchQuery(nodeFilter string, domain
 		JOIN best_results br ON r.domain = br.domain AND r.zone = br.zone AND r.net = br.net AND r.node = br.node AND r.test_time = br.test_time AND r.hostname_index = br.hostname_index AND br.rn = 1
 		WHERE r.is_aggregated = false
 			AND r.address_validated = false
-			AND (length(r.binkp_addresses) > 0 OR length(r.ifcico_addresses) > 0)
+			AND (length(r.binkp_addresses) > 0 OR length(r.ifcico_addresses) > 0 OR length(r.telnet_addresses) > 0)
 			%s
 		ORDER BY r.test_time DESC
-		LIMIT ?`, nodeFilter, domainFilter, domainFilterR, domainFilterR), days),
+		LIMIT ?`, fmt.Sprintf(akaHandshakeSQL, ""), nodeFilter, domainFilter, fmt.Sprintf(akaHandshakeSQL, "r."), domainFilterR, domainFilterR), days),
 		"", domainFilter, nodeFilter))
 }
 
@@ -152,6 +157,8 @@ type AKAIPVersionMismatchNode struct {
 	BinkPIPv6Addresses   []string `json:"binkp_ipv6_addresses"`
 	IfcicoIPv4Addresses  []string `json:"ifcico_ipv4_addresses"`
 	IfcicoIPv6Addresses  []string `json:"ifcico_ipv6_addresses"`
+	TelnetIPv4Addresses  []string `json:"telnet_ipv4_addresses"`
+	TelnetIPv6Addresses  []string `json:"telnet_ipv6_addresses"`
 	AddressValidatedIPv4 bool     `json:"address_validated_ipv4"`
 	AddressValidatedIPv6 bool     `json:"address_validated_ipv6"`
 }
@@ -161,7 +168,7 @@ type AKAIPVersionMismatchNode struct {
 func (am *AKAMismatchOperations) GetIPv6IncorrectIPv4CorrectNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]AKAIPVersionMismatchNode, error) {
 	return am.getIPVersionMismatchNodes(ctx, limit, days, includeZeroNodes,
 		"r.address_validated_ipv4 = true AND r.address_validated_ipv6 = false",
-		"(r.binkp_ipv6_success = true OR r.ifcico_ipv6_success = true) AND (length(r.binkp_ipv6_addresses) + length(r.ifcico_ipv6_addresses)) > 0",
+		"(r.binkp_ipv6_success = true OR r.ifcico_ipv6_success = true OR r.telnet_ipv6_success = true) AND (length(r.binkp_ipv6_addresses) + length(r.ifcico_ipv6_addresses) + length(r.telnet_ipv6_addresses)) > 0",
 		domain,
 	)
 }
@@ -171,7 +178,7 @@ func (am *AKAMismatchOperations) GetIPv6IncorrectIPv4CorrectNodes(ctx context.Co
 func (am *AKAMismatchOperations) GetIPv4IncorrectIPv6CorrectNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]AKAIPVersionMismatchNode, error) {
 	return am.getIPVersionMismatchNodes(ctx, limit, days, includeZeroNodes,
 		"r.address_validated_ipv6 = true AND r.address_validated_ipv4 = false",
-		"(r.binkp_ipv4_success = true OR r.ifcico_ipv4_success = true) AND (length(r.binkp_ipv4_addresses) + length(r.ifcico_ipv4_addresses)) > 0",
+		"(r.binkp_ipv4_success = true OR r.ifcico_ipv4_success = true OR r.telnet_ipv4_success = true) AND (length(r.binkp_ipv4_addresses) + length(r.ifcico_ipv4_addresses) + length(r.telnet_ipv4_addresses)) > 0",
 		domain,
 	)
 }
@@ -227,6 +234,7 @@ func (am *AKAMismatchOperations) getIPVersionMismatchNodes(ctx context.Context,
 			// Extra fields for IP version mismatch
 			&n.BinkPIPv4Addresses, &n.BinkPIPv6Addresses,
 			&n.IfcicoIPv4Addresses, &n.IfcicoIPv6Addresses,
+			&n.TelnetIPv4Addresses, &n.TelnetIPv6Addresses,
 			&n.AddressValidatedIPv4, &n.AddressValidatedIPv6,
 		)
 		if err != nil {
@@ -261,7 +269,7 @@ func (am *AKAMismatchOperations) buildIPVersionMismatchQuery(nodeFilter string,
 			WHERE test_time >= now() - INTERVAL ? DAY
 				AND is_aggregated = false
 				AND is_operational = true
-				AND (binkp_success = true OR ifcico_success = true)
+				AND %s
 				{{NODELIST_GATE}}
 				%s
 				%s
@@ -279,7 +287,7 @@ func (am *AKAMismatchOperations) buildIPVersionMismatchQuery(nodeFilter string,
 			WHERE {{CYCLE_LT}}
 				AND r.is_aggregated = false
 				AND r.is_operational = true
-				AND (r.binkp_success = true OR r.ifcico_success = true)
+				AND %s
 				%s
 				AND %s
 				AND %s
@@ -311,11 +319,12 @@ func (am *AKAMismatchOperations) buildIPVersionMismatchQuery(nodeFilter string,
 			r.ftp_anon_success,
 			r.binkp_ipv4_addresses, r.binkp_ipv6_addresses,
 			r.ifcico_ipv4_addresses, r.ifcico_ipv6_addresses,
+			r.telnet_ipv4_addresses, r.telnet_ipv6_addresses,
 			r.address_validated_ipv4, r.address_validated_ipv6
 		FROM node_test_results r
 		JOIN best_results br ON r.domain = br.domain AND r.zone = br.zone AND r.net = br.net AND r.node = br.node AND r.test_time = br.test_time AND r.hostname_index = br.hostname_index AND br.rn = 1
 		WHERE r.is_aggregated = false
 		ORDER BY r.test_time DESC
-		LIMIT ?`, nodeFilter, domainFilter, domainFilterR, validationFilter, protocolFilter), days),
+		LIMIT ?`, fmt.Sprintf(akaHandshakeSQL, ""), nodeFilter, domainFilter, fmt.Sprintf(akaHandshakeSQL, "r."), domainFilterR, validationFilter, protocolFilter), days),
 		"", domainFilter, nodeFilter)
 }
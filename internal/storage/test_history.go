@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"errors"
 	"fmt"
+	"strings"
 	"sync"
 
 	"github.com/nodelistdb/internal/database"
@@ -59,6 +60,59 @@ func (th *TestHistoryOperations) GetNodeTestHistory(ctx context.Context, zone, n
 	return results, nil
 }
 
+// StreamTestResults calls fn for every node_test_results row tested in the
+// window filter.DateFrom..DateTo (inclusive dates, both required), without
+// collecting them. Besides the window only the identity fields apply - Domain,
+// Zone, Net and Node; the nodelist attributes describe nodes, not test rows.
+// An error from fn stops the stream and is returned as is.
+//
+// Like NodeOperations.StreamNodes it does not hold th.mu for the duration.
+func (th *TestHistoryOperations) StreamTestResults(ctx context.Context, filter database.NodeFilter, fn func(NodeTestResult) error) error {
+	if filter.DateFrom == nil || filter.DateTo == nil {
+		return fmt.Errorf("invalid filter: a test result export needs both date_from and date_to")
+	}
+	if filter.DateFrom.After(*filter.DateTo) {
+		return fmt.Errorf("invalid date range: date_from cannot be after date_to")
+	}
+
+	domain := ""
+	if filter.Domain != nil {
+		domain = *filter.Domain
+	}
+	args := []interface{}{*filter.DateFrom, *filter.DateTo, domain, domain}
+	var conds []string
+	for _, c := range []struct {
+		col string
+		v   *int
+	}{{"zone", filter.Zone}, {"net", filter.Net}, {"node", filter.Node}} {
+		if c.v != nil {
+			conds = append(conds, c.col+" = ?")
+			args = append(args, *c.v)
+		}
+	}
+
+	query := th.queryBuilder.BuildTestResultsExportQuery(strings.Join(conds, " AND "))
+	rows, err := th.db.Conn().QueryContext(ctx, query, args...)
+	if err != nil {
+		return fmt.Errorf("failed to query test results: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r NodeTestResult
+		if err := th.resultParser.ParseTestResultRow(rows, &r); err != nil {
+			return fmt.Errorf("failed to parse test result: %w", err)
+		}
+		if err := fn(r); err != nil {
+			return err
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return fmt.Errorf("failed to read test results: %w", err)
+	}
+	return nil
+}
+
 // GetDetailedTestResult retrieves a detailed test result for a specific node and timestamp
 func (th *TestHistoryOperations) GetDetailedTestResult(ctx context.Context, zone, net, node int, testTime string, domain string) (*NodeTestResult, error) {
 	th.mu.RLock()
@@ -130,3 +130,39 @@ func (pq *ProtocolQueryOperations) GetVModemUnconfirmedNodes(ctx context.Context
 	}
 	return results, nil
 }
+
+// GetBinkPSCertificateIssues returns nodes whose latest binkps test saw a
+// self-signed or expired TLS certificate.
+// An empty domain means all FTN networks (no filtering).
+func (pq *ProtocolQueryOperations) GetBinkPSCertificateIssues(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]NodeTestResult, error) {
+	pq.mu.RLock()
+	defer pq.mu.RUnlock()
+
+	conn := pq.db.Conn()
+
+	nodeFilter := ""
+	if !includeZeroNodes {
+		nodeFilter = "AND node != 0"
+	}
+
+	query := pq.queryBuilder.BuildBinkPSCertificateIssuesQuery(nodeFilter, domainFilterSQL(domain, ""), days)
+
+	rows, err := conn.QueryContext(ctx, query, days, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query binkps certificate issues: %w", err)
+	}
+	defer rows.Close()
+
+	var results []NodeTestResult
+	for rows.Next() {
+		var r NodeTestResult
+		if err := pq.resultParser.ParseTestResultRow(rows, &r); err != nil {
+			return nil, fmt.Errorf("failed to parse test result: %w", err)
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("error iterating binkps certificate rows: %w", err)
+	}
+	return results, nil
+}
@@ -23,6 +23,26 @@ func (tqb *TestQueryBuilder) BuildTestHistoryQuery() string {
 		ORDER BY test_time ASC, hostname_index`)
 }
 
+// BuildTestResultsExportQuery builds the query behind StreamTestResults: every
+// stored row - per-hostname and aggregated alike - tested on a date in
+// [from, to], in sorting-key order so the result streams without a full sort.
+// nodeWhere is an optional predicate over zone/net/node.
+// Binds: from, to, domain, domain, [nodeWhere binds].
+func (tqb *TestQueryBuilder) BuildTestResultsExportQuery(nodeWhere string) string {
+	q := `
+		SELECT
+			{{TEST_RESULT_COLUMNS}}
+		FROM node_test_results
+		WHERE test_date >= toDate(?) AND test_date <= toDate(?)
+		AND (? = '' OR domain = ?)`
+	if nodeWhere != "" {
+		q += `
+		AND ` + nodeWhere
+	}
+	return applyTestResultColumns(q + `
+		ORDER BY test_date, zone, net, node, test_time, hostname_index`)
+}
+
 // BuildDetailedTestResultQuery builds a query for a specific test result (ClickHouse)
 func (tqb *TestQueryBuilder) BuildDetailedTestResultQuery() string {
 	return applyTestResultColumns(`
@@ -242,6 +262,7 @@ func (tqb *TestQueryBuilder) BuildReachabilityTrendsQuery() string {
 				domain, zone, net, node,
 				toDate(test_time) AS day,
 				argMax(is_operational, (test_time, is_aggregated, -hostname_index)) AS day_status,
+				argMax(intermittent_by_schedule, (test_time, is_aggregated, -hostname_index)) AS day_intermittent,
 				argMax(least(
 					if(binkp_response_ms > 0, binkp_response_ms, 999999),
 					if(ifcico_response_ms > 0, ifcico_response_ms, 999999),
@@ -253,7 +274,7 @@ func (tqb *TestQueryBuilder) BuildReachabilityTrendsQuery() string {
 		),
 		carried AS (
 			SELECT
-				domain, zone, net, node, day, day_status, day_response,
+				domain, zone, net, node, day, day_status, day_intermittent, day_response,
 				leadInFrame(day, 1, toDate(now())) OVER (
 					PARTITION BY domain, zone, net, node ORDER BY day ASC
 					ROWS BETWEEN CURRENT ROW AND UNBOUNDED FOLLOWING
@@ -265,11 +286,12 @@ func (tqb *TestQueryBuilder) BuildReachabilityTrendsQuery() string {
 			count() AS total_nodes,
 			countIf(day_status = 1) AS operational_nodes,
 			countIf(day_status = 0) AS failed_nodes,
+			countIf(day_status = 0 AND day_intermittent = 1) AS intermittent_nodes,
 			avg(toUInt8(day_status)) * 100 AS success_rate,
 			avgIf(day_response, day_status = 1 AND day_response < 999999) AS avg_response_ms
 		FROM (
 			SELECT
-				domain, zone, net, node, day_status, day_response,
+				domain, zone, net, node, day_status, day_intermittent, day_response,
 				greatest(day, toDate(now()) - ?) AS span_start,
 				least(next_day - 1, toDate(now()) - 1) AS span_end,
 				arrayJoin(arrayMap(x -> span_start + x, range(toUInt64(greatest(toInt64(span_end - span_start) + 1, 0))))) AS report_date
@@ -432,6 +454,54 @@ func (tqb *TestQueryBuilder) BuildVModemUnconfirmedQuery(nodeFilter, domainFilte
 		LIMIT ?`, announcedProtocolNodesSQL("IVM", domainFilter, nodeFilter), nodeFilter, domainFilter), days))
 }
 
+// BuildBinkPSCertificateIssuesQuery builds a query for nodes whose latest
+// binkps test saw a self-signed or expired certificate.
+//
+// Same three-CTE shape as BuildVModemUnconfirmedQuery, anchored on "a
+// certificate was seen" (binkps_cert_not_after set) rather than on success: a
+// binkps session that failed after the TLS handshake still recorded the
+// certificate, and a broken certificate is a common reason for it to fail.
+// The gate is nodelist membership, not the IBNS flag: announcedProtocolNodesSQL
+// takes three-letter flags, and binkps is only ever tested on nodes announcing
+// IBNS anyway. Expiry is judged against now(), so a certificate that lapsed
+// since its test is listed too.
+// domainFilter is a ready-made SQL clause (see domainFilterSQL); "" means all FTN networks.
+func (tqb *TestQueryBuilder) BuildBinkPSCertificateIssuesQuery(nodeFilter, domainFilter string, days int) string {
+	return applyTestResultColumns(applyCycleWindows(fmt.Sprintf(`
+		WITH announced_nodes AS (%s
+		),
+		latest_tests AS (
+			SELECT
+				domain, zone, net, node,
+				max(test_time) as latest_test_time
+			FROM node_test_results
+			WHERE test_time >= now() - INTERVAL ? DAY
+				AND binkps_cert_not_after IS NOT NULL
+				AND (domain, zone, net, node) IN (SELECT domain, zone, net, node FROM announced_nodes)
+				%s
+				%s
+			GROUP BY domain, zone, net, node
+		),
+		-- Prefer the aggregated row; it keeps the certificate of a failed
+		-- hostname when none succeeded (test_aggregator.go keepFailureDetails).
+		best_results AS (
+			SELECT
+				r.domain, r.zone, r.net, r.node, r.test_time, r.hostname_index, r.is_aggregated,
+				row_number() OVER (PARTITION BY r.domain, r.zone, r.net, r.node ORDER BY r.is_aggregated DESC, r.hostname_index ASC) as rn
+			FROM node_test_results r
+			JOIN latest_tests lt ON r.domain = lt.domain AND r.zone = lt.zone AND r.net = lt.net AND r.node = lt.node AND {{CYCLE_LT}}
+			WHERE r.binkps_cert_not_after IS NOT NULL
+		)
+		SELECT
+			{{TEST_RESULT_COLUMNS_R}}
+		FROM node_test_results r
+		JOIN best_results br ON r.domain = br.domain AND r.zone = br.zone AND r.net = br.net AND r.node = br.node AND r.test_time = br.test_time
+			AND r.hostname_index = br.hostname_index AND r.is_aggregated = br.is_aggregated AND br.rn = 1
+		WHERE r.binkps_cert_self_signed = true OR r.binkps_cert_not_after < now()
+		ORDER BY r.binkps_cert_not_after ASC, r.zone, r.net, r.node
+		LIMIT ?`, currentNodesSQL(domainFilter, nodeFilter), nodeFilter, domainFilter), days))
+}
+
 // BuildSearchByReachabilityQuery builds a query to search nodes by reachability status (ClickHouse)
 //
 // test_time is second-resolution and a multi-hostname node's per-hostname rows
@@ -458,3 +528,34 @@ func (tqb *TestQueryBuilder) BuildSearchByReachabilityQuery() string {
 		ORDER BY test_time DESC
 		LIMIT ?`)
 }
+
+// BuildTestTranscriptsQuery builds a query for the wire transcripts recorded
+// in the test cycle of one node_test_results row. A cycle of a multi-hostname
+// node spans a range of test_time values, so transcripts are matched within
+// testSessionWindowSeconds either side rather than exactly.
+// Binds: zone, net, node, testTime, testTime, domain, domain.
+func (tqb *TestQueryBuilder) BuildTestTranscriptsQuery() string {
+	return fmt.Sprintf(`
+		SELECT test_time, hostname, protocol, remote_ip, success, error,
+			started_at, raw_bytes, truncated, transcript
+		FROM node_test_transcripts
+		WHERE zone = ? AND net = ? AND node = ?
+		AND test_time >= parseDateTimeBestEffort(?) - INTERVAL %[1]d SECOND
+		AND test_time <= parseDateTimeBestEffort(?) + INTERVAL %[1]d SECOND
+		AND (? = '' OR domain = ?)
+		ORDER BY started_at`, testSessionWindowSeconds)
+}
+
+// BuildVantageResultsQuery builds a query for what each location saw in the
+// test behind one node_test_results row. The rows share its test_time exactly.
+// Binds: zone, net, node, testTime, domain, domain.
+func (tqb *TestQueryBuilder) BuildVantageResultsQuery() string {
+	return `
+		SELECT vantage_point, tested_at, is_operational, tested_hostname, dns_error,
+			protocols_ok, protocols_failed, response_ms, error
+		FROM node_test_vantage_results
+		WHERE zone = ? AND net = ? AND node = ?
+		AND test_time = parseDateTimeBestEffort(?)
+		AND (? = '' OR domain = ?)
+		ORDER BY vantage_point`
+}
@@ -128,6 +128,7 @@ func (r *ReachabilityOperations) queryTrends(ctx context.Context, conn *sql.DB,
 			&t.TotalNodes,
 			&t.OperationalNodes,
 			&t.FailedNodes,
+			&t.IntermittentNodes,
 			&t.SuccessRate,
 			&t.AvgResponseMs,
 		)
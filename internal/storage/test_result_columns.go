@@ -19,9 +19,15 @@ var testResultColumnGroups = [][]string{
 	{"country", "country_code", "city", "region", "latitude", "longitude", "isp", "org", "asn"},
 	{"binkp_tested", "binkp_success", "binkp_response_ms", "binkp_system_name"},
 	{"binkp_sysop", "binkp_location", "binkp_version", "binkp_addresses", "binkp_capabilities", "binkp_error"},
+	{"binkp_auth_method", "binkp_mail_exchange", "binkp_mail_detail"},
+	{"binkp_opt_cram", "binkp_opt_crypt", "binkp_opt_plz", "binkp_opt_gz", "binkp_opt_bz2"},
+	{"binkps_tested", "binkps_success", "binkps_response_ms", "binkps_error"},
+	{"binkps_cert_subject", "binkps_cert_issuer", "binkps_cert_not_after"},
+	{"binkps_cert_chain_valid", "binkps_cert_self_signed", "binkps_cert_error"},
 	{"ifcico_tested", "ifcico_success", "ifcico_response_ms", "ifcico_mailer_info", "ifcico_system_name"},
 	{"ifcico_addresses", "ifcico_response_type", "ifcico_error"},
 	{"telnet_tested", "telnet_success", "telnet_response_ms", "telnet_error"},
+	{"telnet_mailer_info", "telnet_system_name", "telnet_sysop", "telnet_addresses", "telnet_response_type"},
 	{"ftp_tested", "ftp_success", "ftp_response_ms", "ftp_error"},
 	{"vmodem_tested", "vmodem_success", "vmodem_response_ms", "vmodem_error"},
 	{"vmodem_variant", "vmodem_conformant", "vmodem_software", "vmodem_system_name"},
@@ -38,6 +44,10 @@ var testResultColumnGroups = [][]string{
 	{"vmodem_ipv4_tested", "vmodem_ipv4_success", "vmodem_ipv4_response_ms", "vmodem_ipv4_address", "vmodem_ipv4_error"},
 	{"vmodem_ipv6_tested", "vmodem_ipv6_success", "vmodem_ipv6_response_ms", "vmodem_ipv6_address", "vmodem_ipv6_error"},
 	{"is_operational", "has_connectivity_issues", "address_validated"},
+	{"path_tcp", "path_hops", "path_last_hop", "path_last_hop_ttl"},
+	{"path_last_hop_asn", "path_last_hop_as", "path_verdict"},
+	{"uptime_window", "intermittent_by_schedule"},
+	{"vantage_points_tested", "vantage_points_reachable", "vantage_verdict"},
 	{"tested_hostname", "hostname_index", "is_aggregated"},
 	{"total_hostnames", "hostnames_tested", "hostnames_operational"},
 	{"ftp_anon_success", "domain", "derived_from_address"},
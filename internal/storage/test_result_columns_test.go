@@ -108,8 +108,10 @@ func TestTestResultQueryColumnCounts(t *testing.T) {
 	}{
 		{"BuildTestHistoryQuery", qb.BuildTestHistoryQuery()},
 		{"BuildDetailedTestResultQuery", qb.BuildDetailedTestResultQuery()},
+		{"BuildTestResultsExportQuery", qb.BuildTestResultsExportQuery("zone = ?")},
 		{"BuildProtocolEnabledQuery", qb.BuildProtocolEnabledQuery("binkp", "", "", 30)},
 		{"BuildVModemUnconfirmedQuery", qb.BuildVModemUnconfirmedQuery("", "", 30)},
+		{"BuildBinkPSCertificateIssuesQuery", qb.BuildBinkPSCertificateIssuesQuery("", "", 30)},
 		{"BuildSearchByReachabilityQuery", qb.BuildSearchByReachabilityQuery()},
 		{"buildAKAMismatchQuery", am.buildAKAMismatchQuery("", "fidonet", 30)},
 	}
@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// TestTranscript is a wire transcript recorded during a test. Data is the
+// encoded form; internal/testing/transcript decodes it.
+type TestTranscript struct {
+	TestTime  time.Time
+	Hostname  string
+	Protocol  string // binkp | binkps | ifcico
+	RemoteIP  string
+	Success   bool
+	Error     string
+	StartedAt time.Time
+	RawBytes  uint32
+	Truncated bool
+	Data      []byte
+}
+
+// GetTestTranscripts returns the transcripts recorded in the test cycle of
+// the node_test_results row at testTime, oldest first. Most tests have none:
+// the testdaemon keeps them only when configured to.
+func (th *TestHistoryOperations) GetTestTranscripts(ctx context.Context, zone, net, node int, testTime string, domain string) ([]TestTranscript, error) {
+	th.mu.RLock()
+	defer th.mu.RUnlock()
+
+	rows, err := th.db.Conn().QueryContext(ctx, th.queryBuilder.BuildTestTranscriptsQuery(),
+		zone, net, node, testTime, testTime, domain, domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query test transcripts: %w", err)
+	}
+	defer rows.Close()
+
+	var transcripts []TestTranscript
+	for rows.Next() {
+		var t TestTranscript
+		var data string
+		if err := rows.Scan(&t.TestTime, &t.Hostname, &t.Protocol, &t.RemoteIP, &t.Success, &t.Error,
+			&t.StartedAt, &t.RawBytes, &t.Truncated, &data); err != nil {
+			return nil, fmt.Errorf("failed to scan test transcript: %w", err)
+		}
+		t.Data = []byte(data)
+		transcripts = append(transcripts, t)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read test transcripts: %w", err)
+	}
+	return transcripts, nil
+}
@@ -0,0 +1,50 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// VantageResult is what one location saw of a node in a test spread over
+// several.
+type VantageResult struct {
+	VantagePoint    string    `json:"vantage_point"`
+	TestedAt        time.Time `json:"tested_at"`
+	IsOperational   bool      `json:"is_operational"`
+	TestedHostname  string    `json:"tested_hostname,omitempty"`
+	DNSError        string    `json:"dns_error,omitempty"`
+	ProtocolsOK     []string  `json:"protocols_ok,omitempty"`
+	ProtocolsFailed []string  `json:"protocols_failed,omitempty"`
+	ResponseMs      uint32    `json:"response_ms,omitempty"`
+	Error           string    `json:"error,omitempty"`
+}
+
+// GetVantageResults returns the per-location results of the test behind the
+// node_test_results row at testTime, by location name. A test from a single
+// location has none.
+func (th *TestHistoryOperations) GetVantageResults(ctx context.Context, zone, net, node int, testTime string, domain string) ([]VantageResult, error) {
+	th.mu.RLock()
+	defer th.mu.RUnlock()
+
+	rows, err := th.db.Conn().QueryContext(ctx, th.queryBuilder.BuildVantageResultsQuery(),
+		zone, net, node, testTime, domain, domain)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query vantage results: %w", err)
+	}
+	defer rows.Close()
+
+	var results []VantageResult
+	for rows.Next() {
+		var v VantageResult
+		if err := rows.Scan(&v.VantagePoint, &v.TestedAt, &v.IsOperational, &v.TestedHostname, &v.DNSError,
+			&v.ProtocolsOK, &v.ProtocolsFailed, &v.ResponseMs, &v.Error); err != nil {
+			return nil, fmt.Errorf("failed to scan vantage result: %w", err)
+		}
+		results = append(results, v)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read vantage results: %w", err)
+	}
+	return results, nil
+}
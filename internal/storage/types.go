@@ -22,6 +22,11 @@ type NodeSummary struct {
 	FirstDate       time.Time `json:"first_date"`
 	LastDate        time.Time `json:"last_date"`
 	CurrentlyActive bool      `json:"currently_active"`
+
+	// Which of the address's tenancies (see NodeTenancy) the row is, and how
+	// many the address has had. Both 0 when the address is not segmented.
+	Tenancy   int `json:"tenancy,omitempty"`
+	Tenancies int `json:"tenancies,omitempty"`
 }
 
 // DomainInfo describes one FTN network present in the database
@@ -39,6 +44,7 @@ type SysopInfo struct {
 	FirstSeen   time.Time `json:"first_seen"`
 	LastSeen    time.Time `json:"last_seen"`
 	Zones       []int     `json:"zones"`
+	Score       float64   `json:"score,omitempty"` // fuzzy search only: how well the name matched, up to 1
 }
 
 // SoftwareDistribution represents software distribution statistics
@@ -73,6 +79,31 @@ type OSStats struct {
 	Percentage float64 `json:"percentage"`
 }
 
+// BinkPOptionSupport shows, per BinkP mailer, how the options its nodes
+// advertise held up when the testdaemon tried them.
+type BinkPOptionSupport struct {
+	Software    []BinkPSoftwareOptions `json:"software"`
+	LastUpdated time.Time              `json:"last_updated"`
+}
+
+// BinkPSoftwareOptions is one mailer version's record.
+type BinkPSoftwareOptions struct {
+	Software string                `json:"software"`
+	Version  string                `json:"version"`
+	Nodes    int                   `json:"nodes"`  // nodes advertising at least one option
+	Failed   int                   `json:"failed"` // failed verdicts over all options
+	Options  []BinkPOptionVerdicts `json:"options"`
+}
+
+// BinkPOptionVerdicts counts the latest verdicts on one option across a
+// mailer's nodes.
+type BinkPOptionVerdicts struct {
+	Option   string `json:"option"`
+	OK       int    `json:"ok"`
+	Failed   int    `json:"failed"`
+	Untested int    `json:"untested"`
+}
+
 // GeoHostingDistribution represents hosting distribution by geography
 type GeoHostingDistribution struct {
 	TotalNodes           int             `json:"total_nodes"`
@@ -101,6 +132,16 @@ type ProviderStats struct {
 	Countries    []string `json:"countries"` // Countries where this provider hosts nodes
 }
 
+// UnreachableASNStats counts the unreachable nodes whose path probe last got
+// an answer from one autonomous system.
+type UnreachableASNStats struct {
+	ASN        uint32 `json:"asn"`
+	Name       string `json:"name"` // AS name or ISP, from geolocation
+	Nodes      int    `json:"nodes"`
+	HostDown   int    `json:"host_down"`   // the path reached the node's own network
+	PathBroken int    `json:"path_broken"` // the path stopped here, short of the node's network
+}
+
 // BatchInsertConfig holds configuration for batch insert operations
 type BatchInsertConfig struct {
 	ChunkSize       int  // Number of nodes per chunk
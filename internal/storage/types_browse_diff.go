@@ -0,0 +1,73 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+)
+
+// Browse diff levels: what a diff breaks its part of the hierarchy down
+// into. They are the levels of the hierarchy browser.
+const (
+	BrowseLevelZones   = "zones"   // every zone of the network
+	BrowseLevelRegions = "regions" // the regions of one zone
+	BrowseLevelNets    = "nets"    // the nets of one region of a zone
+	BrowseLevelNodes   = "nodes"   // the nodes of one net
+)
+
+// BrowseDiffScope selects the part of the hierarchy a diff covers. Zone is
+// ignored at the zones level, Region is read only at the nets level (0 is
+// the "no region" bucket) and Net only at the nodes level.
+type BrowseDiffScope struct {
+	Level  string
+	Zone   int
+	Region int
+	Net    int
+}
+
+// BrowseDiffCounts tallies how the node addresses of one part of the
+// hierarchy fared between two nodelists. The categories are those of
+// GetNodeChanges: an address listed only on the later date was added, one
+// listed only on the earlier was removed, and one listed on both was
+// modified when any field GetNodeChanges compares differs.
+type BrowseDiffCounts struct {
+	Added     int `json:"added"`
+	Removed   int `json:"removed"`
+	Modified  int `json:"modified"`
+	Unchanged int `json:"unchanged"`
+}
+
+// BrowseDiffGroup is one zone, region or net of a browse diff.
+type BrowseDiffGroup struct {
+	Number    int    `json:"number"`     // zone, region (0 = no region) or net
+	Name      string `json:"name"`       // coordinator system name, as last listed
+	FromNodes int    `json:"from_nodes"` // addresses listed on the earlier date
+	ToNodes   int    `json:"to_nodes"`   // addresses listed on the later date
+	BrowseDiffCounts
+}
+
+// BrowseDiff compares two nodelists of a network over one part of the
+// hierarchy. Only the primary entry of an address is compared; a duplicate
+// listing of the same address is ignored on both dates.
+//
+// Totals count each address once. At the regions level an address whose net
+// moved to another region is removed from the old region's group and added
+// to the new one's, so the groups can add up to more than the totals.
+type BrowseDiff struct {
+	Domain string           `json:"domain"`
+	From   time.Time        `json:"from"`
+	To     time.Time        `json:"to"`
+	Level  string           `json:"level"`
+	Zone   int              `json:"zone,omitempty"`
+	Region int              `json:"region,omitempty"`
+	Net    int              `json:"net,omitempty"`
+	Totals BrowseDiffCounts `json:"totals"`
+
+	// Groups breaks the totals down by zone, region or net; empty at the
+	// nodes level.
+	Groups []BrowseDiffGroup `json:"groups,omitempty"`
+
+	// Changes lists every added, removed and modified node by node number;
+	// only at the nodes level. Each is dated To.
+	Changes []database.NodeChange `json:"changes,omitempty"`
+}
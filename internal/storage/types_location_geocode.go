@@ -0,0 +1,42 @@
+package storage
+
+import "time"
+
+// MapPlace is one point of the node map: a city, or the division or country
+// standing in for nodes whose location named nothing finer.
+type MapPlace struct {
+	Place       string  `json:"place"`
+	CountryCode string  `json:"country_code"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
+	Precision   string  `json:"precision"` // city, region or country
+	Nodes       int     `json:"nodes"`
+}
+
+// MapCountry totals the located nodes of one country.
+type MapCountry struct {
+	CountryCode string `json:"country_code"`
+	Country     string `json:"country"`
+	Nodes       int    `json:"nodes"`
+}
+
+// NodeMap places the nodes of one nodelist by their Location field.
+type NodeMap struct {
+	Date      time.Time    `json:"date"`
+	Domain    string       `json:"domain,omitempty"`
+	Places    []MapPlace   `json:"places"`    // most nodes first
+	Countries []MapCountry `json:"countries"` // most nodes first
+	Located   int          `json:"located"`
+	Unlocated int          `json:"unlocated"` // location unresolved, or not geocoded yet
+}
+
+// GeocodeRun summarizes one run of GeocodeLocations.
+type GeocodeRun struct {
+	Locations  int    // distinct locations geocoded
+	City       int    // resolved to a city
+	Region     int    // resolved to a state, province or territory
+	Country    int    // resolved to a country only
+	Unresolved int    // stored as unresolved, so not retried until the gazetteer changes
+	Gazetteer  string // version of the gazetteer used
+	GeocodedAt time.Time
+}
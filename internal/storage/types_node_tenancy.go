@@ -0,0 +1,35 @@
+package storage
+
+import "time"
+
+// NodeTenancy is one stretch of a node address's history under one
+// occupant: the same sysop (allowing for respellings) running it, from the
+// nodelist that first listed them there to the last.
+type NodeTenancy struct {
+	Domain          string    `json:"domain"`
+	Zone            int       `json:"zone"`
+	Net             int       `json:"net"`
+	Node            int       `json:"node"`
+	Tenancy         int       `json:"tenancy"`     // 1 for the first occupant
+	SysopName       string    `json:"sysop_name"`  // as last listed
+	SystemName      string    `json:"system_name"` // as last listed
+	Location        string    `json:"location"`    // as last listed
+	FirstDate       time.Time `json:"first_date"`
+	LastDate        time.Time `json:"last_date"`
+	Entries         int       `json:"entries"` // nodelists listing the address in the tenancy
+	CurrentlyActive bool      `json:"currently_active"`
+
+	// StartReason says why a tenancy after the first began: "sysop" (a
+	// different sysop took the address over), "system" (a new system while
+	// the sysop went unnamed) or "relisted" (a different system after the
+	// address had been unlisted for over a year). Empty for the first.
+	StartReason string `json:"start_reason,omitempty"`
+}
+
+// TenancyDetection summarizes one run of DetectNodeTenancies.
+type TenancyDetection struct {
+	Addresses  int       // node addresses segmented
+	Tenancies  int       // tenancies they split into
+	Reused     int       // addresses with more than one tenancy
+	DetectedAt time.Time // the generation written
+}
@@ -0,0 +1,64 @@
+package storage
+
+import "time"
+
+// SysopTenure is one sysop name at one address: the node or point listed
+// under that name from FirstSeen to LastSeen.
+type SysopTenure struct {
+	Domain          string    `json:"domain"`
+	Zone            int       `json:"zone"`
+	Net             int       `json:"net"`
+	Node            int       `json:"node"`
+	Point           int       `json:"point,omitempty"`
+	IsPoint         bool      `json:"is_point"`
+	SysopName       string    `json:"sysop_name"`
+	SystemName      string    `json:"system_name"` // as last listed
+	FirstSeen       time.Time `json:"first_seen"`
+	LastSeen        time.Time `json:"last_seen"`
+	CurrentlyListed bool      `json:"currently_listed"`
+
+	// Evidence says why the tenure belongs to its person: "name" and
+	// "similar-name" (the same person by name), "host:<hostname>" and
+	// "email:<redacted address>" (an Internet address shared with another
+	// tenure) and "handover" (a respelling at the same address). A person
+	// with a single tenure has none.
+	Evidence []string `json:"evidence,omitempty"`
+
+	// The hostnames and email addresses the tenure advertised. Used to
+	// resolve identities, not stored.
+	Hosts  []string `json:"-"`
+	Emails []string `json:"-"`
+}
+
+// SysopIdentity is one person behind any number of sysop names, addresses
+// and networks: their whole FTN career.
+type SysopIdentity struct {
+	ID         string        `json:"id"`
+	Name       string        `json:"name"`    // the spelling listed longest
+	Aliases    []string      `json:"aliases"` // every spelling, Name first
+	Domains    []string      `json:"domains"`
+	FirstSeen  time.Time     `json:"first_seen"`
+	LastSeen   time.Time     `json:"last_seen"`
+	NodeCount  int           `json:"node_count"`
+	PointCount int           `json:"point_count"`
+	Active     int           `json:"active"` // tenures still listed
+	Career     []SysopTenure `json:"career"` // oldest first
+	ResolvedAt time.Time     `json:"resolved_at"`
+}
+
+// SysopIdentityRef names a person without their career.
+type SysopIdentityRef struct {
+	ID    string    `json:"id"`
+	Name  string    `json:"name"`
+	Since time.Time `json:"since"` // when they first ran the address asked about
+}
+
+// SysopResolution summarizes one run of ResolveSysopIdentities.
+type SysopResolution struct {
+	Tenures    int       // node and point tenures read
+	Identities int       // persons they resolved to
+	Linked     int       // persons with more than one tenure
+	Kept       int       // identities that kept the ID of the previous run
+	Redirects  int       // retired IDs that now point at another identity
+	ResolvedAt time.Time // the generation written
+}
@@ -41,6 +41,26 @@ type NodeTestResult struct {
 	BinkPAddresses    []string `json:"binkp_addresses"`
 	BinkPCapabilities []string `json:"binkp_capabilities"`
 	BinkPError        string   `json:"binkp_error"`
+	BinkPAuthMethod   string   `json:"binkp_auth_method"`   // cram-md5 | plain; "" when we hold no session password for the node
+	BinkPMailExchange string   `json:"binkp_mail_exchange"` // outcome of the password-protected probe transfer; "" when none was attempted
+	BinkPMailDetail   string   `json:"binkp_mail_detail"`   // human-readable note behind the outcome
+	BinkPOptCRAM      string   `json:"binkp_opt_cram"`      // verdict on an advertised option: ok | failed | untested; "" when not advertised
+	BinkPOptCrypt     string   `json:"binkp_opt_crypt"`
+	BinkPOptPLZ       string   `json:"binkp_opt_plz"`
+	BinkPOptGZ        string   `json:"binkp_opt_gz"`
+	BinkPOptBZ2       string   `json:"binkp_opt_bz2"`
+
+	// BinkP over TLS (IBNS) Test Results
+	BinkPSTested         bool       `json:"binkps_tested"`
+	BinkPSSuccess        bool       `json:"binkps_success"`
+	BinkPSResponseMs     uint32     `json:"binkps_response_ms"`
+	BinkPSError          string     `json:"binkps_error"`
+	BinkPSCertSubject    string     `json:"binkps_cert_subject"`
+	BinkPSCertIssuer     string     `json:"binkps_cert_issuer"`
+	BinkPSCertNotAfter   *time.Time `json:"binkps_cert_not_after,omitempty"` // nil when no certificate was seen
+	BinkPSCertChainValid bool       `json:"binkps_cert_chain_valid"`         // chains to a trusted root for the tested host name, and in date
+	BinkPSCertSelfSigned bool       `json:"binkps_cert_self_signed"`
+	BinkPSCertError      string     `json:"binkps_cert_error"` // why the chain did not verify
 
 	// IFCICO Test Results
 	IfcicoTested       bool     `json:"ifcico_tested"`
@@ -57,6 +77,12 @@ type NodeTestResult struct {
 	TelnetSuccess    bool   `json:"telnet_success"`
 	TelnetResponseMs uint32 `json:"telnet_response_ms"`
 	TelnetError      string `json:"telnet_error"`
+	// Set when an EMSI mailer answered behind the telnet port
+	TelnetMailerInfo   string   `json:"telnet_mailer_info"`
+	TelnetSystemName   string   `json:"telnet_system_name"`
+	TelnetSysop        string   `json:"telnet_sysop"`
+	TelnetAddresses    []string `json:"telnet_addresses"`
+	TelnetResponseType string   `json:"telnet_response_type"`
 
 	// FTP Test Results
 	FTPTested      bool   `json:"ftp_tested"`
@@ -139,6 +165,30 @@ type NodeTestResult struct {
 	HasConnectivityIssues bool `json:"has_connectivity_issues"`
 	AddressValidated      bool `json:"address_validated"`
 
+	// Path probe of a node that failed every protocol; empty otherwise.
+	// PathVerdict is open | refused | filtered | host-down | path-broken |
+	// unknown.
+	PathTCP        string   `json:"path_tcp,omitempty"`
+	PathHops       []string `json:"path_hops,omitempty"`
+	PathLastHop    string   `json:"path_last_hop,omitempty"`
+	PathLastHopTTL uint8    `json:"path_last_hop_ttl,omitempty"`
+	PathLastHopASN uint32   `json:"path_last_hop_asn,omitempty"`
+	PathLastHopAS  string   `json:"path_last_hop_as,omitempty"`
+	PathVerdict    string   `json:"path_verdict,omitempty"`
+
+	// Hours the node has been learned to answer in ("22-06 UTC"), and
+	// whether this failure fell outside them: such a node keeps a schedule,
+	// it is not down.
+	UptimeWindow           string `json:"uptime_window,omitempty"`
+	IntermittentBySchedule bool   `json:"intermittent_by_schedule,omitempty"`
+
+	// How many locations tested the node and how many reached it, when
+	// testing is spread over several. VantageVerdict is reachable, regional
+	// or unreachable; empty for a single location.
+	VantagePointsTested    uint8  `json:"vantage_points_tested,omitempty"`
+	VantagePointsReachable uint8  `json:"vantage_points_reachable,omitempty"`
+	VantageVerdict         string `json:"vantage_verdict,omitempty"`
+
 	// Multi-network identity and AKA-derivation provenance
 	Domain             string `json:"domain,omitempty"`               // FTN network of the tested identity
 	DerivedFromAddress string `json:"derived_from_address,omitempty"` // non-empty: result derived from this node's direct test
@@ -197,6 +247,9 @@ type ReachabilityTrend struct {
 	TotalNodes       int       `json:"total_nodes"`
 	OperationalNodes int       `json:"operational_nodes"`
 	FailedNodes      int       `json:"failed_nodes"`
-	SuccessRate      float64   `json:"success_rate"`
-	AvgResponseMs    float64   `json:"avg_response_ms"`
+	// IntermittentNodes are the failed nodes whose failure fell outside
+	// their learned uptime window: asleep rather than down.
+	IntermittentNodes int     `json:"intermittent_nodes"`
+	SuccessRate       float64 `json:"success_rate"`
+	AvgResponseMs     float64 `json:"avg_response_ms"`
 }
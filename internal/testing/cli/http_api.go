@@ -0,0 +1,342 @@
+package cli
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrNotScheduled is returned by ScheduleNode for a node the scheduler does
+// not know.
+var ErrNotScheduled = errors.New("node is not scheduled for testing")
+
+// ControlInterface is what the HTTP API needs beyond the telnet commands:
+// the schedule, and a feed of test progress.
+type ControlInterface interface {
+	DaemonInterface
+	// GetQueue returns up to limit scheduled nodes, soonest due first; a
+	// limit of 0 returns all of them.
+	GetQueue(limit int) []QueueEntry
+	// ScheduleNode moves a node's next test to at. The override lasts until
+	// that test: its result schedules the one after as usual.
+	ScheduleNode(zone, net, node uint16, domain string, at time.Time) (*QueueEntry, error)
+	SubscribeProgress() (<-chan ProgressEvent, func())
+}
+
+// HTTPConfig holds configuration for the HTTP control API.
+type HTTPConfig struct {
+	Listen string   // host:port
+	Tokens []string // bearer tokens; any of them is accepted
+	// TestTimeout bounds an ad-hoc test. Default 2m.
+	TestTimeout time.Duration
+}
+
+// sseKeepalive is how often an idle event stream gets a comment line, so
+// proxies between it and the dashboard do not close it.
+const sseKeepalive = 15 * time.Second
+
+// HTTPServer serves the telnet CLI's operations as a JSON API, plus the
+// schedule and a Server-Sent Events stream of test progress.
+type HTTPServer struct {
+	daemon ControlInterface
+	config HTTPConfig
+	server *http.Server
+}
+
+// NewHTTPServer creates a new HTTP control API server
+func NewHTTPServer(daemon ControlInterface, config HTTPConfig) *HTTPServer {
+	if config.Listen == "" {
+		config.Listen = "127.0.0.1:2380"
+	}
+	if config.TestTimeout == 0 {
+		config.TestTimeout = 2 * time.Minute
+	}
+	return &HTTPServer{daemon: daemon, config: config}
+}
+
+// Start listens and serves in the background until ctx is done.
+func (s *HTTPServer) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", s.config.Listen)
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s: %w", s.config.Listen, err)
+	}
+	s.server = &http.Server{
+		Handler:           s.Handler(),
+		ReadHeaderTimeout: 10 * time.Second,
+		// Event streams never go idle; cancelling their requests with ctx
+		// is what lets Shutdown finish.
+		BaseContext: func(net.Listener) context.Context { return ctx },
+	}
+	go func() {
+		if err := s.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			fmt.Printf("HTTP control API stopped: %v\n", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = s.server.Shutdown(shutdownCtx)
+	}()
+
+	fmt.Printf("HTTP control API listening on %s\n", ln.Addr())
+	return nil
+}
+
+// Handler returns the API's routes, every one behind token auth.
+func (s *HTTPServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status", s.handleStatus)
+	mux.HandleFunc("GET /workers", s.handleWorkers)
+	mux.HandleFunc("GET /node", s.handleNode)
+	mux.HandleFunc("POST /test", s.handleTest)
+	mux.HandleFunc("POST /pause", s.handlePause)
+	mux.HandleFunc("POST /resume", s.handleResume)
+	mux.HandleFunc("POST /reload", s.handleReload)
+	mux.HandleFunc("GET /debug", s.handleGetDebug)
+	mux.HandleFunc("PUT /debug", s.handleSetDebug)
+	mux.HandleFunc("GET /queue", s.handleQueue)
+	mux.HandleFunc("POST /queue", s.handleSchedule)
+	mux.HandleFunc("GET /events", s.handleEvents)
+	return s.authenticate(mux)
+}
+
+// authenticate accepts a request carrying one of the configured tokens as a
+// bearer token. The event stream also takes it as ?token=, since a browser's
+// EventSource cannot set headers.
+func (s *HTTPServer) authenticate(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var token string
+		if auth := r.Header.Get("Authorization"); len(auth) > 7 && strings.EqualFold(auth[:7], "bearer ") {
+			token = strings.TrimSpace(auth[7:])
+		} else if r.URL.Path == "/events" {
+			token = r.URL.Query().Get("token")
+		}
+		valid := false
+		for _, t := range s.config.Tokens {
+			if token != "" && subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+				valid = true
+			}
+		}
+		if !valid {
+			writeError(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *HTTPServer) handleStatus(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.daemon.GetStatus(), http.StatusOK)
+}
+
+func (s *HTTPServer) handleWorkers(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, s.daemon.GetWorkerStatus(), http.StatusOK)
+}
+
+// handleNode is the telnet "show": GET /node?address=2:5001/100
+func (s *HTTPServer) handleNode(w http.ResponseWriter, r *http.Request) {
+	zone, net, node, err := parseAddress(r.URL.Query().Get("address"))
+	if err != nil {
+		writeError(w, "invalid address: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	info, err := s.daemon.GetNodeInfo(r.Context(), zone, net, node)
+	if err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	status := http.StatusOK
+	if !info.Found {
+		status = http.StatusNotFound
+	}
+	writeJSON(w, info, status)
+}
+
+type testRequest struct {
+	Address  string `json:"address"`
+	Hostname string `json:"hostname"`
+	Protocol string `json:"protocol"`
+}
+
+// handleTest is the telnet "test": it runs the test and answers with the
+// result, which can take as long as the slowest protocol's timeout. Its
+// progress also goes out on /events.
+func (s *HTTPServer) handleTest(w http.ResponseWriter, r *http.Request) {
+	var req testRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<16)).Decode(&req); err != nil {
+		writeError(w, "malformed request body", http.StatusBadRequest)
+		return
+	}
+	zone, net, node, err := parseAddress(req.Address)
+	if err != nil {
+		writeError(w, "invalid address: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	options := TestOptions{
+		Protocols: []string{"binkp", "ifcico", "telnet", "ftp", "vmodem"},
+		Timeout:   s.config.TestTimeout,
+	}
+	if p := strings.ToLower(req.Protocol); p != "" && p != "all" {
+		options.Protocols = []string{p}
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), s.config.TestTimeout)
+	defer cancel()
+	result, err := s.daemon.TestNode(ctx, zone, net, node, req.Hostname, options)
+	if err != nil {
+		writeError(w, "test failed: "+err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	writeJSON(w, result, http.StatusOK)
+}
+
+func (s *HTTPServer) handlePause(w http.ResponseWriter, r *http.Request) {
+	if err := s.daemon.Pause(); err != nil {
+		writeError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, s.daemon.GetStatus(), http.StatusOK)
+}
+
+func (s *HTTPServer) handleResume(w http.ResponseWriter, r *http.Request) {
+	if err := s.daemon.Resume(); err != nil {
+		writeError(w, err.Error(), http.StatusConflict)
+		return
+	}
+	writeJSON(w, s.daemon.GetStatus(), http.StatusOK)
+}
+
+func (s *HTTPServer) handleReload(w http.ResponseWriter, r *http.Request) {
+	if err := s.daemon.ReloadConfig(); err != nil {
+		writeError(w, "reload failed: "+err.Error(), http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type debugState struct {
+	Enabled bool `json:"enabled"`
+}
+
+func (s *HTTPServer) handleGetDebug(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, debugState{Enabled: s.daemon.GetDebugMode()}, http.StatusOK)
+}
+
+func (s *HTTPServer) handleSetDebug(w http.ResponseWriter, r *http.Request) {
+	var req debugState
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		writeError(w, "malformed request body", http.StatusBadRequest)
+		return
+	}
+	if err := s.daemon.SetDebugMode(req.Enabled); err != nil {
+		writeError(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	writeJSON(w, debugState{Enabled: s.daemon.GetDebugMode()}, http.StatusOK)
+}
+
+// handleQueue lists the schedule, soonest due first: GET /queue?limit=50
+func (s *HTTPServer) handleQueue(w http.ResponseWriter, r *http.Request) {
+	limit := 0
+	if v := r.URL.Query().Get("limit"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 0 {
+			writeError(w, "limit must be a non-negative integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	writeJSON(w, s.daemon.GetQueue(limit), http.StatusOK)
+}
+
+type scheduleRequest struct {
+	Address string    `json:"address"`
+	Domain  string    `json:"domain"` // empty means fidonet
+	At      time.Time `json:"at"`     // omitted means now
+}
+
+// handleSchedule overrides when a scheduled node is tested next.
+func (s *HTTPServer) handleSchedule(w http.ResponseWriter, r *http.Request) {
+	var req scheduleRequest
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<10)).Decode(&req); err != nil {
+		writeError(w, "malformed request body", http.StatusBadRequest)
+		return
+	}
+	zone, net, node, err := parseAddress(req.Address)
+	if err != nil {
+		writeError(w, "invalid address: "+err.Error(), http.StatusBadRequest)
+		return
+	}
+	at := req.At
+	if at.IsZero() {
+		at = time.Now()
+	}
+	entry, err := s.daemon.ScheduleNode(zone, net, node, req.Domain, at)
+	switch {
+	case errors.Is(err, ErrNotScheduled):
+		writeError(w, err.Error(), http.StatusNotFound)
+	case err != nil:
+		writeError(w, err.Error(), http.StatusInternalServerError)
+	default:
+		writeJSON(w, entry, http.StatusOK)
+	}
+}
+
+// handleEvents streams progress events until the client goes away. Each is
+// sent as an SSE event named after its type, with the event as JSON data.
+func (s *HTTPServer) handleEvents(w http.ResponseWriter, r *http.Request) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeError(w, "streaming unsupported", http.StatusInternalServerError)
+		return
+	}
+	events, unsubscribe := s.daemon.SubscribeProgress()
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	w.WriteHeader(http.StatusOK)
+	fmt.Fprint(w, ": connected\n\n")
+	flusher.Flush()
+
+	keepalive := time.NewTicker(sseKeepalive)
+	defer keepalive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepalive.C:
+			fmt.Fprint(w, ": keepalive\n\n")
+		case ev, ok := <-events:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(ev)
+			if err != nil {
+				continue
+			}
+			fmt.Fprintf(w, "event: %s\ndata: %s\n\n", ev.Type, data)
+		}
+		flusher.Flush()
+	}
+}
+
+func writeJSON(w http.ResponseWriter, v any, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func writeError(w http.ResponseWriter, message string, status int) {
+	writeJSON(w, map[string]string{"error": message}, status)
+}
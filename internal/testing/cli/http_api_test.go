@@ -0,0 +1,258 @@
+package cli
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// fakeDaemon records what the API asked of it.
+type fakeDaemon struct {
+	paused    bool
+	tested    string
+	protocols []string
+	scheduled time.Time
+	queue     []QueueEntry
+	progress  *ProgressHub
+}
+
+func (f *fakeDaemon) TestNode(ctx context.Context, zone, net, node uint16, hostname string, options TestOptions) (*TestResult, error) {
+	if hostname == "" {
+		return nil, errors.New("node not found in database and no hostname provided")
+	}
+	f.tested = hostname
+	f.protocols = options.Protocols
+	return &TestResult{Address: "2:5020/1", Hostname: hostname, IsOperational: true}, nil
+}
+
+func (f *fakeDaemon) GetStatus() DaemonStatus {
+	if f.paused {
+		return DaemonStatus{Status: "paused"}
+	}
+	return DaemonStatus{Status: "running"}
+}
+
+func (f *fakeDaemon) GetWorkerStatus() WorkerStatus { return WorkerStatus{TotalWorkers: 4} }
+
+func (f *fakeDaemon) GetNodeInfo(ctx context.Context, zone, net, node uint16) (*NodeInfo, error) {
+	return &NodeInfo{Address: "2:5020/1", Found: node == 1}, nil
+}
+
+func (f *fakeDaemon) Pause() error {
+	if f.paused {
+		return errors.New("daemon is already paused")
+	}
+	f.paused = true
+	return nil
+}
+
+func (f *fakeDaemon) Resume() error {
+	if !f.paused {
+		return errors.New("daemon is not paused")
+	}
+	f.paused = false
+	return nil
+}
+
+func (f *fakeDaemon) ReloadConfig() error             { return nil }
+func (f *fakeDaemon) SetDebugMode(enabled bool) error { return nil }
+func (f *fakeDaemon) GetDebugMode() bool              { return false }
+
+func (f *fakeDaemon) GetQueue(limit int) []QueueEntry {
+	if limit > 0 && limit < len(f.queue) {
+		return f.queue[:limit]
+	}
+	return f.queue
+}
+
+func (f *fakeDaemon) ScheduleNode(zone, net, node uint16, domain string, at time.Time) (*QueueEntry, error) {
+	if node != 1 {
+		return nil, ErrNotScheduled
+	}
+	f.scheduled = at
+	return &QueueEntry{Address: "2:5020/1", NextTestTime: at, Reason: "override"}, nil
+}
+
+func (f *fakeDaemon) SubscribeProgress() (<-chan ProgressEvent, func()) {
+	return f.progress.Subscribe()
+}
+
+func newTestAPI(t *testing.T) (*fakeDaemon, *httptest.Server) {
+	t.Helper()
+	f := &fakeDaemon{
+		progress: NewProgressHub(),
+		queue:    []QueueEntry{{Address: "2:5020/1"}, {Address: "2:5020/2"}},
+	}
+	srv := httptest.NewServer(NewHTTPServer(f, HTTPConfig{Tokens: []string{"secret"}}).Handler())
+	t.Cleanup(srv.Close)
+	return f, srv
+}
+
+func call(t *testing.T, srv *httptest.Server, method, path, body string) *http.Response {
+	t.Helper()
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer secret")
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { resp.Body.Close() })
+	return resp
+}
+
+func TestHTTPAPIAuthentication(t *testing.T) {
+	_, srv := newTestAPI(t)
+	tests := []struct {
+		name   string
+		path   string
+		header string
+		want   int
+	}{
+		{"no token", "/status", "", http.StatusUnauthorized},
+		{"wrong token", "/status", "Bearer guess", http.StatusUnauthorized},
+		{"bearer token", "/status", "Bearer secret", http.StatusOK},
+		{"scheme is case-insensitive", "/status", "bearer secret", http.StatusOK},
+		{"query token outside the event stream", "/status?token=secret", "", http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodGet, srv.URL+tt.path, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+			resp, err := srv.Client().Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != tt.want {
+				t.Errorf("status %d, want %d", resp.StatusCode, tt.want)
+			}
+		})
+	}
+}
+
+func TestHTTPAPICommands(t *testing.T) {
+	f, srv := newTestAPI(t)
+	tests := []struct {
+		method, path, body string
+		want               int
+	}{
+		{http.MethodPost, "/test", `{"address":"2:5020/1","hostname":"bbs.example.com","protocol":"BinkP"}`, http.StatusOK},
+		{http.MethodPost, "/test", `{"address":"2:5020/1"}`, http.StatusUnprocessableEntity},
+		{http.MethodPost, "/test", `{"address":"2-5020-1","hostname":"bbs.example.com"}`, http.StatusBadRequest},
+		{http.MethodGet, "/node?address=2:5020/1", "", http.StatusOK},
+		{http.MethodGet, "/node?address=2:5020/9", "", http.StatusNotFound},
+		{http.MethodPost, "/pause", "", http.StatusOK},
+		{http.MethodPost, "/pause", "", http.StatusConflict},
+		{http.MethodPost, "/resume", "", http.StatusOK},
+		{http.MethodPost, "/reload", "", http.StatusNoContent},
+		{http.MethodPut, "/debug", `{"enabled":true}`, http.StatusOK},
+		{http.MethodGet, "/queue?limit=-1", "", http.StatusBadRequest},
+		{http.MethodPost, "/queue", `{"address":"2:5020/9"}`, http.StatusNotFound},
+		{http.MethodDelete, "/queue", "", http.StatusMethodNotAllowed},
+	}
+	for _, tt := range tests {
+		if resp := call(t, srv, tt.method, tt.path, tt.body); resp.StatusCode != tt.want {
+			t.Errorf("%s %s %s: status %d, want %d", tt.method, tt.path, tt.body, resp.StatusCode, tt.want)
+		}
+	}
+	if f.tested != "bbs.example.com" || len(f.protocols) != 1 || f.protocols[0] != "binkp" {
+		t.Errorf("test ran against %q with %v, want bbs.example.com with binkp only", f.tested, f.protocols)
+	}
+}
+
+func TestHTTPAPIQueue(t *testing.T) {
+	f, srv := newTestAPI(t)
+
+	var queue []QueueEntry
+	if err := json.NewDecoder(call(t, srv, http.MethodGet, "/queue?limit=1", "").Body).Decode(&queue); err != nil {
+		t.Fatal(err)
+	}
+	if len(queue) != 1 || queue[0].Address != "2:5020/1" {
+		t.Errorf("GET /queue?limit=1 = %+v, want the first entry only", queue)
+	}
+
+	at := time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC)
+	var entry QueueEntry
+	resp := call(t, srv, http.MethodPost, "/queue", `{"address":"2:5020/1","at":"2026-03-10T22:00:00Z"}`)
+	if err := json.NewDecoder(resp.Body).Decode(&entry); err != nil {
+		t.Fatal(err)
+	}
+	if !f.scheduled.Equal(at) || !entry.NextTestTime.Equal(at) {
+		t.Errorf("scheduled for %v (answered %v), want %v", f.scheduled, entry.NextTestTime, at)
+	}
+
+	// Without a time the node is due now
+	call(t, srv, http.MethodPost, "/queue", `{"address":"2:5020/1"}`)
+	if time.Since(f.scheduled) > time.Minute {
+		t.Errorf("scheduled for %v, want now", f.scheduled)
+	}
+}
+
+func TestHTTPAPIEvents(t *testing.T) {
+	f, srv := newTestAPI(t)
+
+	// EventSource cannot set headers, so the stream takes its token in the query
+	resp, err := srv.Client().Get(srv.URL + "/events?token=secret")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/event-stream" {
+		t.Fatalf("GET /events: %d %q", resp.StatusCode, resp.Header.Get("Content-Type"))
+	}
+
+	lines := bufio.NewReader(resp.Body)
+	if line, _ := lines.ReadString('\n'); line != ": connected\n" {
+		t.Fatalf("stream opened with %q", line)
+	}
+	f.progress.Publish(ProgressEvent{Type: EventTestFinished, Address: "2:5020/1", Operational: true, ProtocolsOK: []string{"binkp"}})
+
+	var event, data string
+	for event == "" || data == "" {
+		line, err := lines.ReadString('\n')
+		if err != nil {
+			t.Fatalf("reading the stream: %v", err)
+		}
+		if v, ok := strings.CutPrefix(line, "event: "); ok {
+			event = strings.TrimSpace(v)
+		}
+		if v, ok := strings.CutPrefix(line, "data: "); ok {
+			data = strings.TrimSpace(v)
+		}
+	}
+	var ev ProgressEvent
+	if err := json.Unmarshal([]byte(data), &ev); err != nil {
+		t.Fatalf("event data %q: %v", data, err)
+	}
+	if event != EventTestFinished || ev.Address != "2:5020/1" || !ev.Operational {
+		t.Errorf("got event %q %+v", event, ev)
+	}
+}
+
+func TestProgressHubDropsForSlowSubscribers(t *testing.T) {
+	h := NewProgressHub()
+	slow, unsubscribe := h.Subscribe()
+	for i := 0; i < progressBuffer+10; i++ {
+		h.Publish(ProgressEvent{Type: EventTestStarted})
+	}
+	if len(slow) != progressBuffer {
+		t.Errorf("%d events buffered, want %d", len(slow), progressBuffer)
+	}
+	unsubscribe()
+	unsubscribe() // safe to call twice
+	h.Publish(ProgressEvent{Type: EventTestStarted})
+
+	var nilHub *ProgressHub
+	nilHub.Publish(ProgressEvent{}) // a nil hub discards
+}
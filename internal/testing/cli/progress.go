@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"sync"
+)
+
+// progressBuffer is how many events a subscriber may fall behind by before
+// it starts missing them.
+const progressBuffer = 64
+
+// ProgressHub fans progress events out to any number of subscribers. A
+// subscriber that does not keep up loses events rather than holding up the
+// tests that publish them.
+type ProgressHub struct {
+	mu   sync.Mutex
+	subs map[chan ProgressEvent]struct{}
+}
+
+// NewProgressHub creates a hub with no subscribers.
+func NewProgressHub() *ProgressHub {
+	return &ProgressHub{subs: make(map[chan ProgressEvent]struct{})}
+}
+
+// Subscribe returns a channel of events published from now on and a function
+// that ends the subscription and closes the channel.
+func (h *ProgressHub) Subscribe() (<-chan ProgressEvent, func()) {
+	ch := make(chan ProgressEvent, progressBuffer)
+	h.mu.Lock()
+	h.subs[ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	return ch, func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs, ch)
+			h.mu.Unlock()
+			close(ch)
+		})
+	}
+}
+
+// Publish sends ev to every subscriber with room for it. A nil hub discards
+// it, so callers need not check whether anyone is listening.
+func (h *ProgressHub) Publish(ev ProgressEvent) {
+	if h == nil {
+		return
+	}
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for ch := range h.subs {
+		select {
+		case ch <- ev:
+		default:
+		}
+	}
+}
@@ -12,84 +12,84 @@ type TestOptions struct {
 }
 
 type TestResult struct {
-	TestID          string
-	Address         string
-	Hostname        string
-	StartTime       time.Time
-	Duration        time.Duration
-	ResolvedIPs     []string
-	ExpectedAddress string
-
-	Geolocation *GeolocationInfo
-
-	BinkPResult  *ProtocolResult
-	IFCICOResult *ProtocolResult
-	TelnetResult *ProtocolResult
-	FTPResult    *ProtocolResult
-	VModemResult *ProtocolResult
-
-	IsOperational         bool
-	HasConnectivityIssues bool
-	AddressValidated      bool
+	TestID          string        `json:"test_id"`
+	Address         string        `json:"address"`
+	Hostname        string        `json:"hostname"`
+	StartTime       time.Time     `json:"start_time"`
+	Duration        time.Duration `json:"duration_ns"`
+	ResolvedIPs     []string      `json:"resolved_ips,omitempty"`
+	ExpectedAddress string        `json:"expected_address"`
+
+	Geolocation *GeolocationInfo `json:"geolocation,omitempty"`
+
+	BinkPResult  *ProtocolResult `json:"binkp_result,omitempty"`
+	IFCICOResult *ProtocolResult `json:"ifcico_result,omitempty"`
+	TelnetResult *ProtocolResult `json:"telnet_result,omitempty"`
+	FTPResult    *ProtocolResult `json:"ftp_result,omitempty"`
+	VModemResult *ProtocolResult `json:"vmodem_result,omitempty"`
+
+	IsOperational         bool `json:"is_operational"`
+	HasConnectivityIssues bool `json:"has_connectivity_issues"`
+	AddressValidated      bool `json:"address_validated"`
 }
 
 type ProtocolResult struct {
-	Tested       bool
-	Success      bool
-	ResponseTime int
-	Port         int
-	Error        string
-
-	SystemName   string
-	Sysop        string
-	Location     string
-	Version      string
-	Addresses    []string
-	Capabilities []string
+	Tested       bool   `json:"tested"`
+	Success      bool   `json:"success"`
+	ResponseTime int    `json:"response_time_ms"`
+	Port         int    `json:"port"`
+	Error        string `json:"error,omitempty"`
+
+	SystemName   string   `json:"system_name"`
+	Sysop        string   `json:"sysop"`
+	Location     string   `json:"location"`
+	Version      string   `json:"version"`
+	Addresses    []string `json:"addresses,omitempty"`
+	Capabilities []string `json:"capabilities,omitempty"`
 
 	// VModem/IVM-specific: the protocol actually observed and whether it is a
 	// genuine VMODEM (VMP) responder, plus why the probe came out that way —
 	// how a VMP call ended, and the greeting of a peer we could not identify.
-	Variant     string
-	Conformant  bool
-	Detail      string
-	CallOutcome string
-	Banner      string
+	Variant     string `json:"variant"`
+	Conformant  bool   `json:"conformant"`
+	Detail      string `json:"detail"`
+	CallOutcome string `json:"call_outcome"`
+	Banner      string `json:"banner"`
 }
 
 type GeolocationInfo struct {
-	Country     string
-	CountryCode string
-	City        string
-	Region      string
-	ISP         string
-	ASN         string
-	Latitude    float64
-	Longitude   float64
+	Country     string  `json:"country"`
+	CountryCode string  `json:"country_code"`
+	City        string  `json:"city"`
+	Region      string  `json:"region"`
+	ISP         string  `json:"isp"`
+	ASN         string  `json:"asn"`
+	Latitude    float64 `json:"latitude"`
+	Longitude   float64 `json:"longitude"`
 }
 
 type DaemonStatus struct {
-	Uptime         time.Duration
-	TestsCompleted int
-	SuccessRate    float64
-	ActiveWorkers  int
-	QueueSize      int
-	Status         string
-	NextCycle      time.Time
+	Uptime         time.Duration `json:"uptime_ns"`
+	TestsCompleted int           `json:"tests_completed"`
+	SuccessRate    float64       `json:"success_rate"`
+	ActiveWorkers  int           `json:"active_workers"`
+	QueueSize      int           `json:"queue_size"`
+	Status         string        `json:"status"`
+	NextCycle      time.Time     `json:"next_cycle"`
 }
 
 type WorkerStatus struct {
-	TotalWorkers int
-	Active       int
-	Idle         int
-	QueueLength  int
-	CurrentTasks []TaskInfo
+	TotalWorkers int        `json:"total_workers"`
+	Active       int        `json:"active"`
+	Idle         int        `json:"idle"`
+	QueueLength  int        `json:"queue_length"`
+	CurrentTasks []TaskInfo `json:"current_tasks,omitempty"`
 }
 
 type TaskInfo struct {
-	Node      string
-	StartTime time.Time
-	Protocol  string
+	Node      string    `json:"node"`
+	StartTime time.Time `json:"start_time"`
+	Protocol  string    `json:"protocol"`
 }
 
 type TestOutput struct {
@@ -99,17 +99,53 @@ type TestOutput struct {
 }
 
 type NodeInfo struct {
-	Address           string
-	SystemName        string
-	SysopName         string
-	Location          string
-	NodeType          string
-	HasInternet       bool
-	InternetHostnames []string
-	InternetProtocols []string
-	Flags             []string
-	ModemFlags        []string
-	LastSeen          time.Time
-	Found             bool
-	ErrorMessage      string
+	Address           string    `json:"address"`
+	SystemName        string    `json:"system_name"`
+	SysopName         string    `json:"sysop_name"`
+	Location          string    `json:"location"`
+	NodeType          string    `json:"node_type"`
+	HasInternet       bool      `json:"has_internet"`
+	InternetHostnames []string  `json:"internet_hostnames,omitempty"`
+	InternetProtocols []string  `json:"internet_protocols,omitempty"`
+	Flags             []string  `json:"flags,omitempty"`
+	ModemFlags        []string  `json:"modem_flags,omitempty"`
+	LastSeen          time.Time `json:"last_seen"`
+	Found             bool      `json:"found"`
+	ErrorMessage      string    `json:"error_message,omitempty"`
+}
+
+// QueueEntry is one node's place in the test schedule.
+type QueueEntry struct {
+	Address          string    `json:"address"`
+	Domain           string    `json:"domain,omitempty"`
+	NextTestTime     time.Time `json:"next_test_time"`
+	LastTestTime     time.Time `json:"last_test_time"`
+	LastTestSuccess  bool      `json:"last_test_success"`
+	ConsecutiveFails int       `json:"consecutive_fails"`
+	Priority         int       `json:"priority"`
+	Reason           string    `json:"reason,omitempty"`
+}
+
+// Progress event types.
+const (
+	EventCycleStarted  = "cycle_started"
+	EventCycleFinished = "cycle_finished"
+	EventTestStarted   = "test_started"
+	EventTestFinished  = "test_finished"
+)
+
+// ProgressEvent is one step of the daemon's testing, as streamed to the HTTP
+// API's /events subscribers. Nodes is set on cycle events only; the outcome
+// fields on test_finished only.
+type ProgressEvent struct {
+	Type            string    `json:"type"`
+	Time            time.Time `json:"time"`
+	Address         string    `json:"address,omitempty"`
+	Hostname        string    `json:"hostname,omitempty"`
+	AdHoc           bool      `json:"ad_hoc,omitempty"` // requested through the CLI or API, not the schedule
+	Nodes           int       `json:"nodes,omitempty"`
+	Operational     bool      `json:"operational,omitempty"`
+	ProtocolsOK     []string  `json:"protocols_ok,omitempty"`
+	ProtocolsFailed []string  `json:"protocols_failed,omitempty"`
+	Error           string    `json:"error,omitempty"`
 }
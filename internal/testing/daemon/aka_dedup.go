@@ -111,6 +111,13 @@ func ifcicoAddrs(v any) ([]string, bool) {
 	return nil, false
 }
 
+func telnetAddrs(v any) ([]string, bool) {
+	if details, ok := v.(*models.TelnetTestDetails); ok {
+		return details.Addresses, true
+	}
+	return nil, false
+}
+
 // announcedAKAs collects the announced address lists across all per-hostname
 // results plus the aggregated one. Aggregation keeps only the first successful
 // protocol result per protocol, so later hostnames' announcements would be
@@ -128,6 +135,7 @@ func announcedAKAs(results ...*models.TestResult) (all, ipv4, ipv6 []string) {
 		}{
 			{r.BinkPResult, binkpAddrs},
 			{r.IfcicoResult, ifcicoAddrs},
+			{r.TelnetResult, telnetAddrs},
 		} {
 			a, v4, v6 := protocolAddressLists(pr.result, pr.extract, pr.extract)
 			for _, addr := range a {
@@ -242,6 +250,8 @@ func (d *Daemon) deriveAKAResults(node *models.Node, aggregated *models.TestResu
 			result.Address = candidate.Address()
 			result.Domain = candDomain
 			result.DerivedFromAddress = node.Address() + "@" + testedDomain
+			result.Transcripts = nil // kept once, with the direct test
+			result.VantageResults = nil
 			result.AddressValidated = anyAKAMatches(all, candidate.Zone, candidate.Net, candidate.Node, candDomain)
 			result.AddressValidatedIPv4 = anyAKAMatches(ipv4, candidate.Zone, candidate.Net, candidate.Node, candDomain)
 			result.AddressValidatedIPv6 = anyAKAMatches(ipv6, candidate.Zone, candidate.Net, candidate.Node, candDomain)
@@ -241,6 +241,32 @@ func TestDeriveAKAResultsUsesPartials(t *testing.T) {
 	}
 }
 
+func TestDeriveAKAResultsFromTelnetMailer(t *testing.T) {
+	// A node reachable only on ITN announces its AKAs through the EMSI mailer
+	// behind the telnet port; those count like a BinkP or IFCICO handshake's.
+	tested := &models.Node{Zone: 2, Net: 5001, Node: 100, Domain: "fidonet", InternetHostnames: []string{"bbs.example.com"}}
+	fsxTwin := &models.Node{Zone: 21, Net: 1, Node: 100, Domain: "fsxnet", InternetHostnames: []string{"bbs.example.com"}}
+
+	d := newTestDaemon(t, []*models.Node{tested, fsxTwin})
+
+	result := models.NewTestResult(tested)
+	result.IsOperational = true
+	result.TelnetResult = &models.ProtocolTestResult{
+		Tested: true, Success: true, IPv4Tested: true, IPv4Success: true,
+		Details: map[string]interface{}{
+			"ipv4": &models.TelnetTestDetails{ResponseType: "EMSI", Addresses: []string{"2:5001/100", "21:1/100@fsxnet"}},
+		},
+	}
+
+	derived := d.deriveAKAResults(tested, result, nil, nil)
+	if len(derived) != 1 {
+		t.Fatalf("expected 1 result derived from the telnet mailer's AKAs, got %d", len(derived))
+	}
+	if !derived[0].AddressValidated || !derived[0].AddressValidatedIPv4 {
+		t.Errorf("derived result not validated over IPv4: %+v", derived[0])
+	}
+}
+
 func TestDeriveAKAResultsCycleCoverage(t *testing.T) {
 	// Two same-domain siblings of one physical host are both direct-tested in
 	// one cycle and both announce the same fsxnet AKA: only the first may
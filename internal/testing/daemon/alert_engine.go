@@ -0,0 +1,355 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/logging"
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+// Alert kinds.
+const (
+	AlertUnreachable = "unreachable"  // a node that had been reachable failed
+	AlertAKAMismatch = "aka_mismatch" // a node stopped announcing its own address
+)
+
+// Alert is one event raised from a node's test result.
+type Alert struct {
+	Kind     string    `json:"kind"`
+	Address  string    `json:"address"`
+	Domain   string    `json:"domain"`
+	TestTime time.Time `json:"test_time"`
+	Hostname string    `json:"hostname,omitempty"`
+	Summary  string    `json:"summary"`
+
+	// PreviousStreak is how many operational tests in a row preceded the
+	// failure (unreachable only).
+	PreviousStreak int `json:"previous_streak,omitempty"`
+	// Errors is why each tested protocol failed, keyed by protocol, plus
+	// "dns" when the hostname did not resolve (unreachable only).
+	Errors map[string]string `json:"errors,omitempty"`
+	// Announced is what the node's handshake announced instead of its own
+	// address (aka_mismatch only).
+	Announced []string `json:"announced,omitempty"`
+}
+
+// AlertSink delivers alerts somewhere.
+type AlertSink interface {
+	Name() string
+	Send(ctx context.Context, alert Alert) error
+}
+
+// alertHistoryStore is the slice of storage the engine needs to pick up where
+// it left off before a restart.
+type alertHistoryStore interface {
+	GetNodeTestHistory(ctx context.Context, zone, net, node int, domain string, limit int) ([]*models.TestResult, error)
+}
+
+// nodeAlertState is what the engine remembers about one node.
+type nodeAlertState struct {
+	// streak counts the node's operational tests in a row, newest last.
+	streak int
+	// mismatch is whether the node's last handshake left its own address out.
+	mismatch bool
+}
+
+// AlertEngine watches each node's aggregated test result and raises alerts on
+// two transitions: a node that had passed ConsecutiveSuccesses tests in a row
+// failing, and a handshake that stops announcing the node's own address.
+//
+// Both are edges, not levels. A node that stays down raises one alert, not
+// one per cycle, and is not alerted on again until it has been back up for
+// the full streak; an AKA mismatch is alerted on when it first appears and
+// again only after the node has announced itself correctly in between.
+//
+// State lives in memory, seeded per node from node_test_results the first
+// time the node is seen, so a restart neither forgets a long streak nor
+// re-announces every mismatch already known.
+type AlertEngine struct {
+	threshold int
+	sinks     []AlertSink
+	history   alertHistoryStore
+
+	mu    sync.Mutex
+	nodes map[string]*nodeAlertState
+
+	// Alerts are handed to a single sender goroutine so a slow webhook or
+	// mail relay never holds up a test worker. The sender has a context of
+	// its own, so alerts still queued when the daemon shuts down go out
+	// rather than fail on the daemon's cancelled one.
+	queue    chan Alert
+	cancel   context.CancelFunc
+	stopped  atomic.Bool
+	stopOnce sync.Once
+	wg       sync.WaitGroup
+}
+
+// alertDrainTimeout bounds how long Stop waits for queued alerts to be
+// delivered before it abandons the rest.
+const alertDrainTimeout = 30 * time.Second
+
+// NewAlertEngine creates an alert engine. history may be nil, in which case
+// every node starts with a clean slate.
+func NewAlertEngine(threshold int, history alertHistoryStore, sinks ...AlertSink) *AlertEngine {
+	if threshold <= 0 {
+		threshold = 3
+	}
+	return &AlertEngine{
+		threshold: threshold,
+		sinks:     sinks,
+		history:   history,
+		nodes:     make(map[string]*nodeAlertState),
+		queue:     make(chan Alert, 1000),
+	}
+}
+
+// Start begins delivering queued alerts in a background goroutine.
+func (e *AlertEngine) Start() {
+	ctx, cancel := context.WithCancel(context.Background())
+	e.cancel = cancel
+	e.wg.Add(1)
+	go func() {
+		defer e.wg.Done()
+		for alert := range e.queue {
+			e.deliver(ctx, alert)
+		}
+	}()
+}
+
+// Stop stops accepting alerts, delivers what is already queued and waits for
+// the sender to finish. Delivery still going after alertDrainTimeout is
+// cancelled. Safe to call more than once.
+func (e *AlertEngine) Stop() {
+	e.stopOnce.Do(func() {
+		e.stopped.Store(true)
+		close(e.queue)
+	})
+
+	done := make(chan struct{})
+	go func() {
+		e.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(alertDrainTimeout):
+		logging.Warnf("Alerts: gave up delivering queued alerts after %v", alertDrainTimeout)
+		e.cancel()
+		<-done
+	}
+	if e.cancel != nil {
+		e.cancel()
+	}
+}
+
+// Observe feeds one node's aggregated test result to the engine and queues
+// whatever alerts it raises, which it also returns.
+func (e *AlertEngine) Observe(ctx context.Context, node *models.Node, result *models.TestResult) []Alert {
+	if node == nil || result == nil {
+		return nil
+	}
+	key := node.Address() + "@" + node.EffectiveDomain()
+
+	e.mu.Lock()
+	state, known := e.nodes[key]
+	e.mu.Unlock()
+	if !known {
+		// Seeding queries the database; do it outside the lock and let the
+		// first writer win should two workers see the same node at once.
+		seeded := e.seed(ctx, node)
+		e.mu.Lock()
+		if state, known = e.nodes[key]; !known {
+			state = seeded
+			e.nodes[key] = state
+		}
+		e.mu.Unlock()
+	}
+
+	e.mu.Lock()
+	alerts := e.evaluate(state, node, result)
+	e.mu.Unlock()
+
+	for _, alert := range alerts {
+		logging.Warnf("Alert %s for %s: %s", alert.Kind, alert.Address, alert.Summary)
+		e.enqueue(alert)
+	}
+	return alerts
+}
+
+// evaluate applies one result to a node's state. Callers hold e.mu.
+func (e *AlertEngine) evaluate(state *nodeAlertState, node *models.Node, result *models.TestResult) []Alert {
+	var alerts []Alert
+	base := Alert{
+		Address:  node.Address(),
+		Domain:   node.EffectiveDomain(),
+		TestTime: result.TestTime,
+		Hostname: result.TestedHostname,
+	}
+
+	if result.IsOperational {
+		state.streak++
+	} else {
+		if state.streak >= e.threshold {
+			alert := base
+			alert.Kind = AlertUnreachable
+			alert.PreviousStreak = state.streak
+			alert.Errors = failureReasons(result)
+			alert.Summary = fmt.Sprintf("%s became unreachable after %d consecutive successful tests", alert.Address, state.streak)
+			if reasons := joinReasons(alert.Errors); reasons != "" {
+				alert.Summary += ": " + reasons
+			}
+			alerts = append(alerts, alert)
+		}
+		state.streak = 0
+	}
+
+	// Only a completed handshake says anything about AKAs; a failed or
+	// handshake-less test leaves the last verdict standing.
+	if mismatch, announced, ok := akaVerdict(result); ok {
+		if mismatch && !state.mismatch {
+			alert := base
+			alert.Kind = AlertAKAMismatch
+			alert.Announced = announced
+			alert.Summary = fmt.Sprintf("%s does not announce its own address; it announced %s",
+				alert.Address, strings.Join(announced, " "))
+			alerts = append(alerts, alert)
+		}
+		state.mismatch = mismatch
+	}
+	return alerts
+}
+
+// seed rebuilds a node's state from its stored test history.
+//
+// History holds a multi-hostname node's per-hostname rows next to its
+// aggregated ones. The aggregated rows are the ones the engine sees live, so
+// when there are any, only those count.
+func (e *AlertEngine) seed(ctx context.Context, node *models.Node) *nodeAlertState {
+	state := &nodeAlertState{}
+	if e.history == nil {
+		return state
+	}
+	history, err := e.history.GetNodeTestHistory(ctx, node.Zone, node.Net, node.Node, node.EffectiveDomain(), 50)
+	if err != nil {
+		logging.Debugf("Alerts: no test history for %s: %v", node.Address(), err)
+		return state
+	}
+
+	rows := history
+	var aggregated []*models.TestResult
+	for _, r := range history {
+		if r.IsAggregated {
+			aggregated = append(aggregated, r)
+		}
+	}
+	if len(aggregated) > 0 {
+		rows = aggregated
+	}
+
+	// Newest first: count the leading operational run, and take the AKA
+	// verdict from the newest handshake.
+	counting, verdictFound := true, false
+	for _, r := range rows {
+		if counting {
+			if r.IsOperational {
+				state.streak++
+			} else {
+				counting = false
+			}
+		}
+		if !verdictFound {
+			if mismatch, _, ok := akaVerdict(r); ok {
+				state.mismatch = mismatch
+				verdictFound = true
+			}
+		}
+		if !counting && verdictFound {
+			break
+		}
+	}
+	return state
+}
+
+// akaVerdict reports whether a result's BinkP or EMSI handshake left the
+// node's own address out of what it announced, and what it announced. ok is
+// false when no handshake completed or it announced nothing, since neither
+// says anything either way. This is the condition the AKA mismatch report
+// uses. announcedAKAs reads both the live details and the flat form rows read
+// back from storage carry.
+func akaVerdict(r *models.TestResult) (mismatch bool, announced []string, ok bool) {
+	announced, _, _ = announcedAKAs(r)
+	if len(announced) == 0 {
+		return false, nil, false
+	}
+	return !r.AddressValidated, announced, true
+}
+
+// failureReasons collects why each tested protocol failed.
+func failureReasons(r *models.TestResult) map[string]string {
+	reasons := make(map[string]string)
+	if r.DNSError != "" {
+		reasons["dns"] = r.DNSError
+	}
+	for name, pr := range map[string]*models.ProtocolTestResult{
+		"binkp":  r.BinkPResult,
+		"binkps": r.BinkPSResult,
+		"ifcico": r.IfcicoResult,
+		"telnet": r.TelnetResult,
+		"ftp":    r.FTPResult,
+		"vmodem": r.VModemResult,
+	} {
+		if pr != nil && pr.Tested && !pr.Success && pr.Error != "" {
+			reasons[name] = pr.Error
+		}
+	}
+	if len(reasons) == 0 {
+		return nil
+	}
+	return reasons
+}
+
+// joinReasons renders failureReasons in a stable order for a summary line.
+func joinReasons(reasons map[string]string) string {
+	keys := make([]string, 0, len(reasons))
+	for k := range reasons {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		parts = append(parts, k+": "+reasons[k])
+	}
+	return strings.Join(parts, "; ")
+}
+
+// enqueue hands an alert to the sender. It never blocks a test worker: when
+// the queue is full or the engine is stopping, the alert is logged (above)
+// and dropped. Uses recover for the send-on-closed-channel race with Stop,
+// as the WHOIS worker does.
+func (e *AlertEngine) enqueue(alert Alert) {
+	if e.stopped.Load() {
+		return
+	}
+	defer func() { _ = recover() }()
+	select {
+	case e.queue <- alert:
+	default:
+		logging.Warnf("Alert queue full, dropping %s alert for %s", alert.Kind, alert.Address)
+	}
+}
+
+// deliver sends one alert to every sink. A failing sink is logged and does
+// not keep the alert from the others.
+func (e *AlertEngine) deliver(ctx context.Context, alert Alert) {
+	for _, sink := range e.sinks {
+		if err := sink.Send(ctx, alert); err != nil {
+			logging.Errorf("Alert %s for %s not delivered via %s: %v", alert.Kind, alert.Address, sink.Name(), err)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package daemon
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/smtp"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/notify"
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+// recordingSink collects what the engine sends it.
+type recordingSink struct {
+	mu     sync.Mutex
+	alerts []Alert
+	ctxErr error // the first send's context error, if any
+}
+
+func (s *recordingSink) Name() string { return "recording" }
+
+func (s *recordingSink) Send(ctx context.Context, alert Alert) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.ctxErr == nil {
+		s.ctxErr = ctx.Err()
+	}
+	s.alerts = append(s.alerts, alert)
+	return nil
+}
+
+// fakeHistory serves a fixed test history, newest first.
+type fakeHistory struct {
+	rows []*models.TestResult
+}
+
+func (h *fakeHistory) GetNodeTestHistory(_ context.Context, _, _, _ int, _ string, _ int) ([]*models.TestResult, error) {
+	return h.rows, nil
+}
+
+func alertTestNode() *models.Node {
+	return &models.Node{Zone: 2, Net: 5001, Node: 100, InternetHostnames: []string{"bbs.example.net"}}
+}
+
+func operational() *models.TestResult {
+	return &models.TestResult{
+		IsOperational: true,
+		BinkPResult:   &models.ProtocolTestResult{Tested: true, Success: true},
+	}
+}
+
+func unreachable() *models.TestResult {
+	return &models.TestResult{
+		BinkPResult: &models.ProtocolTestResult{Tested: true, Error: "connection refused"},
+	}
+}
+
+// handshake is an operational result whose BinkP handshake announced addrs.
+func handshake(validated bool, addrs ...string) *models.TestResult {
+	return &models.TestResult{
+		IsOperational:    true,
+		AddressValidated: validated,
+		BinkPResult: &models.ProtocolTestResult{
+			Tested:  true,
+			Success: true,
+			Details: map[string]interface{}{"ipv4": &models.BinkPTestDetails{Addresses: addrs}},
+		},
+	}
+}
+
+func kinds(alerts []Alert) []string {
+	var out []string
+	for _, a := range alerts {
+		out = append(out, a.Kind)
+	}
+	return out
+}
+
+func TestAlertEngineUnreachableAfterStreak(t *testing.T) {
+	tests := []struct {
+		name    string
+		results []*models.TestResult
+		want    []string // alert kinds raised by the last result
+		streak  int      // PreviousStreak of the alert, when one is wanted
+	}{
+		{
+			name:    "drop after the threshold",
+			results: []*models.TestResult{operational(), operational(), operational(), unreachable()},
+			want:    []string{AlertUnreachable},
+			streak:  3,
+		},
+		{
+			name:    "drop before the threshold",
+			results: []*models.TestResult{operational(), operational(), unreachable()},
+		},
+		{
+			name:    "staying down alerts once",
+			results: []*models.TestResult{operational(), operational(), operational(), unreachable(), unreachable()},
+		},
+		{
+			name: "a recovery must rebuild the whole streak",
+			results: []*models.TestResult{
+				operational(), operational(), operational(), unreachable(),
+				operational(), operational(), unreachable(),
+			},
+		},
+		{
+			name:    "a longer streak is reported as such",
+			results: []*models.TestResult{operational(), operational(), operational(), operational(), operational(), unreachable()},
+			want:    []string{AlertUnreachable},
+			streak:  5,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := NewAlertEngine(3, nil)
+			var last []Alert
+			for _, r := range tt.results {
+				last = e.Observe(context.Background(), alertTestNode(), r)
+			}
+			if got := kinds(last); strings.Join(got, ",") != strings.Join(tt.want, ",") {
+				t.Fatalf("last result raised %v, want %v", got, tt.want)
+			}
+			if len(last) == 1 {
+				if last[0].PreviousStreak != tt.streak {
+					t.Errorf("PreviousStreak = %d, want %d", last[0].PreviousStreak, tt.streak)
+				}
+				if last[0].Errors["binkp"] != "connection refused" {
+					t.Errorf("Errors = %v, want the binkp failure", last[0].Errors)
+				}
+				if last[0].Address != "2:5001/100" || last[0].Domain != "fidonet" {
+					t.Errorf("alert names %s@%s", last[0].Address, last[0].Domain)
+				}
+			}
+		})
+	}
+}
+
+func TestAlertEngineAKAMismatch(t *testing.T) {
+	own := "2:5001/100@fidonet"
+	other := "2:5001/999@fidonet"
+
+	e := NewAlertEngine(3, nil)
+	ctx := context.Background()
+	steps := []struct {
+		result *models.TestResult
+		want   int
+	}{
+		{handshake(true, own), 0},
+		{handshake(false, other), 1}, // first appearance
+		{handshake(false, other), 0}, // still there: no repeat
+		{unreachable(), 0},           // no handshake: verdict stands
+		{handshake(false, other), 0},
+		{handshake(true, own, other), 0}, // fixed
+		{handshake(false, other), 1},     // back again
+	}
+	for i, step := range steps {
+		alerts := e.Observe(ctx, alertTestNode(), step.result)
+		var mismatches []Alert
+		for _, a := range alerts {
+			if a.Kind == AlertAKAMismatch {
+				mismatches = append(mismatches, a)
+			}
+		}
+		if len(mismatches) != step.want {
+			t.Fatalf("step %d raised %d AKA alerts, want %d", i, len(mismatches), step.want)
+		}
+		if len(mismatches) == 1 && strings.Join(mismatches[0].Announced, " ") != other {
+			t.Errorf("step %d: Announced = %v", i, mismatches[0].Announced)
+		}
+	}
+}
+
+func TestAlertEngineSeedsFromHistory(t *testing.T) {
+	flatHandshake := func(validated bool, addrs ...string) *models.TestResult {
+		return &models.TestResult{
+			IsOperational:    true,
+			IsAggregated:     true,
+			AddressValidated: validated,
+			BinkPResult: &models.ProtocolTestResult{
+				Tested:  true,
+				Success: true,
+				Details: map[string]interface{}{"addresses": addrs},
+			},
+		}
+	}
+	aggregatedDown := &models.TestResult{IsAggregated: true}
+	partialDown := &models.TestResult{HostnameIndex: 1}
+
+	history := &fakeHistory{rows: []*models.TestResult{
+		// Newest first. The partial failure must not break the streak:
+		// only aggregated rows count when there are any.
+		flatHandshake(false, "2:5001/999"),
+		partialDown,
+		flatHandshake(true, "2:5001/100"),
+		flatHandshake(true, "2:5001/100"),
+		aggregatedDown,
+		flatHandshake(true, "2:5001/100"),
+	}}
+
+	e := NewAlertEngine(3, history)
+	ctx := context.Background()
+
+	// The stored mismatch is already known, so seeing it again is no news.
+	alerts := e.Observe(ctx, alertTestNode(), handshake(false, "2:5001/999"))
+	if len(alerts) != 0 {
+		t.Fatalf("known mismatch re-announced after seeding: %v", kinds(alerts))
+	}
+	// Three stored successes plus the one just observed.
+	alerts = e.Observe(ctx, alertTestNode(), unreachable())
+	if len(alerts) != 1 || alerts[0].Kind != AlertUnreachable || alerts[0].PreviousStreak != 4 {
+		t.Fatalf("got %+v, want one unreachable alert after a streak of 4", alerts)
+	}
+}
+
+func TestAlertEngineDeliversToEverySink(t *testing.T) {
+	a, b := &recordingSink{}, &recordingSink{}
+	e := NewAlertEngine(1, nil, a, b)
+	e.Start()
+
+	// The daemon's context is gone by the time it stops the engine; what is
+	// still queued goes out all the same.
+	ctx, cancel := context.WithCancel(context.Background())
+	e.Observe(ctx, alertTestNode(), operational())
+	e.Observe(ctx, alertTestNode(), unreachable())
+	cancel()
+	e.Stop()
+	e.Stop() // idempotent
+
+	for _, sink := range []*recordingSink{a, b} {
+		if len(sink.alerts) != 1 || sink.alerts[0].Kind != AlertUnreachable {
+			t.Errorf("sink got %v, want one unreachable alert", kinds(sink.alerts))
+		}
+		if sink.ctxErr != nil {
+			t.Errorf("alert delivered on a dead context: %v", sink.ctxErr)
+		}
+	}
+	// Observing after Stop must not panic.
+	e.Observe(context.Background(), alertTestNode(), operational())
+}
+
+func testAlert() Alert {
+	return Alert{
+		Kind:           AlertUnreachable,
+		Address:        "2:5001/100",
+		Domain:         "fidonet",
+		TestTime:       time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC),
+		Summary:        "2:5001/100 became unreachable after 3 consecutive successful tests",
+		PreviousStreak: 3,
+		Errors:         map[string]string{"binkp": "connection refused"},
+	}
+}
+
+func TestWebhookAlertSink(t *testing.T) {
+	var got Alert
+	var sigOK bool
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		ts, _ := strconv.ParseInt(r.Header.Get(notify.HeaderTimestamp), 10, 64)
+		sigOK = r.Header.Get(notify.HeaderSignature) == notify.Sign("s3cret", ts, body)
+		_ = json.Unmarshal(body, &got)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	sink := NewWebhookAlertSink(AlertWebhookConfig{URL: srv.URL, Secret: "s3cret", Timeout: 5 * time.Second})
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	if !sigOK {
+		t.Error("signature does not verify")
+	}
+	if got.Kind != AlertUnreachable || got.Address != "2:5001/100" || got.PreviousStreak != 3 {
+		t.Errorf("webhook received %+v", got)
+	}
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadGateway)
+	}))
+	defer failing.Close()
+	sink = NewWebhookAlertSink(AlertWebhookConfig{URL: failing.URL, Timeout: 5 * time.Second})
+	if err := sink.Send(context.Background(), testAlert()); err == nil {
+		t.Error("a 502 was taken for delivery")
+	}
+}
+
+func TestEmailAlertSink(t *testing.T) {
+	sink := NewEmailAlertSink(AlertEmailConfig{Host: "localhost", Port: 2525, From: "testdaemon@example.net", To: []string{"ops@example.net"}})
+	var addr string
+	var msg []byte
+	sink.sendMail = func(a string, _ smtp.Auth, _ string, _ []string, m []byte) error {
+		addr, msg = a, m
+		return nil
+	}
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+	if addr != "localhost:2525" {
+		t.Errorf("relay = %s", addr)
+	}
+	for _, want := range []string{"Subject: Node 2:5001/100 is unreachable\r\n", "binkp   connection refused\r\n"} {
+		if !strings.Contains(string(msg), want) {
+			t.Errorf("message lacks %q:\n%s", want, msg)
+		}
+	}
+}
+
+func TestNetmailAlertSink(t *testing.T) {
+	dir := t.TempDir()
+	sink, err := NewNetmailAlertSink(AlertNetmailConfig{
+		Outbound:    dir,
+		FromAddress: "2:5001/100",
+		ToAddress:   "2:5001/1",
+		FromName:    "NodelistDB Test Daemon",
+		ToName:      "Sysop",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := sink.Send(context.Background(), testAlert()); err != nil {
+		t.Fatal(err)
+	}
+
+	pkts, _ := filepath.Glob(filepath.Join(dir, "*.pkt"))
+	if len(pkts) != 1 {
+		t.Fatalf("outbound holds %v, want one packet", pkts)
+	}
+	data, err := os.ReadFile(pkts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"Node 2:5001/100 is unreachable", "\x01INTL 2:5001/1 2:5001/100\r", "binkp   connection refused\r"} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("packet lacks %q", want)
+		}
+	}
+}
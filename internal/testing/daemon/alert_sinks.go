@@ -0,0 +1,224 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/smtp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/ftnpkt"
+	"github.com/nodelistdb/internal/notify"
+	"github.com/nodelistdb/internal/testing/logging"
+)
+
+// alertSinks builds the sinks an alerts section configures.
+func alertSinks(cfg AlertsConfig) ([]AlertSink, error) {
+	var sinks []AlertSink
+	if cfg.Webhook.URL != "" {
+		sinks = append(sinks, NewWebhookAlertSink(cfg.Webhook))
+	}
+	if cfg.Email.Host != "" {
+		sinks = append(sinks, NewEmailAlertSink(cfg.Email))
+	}
+	if cfg.Netmail.Outbound != "" {
+		sink, err := NewNetmailAlertSink(cfg.Netmail)
+		if err != nil {
+			return nil, err
+		}
+		sinks = append(sinks, sink)
+	}
+	return sinks, nil
+}
+
+// WebhookAlertSink posts each alert as a JSON object.
+type WebhookAlertSink struct {
+	url    string
+	secret string
+	client *http.Client
+}
+
+// NewWebhookAlertSink creates a webhook sink.
+func NewWebhookAlertSink(cfg AlertWebhookConfig) *WebhookAlertSink {
+	return &WebhookAlertSink{
+		url:    cfg.URL,
+		secret: cfg.Secret,
+		client: &http.Client{Timeout: cfg.Timeout},
+	}
+}
+
+// Name implements AlertSink.
+func (s *WebhookAlertSink) Name() string { return "webhook" }
+
+// Send implements AlertSink. Signed requests carry the same headers as
+// change notifications, so one receiver can verify both.
+func (s *WebhookAlertSink) Send(ctx context.Context, alert Alert) error {
+	body, err := json.Marshal(alert)
+	if err != nil {
+		return fmt.Errorf("failed to encode alert: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "nodelistdb-testdaemon")
+	if s.secret != "" {
+		ts := time.Now().Unix()
+		req.Header.Set(notify.HeaderTimestamp, strconv.FormatInt(ts, 10))
+		req.Header.Set(notify.HeaderSignature, notify.Sign(s.secret, ts, body))
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook answered %s", resp.Status)
+	}
+	return nil
+}
+
+// EmailAlertSink mails each alert through an SMTP relay.
+type EmailAlertSink struct {
+	addr string
+	from string
+	to   []string
+
+	// Swapped by tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+}
+
+// NewEmailAlertSink creates a mail sink.
+func NewEmailAlertSink(cfg AlertEmailConfig) *EmailAlertSink {
+	return &EmailAlertSink{
+		addr:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		from:     cfg.From,
+		to:       cfg.To,
+		sendMail: smtp.SendMail,
+	}
+}
+
+// Name implements AlertSink.
+func (s *EmailAlertSink) Name() string { return "email" }
+
+// Send implements AlertSink.
+func (s *EmailAlertSink) Send(_ context.Context, alert Alert) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", s.from)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(s.to, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", alertSubject(alert))
+	fmt.Fprintf(&b, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	b.WriteString(strings.ReplaceAll(alertText(alert), "\n", "\r\n"))
+	return s.sendMail(s.addr, nil, s.from, s.to, []byte(b.String()))
+}
+
+// NetmailAlertSink writes each alert as a netmail packet into a mailer's
+// outbound directory, addressed to one configured system.
+type NetmailAlertSink struct {
+	outbound string
+	from     ftnpkt.Address
+	to       ftnpkt.Address
+	fromName string
+	toName   string
+	password string
+}
+
+// NewNetmailAlertSink creates a netmail sink.
+func NewNetmailAlertSink(cfg AlertNetmailConfig) (*NetmailAlertSink, error) {
+	from, err := ftnpkt.ParseAddress(cfg.FromAddress)
+	if err != nil {
+		return nil, fmt.Errorf("alerts.netmail.from_address: %w", err)
+	}
+	to, err := ftnpkt.ParseAddress(cfg.ToAddress)
+	if err != nil {
+		return nil, fmt.Errorf("alerts.netmail.to_address: %w", err)
+	}
+	return &NetmailAlertSink{
+		outbound: cfg.Outbound,
+		from:     from,
+		to:       to,
+		fromName: cfg.FromName,
+		toName:   cfg.ToName,
+		password: cfg.Password,
+	}, nil
+}
+
+// Name implements AlertSink.
+func (s *NetmailAlertSink) Name() string { return "netmail" }
+
+// Send implements AlertSink.
+func (s *NetmailAlertSink) Send(_ context.Context, alert Alert) error {
+	now := time.Now()
+	msg := ftnpkt.Message{
+		Orig:    s.from,
+		Dest:    s.to,
+		From:    s.fromName,
+		To:      s.toName,
+		Subject: alertSubject(alert),
+		Date:    now,
+		Attr:    ftnpkt.AttrPrivate | ftnpkt.AttrLocal | ftnpkt.AttrKillSent,
+		PID:     "NodelistDB testdaemon",
+		Body:    alertText(alert),
+	}
+	path, err := ftnpkt.WriteFile(s.outbound, ftnpkt.Header{
+		Orig:     s.from,
+		Dest:     s.to,
+		Password: s.password,
+		Created:  now,
+	}, []ftnpkt.Message{msg})
+	if err != nil {
+		return err
+	}
+	logging.Debugf("Alert %s for %s written to %s", alert.Kind, alert.Address, path)
+	return nil
+}
+
+// alertSubject is the one-line form of an alert for mail and netmail.
+func alertSubject(alert Alert) string {
+	switch alert.Kind {
+	case AlertUnreachable:
+		return fmt.Sprintf("Node %s is unreachable", alert.Address)
+	case AlertAKAMismatch:
+		return fmt.Sprintf("Node %s AKA mismatch", alert.Address)
+	default:
+		return fmt.Sprintf("Node %s: %s", alert.Address, alert.Kind)
+	}
+}
+
+// alertText is the body of an alert for mail and netmail.
+func alertText(alert Alert) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", alert.Summary)
+	fmt.Fprintf(&b, "Node:      %s@%s\n", alert.Address, alert.Domain)
+	fmt.Fprintf(&b, "Tested:    %s\n", alert.TestTime.UTC().Format("2006-01-02 15:04:05 UTC"))
+	if alert.Hostname != "" {
+		fmt.Fprintf(&b, "Hostname:  %s\n", alert.Hostname)
+	}
+	if len(alert.Errors) > 0 {
+		b.WriteString("\nFailures:\n")
+		keys := make([]string, 0, len(alert.Errors))
+		for k := range alert.Errors {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			fmt.Fprintf(&b, "  %-7s %s\n", k, alert.Errors[k])
+		}
+	}
+	if len(alert.Announced) > 0 {
+		fmt.Fprintf(&b, "\nAnnounced: %s\n", strings.Join(alert.Announced, " "))
+	}
+	return b.String()
+}
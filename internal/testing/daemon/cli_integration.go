@@ -55,6 +55,30 @@ func (d *Daemon) StartCLIServer(ctx context.Context) error {
 	return nil
 }
 
+// StartControlAPI starts the HTTP control API if enabled in config. It
+// serves the same adapter as the telnet CLI, and skips a run-once cycle for
+// the same reason.
+func (d *Daemon) StartControlAPI(ctx context.Context) error {
+	if !d.config.ControlAPI.Enabled {
+		return nil
+	}
+	if d.config.Daemon.RunOnce && !d.config.Daemon.CLIOnly {
+		logging.Debugf("Run-once mode: not starting the control API on %s", d.config.ControlAPI.Listen)
+		return nil
+	}
+
+	adapter := &CLIAdapter{
+		daemon:     d,
+		configPath: d.config.ConfigPath,
+	}
+	server := cli.NewHTTPServer(adapter, cli.HTTPConfig{
+		Listen:      d.config.ControlAPI.Listen,
+		Tokens:      d.config.ControlAPI.Tokens,
+		TestTimeout: d.config.ControlAPI.TestTimeout,
+	})
+	return server.Start(ctx)
+}
+
 // CLIAdapter adapts the daemon to the CLI interface
 type CLIAdapter struct {
 	daemon     *Daemon
@@ -148,6 +172,48 @@ func (a *CLIAdapter) GetNodeInfo(ctx context.Context, zone, net, node uint16) (*
 	return a.daemon.GetNodeInfo(ctx, zone, net, node)
 }
 
+func (a *CLIAdapter) GetQueue(limit int) []cli.QueueEntry {
+	if a.daemon.scheduler == nil {
+		return []cli.QueueEntry{}
+	}
+	schedules := a.daemon.scheduler.Queue(limit)
+	queue := make([]cli.QueueEntry, 0, len(schedules))
+	for i := range schedules {
+		queue = append(queue, queueEntry(&schedules[i]))
+	}
+	return queue
+}
+
+func (a *CLIAdapter) ScheduleNode(zone, net, node uint16, domain string, at time.Time) (*cli.QueueEntry, error) {
+	if a.daemon.scheduler == nil {
+		return nil, cli.ErrNotScheduled
+	}
+	schedule, ok := a.daemon.scheduler.OverrideNextTest(zone, net, node, domain, at)
+	if !ok {
+		return nil, cli.ErrNotScheduled
+	}
+	logging.Infof("Next test of %s moved to %s by the control API", schedule.Node.Address(), at.Format(time.RFC3339))
+	entry := queueEntry(&schedule)
+	return &entry, nil
+}
+
+func (a *CLIAdapter) SubscribeProgress() (<-chan cli.ProgressEvent, func()) {
+	return a.daemon.progress.Subscribe()
+}
+
+func queueEntry(s *NodeSchedule) cli.QueueEntry {
+	return cli.QueueEntry{
+		Address:          s.Node.Address(),
+		Domain:           s.Node.Domain,
+		NextTestTime:     s.NextTestTime,
+		LastTestTime:     s.LastTestTime,
+		LastTestSuccess:  s.LastTestSuccess,
+		ConsecutiveFails: s.ConsecutiveFails,
+		Priority:         s.Priority,
+		Reason:           s.TestReason,
+	}
+}
+
 // SetConfigPath sets the config path for reload
 func (a *CLIAdapter) SetConfigPath(path string) {
 	a.configPath = path
@@ -2,8 +2,13 @@ package daemon
 
 import (
 	"context"
+	"errors"
 	"net"
 	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/cli"
+	"github.com/nodelistdb/internal/testing/models"
 )
 
 // A run-once cycle must not bind the CLI port. On the host where such a run is
@@ -53,3 +58,34 @@ func TestStartCLIServer_Disabled(t *testing.T) {
 		t.Errorf("disabled CLI should be a no-op, got: %v", err)
 	}
 }
+
+func TestCLIAdapterQueueAndScheduleNode(t *testing.T) {
+	now := time.Now()
+	s := NewScheduler(SchedulerConfig{}, nil)
+	for i, n := range []*models.Node{
+		{Zone: 2, Net: 5020, Node: 1},
+		{Zone: 2, Net: 5020, Node: 2},
+		{Zone: 2, Net: 5020, Node: 3, Domain: "fsxnet"},
+	} {
+		s.schedules[n.Key()] = &NodeSchedule{Node: n, NextTestTime: now.Add(time.Duration(i+1) * time.Hour)}
+	}
+	a := &CLIAdapter{daemon: &Daemon{scheduler: s}}
+
+	queue := a.GetQueue(2)
+	if len(queue) != 2 || queue[0].Address != "2:5020/1" || queue[1].Address != "2:5020/2" {
+		t.Fatalf("GetQueue(2) = %+v, want 2:5020/1 then 2:5020/2", queue)
+	}
+
+	entry, err := a.ScheduleNode(2, 5020, 3, "fsxnet", now)
+	if err != nil || !entry.NextTestTime.Equal(now) || entry.Reason != "override" {
+		t.Fatalf("ScheduleNode = %+v, %v; want due now as an override", entry, err)
+	}
+	if queue := a.GetQueue(1); queue[0].Address != "2:5020/3" || queue[0].Domain != "fsxnet" {
+		t.Errorf("overridden node not first in the queue: %+v", queue)
+	}
+
+	// The same address in fidonet is not scheduled
+	if _, err := a.ScheduleNode(2, 5020, 3, "", now); !errors.Is(err, cli.ErrNotScheduled) {
+		t.Errorf("ScheduleNode for an unscheduled node: %v, want ErrNotScheduled", err)
+	}
+}
@@ -2,9 +2,12 @@ package daemon
 
 import (
 	"fmt"
+	"net"
+	"net/url"
 	"os"
 	"time"
 
+	"github.com/nodelistdb/internal/ftnpkt"
 	"github.com/xx25/fidomail/pkg/emsi"
 	"gopkg.in/yaml.v3"
 )
@@ -20,6 +23,9 @@ type Config struct {
 	TestdaemonCache CacheConfig       `yaml:"testdaemon_cache"`   // Required cache config for testdaemon
 	Logging         LoggingConfig     `yaml:"testdaemon_logging"` // Testdaemon-specific logging config
 	CLI             CLIConfig         `yaml:"cli"`
+	ControlAPI      ControlAPIConfig  `yaml:"control_api"`
+	Alerts          AlertsConfig      `yaml:"alerts"`
+	Vantage         VantageConfig     `yaml:"vantage"`
 	ConfigPath      string            `yaml:"-"` // Path to config file, set when loading
 	Version         string            `yaml:"-"` // Version string, set from main
 }
@@ -49,6 +55,8 @@ type DaemonConfig struct {
 	BatchSize           int           `yaml:"batch_size"`
 	StaleTestThreshold  time.Duration `yaml:"stale_test_threshold"`  // Consider test stale after this duration (default: same as test_interval)
 	FailedRetryInterval time.Duration `yaml:"failed_retry_interval"` // Retry failed nodes after this duration (default: 24h)
+	ScheduleStrategy    string        `yaml:"schedule_strategy"`     // regular, adaptive, priority or uptime (default: adaptive)
+	UptimeHistoryDays   int           `yaml:"uptime_history_days"`   // Days of test history the uptime windows are learned from (default: 30)
 	RunOnce             bool          `yaml:"-"`                     // Set from command line
 	DryRun              bool          `yaml:"-"`                     // Set from command line
 	CLIOnly             bool          `yaml:"-"`                     // Set from command line - disable automatic testing
@@ -92,11 +100,37 @@ type ProtocolConfig struct {
 	Sysop      string        `yaml:"sysop,omitempty"`       // ZYZ field
 	Location   string        `yaml:"location,omitempty"`    // LOC field
 
+	// Passwords are the BinkP session passwords agreed with individual
+	// nodes, keyed by address. Only meaningful for the binkp protocol.
+	Passwords map[string]string `yaml:"passwords,omitempty"`
+
+	// RequireCRAM refuses to send those passwords in plain text to a node
+	// that offers no CRAM-MD5 challenge, except over binkps (nil = true).
+	RequireCRAM *bool `yaml:"require_cram,omitempty"`
+
+	// Transcripts says which sessions keep a wire transcript: "off" (the
+	// default), "failed" or "all". Only meaningful for binkp (which covers
+	// binkps too) and ifcico.
+	Transcripts string `yaml:"transcripts,omitempty"`
+
 	// DataChannel configures the reverse connection a VMP call needs. Only
 	// meaningful for the vmodem protocol.
 	DataChannel VMPDataChannelConfig `yaml:"data_channel,omitempty"`
 }
 
+// CRAMRequired reports whether session passwords may only go out as CRAM-MD5
+// responses on plain BinkP.
+func (c ProtocolConfig) CRAMRequired() bool {
+	return c.RequireCRAM == nil || *c.RequireCRAM
+}
+
+// Values of ProtocolConfig.Transcripts.
+const (
+	TranscriptsOff    = "off"
+	TranscriptsFailed = "failed" // sessions that did not succeed
+	TranscriptsAll    = "all"
+)
+
 // VMPDataChannelConfig configures outgoing Virtual Modem Protocol calls.
 //
 // VMP is a two-connection protocol: after the caller asks to connect, the
@@ -124,6 +158,8 @@ type ServicesConfig struct {
 	Geolocation GeolocationConfig `yaml:"geolocation"`
 	DNS         DNSConfig         `yaml:"dns"`
 	EmailVerify EmailVerifyConfig `yaml:"email_verify"`
+	// PathDiagnostics probes nodes that failed every protocol
+	PathDiagnostics PathDiagnosticsConfig `yaml:"path_diagnostics"`
 }
 
 // EmailVerifyConfig controls DNS verification of the mail domains published in
@@ -151,6 +187,25 @@ type EmailVerifyConfig struct {
 	Concurrency int `yaml:"concurrency"`
 }
 
+// PathDiagnosticsConfig controls the path probe run on a node that failed
+// every protocol test: a TCP connect to its port and, when that goes
+// unanswered, a tracepath-style walk towards it. The last router that
+// answers, and its ASN, say whether the host is down or the path to it is
+// broken. Both probes run unprivileged.
+type PathDiagnosticsConfig struct {
+	// Enabled turns the probe on. Default false.
+	Enabled bool `yaml:"enabled"`
+	// TCPTimeout bounds the TCP connect. Default 5s.
+	TCPTimeout time.Duration `yaml:"tcp_timeout"`
+	// HopTimeout is how long one hop gets to answer. Default 2s. All hops
+	// are probed at once, so this and Attempts bound the walk.
+	HopTimeout time.Duration `yaml:"hop_timeout"`
+	// MaxHops is the highest TTL tried. Default 30.
+	MaxHops int `yaml:"max_hops"`
+	// Attempts is how many probes a silent hop gets. Default 2.
+	Attempts int `yaml:"attempts"`
+}
+
 // GeolocationConfig for IP geolocation service
 type GeolocationConfig struct {
 	Provider  string        `yaml:"provider"`
@@ -199,6 +254,112 @@ type CLIConfig struct {
 	WelcomeMessage string        `yaml:"welcome_message"`
 }
 
+// ControlAPIConfig contains settings for the HTTP control API: the CLI's
+// commands as JSON, plus the schedule and a live stream of test progress.
+type ControlAPIConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"` // host:port, default 127.0.0.1:2380
+	// Tokens are the bearer tokens clients authenticate with; one per
+	// client, so one can be revoked without the others.
+	Tokens []string `yaml:"tokens"`
+	// TestTimeout bounds an ad-hoc test run through the API. Default 2m.
+	TestTimeout time.Duration `yaml:"test_timeout"`
+}
+
+// AlertsConfig controls the alerts the daemon raises from its own test
+// results: a node that had been reachable for ConsecutiveSuccesses tests in a
+// row failing, and a node whose handshake first stops announcing its own
+// address. Every configured sink receives every alert.
+type AlertsConfig struct {
+	// Enabled turns alerting on. Default false.
+	Enabled bool `yaml:"enabled"`
+	// ConsecutiveSuccesses is how many operational tests in a row a node
+	// needs before a failure is worth an alert. Default 3; one flap of a node
+	// that is down more often than not is not news.
+	ConsecutiveSuccesses int `yaml:"consecutive_successes"`
+
+	Webhook AlertWebhookConfig `yaml:"webhook"`
+	Email   AlertEmailConfig   `yaml:"email"`
+	Netmail AlertNetmailConfig `yaml:"netmail"`
+}
+
+// AlertWebhookConfig posts each alert as JSON. With a secret, requests are
+// signed the way change notifications are (X-Nodelist-Signature).
+type AlertWebhookConfig struct {
+	URL     string        `yaml:"url"`
+	Secret  string        `yaml:"secret"`
+	Timeout time.Duration `yaml:"timeout"`
+}
+
+// AlertEmailConfig mails each alert through an SMTP relay. It is meant for a
+// relay on the local host (the system MTA, or a mail catcher during testing):
+// there is no authentication or TLS.
+type AlertEmailConfig struct {
+	Host string   `yaml:"host"`
+	Port int      `yaml:"port"`
+	From string   `yaml:"from"`
+	To   []string `yaml:"to"`
+}
+
+// AlertNetmailConfig drops each alert as a netmail packet into a mailer's
+// outbound directory.
+type AlertNetmailConfig struct {
+	// Outbound is the directory packets are written to.
+	Outbound string `yaml:"outbound"`
+	// FromAddress defaults to protocols.binkp.our_address, then
+	// protocols.ifcico.our_address.
+	FromAddress string `yaml:"from_address"`
+	FromName    string `yaml:"from_name"`
+	ToAddress   string `yaml:"to_address"`
+	ToName      string `yaml:"to_name"`
+	// Password is the packet password agreed with the receiving system.
+	Password string `yaml:"password"`
+}
+
+// VantageConfig spreads testing over several locations. One testdaemon is
+// the coordinator: it keeps the schedule and the database, and hands every
+// node it tests to the agents as well. An agent runs the same testers from
+// another network, with no database of its own, and reports back. A node one
+// location cannot reach while another can has a routing problem, not a dead
+// mailer.
+type VantageConfig struct {
+	// Name is this testdaemon's own vantage point ("de-fra", "us-east").
+	// Required for a coordinator; an agent is named by the coordinator's
+	// agents map.
+	Name        string                   `yaml:"name"`
+	Coordinator VantageCoordinatorConfig `yaml:"coordinator"`
+	Agent       VantageAgentConfig       `yaml:"agent"`
+}
+
+// VantageCoordinatorConfig serves work to the agents.
+type VantageCoordinatorConfig struct {
+	Enabled bool   `yaml:"enabled"`
+	Listen  string `yaml:"listen"` // host:port of the agent API
+	// Agents maps each agent's vantage point name to the bearer token it
+	// authenticates with.
+	Agents map[string]string `yaml:"agents"`
+	// ResultTimeout is how long a test waits for the agents' reports before
+	// it is stored with those that arrived. Default 3m.
+	ResultTimeout time.Duration `yaml:"result_timeout"`
+	// TLSCert and TLSKey are the PEM certificate and key the agent API is
+	// served with. Agents carry bearer tokens and take orders from it, so
+	// it runs without TLS only when something in front of it terminates TLS.
+	TLSCert string `yaml:"tls_cert"`
+	TLSKey  string `yaml:"tls_key"`
+}
+
+// VantageAgentConfig makes this testdaemon an agent of a coordinator. An
+// agent needs no clickhouse section.
+type VantageAgentConfig struct {
+	Enabled bool `yaml:"enabled"`
+	// CoordinatorURL must be https, except for a coordinator on loopback.
+	CoordinatorURL string `yaml:"coordinator_url"`
+	Token          string `yaml:"token"`
+	// RetryInterval is the wait after the coordinator could not be reached.
+	// Default 30s.
+	RetryInterval time.Duration `yaml:"retry_interval"`
+}
+
 // LoadConfig loads configuration from YAML file
 func LoadConfig(path string) (*Config, error) {
 	data, err := os.ReadFile(path)
@@ -232,6 +393,9 @@ func LoadConfig(path string) (*Config, error) {
 	if cfg.Daemon.FailedRetryInterval == 0 {
 		cfg.Daemon.FailedRetryInterval = 24 * 3600 // 24 hours, will be converted to Duration later
 	}
+	if cfg.Daemon.UptimeHistoryDays == 0 {
+		cfg.Daemon.UptimeHistoryDays = 30
+	}
 
 	// ClickHouse-specific defaults
 	if cfg.ClickHouse != nil {
@@ -376,6 +540,50 @@ func LoadConfig(path string) (*Config, error) {
 		cfg.CLI.WelcomeMessage = "NodelistDB Test Daemon CLI v1.0.0\nType 'help' for available commands.\n"
 	}
 
+	// Control API defaults
+	if cfg.ControlAPI.Listen == "" {
+		cfg.ControlAPI.Listen = "127.0.0.1:2380"
+	}
+	if cfg.ControlAPI.TestTimeout == 0 {
+		cfg.ControlAPI.TestTimeout = 2 * time.Minute
+	} else if cfg.ControlAPI.TestTimeout < time.Duration(oneSecondInNanos) {
+		cfg.ControlAPI.TestTimeout *= time.Second
+	}
+
+	// Alert defaults
+	if cfg.Alerts.ConsecutiveSuccesses == 0 {
+		cfg.Alerts.ConsecutiveSuccesses = 3
+	}
+	if cfg.Alerts.Webhook.Timeout == 0 {
+		cfg.Alerts.Webhook.Timeout = 10 * time.Second
+	} else if cfg.Alerts.Webhook.Timeout < time.Duration(oneSecondInNanos) {
+		cfg.Alerts.Webhook.Timeout *= time.Second
+	}
+	if cfg.Alerts.Email.Port == 0 {
+		cfg.Alerts.Email.Port = 25
+	}
+	if cfg.Alerts.Netmail.FromAddress == "" {
+		cfg.Alerts.Netmail.FromAddress = firstNonEmpty(cfg.Protocols.BinkP.OurAddress, cfg.Protocols.Ifcico.OurAddress)
+	}
+	if cfg.Alerts.Netmail.FromName == "" {
+		cfg.Alerts.Netmail.FromName = "NodelistDB Test Daemon"
+	}
+	if cfg.Alerts.Netmail.ToName == "" {
+		cfg.Alerts.Netmail.ToName = "Sysop"
+	}
+
+	// Vantage defaults
+	if cfg.Vantage.Coordinator.ResultTimeout == 0 {
+		cfg.Vantage.Coordinator.ResultTimeout = 3 * time.Minute
+	} else if cfg.Vantage.Coordinator.ResultTimeout < time.Duration(oneSecondInNanos) {
+		cfg.Vantage.Coordinator.ResultTimeout *= time.Second
+	}
+	if cfg.Vantage.Agent.RetryInterval == 0 {
+		cfg.Vantage.Agent.RetryInterval = 30 * time.Second
+	} else if cfg.Vantage.Agent.RetryInterval < time.Duration(oneSecondInNanos) {
+		cfg.Vantage.Agent.RetryInterval *= time.Second
+	}
+
 	// Normalize EMSI duration fields (convert numeric seconds to time.Duration)
 	// EMSI config uses time.Duration which YAML may unmarshal as plain nanoseconds
 	normalizeEMSIConfig(&cfg)
@@ -437,15 +645,29 @@ func normalizeEMSIConfig(cfg *Config) {
 
 // Validate checks if configuration is valid
 func (c *Config) Validate() error {
-	// Check ClickHouse database configuration
-	if c.ClickHouse == nil {
-		return fmt.Errorf("clickhouse configuration is required")
+	if err := c.Vantage.validate(); err != nil {
+		return err
 	}
-	if c.ClickHouse.Host == "" {
-		return fmt.Errorf("clickhouse.host is required")
+	if c.Vantage.Agent.Enabled && c.Alerts.Enabled {
+		return fmt.Errorf("alerts are raised by the coordinator; disable them on a vantage agent")
 	}
-	if c.ClickHouse.Database == "" {
-		return fmt.Errorf("clickhouse.database is required")
+
+	// Check ClickHouse database configuration. An agent reports to its
+	// coordinator and stores nothing itself.
+	if !c.Vantage.Agent.Enabled {
+		if c.ClickHouse == nil {
+			return fmt.Errorf("clickhouse configuration is required")
+		}
+		if c.ClickHouse.Host == "" {
+			return fmt.Errorf("clickhouse.host is required")
+		}
+		if c.ClickHouse.Database == "" {
+			return fmt.Errorf("clickhouse.database is required")
+		}
+	}
+
+	if _, err := ParseScheduleStrategy(c.Daemon.ScheduleStrategy); err != nil {
+		return fmt.Errorf("daemon.schedule_strategy: %w", err)
 	}
 
 	// Check if at least one protocol is enabled
@@ -462,6 +684,24 @@ func (c *Config) Validate() error {
 	if c.Protocols.BinkP.Enabled && c.Protocols.BinkP.OurAddress == "" {
 		return fmt.Errorf("protocols.binkp.our_address is required when binkp is enabled")
 	}
+	for addr, password := range c.Protocols.BinkP.Passwords {
+		if _, err := ftnpkt.ParseAddress(addr); err != nil {
+			return fmt.Errorf("protocols.binkp.passwords: %w", err)
+		}
+		if password == "" || password == "-" {
+			return fmt.Errorf("protocols.binkp.passwords: empty password for %s", addr)
+		}
+	}
+	for name, mode := range map[string]string{
+		"binkp":  c.Protocols.BinkP.Transcripts,
+		"ifcico": c.Protocols.Ifcico.Transcripts,
+	} {
+		switch mode {
+		case "", TranscriptsOff, TranscriptsFailed, TranscriptsAll:
+		default:
+			return fmt.Errorf("protocols.%s.transcripts must be off, failed or all, not %q", name, mode)
+		}
+	}
 	if c.Protocols.Ifcico.Enabled && c.Protocols.Ifcico.OurAddress == "" {
 		return fmt.Errorf("protocols.ifcico.our_address is required when ifcico is enabled")
 	}
@@ -472,5 +712,106 @@ func (c *Config) Validate() error {
 		return fmt.Errorf("protocols.vmodem.our_address is required when vmodem is enabled (or set protocols.ifcico.our_address, which vmodem falls back to)")
 	}
 
+	if c.ControlAPI.Enabled {
+		if len(c.ControlAPI.Tokens) == 0 {
+			return fmt.Errorf("control_api.tokens is required when the control API is enabled")
+		}
+		for _, token := range c.ControlAPI.Tokens {
+			if token == "" {
+				return fmt.Errorf("control_api.tokens: empty token")
+			}
+		}
+	}
+
+	if c.Alerts.Enabled {
+		if err := c.Alerts.validate(); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// validate checks the vantage section: a testdaemon is a coordinator, an
+// agent or neither, and whichever it is must be complete.
+func (v *VantageConfig) validate() error {
+	if v.Coordinator.Enabled && v.Agent.Enabled {
+		return fmt.Errorf("vantage.coordinator and vantage.agent cannot both be enabled")
+	}
+	if v.Coordinator.Enabled {
+		if v.Name == "" {
+			return fmt.Errorf("vantage.name is required for a coordinator")
+		}
+		if v.Coordinator.Listen == "" {
+			return fmt.Errorf("vantage.coordinator.listen is required")
+		}
+		if len(v.Coordinator.Agents) == 0 {
+			return fmt.Errorf("vantage.coordinator.agents is empty")
+		}
+		tokens := make(map[string]bool, len(v.Coordinator.Agents))
+		for name, token := range v.Coordinator.Agents {
+			switch {
+			case name == v.Name:
+				return fmt.Errorf("vantage.coordinator.agents: %q is the coordinator's own name", name)
+			case token == "":
+				return fmt.Errorf("vantage.coordinator.agents: no token for %q", name)
+			case tokens[token]:
+				return fmt.Errorf("vantage.coordinator.agents: %q shares its token with another agent", name)
+			}
+			tokens[token] = true
+		}
+		if (v.Coordinator.TLSCert == "") != (v.Coordinator.TLSKey == "") {
+			return fmt.Errorf("vantage.coordinator needs both tls_cert and tls_key, or neither")
+		}
+	}
+	if v.Agent.Enabled {
+		if v.Agent.CoordinatorURL == "" || v.Agent.Token == "" {
+			return fmt.Errorf("vantage.agent needs coordinator_url and token")
+		}
+		if err := checkCoordinatorURL(v.Agent.CoordinatorURL); err != nil {
+			return fmt.Errorf("vantage.agent.coordinator_url: %w", err)
+		}
+	}
+	return nil
+}
+
+// checkCoordinatorURL accepts an https URL, or plain http to a coordinator
+// on this host. Anywhere else the agent's token and the coordinator's jobs
+// would cross the network readable and forgeable.
+func checkCoordinatorURL(raw string) error {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "https":
+		return nil
+	case "http":
+		host := u.Hostname()
+		if ip := net.ParseIP(host); host == "localhost" || (ip != nil && ip.IsLoopback()) {
+			return nil
+		}
+		return fmt.Errorf("%s is plain http; use https unless the coordinator is on loopback", raw)
+	}
+	return fmt.Errorf("%s is not an http(s) URL", raw)
+}
+
+// validate checks an enabled alerts section: at least one sink, and each
+// configured sink complete.
+func (a *AlertsConfig) validate() error {
+	if a.Webhook.URL == "" && a.Email.Host == "" && a.Netmail.Outbound == "" {
+		return fmt.Errorf("alerts.enabled needs at least one of alerts.webhook.url, alerts.email.host or alerts.netmail.outbound")
+	}
+	if a.Email.Host != "" && (a.Email.From == "" || len(a.Email.To) == 0) {
+		return fmt.Errorf("alerts.email needs from and at least one to address")
+	}
+	if a.Netmail.Outbound != "" {
+		if _, err := ftnpkt.ParseAddress(a.Netmail.FromAddress); err != nil {
+			return fmt.Errorf("alerts.netmail.from_address: %w", err)
+		}
+		if _, err := ftnpkt.ParseAddress(a.Netmail.ToAddress); err != nil {
+			return fmt.Errorf("alerts.netmail.to_address: %w", err)
+		}
+	}
 	return nil
 }
@@ -217,6 +217,9 @@ protocols:
 	if cfg.Protocols.BinkP.Location == "" {
 		t.Error("Expected BinkP Location to have default value")
 	}
+	if !cfg.Protocols.BinkP.CRAMRequired() {
+		t.Error("Expected BinkP to require CRAM-MD5 by default")
+	}
 
 	// Verify CLI defaults
 	if cfg.CLI.Host != "127.0.0.1" {
@@ -262,6 +265,40 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "binkp session password for a malformed address",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{
+					Host:     "localhost",
+					Database: "testdb",
+				},
+				Protocols: ProtocolsConfig{
+					BinkP: ProtocolConfig{
+						Enabled:    true,
+						OurAddress: "2:5001/100",
+						Passwords:  map[string]string{"5001/100": "secret"},
+					},
+				},
+			},
+			wantError: true,
+		},
+		{
+			name: "empty binkp session password",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{
+					Host:     "localhost",
+					Database: "testdb",
+				},
+				Protocols: ProtocolsConfig{
+					BinkP: ProtocolConfig{
+						Enabled:    true,
+						OurAddress: "2:5001/100",
+						Passwords:  map[string]string{"2:5001/200": ""},
+					},
+				},
+			},
+			wantError: true,
+		},
 		{
 			name: "missing clickhouse config",
 			config: &Config{
@@ -405,6 +442,144 @@ func TestConfigValidate(t *testing.T) {
 			},
 			wantError: false,
 		},
+		{
+			name: "alerts enabled without a sink",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Alerts:     AlertsConfig{Enabled: true},
+			},
+			wantError: true,
+		},
+		{
+			name: "alerts netmail with a bad destination",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Alerts: AlertsConfig{Enabled: true, Netmail: AlertNetmailConfig{
+					Outbound: "/tmp", FromAddress: "2:5001/100", ToAddress: "5001/1",
+				}},
+			},
+			wantError: true,
+		},
+		{
+			name: "alerts by webhook",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Alerts:     AlertsConfig{Enabled: true, Webhook: AlertWebhookConfig{URL: "http://localhost/hook"}},
+			},
+			wantError: false,
+		},
+		{
+			name: "vantage agent needs no clickhouse",
+			config: &Config{
+				Protocols: ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Vantage: VantageConfig{Agent: VantageAgentConfig{
+					Enabled: true, CoordinatorURL: "https://coordinator:8089", Token: "t1",
+				}},
+			},
+			wantError: false,
+		},
+		{
+			name: "vantage agent over plain http",
+			config: &Config{
+				Protocols: ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Vantage: VantageConfig{Agent: VantageAgentConfig{
+					Enabled: true, CoordinatorURL: "http://coordinator:8089", Token: "t1",
+				}},
+			},
+			wantError: true,
+		},
+		{
+			name: "vantage agent over plain http to loopback",
+			config: &Config{
+				Protocols: ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Vantage: VantageConfig{Agent: VantageAgentConfig{
+					Enabled: true, CoordinatorURL: "http://127.0.0.1:8089", Token: "t1",
+				}},
+			},
+			wantError: false,
+		},
+		{
+			name: "vantage agent without a token",
+			config: &Config{
+				Protocols: ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Vantage:   VantageConfig{Agent: VantageAgentConfig{Enabled: true, CoordinatorURL: "https://coordinator:8089"}},
+			},
+			wantError: true,
+		},
+		{
+			name: "vantage coordinator",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Vantage: VantageConfig{Name: "de-fra", Coordinator: VantageCoordinatorConfig{
+					Enabled: true, Listen: ":8089", Agents: map[string]string{"us-east": "t1", "au-syd": "t2"},
+				}},
+			},
+			wantError: false,
+		},
+		{
+			name: "vantage coordinator with a certificate but no key",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Vantage: VantageConfig{Name: "de-fra", Coordinator: VantageCoordinatorConfig{
+					Enabled: true, Listen: ":8089", Agents: map[string]string{"us-east": "t1"}, TLSCert: "coordinator.pem",
+				}},
+			},
+			wantError: true,
+		},
+		{
+			name: "vantage coordinator without a name",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Vantage: VantageConfig{Coordinator: VantageCoordinatorConfig{
+					Enabled: true, Listen: ":8089", Agents: map[string]string{"us-east": "t1"},
+				}},
+			},
+			wantError: true,
+		},
+		{
+			name: "vantage agents sharing a token",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				Vantage: VantageConfig{Name: "de-fra", Coordinator: VantageCoordinatorConfig{
+					Enabled: true, Listen: ":8089", Agents: map[string]string{"us-east": "t1", "au-syd": "t1"},
+				}},
+			},
+			wantError: true,
+		},
+		{
+			name: "control API with a token",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				ControlAPI: ControlAPIConfig{Enabled: true, Tokens: []string{"dashboard"}},
+			},
+			wantError: false,
+		},
+		{
+			name: "control API without tokens",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				ControlAPI: ControlAPIConfig{Enabled: true},
+			},
+			wantError: true,
+		},
+		{
+			name: "control API with an empty token",
+			config: &Config{
+				ClickHouse: &ClickHouseConfig{Host: "localhost", Database: "testdb"},
+				Protocols:  ProtocolsConfig{Telnet: ProtocolConfig{Enabled: true}},
+				ControlAPI: ControlAPIConfig{Enabled: true, Tokens: []string{"dashboard", ""}},
+			},
+			wantError: true,
+		},
 	}
 
 	for _, tt := range tests {
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/nodelistdb/internal/cache"
+	"github.com/nodelistdb/internal/testing/cli"
 	"github.com/nodelistdb/internal/testing/logging"
 	"github.com/nodelistdb/internal/testing/protocols"
 	"github.com/nodelistdb/internal/testing/services"
@@ -27,6 +28,9 @@ type Daemon struct {
 	rdapResolver  *services.RDAPResolver
 	whoisWorker   *WhoisWorker
 	emailSweeper  *EmailDomainSweeper
+	alertEngine   *AlertEngine
+	pathDiagnoser *services.PathDiagnoser // nil unless configured
+	vantage       *vantageCoordinator     // nil unless this is a coordinator
 
 	// Persistent cache (optional) - now uses unified cache interface
 	persistentCache cache.Cache
@@ -59,6 +63,9 @@ type Daemon struct {
 	statisticsCollector *StatisticsCollector
 	nodeFilter          *NodeFilter
 
+	// Test progress, streamed by the control API
+	progress *cli.ProgressHub
+
 	// Control state
 	pauseMu sync.RWMutex
 	paused  bool
@@ -108,38 +115,21 @@ func New(cfg *Config) (*Daemon, error) {
 	logging.Infof("Logging initialized with level: %s", cfg.Logging.Level)
 	logging.Debugf("Debug logging test - if you see this, debug mode is working!")
 
-	// Initialize ClickHouse storage (only supported database type)
-	if cfg.ClickHouse == nil {
-		return nil, fmt.Errorf("ClickHouse configuration is required")
-	}
-
-	chConfig := &storage.ClickHouseConfig{
-		MaxOpenConns:  cfg.ClickHouse.MaxOpenConns,
-		MaxIdleConns:  cfg.ClickHouse.MaxIdleConns,
-		DialTimeout:   cfg.ClickHouse.DialTimeout,
-		ReadTimeout:   cfg.ClickHouse.ReadTimeout,
-		WriteTimeout:  cfg.ClickHouse.WriteTimeout,
-		Compression:   cfg.ClickHouse.Compression,
-		BatchSize:     cfg.ClickHouse.BatchSize,
-		FlushInterval: cfg.ClickHouse.FlushInterval,
-	}
-	store, err := storage.NewClickHouseStorageWithConfig(
-		cfg.ClickHouse.Host,
-		cfg.ClickHouse.Port,
-		cfg.ClickHouse.Database,
-		cfg.ClickHouse.Username,
-		cfg.ClickHouse.Password,
-		chConfig,
-	)
-
-	if err != nil {
-		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	// Initialize ClickHouse storage (only supported database type). A
+	// vantage agent has none: it reports to its coordinator, which stores.
+	var store storage.Storage
+	if !cfg.Vantage.Agent.Enabled {
+		var err error
+		if store, err = openStorage(cfg); err != nil {
+			return nil, err
+		}
 	}
 
 	d := &Daemon{
 		config:   cfg,
 		storage:  store,
 		akaEquiv: NewAkaEquivalence(),
+		progress: cli.NewProgressHub(),
 	}
 
 	// Initialize the cache from testdaemon_cache.
@@ -193,6 +183,8 @@ func New(cfg *Config) (*Daemon, error) {
 		cfg.Services.Geolocation.RateLimit,
 	)
 
+	d.pathDiagnoser = newPathDiagnoser(cfg.Services.PathDiagnostics, d.geolocator)
+
 	// Initialize WHOIS resolver (10s timeout for WHOIS queries)
 	d.whoisResolver = services.NewWhoisResolver(10 * time.Second)
 
@@ -210,19 +202,33 @@ func New(cfg *Config) (*Daemon, error) {
 		d.geolocator.SetPersistentCache(geoCache)
 	}
 
-	// Wire WHOIS persistent cache via ClickHouse (read-only, for cache hits)
-	d.whoisResolver.SetPersistentCache(&whoisReadCache{storage: store})
+	// WHOIS results, the email domain sweep and alerts all live in
+	// ClickHouse; an agent leaves them to its coordinator.
+	if store != nil {
+		// Wire WHOIS persistent cache via ClickHouse (read-only, for cache hits)
+		d.whoisResolver.SetPersistentCache(&whoisReadCache{storage: store})
 
-	// Initialize WHOIS background worker
-	d.whoisWorker = NewWhoisWorker(d.whoisResolver, store, 1000)
+		// Initialize WHOIS background worker
+		d.whoisWorker = NewWhoisWorker(d.whoisResolver, store, 1000)
+	}
 
 	// Periodic DNS verification of the mail domains published in nodelist
 	// email flags. Off unless configured: it is a reporting aid, not part of
 	// connectivity testing.
-	if cfg.Services.EmailVerify.Enabled {
+	if cfg.Services.EmailVerify.Enabled && store != nil {
 		d.emailSweeper = NewEmailDomainSweeper(cfg.Services.EmailVerify, store)
 	}
 
+	// Alerts on reachability drops and newly appearing AKA mismatches. Off
+	// unless configured.
+	if cfg.Alerts.Enabled && store != nil {
+		sinks, err := alertSinks(cfg.Alerts)
+		if err != nil {
+			return nil, fmt.Errorf("failed to configure alerts: %w", err)
+		}
+		d.alertEngine = NewAlertEngine(cfg.Alerts.ConsecutiveSuccesses, store, sinks...)
+	}
+
 	// Initialize EMSI configuration manager only if EMSI config is provided
 	// This preserves backward compatibility: when no testing.emsi section exists,
 	// the legacy protocols.ifcico.timeout continues to control handshake timing
@@ -247,6 +253,11 @@ func New(cfg *Config) (*Daemon, error) {
 			cfg.Protocols.BinkP.Sysop,
 			cfg.Protocols.BinkP.Location,
 		)
+		if setter, ok := d.binkpTester.(protocols.SessionPasswordSetter); ok {
+			setter.SetSessionPasswords(cfg.Protocols.BinkP.Passwords)
+			setter.SetRequireCRAM(cfg.Protocols.BinkP.CRAMRequired())
+		}
+		setTranscriptCapture(d.binkpTester, cfg.Protocols.BinkP.Transcripts)
 		// Set debug mode if BinkP tester supports it
 		if setter, ok := d.binkpTester.(protocols.DebugSetter); ok {
 			setter.SetDebug(debugMode)
@@ -267,6 +278,7 @@ func New(cfg *Config) (*Daemon, error) {
 				setter.SetEMSIConfigManager(d.emsiConfigManager)
 			}
 		}
+		setTranscriptCapture(d.ifcicoTester, cfg.Protocols.Ifcico.Transcripts)
 		// Set debug mode for ifcico tester
 		if setter, ok := d.ifcicoTester.(protocols.DebugSetter); ok {
 			setter.SetDebug(debugMode)
@@ -274,9 +286,24 @@ func New(cfg *Config) (*Daemon, error) {
 	}
 
 	if cfg.Protocols.Telnet.Enabled {
-		d.telnetTester = protocols.NewTelnetTester(
+		// An EMSI mailer behind an ITN port is called with the IFCICO
+		// identity unless telnet has its own; with neither, only the banner
+		// is read.
+		d.telnetTester = protocols.NewTelnetTesterWithInfo(
 			cfg.Protocols.Telnet.Timeout,
+			firstNonEmpty(cfg.Protocols.Telnet.OurAddress, cfg.Protocols.Ifcico.OurAddress),
+			firstNonEmpty(cfg.Protocols.Telnet.SystemName, cfg.Protocols.Ifcico.SystemName),
+			firstNonEmpty(cfg.Protocols.Telnet.Sysop, cfg.Protocols.Ifcico.Sysop),
+			firstNonEmpty(cfg.Protocols.Telnet.Location, cfg.Protocols.Ifcico.Location),
 		)
+		if d.emsiConfigManager != nil {
+			if setter, ok := d.telnetTester.(protocols.EMSIConfigSetter); ok {
+				setter.SetEMSIConfigManager(d.emsiConfigManager)
+			}
+		}
+		if setter, ok := d.telnetTester.(protocols.DebugSetter); ok {
+			setter.SetDebug(debugMode)
+		}
 	}
 
 	if cfg.Protocols.FTP.Enabled {
@@ -309,9 +336,11 @@ func New(cfg *Config) (*Daemon, error) {
 	// Initialize worker pool
 	d.workerPool = NewWorkerPool(cfg.Daemon.Workers)
 
-	// Initialize scheduler with adaptive strategy by default
+	// Initialize scheduler with the configured strategy, adaptive by default
+	// (Validate has already rejected unknown names)
+	strategy, _ := ParseScheduleStrategy(cfg.Daemon.ScheduleStrategy)
 	d.scheduler = NewScheduler(SchedulerConfig{
-		Strategy:            StrategyAdaptive,
+		Strategy:            strategy,
 		BaseInterval:        cfg.Daemon.TestInterval,
 		FailureMultiplier:   2.0,
 		MaxInterval:         7 * 24 * time.Hour, // Allow up to 7 days to accommodate 72h test interval
@@ -319,8 +348,13 @@ func New(cfg *Config) (*Daemon, error) {
 		JitterPercent:       0.1,
 		StaleTestThreshold:  cfg.Daemon.StaleTestThreshold,
 		FailedRetryInterval: cfg.Daemon.FailedRetryInterval,
+		UptimeHistoryDays:   cfg.Daemon.UptimeHistoryDays,
 	}, store)
 
+	if cfg.Vantage.Coordinator.Enabled {
+		d.vantage = newVantageCoordinator(cfg.Vantage)
+	}
+
 	// Initialize modular components
 	d.testExecutor = NewTestExecutor(d)
 	d.testAggregator = NewTestAggregator()
@@ -330,6 +364,37 @@ func New(cfg *Config) (*Daemon, error) {
 	return d, nil
 }
 
+// openStorage connects to ClickHouse, the only supported database.
+func openStorage(cfg *Config) (storage.Storage, error) {
+	if cfg.ClickHouse == nil {
+		return nil, fmt.Errorf("ClickHouse configuration is required")
+	}
+
+	chConfig := &storage.ClickHouseConfig{
+		MaxOpenConns:  cfg.ClickHouse.MaxOpenConns,
+		MaxIdleConns:  cfg.ClickHouse.MaxIdleConns,
+		DialTimeout:   cfg.ClickHouse.DialTimeout,
+		ReadTimeout:   cfg.ClickHouse.ReadTimeout,
+		WriteTimeout:  cfg.ClickHouse.WriteTimeout,
+		Compression:   cfg.ClickHouse.Compression,
+		BatchSize:     cfg.ClickHouse.BatchSize,
+		FlushInterval: cfg.ClickHouse.FlushInterval,
+	}
+	store, err := storage.NewClickHouseStorageWithConfig(
+		cfg.ClickHouse.Host,
+		cfg.ClickHouse.Port,
+		cfg.ClickHouse.Database,
+		cfg.ClickHouse.Username,
+		cfg.ClickHouse.Password,
+		chConfig,
+	)
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize storage: %w", err)
+	}
+	return store, nil
+}
+
 // Run starts the daemon
 func (d *Daemon) Run(ctx context.Context) error {
 	// Log version if available
@@ -344,10 +409,19 @@ func (d *Daemon) Run(ctx context.Context) error {
 	d.stats.startTime = time.Now()
 	d.stats.Unlock()
 
+	// An agent has no schedule of its own: it tests what the coordinator
+	// hands out.
+	if d.config.Vantage.Agent.Enabled {
+		return d.runVantageAgent(ctx)
+	}
+
 	// Start CLI server if enabled
 	if err := d.StartCLIServer(ctx); err != nil {
 		return fmt.Errorf("failed to start CLI server: %w", err)
 	}
+	if err := d.StartControlAPI(ctx); err != nil {
+		return fmt.Errorf("failed to start control API: %w", err)
+	}
 
 	// Start WHOIS background worker (must defer Stop before workerPool
 	// so worker pool stops first, preventing sends on closed queue)
@@ -361,10 +435,25 @@ func (d *Daemon) Run(ctx context.Context) error {
 		logging.Info("Email domain verification enabled")
 	}
 
+	// Start alert delivery if alerts are configured
+	if d.alertEngine != nil {
+		d.alertEngine.Start()
+		defer d.alertEngine.Stop()
+		logging.Infof("Alerting enabled (unreachable after %d consecutive successes, AKA mismatches)",
+			d.config.Alerts.ConsecutiveSuccesses)
+	}
+
 	// Start worker pool (defers are LIFO, so this stops before whoisWorker)
 	d.workerPool.Start()
 	defer d.workerPool.Stop()
 
+	// Serve the agents, if this is a coordinator
+	if d.vantage != nil {
+		if err := d.vantage.Start(ctx); err != nil {
+			return err
+		}
+	}
+
 	// Initialize scheduler with current nodes
 	nodes, err := d.storage.GetNodesWithInternet(ctx, 0)
 	if err != nil {
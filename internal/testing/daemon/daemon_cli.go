@@ -79,7 +79,9 @@ func (d *Daemon) TestNodeDirect(ctx context.Context, zone, net, node uint16, hos
 		}
 	}
 
+	d.progress.Publish(testStartedEvent(testNode, true))
 	result := d.testExecutor.TestNode(ctx, testNode)
+	d.progress.Publish(testFinishedEvent(testNode, result, true))
 	if result == nil {
 		return nil, fmt.Errorf("test returned no result for node %d:%d/%d", zone, net, node)
 	}
@@ -49,6 +49,8 @@ func (d *Daemon) ReloadConfig(configPath string) error {
 		newCfg.Services.Geolocation.RateLimit,
 	)
 
+	d.pathDiagnoser = newPathDiagnoser(newCfg.Services.PathDiagnostics, d.geolocator)
+
 	// Re-wire persistent cache to new service instances if available
 	if d.persistentCache != nil {
 		dnsCache := storage.NewDNSCache(d.persistentCache)
@@ -67,6 +69,11 @@ func (d *Daemon) ReloadConfig(configPath string) error {
 			newCfg.Protocols.BinkP.Sysop,
 			newCfg.Protocols.BinkP.Location,
 		)
+		if setter, ok := d.binkpTester.(protocols.SessionPasswordSetter); ok {
+			setter.SetSessionPasswords(newCfg.Protocols.BinkP.Passwords)
+			setter.SetRequireCRAM(newCfg.Protocols.BinkP.CRAMRequired())
+		}
+		setTranscriptCapture(d.binkpTester, newCfg.Protocols.BinkP.Transcripts)
 	} else {
 		d.binkpTester = nil
 	}
@@ -79,13 +86,18 @@ func (d *Daemon) ReloadConfig(configPath string) error {
 			newCfg.Protocols.Ifcico.Sysop,
 			newCfg.Protocols.Ifcico.Location,
 		)
+		setTranscriptCapture(d.ifcicoTester, newCfg.Protocols.Ifcico.Transcripts)
 	} else {
 		d.ifcicoTester = nil
 	}
 
 	if newCfg.Protocols.Telnet.Enabled {
-		d.telnetTester = protocols.NewTelnetTester(
+		d.telnetTester = protocols.NewTelnetTesterWithInfo(
 			newCfg.Protocols.Telnet.Timeout,
+			firstNonEmpty(newCfg.Protocols.Telnet.OurAddress, newCfg.Protocols.Ifcico.OurAddress),
+			firstNonEmpty(newCfg.Protocols.Telnet.SystemName, newCfg.Protocols.Ifcico.SystemName),
+			firstNonEmpty(newCfg.Protocols.Telnet.Sysop, newCfg.Protocols.Ifcico.Sysop),
+			firstNonEmpty(newCfg.Protocols.Telnet.Location, newCfg.Protocols.Ifcico.Location),
 		)
 	} else {
 		d.telnetTester = nil
@@ -124,6 +136,10 @@ func (d *Daemon) ReloadConfig(configPath string) error {
 		d.scheduler.baseInterval = newCfg.Daemon.TestInterval
 		d.scheduler.failedRetryInterval = newCfg.Daemon.FailedRetryInterval
 		d.scheduler.staleTestThreshold = newCfg.Daemon.StaleTestThreshold
+		if strategy, err := ParseScheduleStrategy(newCfg.Daemon.ScheduleStrategy); err == nil {
+			d.scheduler.strategy = strategy
+		}
+		d.scheduler.uptimeHistoryDays = newCfg.Daemon.UptimeHistoryDays
 		d.scheduler.mu.Unlock()
 	}
 
@@ -136,10 +152,10 @@ func (d *Daemon) ReloadConfig(configPath string) error {
 		}
 		logging.Infof("Reloaded EMSI ConfigManager with %d per-node overrides", len(newCfg.Testing.EMSI.Overrides))
 
-		// Wire ConfigManager to testers that support it (IFCICO and VModem's
-		// EMSI fall-through).
-		if d.ifcicoTester != nil {
-			if setter, ok := d.ifcicoTester.(protocols.EMSIConfigSetter); ok {
+		// Wire ConfigManager to testers that support it (IFCICO, and the
+		// EMSI handshakes VModem and Telnet run behind their own protocol).
+		for _, tester := range []protocols.Tester{d.ifcicoTester, d.telnetTester} {
+			if setter, ok := tester.(protocols.EMSIConfigSetter); ok {
 				setter.SetEMSIConfigManager(d.emsiConfigManager)
 			}
 		}
@@ -149,8 +165,8 @@ func (d *Daemon) ReloadConfig(configPath string) error {
 	} else {
 		// No EMSI config provided - clear ConfigManager to use legacy timeout behavior
 		d.emsiConfigManager = nil
-		if d.ifcicoTester != nil {
-			if setter, ok := d.ifcicoTester.(protocols.EMSIConfigSetter); ok {
+		for _, tester := range []protocols.Tester{d.ifcicoTester, d.telnetTester} {
+			if setter, ok := tester.(protocols.EMSIConfigSetter); ok {
 				setter.SetEMSIConfigManager(nil)
 			}
 		}
@@ -22,6 +22,7 @@ func (d *Daemon) logConnectivitySummary(nodeAddr string, node *models.Node, resu
 		result *models.ProtocolTestResult
 	}{
 		{"BinkP", result.BinkPResult},
+		{"binkps", result.BinkPSResult},
 		{"IFCICO", result.IfcicoResult},
 		{"Telnet", result.TelnetResult},
 		{"FTP", result.FTPResult},
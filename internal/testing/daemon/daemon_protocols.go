@@ -6,6 +6,7 @@ import (
 	"github.com/nodelistdb/internal/testing/logging"
 	"github.com/nodelistdb/internal/testing/models"
 	"github.com/nodelistdb/internal/testing/protocols"
+	"github.com/nodelistdb/internal/testing/transcript"
 )
 
 // testBinkP tests BinkP connectivity on both IPv4 and IPv6
@@ -44,18 +45,12 @@ func (d *Daemon) testBinkP(ctx context.Context, node *models.Node, result *model
 					ipv6,
 					binkpResult.Error,
 				)
+				keepTranscript(result, d.config.Protocols.BinkP.Transcripts, ipv6, binkpResult.Success, binkpResult.Error, binkpResult.Transcript)
 
 				// Store details if successful
 				if binkpResult.Success {
 					logging.Debugf("[%s]     BinkP IPv6 success: %s (%dms)", node.Address(), binkpResult.SystemName, binkpResult.ResponseMs)
-					details := &models.BinkPTestDetails{
-						SystemName:   binkpResult.SystemName,
-						Sysop:        binkpResult.Sysop,
-						Location:     binkpResult.Location,
-						Version:      binkpResult.Version,
-						Addresses:    binkpResult.Addresses,
-						Capabilities: binkpResult.Capabilities,
-					}
+					details := binkpDetails(binkpResult)
 					result.BinkPResult.Details["ipv6"] = details
 
 					if binkpResult.AddressValid {
@@ -85,18 +80,12 @@ func (d *Daemon) testBinkP(ctx context.Context, node *models.Node, result *model
 					ipv4,
 					binkpResult.Error,
 				)
+				keepTranscript(result, d.config.Protocols.BinkP.Transcripts, ipv4, binkpResult.Success, binkpResult.Error, binkpResult.Transcript)
 
 				// Store details if successful
 				if binkpResult.Success {
 					logging.Debugf("[%s]     BinkP IPv4 success: %s (%dms)", node.Address(), binkpResult.SystemName, binkpResult.ResponseMs)
-					details := &models.BinkPTestDetails{
-						SystemName:   binkpResult.SystemName,
-						Sysop:        binkpResult.Sysop,
-						Location:     binkpResult.Location,
-						Version:      binkpResult.Version,
-						Addresses:    binkpResult.Addresses,
-						Capabilities: binkpResult.Capabilities,
-					}
+					details := binkpDetails(binkpResult)
 					result.BinkPResult.Details["ipv4"] = details
 
 					if binkpResult.AddressValid {
@@ -121,6 +110,124 @@ func (d *Daemon) testBinkP(ctx context.Context, node *models.Node, result *model
 	}
 }
 
+// binkpDetails keeps what a BinkP or binkps session learned about the node.
+func binkpDetails(r *protocols.BinkPTestResult) *models.BinkPTestDetails {
+	details := &models.BinkPTestDetails{
+		SystemName:   r.SystemName,
+		Sysop:        r.Sysop,
+		Location:     r.Location,
+		Version:      r.Version,
+		Addresses:    r.Addresses,
+		Capabilities: r.Capabilities,
+		AuthMethod:   r.AuthMethod,
+		MailExchange: r.MailExchange,
+		MailDetail:   r.MailDetail,
+		OptCRAM:      r.OptCRAM,
+		OptCrypt:     r.OptCrypt,
+		OptPLZ:       r.OptPLZ,
+		OptGZ:        r.OptGZ,
+		OptBZ2:       r.OptBZ2,
+	}
+	if c := r.Certificate; c != nil {
+		details.Certificate = &models.TLSCertificate{
+			Subject:    c.Subject,
+			Issuer:     c.Issuer,
+			NotAfter:   c.NotAfter,
+			ChainValid: c.ChainValid,
+			SelfSigned: c.SelfSigned,
+			Error:      c.Error,
+		}
+	}
+	return details
+}
+
+// keepTranscript attaches the transcript of a session with ip to result when
+// the protocol's transcripts setting keeps it.
+func keepTranscript(result *models.TestResult, mode, ip string, success bool, errText string, t *transcript.Transcript) {
+	if t == nil || mode == TranscriptsOff || mode == "" || (mode == TranscriptsFailed && success) {
+		return
+	}
+	data, err := t.Encode()
+	if err != nil {
+		logging.Warnf("[%s] dropping %s transcript of %s: %v", result.Address, t.Protocol, ip, err)
+		return
+	}
+	result.Transcripts = append(result.Transcripts, models.TestTranscript{
+		Protocol:  t.Protocol,
+		RemoteIP:  ip,
+		Success:   success,
+		Error:     errText,
+		Started:   t.Started,
+		RawBytes:  t.Bytes(),
+		Truncated: t.Truncated,
+		Data:      data,
+	})
+}
+
+// setTranscriptCapture has tester record sessions when mode keeps any.
+func setTranscriptCapture(tester protocols.Tester, mode string) {
+	if setter, ok := tester.(protocols.TranscriptSetter); ok {
+		setter.SetTranscriptCapture(mode == TranscriptsFailed || mode == TranscriptsAll)
+	}
+}
+
+// testBinkPS tests BinkP over TLS on the node's IBNS port, IPv6 first. The
+// certificate is the point of the test, so it is kept from a session that
+// failed after the TLS handshake too.
+func (d *Daemon) testBinkPS(ctx context.Context, node *models.Node, result *models.TestResult) {
+	tester, ok := d.binkpTester.(protocols.TLSTester)
+	if !ok || len(node.InternetHostnames) == 0 {
+		return
+	}
+
+	// Port 0 lets the tester use the binkps default.
+	port := node.GetProtocolPort("IBNS")
+	serverName := result.TestedHostname
+	if serverName == "" {
+		serverName = result.Hostname
+	}
+
+	if result.BinkPSResult == nil {
+		result.BinkPSResult = &models.ProtocolTestResult{
+			Details: make(map[string]interface{}),
+		}
+	}
+
+	families := []struct {
+		key string
+		ips []string
+		set func(success bool, responseMs uint32, address string, err string)
+	}{
+		{"ipv6", result.ResolvedIPv6, result.BinkPSResult.SetIPv6Result},
+		{"ipv4", result.ResolvedIPv4, result.BinkPSResult.SetIPv4Result},
+	}
+	for _, family := range families {
+		if family.key == "ipv4" && !node.ShouldTestIPv4() {
+			if len(family.ips) > 0 {
+				logging.Infof("[%s]   Skipping binkps IPv4 test: node has INO4 flag", node.Address())
+			}
+			continue
+		}
+		for _, ip := range family.ips {
+			logging.Debugf("[%s]   Testing binkps %s %s:%d", node.Address(), family.key, ip, port)
+			binkpResult, ok := tester.TestTLS(ctx, ip, port, node.Address(), serverName).(*protocols.BinkPTestResult)
+			if !ok {
+				continue
+			}
+			family.set(binkpResult.Success, binkpResult.ResponseMs, ip, binkpResult.Error)
+			keepTranscript(result, d.config.Protocols.BinkP.Transcripts, ip, binkpResult.Success, binkpResult.Error, binkpResult.Transcript)
+			if binkpResult.Success || binkpResult.Certificate != nil {
+				result.BinkPSResult.Details[family.key] = binkpDetails(binkpResult)
+			}
+			if binkpResult.Success {
+				logging.Debugf("[%s]     binkps %s success: %s (%dms)", node.Address(), family.key, binkpResult.SystemName, binkpResult.ResponseMs)
+				break // First successful address is enough
+			}
+			logging.Debugf("[%s]     binkps %s failed: %s", node.Address(), family.key, binkpResult.Error)
+		}
+	}
+}
+
 // testIfcico tests IFCICO connectivity on both IPv4 and IPv6
 func (d *Daemon) testIfcico(ctx context.Context, node *models.Node, result *models.TestResult) {
 	if d.ifcicoTester == nil {
@@ -157,6 +264,7 @@ func (d *Daemon) testIfcico(ctx context.Context, node *models.Node, result *mode
 					ipv6,
 					ifcicoResult.Error,
 				)
+				keepTranscript(result, d.config.Protocols.Ifcico.Transcripts, ipv6, ifcicoResult.Success, ifcicoResult.Error, ifcicoResult.Transcript)
 
 				// Store details if successful
 				if ifcicoResult.Success {
@@ -196,6 +304,7 @@ func (d *Daemon) testIfcico(ctx context.Context, node *models.Node, result *mode
 					ipv4,
 					ifcicoResult.Error,
 				)
+				keepTranscript(result, d.config.Protocols.Ifcico.Transcripts, ipv4, ifcicoResult.Success, ifcicoResult.Error, ifcicoResult.Transcript)
 
 				// Store details if successful
 				if ifcicoResult.Success {
@@ -266,9 +375,13 @@ func (d *Daemon) testTelnet(ctx context.Context, node *models.Node, result *mode
 					telnetResult.Error,
 				)
 
-				// Store banner if successful
-				if telnetResult.Success && telnetResult.Banner != "" {
-					result.TelnetResult.Details["ipv6_banner"] = telnetResult.Banner
+				// Store banner and any mailer identity if successful
+				if telnetResult.Success && (telnetResult.Banner != "" || telnetResult.MailerInfo != "") {
+					result.TelnetResult.Details["ipv6"] = telnetDetails(telnetResult)
+					if telnetResult.AddressValid {
+						result.AddressValidated = true
+					}
+					result.AddressValidatedIPv6 = result.AddressValidatedIPv6 || telnetResult.AddressValid
 					break // First successful IPv6 is enough
 				}
 			}
@@ -288,9 +401,13 @@ func (d *Daemon) testTelnet(ctx context.Context, node *models.Node, result *mode
 					telnetResult.Error,
 				)
 
-				// Store banner if successful
-				if telnetResult.Success && telnetResult.Banner != "" {
-					result.TelnetResult.Details["ipv4_banner"] = telnetResult.Banner
+				// Store banner and any mailer identity if successful
+				if telnetResult.Success && (telnetResult.Banner != "" || telnetResult.MailerInfo != "") {
+					result.TelnetResult.Details["ipv4"] = telnetDetails(telnetResult)
+					if telnetResult.AddressValid {
+						result.AddressValidated = true
+					}
+					result.AddressValidatedIPv4 = result.AddressValidatedIPv4 || telnetResult.AddressValid
 					break // First successful IPv4 is enough
 				}
 			}
@@ -305,6 +422,18 @@ func (d *Daemon) testTelnet(ctx context.Context, node *models.Node, result *mode
 	}
 }
 
+// telnetDetails keeps the banner and whatever EMSI mailer answered.
+func telnetDetails(r *protocols.TelnetTestResult) *models.TelnetTestDetails {
+	return &models.TelnetTestDetails{
+		Banner:       r.Banner,
+		MailerInfo:   r.MailerInfo,
+		SystemName:   r.SystemName,
+		Sysop:        r.Sysop,
+		Addresses:    r.Addresses,
+		ResponseType: r.ResponseType,
+	}
+}
+
 // testFTP tests FTP connectivity on both IPv4 and IPv6
 func (d *Daemon) testFTP(ctx context.Context, node *models.Node, result *models.TestResult) {
 	if d.ftpTester == nil {
@@ -9,6 +9,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/nodelistdb/internal/testing/cli"
 	"github.com/nodelistdb/internal/testing/logging"
 	"github.com/nodelistdb/internal/testing/models"
 )
@@ -80,8 +81,8 @@ func (d *Daemon) runTestCycle(ctx context.Context) error {
 	if len(nodes) == 0 {
 		// Log scheduler status for debugging
 		schedStatus := d.scheduler.GetScheduleStatus()
-		logging.Infof("Scheduler status: total_nodes=%v, ready=%v, failing=%v, pending_first_test=%v",
-			schedStatus["total_nodes"], schedStatus["ready_for_test"], schedStatus["failing_nodes"], schedStatus["pending_first_test"])
+		logging.Infof("Scheduler status: total_nodes=%v, ready=%v, failing=%v (intermittent by schedule=%v), pending_first_test=%v",
+			schedStatus["total_nodes"], schedStatus["ready_for_test"], schedStatus["failing_nodes"], schedStatus["intermittent_nodes"], schedStatus["pending_first_test"])
 
 		// This is normal after a restart if all nodes were tested recently
 		logging.Infof("No nodes ready for testing at this time. All nodes are within their test intervals.")
@@ -123,6 +124,8 @@ func (d *Daemon) runTestCycle(ctx context.Context) error {
 		logging.Infof("Test reasons: %s", strings.Join(reasons, ", "))
 	}
 
+	d.progress.Publish(cli.ProgressEvent{Type: cli.EventCycleStarted, Time: time.Now(), Nodes: len(nodes)})
+
 	// Process nodes in batches
 	batchSize := d.config.Daemon.BatchSize
 	var allResults []*models.TestResult
@@ -157,7 +160,28 @@ func (d *Daemon) runTestCycle(ctx context.Context) error {
 			d.workerPool.Submit(func() {
 				defer wg.Done()
 
+				d.progress.Publish(testStartedEvent(nodeToTest, false))
+
+				// With agents, they test the node alongside us; the result
+				// waits for theirs (bounded by result_timeout) and stands
+				// for all of them.
+				var dispatch *vantageDispatch
+				if d.vantage != nil {
+					dispatch = d.vantage.Dispatch(nodeToTest)
+				}
+
 				result, partials := d.testExecutor.TestNodeWithPartials(ctx, nodeToTest)
+				if d.vantage != nil {
+					var remote []*models.TestResult
+					if dispatch != nil {
+						remote = d.vantage.Wait(ctx, dispatch)
+					}
+					if result != nil {
+						result.VantagePoint = d.config.Vantage.Name
+						result = d.testAggregator.CombineVantageResults(result, remote)
+					}
+				}
+				d.progress.Publish(testFinishedEvent(nodeToTest, result, false))
 				if result == nil {
 					return
 				}
@@ -167,6 +191,13 @@ func (d *Daemon) runTestCycle(ctx context.Context) error {
 					d.scheduler.UpdateTestResult(ctx, nodeToTest, result)
 				}
 
+				// Alerts see the node's own (aggregated) result only; the
+				// derived results below are someone else's test. A dry run
+				// stores nothing, so it alerts on nothing either.
+				if d.alertEngine != nil && !d.config.Daemon.DryRun {
+					d.alertEngine.Observe(ctx, nodeToTest, result)
+				}
+
 				// Cover the same host's entries in other networks with
 				// derived results (stored via the same batch path)
 				derived := d.deriveAKAResults(nodeToTest, result, partials, cycle)
@@ -211,6 +242,8 @@ func (d *Daemon) runTestCycle(ctx context.Context) error {
 	d.stats.lastCycleTime = startTime
 	d.stats.Unlock()
 
+	d.progress.Publish(cli.ProgressEvent{Type: cli.EventCycleFinished, Time: time.Now(), Nodes: len(allResults)})
+
 	duration := time.Since(startTime)
 	logging.Infof("Test cycle completed in %v: %d nodes tested, %d operational, %d with issues",
 		duration, len(allResults), stats.NodesOperational, stats.NodesWithIssues)
@@ -218,6 +251,31 @@ func (d *Daemon) runTestCycle(ctx context.Context) error {
 	return nil
 }
 
+// testStartedEvent and testFinishedEvent are the progress events around one
+// node's test. A nil result is a test cut short by shutdown.
+func testStartedEvent(node *models.Node, adHoc bool) cli.ProgressEvent {
+	ev := cli.ProgressEvent{Type: cli.EventTestStarted, Time: time.Now(), Address: node.Address(), AdHoc: adHoc}
+	if len(node.InternetHostnames) > 0 {
+		ev.Hostname = node.InternetHostnames[0]
+	}
+	return ev
+}
+
+func testFinishedEvent(node *models.Node, result *models.TestResult, adHoc bool) cli.ProgressEvent {
+	ev := cli.ProgressEvent{Type: cli.EventTestFinished, Time: time.Now(), Address: node.Address(), AdHoc: adHoc}
+	if result == nil {
+		ev.Error = "test cancelled"
+		return ev
+	}
+	summary := vantageSummary(result)
+	ev.Hostname = result.Hostname
+	ev.Operational = result.IsOperational
+	ev.ProtocolsOK = summary.ProtocolsOK
+	ev.ProtocolsFailed = summary.ProtocolsFailed
+	ev.Error = summary.Error
+	return ev
+}
+
 // TestSingleNode tests a single node and returns immediately
 // nodeSpec can be in format "zone:net/node" or "host:port" or "host"
 func (d *Daemon) TestSingleNode(ctx context.Context, nodeSpec, protocol string) error {
@@ -235,6 +293,9 @@ func (d *Daemon) TestSingleNode(ctx context.Context, nodeSpec, protocol string)
 	// Try to parse as FTN address first (e.g., "2:5053/56" or "21:1/100@fsxnet")
 	if aka, ok := parseAKA(nodeSpec); ok {
 		zone, net, node = uint16(aka.Zone), uint16(aka.Net), uint16(aka.Node)
+		if d.storage == nil {
+			return fmt.Errorf("a vantage agent has no nodelist to find %s in; test it by host:port", nodeSpec)
+		}
 		// It's an FTN address - look up node in database
 		nodes, err := d.storage.GetNodesByZone(ctx, int(zone))
 		if err != nil {
@@ -501,7 +562,7 @@ func (d *Daemon) TestSingleNode(ctx context.Context, nodeSpec, protocol string)
 	}
 
 	// Store result if not in dry-run mode
-	if !d.config.Daemon.DryRun {
+	if !d.config.Daemon.DryRun && d.storage != nil {
 		if err := d.storage.StoreTestResult(ctx, result); err != nil {
 			logging.Warnf("Failed to store test result: %v", err)
 		}
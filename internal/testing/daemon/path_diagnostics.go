@@ -0,0 +1,74 @@
+package daemon
+
+import (
+	"context"
+
+	"github.com/nodelistdb/internal/testing/logging"
+	"github.com/nodelistdb/internal/testing/models"
+	"github.com/nodelistdb/internal/testing/services"
+)
+
+// newPathDiagnoser builds the path prober, or returns nil when it is off.
+func newPathDiagnoser(cfg PathDiagnosticsConfig, geo *services.Geolocation) *services.PathDiagnoser {
+	if !cfg.Enabled {
+		return nil
+	}
+	return services.NewPathDiagnoser(services.PathDiagnoserConfig{
+		TCPTimeout: cfg.TCPTimeout,
+		HopTimeout: cfg.HopTimeout,
+		MaxHops:    cfg.MaxHops,
+		Attempts:   cfg.Attempts,
+	}, geo)
+}
+
+// diagnosePath runs the path probe for a result in which every protocol
+// that was tested failed. A node with nothing tested (no IP, no announced
+// protocol) is not probed: there is no port to aim at.
+func (d *Daemon) diagnosePath(ctx context.Context, node *models.Node, result *models.TestResult) {
+	if d.pathDiagnoser == nil || result.IsOperational {
+		return
+	}
+	ip, port := d.pathProbeTarget(node, result)
+	if ip == "" {
+		return
+	}
+	result.PathDiagnostic = d.pathDiagnoser.Diagnose(ctx, ip, port, result.ASN)
+	if pd := result.PathDiagnostic; pd != nil {
+		logging.Infof("[%s] Path probe %s:%d: tcp %s, last hop %q at ttl %d (AS%d), verdict %s",
+			node.Address(), ip, port, pd.TCP, pd.LastHop, pd.LastHopTTL, pd.LastHopASN, pd.Verdict)
+	}
+}
+
+// pathProbeTarget picks the address and port to probe: the first protocol
+// tested, in the order they are tested, on the address its test used, IPv4
+// first since that is where most nodes live and where ICMP is least
+// filtered.
+func (d *Daemon) pathProbeTarget(node *models.Node, result *models.TestResult) (string, int) {
+	candidates := []struct {
+		flag   string
+		result *models.ProtocolTestResult
+		port   int
+	}{
+		{"IBN", result.BinkPResult, d.config.Protocols.BinkP.Port},
+		{"IFC", result.IfcicoResult, d.config.Protocols.Ifcico.Port},
+		{"ITN", result.TelnetResult, d.config.Protocols.Telnet.Port},
+		{"IFT", result.FTPResult, d.config.Protocols.FTP.Port},
+		{"IVM", result.VModemResult, d.config.Protocols.VModem.Port},
+	}
+	for _, c := range candidates {
+		if c.result == nil || !c.result.Tested {
+			continue
+		}
+		port := node.GetProtocolPort(c.flag)
+		if port == 0 {
+			port = c.port
+		}
+		switch {
+		case c.result.IPv4Tested && c.result.IPv4Address != "":
+			return c.result.IPv4Address, port
+		case c.result.IPv6Tested && c.result.IPv6Address != "":
+			return c.result.IPv6Address, port
+		}
+	}
+	return "", 0
+}
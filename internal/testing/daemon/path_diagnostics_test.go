@@ -0,0 +1,84 @@
+package daemon
+
+import (
+	"testing"
+
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+func TestPathProbeTarget(t *testing.T) {
+	d := &Daemon{config: &Config{Protocols: ProtocolsConfig{
+		BinkP:  ProtocolConfig{Port: 24554},
+		Ifcico: ProtocolConfig{Port: 60179},
+	}}}
+	failed := func(ipv4, ipv6 string) *models.ProtocolTestResult {
+		r := &models.ProtocolTestResult{}
+		if ipv4 != "" {
+			r.SetIPv4Result(false, 0, ipv4, "timeout")
+		}
+		if ipv6 != "" {
+			r.SetIPv6Result(false, 0, ipv6, "timeout")
+		}
+		return r
+	}
+
+	tests := []struct {
+		name     string
+		node     *models.Node
+		result   *models.TestResult
+		wantIP   string
+		wantPort int
+	}{
+		{
+			name:     "binkp first, IPv4 first",
+			node:     &models.Node{InternetProtocols: []string{"IBN", "IFC"}},
+			result:   &models.TestResult{BinkPResult: failed("192.0.2.1", "2001:db8::1"), IfcicoResult: failed("192.0.2.2", "")},
+			wantIP:   "192.0.2.1",
+			wantPort: 24554,
+		},
+		{
+			name:     "announced port wins",
+			node:     &models.Node{InternetProtocols: []string{"IFC"}, ProtocolPorts: map[string]int{"IFC": 60177}},
+			result:   &models.TestResult{IfcicoResult: failed("", "2001:db8::1")},
+			wantIP:   "2001:db8::1",
+			wantPort: 60177,
+		},
+		{
+			name:   "nothing tested",
+			node:   &models.Node{InternetProtocols: []string{"IBN"}},
+			result: &models.TestResult{BinkPResult: &models.ProtocolTestResult{}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ip, port := d.pathProbeTarget(tt.node, tt.result)
+			if ip != tt.wantIP || port != tt.wantPort {
+				t.Errorf("pathProbeTarget = %s:%d, want %s:%d", ip, port, tt.wantIP, tt.wantPort)
+			}
+		})
+	}
+}
+
+func TestAggregatedResultKeepsPathDiagnosticOnlyWhenAllFailed(t *testing.T) {
+	node := &models.Node{Zone: 2, Net: 5020, Node: 1, InternetHostnames: []string{"a.example", "b.example"}}
+	diag := &models.PathDiagnostic{IP: "192.0.2.1", Verdict: models.PathVerdictHostDown}
+	failedHost := func() *models.TestResult {
+		return &models.TestResult{TestedHostname: "a.example", ResolvedIPv4: []string{"192.0.2.1"}, PathDiagnostic: diag}
+	}
+
+	agg := NewTestAggregator().CreateAggregatedResult(node, []*models.TestResult{
+		failedHost(),
+		{TestedHostname: "b.example", ResolvedIPv4: []string{"192.0.2.2"}},
+	})
+	if agg.PathDiagnostic != diag {
+		t.Errorf("all hostnames failed: PathDiagnostic = %+v, want the first hostname's", agg.PathDiagnostic)
+	}
+
+	agg = NewTestAggregator().CreateAggregatedResult(node, []*models.TestResult{
+		failedHost(),
+		{TestedHostname: "b.example", ResolvedIPv4: []string{"192.0.2.2"}, IsOperational: true},
+	})
+	if agg.PathDiagnostic != nil {
+		t.Errorf("node reachable via b.example: PathDiagnostic = %+v, want none", agg.PathDiagnostic)
+	}
+}
@@ -19,6 +19,9 @@ const (
 	StrategyRegular ScheduleStrategy = iota
 	StrategyAdaptive
 	StrategyPriority
+	// StrategyUptime is adaptive ordering plus learned uptime windows: a node
+	// that only answers at certain hours is tested in those hours.
+	StrategyUptime
 )
 
 type NodeSchedule struct {
@@ -43,8 +46,10 @@ type Scheduler struct {
 	strategy            ScheduleStrategy
 	staleTestThreshold  time.Duration // Consider test stale after this duration
 	failedRetryInterval time.Duration // Retry failed nodes after this duration
+	uptimeHistoryDays   int           // Days of history the uptime windows are learned from
 
 	schedules map[string]*NodeSchedule
+	uptime    map[string]*uptimeProfile // keyed like schedules
 	storage   storage.Storage
 
 	jitterPercent float64
@@ -62,6 +67,7 @@ type SchedulerConfig struct {
 	PriorityBoost       int
 	StaleTestThreshold  time.Duration
 	FailedRetryInterval time.Duration
+	UptimeHistoryDays   int
 }
 
 func NewScheduler(cfg SchedulerConfig, storage storage.Storage) *Scheduler {
@@ -92,6 +98,9 @@ func NewScheduler(cfg SchedulerConfig, storage storage.Storage) *Scheduler {
 	if cfg.FailedRetryInterval == 0 {
 		cfg.FailedRetryInterval = 24 * time.Hour
 	}
+	if cfg.UptimeHistoryDays == 0 {
+		cfg.UptimeHistoryDays = 30
+	}
 
 	return &Scheduler{
 		baseInterval:        cfg.BaseInterval,
@@ -102,6 +111,7 @@ func NewScheduler(cfg SchedulerConfig, storage storage.Storage) *Scheduler {
 		strategy:            cfg.Strategy,
 		staleTestThreshold:  cfg.StaleTestThreshold,
 		failedRetryInterval: cfg.FailedRetryInterval,
+		uptimeHistoryDays:   cfg.UptimeHistoryDays,
 		schedules:           make(map[string]*NodeSchedule),
 		storage:             storage,
 		jitterPercent:       cfg.JitterPercent,
@@ -115,10 +125,15 @@ func (s *Scheduler) timeNow() time.Time {
 }
 
 func (s *Scheduler) InitializeSchedules(ctx context.Context, nodes []*models.Node) error {
+	profiles := s.loadUptimeProfiles(ctx)
+
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
 	logging.Debugf("InitializeSchedules: Processing %d nodes", len(nodes))
+	if profiles != nil {
+		s.uptime = profiles
+	}
 	nodesWithHistory := 0
 	nodesWithoutHistory := 0
 	failedQueries := 0
@@ -203,10 +218,17 @@ func (s *Scheduler) RefreshNodes(ctx context.Context) error {
 	if err != nil {
 		return fmt.Errorf("failed to get nodes from database: %w", err)
 	}
+	// Relearn the uptime windows from the history written since, before
+	// taking the lock
+	profiles := s.loadUptimeProfiles(ctx)
 
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
+	if profiles != nil {
+		s.uptime = profiles
+	}
+
 	// Track which nodes are still in database
 	currentNodeKeys := make(map[string]bool)
 	nodesWithChangedConfig := 0
@@ -286,6 +308,7 @@ func (s *Scheduler) GetNodesForTesting(ctx context.Context, maxNodes int) []*mod
 	var notInCallWindow []*NodeSchedule
 	staleNodes := 0
 	skippedForTimeWindow := 0
+	skippedForUptimeWindow := 0
 
 	for _, schedule := range s.schedules {
 		// Check if test is stale (hasn't been tested in staleTestThreshold duration)
@@ -302,6 +325,21 @@ func (s *Scheduler) GetNodesForTesting(ctx context.Context, maxNodes int) []*mod
 				continue
 			}
 
+			// Under the uptime strategy a node that keeps hours waits for
+			// them, stale or not: testing it now would only record the
+			// failure its history already predicts. An operator's override
+			// is tested when asked for.
+			if s.strategy == StrategyUptime && schedule.TestReason != "override" {
+				if in, known := s.inUptimeWindow(schedule.Node, now); known && !in {
+					if opening, ok := s.nextUptimeOpening(schedule.Node, now); ok {
+						schedule.NextTestTime = opening
+						schedule.TestReason = "outside_uptime_window"
+						skippedForUptimeWindow++
+						continue
+					}
+				}
+			}
+
 			// Update test reason based on current state
 			if isStale {
 				schedule.TestReason = "stale"
@@ -327,8 +365,12 @@ func (s *Scheduler) GetNodesForTesting(ctx context.Context, maxNodes int) []*mod
 		return allFutureNodes[i].NextTestTime.Before(allFutureNodes[j].NextTestTime)
 	})
 
-	logging.Debugf("GetNodesForTesting: now=%v, ready=%d (stale=%d), future=%d, outside_call_window=%d, total=%d, staleThreshold=%v",
-		now, len(readyNodes), staleNodes, len(allFutureNodes), skippedForTimeWindow, len(s.schedules), s.staleTestThreshold)
+	logging.Debugf("GetNodesForTesting: now=%v, ready=%d (stale=%d), future=%d, outside_call_window=%d, outside_uptime_window=%d, total=%d, staleThreshold=%v",
+		now, len(readyNodes), staleNodes, len(allFutureNodes), skippedForTimeWindow, skippedForUptimeWindow, len(s.schedules), s.staleTestThreshold)
+
+	if skippedForUptimeWindow > 0 {
+		logging.Infof("Deferred %d nodes to their learned uptime windows", skippedForUptimeWindow)
+	}
 
 	// Log nodes skipped due to time windows
 	if skippedForTimeWindow > 0 {
@@ -366,7 +408,7 @@ func (s *Scheduler) GetNodesForTesting(ctx context.Context, maxNodes int) []*mod
 
 	if s.strategy == StrategyPriority {
 		s.sortByPriority(readyNodes)
-	} else if s.strategy == StrategyAdaptive {
+	} else if s.strategy == StrategyAdaptive || s.strategy == StrategyUptime {
 		s.sortByAdaptive(readyNodes, now)
 	}
 
@@ -441,8 +483,17 @@ func (s *Scheduler) UpdateTestResult(ctx context.Context, node *models.Node, res
 		s.schedules[key] = schedule
 	}
 
+	// Judge the result against the window learned so far, then learn from it
+	if s.strategy == StrategyUptime {
+		s.classifyResult(node, result)
+		s.recordUptime(node, result)
+	}
+
 	schedule.LastTestTime = result.TestTime
 	schedule.LastTestSuccess = result.IsOperational
+	if schedule.TestReason == "override" {
+		schedule.TestReason = "" // spent; the next test is the schedule's own
+	}
 
 	if result.IsOperational {
 		schedule.ConsecutiveFails = 0
@@ -52,6 +52,10 @@ func (s *Scheduler) calculateNextTestTime(schedule *NodeSchedule) time.Time {
 		nextTime = nextTime.Add(jitter)
 	}
 
+	if s.strategy == StrategyUptime {
+		nextTime = s.uptimeNextTestTime(schedule, nextTime, now)
+	}
+
 	return nextTime
 }
 
@@ -0,0 +1,238 @@
+package daemon
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/logging"
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+// Thresholds for reading a daily pattern out of a node's history. An hour is
+// up when the node answered on at least half of the days it was tried then;
+// it is down only after uptimeMinDownDays tries without a single answer, so
+// one unlucky test does not carve a hole in a node's window. The next
+// opening is looked for up to a week ahead.
+const (
+	uptimeMinDownDays = 2
+	uptimeSearchHours = 7 * 24
+)
+
+// uptimeProfile is one node's test history folded onto the 24 UTC hours of
+// the day: on how many days it was tested in each hour and on how many of
+// those it answered. lastDay/lastUp remember the newest day counted per hour
+// so a retest within the same hour does not count the day twice.
+type uptimeProfile struct {
+	days      [24]int
+	reachable [24]int
+	lastDay   [24]int64
+	lastUp    [24]bool
+}
+
+// addHistory adds one hour's stored history. The newest stored day becomes
+// the hour's last day, so a live result from that day only upgrades it to
+// reachable instead of counting the day a second time.
+func (p *uptimeProfile) addHistory(rec models.UptimeHourRecord) {
+	h := rec.Hour
+	p.days[h] += rec.Days
+	p.reachable[h] += rec.Reachable
+	p.lastDay[h] = rec.LastDay.UTC().Unix() / 86400
+	p.lastUp[h] = rec.LastUp
+}
+
+// add records one test result, counting at most one day per hour.
+func (p *uptimeProfile) add(at time.Time, up bool) {
+	at = at.UTC()
+	h := at.Hour()
+	day := at.Unix() / 86400
+	if p.lastDay[h] == day {
+		if up && !p.lastUp[h] {
+			p.reachable[h]++
+			p.lastUp[h] = true
+		}
+		return
+	}
+	p.lastDay[h] = day
+	p.lastUp[h] = up
+	p.days[h]++
+	if up {
+		p.reachable[h]++
+	}
+}
+
+// window returns the hours the node answers in. ok is false unless the
+// history shows a schedule, that is both hours it answers in and hours it
+// reliably does not: a node up whenever it was tried, one never up, or one
+// tried too rarely has no window.
+func (p *uptimeProfile) window() (hours [24]bool, ok bool) {
+	var up, down bool
+	for h := 0; h < 24; h++ {
+		switch {
+		case p.reachable[h] > 0 && p.reachable[h]*2 >= p.days[h]:
+			hours[h] = true
+			up = true
+		case p.reachable[h] == 0 && p.days[h] >= uptimeMinDownDays:
+			down = true
+		}
+	}
+	return hours, up && down
+}
+
+// formatUptimeWindow renders the hours as UTC ranges, "22-06 UTC" for a node
+// up from 22:00 to 06:00. Ranges wrap over midnight.
+func formatUptimeWindow(hours [24]bool) string {
+	start := 0
+	for start < 24 && hours[start] {
+		start++
+	}
+	if start == 24 {
+		return "00-24 UTC"
+	}
+	// Walk once round the clock from an hour the node is down in, so every
+	// run of up hours is closed by the time the walk ends.
+	var ranges []string
+	runStart := -1
+	for i := 1; i <= 24; i++ {
+		h := (start + i) % 24
+		if hours[h] {
+			if runStart < 0 {
+				runStart = h
+			}
+			continue
+		}
+		if runStart >= 0 {
+			ranges = append(ranges, fmt.Sprintf("%02d-%02d", runStart, h))
+			runStart = -1
+		}
+	}
+	if len(ranges) == 0 {
+		return ""
+	}
+	return strings.Join(ranges, ",") + " UTC"
+}
+
+// loadUptimeProfiles rebuilds the profiles from the stored history, for the
+// caller to swap in under s.mu. The query scans all recent results, so
+// callers run it before taking the lock. It returns nil, leaving the
+// profiles as they are, under any strategy but StrategyUptime and when the
+// history cannot be read.
+func (s *Scheduler) loadUptimeProfiles(ctx context.Context) map[string]*uptimeProfile {
+	if s.storage == nil || s.strategy != StrategyUptime {
+		return nil
+	}
+	records, err := s.storage.GetUptimeHours(ctx, s.uptimeHistoryDays)
+	if err != nil {
+		logging.Warnf("Failed to load uptime history: %v", err)
+		return nil
+	}
+
+	profiles := make(map[string]*uptimeProfile)
+	for _, rec := range records {
+		if rec.Hour < 0 || rec.Hour > 23 {
+			continue
+		}
+		key := (&models.Node{Zone: rec.Zone, Net: rec.Net, Node: rec.Node, Domain: rec.Domain}).Key()
+		p := profiles[key]
+		if p == nil {
+			p = &uptimeProfile{}
+			profiles[key] = p
+		}
+		p.addHistory(rec)
+	}
+
+	windowed := 0
+	for _, p := range profiles {
+		if _, ok := p.window(); ok {
+			windowed++
+		}
+	}
+	logging.Infof("Learned uptime windows: %d of %d nodes with history keep hours", windowed, len(profiles))
+	return profiles
+}
+
+// uptimeWindow returns the learned window of a node, if it has one.
+func (s *Scheduler) uptimeWindow(node *models.Node) ([24]bool, bool) {
+	if node == nil {
+		return [24]bool{}, false
+	}
+	p := s.uptime[s.nodeKey(node)]
+	if p == nil {
+		return [24]bool{}, false
+	}
+	return p.window()
+}
+
+// nextUptimeOpening returns the first moment at or after from that lies in
+// both the node's learned window and its nodelist call window. ok is false
+// when the node has no learned window or the two never meet within a week,
+// in which case the node is scheduled as if it had none.
+func (s *Scheduler) nextUptimeOpening(node *models.Node, from time.Time) (time.Time, bool) {
+	hours, ok := s.uptimeWindow(node)
+	if !ok {
+		return time.Time{}, false
+	}
+	t := from
+	for i := 0; i < uptimeSearchHours; i++ {
+		if hours[t.UTC().Hour()] && (node.Availability == nil || node.Availability.IsCallableNow(t)) {
+			return t, true
+		}
+		t = t.Truncate(time.Hour).Add(time.Hour)
+	}
+	return time.Time{}, false
+}
+
+// inUptimeWindow reports whether at falls inside the node's learned window.
+// known is false when the node has none.
+func (s *Scheduler) inUptimeWindow(node *models.Node, at time.Time) (in, known bool) {
+	hours, ok := s.uptimeWindow(node)
+	if !ok {
+		return false, false
+	}
+	return hours[at.UTC().Hour()], true
+}
+
+// classifyResult stamps a result with the node's learned window and marks a
+// failure outside it as intermittent by schedule rather than down. It runs
+// before the result is added to the profile. Callers hold s.mu.
+func (s *Scheduler) classifyResult(node *models.Node, result *models.TestResult) {
+	hours, ok := s.uptimeWindow(node)
+	if !ok {
+		return
+	}
+	result.UptimeWindow = formatUptimeWindow(hours)
+	result.IntermittentBySchedule = !result.IsOperational && !hours[result.TestTime.UTC().Hour()]
+}
+
+// recordUptime adds a result to the node's profile. Callers hold s.mu.
+func (s *Scheduler) recordUptime(node *models.Node, result *models.TestResult) {
+	if s.uptime == nil {
+		s.uptime = make(map[string]*uptimeProfile)
+	}
+	key := s.nodeKey(node)
+	p := s.uptime[key]
+	if p == nil {
+		p = &uptimeProfile{}
+		s.uptime[key] = p
+	}
+	p.add(result.TestTime, result.IsOperational)
+}
+
+// uptimeNextTestTime moves a computed next test into the node's window. A
+// node whose last test failed outside its window was not down but asleep, so
+// it is retried at the next opening instead of after the failed-retry
+// interval.
+func (s *Scheduler) uptimeNextTestTime(schedule *NodeSchedule, next, now time.Time) time.Time {
+	if !schedule.LastTestSuccess {
+		if in, known := s.inUptimeWindow(schedule.Node, schedule.LastTestTime); known && !in {
+			if opening, ok := s.nextUptimeOpening(schedule.Node, now); ok && opening.Before(next) {
+				return opening
+			}
+		}
+	}
+	if opening, ok := s.nextUptimeOpening(schedule.Node, next); ok {
+		return opening
+	}
+	return next
+}
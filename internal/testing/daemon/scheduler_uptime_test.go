@@ -0,0 +1,309 @@
+package daemon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/models"
+	"github.com/nodelistdb/internal/testing/timeavail"
+)
+
+// nightProfile is a node that answered every night from 22:00 to 06:00 UTC
+// and never by day, tested three times in each hour.
+func nightProfile() *uptimeProfile {
+	p := &uptimeProfile{}
+	for h := 0; h < 24; h++ {
+		p.days[h] = 3
+		if h >= 22 || h < 6 {
+			p.reachable[h] = 3
+		}
+	}
+	return p
+}
+
+func nightHours() [24]bool {
+	var hours [24]bool
+	for h := 0; h < 24; h++ {
+		hours[h] = h >= 22 || h < 6
+	}
+	return hours
+}
+
+func TestUptimeProfileWindow(t *testing.T) {
+	alwaysUp := &uptimeProfile{}
+	neverUp := &uptimeProfile{}
+	oneBadDay := &uptimeProfile{}
+	for h := 0; h < 24; h++ {
+		alwaysUp.days[h], alwaysUp.reachable[h] = 2, 2
+		neverUp.days[h] = 5
+		oneBadDay.days[h], oneBadDay.reachable[h] = 1, 1
+	}
+	oneBadDay.reachable[12] = 0
+
+	tests := []struct {
+		name    string
+		profile *uptimeProfile
+		want    [24]bool
+		wantOK  bool
+	}{
+		{"answers at night only", nightProfile(), nightHours(), true},
+		{"always up", alwaysUp, [24]bool{}, false},
+		{"never up", neverUp, [24]bool{}, false},
+		{"one failed test is not a closed hour", oneBadDay, [24]bool{}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hours, ok := tt.profile.window()
+			if ok != tt.wantOK || (ok && hours != tt.want) {
+				t.Errorf("window() = %v, %v; want %v, %v", hours, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestUptimeProfileAddCountsADayOnce(t *testing.T) {
+	p := &uptimeProfile{}
+	day := time.Date(2026, 3, 10, 3, 10, 0, 0, time.UTC)
+	p.add(day, false)
+	p.add(day.Add(30*time.Minute), true) // retest within the hour answers
+	p.add(day.Add(24*time.Hour), false)  // next day, same hour
+	if p.days[3] != 2 || p.reachable[3] != 1 {
+		t.Errorf("hour 3: days=%d reachable=%d, want 2 and 1", p.days[3], p.reachable[3])
+	}
+}
+
+func TestUptimeProfileAddAfterReload(t *testing.T) {
+	// Storage already holds a failed test at 03:xx today; a live success in
+	// the same hour upgrades that day, it does not add another.
+	today := time.Date(2026, 3, 10, 0, 0, 0, 0, time.UTC)
+	p := &uptimeProfile{}
+	p.addHistory(models.UptimeHourRecord{Hour: 3, Days: 4, Reachable: 1, LastDay: today})
+	p.add(today.Add(3*time.Hour+40*time.Minute), true)
+	p.add(today.Add(3*time.Hour+50*time.Minute), true)
+	if p.days[3] != 4 || p.reachable[3] != 2 {
+		t.Errorf("hour 3 today: days=%d reachable=%d, want 4 and 2", p.days[3], p.reachable[3])
+	}
+	p.add(today.Add(27*time.Hour), false)
+	if p.days[3] != 5 || p.reachable[3] != 2 {
+		t.Errorf("hour 3 tomorrow: days=%d reachable=%d, want 5 and 2", p.days[3], p.reachable[3])
+	}
+}
+
+func TestFormatUptimeWindow(t *testing.T) {
+	var split, all [24]bool
+	for _, h := range []int{8, 9, 10, 11, 20, 21, 22} {
+		split[h] = true
+	}
+	for h := range all {
+		all[h] = true
+	}
+	tests := []struct {
+		hours [24]bool
+		want  string
+	}{
+		{nightHours(), "22-06 UTC"},
+		{split, "08-12,20-23 UTC"},
+		{all, "00-24 UTC"},
+		{[24]bool{}, ""},
+	}
+	for _, tt := range tests {
+		if got := formatUptimeWindow(tt.hours); got != tt.want {
+			t.Errorf("formatUptimeWindow(%v) = %q, want %q", tt.hours, got, tt.want)
+		}
+	}
+}
+
+func TestNextUptimeOpening(t *testing.T) {
+	noon := time.Date(2026, 3, 10, 12, 30, 0, 0, time.UTC)
+	midnight := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+	// A nodelist T-flag window of 00:00-04:00 UTC narrows the night window
+	tflag := &timeavail.NodeAvailability{Windows: []timeavail.TimeWindow{
+		{StartUTC: midnight, EndUTC: midnight.Add(4 * time.Hour), Source: timeavail.SourceTFlag},
+	}}
+
+	tests := []struct {
+		name   string
+		node   *models.Node
+		from   time.Time
+		want   time.Time
+		wantOK bool
+	}{
+		{"waits for the night", &models.Node{Zone: 2, Net: 5020, Node: 1}, noon, time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC), true},
+		{"already inside", &models.Node{Zone: 2, Net: 5020, Node: 1}, noon.Add(11 * time.Hour), noon.Add(11 * time.Hour), true},
+		{"T-flag narrows the window", &models.Node{Zone: 2, Net: 5020, Node: 1, Availability: tflag}, noon, time.Date(2026, 3, 11, 0, 0, 0, 0, time.UTC), true},
+		{"no learned window", &models.Node{Zone: 2, Net: 5020, Node: 2}, noon, time.Time{}, false},
+	}
+	s := NewScheduler(SchedulerConfig{}, nil)
+	s.uptime = map[string]*uptimeProfile{(&models.Node{Zone: 2, Net: 5020, Node: 1}).Key(): nightProfile()}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := s.nextUptimeOpening(tt.node, tt.from)
+			if ok != tt.wantOK || !got.Equal(tt.want) {
+				t.Errorf("nextUptimeOpening = %v, %v; want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestUpdateTestResultClassifiesAgainstTheWindow(t *testing.T) {
+	node := &models.Node{Zone: 2, Net: 5020, Node: 1}
+	tests := []struct {
+		name             string
+		at               time.Time
+		operational      bool
+		wantIntermittent bool
+	}{
+		{"failed by day", time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), false, true},
+		{"failed at night", time.Date(2026, 3, 10, 23, 0, 0, 0, time.UTC), false, false},
+		{"answered by day", time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), true, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := NewScheduler(SchedulerConfig{Strategy: StrategyUptime}, nil)
+			s.uptime = map[string]*uptimeProfile{node.Key(): nightProfile()}
+			result := &models.TestResult{TestTime: tt.at, IsOperational: tt.operational}
+			s.UpdateTestResult(context.Background(), node, result)
+			if result.UptimeWindow != "22-06 UTC" || result.IntermittentBySchedule != tt.wantIntermittent {
+				t.Errorf("window %q intermittent %v; want %q, %v", result.UptimeWindow, result.IntermittentBySchedule, "22-06 UTC", tt.wantIntermittent)
+			}
+		})
+	}
+
+	// A node with no learned window is never stamped
+	s := NewScheduler(SchedulerConfig{Strategy: StrategyUptime}, nil)
+	result := &models.TestResult{TestTime: time.Now(), IsOperational: false}
+	s.UpdateTestResult(context.Background(), node, result)
+	if result.UptimeWindow != "" || result.IntermittentBySchedule {
+		t.Errorf("node without history stamped: %q, %v", result.UptimeWindow, result.IntermittentBySchedule)
+	}
+
+	// Other strategies neither classify nor learn
+	s = NewScheduler(SchedulerConfig{Strategy: StrategyAdaptive}, nil)
+	s.uptime = map[string]*uptimeProfile{node.Key(): nightProfile()}
+	result = &models.TestResult{TestTime: time.Date(2026, 3, 10, 12, 0, 0, 0, time.UTC), IsOperational: false}
+	s.UpdateTestResult(context.Background(), node, result)
+	if result.UptimeWindow != "" || result.IntermittentBySchedule {
+		t.Errorf("adaptive scheduler stamped: %q, %v", result.UptimeWindow, result.IntermittentBySchedule)
+	}
+	if *s.uptime[node.Key()] != *nightProfile() {
+		t.Error("adaptive scheduler recorded uptime")
+	}
+}
+
+func TestUptimeNextTestTime(t *testing.T) {
+	node := &models.Node{Zone: 2, Net: 5020, Node: 1}
+	s := NewScheduler(SchedulerConfig{Strategy: StrategyUptime}, nil)
+	s.uptime = map[string]*uptimeProfile{node.Key(): nightProfile()}
+	now := time.Date(2026, 3, 10, 12, 5, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		schedule *NodeSchedule
+		next     time.Time
+		want     time.Time
+	}{
+		{
+			// Asleep, not down: tonight, not after the failed-retry interval
+			name:     "failed outside the window",
+			schedule: &NodeSchedule{Node: node, LastTestTime: now.Add(-5 * time.Minute)},
+			next:     now.Add(24 * time.Hour),
+			want:     time.Date(2026, 3, 10, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			// Down within its hours: keeps its retry interval, moved into the window
+			name:     "failed inside the window",
+			schedule: &NodeSchedule{Node: node, LastTestTime: time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)},
+			next:     time.Date(2026, 3, 11, 12, 0, 0, 0, time.UTC),
+			want:     time.Date(2026, 3, 11, 22, 0, 0, 0, time.UTC),
+		},
+		{
+			name:     "operational, due inside the window",
+			schedule: &NodeSchedule{Node: node, LastTestSuccess: true, LastTestTime: time.Date(2026, 3, 10, 2, 0, 0, 0, time.UTC)},
+			next:     time.Date(2026, 3, 13, 3, 15, 0, 0, time.UTC),
+			want:     time.Date(2026, 3, 13, 3, 15, 0, 0, time.UTC),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.uptimeNextTestTime(tt.schedule, tt.next, now); !got.Equal(tt.want) {
+				t.Errorf("uptimeNextTestTime = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetNodesForTestingWaitsForUptimeWindow(t *testing.T) {
+	now := time.Now()
+	// The node answers from six to twelve hours from now and never otherwise
+	p := &uptimeProfile{}
+	for i := 0; i < 24; i++ {
+		h := (now.UTC().Hour() + i) % 24
+		p.days[h] = 3
+		if i >= 6 && i < 12 {
+			p.reachable[h] = 3
+		}
+	}
+	sleeper := &models.Node{Zone: 2, Net: 5020, Node: 1}
+	plain := &models.Node{Zone: 2, Net: 5020, Node: 2}
+
+	s := NewScheduler(SchedulerConfig{Strategy: StrategyUptime}, nil)
+	s.uptime = map[string]*uptimeProfile{sleeper.Key(): p}
+	for _, n := range []*models.Node{sleeper, plain} {
+		s.schedules[n.Key()] = &NodeSchedule{Node: n, NextTestTime: now.Add(-time.Minute), LastTestTime: now.Add(-25 * time.Hour)}
+	}
+
+	got := s.GetNodesForTesting(context.Background(), 0)
+	if len(got) != 1 || got[0] != plain {
+		t.Fatalf("GetNodesForTesting = %v, want only the node without a window", got)
+	}
+	deferred := s.schedules[sleeper.Key()]
+	if deferred.TestReason != "outside_uptime_window" || !deferred.NextTestTime.After(now.Add(5*time.Hour)) {
+		t.Errorf("deferred schedule: reason %q next %v; want outside_uptime_window about six hours out", deferred.TestReason, deferred.NextTestTime)
+	}
+}
+
+func TestOverrideIgnoresUptimeWindowOnce(t *testing.T) {
+	now := time.Now()
+	// Never answers in the coming hour, so the uptime strategy would defer it
+	p := &uptimeProfile{}
+	for h := 0; h < 24; h++ {
+		p.days[h] = 3
+		if h != now.UTC().Hour() {
+			p.reachable[h] = 3
+		}
+	}
+	node := &models.Node{Zone: 2, Net: 5020, Node: 1}
+	s := NewScheduler(SchedulerConfig{Strategy: StrategyUptime}, nil)
+	s.uptime = map[string]*uptimeProfile{node.Key(): p}
+	s.schedules[node.Key()] = &NodeSchedule{Node: node, NextTestTime: now.Add(time.Hour), LastTestTime: now.Add(-10 * time.Minute)}
+
+	if _, ok := s.OverrideNextTest(2, 5020, 1, "", now.Add(-time.Second)); !ok {
+		t.Fatal("OverrideNextTest did not find the node")
+	}
+	if got := s.GetNodesForTesting(context.Background(), 0); len(got) != 1 || got[0].TestReason != "override" {
+		t.Fatalf("GetNodesForTesting = %v, want the overridden node despite its window", got)
+	}
+	s.UpdateTestResult(context.Background(), node, &models.TestResult{TestTime: now, IsOperational: true})
+	if reason := s.schedules[node.Key()].TestReason; reason == "override" {
+		t.Error("override still set after the test it asked for")
+	}
+}
+
+func TestParseScheduleStrategy(t *testing.T) {
+	for name, want := range map[string]ScheduleStrategy{
+		"": StrategyAdaptive, "adaptive": StrategyAdaptive, "regular": StrategyRegular,
+		"priority": StrategyPriority, "uptime": StrategyUptime,
+	} {
+		got, err := ParseScheduleStrategy(name)
+		if err != nil || got != want {
+			t.Errorf("ParseScheduleStrategy(%q) = %v, %v; want %v", name, got, err, want)
+		}
+		if name != "" && got.String() != name {
+			t.Errorf("%v.String() = %q, want %q", got, got.String(), name)
+		}
+	}
+	if _, err := ParseScheduleStrategy("nightly"); err == nil {
+		t.Error("ParseScheduleStrategy accepted an unknown name")
+	}
+}
@@ -2,6 +2,8 @@ package daemon
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
 
 	"github.com/nodelistdb/internal/testing/models"
@@ -23,6 +25,7 @@ func (s *Scheduler) GetScheduleStatus() map[string]interface{} {
 	totalNodes := len(s.schedules)
 	readyNodes := 0
 	failingNodes := 0
+	intermittentNodes := 0
 	pendingFirstTest := 0
 	avgBackoffLevel := 0.0
 
@@ -37,6 +40,9 @@ func (s *Scheduler) GetScheduleStatus() map[string]interface{} {
 			// Only count as failing if it has been tested and failed
 			failingNodes++
 			avgBackoffLevel += float64(schedule.BackoffLevel)
+			if in, known := s.inUptimeWindow(schedule.Node, schedule.LastTestTime); known && !in {
+				intermittentNodes++
+			}
 		}
 	}
 
@@ -48,6 +54,7 @@ func (s *Scheduler) GetScheduleStatus() map[string]interface{} {
 		"total_nodes":        totalNodes,
 		"ready_for_test":     readyNodes,
 		"failing_nodes":      failingNodes,
+		"intermittent_nodes": intermittentNodes,
 		"pending_first_test": pendingFirstTest,
 		"avg_backoff_level":  avgBackoffLevel,
 		"strategy":           s.strategy.String(),
@@ -68,6 +75,45 @@ func (s *Scheduler) ResetNodeSchedule(ctx context.Context, zone, net, node uint1
 	}
 }
 
+// OverrideNextTest moves a node's next test to at, whatever its interval or
+// backoff says, and reports whether the node is scheduled at all. The override
+// is one-shot: the result of that test schedules the next as usual.
+func (s *Scheduler) OverrideNextTest(zone, net, node uint16, domain string, at time.Time) (NodeSchedule, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	key := (&models.Node{Zone: int(zone), Net: int(net), Node: int(node), Domain: domain}).Key()
+	schedule, exists := s.schedules[key]
+	if !exists {
+		return NodeSchedule{}, false
+	}
+	schedule.NextTestTime = at
+	schedule.TestReason = "override"
+	return *schedule, true
+}
+
+// Queue returns copies of up to limit schedules (all of them for 0), soonest
+// due first.
+func (s *Scheduler) Queue(limit int) []NodeSchedule {
+	s.mu.RLock()
+	queue := make([]NodeSchedule, 0, len(s.schedules))
+	for _, schedule := range s.schedules {
+		queue = append(queue, *schedule)
+	}
+	s.mu.RUnlock()
+
+	sort.Slice(queue, func(i, j int) bool {
+		if !queue[i].NextTestTime.Equal(queue[j].NextTestTime) {
+			return queue[i].NextTestTime.Before(queue[j].NextTestTime)
+		}
+		return queue[i].Node.Key() < queue[j].Node.Key()
+	})
+	if limit > 0 && len(queue) > limit {
+		queue = queue[:limit]
+	}
+	return queue
+}
+
 // SchedulesFor3D returns the scheduled nodes matching a 3D address across all
 // FTN networks. Used by AKA-derivation to find the same physical host's
 // entries in other networks.
@@ -150,11 +196,30 @@ func (st ScheduleStrategy) String() string {
 		return "adaptive"
 	case StrategyPriority:
 		return "priority"
+	case StrategyUptime:
+		return "uptime"
 	default:
 		return "unknown"
 	}
 }
 
+// ParseScheduleStrategy parses the daemon.schedule_strategy setting. An
+// empty value is the adaptive strategy.
+func ParseScheduleStrategy(name string) (ScheduleStrategy, error) {
+	switch name {
+	case "", "adaptive":
+		return StrategyAdaptive, nil
+	case "regular":
+		return StrategyRegular, nil
+	case "priority":
+		return StrategyPriority, nil
+	case "uptime":
+		return StrategyUptime, nil
+	default:
+		return StrategyAdaptive, fmt.Errorf("unknown strategy %q (want regular, adaptive, priority or uptime)", name)
+	}
+}
+
 // stringSlicesEqual compares two string slices for equality
 func stringSlicesEqual(a, b []string) bool {
 	if len(a) != len(b) {
@@ -178,6 +178,8 @@ func (ta *TestAggregator) CreateAggregatedResult(node *models.Node, results []*m
 	vmodemDetailsFromSuccess := false
 	// Tracks whether they came from a confirmed VMODEM; see preferConfirmedVMODEM.
 	vmodemVMPFound := false
+	// The same for the binkps certificate.
+	binkpsDetailsFromSuccess := false
 
 	// Aggregate DNS results
 	var allIPv4s []string
@@ -230,6 +232,18 @@ func (ta *TestAggregator) CreateAggregatedResult(node *models.Node, results []*m
 			}
 		}
 
+		if result.BinkPSResult != nil {
+			if aggregated.BinkPSResult == nil {
+				aggregated.BinkPSResult = &models.ProtocolTestResult{}
+			}
+			mergeProtocolResult(aggregated.BinkPSResult, result.BinkPSResult)
+			// A failed session still carries the certificate.
+			keepFailureDetails(aggregated.BinkPSResult, result.BinkPSResult, &binkpsDetailsFromSuccess)
+			if result.BinkPSResult.Success {
+				hasAnyProtocolSuccess = true
+			}
+		}
+
 		if result.IfcicoResult != nil {
 			if aggregated.IfcicoResult == nil {
 				aggregated.IfcicoResult = &models.ProtocolTestResult{}
@@ -288,6 +302,12 @@ func (ta *TestAggregator) CreateAggregatedResult(node *models.Node, results []*m
 			operationalHostnames = append(operationalHostnames, hostname)
 		}
 
+		// The path probe of the first hostname that had one; dropped
+		// below if another hostname worked.
+		if result.PathDiagnostic != nil && aggregated.PathDiagnostic == nil {
+			aggregated.PathDiagnostic = result.PathDiagnostic
+		}
+
 		// Use geolocation from first successful result
 		if result.Country != "" && aggregated.Country == "" {
 			aggregated.Country = result.Country
@@ -322,6 +342,7 @@ func (ta *TestAggregator) CreateAggregatedResult(node *models.Node, results []*m
 	// Derive each protocol's overall Tested/Success/ResponseMs/Error from the
 	// merged per-IP-family results now that every hostname has been folded in.
 	finalizeProtocolResult(aggregated.BinkPResult)
+	finalizeProtocolResult(aggregated.BinkPSResult)
 	finalizeProtocolResult(aggregated.IfcicoResult)
 	finalizeProtocolResult(aggregated.TelnetResult)
 	finalizeProtocolResult(aggregated.FTPResult)
@@ -331,6 +352,7 @@ func (ta *TestAggregator) CreateAggregatedResult(node *models.Node, results []*m
 	if hasAnyProtocolSuccess {
 		aggregated.IsOperational = true
 		aggregated.HasConnectivityIssues = false
+		aggregated.PathDiagnostic = nil
 	} else if hasAnyDNSSuccess {
 		aggregated.IsOperational = false
 		aggregated.HasConnectivityIssues = true
@@ -346,3 +368,108 @@ func (ta *TestAggregator) CreateAggregatedResult(node *models.Node, results []*m
 
 	return aggregated
 }
+
+// CombineVantageResults folds the results other locations reported for a
+// node into this location's own, which becomes the node's global result and
+// is returned. The node is operational if any location reached it, and a
+// protocol this location could not reach takes the result of one that could;
+// where this location succeeded its own measurements stand. DNS, geolocation
+// and the path probe stay this location's: a path that ends short of a node
+// others reach is exactly the regional problem VantageVerdict names.
+func (ta *TestAggregator) CombineVantageResults(local *models.TestResult, remote []*models.TestResult) *models.TestResult {
+	all := append([]*models.TestResult{local}, remote...)
+	local.VantageResults = make([]models.VantageResult, 0, len(all))
+	reachable := 0
+	for _, r := range all {
+		local.VantageResults = append(local.VantageResults, vantageSummary(r))
+		if r.IsOperational {
+			reachable++
+		}
+	}
+
+	for _, r := range remote {
+		mergeVantageProtocol(&local.BinkPResult, r.BinkPResult)
+		mergeVantageProtocol(&local.BinkPSResult, r.BinkPSResult)
+		mergeVantageProtocol(&local.IfcicoResult, r.IfcicoResult)
+		mergeVantageProtocol(&local.TelnetResult, r.TelnetResult)
+		mergeVantageProtocol(&local.FTPResult, r.FTPResult)
+		mergeVantageProtocol(&local.VModemResult, r.VModemResult)
+
+		local.AddressValidated = local.AddressValidated || r.AddressValidated
+		local.AddressValidatedIPv4 = local.AddressValidatedIPv4 || r.AddressValidatedIPv4
+		local.AddressValidatedIPv6 = local.AddressValidatedIPv6 || r.AddressValidatedIPv6
+		if r.IsOperational {
+			local.IsOperational = true
+			local.HasConnectivityIssues = false
+		}
+	}
+
+	local.VantagePointsTested = int32(len(all))
+	local.VantagePointsReachable = int32(reachable)
+	switch {
+	case len(all) < 2:
+		local.VantageVerdict = ""
+	case reachable == len(all):
+		local.VantageVerdict = models.VantageVerdictReachable
+	case reachable == 0:
+		local.VantageVerdict = models.VantageVerdictUnreachable
+	default:
+		local.VantageVerdict = models.VantageVerdictRegional
+	}
+	return local
+}
+
+// mergeVantageProtocol takes another location's result for a protocol this
+// location did not get through on.
+func mergeVantageProtocol(dst **models.ProtocolTestResult, src *models.ProtocolTestResult) {
+	if src == nil || (*dst != nil && (*dst).Success) {
+		return
+	}
+	if *dst == nil {
+		*dst = &models.ProtocolTestResult{}
+	}
+	mergeProtocolResult(*dst, src)
+	finalizeProtocolResult(*dst)
+}
+
+// vantageSummary reduces one location's result to its
+// node_test_vantage_results row.
+func vantageSummary(r *models.TestResult) models.VantageResult {
+	v := models.VantageResult{
+		VantagePoint:   r.VantagePoint,
+		TestTime:       r.TestTime,
+		IsOperational:  r.IsOperational,
+		TestedHostname: r.TestedHostname,
+		DNSError:       r.DNSError,
+	}
+	for _, p := range []struct {
+		name   string
+		result *models.ProtocolTestResult
+	}{
+		{"binkp", r.BinkPResult},
+		{"binkps", r.BinkPSResult},
+		{"ifcico", r.IfcicoResult},
+		{"telnet", r.TelnetResult},
+		{"ftp", r.FTPResult},
+		{"vmodem", r.VModemResult},
+	} {
+		if p.result == nil || !p.result.Tested {
+			continue
+		}
+		if !p.result.Success {
+			v.ProtocolsFailed = append(v.ProtocolsFailed, p.name)
+			if v.Error == "" {
+				v.Error = p.result.Error
+			}
+			continue
+		}
+		if len(v.ProtocolsOK) == 0 || p.result.ResponseMs < v.ResponseMs {
+			v.ResponseMs = p.result.ResponseMs
+		}
+		v.ProtocolsOK = append(v.ProtocolsOK, p.name)
+	}
+	if v.IsOperational {
+		v.Error = ""
+	}
+	return v
+}
@@ -1,6 +1,7 @@
 package daemon
 
 import (
+	"fmt"
 	"testing"
 
 	"github.com/nodelistdb/internal/testing/models"
@@ -914,3 +915,74 @@ func TestCreateAggregatedResult_AddressValidation(t *testing.T) {
 		})
 	}
 }
+
+// vantageResult is one location's result with a BinkP test that either
+// answered over IPv4 in ms milliseconds or timed out.
+func vantageResult(name string, ok bool, ms uint32) *models.TestResult {
+	binkp := &models.ProtocolTestResult{Tested: true, IPv4Tested: true}
+	if ok {
+		binkp.Success, binkp.IPv4Success = true, true
+		binkp.ResponseMs, binkp.IPv4ResponseMs = ms, ms
+		binkp.IPv4Address = "192.0.2.1"
+	} else {
+		binkp.Error, binkp.IPv4Error = "i/o timeout", "i/o timeout"
+	}
+	return &models.TestResult{VantagePoint: name, IsOperational: ok, TestedHostname: "bbs.example.com", BinkPResult: binkp}
+}
+
+func TestCombineVantageResults(t *testing.T) {
+	tests := []struct {
+		name          string
+		local         *models.TestResult
+		remote        []*models.TestResult
+		wantVerdict   string
+		wantReachable int32
+		wantOp        bool
+		wantMs        uint32
+	}{
+		{"single location", vantageResult("de-fra", true, 40), nil, "", 1, true, 40},
+		{"reachable from all", vantageResult("de-fra", true, 40),
+			[]*models.TestResult{vantageResult("us-east", true, 10)}, models.VantageVerdictReachable, 2, true, 40},
+		{"regional, local cut off", vantageResult("de-fra", false, 0),
+			[]*models.TestResult{vantageResult("us-east", true, 120), vantageResult("au-syd", false, 0)},
+			models.VantageVerdictRegional, 1, true, 120},
+		{"unreachable from all", vantageResult("de-fra", false, 0),
+			[]*models.TestResult{vantageResult("us-east", false, 0)}, models.VantageVerdictUnreachable, 0, false, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := NewTestAggregator().CombineVantageResults(tt.local, tt.remote)
+			if got.VantageVerdict != tt.wantVerdict || got.VantagePointsReachable != tt.wantReachable ||
+				got.VantagePointsTested != int32(1+len(tt.remote)) {
+				t.Errorf("verdict %q reachable %d/%d; want %q %d/%d", got.VantageVerdict,
+					got.VantagePointsReachable, got.VantagePointsTested, tt.wantVerdict, tt.wantReachable, 1+len(tt.remote))
+			}
+			if got.IsOperational != tt.wantOp || got.BinkPResult.Success != tt.wantOp {
+				t.Errorf("operational %v, binkp success %v; want %v", got.IsOperational, got.BinkPResult.Success, tt.wantOp)
+			}
+			// A location's own success keeps its own response time
+			if got.BinkPResult.ResponseMs != tt.wantMs {
+				t.Errorf("binkp response %d ms, want %d", got.BinkPResult.ResponseMs, tt.wantMs)
+			}
+			if len(got.VantageResults) != 1+len(tt.remote) || got.VantageResults[0].VantagePoint != "de-fra" {
+				t.Fatalf("vantage results %+v, want the local one first and one per location", got.VantageResults)
+			}
+		})
+	}
+}
+
+func TestVantageSummary(t *testing.T) {
+	r := vantageResult("us-east", false, 0)
+	r.IfcicoResult = &models.ProtocolTestResult{Tested: true, Success: true, ResponseMs: 300}
+	r.TelnetResult = &models.ProtocolTestResult{Tested: true, Success: true, ResponseMs: 90}
+	r.FTPResult = &models.ProtocolTestResult{} // not announced
+	r.IsOperational = true
+
+	got := vantageSummary(r)
+	if got.VantagePoint != "us-east" || !got.IsOperational || got.ResponseMs != 90 || got.Error != "" {
+		t.Errorf("summary %+v: want us-east, operational, 90 ms, no error", got)
+	}
+	if fmt.Sprint(got.ProtocolsOK) != "[ifcico telnet]" || fmt.Sprint(got.ProtocolsFailed) != "[binkp]" {
+		t.Errorf("protocols ok %v failed %v; want [ifcico telnet] and [binkp]", got.ProtocolsOK, got.ProtocolsFailed)
+	}
+}
@@ -88,7 +88,7 @@ func (te *TestExecutor) testMultipleHostnameNode(ctx context.Context, node *mode
 			// Store the partial result. The aggregate is stored by the caller,
 			// which skips storage under -dry-run; these partials have to honour
 			// the same flag or a dry run would still write rows.
-			if !te.daemon.config.Daemon.DryRun {
+			if !te.daemon.config.Daemon.DryRun && te.daemon.storage != nil {
 				if err := te.daemon.storage.StoreTestResult(ctx, result); err != nil {
 					logging.Errorf("Failed to store partial test result for %s (hostname: %s): %v",
 						nodeAddr, hostname, err)
@@ -137,7 +137,7 @@ func (te *TestExecutor) performTesting(ctx context.Context, node *models.Node, h
 	// DNS resolution if hostname is provided
 	if hostname != "" {
 		// Enqueue WHOIS lookup for the domain (non-blocking, runs regardless of DNS result)
-		if regDomain := domain.ExtractRegistrableDomain(hostname); regDomain != "" {
+		if regDomain := domain.ExtractRegistrableDomain(hostname); regDomain != "" && te.daemon.whoisWorker != nil {
 			te.daemon.whoisWorker.Enqueue(regDomain)
 		}
 
@@ -195,6 +195,12 @@ func (te *TestExecutor) performTesting(ctx context.Context, node *models.Node, h
 			te.daemon.testBinkP(ctx, node, result)
 		}
 
+		// BinkP over TLS
+		if node.HasProtocol("IBNS") && te.daemon.binkpTester != nil {
+			logging.Debugf("Testing binkps for %s", nodeAddr)
+			te.daemon.testBinkPS(ctx, node, result)
+		}
+
 		// IFCico/EMSI test
 		if node.HasProtocol("IFC") && te.daemon.ifcicoTester != nil {
 			logging.Debugf("Testing IFCico/EMSI for %s", nodeAddr)
@@ -223,6 +229,9 @@ func (te *TestExecutor) performTesting(ctx context.Context, node *models.Node, h
 	// Determine overall operational status based on test results
 	result.IsOperational = te.determineOperationalStatus(result)
 
+	// Every protocol failed: find out whether the host or the path is down
+	te.daemon.diagnosePath(ctx, node, result)
+
 	// Log connectivity summary using daemon's logging method
 	te.daemon.logConnectivitySummary(nodeAddr, node, result)
 
@@ -236,6 +245,10 @@ func (te *TestExecutor) determineOperationalStatus(result *models.TestResult) bo
 		return true
 	}
 
+	if result.BinkPSResult != nil && result.BinkPSResult.Tested && result.BinkPSResult.Success {
+		return true
+	}
+
 	if result.IfcicoResult != nil && result.IfcicoResult.Tested && result.IfcicoResult.Success {
 		return true
 	}
@@ -0,0 +1,333 @@
+package daemon
+
+import (
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/logging"
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+// Agent API paths, served by the coordinator.
+const (
+	vantageWorkPath    = "/vantage/work"
+	vantageResultsPath = "/vantage/results"
+)
+
+// vantageLongPoll is how long a work request waits for a job before it
+// returns empty and the agent asks again.
+const vantageLongPoll = 25 * time.Second
+
+// vantageQueueSize bounds the jobs waiting for one agent. A node that finds
+// an agent's queue full is tested without it.
+const vantageQueueSize = 256
+
+// vantageJob is a node handed to an agent.
+type vantageJob struct {
+	ID   string       `json:"id"`
+	Node *models.Node `json:"node"`
+}
+
+// vantageReport is an agent's result for a job. The coordinator takes the
+// vantage point from the agent's token, not from the result.
+type vantageReport struct {
+	JobID  string             `json:"job_id"`
+	Result *models.TestResult `json:"result"`
+}
+
+// vantageCoordinator hands the nodes this testdaemon tests to its agents and
+// collects what they report.
+type vantageCoordinator struct {
+	listen          string
+	tlsCert, tlsKey string // empty: plain HTTP
+	timeout         time.Duration
+	tokens          map[string]string // bearer token -> agent name
+
+	mu      sync.Mutex
+	agents  map[string]*vantageAgentState
+	pending map[string]*vantageDispatch
+	nextID  uint64
+
+	server *http.Server
+}
+
+type vantageAgentState struct {
+	queue    chan vantageJob
+	lastSeen time.Time
+}
+
+// vantageDispatch is one node out with the agents.
+type vantageDispatch struct {
+	id      string
+	sent    int             // agents it went to
+	waiting map[string]bool // agents yet to report
+	results chan *models.TestResult
+}
+
+func newVantageCoordinator(cfg VantageConfig) *vantageCoordinator {
+	c := &vantageCoordinator{
+		listen:  cfg.Coordinator.Listen,
+		tlsCert: cfg.Coordinator.TLSCert,
+		tlsKey:  cfg.Coordinator.TLSKey,
+		timeout: cfg.Coordinator.ResultTimeout,
+		tokens:  make(map[string]string, len(cfg.Coordinator.Agents)),
+		agents:  make(map[string]*vantageAgentState, len(cfg.Coordinator.Agents)),
+		pending: make(map[string]*vantageDispatch),
+	}
+	for name, token := range cfg.Coordinator.Agents {
+		c.tokens[token] = name
+		c.agents[name] = &vantageAgentState{queue: make(chan vantageJob, vantageQueueSize)}
+	}
+	return c
+}
+
+// Start serves the agent API until ctx is done.
+func (c *vantageCoordinator) Start(ctx context.Context) error {
+	ln, err := net.Listen("tcp", c.listen)
+	if err != nil {
+		return fmt.Errorf("vantage coordinator: %w", err)
+	}
+	return c.serve(ctx, ln)
+}
+
+// serve serves the agent API on ln, over TLS when a certificate is
+// configured, until ctx is done.
+func (c *vantageCoordinator) serve(ctx context.Context, ln net.Listener) error {
+	c.server = &http.Server{Handler: c.handler(), ReadHeaderTimeout: 10 * time.Second}
+	scheme := "http"
+	if c.tlsCert != "" {
+		cert, err := tls.LoadX509KeyPair(c.tlsCert, c.tlsKey)
+		if err != nil {
+			_ = ln.Close()
+			return fmt.Errorf("vantage coordinator: %w", err)
+		}
+		c.server.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}, MinVersion: tls.VersionTLS12}
+		ln = tls.NewListener(ln, c.server.TLSConfig)
+		scheme = "https"
+	} else {
+		logging.Warnf("Vantage coordinator serves plain HTTP: agent tokens and jobs are readable on the way unless a TLS proxy fronts it")
+	}
+	go func() {
+		if err := c.server.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			logging.Errorf("Vantage coordinator stopped: %v", err)
+		}
+	}()
+	go func() {
+		<-ctx.Done()
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		defer cancel()
+		_ = c.server.Shutdown(shutdownCtx)
+	}()
+	logging.Infof("Vantage coordinator listening on %s://%s for %d agent(s)", scheme, ln.Addr(), len(c.agents))
+	return nil
+}
+
+func (c *vantageCoordinator) handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET "+vantageWorkPath, c.handleWork)
+	mux.HandleFunc("POST "+vantageResultsPath, c.handleResults)
+	return mux
+}
+
+// agentFor authenticates a request and marks its agent as seen.
+func (c *vantageCoordinator) agentFor(r *http.Request) (string, *vantageAgentState, bool) {
+	auth := r.Header.Get("Authorization")
+	if len(auth) < 7 || !strings.EqualFold(auth[:7], "bearer ") {
+		return "", nil, false
+	}
+	token := strings.TrimSpace(auth[7:])
+	var name string
+	for t, n := range c.tokens {
+		if subtle.ConstantTimeCompare([]byte(t), []byte(token)) == 1 {
+			name = n
+		}
+	}
+	if name == "" {
+		return "", nil, false
+	}
+	c.mu.Lock()
+	agent := c.agents[name]
+	agent.lastSeen = time.Now()
+	c.mu.Unlock()
+	return name, agent, true
+}
+
+// Dispatch hands node to every agent that has been in touch recently, and
+// returns nil when there is none.
+func (c *vantageCoordinator) Dispatch(node *models.Node) *vantageDispatch {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.nextID++
+	d := &vantageDispatch{
+		id:      strconv.FormatUint(c.nextID, 10),
+		waiting: make(map[string]bool),
+	}
+	// A copy: the job is encoded later, on the agent's request, while the
+	// caller goes on to test the node itself.
+	nodeCopy := *node
+	job := vantageJob{ID: d.id, Node: &nodeCopy}
+	now := time.Now()
+	for name, agent := range c.agents {
+		// An agent busy testing does not poll, but reports within the
+		// timeout; one not heard from for longer is gone, and every test
+		// would wait the full timeout for it.
+		if now.Sub(agent.lastSeen) > c.timeout {
+			continue
+		}
+		select {
+		case agent.queue <- job:
+			d.waiting[name] = true
+		default:
+			logging.Debugf("Vantage agent %s is %d jobs behind, testing %s without it", name, vantageQueueSize, node.Address())
+		}
+	}
+	if len(d.waiting) == 0 {
+		return nil
+	}
+	d.sent = len(d.waiting)
+	d.results = make(chan *models.TestResult, d.sent)
+	c.pending[d.id] = d
+	return d
+}
+
+// Wait collects the agents' results for a dispatch until all have reported,
+// the result timeout passes or ctx is done, whichever is first.
+func (c *vantageCoordinator) Wait(ctx context.Context, d *vantageDispatch) []*models.TestResult {
+	timer := time.NewTimer(c.timeout)
+	defer timer.Stop()
+
+	var results []*models.TestResult
+	for len(results) < d.sent {
+		select {
+		case r := <-d.results:
+			results = append(results, r)
+		case <-timer.C:
+			c.abandon(d, "timed out")
+			return results
+		case <-ctx.Done():
+			c.abandon(d, "cancelled")
+			return results
+		}
+	}
+	c.mu.Lock()
+	delete(c.pending, d.id)
+	c.mu.Unlock()
+	return results
+}
+
+// abandon stops waiting for a dispatch; a late report is refused.
+func (c *vantageCoordinator) abandon(d *vantageDispatch, why string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.pending, d.id)
+	var missing []string
+	for name := range d.waiting {
+		missing = append(missing, name)
+	}
+	if len(missing) > 0 {
+		logging.Infof("Vantage job %s %s without a report from %s", d.id, why, strings.Join(missing, ", "))
+	}
+}
+
+// handleWork returns up to max jobs (default 1) for the calling agent,
+// waiting up to vantageLongPoll for the first.
+func (c *vantageCoordinator) handleWork(w http.ResponseWriter, r *http.Request) {
+	_, agent, ok := c.agentFor(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	limit := 1
+	if v := r.URL.Query().Get("max"); v != "" {
+		n, err := strconv.Atoi(v)
+		if err != nil || n < 1 {
+			http.Error(w, "max must be a positive integer", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+
+	jobs := []vantageJob{}
+	timer := time.NewTimer(vantageLongPoll)
+	defer timer.Stop()
+	for len(jobs) < limit {
+		var job vantageJob
+		if len(jobs) == 0 {
+			select {
+			case job = <-agent.queue:
+			case <-timer.C:
+				writeVantageJSON(w, jobs)
+				return
+			case <-r.Context().Done():
+				return
+			}
+		} else {
+			select {
+			case job = <-agent.queue:
+			default:
+				writeVantageJSON(w, jobs)
+				return
+			}
+		}
+		// A job whose test gave up on the agents is not worth running.
+		c.mu.Lock()
+		_, live := c.pending[job.ID]
+		c.mu.Unlock()
+		if live {
+			jobs = append(jobs, job)
+		}
+	}
+	writeVantageJSON(w, jobs)
+}
+
+// handleResults accepts an agent's report for one job.
+func (c *vantageCoordinator) handleResults(w http.ResponseWriter, r *http.Request) {
+	name, _, ok := c.agentFor(r)
+	if !ok {
+		http.Error(w, "unauthorized", http.StatusUnauthorized)
+		return
+	}
+	var report vantageReport
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20)).Decode(&report); err != nil || report.Result == nil {
+		http.Error(w, "malformed report", http.StatusBadRequest)
+		return
+	}
+
+	c.mu.Lock()
+	d, live := c.pending[report.JobID]
+	expected := live && d.waiting[name]
+	if expected {
+		delete(d.waiting, name)
+	}
+	c.mu.Unlock()
+
+	switch {
+	case !live:
+		http.Error(w, "job expired", http.StatusGone)
+		return
+	case !expected:
+		http.Error(w, "job already reported", http.StatusConflict)
+		return
+	}
+	report.Result.VantagePoint = name
+	d.results <- report.Result // buffered for every agent it went to
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeVantageJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		logging.Debugf("Vantage coordinator: writing response: %v", err)
+	}
+}
@@ -0,0 +1,152 @@
+package daemon
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/logging"
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+// vantageClient is an agent's side of the coordinator API.
+type vantageClient struct {
+	baseURL string
+	token   string
+	http    *http.Client
+}
+
+func newVantageClient(cfg VantageAgentConfig) *vantageClient {
+	return &vantageClient{
+		baseURL: strings.TrimRight(cfg.CoordinatorURL, "/"),
+		token:   cfg.Token,
+		// Long enough to outlast the coordinator holding a work request
+		http: &http.Client{Timeout: vantageLongPoll + 15*time.Second},
+	}
+}
+
+func (c *vantageClient) do(ctx context.Context, method, path string, body io.Reader) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	return c.http.Do(req)
+}
+
+// fetchWork asks the coordinator for up to max jobs. An empty answer means
+// none came up while the coordinator held the request.
+func (c *vantageClient) fetchWork(ctx context.Context, max int) ([]vantageJob, error) {
+	resp, err := c.do(ctx, http.MethodGet, fmt.Sprintf("%s?max=%d", vantageWorkPath, max), nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("coordinator answered %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	var jobs []vantageJob
+	if err := json.NewDecoder(resp.Body).Decode(&jobs); err != nil {
+		return nil, fmt.Errorf("malformed work from coordinator: %w", err)
+	}
+	return jobs, nil
+}
+
+// report sends the result of one job. A 410 means the coordinator stopped
+// waiting for it; the test was wasted, not the connection.
+func (c *vantageClient) report(ctx context.Context, report vantageReport) error {
+	body, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	resp, err := c.do(ctx, http.MethodPost, vantageResultsPath, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNoContent {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("coordinator answered %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// reportableResult is the part of a result worth sending to the coordinator:
+// protocol details and transcripts stay behind. Details hold typed values
+// that do not survive JSON, and the coordinator keeps its own.
+func reportableResult(r *models.TestResult) *models.TestResult {
+	out := *r
+	out.Transcripts = nil
+	out.VantageResults = nil
+	for _, pr := range []**models.ProtocolTestResult{
+		&out.BinkPResult, &out.BinkPSResult, &out.IfcicoResult,
+		&out.TelnetResult, &out.FTPResult, &out.VModemResult,
+	} {
+		if *pr != nil {
+			stripped := **pr
+			stripped.Details = nil
+			*pr = &stripped
+		}
+	}
+	return &out
+}
+
+// runVantageAgent tests what the coordinator hands out until ctx is done,
+// one long-polling loop per worker.
+func (d *Daemon) runVantageAgent(ctx context.Context) error {
+	client := newVantageClient(d.config.Vantage.Agent)
+	logging.Infof("Running as vantage agent of %s with %d workers", client.baseURL, d.config.Daemon.Workers)
+
+	var wg sync.WaitGroup
+	for i := 0; i < d.config.Daemon.Workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.vantageAgentLoop(ctx, client)
+		}()
+	}
+	wg.Wait()
+	logging.Info("Vantage agent stopping due to context cancellation")
+	return ctx.Err()
+}
+
+func (d *Daemon) vantageAgentLoop(ctx context.Context, client *vantageClient) {
+	retry := d.config.Vantage.Agent.RetryInterval
+	for ctx.Err() == nil {
+		jobs, err := client.fetchWork(ctx, 1)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			logging.Warnf("Vantage agent: %v; retrying in %v", err, retry)
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(retry):
+			}
+			continue
+		}
+		for _, job := range jobs {
+			if job.Node == nil {
+				continue
+			}
+			result, _ := d.testExecutor.TestNodeWithPartials(ctx, job.Node)
+			if result == nil {
+				continue
+			}
+			if err := client.report(ctx, vantageReport{JobID: job.ID, Result: reportableResult(result)}); err != nil {
+				logging.Warnf("Vantage agent: report for %s lost: %v", job.Node.Address(), err)
+			}
+		}
+	}
+}
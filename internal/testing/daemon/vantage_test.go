@@ -0,0 +1,213 @@
+package daemon
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+func newTestCoordinator(t *testing.T, timeout time.Duration) (*vantageCoordinator, *httptest.Server) {
+	t.Helper()
+	c := newVantageCoordinator(VantageConfig{
+		Name: "de-fra",
+		Coordinator: VantageCoordinatorConfig{
+			Agents:        map[string]string{"us-east": "t1"},
+			ResultTimeout: timeout,
+		},
+	})
+	srv := httptest.NewServer(c.handler())
+	t.Cleanup(srv.Close)
+	return c, srv
+}
+
+// checkIn makes the agent known to the coordinator without a long poll: any
+// authenticated request counts, even a report for a job that does not exist.
+func checkIn(t *testing.T, client *vantageClient) {
+	t.Helper()
+	err := client.report(context.Background(), vantageReport{JobID: "none", Result: &models.TestResult{}})
+	if err == nil || !strings.Contains(err.Error(), "410") {
+		t.Fatalf("report for an unknown job: %v, want 410 Gone", err)
+	}
+}
+
+func TestVantageCoordinatorRoundTrip(t *testing.T) {
+	c, srv := newTestCoordinator(t, 5*time.Second)
+	client := newVantageClient(VantageAgentConfig{CoordinatorURL: srv.URL + "/", Token: "t1"})
+	ctx := context.Background()
+	node := &models.Node{Zone: 2, Net: 5020, Node: 1, InternetHostnames: []string{"bbs.example.com"}}
+
+	if d := c.Dispatch(node); d != nil {
+		t.Fatal("dispatched to an agent that has never been in touch")
+	}
+	checkIn(t, client)
+
+	d := c.Dispatch(node)
+	if d == nil {
+		t.Fatal("no dispatch to an agent that checked in")
+	}
+	jobs, err := client.fetchWork(ctx, 5)
+	if err != nil || len(jobs) != 1 || jobs[0].Node.Address() != "2:5020/1" {
+		t.Fatalf("fetchWork = %+v, %v; want the one job for 2:5020/1", jobs, err)
+	}
+
+	// The agent's claim to a vantage point is ignored; its token names it
+	sent := &models.TestResult{VantagePoint: "somewhere-else", IsOperational: true}
+	if err := client.report(ctx, vantageReport{JobID: jobs[0].ID, Result: sent}); err != nil {
+		t.Fatalf("report: %v", err)
+	}
+	got := c.Wait(ctx, d)
+	if len(got) != 1 || got[0].VantagePoint != "us-east" || !got[0].IsOperational {
+		t.Fatalf("Wait = %+v, want one operational result from us-east", got)
+	}
+
+	// The job is done; a second report for it is refused
+	if err := client.report(ctx, vantageReport{JobID: jobs[0].ID, Result: sent}); err == nil {
+		t.Error("second report for a finished job accepted")
+	}
+}
+
+func TestVantageCoordinatorTimesOut(t *testing.T) {
+	c, srv := newTestCoordinator(t, 50*time.Millisecond)
+	client := newVantageClient(VantageAgentConfig{CoordinatorURL: srv.URL, Token: "t1"})
+	checkIn(t, client)
+
+	d := c.Dispatch(&models.Node{Zone: 2, Net: 5020, Node: 1})
+	if d == nil {
+		t.Fatal("no dispatch")
+	}
+	start := time.Now()
+	if got := c.Wait(context.Background(), d); len(got) != 0 {
+		t.Fatalf("Wait = %+v, want nothing from an agent that never reported", got)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("Wait took %v with a 50ms timeout", elapsed)
+	}
+	err := client.report(context.Background(), vantageReport{JobID: d.id, Result: &models.TestResult{}})
+	if err == nil || !strings.Contains(err.Error(), "410") {
+		t.Errorf("late report: %v, want 410 Gone", err)
+	}
+}
+
+func TestVantageCoordinatorRejectsUnknownToken(t *testing.T) {
+	_, srv := newTestCoordinator(t, time.Second)
+	client := newVantageClient(VantageAgentConfig{CoordinatorURL: srv.URL, Token: "wrong"})
+	if _, err := client.fetchWork(context.Background(), 1); err == nil || !strings.Contains(err.Error(), "401") {
+		t.Errorf("fetchWork with a bad token: %v, want 401", err)
+	}
+}
+
+// writeTestCertificate writes a self-signed certificate for 127.0.0.1 and its
+// key as PEM files, and returns their paths and the certificate.
+func writeTestCertificate(t *testing.T) (certFile, keyFile string, cert *x509.Certificate) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "coordinator"},
+		IPAddresses:  []net.IP{net.IPv4(127, 0, 0, 1)},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cert, err = x509.ParseCertificate(der); err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	certFile, keyFile = filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+	if err := os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return certFile, keyFile, cert
+}
+
+func TestVantageCoordinatorServesTLS(t *testing.T) {
+	certFile, keyFile, cert := writeTestCertificate(t)
+	c := newVantageCoordinator(VantageConfig{
+		Name: "de-fra",
+		Coordinator: VantageCoordinatorConfig{
+			Agents:        map[string]string{"us-east": "t1"},
+			ResultTimeout: time.Second,
+			TLSCert:       certFile,
+			TLSKey:        keyFile,
+		},
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := c.serve(ctx, ln); err != nil {
+		t.Fatalf("serve: %v", err)
+	}
+
+	client := newVantageClient(VantageAgentConfig{CoordinatorURL: "https://" + ln.Addr().String(), Token: "t1"})
+	roots := x509.NewCertPool()
+	roots.AddCert(cert)
+	client.http.Transport = &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots}}
+	checkIn(t, client)
+
+	plain := newVantageClient(VantageAgentConfig{CoordinatorURL: "http://" + ln.Addr().String(), Token: "t1"})
+	if _, err := plain.fetchWork(ctx, 1); err == nil {
+		t.Error("coordinator answered plain HTTP")
+	}
+}
+
+func TestVantageCoordinatorRejectsBadCertificate(t *testing.T) {
+	c := newVantageCoordinator(VantageConfig{Coordinator: VantageCoordinatorConfig{
+		TLSCert: filepath.Join(t.TempDir(), "missing.pem"), TLSKey: "missing.key",
+	}})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.serve(context.Background(), ln); err == nil {
+		t.Error("serve started without its certificate")
+	}
+}
+
+func TestReportableResultDropsDetails(t *testing.T) {
+	details := map[string]interface{}{"ipv4": &models.BinkPTestDetails{SystemName: "Test BBS"}}
+	r := &models.TestResult{
+		BinkPResult: &models.ProtocolTestResult{Tested: true, Success: true, Details: details},
+		Transcripts: []models.TestTranscript{{Protocol: "binkp"}},
+	}
+	out := reportableResult(r)
+	if out.BinkPResult.Details != nil || out.Transcripts != nil || !out.BinkPResult.Success {
+		t.Errorf("reportableResult kept details or transcripts, or lost the outcome: %+v", out.BinkPResult)
+	}
+	if r.BinkPResult.Details == nil || r.Transcripts == nil {
+		t.Error("reportableResult modified the result it was given")
+	}
+}
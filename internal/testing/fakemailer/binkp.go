@@ -0,0 +1,96 @@
+package fakemailer
+
+import (
+	"io"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/protocols/binkp"
+)
+
+// answerBinkP runs the answering side of a BinkP session: greeting, CRAM
+// challenge, password check, then the file transfer stage, taking whatever
+// the caller sends and sending nothing back.
+func (m *Mailer) answerBinkP(c net.Conn, s *Session) error {
+	id := m.cfg.Identity
+	session := binkp.NewSessionWithInfo(c, strings.Join(id.Addresses, " "), id.SystemName, id.Sysop, id.Location)
+	session.SetVersion(binkpVersion(id))
+	session.SetTimeout(m.cfg.timeout())
+	session.SetOptions(m.cfg.BinkPOptions)
+
+	switch m.cfg.Fault {
+	case FaultBusy:
+		if err := m.binkpGreetThen(c, &binkp.Frame{Type: binkp.M_BSY, Command: true, Data: []byte("Too many servers")}); err != nil {
+			return err
+		}
+		// Like binkd, let the caller finish its greeting and read the
+		// M_BSY before the line drops.
+		_ = c.SetReadDeadline(time.Now().Add(time.Second))
+		_, _ = io.Copy(io.Discard, c)
+		return nil
+	case FaultHangup:
+		if err := m.binkpGreetThen(c, nil); err != nil {
+			return err
+		}
+		return errHangup
+	case FaultMalformed:
+		if err := m.binkpGreetThen(c, nil); err != nil {
+			return err
+		}
+		// A command frame announcing 32767 bytes, then the line drops
+		// with only a few of them sent.
+		_, _ = c.Write([]byte{0xFF, 0xFF, binkp.M_NUL, 'S', 'Y', 'S'})
+		return errHangup
+	}
+
+	err := session.Answer(func(addresses []string) string {
+		s.Addresses = addresses
+		return id.Password
+	})
+	s.Mailer = session.GetNodeInfo().Version
+	if err != nil {
+		return err
+	}
+	_, err = session.Transfer(nil, func(binkp.FileInfo) io.Writer {
+		s.Files++
+		return io.Discard
+	})
+	_ = session.Close()
+	return err
+}
+
+// binkpGreetThen sends the greeting a BinkP answerer opens with, then last
+// when it is not nil.
+func (m *Mailer) binkpGreetThen(c net.Conn, last *binkp.Frame) error {
+	id := m.cfg.Identity
+	frames := []*binkp.Frame{
+		binkp.CreateM_NUL("SYS", id.SystemName),
+		binkp.CreateM_NUL("ZYZ", id.Sysop),
+		binkp.CreateM_NUL("LOC", id.Location),
+		binkp.CreateM_NUL("VER", binkpVersion(id)),
+		binkp.CreateM_NUL("TIME", time.Now().Format(time.RFC822)),
+		binkp.CreateM_ADR(id.Addresses...),
+	}
+	if last != nil {
+		frames = append(frames, last)
+	}
+	for _, f := range frames {
+		if err := binkp.WriteFrame(c, f); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// binkpVersion is the M_NUL VER id announces, binkd style:
+// "binkd/1.1a-115 binkp/1.1".
+func binkpVersion(id Identity) string {
+	if id.Mailer == "" {
+		return "binkp/1.1"
+	}
+	if id.Version == "" {
+		return id.Mailer + " binkp/1.1"
+	}
+	return id.Mailer + "/" + id.Version + " binkp/1.1"
+}
@@ -0,0 +1,229 @@
+package fakemailer
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The fixed EMSI sequences of FSC-0056, each with its CRC-16 suffix.
+const (
+	emsiINQ = "**EMSI_INQC816"
+	emsiREQ = "**EMSI_REQA77E"
+	emsiACK = "**EMSI_ACKA490"
+	emsiNAK = "**EMSI_NAKEEC3"
+)
+
+// emsiDATRetries is how many times our EMSI_DAT goes out before we give up on
+// a caller that keeps answering it with EMSI_NAK.
+const emsiDATRetries = 3
+
+// answerEMSI runs the answering side of an EMSI handshake over c: EMSI_REQ
+// (after the banner) to invite the caller, EMSI_ACK for its EMSI_DAT, then our
+// own EMSI_DAT until the caller acknowledges it. An EMSI_INQ is answered with
+// another EMSI_REQ, at most once a second so a caller that repeats its INQ
+// does not get a REQ for each.
+func (m *Mailer) answerEMSI(c net.Conn, s *Session) error {
+	var lastREQ time.Time
+	greet := func() error {
+		out := m.cfg.Banner
+		if out != "" && !strings.HasSuffix(out, "\r") && !strings.HasSuffix(out, "\n") {
+			out += "\r\n"
+		}
+		lastREQ = time.Now()
+		_, err := c.Write([]byte(out + emsiREQ + "\r"))
+		return err
+	}
+	if !m.cfg.Quiet {
+		if err := greet(); err != nil {
+			return err
+		}
+	}
+
+	ours := encodeEMSIDAT(m.cfg.Identity, m.cfg.Fault == FaultMalformed)
+	var buf []byte
+	datSent := 0
+	chunk := make([]byte, 1024)
+	for {
+		for {
+			kind, pkt, rest, ok := nextEMSI(buf)
+			buf = rest
+			if !ok {
+				break
+			}
+			switch kind {
+			case "INQ":
+				if datSent == 0 && time.Since(lastREQ) > time.Second {
+					if err := greet(); err != nil {
+						return err
+					}
+				}
+			case "DAT":
+				if pkt == nil {
+					if _, err := c.Write([]byte(emsiNAK + "\r")); err != nil {
+						return err
+					}
+					continue
+				}
+				s.Addresses, s.Mailer = parseEMSIDAT(pkt)
+				if m.cfg.Fault == FaultHangup {
+					return errHangup
+				}
+				if _, err := c.Write([]byte(emsiACK + "\r" + emsiACK + "\r" + ours)); err != nil {
+					return err
+				}
+				datSent = 1
+			case "NAK":
+				if datSent == 0 {
+					continue
+				}
+				if datSent == emsiDATRetries {
+					return errors.New("caller rejected our EMSI_DAT")
+				}
+				if _, err := c.Write([]byte(ours)); err != nil {
+					return err
+				}
+				datSent++
+			case "ACK":
+				if datSent > 0 {
+					// Handshake done. A real mailer would start the file
+					// transfer now; we wait for the caller to hang up.
+					_, _ = c.Read(chunk)
+					return nil
+				}
+			}
+		}
+		n, err := c.Read(chunk)
+		if n > 0 && m.cfg.Quiet && lastREQ.IsZero() {
+			if err := greet(); err != nil {
+				return err
+			}
+		}
+		buf = append(buf, chunk[:n]...)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// nextEMSI finds the first complete EMSI sequence in buf and returns its kind
+// ("INQ", "DAT", ...) and the unconsumed rest. For a DAT, pkt is the packet
+// from "EMSI_DAT" up to the CRC, or nil when the CRC does not match. ok is
+// false when buf holds no complete sequence yet, in which case rest keeps
+// whatever may be the start of one.
+func nextEMSI(buf []byte) (kind string, pkt []byte, rest []byte, ok bool) {
+	i := strings.Index(string(buf), "**EMSI_")
+	if i < 0 {
+		// Keep a tail long enough to hold a split "**EMSI_".
+		if len(buf) > 6 {
+			buf = buf[len(buf)-6:]
+		}
+		return "", nil, buf, false
+	}
+	seq := buf[i+2:] // "EMSI_XXX..."
+	if len(seq) < 12 {
+		return "", nil, buf[i:], false
+	}
+	kind = string(seq[5:8])
+	if kind != "DAT" {
+		return kind, nil, seq[12:], true
+	}
+	n, err := strconv.ParseUint(string(seq[8:12]), 16, 16)
+	if err != nil {
+		return kind, nil, seq[12:], true
+	}
+	end := 12 + int(n)
+	if len(seq) < end+4 {
+		return "", nil, buf[i:], false
+	}
+	crc, err := strconv.ParseUint(string(seq[end:end+4]), 16, 16)
+	if err != nil || uint16(crc) != crc16(seq[:end]) {
+		return kind, nil, seq[end+4:], true
+	}
+	return kind, seq[:end], seq[end+4:], true
+}
+
+// encodeEMSIDAT builds the EMSI_DAT packet for id, ready to send. badCRC
+// corrupts the CRC so the caller has to reject it.
+func encodeEMSIDAT(id Identity, badCRC bool) string {
+	data := fmt.Sprintf("{EMSI}{%s}{%s}{8N1,PUA}{ZAP,ZMO,ARC,XMA}{fe}{%s}{%s}{1}{IDENT}{[%s][%s][%s][-Unpublished-][33600][XA,IFC]}",
+		emsiEscape(strings.Join(id.Addresses, " "), '}'), emsiEscape(id.Password, '}'),
+		emsiEscape(id.Mailer, '}'), emsiEscape(id.Version, '}'),
+		emsiEscape(emsiEscape(id.SystemName, ']'), '}'),
+		emsiEscape(emsiEscape(id.Location, ']'), '}'),
+		emsiEscape(emsiEscape(id.Sysop, ']'), '}'))
+	pkt := fmt.Sprintf("EMSI_DAT%04X%s", len(data), data)
+	crc := crc16([]byte(pkt))
+	if badCRC {
+		crc ^= 0xFFFF
+	}
+	return fmt.Sprintf("**%s%04X\r", pkt, crc)
+}
+
+// parseEMSIDAT reads the caller's addresses and product name out of an
+// EMSI_DAT packet as returned by nextEMSI.
+func parseEMSIDAT(pkt []byte) (addresses []string, mailer string) {
+	if len(pkt) < 12 {
+		return nil, ""
+	}
+	f := emsiFields(string(pkt[12:]), '{', '}')
+	if len(f) > 1 {
+		addresses = strings.Fields(f[1])
+	}
+	if len(f) > 7 {
+		mailer = strings.TrimSpace(f[6] + " " + f[7])
+	}
+	return addresses, mailer
+}
+
+// emsiEscape doubles close so it survives inside a field that close ends.
+func emsiEscape(s string, close byte) string {
+	c := string(close)
+	return strings.ReplaceAll(s, c, c+c)
+}
+
+// emsiFields splits s into the fields between open and close, undoing
+// emsiEscape.
+func emsiFields(s string, open, close byte) []string {
+	var out []string
+	for i := 0; i < len(s); i++ {
+		if s[i] != open {
+			continue
+		}
+		var b strings.Builder
+		j := i + 1
+		for ; j < len(s); j++ {
+			if s[j] == close {
+				if j+1 < len(s) && s[j+1] == close {
+					b.WriteByte(close)
+					j++
+					continue
+				}
+				break
+			}
+			b.WriteByte(s[j])
+		}
+		out = append(out, b.String())
+		i = j
+	}
+	return out
+}
+
+// crc16 is the CRC-16/XMODEM EMSI packets carry.
+func crc16(b []byte) uint16 {
+	var crc uint16
+	for _, c := range b {
+		crc ^= uint16(c) << 8
+		for range 8 {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
@@ -0,0 +1,263 @@
+// Package fakemailer runs an in-process FidoNet mailer on a loopback port so
+// the protocol testers can be exercised end to end without network access.
+//
+// A Mailer answers one protocol the way a node would on the matching nodelist
+// flag: BinkP (IBN), EMSI on a raw TCP port (IFC), EMSI behind telnet-binary
+// negotiation (ITN, and most IVM ports in practice), a telnet BBS login and
+// FTP (IFT). It does not speak VMP: for IVM it can only stand in for a
+// VMODEM with calls disabled, which says nothing and hangs up once the
+// caller speaks. Its identity, AKAs, timing and the
+// faults it commits are all configurable, and it records what each caller
+// presented so tests can assert on both sides of a session.
+package fakemailer
+
+import (
+	"errors"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/protocols/binkp"
+)
+
+// Protocol is what a Mailer answers with.
+type Protocol string
+
+const (
+	BinkP      Protocol = "binkp"       // FTS-1026 answering side
+	EMSI       Protocol = "emsi"        // FSC-0056 answering side on raw TCP
+	TelnetEMSI Protocol = "telnet-emsi" // EMSI after telnet-binary negotiation
+	TelnetBBS  Protocol = "telnet-bbs"  // telnet login prompt, no mailer behind it
+	Silent     Protocol = "silent"      // says nothing, hangs up once the caller speaks
+	FTP        Protocol = "ftp"         // FTP server with optional anonymous login
+)
+
+// Fault is something a Mailer does wrong on purpose.
+type Fault string
+
+const (
+	FaultNone Fault = ""
+	// FaultRefuse closes every connection as soon as it is accepted.
+	FaultRefuse Fault = "refuse"
+	// FaultSilent accepts and then never sends a byte.
+	FaultSilent Fault = "silent"
+	// FaultHangup drops the line part way through: after the caller's
+	// EMSI_DAT, after the BinkP greeting, or after the FTP or telnet banner.
+	FaultHangup Fault = "hangup"
+	// FaultMalformed sends broken frames: an EMSI_DAT whose CRC does not
+	// match, a BinkP frame cut short of its declared length, an FTP banner
+	// without a reply code.
+	FaultMalformed Fault = "malformed"
+	// FaultBusy answers BinkP with M_BSY. Other protocols ignore it.
+	FaultBusy Fault = "busy"
+)
+
+// Identity is who the fake node claims to be.
+type Identity struct {
+	Addresses  []string // AKAs, the first being the main address
+	SystemName string
+	Sysop      string
+	Location   string
+	Mailer     string // product name, e.g. "binkd" or "FrontDoor"
+	Version    string
+	Password   string // BinkP session password; "" takes any caller non-secure
+}
+
+// Config describes a fake node.
+type Config struct {
+	Protocol Protocol
+	Identity Identity
+	// Banner is sent ahead of the protocol's own greeting: the text a mailer
+	// prints before EMSI_REQ, the telnet BBS welcome, the FTP 220 text.
+	Banner string
+	// Quiet holds the EMSI greeting back until the caller speaks, as the
+	// telnet-binary mailers that wait for an EMSI_INQ do.
+	Quiet bool
+	// GreetDelay is waited out before the first byte is sent.
+	GreetDelay time.Duration
+	// ReplyDelay is added before every write.
+	ReplyDelay time.Duration
+	Fault      Fault
+	// BinkPOptions are the extensions offered in a BinkP session.
+	BinkPOptions binkp.Options
+	// AnonFTP lets "anonymous" log in to the FTP server.
+	AnonFTP bool
+	// Timeout bounds each session. Zero means 10s.
+	Timeout time.Duration
+}
+
+func (c Config) timeout() time.Duration {
+	if c.Timeout > 0 {
+		return c.Timeout
+	}
+	return 10 * time.Second
+}
+
+// Session is what one caller presented.
+type Session struct {
+	Addresses []string // AKAs from the caller's EMSI_DAT or M_ADR
+	Mailer    string   // the caller's product name, when it sent one
+	Received  []byte   // application bytes read from the caller, capped at 4 KiB
+	Files     int      // BinkP files received
+	Err       error    // why the session ended early; nil when it completed
+}
+
+// maxReceived caps Session.Received.
+const maxReceived = 4096
+
+// Mailer is a running fake node.
+type Mailer struct {
+	cfg Config
+	ln  net.Listener
+
+	mu       sync.Mutex
+	sessions []Session
+	wg       sync.WaitGroup
+}
+
+// Start runs a fake node on an ephemeral loopback port.
+func Start(cfg Config) (*Mailer, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	return Serve(ln, cfg), nil
+}
+
+// Serve runs a fake node on a listener of the caller's choosing, e.g. one
+// wrapped in TLS. The Mailer owns ln from here on.
+func Serve(ln net.Listener, cfg Config) *Mailer {
+	m := &Mailer{cfg: cfg, ln: ln}
+	m.wg.Add(1)
+	go m.accept()
+	return m
+}
+
+// Host returns the address the node listens on.
+func (m *Mailer) Host() string {
+	host, _, _ := net.SplitHostPort(m.ln.Addr().String())
+	return host
+}
+
+// Port returns the port the node listens on.
+func (m *Mailer) Port() int {
+	_, port, _ := net.SplitHostPort(m.ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return p
+}
+
+// Close stops listening and waits for sessions in progress to end.
+func (m *Mailer) Close() error {
+	err := m.ln.Close()
+	m.wg.Wait()
+	return err
+}
+
+// Sessions returns the sessions that have ended, oldest first.
+func (m *Mailer) Sessions() []Session {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return append([]Session(nil), m.sessions...)
+}
+
+func (m *Mailer) accept() {
+	defer m.wg.Done()
+	for {
+		conn, err := m.ln.Accept()
+		if err != nil {
+			return
+		}
+		m.wg.Add(1)
+		go func() {
+			defer m.wg.Done()
+			defer conn.Close()
+			s := m.serve(conn)
+			m.mu.Lock()
+			m.sessions = append(m.sessions, s)
+			m.mu.Unlock()
+		}()
+	}
+}
+
+// errHangup is the Session.Err of a session FaultHangup cut short.
+var errHangup = errors.New("hung up on purpose")
+
+func (m *Mailer) serve(conn net.Conn) Session {
+	var s Session
+	if m.cfg.Fault == FaultRefuse {
+		s.Err = errHangup
+		return s
+	}
+	_ = conn.SetDeadline(time.Now().Add(m.cfg.timeout()))
+	time.Sleep(m.cfg.GreetDelay)
+
+	raw := &recordingConn{Conn: conn, delay: m.cfg.ReplyDelay}
+	if m.cfg.Fault == FaultSilent {
+		_, _ = io.Copy(io.Discard, recorded(raw, &s))
+		return s
+	}
+
+	var err error
+	switch m.cfg.Protocol {
+	case BinkP:
+		err = m.answerBinkP(raw, &s)
+	case EMSI:
+		err = m.answerEMSI(recorded(raw, &s), &s)
+	case TelnetEMSI:
+		tn := newTelnetConn(raw)
+		if err = tn.negotiate(); err == nil {
+			err = m.answerEMSI(recorded(tn, &s), &s)
+		}
+	case TelnetBBS:
+		tn := newTelnetConn(raw)
+		if err = tn.negotiate(); err == nil {
+			err = m.answerBBS(recorded(tn, &s))
+		}
+	case Silent:
+		err = m.answerSilent(recorded(raw, &s))
+	case FTP:
+		err = m.answerFTP(recorded(raw, &s))
+	default:
+		err = errors.New("fakemailer: unknown protocol " + string(m.cfg.Protocol))
+	}
+	s.Err = err
+	return s
+}
+
+// answerSilent waits for the caller's first bytes and hangs up without a
+// word.
+func (m *Mailer) answerSilent(c net.Conn) error {
+	_, err := c.Read(make([]byte, 512))
+	return err
+}
+
+// recordingConn keeps what the caller sent in s, when set, and delays what
+// we send by delay.
+type recordingConn struct {
+	net.Conn
+	s     *Session
+	delay time.Duration
+}
+
+// recorded keeps what is read from c in s.Received.
+func recorded(c net.Conn, s *Session) net.Conn {
+	return &recordingConn{Conn: c, s: s}
+}
+
+func (r *recordingConn) Read(p []byte) (int, error) {
+	n, err := r.Conn.Read(p)
+	if r.s == nil {
+		return n, err
+	}
+	if room := maxReceived - len(r.s.Received); room > 0 {
+		r.s.Received = append(r.s.Received, p[:min(n, room)]...)
+	}
+	return n, err
+}
+
+func (r *recordingConn) Write(p []byte) (int, error) {
+	time.Sleep(r.delay)
+	return r.Conn.Write(p)
+}
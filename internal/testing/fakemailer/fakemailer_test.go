@@ -0,0 +1,123 @@
+package fakemailer
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestEMSISequenceCRCs(t *testing.T) {
+	for _, seq := range []string{emsiINQ, emsiREQ, emsiACK, emsiNAK} {
+		body, suffix := seq[2:10], seq[10:]
+		if got := fmt.Sprintf("%04X", crc16([]byte(body))); got != suffix {
+			t.Errorf("crc16(%q) = %s, want %s", body, got, suffix)
+		}
+	}
+}
+
+func TestEMSIDATRoundTrip(t *testing.T) {
+	id := Identity{
+		Addresses:  []string{"2:5020/1@fidonet", "21:1/100@fsxnet"},
+		SystemName: "Brace}}s [BBS]",
+		Sysop:      "Jane Doe",
+		Location:   "Moscow",
+		Mailer:     "FrontDoor",
+		Version:    "2.33",
+	}
+	kind, pkt, rest, ok := nextEMSI([]byte("junk" + encodeEMSIDAT(id, false) + "tail"))
+	if !ok || kind != "DAT" || pkt == nil {
+		t.Fatalf("nextEMSI = %q, %q, ok=%v", kind, pkt, ok)
+	}
+	if string(rest) != "\rtail" {
+		t.Errorf("rest = %q", rest)
+	}
+	addresses, mailer := parseEMSIDAT(pkt)
+	if !reflect.DeepEqual(addresses, id.Addresses) || mailer != "FrontDoor 2.33" {
+		t.Errorf("parsed %v, %q", addresses, mailer)
+	}
+	ident := emsiFields(string(pkt), '{', '}')
+	if got := emsiFields(ident[len(ident)-1], '[', ']'); got[0] != id.SystemName {
+		t.Errorf("system name = %q, want %q", got[0], id.SystemName)
+	}
+
+	if kind, pkt, _, ok := nextEMSI([]byte(encodeEMSIDAT(id, true))); !ok || kind != "DAT" || pkt != nil {
+		t.Errorf("corrupt CRC: nextEMSI = %q, %q, ok=%v; want a DAT with no packet", kind, pkt, ok)
+	}
+}
+
+func TestNextEMSISplitAcrossReads(t *testing.T) {
+	full := []byte("banner\r\n" + emsiREQ + "\r")
+	for cut := 1; cut < len(full); cut++ {
+		_, _, rest, ok := nextEMSI(full[:cut])
+		if ok {
+			continue // the cut left a complete sequence
+		}
+		kind, _, _, ok := nextEMSI(append(append([]byte(nil), rest...), full[cut:]...))
+		if !ok || kind != "REQ" {
+			t.Fatalf("cut at %d: kind=%q ok=%v", cut, kind, ok)
+		}
+	}
+}
+
+func TestTelnetConnRead(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	tn := newTelnetConn(server)
+	go func() {
+		_, _ = client.Write([]byte{tnIAC, tnDO, tnOptBinary})
+		_, _ = client.Write([]byte{'a', tnIAC})
+		_, _ = client.Write([]byte{tnIAC, tnIAC, tnSB, 24, 'x', tnIAC, tnSE, 'b'})
+	}()
+	var got []byte
+	buf := make([]byte, 16)
+	for len(got) < 3 {
+		n, err := tn.Read(buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got = append(got, buf[:n]...)
+	}
+	if want := []byte{'a', tnIAC, 'b'}; !bytes.Equal(got, want) {
+		t.Errorf("decoded %v, want %v", got, want)
+	}
+}
+
+func TestMailerRecordsSessions(t *testing.T) {
+	m, err := Start(Config{Protocol: TelnetBBS, Identity: Identity{SystemName: "Test BBS"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c, err := net.Dial("tcp", net.JoinHostPort(m.Host(), fmt.Sprint(m.Port())))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_ = c.SetDeadline(time.Now().Add(5 * time.Second))
+	var greeting []byte
+	buf := make([]byte, 256)
+	for !strings.Contains(string(greeting), "login: ") {
+		n, err := c.Read(buf)
+		if err != nil {
+			t.Fatalf("greeting %q: %v", greeting, err)
+		}
+		greeting = append(greeting, buf[:n]...)
+	}
+	if !strings.Contains(string(greeting), "Welcome to Test BBS") {
+		t.Errorf("greeting = %q", greeting)
+	}
+	_, _ = c.Write([]byte("sysop\xff\xff\r\n"))
+	time.Sleep(100 * time.Millisecond)
+	c.Close()
+	m.Close()
+
+	sessions := m.Sessions()
+	if len(sessions) != 1 {
+		t.Fatalf("%d sessions, want 1", len(sessions))
+	}
+	if got := string(sessions[0].Received); got != "sysop\xff\r\n" {
+		t.Errorf("Received = %q", got)
+	}
+}
@@ -0,0 +1,61 @@
+package fakemailer
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+)
+
+// answerFTP runs enough of an FTP server to greet, take a login and say
+// goodbye. Anything past that is answered 502.
+func (m *Mailer) answerFTP(c net.Conn) error {
+	banner := m.cfg.Banner
+	if banner == "" {
+		banner = m.cfg.Identity.SystemName + " FTP server ready"
+	}
+	if m.cfg.Fault == FaultMalformed {
+		_, err := fmt.Fprintf(c, "%s\r\n", banner)
+		return err
+	}
+	if _, err := fmt.Fprintf(c, "220 %s\r\n", banner); err != nil {
+		return err
+	}
+	if m.cfg.Fault == FaultHangup {
+		return errHangup
+	}
+
+	r := bufio.NewReader(c)
+	var user string
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return nil
+		}
+		cmd, arg, _ := strings.Cut(strings.TrimRight(line, "\r\n"), " ")
+		var reply string
+		switch strings.ToUpper(cmd) {
+		case "USER":
+			user = arg
+			if strings.EqualFold(user, "anonymous") && !m.cfg.AnonFTP {
+				reply = "530 Anonymous access not allowed"
+			} else {
+				reply = "331 Password required for " + user
+			}
+		case "PASS":
+			if strings.EqualFold(user, "anonymous") && m.cfg.AnonFTP {
+				reply = "230 Anonymous access granted"
+			} else {
+				reply = "530 Login incorrect"
+			}
+		case "QUIT":
+			_, err := fmt.Fprintf(c, "221 Goodbye\r\n")
+			return err
+		default:
+			reply = "502 Command not implemented"
+		}
+		if _, err := fmt.Fprintf(c, "%s\r\n", reply); err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package fakemailer
+
+import (
+	"net"
+	"strings"
+)
+
+// Telnet bytes (RFC 854 / RFC 856) the server side needs.
+const (
+	tnIAC  = 255
+	tnDONT = 254
+	tnDO   = 253
+	tnWONT = 252
+	tnWILL = 251
+	tnSB   = 250
+	tnSE   = 240
+
+	tnOptBinary = 0
+	tnOptEcho   = 1
+	tnOptSGA    = 3
+)
+
+// telnetConn is the server end of a telnet session: reads come back with the
+// telnet commands stripped and IAC IAC unescaped, writes have IAC doubled.
+// Option requests from the caller are read past, not answered; the options
+// we open with are the only ones the session uses.
+type telnetConn struct {
+	net.Conn
+	pending []byte // undecoded tail of the last read
+	sb      bool   // inside IAC SB ... IAC SE
+}
+
+func newTelnetConn(c net.Conn) *telnetConn {
+	return &telnetConn{Conn: c}
+}
+
+// negotiate opens the session the way BBS and mailer telnet servers do:
+// binary both ways, suppress go-ahead and server-side echo.
+func (t *telnetConn) negotiate() error {
+	_, err := t.Conn.Write([]byte{
+		tnIAC, tnWILL, tnOptBinary,
+		tnIAC, tnDO, tnOptBinary,
+		tnIAC, tnWILL, tnOptSGA,
+		tnIAC, tnWILL, tnOptEcho,
+	})
+	return err
+}
+
+func (t *telnetConn) Read(p []byte) (int, error) {
+	for {
+		buf := make([]byte, len(p))
+		n, err := t.Conn.Read(buf)
+		data := append(t.pending, buf[:n]...)
+		t.pending = nil
+		out := p[:0]
+		for i := 0; i < len(data); i++ {
+			b := data[i]
+			if b != tnIAC {
+				if !t.sb {
+					out = append(out, b)
+				}
+				continue
+			}
+			if i+1 >= len(data) {
+				t.pending = append(t.pending, data[i:]...)
+				break
+			}
+			switch cmd := data[i+1]; cmd {
+			case tnIAC:
+				if !t.sb {
+					out = append(out, tnIAC)
+				}
+				i++
+			case tnWILL, tnWONT, tnDO, tnDONT:
+				if i+2 >= len(data) {
+					t.pending = append(t.pending, data[i:]...)
+					i = len(data)
+					continue
+				}
+				i += 2
+			case tnSB:
+				t.sb = true
+				i++
+			case tnSE:
+				t.sb = false
+				i++
+			default:
+				i++
+			}
+		}
+		// A read made only of telnet commands is not an answer; go again
+		// rather than hand back zero bytes.
+		if len(out) > 0 || err != nil {
+			return len(out), err
+		}
+	}
+}
+
+func (t *telnetConn) Write(p []byte) (int, error) {
+	esc := strings.ReplaceAll(string(p), "\xff", "\xff\xff")
+	if _, err := t.Conn.Write([]byte(esc)); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// answerBBS prints a login prompt and reads whatever the caller types until
+// it hangs up. It never speaks EMSI, whatever it is sent.
+func (m *Mailer) answerBBS(c net.Conn) error {
+	banner := m.cfg.Banner
+	if banner == "" {
+		banner = "Welcome to " + m.cfg.Identity.SystemName + "\r\n"
+	}
+	if _, err := c.Write([]byte(banner + "login: ")); err != nil {
+		return err
+	}
+	if m.cfg.Fault == FaultHangup {
+		return errHangup
+	}
+	buf := make([]byte, 512)
+	for {
+		if _, err := c.Read(buf); err != nil {
+			return nil
+		}
+	}
+}
@@ -44,6 +44,9 @@ type TestResult struct {
 	TelnetResult *ProtocolTestResult
 	FTPResult    *ProtocolTestResult
 	VModemResult *ProtocolTestResult
+	// BinkPSResult is BinkP over TLS on the IBNS port. Its details are
+	// BinkPTestDetails with Certificate set.
+	BinkPSResult *ProtocolTestResult
 
 	// Summary flags
 	IsOperational         bool
@@ -61,8 +64,108 @@ type TestResult struct {
 	TotalHostnames       int32 // Total number of hostnames for this node
 	HostnamesTested      int32 // Number of hostnames actually tested
 	HostnamesOperational int32 // Number of operational hostnames
+
+	// PathDiagnostic is set when every protocol failed and the path probe
+	// ran: it tells a host that is down from a network path that is broken.
+	PathDiagnostic *PathDiagnostic
+
+	// UptimeWindow is the node's learned reachable hours ("22-06 UTC"),
+	// empty when its history shows no daily pattern. IntermittentBySchedule
+	// marks a failure that fell outside that window: the node keeps hours,
+	// it is not down.
+	UptimeWindow           string
+	IntermittentBySchedule bool
+
+	// VantagePoint names the location a result was tested from, when
+	// testing is spread over several (see daemon.VantageConfig).
+	VantagePoint string
+	// On the combined result: how many locations tested the node, how many
+	// reached it, and the VantageVerdict that follows. VantageResults are
+	// the per-location summaries, stored in node_test_vantage_results.
+	VantagePointsTested    int32
+	VantagePointsReachable int32
+	VantageVerdict         string
+	VantageResults         []VantageResult
+
+	// Transcripts are the wire recordings kept from this test's BinkP,
+	// binkps and IFCICO sessions. Stored in node_test_transcripts, not in
+	// node_test_results.
+	Transcripts []TestTranscript
+}
+
+// TestTranscript is one recorded protocol session of a test.
+type TestTranscript struct {
+	Protocol  string // binkp | binkps | ifcico
+	RemoteIP  string
+	Success   bool
+	Error     string
+	Started   time.Time
+	RawBytes  int    // bytes recorded, both directions
+	Truncated bool   // the session outran the recording limit
+	Data      []byte // transcript.Transcript, encoded
+}
+
+// VantageResult is what one location saw of a node.
+type VantageResult struct {
+	VantagePoint   string
+	TestTime       time.Time
+	IsOperational  bool
+	TestedHostname string
+	DNSError       string
+	// Protocols that answered and that were tried without an answer, by
+	// column name ("binkp", "ifcico", ...).
+	ProtocolsOK     []string
+	ProtocolsFailed []string
+	ResponseMs      uint32 // fastest answering protocol
+	Error           string // first protocol error, when nothing answered
 }
 
+// Vantage verdicts. A node tested from a single location has none.
+const (
+	VantageVerdictReachable   = "reachable"   // every location reached it
+	VantageVerdictRegional    = "regional"    // some did, some did not
+	VantageVerdictUnreachable = "unreachable" // none did
+)
+
+// PathDiagnostic is what the path probe found for a node no protocol test
+// reached: whether its port answers a TCP SYN, and how far towards it the
+// network path can be followed.
+type PathDiagnostic struct {
+	IP   string
+	Port int
+	TCP  string // open | refused | timeout | unreachable | error
+	// Hops are the routers that answered, by TTL from 1; "" for a silent
+	// hop. The path ends at the last one that answered.
+	Hops       []string
+	LastHop    string
+	LastHopTTL int
+	LastHopASN uint32
+	LastHopAS  string // AS name or ISP of LastHop, when known
+	Verdict    string // one of the PathVerdict constants
+}
+
+// Path probe verdicts.
+const (
+	// PathVerdictOpen: the TCP handshake completed, so the failure is in the
+	// mailer above TCP, not in the network.
+	PathVerdictOpen = "open"
+	// PathVerdictRefused: the host answered with a reset; it is up and
+	// nothing listens on the port.
+	PathVerdictRefused = "refused"
+	// PathVerdictFiltered: the host itself answered the path probe but the
+	// port stayed silent, which is a firewall.
+	PathVerdictFiltered = "filtered"
+	// PathVerdictHostDown: the path reaches the host's own network (its
+	// ASN) and stops there.
+	PathVerdictHostDown = "host-down"
+	// PathVerdictPathBroken: the path stops in another network before
+	// reaching the host's.
+	PathVerdictPathBroken = "path-broken"
+	// PathVerdictUnknown: no hop answered, or the last one's network is
+	// not known, so the two cannot be told apart.
+	PathVerdictUnknown = "unknown"
+)
+
 // ProtocolTestResult represents test result for a specific protocol
 type ProtocolTestResult struct {
 	// Overall results (backward compatible)
@@ -128,6 +231,51 @@ type BinkPTestDetails struct {
 	Version      string
 	Addresses    []string
 	Capabilities []string
+	// Set only for nodes we hold a session password for: how the session
+	// authenticated, and how the probe transfer came out (see
+	// protocols.BinkPTestResult).
+	AuthMethod   string
+	MailExchange string
+	MailDetail   string
+	// Verdict on each option the remote advertised: "", ok, failed or
+	// untested.
+	OptCRAM  string
+	OptCrypt string
+	OptPLZ   string
+	OptGZ    string
+	OptBZ2   string
+	// Certificate is what a binkps server presented; nil on plain BinkP.
+	Certificate *TLSCertificate
+}
+
+// TLSCertificate describes the certificate a TLS service presented.
+type TLSCertificate struct {
+	Subject    string
+	Issuer     string
+	NotAfter   time.Time
+	ChainValid bool // chains to a trusted root, for the host name tested, and is in date
+	SelfSigned bool
+	Error      string // why the chain did not verify
+}
+
+// BinkPSDetails picks the binkps session whose certificate represents this
+// result, by the same rule as VModemDetails.
+func (pr *ProtocolTestResult) BinkPSDetails() *BinkPTestDetails {
+	if pr == nil {
+		return nil
+	}
+	ipv6, _ := pr.Details["ipv6"].(*BinkPTestDetails)
+	ipv4, _ := pr.Details["ipv4"].(*BinkPTestDetails)
+	switch {
+	case pr.IPv6Success && ipv6 != nil:
+		return ipv6
+	case pr.IPv4Success && ipv4 != nil:
+		return ipv4
+	case ipv6 != nil:
+		return ipv6
+	default:
+		return ipv4
+	}
 }
 
 // IfcicoTestDetails contains IFCICO-specific test details
@@ -138,6 +286,17 @@ type IfcicoTestDetails struct {
 	ResponseType string // REQ/ACK/NAK/CLI/HBT
 }
 
+// TelnetTestDetails contains Telnet-specific test details. Everything but
+// Banner is empty unless an EMSI mailer answered on the ITN port.
+type TelnetTestDetails struct {
+	Banner       string
+	MailerInfo   string
+	SystemName   string
+	Sysop        string
+	Addresses    []string
+	ResponseType string // "EMSI" once an EMSI identity was read
+}
+
 // VModemTestDetails contains VModem/IVM-specific test details. Variant is the
 // protocol actually observed on the announced IVM port; Conformant is true only
 // when a genuine VMODEM (VMP) responder was confirmed.
@@ -170,6 +329,22 @@ type AnnouncedAKARecord struct {
 	Announced []string
 }
 
+// UptimeHourRecord is one node identity's history for one UTC hour of the
+// day: on how many days it was tested in that hour, and on how many of them
+// it answered. LastDay is the newest of those days and LastUp whether the
+// node answered on it.
+type UptimeHourRecord struct {
+	Zone      int
+	Net       int
+	Node      int
+	Domain    string
+	Hour      int
+	Days      int
+	Reachable int
+	LastDay   time.Time
+	LastUp    bool
+}
+
 // DNSResult represents DNS resolution result
 type DNSResult struct {
 	Hostname      string
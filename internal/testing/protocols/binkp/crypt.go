@@ -0,0 +1,90 @@
+package binkp
+
+import (
+	"hash/crc32"
+	"net"
+)
+
+// CRYPT is binkd's session encryption. When both sides offer it in a
+// password-protected session, everything after M_OK, frame headers included,
+// goes through the traditional PKZIP stream cipher. Each direction has its
+// own keys: the originator encrypts with keys set from the password and
+// decrypts with keys set from "-" followed by the password; the answering
+// side does the reverse.
+
+// cipherKeys is the PKZIP cipher state (APPNOTE.TXT 6.1).
+type cipherKeys [3]uint32
+
+func newCipherKeys(password string) *cipherKeys {
+	k := &cipherKeys{0x12345678, 0x23456789, 0x34567890}
+	k.updateString(password)
+	return k
+}
+
+func (k *cipherKeys) updateString(s string) {
+	for i := 0; i < len(s); i++ {
+		k.update(s[i])
+	}
+}
+
+func (k *cipherKeys) update(c byte) {
+	k[0] = crc32Update(k[0], c)
+	k[1] += k[0] & 0xff
+	k[1] = k[1]*134775813 + 1
+	k[2] = crc32Update(k[2], byte(k[1]>>24))
+}
+
+func (k *cipherKeys) streamByte() byte {
+	t := k[2]&0xffff | 2
+	return byte((t * (t ^ 1)) >> 8)
+}
+
+func (k *cipherKeys) encrypt(b []byte) {
+	for i, c := range b {
+		b[i] = c ^ k.streamByte()
+		k.update(c)
+	}
+}
+
+func (k *cipherKeys) decrypt(b []byte) {
+	for i := range b {
+		b[i] ^= k.streamByte()
+		k.update(b[i])
+	}
+}
+
+// crc32Update is one step of the byte-wise CRC-32 the cipher is built on,
+// without the pre- and post-inversion of crc32.Update.
+func crc32Update(crc uint32, b byte) uint32 {
+	return crc32.IEEETable[byte(crc)^b] ^ crc>>8
+}
+
+// cryptConn encrypts what is written to a connection and decrypts what is
+// read from it.
+type cryptConn struct {
+	net.Conn
+	in, out *cipherKeys
+}
+
+// newCryptConn wraps conn for the side of the session named by originator.
+func newCryptConn(conn net.Conn, password string, originator bool) *cryptConn {
+	mine := newCipherKeys(password)
+	theirs := newCipherKeys("-")
+	theirs.updateString(password)
+	if originator {
+		return &cryptConn{Conn: conn, in: theirs, out: mine}
+	}
+	return &cryptConn{Conn: conn, in: mine, out: theirs}
+}
+
+func (c *cryptConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.in.decrypt(b[:n])
+	return n, err
+}
+
+func (c *cryptConn) Write(b []byte) (int, error) {
+	buf := append([]byte(nil), b...)
+	c.out.encrypt(buf)
+	return c.Conn.Write(buf)
+}
@@ -1,6 +1,8 @@
 package binkp
 
 import (
+	"bytes"
+	"compress/zlib"
 	"encoding/binary"
 	"fmt"
 	"io"
@@ -41,11 +43,19 @@ var frameTypeNames = map[uint8]string{
 	M_SKIP: "M_SKIP",
 }
 
+// plzMaxBlock is the largest frame body once PLZ is on: bit 14 of the
+// header marks a zlib-compressed block, leaving 14 bits for the length.
+const plzMaxBlock = 0x3FFF
+
 // Frame represents a BinkP protocol frame
 type Frame struct {
 	Type    uint8  // Frame type (M_NUL, M_ADR, etc.)
 	Command bool   // True if command frame (bit 7 set)
 	Data    []byte // Frame data
+	// Compressed marks a PLZ block. Data is always the uncompressed
+	// content; WriteFrame compresses it and clears Compressed when the
+	// result would not fit a PLZ frame.
+	Compressed bool
 }
 
 // String returns a human-readable representation of the frame
@@ -71,6 +81,11 @@ func (f *Frame) String() string {
 // ReadFrame reads a single BinkP frame from the connection
 // Note: Caller is responsible for setting read deadline on conn before calling
 func ReadFrame(conn net.Conn) (*Frame, error) {
+	return readFrame(conn, false)
+}
+
+// readFrame reads a frame, decoding PLZ-compressed blocks when plz is on.
+func readFrame(conn net.Conn, plz bool) (*Frame, error) {
 	// Read 2-byte header (network byte order)
 	header := make([]byte, 2)
 	n, err := io.ReadFull(conn, header)
@@ -88,6 +103,10 @@ func ReadFrame(conn net.Conn) (*Frame, error) {
 	headerValue := binary.BigEndian.Uint16(header)
 	isCommand := (headerValue & 0x8000) != 0
 	dataLen := int(headerValue & 0x7FFF)
+	compressed := plz && headerValue&0x4000 != 0
+	if compressed {
+		dataLen = int(headerValue & plzMaxBlock)
+	}
 
 	// Command frames must have at least 1 byte (the command type)
 	if isCommand && dataLen == 0 {
@@ -108,6 +127,15 @@ func ReadFrame(conn net.Conn) (*Frame, error) {
 			return nil, fmt.Errorf("short data read: %d bytes, expected %d", n, dataLen)
 		}
 
+		if compressed {
+			if data, err = inflateBlock(data); err != nil {
+				return nil, fmt.Errorf("bad PLZ block: %w", err)
+			}
+			if isCommand && len(data) == 0 {
+				return nil, fmt.Errorf("invalid command frame: zero-length data")
+			}
+		}
+
 		// For command frames, first byte is the command type
 		if isCommand {
 			frameType = data[0]
@@ -116,9 +144,10 @@ func ReadFrame(conn net.Conn) (*Frame, error) {
 	}
 
 	return &Frame{
-		Type:    frameType,
-		Command: isCommand,
-		Data:    data,
+		Type:       frameType,
+		Command:    isCommand,
+		Data:       data,
+		Compressed: compressed,
 	}, nil
 }
 
@@ -148,6 +177,15 @@ func WriteFrame(conn net.Conn, frame *Frame) error {
 		fullData = frame.Data
 	}
 
+	if frame.Compressed {
+		if z := deflateBlock(fullData); len(z) <= plzMaxBlock {
+			fullData = z
+			dataLen = len(z)
+		} else {
+			frame.Compressed = false
+		}
+	}
+
 	if dataLen > 0x7FFF {
 		return fmt.Errorf("data too large: %d bytes (max 32767)", dataLen)
 	}
@@ -158,6 +196,9 @@ func WriteFrame(conn net.Conn, frame *Frame) error {
 	if frame.Command {
 		headerValue |= 0x8000 // Set command flag
 	}
+	if frame.Compressed {
+		headerValue |= 0x4000 // PLZ: zlib-compressed block
+	}
 	binary.BigEndian.PutUint16(header, headerValue)
 
 	// Write header
@@ -175,6 +216,35 @@ func WriteFrame(conn net.Conn, frame *Frame) error {
 	return nil
 }
 
+// plzMaxInflated caps what one PLZ block may inflate to. binkd compresses
+// blocks of at most 32767 bytes; the cap only guards against a zlib bomb.
+const plzMaxInflated = 1 << 16
+
+// deflateBlock compresses one PLZ block as a complete zlib stream.
+func deflateBlock(data []byte) []byte {
+	var buf bytes.Buffer
+	zw := zlib.NewWriter(&buf)
+	_, _ = zw.Write(data)
+	_ = zw.Close()
+	return buf.Bytes()
+}
+
+func inflateBlock(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	out, err := io.ReadAll(io.LimitReader(zr, plzMaxInflated+1))
+	if err != nil {
+		return nil, err
+	}
+	if len(out) > plzMaxInflated {
+		return nil, fmt.Errorf("inflates past %d bytes", plzMaxInflated)
+	}
+	return out, nil
+}
+
 // ParseM_NUL parses M_NUL frame data into key and value
 // Format: "KEY value" or just "value" for some fields
 func ParseM_NUL(data []byte) (key, value string) {
@@ -1,6 +1,8 @@
 package binkp
 
 import (
+	"encoding/hex"
+	"errors"
 	"fmt"
 	"github.com/nodelistdb/internal/testing/logging"
 	"io"
@@ -31,13 +33,52 @@ type Session struct {
 	systemName     string // Our system name (SYS)
 	sysop          string // Our sysop name (ZYZ)
 	location       string // Our location (LOC)
+	version        string // Our software version (VER)
 	remoteInfo     NodeInfo
 	timeout        time.Duration
 	debug          bool
 	localEOBSent   bool // Track if we sent M_EOB
 	remoteEOBRecvd bool // Track if we received M_EOB from remote
+
+	password      string // Session password ("" sends "-")
+	allowPlain    bool   // Send the password in plain text if the remote offers no CRAM
+	nr            bool   // Ask for non-reliable mode (OPT NR)
+	cramChallenge []byte // Our outstanding CRAM challenge, answering side only
+	receivedADR   bool   // Remote M_ADR seen
+	auth          Auth   // How the handshake authenticated
+	options       Options
+	crypt         bool // CRYPT in effect; conn is a *cryptConn
+}
+
+// Options are the optional binkd extensions a session offers. Each only
+// takes effect when the remote offers it too.
+type Options struct {
+	Crypt bool // CRYPT: encrypt the session after M_OK; password sessions only
+	PLZ   bool // PLZ: data frames may carry zlib-compressed blocks
+	GZ    bool // EXTCMD GZ: files may be sent as zlib streams
 }
 
+// Auth describes how a handshake authenticated.
+type Auth struct {
+	Method string // "cram-md5", "plain", or "" when no password was used
+	Secure bool   // Remote accepted the password and called the session secure
+}
+
+// RemoteError is an M_ERR from the remote. A bad password during the
+// handshake arrives this way.
+type RemoteError struct {
+	Message string
+}
+
+func (e *RemoteError) Error() string {
+	return "remote error: " + e.Message
+}
+
+// ErrNoCRAM is returned by Handshake when the remote offers no CRAM-MD5
+// challenge and the password may not go out in plain text. The password is
+// not sent.
+var ErrNoCRAM = errors.New("remote offered no CRAM-MD5 challenge; password not sent in plain text")
+
 // NewSession creates a new BinkP session
 func NewSession(conn net.Conn, localAddress string) *Session {
 	return &Session{
@@ -46,6 +87,7 @@ func NewSession(conn net.Conn, localAddress string) *Session {
 		systemName:   "NodelistDB Test Daemon",
 		sysop:        "Test Operator",
 		location:     "Test Location",
+		version:      defaultVersion,
 		timeout:      30 * time.Second,
 		debug:        false,
 	}
@@ -59,11 +101,21 @@ func NewSessionWithInfo(conn net.Conn, localAddress, systemName, sysop, location
 		systemName:   systemName,
 		sysop:        sysop,
 		location:     location,
+		version:      defaultVersion,
 		timeout:      30 * time.Second,
 		debug:        false,
 	}
 }
 
+// defaultVersion is the VER we announce unless SetVersion says otherwise.
+const defaultVersion = "NodelistDB/1.0 binkp/1.1"
+
+// SetVersion sets the software version announced in M_NUL VER, e.g.
+// "binkd/1.1a-115 binkp/1.1".
+func (s *Session) SetVersion(version string) {
+	s.version = version
+}
+
 // SetDebug enables debug logging
 func (s *Session) SetDebug(debug bool) {
 	s.debug = debug
@@ -74,6 +126,47 @@ func (s *Session) SetTimeout(timeout time.Duration) {
 	s.timeout = timeout
 }
 
+// SetPassword sets the session password Handshake presents. Answered with
+// CRAM-MD5 when the remote offers a challenge; see SetAllowPlain for a
+// remote that does not.
+func (s *Session) SetPassword(password string) {
+	s.password = password
+}
+
+// SetAllowPlain lets Handshake send the password in plain text to a remote
+// that offers no CRAM challenge. Off by default, when Handshake refuses the
+// session with ErrNoCRAM instead; only allow it where the connection is
+// already encrypted, as binkps is.
+func (s *Session) SetAllowPlain(allow bool) {
+	s.allowPlain = allow
+}
+
+// SetNR asks for non-reliable mode: files we send are offered at offset -1
+// and only start once the remote names an offset with M_GET.
+func (s *Session) SetNR(nr bool) {
+	s.nr = nr
+}
+
+// GetAuth reports how the handshake authenticated.
+func (s *Session) GetAuth() Auth {
+	return s.auth
+}
+
+// SetOptions sets the extensions the handshake offers.
+func (s *Session) SetOptions(options Options) {
+	s.options = options
+}
+
+// Negotiated reports which extensions are in effect after the handshake:
+// those both sides offered, and CRYPT only if the session went secure.
+func (s *Session) Negotiated() Options {
+	return Options{
+		Crypt: s.crypt,
+		PLZ:   s.options.PLZ && s.remoteHasOption("PLZ"),
+		GZ:    s.options.GZ && s.remoteHasOption("EXTCMD") && s.remoteHasOption("GZ"),
+	}
+}
+
 // Handshake performs the BinkP handshake
 func (s *Session) Handshake() error {
 	// Set write deadline for outgoing frames
@@ -89,19 +182,122 @@ func (s *Session) Handshake() error {
 		return fmt.Errorf("failed to send our address: %w", err)
 	}
 
-	// Send password (we send "-" for no password in testing)
-	if err := s.sendPassword("-"); err != nil {
-		return fmt.Errorf("failed to send password: %w", err)
+	if s.password == "" {
+		// Send password (we send "-" for no password in testing)
+		if err := s.sendPassword("-"); err != nil {
+			return fmt.Errorf("failed to send password: %w", err)
+		}
+	} else {
+		// The CRAM challenge (FTS-1027) comes in the remote's M_NUL frames
+		// ahead of its M_ADR, so the password has to wait for the address.
+		if err := s.receiveRemoteInfo(true); err != nil {
+			return fmt.Errorf("failed to receive remote info: %w", err)
+		}
+		pwd := s.password
+		s.auth.Method = "plain"
+		if challenge, ok := ParseCRAMChallenge(s.remoteInfo.Capabilities); ok {
+			pwd = CRAMResponse(s.password, challenge)
+			s.auth.Method = "cram-md5"
+		}
+		_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+		if s.auth.Method == "plain" && !s.allowPlain {
+			s.auth.Method = ""
+			_ = WriteFrame(s.conn, CreateM_ERR("CRAM-MD5 required"))
+			return ErrNoCRAM
+		}
+		if err := s.sendPassword(pwd); err != nil {
+			return fmt.Errorf("failed to send password: %w", err)
+		}
 	}
 
 	// Receive remote frames
-	if err := s.receiveRemoteInfo(); err != nil {
+	if err := s.receiveRemoteInfo(false); err != nil {
 		return fmt.Errorf("failed to receive remote info: %w", err)
 	}
 
 	return nil
 }
 
+// Answer performs the answering side of the handshake: it offers a CRAM
+// challenge, waits for the caller's M_PWD and accepts or rejects it.
+//
+// password returns the password agreed with the caller's addresses, or ""
+// for none, in which case any password is accepted and the session is not
+// secure. A wrong password is answered with M_ERR and returned as an error.
+func (s *Session) Answer(password func(addresses []string) string) error {
+	challenge, err := newCRAMChallenge()
+	if err != nil {
+		return fmt.Errorf("failed to create CRAM challenge: %w", err)
+	}
+	s.cramChallenge = challenge
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	if err := s.sendOurInfo(); err != nil {
+		return fmt.Errorf("failed to send our info: %w", err)
+	}
+	if err := s.sendOurAddress(); err != nil {
+		return fmt.Errorf("failed to send our address: %w", err)
+	}
+
+	_ = s.conn.SetReadDeadline(time.Now().Add(s.timeout))
+	for gotPWD := false; !gotPWD; {
+		frame, err := ReadFrame(s.conn)
+		if err != nil {
+			return fmt.Errorf("failed to read frame: %w", err)
+		}
+		if s.debug {
+			logging.Debugf("BinkP: Received %s", frame)
+		}
+		switch frame.Type {
+		case M_NUL:
+			s.parseM_NUL(ParseM_NUL(frame.Data))
+		case M_ADR:
+			s.remoteInfo.Addresses = ParseAddresses(frame.Data)
+			s.receivedADR = true
+		case M_PWD:
+			if !s.receivedADR {
+				return fmt.Errorf("received M_PWD before M_ADR - invalid handshake")
+			}
+			s.remoteInfo.Password = strings.TrimRight(string(frame.Data), "\x00")
+			gotPWD = true
+		case M_ERR:
+			return &RemoteError{Message: string(frame.Data)}
+		case M_BSY:
+			return fmt.Errorf("remote is busy")
+		}
+	}
+
+	_ = s.conn.SetWriteDeadline(time.Now().Add(s.timeout))
+	expected := password(s.remoteInfo.Addresses)
+	if expected == "" {
+		return WriteFrame(s.conn, &Frame{Type: M_OK, Command: true, Data: []byte("non-secure")})
+	}
+	if !checkPassword(expected, s.remoteInfo.Password, challenge) {
+		_ = WriteFrame(s.conn, CreateM_ERR("Incorrect password"))
+		return fmt.Errorf("incorrect password from %s", strings.Join(s.remoteInfo.Addresses, " "))
+	}
+	s.auth = Auth{Method: "plain", Secure: true}
+	if strings.HasPrefix(strings.ToUpper(s.remoteInfo.Password), cramPrefix) {
+		s.auth.Method = "cram-md5"
+	}
+	// As binkd does, the answering side agrees to CRYPT only once the
+	// password checks out.
+	crypt := s.options.Crypt && s.remoteHasOption("CRYPT")
+	if crypt {
+		if err := WriteFrame(s.conn, CreateM_NUL("OPT", "CRYPT")); err != nil {
+			return err
+		}
+	}
+	if err := WriteFrame(s.conn, &Frame{Type: M_OK, Command: true, Data: []byte("secure")}); err != nil {
+		return err
+	}
+	if crypt {
+		s.conn = newCryptConn(s.conn, expected, false)
+		s.crypt = true
+	}
+	return nil
+}
+
 // sendOurInfo sends our M_NUL frames
 func (s *Session) sendOurInfo() error {
 	// System info
@@ -109,10 +305,31 @@ func (s *Session) sendOurInfo() error {
 		CreateM_NUL("SYS", s.systemName),
 		CreateM_NUL("ZYZ", s.sysop),
 		CreateM_NUL("LOC", s.location),
-		CreateM_NUL("VER", "NodelistDB/1.0 binkp/1.1"),
+		CreateM_NUL("VER", s.version),
 		CreateM_NUL("TIME", time.Now().Format(time.RFC822)),
-		// Don't advertise CRAM-MD5 since we're not implementing it for testing
-		// CreateM_NUL("OPT", "CRAM-MD5"),
+	}
+
+	// The CRAM challenge is the answering side's to offer; as the caller we
+	// only answer one. CRYPT needs a password to key it, so the caller only
+	// offers it with one; the answering side offers it after M_PWD.
+	var opts []string
+	if s.nr {
+		opts = append(opts, "NR")
+	}
+	if s.options.GZ {
+		opts = append(opts, "EXTCMD", "GZ")
+	}
+	if s.options.PLZ {
+		opts = append(opts, "PLZ")
+	}
+	if s.options.Crypt && s.password != "" {
+		opts = append(opts, "CRYPT")
+	}
+	if s.cramChallenge != nil {
+		opts = append(opts, cramPrefix+"MD5-"+hex.EncodeToString(s.cramChallenge))
+	}
+	if len(opts) > 0 {
+		frames = append(frames, CreateM_NUL("OPT", strings.Join(opts, " ")))
 	}
 
 	for _, frame := range frames {
@@ -145,12 +362,13 @@ func (s *Session) sendPassword(password string) error {
 	return WriteFrame(s.conn, frame)
 }
 
-// receiveRemoteInfo receives and parses remote node information
-func (s *Session) receiveRemoteInfo() error {
+// receiveRemoteInfo receives and parses remote node information. With
+// untilADR it stops as soon as the remote's M_ADR is in.
+func (s *Session) receiveRemoteInfo(untilADR bool) error {
 	// Set overall timeout for receiving all frames
 	_ = s.conn.SetReadDeadline(time.Now().Add(s.timeout))
 
-	receivedADR := false
+	receivedADR := s.receivedADR
 	frameCount := 0
 	maxFrames := 50 // Prevent infinite loop
 
@@ -183,9 +401,13 @@ func (s *Session) receiveRemoteInfo() error {
 			// Parse addresses
 			s.remoteInfo.Addresses = ParseAddresses(frame.Data)
 			receivedADR = true
+			s.receivedADR = true
 			if s.debug {
 				logging.Debugf("BinkP: Remote addresses: %v", s.remoteInfo.Addresses)
 			}
+			if untilADR {
+				return nil
+			}
 
 		case M_PWD:
 			// Remote sent password
@@ -197,12 +419,19 @@ func (s *Session) receiveRemoteInfo() error {
 				logging.Debugf("BinkP: Remote sent M_OK - handshake complete")
 			}
 			// Handshake complete - authentication accepted
+			if s.password != "" {
+				s.auth.Secure = !strings.EqualFold(strings.Trim(string(frame.Data), "\x00 "), "non-secure")
+			}
+			// Whatever follows M_OK is encrypted once CRYPT is agreed.
+			if s.auth.Secure && s.options.Crypt && s.remoteHasOption("CRYPT") {
+				s.conn = newCryptConn(s.conn, s.password, true)
+				s.crypt = true
+			}
 			return nil
 
 		case M_ERR:
 			// Remote reported error
-			errMsg := string(frame.Data)
-			return fmt.Errorf("remote error: %s", errMsg)
+			return &RemoteError{Message: string(frame.Data)}
 
 		case M_BSY:
 			// Remote is busy
@@ -276,8 +505,9 @@ func (s *Session) parseM_NUL(key, value string) {
 	case "TIME":
 		s.remoteInfo.Time = value
 	case "OPT":
-		// Parse capabilities (space-separated)
-		s.remoteInfo.Capabilities = strings.Fields(value)
+		// Parse capabilities (space-separated); a mailer may send several
+		// OPT frames
+		s.remoteInfo.Capabilities = append(s.remoteInfo.Capabilities, strings.Fields(value)...)
 	case "NDL":
 		s.remoteInfo.NDL = value
 	default:
@@ -346,7 +576,16 @@ func (s *Session) Close() error {
 
 	// Perform graceful TCP shutdown like MBSE's closetcp() does
 	// This sends FIN instead of RST, preventing SIGPIPE on the remote side
-	if tcpConn, ok := s.conn.(*net.TCPConn); ok {
+	conn := s.conn
+	if c, ok := conn.(*cryptConn); ok {
+		conn = c.Conn
+	}
+	// *net.TCPConn, or *tls.Conn on a binkps session, where CloseWrite sends
+	// close_notify first.
+	if tcpConn, ok := conn.(interface {
+		net.Conn
+		CloseWrite() error
+	}); ok {
 		// First, close the write side (sends TCP FIN)
 		if err := tcpConn.CloseWrite(); err != nil {
 			if s.debug {
@@ -372,10 +611,10 @@ func (s *Session) Close() error {
 // ValidateAddress checks if the remote announced the expected address
 func (s *Session) ValidateAddress(expectedAddress string) bool {
 	// Normalize addresses for comparison
-	expected := normalizeAddress(expectedAddress)
+	expected := NormalizeAddress(expectedAddress)
 
 	for _, addr := range s.remoteInfo.Addresses {
-		if normalizeAddress(addr) == expected {
+		if NormalizeAddress(addr) == expected {
 			return true
 		}
 	}
@@ -383,8 +622,8 @@ func (s *Session) ValidateAddress(expectedAddress string) bool {
 	return false
 }
 
-// normalizeAddress normalizes a FidoNet address for comparison
-func normalizeAddress(addr string) string {
+// NormalizeAddress normalizes a FidoNet address for comparison
+func NormalizeAddress(addr string) string {
 	// Remove leading/trailing spaces
 	addr = strings.TrimSpace(addr)
 
@@ -0,0 +1,814 @@
+package binkp
+
+import (
+	"bytes"
+	"compress/zlib"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/logging"
+)
+
+// dataBlockSize is how much file data goes in one data frame. The frame
+// limit is 32767 and binkd sends 4096 to 16384; one byte short of 16384 keeps
+// a block within a PLZ frame.
+const dataBlockSize = plzMaxBlock
+
+// compressionGZ is the M_FILE field naming a file sent as a zlib stream
+// (binkd EXTCMD GZ). The size in M_FILE stays the uncompressed size.
+const compressionGZ = "GZ"
+
+// FileInfo is a file as named in M_FILE, M_GET, M_GOT and M_SKIP.
+type FileInfo struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	Offset  int64 // M_FILE and M_GET only; -1 in an M_FILE sent in NR mode
+	// Compression is the fifth M_FILE field: "GZ" for a zlib stream, ""
+	// for plain data.
+	Compression string
+}
+
+// OutboundFile is a file to send.
+type OutboundFile struct {
+	Name    string
+	Size    int64
+	ModTime time.Time
+	Body    io.ReadSeeker // may be nil when Size is 0
+	// Compression asks for the file to go out as a GZ stream. Honoured
+	// only when GZ was negotiated and the file is not empty.
+	Compression string
+}
+
+// ProbeFile is an empty file to send in place of mail: its M_GOT proves the
+// remote accepts files from us without putting anything in its inbound that
+// a tosser would act on.
+func ProbeFile(now time.Time) OutboundFile {
+	return OutboundFile{
+		Name:    fmt.Sprintf("%08x.tst", uint32(now.Unix())),
+		ModTime: now,
+	}
+}
+
+// InboundFunc decides what happens to a file the remote offers. It returns
+// the writer the file goes to, or nil to refuse it with M_SKIP, which leaves
+// the file queued on the remote for a later session.
+type InboundFunc func(FileInfo) io.Writer
+
+// SkipAll refuses every file. A tester must not take a node's mail.
+func SkipAll(FileInfo) io.Writer { return nil }
+
+// TransferResult is what a Transfer moved.
+type TransferResult struct {
+	Sent            []FileInfo // ours, acknowledged with M_GOT
+	SkippedByRemote []FileInfo // ours, answered with M_SKIP
+	Received        []FileInfo // theirs, written and acknowledged
+	SkippedByUs     []FileInfo // theirs, refused by the InboundFunc
+	Batches         int
+	BytesSent       int64 // file bytes, before any compression
+	BytesReceived   int64
+	// PLZ-compressed data frames in each direction.
+	CompressedBlocksSent     int
+	CompressedBlocksReceived int
+}
+
+// Transfer runs the file transfer stage of an FTS-1026 session once the
+// handshake is done: it sends files, hands the remote's files to inbound, and
+// returns when both sides have ended the last batch with M_EOB.
+//
+// binkp/1.1 sessions run further batches for as long as the previous one
+// moved a file, so a remote can send back mail that an inbound file
+// generated. Reading runs on its own goroutine so that an M_GET or M_SKIP
+// arriving while we are mid-file is seen before the rest of the file goes out.
+func (s *Session) Transfer(files []OutboundFile, inbound InboundFunc) (*TransferResult, error) {
+	if inbound == nil {
+		inbound = SkipAll
+	}
+	negotiated := s.Negotiated()
+	t := &transfer{
+		s:       s,
+		queue:   append([]OutboundFile(nil), files...),
+		inbound: inbound,
+		pending: map[string]*outgoing{},
+		result:  &TransferResult{Batches: 1},
+		plz:     negotiated.PLZ,
+		gz:      negotiated.GZ,
+	}
+
+	r := &frameReader{s: s, plz: t.plz, frames: make(chan incoming), quit: make(chan struct{}), done: make(chan struct{})}
+	go r.run()
+	defer r.stop()
+
+	err := t.run(r.frames)
+	if t.recv != nil && t.recv.inflate != nil {
+		t.recv.inflate.abort()
+	}
+	s.localEOBSent = t.localEOB
+	s.remoteEOBRecvd = t.remoteEOB
+	return t.result, err
+}
+
+type outgoing struct {
+	file        OutboundFile
+	info        FileInfo
+	pos         int64
+	awaitingGet bool      // offered at -1 in NR mode
+	deflate     *deflater // GZ stream from pos, when info.Compression is GZ
+}
+
+type receiving struct {
+	info         FileInfo
+	w            io.Writer
+	received     int64
+	skipping     bool      // refused; discard data still in flight
+	awaitingFile bool      // M_GET sent; data resumes after the remote's M_FILE
+	inflate      *inflater // decoding a GZ file
+}
+
+type transfer struct {
+	s       *Session
+	queue   []OutboundFile
+	cur     *outgoing
+	pending map[string]*outgoing // offered, not yet acknowledged
+	recv    *receiving
+	inbound InboundFunc
+	result  *TransferResult
+	buf     []byte
+
+	localEOB, remoteEOB bool
+	moved               bool // a file was acknowledged in this batch
+	plz, gz             bool // negotiated
+}
+
+func (t *transfer) run(frames <-chan incoming) error {
+	for {
+		sent, err := t.sendNext()
+		if err != nil {
+			return err
+		}
+		if t.batchDone() {
+			if !t.moved || !t.s.multiBatch() {
+				return nil
+			}
+			t.localEOB, t.remoteEOB, t.moved = false, false, false
+			t.result.Batches++
+			continue
+		}
+
+		var in incoming
+		if sent {
+			// More may be ready to go; only take a frame that is already here.
+			select {
+			case in = <-frames:
+			default:
+				continue
+			}
+		} else {
+			in = <-frames
+		}
+		if in.err != nil {
+			// A binkp/1.1 remote with nothing more to say may hang up
+			// instead of closing an empty extra batch.
+			if errors.Is(in.err, io.EOF) && t.result.Batches > 1 && t.idle() {
+				t.remoteEOB = true
+				return nil
+			}
+			return fmt.Errorf("failed to read frame: %w", in.err)
+		}
+		if err := t.handle(in.frame); err != nil {
+			return err
+		}
+	}
+}
+
+// sendNext sends at most one frame: an M_FILE, a block of data, or M_EOB.
+func (t *transfer) sendNext() (bool, error) {
+	switch {
+	case t.cur != nil && !t.cur.awaitingGet:
+		return true, t.sendBlock()
+	case t.cur == nil && len(t.queue) > 0:
+		return true, t.offerNext()
+	case t.cur == nil && !t.localEOB:
+		t.localEOB = true
+		return true, t.write(&Frame{Type: M_EOB, Command: true})
+	}
+	return false, nil
+}
+
+func (t *transfer) idle() bool {
+	return t.cur == nil && len(t.queue) == 0 && len(t.pending) == 0 && (t.recv == nil || t.recv.skipping)
+}
+
+func (t *transfer) batchDone() bool {
+	return t.localEOB && t.remoteEOB && t.idle()
+}
+
+func (t *transfer) offerNext() error {
+	f := t.queue[0]
+	t.queue = t.queue[1:]
+	if f.Body == nil && f.Size != 0 {
+		return fmt.Errorf("%s: no body for %d bytes", f.Name, f.Size)
+	}
+
+	o := &outgoing{file: f, info: FileInfo{Name: f.Name, Size: f.Size, ModTime: f.ModTime}}
+	if t.gz && f.Size > 0 && strings.EqualFold(f.Compression, compressionGZ) {
+		o.info.Compression = compressionGZ
+	}
+	if t.s.nonReliable() {
+		o.info.Offset = -1
+		o.awaitingGet = true
+	} else if err := o.seek(0); err != nil {
+		return err
+	}
+	t.pending[f.Name] = o
+	t.cur = o
+	if !o.awaitingGet && f.Size == 0 {
+		t.cur = nil
+	}
+	return t.write(&Frame{Type: M_FILE, Command: true, Data: []byte(o.info.offer())})
+}
+
+// seek positions o to send from offset, restarting its GZ stream there.
+func (o *outgoing) seek(offset int64) error {
+	if o.file.Body != nil {
+		if _, err := o.file.Body.Seek(offset, io.SeekStart); err != nil {
+			return fmt.Errorf("%s: %w", o.file.Name, err)
+		}
+	}
+	o.pos = offset
+	o.deflate = nil
+	if o.info.Compression == compressionGZ && offset < o.file.Size {
+		o.deflate = newDeflater(io.LimitReader(o.file.Body, o.file.Size-offset))
+	}
+	return nil
+}
+
+func (t *transfer) sendBlock() error {
+	o := t.cur
+	if o.deflate != nil {
+		return t.sendStream(o)
+	}
+	n := o.file.Size - o.pos
+	if n > dataBlockSize {
+		n = dataBlockSize
+	}
+	if n <= 0 {
+		t.cur = nil
+		return nil
+	}
+	if t.buf == nil {
+		t.buf = make([]byte, dataBlockSize)
+	}
+	block := t.buf[:n]
+	if _, err := io.ReadFull(o.file.Body, block); err != nil {
+		return fmt.Errorf("%s: %w", o.file.Name, err)
+	}
+	if err := t.writeData(block); err != nil {
+		return err
+	}
+	o.pos += n
+	t.result.BytesSent += n
+	if o.pos >= o.file.Size {
+		t.cur = nil
+	}
+	return nil
+}
+
+// sendStream sends the next block of a GZ file. Compressing it again with
+// PLZ would gain nothing, so it goes out as plain data frames.
+func (t *transfer) sendStream(o *outgoing) error {
+	block, read, err := o.deflate.next(dataBlockSize)
+	if err != nil {
+		return fmt.Errorf("%s: %w", o.file.Name, err)
+	}
+	o.pos += read
+	t.result.BytesSent += read
+	if len(block) > 0 {
+		if err := t.write(&Frame{Data: block}); err != nil {
+			return err
+		}
+	}
+	if o.deflate.finished() {
+		t.cur = nil
+	}
+	return nil
+}
+
+// writeData sends a block of file data, as a PLZ block when PLZ is on.
+func (t *transfer) writeData(block []byte) error {
+	f := &Frame{Data: block, Compressed: t.plz}
+	if err := t.write(f); err != nil {
+		return err
+	}
+	if f.Compressed {
+		t.result.CompressedBlocksSent++
+	}
+	return nil
+}
+
+func (t *transfer) handle(f *Frame) error {
+	if t.s.debug {
+		logging.Debugf("BinkP: Received %s", f)
+	}
+	if !f.Command {
+		if f.Compressed {
+			t.result.CompressedBlocksReceived++
+		}
+		return t.receiveData(f.Data)
+	}
+
+	switch f.Type {
+	case M_NUL:
+		t.s.parseM_NUL(ParseM_NUL(f.Data))
+	case M_FILE:
+		return t.offered(f.Data)
+	case M_GET:
+		return t.get(f.Data)
+	case M_GOT:
+		return t.acknowledged(f.Data, true)
+	case M_SKIP:
+		return t.acknowledged(f.Data, false)
+	case M_EOB:
+		if t.recv != nil && !t.recv.skipping {
+			return fmt.Errorf("M_EOB in the middle of %s", t.recv.info.Name)
+		}
+		t.recv = nil
+		t.remoteEOB = true
+	case M_ERR:
+		return &RemoteError{Message: string(f.Data)}
+	case M_BSY:
+		return fmt.Errorf("remote is busy: %s", f.Data)
+	}
+	return nil
+}
+
+func (t *transfer) offered(data []byte) error {
+	info, err := parseFileInfo(data, true)
+	if err != nil {
+		return fmt.Errorf("bad M_FILE: %w", err)
+	}
+	if r := t.recv; r != nil && r.inflate != nil && r.info.Name != info.Name {
+		r.inflate.abort()
+	}
+
+	if r := t.recv; r != nil && r.awaitingFile && r.info.Name == info.Name {
+		if info.Offset != 0 {
+			return fmt.Errorf("%s: asked for offset 0, remote resumed at %d", info.Name, info.Offset)
+		}
+		r.awaitingFile = false
+		r.info.Compression = info.Compression
+		r.startInflate()
+		return t.completeIfDone()
+	}
+
+	var w io.Writer
+	if info.Compression == "" || info.Compression == compressionGZ {
+		// Anything else (BZ2) we cannot decode; it waits for a session
+		// without it.
+		w = t.inbound(info)
+	}
+	if w == nil {
+		t.recv = &receiving{info: info, skipping: true}
+		t.result.SkippedByUs = append(t.result.SkippedByUs, info)
+		return t.write(&Frame{Type: M_SKIP, Command: true, Data: []byte(info.args())})
+	}
+
+	t.recv = &receiving{info: info, w: w}
+	if info.Offset != 0 {
+		// NR mode (-1), or a resume we have nothing to resume from: ask for
+		// the whole file.
+		t.recv.awaitingFile = true
+		get := info
+		get.Offset = 0
+		return t.write(&Frame{Type: M_GET, Command: true, Data: []byte(get.withOffset())})
+	}
+	t.recv.startInflate()
+	return t.completeIfDone()
+}
+
+// startInflate starts decoding r when it arrives as a GZ stream. An empty
+// file needs no stream; anything the remote sends for one is ignored.
+func (r *receiving) startInflate() {
+	if r.info.Compression == compressionGZ && r.info.Size > 0 {
+		r.inflate = newInflater(r.w, r.info.Size)
+	}
+}
+
+func (t *transfer) receiveData(data []byte) error {
+	r := t.recv
+	if r == nil || r.skipping || r.awaitingFile {
+		return nil
+	}
+	if r.inflate != nil {
+		n, err := r.inflate.feed(data)
+		r.received += n
+		t.result.BytesReceived += n
+		if err != nil {
+			return fmt.Errorf("%s: %w", r.info.Name, err)
+		}
+		return t.completeIfDone()
+	}
+	if r.received+int64(len(data)) > r.info.Size {
+		return fmt.Errorf("%s: more data than the %d bytes announced", r.info.Name, r.info.Size)
+	}
+	if _, err := r.w.Write(data); err != nil {
+		return fmt.Errorf("%s: %w", r.info.Name, err)
+	}
+	r.received += int64(len(data))
+	t.result.BytesReceived += int64(len(data))
+	return t.completeIfDone()
+}
+
+func (t *transfer) completeIfDone() error {
+	r := t.recv
+	if r.received < r.info.Size || (r.inflate != nil && !r.inflate.finished) {
+		return nil
+	}
+	t.recv = nil
+	t.moved = true
+	t.result.Received = append(t.result.Received, r.info)
+	return t.write(&Frame{Type: M_GOT, Command: true, Data: []byte(r.info.args())})
+}
+
+func (t *transfer) get(data []byte) error {
+	info, err := parseFileInfo(data, true)
+	if err != nil {
+		return fmt.Errorf("bad M_GET: %w", err)
+	}
+	o := t.pending[info.Name]
+	if o == nil {
+		return nil
+	}
+	if info.Offset < 0 || info.Offset > o.file.Size {
+		return fmt.Errorf("%s: M_GET offset %d outside 0..%d", info.Name, info.Offset, o.file.Size)
+	}
+	if err := o.seek(info.Offset); err != nil {
+		return err
+	}
+
+	// A file interrupted by a request for another one is offered again later.
+	if t.cur != nil && t.cur != o {
+		delete(t.pending, t.cur.file.Name)
+		t.queue = append([]OutboundFile{t.cur.file}, t.queue...)
+	}
+	o.awaitingGet = false
+	o.info.Offset = info.Offset
+	t.cur = o
+	if o.pos >= o.file.Size {
+		t.cur = nil
+	}
+	return t.write(&Frame{Type: M_FILE, Command: true, Data: []byte(o.info.offer())})
+}
+
+func (t *transfer) acknowledged(data []byte, got bool) error {
+	info, err := parseFileInfo(data, false)
+	if err != nil {
+		return fmt.Errorf("bad acknowledgement: %w", err)
+	}
+	o := t.pending[info.Name]
+	if o == nil {
+		return nil
+	}
+	delete(t.pending, info.Name)
+	if t.cur == o {
+		// Declined, or already there, before we finished sending it.
+		t.cur = nil
+	}
+	if got {
+		t.moved = true
+		t.result.Sent = append(t.result.Sent, o.info)
+	} else {
+		t.result.SkippedByRemote = append(t.result.SkippedByRemote, o.info)
+	}
+	return nil
+}
+
+func (t *transfer) write(f *Frame) error {
+	if t.s.debug && f.Command {
+		logging.Debugf("BinkP: Sending %s", f)
+	}
+	_ = t.s.conn.SetWriteDeadline(time.Now().Add(t.s.timeout))
+	return WriteFrame(t.s.conn, f)
+}
+
+// nonReliable reports whether files we send go out in NR mode: asked for by
+// either side.
+func (s *Session) nonReliable() bool {
+	return s.nr || s.remoteHasOption("NR")
+}
+
+// multiBatch reports whether the session runs binkp/1.1 batches. We always
+// announce 1.1, so it comes down to the remote.
+func (s *Session) multiBatch() bool {
+	return strings.Contains(strings.ToLower(s.remoteInfo.Version), "binkp/1.1")
+}
+
+func (s *Session) remoteHasOption(opt string) bool {
+	for _, o := range s.remoteInfo.Capabilities {
+		if strings.EqualFold(o, opt) {
+			return true
+		}
+	}
+	return false
+}
+
+type incoming struct {
+	frame *Frame
+	err   error
+}
+
+// frameReader reads frames for Transfer until stopped. Each read gets the
+// session timeout as its deadline, so a silent remote ends the transfer.
+type frameReader struct {
+	s       *Session
+	plz     bool
+	frames  chan incoming
+	quit    chan struct{}
+	done    chan struct{}
+	mu      sync.Mutex
+	stopped bool
+}
+
+func (r *frameReader) run() {
+	defer close(r.done)
+	for {
+		r.mu.Lock()
+		if r.stopped {
+			r.mu.Unlock()
+			return
+		}
+		_ = r.s.conn.SetReadDeadline(time.Now().Add(r.s.timeout))
+		r.mu.Unlock()
+
+		frame, err := readFrame(r.s.conn, r.plz)
+		select {
+		case r.frames <- incoming{frame, err}:
+		case <-r.quit:
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// stop interrupts a pending read and waits for the goroutine to exit. Under
+// mu, so that run cannot push the deadline back out after it.
+func (r *frameReader) stop() {
+	r.mu.Lock()
+	r.stopped = true
+	close(r.quit)
+	_ = r.s.conn.SetReadDeadline(time.Now())
+	r.mu.Unlock()
+	<-r.done
+}
+
+// args formats "name size time" for M_GOT and M_SKIP.
+func (f FileInfo) args() string {
+	return fmt.Sprintf("%s %d %d", escapeName(f.Name), f.Size, f.ModTime.Unix())
+}
+
+// withOffset formats "name size time offset" for M_GET.
+func (f FileInfo) withOffset() string {
+	return fmt.Sprintf("%s %d", f.args(), f.Offset)
+}
+
+// offer formats M_FILE: withOffset and, for a compressed file, its method.
+func (f FileInfo) offer() string {
+	if f.Compression == "" {
+		return f.withOffset()
+	}
+	return f.withOffset() + " " + f.Compression
+}
+
+func parseFileInfo(data []byte, withOffset bool) (FileInfo, error) {
+	fields := strings.Fields(strings.Trim(string(data), "\x00"))
+	want := 3
+	if withOffset {
+		want = 4
+	}
+	// M_GOT and M_SKIP from some mailers carry the offset too.
+	if len(fields) < want {
+		return FileInfo{}, fmt.Errorf("%q: want %d fields", data, want)
+	}
+	name, err := unescapeName(fields[0])
+	if err != nil {
+		return FileInfo{}, err
+	}
+	size, err := strconv.ParseInt(fields[1], 10, 64)
+	if err != nil || size < 0 {
+		return FileInfo{}, fmt.Errorf("%q: bad size", data)
+	}
+	mtime, err := strconv.ParseInt(fields[2], 10, 64)
+	if err != nil {
+		return FileInfo{}, fmt.Errorf("%q: bad time", data)
+	}
+	info := FileInfo{Name: name, Size: size, ModTime: time.Unix(mtime, 0)}
+	if withOffset {
+		if info.Offset, err = strconv.ParseInt(fields[3], 10, 64); err != nil || info.Offset < -1 {
+			return FileInfo{}, fmt.Errorf("%q: bad offset", data)
+		}
+		if len(fields) > 4 {
+			info.Compression = strings.ToUpper(fields[4])
+		}
+	}
+	return info, nil
+}
+
+// escapeName writes spaces, control characters, non-ASCII bytes and the
+// backslash itself as \xHH, as FTS-1026 requires of file names.
+func escapeName(name string) string {
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		if c <= ' ' || c >= 0x7F || c == '\\' {
+			fmt.Fprintf(&b, "\\x%02x", c)
+			continue
+		}
+		b.WriteByte(c)
+	}
+	return b.String()
+}
+
+func unescapeName(name string) (string, error) {
+	if !strings.Contains(name, "\\") {
+		return name, nil
+	}
+	var b strings.Builder
+	for i := 0; i < len(name); i++ {
+		if name[i] != '\\' {
+			b.WriteByte(name[i])
+			continue
+		}
+		if i+4 > len(name) || name[i+1] != 'x' {
+			return "", fmt.Errorf("bad escape in file name %q", name)
+		}
+		v, err := strconv.ParseUint(name[i+2:i+4], 16, 8)
+		if err != nil {
+			return "", fmt.Errorf("bad escape in file name %q", name)
+		}
+		b.WriteByte(byte(v))
+		i += 3
+	}
+	return b.String(), nil
+}
+
+// deflater produces a file's GZ stream a block at a time.
+type deflater struct {
+	src  io.Reader
+	out  bytes.Buffer
+	zw   *zlib.Writer
+	done bool // src exhausted and the stream closed
+}
+
+func newDeflater(src io.Reader) *deflater {
+	d := &deflater{src: src}
+	d.zw = zlib.NewWriter(&d.out)
+	return d
+}
+
+// next returns up to max bytes of the stream and how many file bytes went
+// into the stream meanwhile.
+func (d *deflater) next(max int) ([]byte, int64, error) {
+	var read int64
+	for d.out.Len() < max && !d.done {
+		n, err := io.CopyN(d.zw, d.src, int64(max))
+		read += n
+		if errors.Is(err, io.EOF) {
+			if err := d.zw.Close(); err != nil {
+				return nil, read, err
+			}
+			d.done = true
+		} else if err != nil {
+			return nil, read, err
+		}
+	}
+	return d.out.Next(max), read, nil
+}
+
+func (d *deflater) finished() bool {
+	return d.done && d.out.Len() == 0
+}
+
+// inflater decodes a GZ file as its data frames arrive. flate cannot be
+// resumed once its source runs dry, so it runs on its own goroutine, reading
+// from in; feed hands it one frame and waits until it has written out all
+// it can decode, which it signals by asking for more on hungry, or until
+// the stream ends.
+type inflater struct {
+	in       chan []byte
+	hungry   chan struct{}
+	done     chan error
+	written  chan int64 // bytes written since the last feed
+	finished bool
+	closed   bool
+}
+
+func newInflater(w io.Writer, size int64) *inflater {
+	z := &inflater{
+		in:      make(chan []byte),
+		hungry:  make(chan struct{}),
+		done:    make(chan error, 1),
+		written: make(chan int64, 1),
+	}
+	go z.run(w, size)
+	return z
+}
+
+func (z *inflater) run(w io.Writer, size int64) {
+	out := &countingWriter{w: w}
+	zr, err := zlib.NewReader(&feedReader{z: z, out: out})
+	if err == nil {
+		// One byte past size shows a stream longer than announced.
+		var n int64
+		n, err = io.Copy(out, io.LimitReader(zr, size+1))
+		if err == nil && n != size {
+			err = fmt.Errorf("GZ stream holds %d bytes, %d announced", n, size)
+		}
+		if err == nil {
+			err = zr.Close()
+		}
+	}
+	z.written <- out.take()
+	z.done <- err
+}
+
+// feed passes data to the decoder and returns the bytes written since the
+// last call. The stream ending cleanly sets finished.
+func (z *inflater) feed(data []byte) (int64, error) {
+	if z.finished {
+		return 0, fmt.Errorf("data after the end of the GZ stream")
+	}
+	z.in <- data
+	select {
+	case <-z.hungry:
+		return <-z.written, nil
+	case err := <-z.done:
+		z.finished = true
+		z.closed = true
+		n := <-z.written
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			err = fmt.Errorf("GZ stream cut short")
+		}
+		return n, err
+	}
+}
+
+// abort stops a decoder still waiting for data.
+func (z *inflater) abort() {
+	if !z.closed {
+		z.closed = true
+		close(z.in)
+		<-z.done
+	}
+}
+
+// feedReader is the decoder's source: the frames passed to feed.
+type feedReader struct {
+	z       *inflater
+	buf     []byte
+	started bool
+	out     *countingWriter
+}
+
+func (r *feedReader) Read(b []byte) (int, error) {
+	for len(r.buf) == 0 {
+		if r.started {
+			// Everything fed so far is decoded and written.
+			r.z.written <- r.out.take()
+			r.z.hungry <- struct{}{}
+		}
+		r.started = true
+		data, ok := <-r.z.in
+		if !ok {
+			return 0, io.ErrUnexpectedEOF
+		}
+		r.buf = data
+	}
+	n := copy(b, r.buf)
+	r.buf = r.buf[n:]
+	return n, nil
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(b []byte) (int, error) {
+	n, err := c.w.Write(b)
+	c.n += int64(n)
+	return n, err
+}
+
+// take returns the count since the last take.
+func (c *countingWriter) take() int64 {
+	n := c.n
+	c.n = 0
+	return n
+}
@@ -0,0 +1,415 @@
+package binkp
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCRAMResponse(t *testing.T) {
+	// RFC 2104 test case 2.
+	got := CRAMResponse("Jefe", []byte("what do ya want for nothing?"))
+	if want := "CRAM-MD5-750c783e6ab0b503eaa86e310a5db738"; got != want {
+		t.Errorf("CRAMResponse = %s, want %s", got, want)
+	}
+}
+
+func TestParseCRAMChallenge(t *testing.T) {
+	tests := []struct {
+		name    string
+		options []string
+		want    string
+		ok      bool
+	}{
+		{"md5 only", []string{"NR", "CRAM-MD5-f0315b074d728d483d6887d0182fc328"}, "f0315b074d728d483d6887d0182fc328", true},
+		{"md5 second choice", []string{"CRAM-SHA1/MD5-0a0b"}, "0a0b", true},
+		{"lower case", []string{"cram-md5-0a0b"}, "0a0b", true},
+		{"sha1 only", []string{"CRAM-SHA1-0a0b"}, "", false},
+		{"bad hex", []string{"CRAM-MD5-xyz"}, "", false},
+		{"no challenge", []string{"NR", "ND"}, "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := ParseCRAMChallenge(tt.options)
+			if ok != tt.ok || string(got) != string(mustHex(t, tt.want)) {
+				t.Errorf("ParseCRAMChallenge(%v) = %x, %v; want %s, %v", tt.options, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func mustHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestFileInfoArgs(t *testing.T) {
+	info := FileInfo{Name: `my file\1.pkt`, Size: 1234, ModTime: time.Unix(1700000000, 0), Offset: -1}
+	args := info.withOffset()
+	if want := `my\x20file\x5c1.pkt 1234 1700000000 -1`; args != want {
+		t.Fatalf("withOffset = %q, want %q", args, want)
+	}
+	got, err := parseFileInfo([]byte(args), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Name != info.Name || got.Size != info.Size || !got.ModTime.Equal(info.ModTime) || got.Offset != -1 {
+		t.Errorf("round trip = %+v, want %+v", got, info)
+	}
+	for _, bad := range []string{"a 1", "a x 1", "a 1 2 -2", `a\x2 1 2`} {
+		if _, err := parseFileInfo([]byte(bad), true); err == nil {
+			t.Errorf("parseFileInfo(%q) accepted", bad)
+		}
+	}
+}
+
+// peer is the answering side of a test session.
+type peer struct {
+	password string
+	nr       bool
+	options  Options
+	files    []OutboundFile
+
+	mu       sync.Mutex
+	received map[string][]byte
+	result   *TransferResult
+	err      error
+}
+
+func (p *peer) serve(conn net.Conn) {
+	defer conn.Close()
+	s := NewSession(conn, "2:5001/100@fidonet")
+	s.SetTimeout(5 * time.Second)
+	s.SetNR(p.nr)
+	s.SetOptions(p.options)
+	if p.err = s.Answer(func([]string) string { return p.password }); p.err != nil {
+		return
+	}
+	p.result, p.err = s.Transfer(p.files, func(info FileInfo) io.Writer {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		buf := &bytes.Buffer{}
+		if p.received == nil {
+			p.received = map[string][]byte{}
+		}
+		p.received[info.Name] = nil
+		return writerFunc(func(b []byte) (int, error) {
+			p.mu.Lock()
+			defer p.mu.Unlock()
+			buf.Write(b)
+			p.received[info.Name] = buf.Bytes()
+			return len(b), nil
+		})
+	})
+	_ = s.Close()
+}
+
+type writerFunc func([]byte) (int, error)
+
+func (f writerFunc) Write(b []byte) (int, error) { return f(b) }
+
+// dial starts p on a loopback listener and returns a connected caller.
+// net.Pipe will not do: both sides write their M_NUL frames before reading.
+func dial(t *testing.T, p *peer) (net.Conn, <-chan struct{}) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		p.serve(conn)
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, done
+}
+
+func fileOf(name string, data []byte) OutboundFile {
+	return OutboundFile{Name: name, Size: int64(len(data)), ModTime: time.Unix(1700000000, 0), Body: bytes.NewReader(data)}
+}
+
+func TestTransfer(t *testing.T) {
+	big := bytes.Repeat([]byte("0123456789abcdef"), 3000) // spans several data frames
+	probe := ProbeFile(time.Unix(1700000000, 0))
+
+	all := Options{Crypt: true, PLZ: true, GZ: true}
+
+	tests := []struct {
+		name          string
+		callerNR      bool
+		answerNR      bool
+		callerOptions Options
+		answerOptions Options
+		want          Options // negotiated
+	}{
+		{"reliable", false, false, Options{}, Options{}, Options{}},
+		{"caller asks for NR", true, false, Options{}, Options{}, Options{}},
+		{"answerer asks for NR", false, true, Options{}, Options{}, Options{}},
+		{"options offered by one side only", false, false, all, Options{}, Options{}},
+		{"CRYPT", false, false, Options{Crypt: true}, Options{Crypt: true}, Options{Crypt: true}},
+		{"PLZ", false, false, Options{PLZ: true}, Options{PLZ: true}, Options{PLZ: true}},
+		{"GZ", false, false, Options{GZ: true}, Options{GZ: true}, Options{GZ: true}},
+		{"everything in NR mode", true, false, all, all, all},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := &peer{password: "secret", nr: tt.answerNR, options: tt.answerOptions, files: []OutboundFile{fileOf("0000ffff.pkt", []byte("echomail"))}}
+			conn, done := dial(t, p)
+
+			s := NewSession(conn, "2:5001/1@fidonet")
+			s.SetTimeout(5 * time.Second)
+			s.SetPassword("secret")
+			s.SetNR(tt.callerNR)
+			s.SetOptions(tt.callerOptions)
+			if err := s.Handshake(); err != nil {
+				t.Fatalf("Handshake: %v", err)
+			}
+			if got, want := s.GetAuth(), (Auth{Method: "cram-md5", Secure: true}); got != want {
+				t.Errorf("auth = %+v, want %+v", got, want)
+			}
+			if got := s.Negotiated(); got != tt.want {
+				t.Errorf("negotiated %+v, want %+v", got, tt.want)
+			}
+			gz := fileOf("big.bin", big)
+			gz.Compression = "GZ"
+			res, err := s.Transfer([]OutboundFile{probe, gz}, SkipAll)
+			if err != nil {
+				t.Fatalf("Transfer: %v", err)
+			}
+			_ = s.Close()
+			<-done
+			if p.err != nil {
+				t.Fatalf("answering side: %v", p.err)
+			}
+
+			if len(res.Sent) != 2 || res.BytesSent != int64(len(big)) {
+				t.Errorf("sent %v (%d bytes), want probe and big.bin", res.Sent, res.BytesSent)
+			}
+			if len(res.SkippedByUs) != 1 || res.SkippedByUs[0].Name != "0000ffff.pkt" || len(res.Received) != 0 {
+				t.Errorf("inbound: received %v, skipped %v; want the remote's packet skipped", res.Received, res.SkippedByUs)
+			}
+			// Files moved in the first batch, so binkp/1.1 runs an empty second.
+			if res.Batches != 2 {
+				t.Errorf("batches = %d, want 2", res.Batches)
+			}
+			if got, ok := p.received[probe.Name]; !ok || len(got) != 0 {
+				t.Errorf("probe not received as an empty file: %q, %v", got, ok)
+			}
+			if !bytes.Equal(p.received["big.bin"], big) {
+				t.Errorf("big.bin arrived as %d bytes, want %d", len(p.received["big.bin"]), len(big))
+			}
+			if len(p.result.SkippedByRemote) != 1 {
+				t.Errorf("answering side saw %v skipped, want its packet", p.result.SkippedByRemote)
+			}
+			if wantGZ := map[bool]string{true: "GZ"}[tt.want.GZ]; len(res.Sent) == 2 && res.Sent[1].Compression != wantGZ {
+				t.Errorf("big.bin sent with compression %q, want %q", res.Sent[1].Compression, wantGZ)
+			}
+			// A GZ stream is not compressed again, so PLZ blocks only show
+			// up without GZ.
+			if wantPLZ := tt.want.PLZ && !tt.want.GZ; (p.result.CompressedBlocksReceived > 0) != wantPLZ || res.CompressedBlocksSent != p.result.CompressedBlocksReceived {
+				t.Errorf("PLZ blocks: sent %d, received %d; want some: %v", res.CompressedBlocksSent, p.result.CompressedBlocksReceived, wantPLZ)
+			}
+		})
+	}
+}
+
+func TestCryptConnPairs(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+	caller := newCryptConn(a, "secret", true)
+	answerer := newCryptConn(b, "secret", false)
+
+	for _, dir := range []struct {
+		name     string
+		from, to net.Conn
+	}{
+		{"caller to answerer", caller, answerer},
+		{"answerer to caller", answerer, caller},
+		{"caller again", caller, answerer},
+	} {
+		msg := []byte("M_OK secure, then data " + dir.name)
+		go func() { _, _ = dir.from.Write(msg) }()
+		got := make([]byte, len(msg))
+		if _, err := io.ReadFull(dir.to, got); err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, msg) {
+			t.Errorf("%s: got %q", dir.name, got)
+		}
+	}
+
+	// The two directions use different keys, so the stream does not decode
+	// with the wrong side's.
+	wire := []byte("same text")
+	newCipherKeys("secret").encrypt(wire)
+	other := newCipherKeys("-")
+	other.updateString("secret")
+	other.decrypt(wire)
+	if string(wire) == "same text" {
+		t.Error("both directions share a key stream")
+	}
+}
+
+func TestPLZFrame(t *testing.T) {
+	a, b := net.Pipe()
+	defer a.Close()
+	defer b.Close()
+
+	block := bytes.Repeat([]byte("compressible "), 1000)
+	random := make([]byte, plzMaxBlock)
+	if _, err := rand.Read(random); err != nil {
+		t.Fatal(err)
+	}
+	for _, tt := range []struct {
+		name           string
+		data           []byte
+		wantCompressed bool
+	}{
+		{"text", block, true},
+		{"incompressible block sent plain", random, false},
+	} {
+		f := &Frame{Data: tt.data, Compressed: true}
+		go func() { _ = WriteFrame(a, f) }()
+		got, err := readFrame(b, true)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got.Compressed != tt.wantCompressed || !bytes.Equal(got.Data, tt.data) {
+			t.Errorf("%s: compressed %v, %d bytes; want %v, %d", tt.name, got.Compressed, len(got.Data), tt.wantCompressed, len(tt.data))
+		}
+	}
+}
+
+func TestHandshakeWrongPassword(t *testing.T) {
+	p := &peer{password: "secret"}
+	conn, done := dial(t, p)
+
+	s := NewSession(conn, "2:5001/1@fidonet")
+	s.SetTimeout(5 * time.Second)
+	s.SetPassword("guess")
+	err := s.Handshake()
+	var remote *RemoteError
+	if !errors.As(err, &remote) {
+		t.Fatalf("Handshake = %v, want a RemoteError", err)
+	}
+	if len(s.GetNodeInfo().Addresses) == 0 {
+		t.Error("remote address not kept after a refused password")
+	}
+	conn.Close()
+	<-done
+	if p.err == nil {
+		t.Error("answering side accepted a wrong password")
+	}
+}
+
+func TestHandshakeNoPasswordOnRemote(t *testing.T) {
+	p := &peer{}
+	conn, done := dial(t, p)
+
+	s := NewSession(conn, "2:5001/1@fidonet")
+	s.SetTimeout(5 * time.Second)
+	s.SetPassword("secret")
+	if err := s.Handshake(); err != nil {
+		t.Fatal(err)
+	}
+	if got := s.GetAuth(); got.Secure {
+		t.Errorf("auth = %+v, want a non-secure session", got)
+	}
+	if _, err := s.Transfer(nil, SkipAll); err != nil {
+		t.Fatal(err)
+	}
+	_ = s.Close()
+	<-done
+}
+
+// plainNode answers one session the way a mailer without CRAM does and
+// reports the password it was sent, or "" if none came.
+func plainNode(t *testing.T) (net.Conn, <-chan string) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			got <- ""
+			return
+		}
+		defer conn.Close()
+		_ = WriteFrame(conn, CreateM_NUL("SYS", "Plain Node"))
+		_ = WriteFrame(conn, CreateM_ADR("2:5001/100@fidonet"))
+		for {
+			frame, err := ReadFrame(conn)
+			if err != nil || frame.Type == M_ERR {
+				got <- ""
+				return
+			}
+			if frame.Type == M_PWD {
+				got <- string(frame.Data)
+				_ = WriteFrame(conn, &Frame{Type: M_OK, Command: true, Data: []byte("secure")})
+				return
+			}
+		}
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn, got
+}
+
+func TestHandshakeWithoutCRAM(t *testing.T) {
+	tests := []struct {
+		name       string
+		allowPlain bool
+		wantErr    error
+		wantSent   string
+		wantMethod string
+	}{
+		{"plain refused", false, ErrNoCRAM, "", ""},
+		{"plain allowed", true, nil, "secret", "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			conn, got := plainNode(t)
+			s := NewSession(conn, "2:5001/1@fidonet")
+			s.SetTimeout(5 * time.Second)
+			s.SetPassword("secret")
+			s.SetAllowPlain(tt.allowPlain)
+			if err := s.Handshake(); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Handshake = %v, want %v", err, tt.wantErr)
+			}
+			if sent := <-got; sent != tt.wantSent {
+				t.Errorf("remote was sent password %q, want %q", sent, tt.wantSent)
+			}
+			if got := s.GetAuth().Method; got != tt.wantMethod {
+				t.Errorf("auth method = %q, want %q", got, tt.wantMethod)
+			}
+		})
+	}
+}
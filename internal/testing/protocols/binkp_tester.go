@@ -1,7 +1,11 @@
 package protocols
 
 import (
+	"bytes"
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
 	"fmt"
 	"net"
 	"os"
@@ -10,8 +14,24 @@ import (
 
 	"github.com/nodelistdb/internal/testing/logging"
 	"github.com/nodelistdb/internal/testing/protocols/binkp"
+	"github.com/nodelistdb/internal/testing/transcript"
 )
 
+// Verdicts on an option the remote advertised, as stored in the binkp_opt_*
+// columns. An option the remote did not advertise gets "".
+const (
+	binkpOptionOK       = "ok"       // exercised and it worked
+	binkpOptionFailed   = "failed"   // malformed, or the transfer using it broke off
+	binkpOptionUntested = "untested" // advertised; this session could not exercise it
+)
+
+// binkpProbeText is what the probe files carry when a compression option is
+// to be exercised: an empty file sends no data frames.
+var binkpProbeText = []byte(strings.Repeat("NodelistDB BinkP option probe. Safe to delete.\r\n", 8))
+
+// binkpsDefaultPort is where nodes announcing IBNS run BinkP over TLS.
+const binkpsDefaultPort = 24553
+
 // BinkPTester tests BinkP protocol connectivity
 type BinkPTester struct {
 	timeout     time.Duration
@@ -21,6 +41,10 @@ type BinkPTester struct {
 	location    string
 	defaultPort int
 	debug       bool
+	passwords   map[string]string // normalized node address -> session password
+	requireCRAM bool              // never send a password in plain text over plain BinkP
+	roots       *x509.CertPool    // trust anchors for binkps chains; nil means the system pool
+	capture     bool              // record a transcript of every session
 }
 
 // GetProtocolName returns the protocol name
@@ -41,6 +65,7 @@ func NewBinkPTester(timeout time.Duration, ourAddress string) *BinkPTester {
 		location:    "Test Location",
 		defaultPort: 24554,
 		debug:       debug,
+		requireCRAM: true,
 	}
 }
 
@@ -57,17 +82,31 @@ func NewBinkPTesterWithInfo(timeout time.Duration, ourAddress, systemName, sysop
 		location:    location,
 		defaultPort: 24554,
 		debug:       debug,
+		requireCRAM: true,
 	}
 }
 
 // Test performs a BinkP connectivity test
 func (t *BinkPTester) Test(ctx context.Context, host string, port int, expectedAddress string) TestResult {
-	startTime := time.Now()
-
-	// Use default port if not specified
 	if port == 0 {
 		port = t.defaultPort
 	}
+	return t.test(ctx, host, port, expectedAddress, false, "")
+}
+
+// TestTLS performs the same test against a node's binkps service, BinkP
+// inside TLS. The certificate is recorded whatever its state: an expired or
+// self-signed one does not stop the session, it is what the test is after.
+// serverName is the host name the certificate should be issued for.
+func (t *BinkPTester) TestTLS(ctx context.Context, host string, port int, expectedAddress, serverName string) TestResult {
+	if port == 0 {
+		port = binkpsDefaultPort
+	}
+	return t.test(ctx, host, port, expectedAddress, true, serverName)
+}
+
+func (t *BinkPTester) test(ctx context.Context, host string, port int, expectedAddress string, useTLS bool, serverName string) TestResult {
+	startTime := time.Now()
 
 	// Parse hostname:port if port is in the hostname
 	// But skip this for IPv6 addresses (which contain colons)
@@ -107,26 +146,124 @@ func (t *BinkPTester) Test(ctx context.Context, host string, port int, expectedA
 			},
 		}
 	}
-	defer conn.Close()
+	defer func() { conn.Close() }()
+
+	var certificate *TLSCertificateInfo
+	if useTLS {
+		tlsConn := tls.Client(conn, &tls.Config{
+			// inspectCertificate judges the chain; the handshake only has
+			// to get us the certificates.
+			InsecureSkipVerify: true, //nolint:gosec
+			ServerName:         tlsServerName(serverName),
+		})
+		_ = tlsConn.SetDeadline(time.Now().Add(t.timeout))
+		if err := tlsConn.HandshakeContext(ctx); err != nil {
+			return &BinkPTestResult{
+				BaseTestResult: BaseTestResult{
+					Success:    false,
+					Error:      fmt.Sprintf("tls handshake failed: %v", err),
+					ResponseMs: uint32(time.Since(startTime).Milliseconds()),
+					TestTime:   startTime,
+				},
+				TLS: true,
+			}
+		}
+		_ = tlsConn.SetDeadline(time.Time{})
+		certificate = inspectCertificate(tlsConn.ConnectionState().PeerCertificates, serverName, time.Now(), t.roots)
+		conn = tlsConn
+	}
+
+	// The recorder goes above TLS: the BinkP frames are what is worth
+	// reading, not the records carrying them.
+	var recorder *transcript.Recorder
+	if t.capture {
+		protocol := "binkp"
+		if useTLS {
+			protocol = "binkps"
+		}
+		recorder = transcript.Record(conn, protocol)
+		conn = recorder
+	}
+	withTranscript := func(result *BinkPTestResult) *BinkPTestResult {
+		if recorder != nil {
+			result.Transcript = recorder.Transcript()
+			transcript.RedactBinkPPasswords(result.Transcript)
+		}
+		return result
+	}
 
 	// Create BinkP session with custom system info
 	session := binkp.NewSessionWithInfo(conn, t.ourAddress, t.systemName, t.sysop, t.location)
 	session.SetTimeout(t.timeout)
 	session.SetDebug(t.debug)
 
+	password := t.passwords[binkp.NormalizeAddress(expectedAddress)]
+	if password != "" {
+		session.SetPassword(password)
+		// TLS already keeps a plain password off the wire.
+		session.SetAllowPlain(useTLS || !t.requireCRAM)
+		// The options are only exercised by the probe transfer, so an
+		// anonymous session does not offer them.
+		session.SetOptions(binkp.Options{Crypt: true, PLZ: true, GZ: true})
+	}
+
 	// Perform handshake
 	err = session.Handshake()
+	var remoteErr *binkp.RemoteError
+	if err != nil && password != "" && errors.As(err, &remoteErr) && len(session.GetNodeInfo().Addresses) > 0 {
+		// The node answered and identified itself, then refused our
+		// password: reachable, but mail would not flow.
+		result := t.buildResult(session, expectedAddress, port, startTime)
+		result.TLS, result.Certificate = useTLS, certificate
+		result.AuthMethod = session.GetAuth().Method
+		result.MailExchange = "auth-failed"
+		result.MailDetail = remoteErr.Message
+		return withTranscript(result)
+	}
+	if errors.Is(err, binkp.ErrNoCRAM) {
+		// We refused to send the password in plain text: the node is
+		// reachable, but we could not authenticate to it.
+		result := t.buildResult(session, expectedAddress, port, startTime)
+		result.TLS, result.Certificate = useTLS, certificate
+		result.MailExchange = "auth-failed"
+		result.MailDetail = "no-cram: " + err.Error()
+		return withTranscript(result)
+	}
 	if err != nil {
-		return &BinkPTestResult{
+		return withTranscript(&BinkPTestResult{
 			BaseTestResult: BaseTestResult{
 				Success:    false,
 				Error:      fmt.Sprintf("handshake failed: %v", err),
 				ResponseMs: uint32(time.Since(startTime).Milliseconds()),
 				TestTime:   startTime,
 			},
+			TLS:         useTLS,
+			Certificate: certificate,
+		})
+	}
+
+	result := t.buildResult(session, expectedAddress, port, startTime)
+	result.TLS, result.Certificate = useTLS, certificate
+	if password != "" {
+		auth := session.GetAuth()
+		result.AuthMethod = auth.Method
+		if auth.Method == "cram-md5" && auth.Secure {
+			// Only a remote holding our password can have checked the
+			// answer; one that calls the session non-secure accepts anything.
+			result.OptCRAM = binkpOptionOK
 		}
+		t.exchangeMail(session, result)
 	}
 
+	// Close session gracefully
+	session.Close()
+
+	result.ResponseMs = uint32(time.Since(startTime).Milliseconds())
+	return withTranscript(result)
+}
+
+// buildResult reports what the handshake learned about the remote.
+func (t *BinkPTester) buildResult(session *binkp.Session, expectedAddress string, port int, startTime time.Time) *BinkPTestResult {
 	// Get remote node information
 	nodeInfo := session.GetNodeInfo()
 
@@ -141,9 +278,6 @@ func (t *BinkPTester) Test(ctx context.Context, host string, port int, expectedA
 		}
 	}
 
-	// Close session gracefully
-	session.Close()
-
 	// Build capabilities list
 	capabilities := nodeInfo.Capabilities
 	if nodeInfo.Flags != "" {
@@ -151,6 +285,20 @@ func (t *BinkPTester) Test(ctx context.Context, host string, port int, expectedA
 		capabilities = append(capabilities, strings.Fields(nodeInfo.Flags)...)
 	}
 
+	advertised := func(opt string) string {
+		for _, o := range nodeInfo.Capabilities {
+			if strings.EqualFold(o, opt) {
+				return binkpOptionUntested
+			}
+		}
+		return ""
+	}
+	optGZ := advertised("GZ")
+	if optGZ != "" && advertised("EXTCMD") == "" {
+		// GZ is an M_FILE argument, which only EXTCMD allows.
+		optGZ = binkpOptionFailed
+	}
+
 	return &BinkPTestResult{
 		BaseTestResult: BaseTestResult{
 			Success:    true,
@@ -166,10 +314,189 @@ func (t *BinkPTester) Test(ctx context.Context, host string, port int, expectedA
 		Capabilities: capabilities,
 		AddressValid: addressValid,
 		Port:         port,
+		OptCRAM:      cramVerdict(nodeInfo.Capabilities),
+		OptCrypt:     advertised("CRYPT"),
+		OptPLZ:       advertised("PLZ"),
+		OptGZ:        optGZ,
+		// We have no bzip2 compressor, so BZ2 is never negotiated.
+		OptBZ2: advertised("BZ2"),
 	}
 }
 
+// cramVerdict judges the remote's CRAM challenge before it is used: one that
+// offers MD5 but cannot be parsed is broken; SHA1-only challenges are ones
+// we do not answer.
+func cramVerdict(options []string) string {
+	for _, opt := range options {
+		if !strings.HasPrefix(strings.ToUpper(opt), "CRAM-") {
+			continue
+		}
+		if _, ok := binkp.ParseCRAMChallenge([]string{opt}); !ok && strings.Contains(strings.ToUpper(opt), "MD5") {
+			return binkpOptionFailed
+		}
+		return binkpOptionUntested
+	}
+	return ""
+}
+
+// exchangeMail runs the transfer stage of a password-protected session and
+// records the outcome in result. It sends a probe file and skips whatever
+// the node has queued for us, so its mail stays where it is. With PLZ or GZ
+// negotiated the probes carry text so that the compression is exercised:
+// the first probe in PLZ blocks, a second one as a GZ stream.
+func (t *BinkPTester) exchangeMail(session *binkp.Session, result *BinkPTestResult) {
+	if !session.GetAuth().Secure {
+		// The remote holds no password for us. Anything we sent would land
+		// in its unprotected inbound, so the probe is not sent.
+		result.MailExchange = "not-secure"
+		result.MailDetail = "remote accepted the session without a password for us"
+		return
+	}
+
+	negotiated := session.Negotiated()
+	now := time.Now()
+	probe := binkp.ProbeFile(now)
+	if negotiated.PLZ {
+		probe.Body, probe.Size = bytes.NewReader(binkpProbeText), int64(len(binkpProbeText))
+	}
+	files := []binkp.OutboundFile{probe}
+	gzProbe := binkp.ProbeFile(now)
+	if negotiated.GZ {
+		gzProbe.Name = strings.TrimSuffix(gzProbe.Name, ".tst") + ".tsz"
+		gzProbe.Body, gzProbe.Size = bytes.NewReader(binkpProbeText), int64(len(binkpProbeText))
+		gzProbe.Compression = "GZ"
+		files = append(files, gzProbe)
+	}
+
+	res, err := session.Transfer(files, binkp.SkipAll)
+
+	// A probe the remote skipped tells nothing about the option it was
+	// sent with; one left unacknowledged when the transfer broke off
+	// counts against it.
+	verdict := func(name string) (binkp.FileInfo, string) {
+		for _, f := range res.Sent {
+			if f.Name == name {
+				return f, binkpOptionOK
+			}
+		}
+		for _, f := range res.SkippedByRemote {
+			if f.Name == name {
+				return f, binkpOptionUntested
+			}
+		}
+		if err != nil {
+			return binkp.FileInfo{}, binkpOptionFailed
+		}
+		return binkp.FileInfo{}, binkpOptionUntested
+	}
+	if negotiated.PLZ {
+		_, v := verdict(probe.Name)
+		if v == binkpOptionOK && res.CompressedBlocksSent == 0 {
+			v = binkpOptionUntested
+		}
+		result.OptPLZ = v
+	}
+	if negotiated.GZ {
+		info, v := verdict(gzProbe.Name)
+		if v == binkpOptionOK && info.Compression != "GZ" {
+			v = binkpOptionUntested
+		}
+		result.OptGZ = v
+	}
+	if negotiated.Crypt {
+		// Every frame after M_OK went through the cipher.
+		result.OptCrypt = binkpOptionOK
+		if err != nil {
+			result.OptCrypt = binkpOptionFailed
+		}
+	}
+
+	if err != nil {
+		result.MailExchange, result.MailDetail = "failed", err.Error()
+		return
+	}
+	var waiting string
+	if n := len(res.SkippedByUs); n > 0 {
+		waiting = fmt.Sprintf("; %d file(s) queued for us left in place", n)
+	}
+	if _, v := verdict(probe.Name); v != binkpOptionOK {
+		result.MailExchange, result.MailDetail = "refused", "remote skipped the probe file"+waiting
+		return
+	}
+	result.MailExchange, result.MailDetail = "ok", "probe file accepted"+waiting
+}
+
+// tlsServerName is the SNI name to send: none when the node is known only by
+// its IP address.
+func tlsServerName(serverName string) string {
+	if net.ParseIP(serverName) != nil {
+		return ""
+	}
+	return serverName
+}
+
+// inspectCertificate describes the leaf of the chain a server presented and
+// verifies the chain against roots (the system pool when nil) as of now.
+// An empty serverName skips the host name check.
+func inspectCertificate(chain []*x509.Certificate, serverName string, now time.Time, roots *x509.CertPool) *TLSCertificateInfo {
+	if len(chain) == 0 {
+		return &TLSCertificateInfo{Error: "no certificate presented"}
+	}
+	leaf := chain[0]
+	info := &TLSCertificateInfo{
+		Subject:  leaf.Subject.String(),
+		Issuer:   leaf.Issuer.String(),
+		NotAfter: leaf.NotAfter,
+		// Signed with its own key. CheckSignatureFrom would insist the
+		// certificate be a CA, which a self-signed leaf usually is not.
+		SelfSigned: bytes.Equal(leaf.RawSubject, leaf.RawIssuer) &&
+			leaf.CheckSignature(leaf.SignatureAlgorithm, leaf.RawTBSCertificate, leaf.Signature) == nil,
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, c := range chain[1:] {
+		intermediates.AddCert(c)
+	}
+	opts := x509.VerifyOptions{
+		Roots:         roots,
+		Intermediates: intermediates,
+		CurrentTime:   now,
+	}
+	if serverName != "" {
+		opts.DNSName = serverName
+	}
+	if _, err := leaf.Verify(opts); err != nil {
+		info.Error = err.Error()
+	} else {
+		info.ChainValid = true
+	}
+	return info
+}
+
 // SetDebug enables or disables debug mode
 func (t *BinkPTester) SetDebug(enabled bool) {
 	t.debug = enabled
 }
+
+// SetTranscriptCapture turns recording of each session's transcript on or
+// off. Implements the TranscriptSetter interface.
+func (t *BinkPTester) SetTranscriptCapture(enabled bool) {
+	t.capture = enabled
+}
+
+// SetSessionPasswords sets the session passwords agreed with individual
+// nodes, keyed by address. A node with a password gets an authenticated
+// session and a probe transfer instead of the anonymous handshake.
+func (t *BinkPTester) SetSessionPasswords(passwords map[string]string) {
+	t.passwords = make(map[string]string, len(passwords))
+	for addr, password := range passwords {
+		t.passwords[binkp.NormalizeAddress(addr)] = password
+	}
+}
+
+// SetRequireCRAM sets whether a node that offers no CRAM-MD5 challenge is
+// refused the password rather than sent it in plain text. On by default;
+// binkps sessions may always send it, inside TLS.
+func (t *BinkPTester) SetRequireCRAM(require bool) {
+	t.requireCRAM = require
+}
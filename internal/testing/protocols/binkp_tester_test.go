@@ -0,0 +1,347 @@
+package protocols
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/protocols/binkp"
+)
+
+// fakeBinkPNode answers one BinkP session on a loopback port, expecting
+// password and offering options, and keeps whatever it is sent.
+func fakeBinkPNode(t *testing.T, password string, options binkp.Options, queued ...binkp.OutboundFile) (int, <-chan int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return serveFakeBinkPNode(t, ln, password, options, queued...)
+}
+
+// serveFakeBinkPNode is fakeBinkPNode on a listener of the caller's choosing.
+func serveFakeBinkPNode(t *testing.T, ln net.Listener, password string, options binkp.Options, queued ...binkp.OutboundFile) (int, <-chan int) {
+	t.Helper()
+	t.Cleanup(func() { ln.Close() })
+
+	received := make(chan int, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		s := binkp.NewSession(conn, "2:5001/100@fidonet")
+		s.SetTimeout(5 * time.Second)
+		s.SetOptions(options)
+		if err := s.Answer(func([]string) string { return password }); err != nil {
+			received <- -1
+			return
+		}
+		files := 0
+		_, _ = s.Transfer(queued, func(binkp.FileInfo) io.Writer {
+			files++
+			return io.Discard
+		})
+		_ = s.Close()
+		received <- files
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return p, received
+}
+
+func TestBinkPTesterMailExchange(t *testing.T) {
+	queued := binkp.OutboundFile{Name: "0000ffff.pkt", Size: 4, Body: bytes.NewReader([]byte("mail"))}
+
+	tests := []struct {
+		name      string
+		ours      map[string]string
+		theirs    string
+		want      string // MailExchange
+		wantAuth  string
+		wantFiles int // files the node received; -1 when it refused the session
+	}{
+		{"no password for the node", nil, "", "", "", 0},
+		{"authenticated exchange", map[string]string{"2:5001/100@fidonet": "secret"}, "secret", "ok", "cram-md5", 1},
+		{"wrong password", map[string]string{"2:5001/100": "guess"}, "secret", "auth-failed", "cram-md5", -1},
+		{"node has no password for us", map[string]string{"2:5001/100": "secret"}, "", "not-secure", "cram-md5", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port, received := fakeBinkPNode(t, tt.theirs, binkp.Options{}, queued)
+			tester := NewBinkPTester(5*time.Second, "2:5001/1@fidonet")
+			tester.SetSessionPasswords(tt.ours)
+
+			res, ok := tester.Test(context.Background(), "127.0.0.1", port, "2:5001/100").(*BinkPTestResult)
+			if !ok || !res.Success {
+				t.Fatalf("Test = %+v, want a reachable node", res)
+			}
+			if !res.AddressValid {
+				t.Error("address not validated")
+			}
+			if res.MailExchange != tt.want || res.AuthMethod != tt.wantAuth {
+				t.Errorf("mail exchange = %q (%s) via %q, want %q via %q", res.MailExchange, res.MailDetail, res.AuthMethod, tt.want, tt.wantAuth)
+			}
+			if tt.want == "ok" && res.MailDetail != "probe file accepted; 1 file(s) queued for us left in place" {
+				t.Errorf("detail = %q", res.MailDetail)
+			}
+			if got := <-received; got != tt.wantFiles {
+				t.Errorf("node received %d files, want %d", got, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestBinkPTesterOptions(t *testing.T) {
+	all := binkp.Options{Crypt: true, PLZ: true, GZ: true}
+	ours := map[string]string{"2:5001/100": "secret"}
+
+	tests := []struct {
+		name      string
+		ours      map[string]string
+		theirs    string
+		options   binkp.Options
+		want      [4]string // CRAM, CRYPT, PLZ, GZ
+		wantFiles int
+	}{
+		// Our fake node always offers a CRAM challenge.
+		{"anonymous session", nil, "", all, [4]string{"untested", "", "untested", "untested"}, 0},
+		{"all options work", ours, "secret", all, [4]string{"ok", "ok", "ok", "ok"}, 2},
+		{"no options offered", ours, "secret", binkp.Options{}, [4]string{"ok", "", "", ""}, 1},
+		{"node has no password for us", ours, "", all, [4]string{"untested", "", "untested", "untested"}, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			port, received := fakeBinkPNode(t, tt.theirs, tt.options)
+			tester := NewBinkPTester(5*time.Second, "2:5001/1@fidonet")
+			tester.SetSessionPasswords(tt.ours)
+
+			res, ok := tester.Test(context.Background(), "127.0.0.1", port, "2:5001/100").(*BinkPTestResult)
+			if !ok || !res.Success {
+				t.Fatalf("Test = %+v, want a reachable node", res)
+			}
+			if got := [4]string{res.OptCRAM, res.OptCrypt, res.OptPLZ, res.OptGZ}; got != tt.want {
+				t.Errorf("CRAM, CRYPT, PLZ, GZ = %q, want %q (%s)", got, tt.want, res.MailDetail)
+			}
+			if got := <-received; got != tt.wantFiles {
+				t.Errorf("node received %d files, want %d", got, tt.wantFiles)
+			}
+		})
+	}
+}
+
+func TestCRAMVerdict(t *testing.T) {
+	tests := []struct {
+		options []string
+		want    string
+	}{
+		{[]string{"NR", "CRAM-MD5-0a0b"}, "untested"},
+		{[]string{"CRAM-MD5-zz"}, "failed"},
+		{[]string{"CRAM-MD5-"}, "failed"},
+		{[]string{"CRAM-SHA1-0a0b"}, "untested"},
+		{[]string{"NR", "PLZ"}, ""},
+	}
+	for _, tt := range tests {
+		if got := cramVerdict(tt.options); got != tt.want {
+			t.Errorf("cramVerdict(%v) = %q, want %q", tt.options, got, tt.want)
+		}
+	}
+}
+
+// testCertificate issues a certificate for host valid until notAfter, signed
+// by parent (self-signed when parent is nil).
+func testCertificate(t *testing.T, host string, notAfter time.Time, isCA bool, parent *tls.Certificate) tls.Certificate {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: host},
+		DNSNames:              []string{host},
+		NotBefore:             time.Now().Add(-365 * 24 * time.Hour),
+		NotAfter:              notAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: isCA,
+		IsCA:                  isCA,
+	}
+	signer, signerKey := tmpl, any(key)
+	if parent != nil {
+		signer, signerKey = parent.Leaf, parent.PrivateKey
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, signer, &key.PublicKey, signerKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}
+}
+
+func TestInspectCertificate(t *testing.T) {
+	now := time.Now()
+	year := 365 * 24 * time.Hour
+	ca := testCertificate(t, "Test CA", now.Add(10*year), true, nil)
+	roots := x509.NewCertPool()
+	roots.AddCert(ca.Leaf)
+
+	valid := testCertificate(t, "bbs.example.com", now.Add(year), false, &ca)
+	expired := testCertificate(t, "bbs.example.com", now.Add(-24*time.Hour), false, &ca)
+	selfSigned := testCertificate(t, "bbs.example.com", now.Add(year), false, nil)
+
+	tests := []struct {
+		name           string
+		cert           tls.Certificate
+		serverName     string
+		wantValid      bool
+		wantSelfSigned bool
+		wantErr        string
+	}{
+		{"chains to a trusted root", valid, "bbs.example.com", true, false, ""},
+		{"issued for another host", valid, "other.example.com", false, false, "other.example.com"},
+		{"no host name to check", valid, "", true, false, ""},
+		{"expired", expired, "bbs.example.com", false, false, "expired"},
+		{"self-signed", selfSigned, "bbs.example.com", false, true, "unknown authority"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			info := inspectCertificate([]*x509.Certificate{tt.cert.Leaf}, tt.serverName, now, roots)
+			if info.ChainValid != tt.wantValid || info.SelfSigned != tt.wantSelfSigned {
+				t.Errorf("valid, self-signed = %v, %v, want %v, %v (%s)", info.ChainValid, info.SelfSigned, tt.wantValid, tt.wantSelfSigned, info.Error)
+			}
+			if !strings.Contains(info.Error, tt.wantErr) || (tt.wantErr == "") != (info.Error == "") {
+				t.Errorf("error = %q, want one mentioning %q", info.Error, tt.wantErr)
+			}
+			if info.Subject != "CN=bbs.example.com" || !info.NotAfter.Equal(tt.cert.Leaf.NotAfter) {
+				t.Errorf("subject %q, not after %v", info.Subject, info.NotAfter)
+			}
+		})
+	}
+}
+
+func TestBinkPTesterTLS(t *testing.T) {
+	cert := testCertificate(t, "bbs.example.com", time.Now().Add(24*time.Hour), false, nil)
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port, received := serveFakeBinkPNode(t, tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}}), "", binkp.Options{})
+
+	tester := NewBinkPTester(5*time.Second, "2:5001/1@fidonet")
+	res, ok := tester.TestTLS(context.Background(), "127.0.0.1", port, "2:5001/100", "bbs.example.com").(*BinkPTestResult)
+	if !ok || !res.Success {
+		t.Fatalf("TestTLS = %+v, want a reachable node", res)
+	}
+	if !res.TLS || !res.AddressValid {
+		t.Errorf("TLS = %v, address valid = %v", res.TLS, res.AddressValid)
+	}
+	if c := res.Certificate; c == nil || !c.SelfSigned || c.ChainValid || c.Subject != "CN=bbs.example.com" {
+		t.Errorf("certificate = %+v, want the self-signed one", c)
+	}
+	if got := <-received; got != 0 {
+		t.Errorf("node received %d files, want 0", got)
+	}
+
+	// A plain BinkP client gets nowhere with a TLS listener, and the other
+	// way round.
+	plain, _ := fakeBinkPNode(t, "", binkp.Options{})
+	if res := tester.TestTLS(context.Background(), "127.0.0.1", plain, "2:5001/100", ""); res.IsSuccess() || !strings.Contains(res.GetError(), "tls handshake failed") {
+		t.Errorf("TestTLS against plain BinkP = %v, %q", res.IsSuccess(), res.GetError())
+	}
+}
+
+// plainBinkPNode answers one session on ln the way a mailer without CRAM
+// does, and reports the password it was sent, or "" if none came.
+func plainBinkPNode(t *testing.T, ln net.Listener) (int, <-chan string) {
+	t.Helper()
+	t.Cleanup(func() { ln.Close() })
+	got := make(chan string, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			got <- ""
+			return
+		}
+		defer conn.Close()
+		_ = binkp.WriteFrame(conn, binkp.CreateM_NUL("SYS", "Plain Node"))
+		_ = binkp.WriteFrame(conn, binkp.CreateM_ADR("2:5001/100@fidonet"))
+		for {
+			frame, err := binkp.ReadFrame(conn)
+			if err != nil || frame.Type == binkp.M_ERR {
+				got <- ""
+				return
+			}
+			if frame.Type == binkp.M_PWD {
+				got <- string(frame.Data)
+				_ = binkp.WriteFrame(conn, &binkp.Frame{Type: binkp.M_OK, Command: true, Data: []byte("secure")})
+				return
+			}
+		}
+	}()
+	_, port, _ := net.SplitHostPort(ln.Addr().String())
+	p, _ := strconv.Atoi(port)
+	return p, got
+}
+
+func TestBinkPTesterWithoutCRAM(t *testing.T) {
+	cert := testCertificate(t, "bbs.example.com", time.Now().Add(24*time.Hour), false, nil)
+	tests := []struct {
+		name        string
+		tls         bool
+		requireCRAM bool
+		wantSent    string
+		wantAuth    string
+	}{
+		{"plain binkp refused", false, true, "", ""},
+		{"plain binkp allowed", false, false, "secret", "plain"},
+		{"binkps", true, true, "secret", "plain"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, err := net.Listen("tcp", "127.0.0.1:0")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.tls {
+				ln = tls.NewListener(ln, &tls.Config{Certificates: []tls.Certificate{cert}})
+			}
+			port, sent := plainBinkPNode(t, ln)
+			tester := NewBinkPTester(5*time.Second, "2:5001/1@fidonet")
+			tester.SetSessionPasswords(map[string]string{"2:5001/100": "secret"})
+			tester.SetRequireCRAM(tt.requireCRAM)
+
+			var res *BinkPTestResult
+			if tt.tls {
+				res, _ = tester.TestTLS(context.Background(), "127.0.0.1", port, "2:5001/100", "bbs.example.com").(*BinkPTestResult)
+			} else {
+				res, _ = tester.Test(context.Background(), "127.0.0.1", port, "2:5001/100").(*BinkPTestResult)
+			}
+			if got := <-sent; got != tt.wantSent {
+				t.Errorf("node was sent password %q, want %q", got, tt.wantSent)
+			}
+			if res == nil || res.AuthMethod != tt.wantAuth {
+				t.Fatalf("result = %+v, want auth method %q", res, tt.wantAuth)
+			}
+			if tt.wantSent == "" && (!res.Success || res.MailExchange != "auth-failed" || !strings.HasPrefix(res.MailDetail, "no-cram: ")) {
+				t.Errorf("success %v, mail exchange %q (%s); want a reachable node refused as no-cram", res.Success, res.MailExchange, res.MailDetail)
+			}
+		})
+	}
+}
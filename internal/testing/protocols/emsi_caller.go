@@ -0,0 +1,148 @@
+package protocols
+
+import (
+	"fmt"
+	"net"
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/logging"
+	"github.com/xx25/fidomail/pkg/emsi"
+)
+
+// emsiCaller is the calling side of an EMSI handshake run over a transport
+// some other protocol tester has already opened: the VMODEM data channel, or
+// a telnet-binary stream on an ITN port. It carries the identity we advertise
+// and the per-node EMSI settings; IfcicoTester keeps its own copy because it
+// reports the handshake differently.
+type emsiCaller struct {
+	label      string // log prefix, e.g. "VModem"
+	timeout    time.Duration
+	ourAddress string
+	systemName string
+	sysop      string
+	location   string
+	debug      bool
+	configMgr  *emsi.ConfigManager
+}
+
+// emsiRemote is what a remote mailer announced during an EMSI handshake.
+type emsiRemote struct {
+	Identified   bool   // a genuine EMSI_DAT was read, not a guess from the banner
+	Software     string // mailer name and version; may come from the banner alone
+	SystemName   string // with the location appended when it is not already there
+	Sysop        string
+	Location     string
+	Addresses    []string
+	AddressValid bool // expected address present in Addresses
+}
+
+// emsiCallerStrategy makes the EMSI session announce itself with EMSI_INQ at
+// connect instead of waiting to be spoken to.
+//
+// This is only the preamble; the calling role itself is pinned by
+// HandshakeCaller (see call), which is what makes a spec-strict answerer
+// respond at all. The announce is kept because it is what our field results
+// were measured with — it saves a REQ round-trip against mailers that greet
+// first, and it is what gained identity from 2:371/52 (Xenia/2 Mailer) and
+// 2:420/33 (FrontDoor) where waiting to be spoken to did not.
+const emsiCallerStrategy = "send_inq"
+
+// emsiBannerPlaceholder is the system name pkg/emsi substitutes when it gave up
+// on the handshake and read the product name out of the mailer's banner
+// instead. It marks a guess, not an announced identity.
+const emsiBannerPlaceholder = "[Extracted from banner]"
+
+// call runs an EMSI handshake as the calling side over c and returns whatever
+// the remote announced, with a short note on the outcome. The note is empty
+// only when a full EMSI identity was read cleanly. Everything else names the
+// fault, because those faults are not the same thing: a handshake that never
+// completed, one that completed while the peer supplied nothing usable, and
+// one that produced only a product name guessed from the mailer's banner are
+// three different states of the far side.
+func (e *emsiCaller) call(c net.Conn, expectedAddress string) (remote emsiRemote, note string) {
+	var cfg *emsi.Config
+	if e.configMgr != nil {
+		cfg = e.configMgr.GetConfigForNode(expectedAddress)
+	} else {
+		cfg = emsi.DefaultConfig()
+	}
+	cfg.MailerName = "NodelistDB"
+	cfg.MailerVersion = mailerVersion
+	if cfg.InitialStrategy == "" || cfg.InitialStrategy == "wait" {
+		cfg.InitialStrategy = emsiCallerStrategy
+	}
+
+	session := emsi.NewSessionWithInfoAndConfig(c, e.ourAddress, e.systemName, e.sysop, e.location, cfg)
+	if e.configMgr == nil {
+		session.SetTimeout(e.timeout)
+	}
+	session.SetDebug(e.debug)
+
+	// We dialed, so pin the calling role: the peer's EMSI_REQ is answered with
+	// EMSI_INQ before our EMSI_DAT, which is what a strict answerer waits for.
+	err := session.HandshakeCaller()
+	defer session.Close()
+
+	info := session.GetRemoteInfo()
+	reason := session.GetCompletionReason()
+
+	// What the identity is worth is decided by where it came from, not by the
+	// handshake error. Two asymmetries make that distinction necessary:
+	//
+	//   - An error does not mean nothing was learned. On the INQ-first path the
+	//     remote's DAT is read and parsed before ours goes out, so a TX-phase
+	//     failure afterwards still leaves a genuine identity behind.
+	//   - No error does not mean an EMSI identity. When the RX phase runs out
+	//     of retries the library falls back to reading the product name out of
+	//     the mailer's banner and returns success, marking that guess with a
+	//     placeholder system name. Software so identified is weaker evidence
+	//     and the placeholder is never a system name.
+	bannerOnly := info != nil && info.SystemName == emsiBannerPlaceholder
+
+	remote.Software = remoteMailer(info)
+	if info != nil && !bannerOnly {
+		remote.Identified = true
+		remote.SystemName = info.SystemName
+		remote.Sysop = info.Sysop
+		remote.Location = info.Location
+		remote.Addresses = info.Addresses
+		if info.Location != "" && remote.SystemName != "" && !strings.Contains(remote.SystemName, info.Location) {
+			remote.SystemName = fmt.Sprintf("%s (%s)", remote.SystemName, info.Location)
+		}
+		if expectedAddress != "" {
+			remote.AddressValid = session.ValidateAddress(expectedAddress)
+		}
+	}
+
+	switch {
+	case info != nil && !bannerOnly:
+		if err != nil {
+			note = fmt.Sprintf("identity read, handshake then failed (%s)", reason)
+		}
+	case bannerOnly:
+		note = fmt.Sprintf("no EMSI identity, software read from the mailer's banner (%s)", reason)
+	case err != nil:
+		note = fmt.Sprintf("handshake failed (%s)", reason)
+	default:
+		// Completed, but the peer supplied nothing usable — e.g. its EMSI_DAT
+		// failed to parse after we ACKed it.
+		note = "handshake completed, no identity sent"
+	}
+	if note != "" && e.debug {
+		if err != nil {
+			logging.Debugf("%s EMSI: %s: %v", e.label, note, err)
+		} else {
+			logging.Debugf("%s EMSI: %s", e.label, note)
+		}
+	}
+	return remote, note
+}
+
+// remoteMailer renders the product name a remote announced, if any.
+func remoteMailer(info *emsi.EMSIData) string {
+	if info == nil {
+		return ""
+	}
+	return strings.TrimSpace(info.MailerName + " " + info.MailerVersion)
+}
@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/nodelistdb/internal/testing/logging"
+	"github.com/nodelistdb/internal/testing/transcript"
 	"github.com/nodelistdb/internal/version"
 	"github.com/xx25/fidomail/pkg/emsi"
 )
@@ -26,6 +27,7 @@ type IfcicoTester struct {
 	defaultPort int
 	debug       bool
 	configMgr   *emsi.ConfigManager // Per-node EMSI configuration manager
+	capture     bool                // record a transcript of every session
 }
 
 // NewIfcicoTester creates a new IFCICO tester. ourAddress must come from
@@ -112,6 +114,12 @@ func (t *IfcicoTester) Test(ctx context.Context, host string, port int, expected
 	}
 	defer conn.Close()
 
+	var recorder *transcript.Recorder
+	if t.capture {
+		recorder = transcript.Record(conn, "ifcico")
+		conn = recorder
+	}
+
 	if t.debug {
 		logging.Debugf("IFCICO: TCP connection established in %v", connDuration)
 		if tcpConn, ok := conn.(*net.TCPConn); ok {
@@ -167,7 +175,7 @@ func (t *IfcicoTester) Test(ctx context.Context, host string, port int, expected
 		if t.debug {
 			logging.Debugf("IFCICO: Handshake failed after %v: %v", handshakeDuration, err)
 		}
-		return &IfcicoTestResult{
+		result := &IfcicoTestResult{
 			BaseTestResult: BaseTestResult{
 				Success:    false,
 				Error:      fmt.Sprintf("handshake failed after %v: %v", handshakeDuration, err),
@@ -175,6 +183,10 @@ func (t *IfcicoTester) Test(ctx context.Context, host string, port int, expected
 				TestTime:   startTime,
 			},
 		}
+		if recorder != nil {
+			result.Transcript = recorder.Transcript()
+		}
+		return result
 	}
 
 	if t.debug {
@@ -246,6 +258,9 @@ func (t *IfcicoTester) Test(ctx context.Context, host string, port int, expected
 		logging.Debugf("IFCICO: Closing session gracefully...")
 	}
 	session.Close()
+	if recorder != nil {
+		result.Transcript = recorder.Transcript()
+	}
 
 	if t.debug {
 		totalDuration := time.Since(startTime)
@@ -264,6 +279,12 @@ func (t *IfcicoTester) SetDebug(enabled bool) {
 	t.debug = enabled
 }
 
+// SetTranscriptCapture turns recording of each session's transcript on or
+// off. Implements the TranscriptSetter interface.
+func (t *IfcicoTester) SetTranscriptCapture(enabled bool) {
+	t.capture = enabled
+}
+
 // SetEMSIConfigManager sets the per-node EMSI configuration manager
 // Implements the EMSIConfigSetter interface
 func (t *IfcicoTester) SetEMSIConfigManager(mgr *emsi.ConfigManager) {
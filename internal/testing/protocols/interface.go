@@ -4,6 +4,7 @@ import (
 	"context"
 	"time"
 
+	"github.com/nodelistdb/internal/testing/transcript"
 	"github.com/xx25/fidomail/pkg/emsi"
 )
 
@@ -27,6 +28,27 @@ type EMSIConfigSetter interface {
 	SetEMSIConfigManager(mgr *emsi.ConfigManager)
 }
 
+// TLSTester is an optional interface for testers that can also reach the
+// TLS-wrapped variant of their protocol. serverName is the host name the
+// certificate is checked against.
+type TLSTester interface {
+	TestTLS(ctx context.Context, host string, port int, expectedAddress, serverName string) TestResult
+}
+
+// SessionPasswordSetter is an optional interface for testers that can run
+// password-protected sessions with the nodes they hold a password for, and
+// can be told not to send those passwords in plain text
+type SessionPasswordSetter interface {
+	SetSessionPasswords(passwords map[string]string)
+	SetRequireCRAM(require bool)
+}
+
+// TranscriptSetter is an optional interface for testers that can record
+// the bytes of each session they run into the result
+type TranscriptSetter interface {
+	SetTranscriptCapture(enabled bool)
+}
+
 // TestResult is the base interface for test results
 type TestResult interface {
 	IsSuccess() bool
@@ -65,6 +87,40 @@ type BinkPTestResult struct {
 	Capabilities []string
 	AddressValid bool
 	Port         int
+	// Set only for nodes we hold a session password for. AuthMethod is how
+	// the session authenticated ("cram-md5" or "plain"). MailExchange is the
+	// groupable outcome of the authenticated exchange: ok | refused |
+	// not-secure | auth-failed | failed; MailDetail says more, and starts
+	// "no-cram:" when we would not send the password in plain text.
+	AuthMethod   string
+	MailExchange string
+	MailDetail   string
+	// Verdict on each option the remote advertised in OPT: "" when it did
+	// not, else ok | failed | untested. Beyond a malformed CRAM challenge
+	// or GZ without EXTCMD, options are only exercised in password
+	// sessions.
+	OptCRAM  string
+	OptCrypt string
+	OptPLZ   string
+	OptGZ    string
+	OptBZ2   string
+	// TLS is set when the session ran over TLS (binkps). Certificate is what
+	// the server presented, nil when the TLS handshake itself failed.
+	TLS         bool
+	Certificate *TLSCertificateInfo
+	// Transcript is the session on the wire, when capture is on and a
+	// connection was made. Passwords we sent are blanked out.
+	Transcript *transcript.Transcript
+}
+
+// TLSCertificateInfo describes the certificate a TLS service presented.
+type TLSCertificateInfo struct {
+	Subject    string
+	Issuer     string
+	NotAfter   time.Time
+	ChainValid bool // chains to a trusted root, for the host name tested, and is in date
+	SelfSigned bool
+	Error      string // why the chain did not verify
 }
 
 // IfcicoTestResult contains IFCICO-specific test results
@@ -76,12 +132,22 @@ type IfcicoTestResult struct {
 	ResponseType   string // REQ/ACK/NAK/CLI/HBT
 	AddressValid   bool
 	SoftwareSource string // "emsi_dat", "banner", or ""
+	// Transcript is the session on the wire, when capture is on and a
+	// connection was made.
+	Transcript *transcript.Transcript
 }
 
-// TelnetTestResult contains Telnet-specific test results
+// TelnetTestResult contains Telnet-specific test results. The EMSI fields
+// are set only when an EMSI mailer answered on the port.
 type TelnetTestResult struct {
 	BaseTestResult
-	Banner string
+	Banner       string
+	MailerInfo   string // from the EMSI_DAT, or guessed from the banner
+	SystemName   string
+	Sysop        string
+	Addresses    []string
+	ResponseType string // "EMSI" once an EMSI identity was read
+	AddressValid bool
 }
 
 // FTPTestResult contains FTP-specific test results
@@ -1,23 +1,47 @@
 package protocols
 
 import (
-	"bufio"
 	"context"
 	"fmt"
 	"net"
 	"strings"
 	"time"
+
+	"github.com/nodelistdb/internal/testing/logging"
+	"github.com/xx25/fidomail/pkg/emsi"
 )
 
-// TelnetTester implements Telnet protocol testing
+// TelnetTester implements Telnet protocol testing.
+//
+// Many ITN ports have a FidoNet mailer behind them rather than a BBS login,
+// and those answer EMSI once the telnet options are settled. When the tester
+// has an identity to advertise it looks for such a mailer and, on finding one,
+// runs the EMSI handshake over telnet-binary to read the node's system name,
+// sysop and addresses, the same identity IfcicoTester collects on IFC ports.
 type TelnetTester struct {
-	timeout time.Duration
+	emsiCaller
 }
 
-// NewTelnetTester creates a new Telnet tester
+// NewTelnetTester creates a new Telnet tester that only reads the banner
 func NewTelnetTester(timeout time.Duration) *TelnetTester {
 	return &TelnetTester{
-		timeout: timeout,
+		emsiCaller: emsiCaller{label: "Telnet", timeout: timeout},
+	}
+}
+
+// NewTelnetTesterWithInfo creates a Telnet tester that also identifies EMSI
+// mailers, advertising the given identity in the handshake. ourAddress has no
+// built-in default on purpose — see NewIfcicoTesterWithInfo.
+func NewTelnetTesterWithInfo(timeout time.Duration, ourAddress, systemName, sysop, location string) *TelnetTester {
+	return &TelnetTester{
+		emsiCaller: emsiCaller{
+			label:      "Telnet",
+			timeout:    timeout,
+			ourAddress: ourAddress,
+			systemName: systemName,
+			sysop:      sysop,
+			location:   location,
+		},
 	}
 }
 
@@ -26,6 +50,15 @@ func (t *TelnetTester) GetProtocolName() string {
 	return "Telnet"
 }
 
+// SetDebug implements DebugSetter.
+func (t *TelnetTester) SetDebug(enabled bool) { t.debug = enabled }
+
+// SetEMSIConfigManager implements EMSIConfigSetter.
+func (t *TelnetTester) SetEMSIConfigManager(mgr *emsi.ConfigManager) { t.configMgr = mgr }
+
+// telnetBannerWindow is how long a telnet server gets to greet us.
+const telnetBannerWindow = 2 * time.Second
+
 // Test performs a Telnet connectivity test
 func (t *TelnetTester) Test(ctx context.Context, host string, port int, expectedAddress string) TestResult {
 	startTime := time.Now()
@@ -51,37 +84,12 @@ func (t *TelnetTester) Test(ctx context.Context, host string, port int, expected
 			},
 		}
 	}
-	defer conn.Close()
-
-	// Set read deadline
-	_ = conn.SetReadDeadline(time.Now().Add(t.timeout))
-
-	// Try to read banner/welcome message
-	reader := bufio.NewReader(conn)
-	banner := ""
-
-	// Read initial response (with timeout)
-	bannerChan := make(chan string, 1)
-	go func() {
-		data := make([]byte, 1024)
-		n, err := reader.Read(data)
-		if err == nil && n > 0 {
-			bannerChan <- string(data[:n])
-		} else {
-			bannerChan <- ""
-		}
-	}()
-
-	select {
-	case b := <-bannerChan:
-		banner = b
-	case <-time.After(2 * time.Second):
-		// No banner within 2 seconds, but connection successful
-		banner = ""
-	}
+	defer func() { conn.Close() }()
 
-	// Clean up banner (remove telnet negotiation bytes and control characters)
-	banner = t.cleanBanner(banner)
+	// Read the banner; no banner within the window still means the
+	// connection succeeded.
+	_ = conn.SetReadDeadline(time.Now().Add(telnetBannerWindow))
+	first := readSome(conn, 1024)
 
 	result := &TelnetTestResult{
 		BaseTestResult: BaseTestResult{
@@ -89,24 +97,71 @@ func (t *TelnetTester) Test(ctx context.Context, host string, port int, expected
 			ResponseMs: uint32(time.Since(startTime).Milliseconds()),
 			TestTime:   startTime,
 		},
-		Banner: banner,
-	}
-
-	// Check if banner contains BBS/FidoNet indicators
-	if banner != "" {
-		lowerBanner := strings.ToLower(banner)
-		if strings.Contains(lowerBanner, "bbs") ||
-			strings.Contains(lowerBanner, "fidonet") ||
-			strings.Contains(lowerBanner, "node") ||
-			strings.Contains(lowerBanner, "sysop") {
-			// Likely a BBS system
-			result.Success = true
-		}
+		// Clean up banner (remove telnet negotiation bytes and control characters)
+		Banner: t.cleanBanner(string(first)),
 	}
 
+	// Without an address of our own there is nothing to call a mailer with.
+	if t.ourAddress == "" {
+		return result
+	}
+
+	text, sawTelnet, nudged := sniffTelnetMailer(conn, first)
+	if result.Banner == "" {
+		result.Banner = t.cleanBanner(text)
+	}
+	software := sniffSoftware(text)
+	if !hasEMSIMarker(text, nudged) && software == "" {
+		return result // a BBS login or something else that does not speak EMSI
+	}
+	conn.Close()
+
+	// The sniff consumed the mailer's opening, so the handshake gets a fresh
+	// connection, through the telnet layer when the port spoke telnet.
+	conn, err = dialer.DialContext(ctx, "tcp", net.JoinHostPort(host, fmt.Sprintf("%d", port)))
+	if err != nil {
+		result.MailerInfo = software
+		return result
+	}
+	var c net.Conn = conn
+	if sawTelnet {
+		c = newTelnetBinaryConn(conn)
+	}
+	remote, note := t.call(c, expectedAddress)
+
+	result.MailerInfo = remote.Software
+	if result.MailerInfo == "" {
+		result.MailerInfo = software
+	}
+	if remote.Identified {
+		result.ResponseType = "EMSI"
+		result.SystemName = remote.SystemName
+		result.Sysop = remote.Sysop
+		result.Addresses = remote.Addresses
+		result.AddressValid = remote.AddressValid
+	}
+	if t.debug {
+		logging.Debugf("Telnet %s:%d -> mailer=%q system=%q addresses=%v valid=%v note=%q",
+			host, port, result.MailerInfo, result.SystemName, result.Addresses, result.AddressValid, note)
+	}
 	return result
 }
 
+// sniffTelnetMailer reads what a telnet port says after option negotiation,
+// nudging once with EMSI_INQ if no EMSI reply has shown up, the way a calling
+// mailer would. first holds the bytes already read off conn. It returns the
+// decoded text, whether the peer spoke telnet, and whether we sent the nudge.
+func sniffTelnetMailer(conn net.Conn, first []byte) (text string, sawTelnet, nudged bool) {
+	tn := newTelnetBinaryConn(newPrefixConn(conn, first))
+	app := readMore(tn, 2048, 1500*time.Millisecond)
+	if !containsEMSIReply(string(app)) {
+		_, _ = tn.Write(emsiINQNudge)
+		nudged = true
+		app = append(app, readMore(tn, 2048, 3500*time.Millisecond)...)
+	}
+	return string(app), tn.sawIAC, nudged
+}
+
 // cleanBanner removes telnet negotiation and control characters
 func (t *TelnetTester) cleanBanner(banner string) string {
 	if banner == "" {
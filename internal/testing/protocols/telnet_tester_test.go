@@ -0,0 +1,118 @@
+package protocols
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveTelnet accepts one connection on ln and hands it to handle.
+func serveTelnet(t *testing.T, ln net.Listener, handle func(net.Conn)) {
+	t.Helper()
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		handle(c)
+	}()
+}
+
+func listenTelnet(t *testing.T) (net.Listener, string, int) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	host, portStr, _ := net.SplitHostPort(ln.Addr().String())
+	port, _ := strconv.Atoi(portStr)
+	return ln, host, port
+}
+
+func TestTelnetTesterBBSLogin(t *testing.T) {
+	ln, host, port := listenTelnet(t)
+	serveTelnet(t, ln, func(c net.Conn) {
+		_, _ = c.Write(append([]byte{tnIAC, tnWILL, 1}, "Welcome to Test BBS\r\nlogin: "...))
+		_, _ = c.Read(make([]byte, 64))
+		time.Sleep(6 * time.Second)
+	})
+
+	tester := NewTelnetTesterWithInfo(5*time.Second, "2:5001/5001", "Test", "Op", "Here")
+	res, ok := tester.Test(context.Background(), host, port, "2:5020/1").(*TelnetTestResult)
+	if !ok {
+		t.Fatal("expected *TelnetTestResult")
+	}
+	if !res.Success {
+		t.Fatalf("Success = false: %s", res.Error)
+	}
+	if !strings.Contains(res.Banner, "Welcome to Test BBS") {
+		t.Errorf("Banner = %q", res.Banner)
+	}
+	if res.ResponseType != "" || res.MailerInfo != "" || len(res.Addresses) > 0 {
+		t.Errorf("a BBS login is not a mailer: %+v", res)
+	}
+}
+
+func TestTelnetTesterWithoutIdentityOnlyReadsBanner(t *testing.T) {
+	ln, host, port := listenTelnet(t)
+	got := make(chan []byte, 1)
+	serveTelnet(t, ln, func(c net.Conn) {
+		_, _ = c.Write([]byte("FrontDoor/2 2.32.mL\r\n"))
+		_ = c.SetReadDeadline(time.Now().Add(500 * time.Millisecond))
+		buf := make([]byte, 64)
+		n, _ := c.Read(buf)
+		got <- buf[:n]
+	})
+
+	res := NewTelnetTester(5*time.Second).Test(context.Background(), host, port, "").(*TelnetTestResult)
+	if !res.Success || !strings.Contains(res.Banner, "FrontDoor") {
+		t.Fatalf("unexpected result %+v", res)
+	}
+	if res.MailerInfo != "" {
+		t.Errorf("MailerInfo = %q, want empty without an identity to call with", res.MailerInfo)
+	}
+	if b := <-got; len(b) > 0 {
+		t.Errorf("tester sent %q; with no identity of its own it must not nudge", b)
+	}
+}
+
+func TestSniffTelnetMailer(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	negotiated := make(chan []byte, 1)
+	go func() {
+		defer server.Close()
+		_, _ = server.Write([]byte{tnIAC, tnDO, tnOptBinary})
+		buf := make([]byte, 64)
+		var in []byte
+		for !bytes.Contains(in, []byte("EMSI_INQ")) {
+			n, err := server.Read(buf)
+			if err != nil {
+				return
+			}
+			in = append(in, buf[:n]...)
+		}
+		negotiated <- in
+		_, _ = server.Write([]byte("**EMSI_REQA77E\r"))
+	}()
+
+	text, sawTelnet, nudged := sniffTelnetMailer(client, nil)
+	if !sawTelnet {
+		t.Error("sawTelnet = false after IAC DO BINARY")
+	}
+	if !nudged {
+		t.Error("nudged = false, the peer stayed quiet until our EMSI_INQ")
+	}
+	if !hasEMSIMarker(text, nudged) {
+		t.Errorf("text %q carries no EMSI reply", text)
+	}
+	if in := <-negotiated; !bytes.HasPrefix(in, []byte{tnIAC, tnWILL, tnOptBinary}) {
+		t.Errorf("peer got %q, want our WILL BINARY before the nudge", in)
+	}
+}
@@ -0,0 +1,284 @@
+package protocols
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/fakemailer"
+	"github.com/nodelistdb/internal/testing/protocols/binkp"
+)
+
+// fakeIdentity is the node every fake mailer below claims to be.
+var fakeIdentity = fakemailer.Identity{
+	Addresses:  []string{"2:5020/1@fidonet", "2:5020/2@fidonet"},
+	SystemName: "Fake Node",
+	Sysop:      "Jane Doe",
+	Location:   "Moscow",
+	Mailer:     "FrontDoor",
+	Version:    "2.33",
+}
+
+// startFakeMailer runs cfg on a loopback port for the length of the test.
+func startFakeMailer(t *testing.T, cfg fakemailer.Config) *fakemailer.Mailer {
+	t.Helper()
+	if cfg.Identity.Addresses == nil {
+		cfg.Identity = fakeIdentity
+	}
+	m, err := fakemailer.Start(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { m.Close() })
+	return m
+}
+
+func TestBinkPTesterAgainstFakeMailer(t *testing.T) {
+	binkd := fakeIdentity
+	binkd.Mailer, binkd.Version = "binkd", "1.1a-115"
+
+	tests := []struct {
+		name     string
+		cfg      fakemailer.Config
+		timeout  time.Duration
+		expected string
+		want     bool // Success
+		wantErr  string
+		valid    bool
+	}{
+		{"main address", fakemailer.Config{Identity: binkd}, 5 * time.Second, "2:5020/1", true, "", true},
+		{"second AKA", fakemailer.Config{Identity: binkd}, 5 * time.Second, "2:5020/2", true, "", true},
+		{"address not announced", fakemailer.Config{Identity: binkd}, 5 * time.Second, "2:5020/99", true, "", false},
+		{"slow replies", fakemailer.Config{Identity: binkd, ReplyDelay: 50 * time.Millisecond}, 5 * time.Second, "2:5020/1", true, "", true},
+		{"greeting later than our timeout", fakemailer.Config{Identity: binkd, GreetDelay: 2 * time.Second}, time.Second, "2:5020/1", false, "handshake failed", false},
+		{"busy", fakemailer.Config{Identity: binkd, Fault: fakemailer.FaultBusy}, 5 * time.Second, "2:5020/1", false, "remote is busy", false},
+		{"truncated frame", fakemailer.Config{Identity: binkd, Fault: fakemailer.FaultMalformed}, 5 * time.Second, "2:5020/1", false, "handshake failed", false},
+		{"refused", fakemailer.Config{Fault: fakemailer.FaultRefuse}, 5 * time.Second, "2:5020/1", false, "handshake failed", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.cfg.Protocol = fakemailer.BinkP
+			m := startFakeMailer(t, tt.cfg)
+
+			res := NewBinkPTester(tt.timeout, "2:5001/5001").Test(context.Background(), m.Host(), m.Port(), tt.expected).(*BinkPTestResult)
+			if res.Success != tt.want || !strings.Contains(res.Error, tt.wantErr) {
+				t.Fatalf("Success = %v (%q), want %v (%q)", res.Success, res.Error, tt.want, tt.wantErr)
+			}
+			if res.AddressValid != tt.valid {
+				t.Errorf("AddressValid = %v, want %v (announced %v)", res.AddressValid, tt.valid, res.Addresses)
+			}
+			if !tt.want {
+				return
+			}
+			if res.SystemName != "Fake Node" || res.Sysop != "Jane Doe" || res.Location != "Moscow" {
+				t.Errorf("identity = %q / %q / %q", res.SystemName, res.Sysop, res.Location)
+			}
+			if res.Version != "binkd/1.1a-115 binkp/1.1" {
+				t.Errorf("Version = %q", res.Version)
+			}
+			if !reflect.DeepEqual(res.Addresses, binkd.Addresses) {
+				t.Errorf("Addresses = %v", res.Addresses)
+			}
+		})
+	}
+}
+
+func TestBinkPTesterPresentsItselfToFakeMailer(t *testing.T) {
+	m := startFakeMailer(t, fakemailer.Config{Protocol: fakemailer.BinkP, Identity: fakeIdentity, BinkPOptions: binkp.Options{PLZ: true}})
+	if res := NewBinkPTester(5*time.Second, "2:5001/5001").Test(context.Background(), m.Host(), m.Port(), "2:5020/1"); !res.IsSuccess() {
+		t.Fatalf("Test failed: %s", res.GetError())
+	}
+	m.Close()
+	sessions := m.Sessions()
+	if len(sessions) != 1 {
+		t.Fatalf("%d sessions, want 1", len(sessions))
+	}
+	if got := sessions[0].Addresses; !reflect.DeepEqual(got, []string{"2:5001/5001"}) {
+		t.Errorf("we presented %v", got)
+	}
+	if sessions[0].Files != 0 {
+		t.Errorf("an anonymous test sent %d files", sessions[0].Files)
+	}
+}
+
+func TestIfcicoTesterAgainstFakeMailer(t *testing.T) {
+	tests := []struct {
+		name     string
+		cfg      fakemailer.Config
+		timeout  time.Duration
+		expected string
+		identity bool // a genuine EMSI_DAT was read
+		valid    bool
+	}{
+		{"answers at once", fakemailer.Config{Banner: "FrontDoor 2.33; press Esc twice"}, 5 * time.Second, "2:5020/1", true, true},
+		{"waits for our INQ", fakemailer.Config{Quiet: true}, 5 * time.Second, "2:5020/1", true, true},
+		{"second AKA", fakemailer.Config{}, 5 * time.Second, "2:5020/2", true, true},
+		{"address not announced", fakemailer.Config{}, 5 * time.Second, "2:5020/99", true, false},
+		{"slow replies", fakemailer.Config{ReplyDelay: 100 * time.Millisecond}, 5 * time.Second, "2:5020/1", true, true},
+		{"EMSI_DAT with a bad CRC", fakemailer.Config{Fault: fakemailer.FaultMalformed}, 3 * time.Second, "2:5020/1", false, false},
+		{"hangs up on our EMSI_DAT", fakemailer.Config{Fault: fakemailer.FaultHangup}, 3 * time.Second, "2:5020/1", false, false},
+		{"silent", fakemailer.Config{Fault: fakemailer.FaultSilent}, 2 * time.Second, "2:5020/1", false, false},
+		{"refused", fakemailer.Config{Fault: fakemailer.FaultRefuse}, 2 * time.Second, "2:5020/1", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.cfg.Protocol = fakemailer.EMSI
+			m := startFakeMailer(t, tt.cfg)
+
+			tester := NewIfcicoTesterWithInfo(tt.timeout, "2:5001/5001", "NodelistDB", "Test Operator", "Test Location")
+			res := tester.Test(context.Background(), m.Host(), m.Port(), tt.expected).(*IfcicoTestResult)
+			if got := res.Success && res.SoftwareSource == "emsi_dat"; got != tt.identity {
+				t.Fatalf("identity read = %v, want %v: %+v", got, tt.identity, res)
+			}
+			if res.AddressValid != tt.valid {
+				t.Errorf("AddressValid = %v, want %v (announced %v)", res.AddressValid, tt.valid, res.Addresses)
+			}
+			if !tt.identity {
+				if len(res.Addresses) > 0 {
+					t.Errorf("Addresses = %v without an identity", res.Addresses)
+				}
+				return
+			}
+			if res.ResponseType != "EMSI" || res.MailerInfo != "FrontDoor 2.33" || res.SystemName != "Fake Node (Moscow)" {
+				t.Errorf("result = %q / %q / %q", res.ResponseType, res.MailerInfo, res.SystemName)
+			}
+			if !reflect.DeepEqual(res.Addresses, fakeIdentity.Addresses) {
+				t.Errorf("Addresses = %v", res.Addresses)
+			}
+		})
+	}
+}
+
+func TestTelnetTesterAgainstFakeMailer(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        fakemailer.Config
+		expected   string
+		banner     string // substring of Banner
+		mailer     string // MailerInfo
+		identified bool
+		valid      bool
+	}{
+		{"mailer behind telnet", fakemailer.Config{Protocol: fakemailer.TelnetEMSI, Banner: "FrontDoor 2.33"}, "2:5020/1", "FrontDoor", "FrontDoor 2.33", true, true},
+		{"mailer waits for our INQ", fakemailer.Config{Protocol: fakemailer.TelnetEMSI, Quiet: true}, "2:5020/2", "", "FrontDoor 2.33", true, true},
+		{"raw EMSI on the telnet port", fakemailer.Config{Protocol: fakemailer.EMSI}, "2:5020/1", "EMSI_REQ", "FrontDoor 2.33", true, true},
+		{"address not announced", fakemailer.Config{Protocol: fakemailer.TelnetEMSI}, "2:5020/99", "", "FrontDoor 2.33", true, false},
+		{"BBS login", fakemailer.Config{Protocol: fakemailer.TelnetBBS}, "2:5020/1", "Welcome to Fake Node", "", false, false},
+		// The port took the connection, which is all a telnet test asks.
+		{"refused", fakemailer.Config{Protocol: fakemailer.TelnetBBS, Fault: fakemailer.FaultRefuse}, "2:5020/1", "", "", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := startFakeMailer(t, tt.cfg)
+
+			tester := NewTelnetTesterWithInfo(5*time.Second, "2:5001/5001", "NodelistDB", "Test Operator", "Test Location")
+			res := tester.Test(context.Background(), m.Host(), m.Port(), tt.expected).(*TelnetTestResult)
+			if !res.Success {
+				t.Fatalf("Success = false: %s", res.Error)
+			}
+			if !strings.Contains(res.Banner, tt.banner) {
+				t.Errorf("Banner = %q, want it to contain %q", res.Banner, tt.banner)
+			}
+			if res.MailerInfo != tt.mailer {
+				t.Errorf("MailerInfo = %q, want %q", res.MailerInfo, tt.mailer)
+			}
+			if got := res.ResponseType == "EMSI"; got != tt.identified {
+				t.Errorf("identified = %v, want %v: %+v", got, tt.identified, res)
+			}
+			if res.AddressValid != tt.valid {
+				t.Errorf("AddressValid = %v, want %v (announced %v)", res.AddressValid, tt.valid, res.Addresses)
+			}
+			if tt.identified && (res.SystemName != "Fake Node (Moscow)" || res.Sysop != "Jane Doe" || !reflect.DeepEqual(res.Addresses, fakeIdentity.Addresses)) {
+				t.Errorf("identity = %q / %q / %v", res.SystemName, res.Sysop, res.Addresses)
+			}
+		})
+	}
+}
+
+func TestFTPTesterAgainstFakeMailer(t *testing.T) {
+	tests := []struct {
+		name       string
+		cfg        fakemailer.Config
+		want       bool // Success
+		wantErr    string
+		anonTested bool
+		anonLogin  bool
+	}{
+		{"anonymous allowed", fakemailer.Config{AnonFTP: true}, true, "", true, true},
+		{"anonymous refused", fakemailer.Config{}, true, "", true, false},
+		{"hangs up after the banner", fakemailer.Config{Fault: fakemailer.FaultHangup}, true, "", false, false},
+		{"banner without a reply code", fakemailer.Config{Fault: fakemailer.FaultMalformed, Banner: "hello"}, false, "no FTP response received", false, false},
+		{"silent", fakemailer.Config{Fault: fakemailer.FaultSilent}, false, "no FTP response received", false, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			tt.cfg.Protocol = fakemailer.FTP
+			m := startFakeMailer(t, tt.cfg)
+
+			res := NewFTPTester(time.Second).Test(context.Background(), m.Host(), m.Port(), "").(*FTPTestResult)
+			if res.Success != tt.want || !strings.Contains(res.Error, tt.wantErr) {
+				t.Fatalf("Success = %v (%q), want %v (%q)", res.Success, res.Error, tt.want, tt.wantErr)
+			}
+			if res.AnonTested != tt.anonTested || res.AnonLogin != tt.anonLogin {
+				t.Errorf("anonymous tested/login = %v/%v, want %v/%v", res.AnonTested, res.AnonLogin, tt.anonTested, tt.anonLogin)
+			}
+			if tt.want && !strings.Contains(res.Banner, "Fake Node FTP server ready") {
+				t.Errorf("Banner = %q", res.Banner)
+			}
+		})
+	}
+}
+
+func TestVModemTesterAgainstFakeMailer(t *testing.T) {
+	binkd := fakeIdentity
+	binkd.Mailer, binkd.Version = "binkd", "1.1a-115"
+
+	tests := []struct {
+		name     string
+		cfg      fakemailer.Config
+		want     bool // Success
+		variant  string
+		software string
+		valid    bool
+	}{
+		{"EMSI over telnet", fakemailer.Config{Protocol: fakemailer.TelnetEMSI}, true, "emsi-telnet", "FrontDoor 2.33", true},
+		{"EMSI over telnet, waits for our INQ", fakemailer.Config{Protocol: fakemailer.TelnetEMSI, Quiet: true}, true, "emsi-telnet", "FrontDoor 2.33", true},
+		{"raw EMSI", fakemailer.Config{Protocol: fakemailer.EMSI}, true, "emsi-raw", "FrontDoor 2.33", true},
+		{"binkd", fakemailer.Config{Protocol: fakemailer.BinkP, Identity: binkd}, true, "binkp", "binkd/1.1a-115 binkp/1.1", false},
+		{"BBS login", fakemailer.Config{Protocol: fakemailer.TelnetBBS}, true, "telnet-login", "", false},
+		{"FTP server", fakemailer.Config{Protocol: fakemailer.FTP}, true, "ftp", "", false},
+		// Without VMP calls enabled a VMODEM is only a peer that waits, then
+		// hangs up on our nudge.
+		{"VMODEM, calls disabled", fakemailer.Config{Protocol: fakemailer.Silent}, false, "unknown", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			m := startFakeMailer(t, tt.cfg)
+
+			tester := NewVModemTesterWithInfo(5*time.Second, "2:5001/5001", "NodelistDB", "Test Operator", "Test Location")
+			res := tester.Test(context.Background(), m.Host(), m.Port(), "2:5020/1").(*VModemTestResult)
+			if res.Success != tt.want || res.Variant != tt.variant {
+				t.Fatalf("Success, Variant = %v, %q, want %v, %q (%s)", res.Success, res.Variant, tt.want, tt.variant, res.Detail)
+			}
+			if res.Conformant {
+				t.Error("Conformant = true for a port that is not VMP")
+			}
+			if res.Software != tt.software {
+				t.Errorf("Software = %q, want %q", res.Software, tt.software)
+			}
+			if res.AddressValid != tt.valid {
+				t.Errorf("AddressValid = %v, want %v (announced %v)", res.AddressValid, tt.valid, res.Addresses)
+			}
+			if strings.HasPrefix(tt.variant, "emsi-") && res.Sysop != "Jane Doe" {
+				t.Errorf("Sysop = %q", res.Sysop)
+			}
+		})
+	}
+}
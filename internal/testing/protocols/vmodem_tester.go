@@ -30,13 +30,7 @@ import (
 // speaks it in both directions; what stays here is the classification — what
 // is actually running on a port the nodelist flagged IVM.
 type VModemTester struct {
-	timeout    time.Duration
-	ourAddress string
-	systemName string
-	sysop      string
-	location   string
-	debug      bool
-	configMgr  *emsi.ConfigManager
+	emsiCaller
 	vmp        vmp.DialConfig
 	vmpEnabled bool
 	vmpSlot    chan struct{} // non-nil when calls must be serialized on one port
@@ -49,11 +43,14 @@ type VModemTester struct {
 // It comes from configuration, validated before any tester is built.
 func NewVModemTesterWithInfo(timeout time.Duration, ourAddress, systemName, sysop, location string) *VModemTester {
 	return &VModemTester{
-		timeout:    timeout,
-		ourAddress: ourAddress,
-		systemName: systemName,
-		sysop:      sysop,
-		location:   location,
+		emsiCaller: emsiCaller{
+			label:      "VModem",
+			timeout:    timeout,
+			ourAddress: ourAddress,
+			systemName: systemName,
+			sysop:      sysop,
+			location:   location,
+		},
 	}
 }
 
@@ -197,17 +194,6 @@ func (t *VModemTester) test(ctx context.Context, host string, port int, expected
 // silence as "this is a VMODEM waiting for an opening frame" and call it.
 const vmpGreetWindow = 2 * time.Second
 
-// emsiCallerStrategy makes the EMSI session announce itself with EMSI_INQ at
-// connect instead of waiting to be spoken to.
-//
-// This is only the preamble; the calling role itself is pinned by
-// HandshakeCaller (see emsiOver), which is what makes a spec-strict answerer
-// respond at all. The announce is kept because it is what our field results
-// were measured with — it saves a REQ round-trip against mailers that greet
-// first, and it is what gained identity from 2:371/52 (Xenia/2 Mailer) and
-// 2:420/33 (FrontDoor) where waiting to be spoken to did not.
-const emsiCallerStrategy = "send_inq"
-
 // emsiINQNudge is the sequence sniff sends to coax a greeting out of a silent
 // peer. It must be the spec form — FSC-0056 spells EMSI_INQ with its fixed
 // C816 CRC suffix, and a mailer's reader matches the whole 14-character token,
@@ -437,106 +423,23 @@ func (t *VModemTester) emsiIdentity(ctx context.Context, host string, port int,
 }
 
 // emsiOver runs an EMSI handshake as the calling side over an already-open
-// transport and copies whatever the remote announced into res. It returns a
-// short note on the outcome, empty only when a full EMSI identity was read
-// cleanly. Everything else names the fault, because those faults are not the
-// same thing: a handshake that never completed, one that completed while the
-// peer supplied nothing usable, and one that produced only a product name
-// guessed from the mailer's banner are three different states of the far side.
+// transport and copies whatever the remote announced into res. The returned
+// note is emsiCaller.call's: empty only when a full identity was read.
 func (t *VModemTester) emsiOver(c net.Conn, expectedAddress string, res *VModemTestResult) string {
-	var cfg *emsi.Config
-	if t.configMgr != nil {
-		cfg = t.configMgr.GetConfigForNode(expectedAddress)
-	} else {
-		cfg = emsi.DefaultConfig()
-	}
-	cfg.MailerName = "NodelistDB"
-	cfg.MailerVersion = mailerVersion
-	if cfg.InitialStrategy == "" || cfg.InitialStrategy == "wait" {
-		cfg.InitialStrategy = emsiCallerStrategy
-	}
-
-	session := emsi.NewSessionWithInfoAndConfig(c, t.ourAddress, t.systemName, t.sysop, t.location, cfg)
-	if t.configMgr == nil {
-		session.SetTimeout(t.timeout)
+	remote, note := t.call(c, expectedAddress)
+	if remote.Software != "" {
+		res.Software = remote.Software
 	}
-	session.SetDebug(t.debug)
-
-	// We dialed, so pin the calling role: the peer's EMSI_REQ is answered with
-	// EMSI_INQ before our EMSI_DAT, which is what a strict answerer waits for.
-	err := session.HandshakeCaller()
-	defer session.Close()
-
-	info := session.GetRemoteInfo()
-	reason := session.GetCompletionReason()
-
-	// What the identity is worth is decided by where it came from, not by the
-	// handshake error. Two asymmetries make that distinction necessary:
-	//
-	//   - An error does not mean nothing was learned. On the INQ-first path the
-	//     remote's DAT is read and parsed before ours goes out, so a TX-phase
-	//     failure afterwards still leaves a genuine identity behind.
-	//   - No error does not mean an EMSI identity. When the RX phase runs out
-	//     of retries the library falls back to reading the product name out of
-	//     the mailer's banner and returns success, marking that guess with a
-	//     placeholder system name. Software so identified is weaker evidence
-	//     and the placeholder is never a system name.
-	bannerOnly := info != nil && info.SystemName == emsiBannerPlaceholder
-
-	if mailer := remoteMailer(info); mailer != "" {
-		res.Software = mailer
-	}
-	if info != nil && !bannerOnly {
-		res.SystemName = info.SystemName
-		res.Sysop = info.Sysop
-		res.Location = info.Location
-		res.Addresses = info.Addresses
-		if info.Location != "" && res.SystemName != "" && !strings.Contains(res.SystemName, info.Location) {
-			res.SystemName = fmt.Sprintf("%s (%s)", res.SystemName, info.Location)
-		}
-		if expectedAddress != "" {
-			res.AddressValid = session.ValidateAddress(expectedAddress)
-		}
-	}
-
-	var note string
-	switch {
-	case info != nil && !bannerOnly:
-		if err != nil {
-			note = fmt.Sprintf("identity read, handshake then failed (%s)", reason)
-		}
-	case bannerOnly:
-		note = fmt.Sprintf("no EMSI identity, software read from the mailer's banner (%s)", reason)
-	case err != nil:
-		note = fmt.Sprintf("handshake failed (%s)", reason)
-	default:
-		// Completed, but the peer supplied nothing usable — e.g. its EMSI_DAT
-		// failed to parse after we ACKed it.
-		note = "handshake completed, no identity sent"
-	}
-	if note != "" && t.debug {
-		if err != nil {
-			logging.Debugf("VModem EMSI: %s: %v", note, err)
-		} else {
-			logging.Debugf("VModem EMSI: %s", note)
-		}
+	if remote.Identified {
+		res.SystemName = remote.SystemName
+		res.Sysop = remote.Sysop
+		res.Location = remote.Location
+		res.Addresses = remote.Addresses
+		res.AddressValid = remote.AddressValid
 	}
 	return note
 }
 
-// emsiBannerPlaceholder is the system name pkg/emsi substitutes when it gave up
-// on the handshake and read the product name out of the mailer's banner
-// instead. It marks a guess, not an announced identity.
-const emsiBannerPlaceholder = "[Extracted from banner]"
-
-// remoteMailer renders the product name a remote announced, if any.
-func remoteMailer(info *emsi.EMSIData) string {
-	if info == nil {
-		return ""
-	}
-	return strings.TrimSpace(info.MailerName + " " + info.MailerVersion)
-}
-
 // describeMismatch produces the human note reported for a non-VMP IVM port.
 func describeMismatch(res *VModemTestResult) string {
 	if res.Conformant {
@@ -0,0 +1,222 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/netip"
+	"strconv"
+	"sync"
+	"syscall"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+// PathDiagnoser looks into why a node failed every protocol test: whether
+// the host answers a TCP SYN at all, and if not, how far along the network
+// path towards it replies still come back.
+//
+// Nothing here needs privileges. The SYN is an ordinary connect, and the
+// path is followed the way tracepath does it, with UDP datagrams of rising
+// TTL and the ICMP errors they provoke read off the socket's error queue.
+// Where that is not available (anything but Linux) only the TCP probe runs.
+type PathDiagnoser struct {
+	tcpTimeout time.Duration
+	hopTimeout time.Duration
+	maxHops    int
+	attempts   int
+
+	asn ASNLookup
+	// hop sends one probe; replaced in tests.
+	hop func(ctx context.Context, dst netip.Addr, ttl int, timeout time.Duration) (hopReply, error)
+}
+
+// ASNLookup names the network an address belongs to. Geolocation is one.
+type ASNLookup interface {
+	GetLocation(ctx context.Context, ip string) *models.GeolocationResult
+}
+
+// PathDiagnoserConfig configures the diagnoser.
+type PathDiagnoserConfig struct {
+	// TCPTimeout bounds the SYN probe. Defaults to 5s.
+	TCPTimeout time.Duration
+	// HopTimeout is how long to wait for one hop to answer. Defaults to 2s.
+	HopTimeout time.Duration
+	// MaxHops is the highest TTL tried. Defaults to 30.
+	MaxHops int
+	// Attempts is how many probes a silent hop gets. Defaults to 2; routers
+	// rate-limit ICMP and drop the odd one.
+	Attempts int
+}
+
+// hopKind is what a probe's ICMP error said.
+type hopKind int
+
+const (
+	hopNone        hopKind = iota // nothing came back
+	hopTransit                    // time exceeded: a router on the way
+	hopTarget                     // port unreachable from the target itself
+	hopUnreachable                // a router declared the target unreachable
+)
+
+type hopReply struct {
+	addr netip.Addr
+	kind hopKind
+}
+
+// NewPathDiagnoser builds a diagnoser. asn may be nil, in which case no
+// verdict can tell a dead host from a broken path.
+func NewPathDiagnoser(cfg PathDiagnoserConfig, asn ASNLookup) *PathDiagnoser {
+	if cfg.TCPTimeout <= 0 {
+		cfg.TCPTimeout = 5 * time.Second
+	}
+	if cfg.HopTimeout <= 0 {
+		cfg.HopTimeout = 2 * time.Second
+	}
+	if cfg.MaxHops <= 0 {
+		cfg.MaxHops = 30
+	}
+	if cfg.MaxHops > 64 {
+		cfg.MaxHops = 64
+	}
+	if cfg.Attempts <= 0 {
+		cfg.Attempts = 2
+	}
+	return &PathDiagnoser{
+		tcpTimeout: cfg.TCPTimeout,
+		hopTimeout: cfg.HopTimeout,
+		maxHops:    cfg.MaxHops,
+		attempts:   cfg.Attempts,
+		asn:        asn,
+		hop:        probeHop,
+	}
+}
+
+// Diagnose probes ip:port. targetASN is the host's own ASN from
+// geolocation, 0 if unknown; it is what the last hop's network is compared
+// with. It returns nil for an address that does not parse.
+func (p *PathDiagnoser) Diagnose(ctx context.Context, ip string, port int, targetASN uint32) *models.PathDiagnostic {
+	dst, err := netip.ParseAddr(ip)
+	if err != nil {
+		return nil
+	}
+	dst = dst.Unmap()
+	d := &models.PathDiagnostic{IP: dst.String(), Port: port}
+
+	d.TCP = p.probeTCP(ctx, dst, port)
+	switch d.TCP {
+	case "open":
+		d.Verdict = models.PathVerdictOpen
+		return d
+	case "refused":
+		d.Verdict = models.PathVerdictRefused
+		return d
+	}
+
+	hops, end := p.trace(ctx, dst)
+	for ttl := end; ttl >= 1; ttl-- {
+		if hops[ttl-1].kind != hopNone {
+			d.LastHop = hops[ttl-1].addr.String()
+			d.LastHopTTL = ttl
+			break
+		}
+	}
+	if d.LastHopTTL > 0 {
+		d.Hops = make([]string, d.LastHopTTL)
+		for i := range d.Hops {
+			if hops[i].kind != hopNone {
+				d.Hops[i] = hops[i].addr.String()
+			}
+		}
+	}
+
+	if d.LastHopTTL > 0 && p.asn != nil && routable(hops[d.LastHopTTL-1].addr) {
+		if geo := p.asn.GetLocation(ctx, d.LastHop); geo != nil {
+			d.LastHopASN = geo.ASN
+			d.LastHopAS = geo.ISP
+			if d.LastHopAS == "" {
+				d.LastHopAS = geo.Org
+			}
+		}
+	}
+	var last hopReply
+	if d.LastHopTTL > 0 {
+		last = hops[d.LastHopTTL-1]
+	}
+	d.Verdict = pathVerdict(last, d.LastHopASN, targetASN)
+	return d
+}
+
+// pathVerdict reads the end of the path against the target's network.
+func pathVerdict(last hopReply, lastASN, targetASN uint32) string {
+	switch {
+	case last.kind == hopTarget:
+		return models.PathVerdictFiltered
+	case last.kind == hopNone, lastASN == 0, targetASN == 0:
+		return models.PathVerdictUnknown
+	case lastASN == targetASN:
+		return models.PathVerdictHostDown
+	default:
+		return models.PathVerdictPathBroken
+	}
+}
+
+// probeTCP connects to the port and classifies how that went.
+func (p *PathDiagnoser) probeTCP(ctx context.Context, dst netip.Addr, port int) string {
+	dialer := net.Dialer{Timeout: p.tcpTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(dst.String(), strconv.Itoa(port)))
+	if err == nil {
+		conn.Close()
+		return "open"
+	}
+	var ne net.Error
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return "refused"
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return "unreachable"
+	case errors.As(err, &ne) && ne.Timeout():
+		return "timeout"
+	default:
+		return "error"
+	}
+}
+
+// trace probes every TTL up to maxHops at once and returns the replies by
+// TTL, with the TTL at which the path ends: where the target or an
+// unreachable answered, else maxHops.
+func (p *PathDiagnoser) trace(ctx context.Context, dst netip.Addr) ([]hopReply, int) {
+	hops := make([]hopReply, p.maxHops)
+	var wg sync.WaitGroup
+	for ttl := 1; ttl <= p.maxHops; ttl++ {
+		wg.Add(1)
+		go func(ttl int) {
+			defer wg.Done()
+			for range p.attempts {
+				if ctx.Err() != nil {
+					return
+				}
+				reply, err := p.hop(ctx, dst, ttl, p.hopTimeout)
+				if err == nil && reply.kind != hopNone {
+					hops[ttl-1] = reply
+					return
+				}
+			}
+		}(ttl)
+	}
+	wg.Wait()
+
+	for ttl := 1; ttl <= p.maxHops; ttl++ {
+		if k := hops[ttl-1].kind; k == hopTarget || k == hopUnreachable {
+			return hops, ttl
+		}
+	}
+	return hops, p.maxHops
+}
+
+// routable reports whether addr can have an ASN: a router with a private
+// address belongs to whoever runs it, and a lookup says nothing.
+func routable(addr netip.Addr) bool {
+	return addr.IsGlobalUnicast() && !addr.IsPrivate()
+}
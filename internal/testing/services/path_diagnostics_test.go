@@ -0,0 +1,131 @@
+package services
+
+import (
+	"context"
+	"net"
+	"net/netip"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+// asnTable is an ASNLookup over a fixed map.
+type asnTable map[string]uint32
+
+func (a asnTable) GetLocation(_ context.Context, ip string) *models.GeolocationResult {
+	asn, ok := a[ip]
+	if !ok {
+		return nil
+	}
+	return &models.GeolocationResult{IP: ip, ASN: asn, ISP: "AS" + ip}
+}
+
+func TestPathVerdict(t *testing.T) {
+	router := netip.MustParseAddr("198.51.100.1")
+	tests := []struct {
+		name      string
+		last      hopReply
+		lastASN   uint32
+		targetASN uint32
+		want      string
+	}{
+		{"target answered the UDP probe", hopReply{router, hopTarget}, 0, 0, models.PathVerdictFiltered},
+		{"nothing answered", hopReply{}, 0, 64500, models.PathVerdictUnknown},
+		{"last hop in the host's network", hopReply{router, hopTransit}, 64500, 64500, models.PathVerdictHostDown},
+		{"last hop elsewhere", hopReply{router, hopTransit}, 64501, 64500, models.PathVerdictPathBroken},
+		{"router says unreachable from elsewhere", hopReply{router, hopUnreachable}, 64501, 64500, models.PathVerdictPathBroken},
+		{"last hop's network unknown", hopReply{router, hopTransit}, 0, 64500, models.PathVerdictUnknown},
+		{"host's network unknown", hopReply{router, hopTransit}, 64501, 0, models.PathVerdictUnknown},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pathVerdict(tt.last, tt.lastASN, tt.targetASN); got != tt.want {
+				t.Errorf("pathVerdict = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDiagnoseTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	open := ln.Addr().(*net.TCPAddr).Port
+	// A port that was just listening and is not any more refuses.
+	ln2, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed := ln2.Addr().(*net.TCPAddr).Port
+	ln2.Close()
+	defer ln.Close()
+
+	p := NewPathDiagnoser(PathDiagnoserConfig{TCPTimeout: time.Second}, nil)
+	p.hop = func(context.Context, netip.Addr, int, time.Duration) (hopReply, error) {
+		t.Error("the path was traced although the host answered")
+		return hopReply{}, nil
+	}
+	for port, want := range map[int]string{open: models.PathVerdictOpen, closed: models.PathVerdictRefused} {
+		d := p.Diagnose(context.Background(), "127.0.0.1", port, 0)
+		if d == nil || d.Verdict != want || d.TCP != want {
+			t.Errorf("port %d: %+v, want %s", port, d, want)
+		}
+	}
+	if d := p.Diagnose(context.Background(), "not an address", 1, 0); d != nil {
+		t.Errorf("Diagnose of a bad address = %+v", d)
+	}
+}
+
+func TestDiagnosePath(t *testing.T) {
+	// A fake network: two routers answer, the third drops every probe, the
+	// fourth answers the second attempt only, and the path goes dark after
+	// it. The probe target is on 192.0.2.0/24, an address the TCP probe
+	// cannot reach within the test's timeout.
+	path := map[int]string{1: "10.0.0.1", 2: "203.0.113.1", 4: "203.0.113.9"}
+	attempts := map[int]int{}
+	hop := func(_ context.Context, _ netip.Addr, ttl int, _ time.Duration) (hopReply, error) {
+		addr, ok := path[ttl]
+		if !ok {
+			return hopReply{}, nil
+		}
+		if ttl == 4 {
+			attempts[ttl]++ // ttl 4's goroutine is the only one writing here
+			if attempts[ttl] == 1 {
+				return hopReply{}, nil
+			}
+		}
+		return hopReply{netip.MustParseAddr(addr), hopTransit}, nil
+	}
+
+	tests := []struct {
+		name      string
+		asns      asnTable
+		targetASN uint32
+		want      string
+		wantAS    uint32
+	}{
+		{"path stops in the host's network", asnTable{"203.0.113.9": 64500}, 64500, models.PathVerdictHostDown, 64500},
+		{"path stops upstream", asnTable{"203.0.113.9": 64501}, 64500, models.PathVerdictPathBroken, 64501},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clear(attempts)
+			p := NewPathDiagnoser(PathDiagnoserConfig{TCPTimeout: 50 * time.Millisecond, MaxHops: 8}, tt.asns)
+			p.hop = hop
+			d := p.Diagnose(context.Background(), "192.0.2.7", 24554, tt.targetASN)
+			if d.TCP == "open" || d.TCP == "refused" {
+				t.Skipf("192.0.2.7 answered TCP (%s) on this network", d.TCP)
+			}
+			wantHops := []string{"10.0.0.1", "203.0.113.1", "", "203.0.113.9"}
+			if !reflect.DeepEqual(d.Hops, wantHops) || d.LastHop != "203.0.113.9" || d.LastHopTTL != 4 {
+				t.Errorf("hops = %q, last %s at %d; want %q", d.Hops, d.LastHop, d.LastHopTTL, wantHops)
+			}
+			if d.Verdict != tt.want || d.LastHopASN != tt.wantAS || d.LastHopAS != "AS203.0.113.9" {
+				t.Errorf("verdict %q, last hop AS%d %q; want %q, AS%d", d.Verdict, d.LastHopASN, d.LastHopAS, tt.want, tt.wantAS)
+			}
+		})
+	}
+}
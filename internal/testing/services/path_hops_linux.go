@@ -0,0 +1,147 @@
+//go:build linux
+
+package services
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"net/netip"
+	"syscall"
+	"time"
+)
+
+// traceBasePort is where probe ports start, as in traceroute: high enough
+// that nothing listens there and the target answers port unreachable.
+const traceBasePort = 33434
+
+// Origins of a sock_extended_err (linux/errqueue.h).
+const (
+	eeOriginICMP  = 2
+	eeOriginICMP6 = 3
+)
+
+// probeHop sends one UDP datagram to dst with the given TTL and reads the
+// ICMP error it provokes from the socket's error queue (IP_RECVERR), which
+// needs no raw socket.
+func probeHop(ctx context.Context, dst netip.Addr, ttl int, timeout time.Duration) (hopReply, error) {
+	network := "udp4"
+	if dst.Is6() {
+		network = "udp6"
+	}
+	dialer := net.Dialer{Control: func(_, _ string, c syscall.RawConn) error {
+		var serr error
+		err := c.Control(func(fd uintptr) {
+			if dst.Is6() {
+				serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_UNICAST_HOPS, ttl)
+				if serr == nil {
+					serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IPV6, syscall.IPV6_RECVERR, 1)
+				}
+				return
+			}
+			serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_TTL, ttl)
+			if serr == nil {
+				serr = syscall.SetsockoptInt(int(fd), syscall.IPPROTO_IP, syscall.IP_RECVERR, 1)
+			}
+		})
+		if err != nil {
+			return err
+		}
+		return serr
+	}}
+	conn, err := dialer.DialContext(ctx, network, netip.AddrPortFrom(dst, uint16(traceBasePort+ttl)).String())
+	if err != nil {
+		return hopReply{}, err
+	}
+	defer conn.Close()
+
+	deadline := time.Now().Add(timeout)
+	if d, ok := ctx.Deadline(); ok && d.Before(deadline) {
+		deadline = d
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		return hopReply{}, err
+	}
+	if _, err := conn.Write([]byte("nodelistdb path probe")); err != nil {
+		return hopReply{}, err
+	}
+
+	raw, err := conn.(*net.UDPConn).SyscallConn()
+	if err != nil {
+		return hopReply{}, err
+	}
+	buf := make([]byte, 64)
+	oob := make([]byte, 512)
+	var (
+		reply   hopReply
+		readErr error
+	)
+	err = raw.Read(func(fd uintptr) bool {
+		_, oobn, _, _, err := syscall.Recvmsg(int(fd), buf, oob, syscall.MSG_ERRQUEUE)
+		if errors.Is(err, syscall.EAGAIN) {
+			// An ICMP error raises EPOLLERR, which wakes this read.
+			return false
+		}
+		if err != nil {
+			readErr = err
+			return true
+		}
+		reply = parseRecvErr(oob[:oobn])
+		return true
+	})
+	if err != nil {
+		var ne net.Error
+		if errors.As(err, &ne) && ne.Timeout() {
+			return hopReply{}, nil
+		}
+		return hopReply{}, err
+	}
+	return reply, readErr
+}
+
+// parseRecvErr reads the sock_extended_err and offender address out of an
+// error queue control message.
+func parseRecvErr(oob []byte) hopReply {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return hopReply{}
+	}
+	for _, m := range msgs {
+		v4 := m.Header.Level == syscall.IPPROTO_IP && m.Header.Type == syscall.IP_RECVERR
+		v6 := m.Header.Level == syscall.IPPROTO_IPV6 && m.Header.Type == syscall.IPV6_RECVERR
+		if (!v4 && !v6) || len(m.Data) < 16+2 {
+			continue
+		}
+		// struct sock_extended_err: errno u32, origin u8, type u8, code u8,
+		// pad u8, info u32, data u32; the offender's sockaddr follows.
+		origin, icmpType, icmpCode := m.Data[4], m.Data[5], m.Data[6]
+		offender := m.Data[16:]
+
+		var addr netip.Addr
+		switch binary.NativeEndian.Uint16(offender) {
+		case syscall.AF_INET:
+			if len(offender) >= 8 {
+				addr = netip.AddrFrom4([4]byte(offender[4:8]))
+			}
+		case syscall.AF_INET6:
+			if len(offender) >= 24 {
+				addr = netip.AddrFrom16([16]byte(offender[8:24])).Unmap()
+			}
+		}
+		if !addr.IsValid() {
+			continue
+		}
+
+		switch {
+		case origin == eeOriginICMP && icmpType == 11, origin == eeOriginICMP6 && icmpType == 3:
+			return hopReply{addr: addr, kind: hopTransit}
+		case origin == eeOriginICMP && icmpType == 3 && icmpCode == 3,
+			origin == eeOriginICMP6 && icmpType == 1 && icmpCode == 4:
+			return hopReply{addr: addr, kind: hopTarget}
+		case origin == eeOriginICMP && icmpType == 3, origin == eeOriginICMP6 && icmpType == 1:
+			return hopReply{addr: addr, kind: hopUnreachable}
+		}
+	}
+	return hopReply{}
+}
@@ -0,0 +1,23 @@
+//go:build linux
+
+package services
+
+import (
+	"context"
+	"net/netip"
+	"testing"
+	"time"
+)
+
+// On loopback the first hop is the target, so a TTL 1 probe comes back as
+// port unreachable from 127.0.0.1.
+func TestProbeHopLoopback(t *testing.T) {
+	dst := netip.MustParseAddr("127.0.0.1")
+	reply, err := probeHop(context.Background(), dst, 1, 2*time.Second)
+	if err != nil {
+		t.Skipf("UDP probes not possible here: %v", err)
+	}
+	if reply.kind != hopTarget || reply.addr != dst {
+		t.Errorf("reply = %+v, want port unreachable from %s", reply, dst)
+	}
+}
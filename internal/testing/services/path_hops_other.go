@@ -0,0 +1,16 @@
+//go:build !linux
+
+package services
+
+import (
+	"context"
+	"errors"
+	"net/netip"
+	"time"
+)
+
+// probeHop needs the Linux socket error queue; elsewhere the path is not
+// followed and a diagnosis rests on the TCP probe alone.
+func probeHop(context.Context, netip.Addr, int, time.Duration) (hopReply, error) {
+	return hopReply{}, errors.New("path discovery is only supported on Linux")
+}
@@ -141,6 +141,54 @@ func (s *ClickHouseStorage) initSchema(ctx context.Context) error {
 	ORDER BY domain
 	TTL last_attempt_time + INTERVAL 180 DAY`)
 
+	// Add node_test_transcripts for the wire transcripts of BinkP and
+	// IFCICO sessions. Matched to node_test_results by node and time, not
+	// by key: see GetTestTranscripts on the server side.
+	schemas = append(schemas, `CREATE TABLE IF NOT EXISTS node_test_transcripts (
+		test_time DateTime,
+		zone UInt16,
+		net UInt16,
+		node UInt16,
+		address String,
+		domain LowCardinality(String) DEFAULT 'fidonet',
+		hostname String,
+		protocol LowCardinality(String),
+		remote_ip String,
+		success Bool,
+		error String,
+		started_at DateTime64(3),
+		raw_bytes UInt32,
+		truncated Bool,
+		transcript String CODEC(ZSTD(1))
+	) ENGINE = MergeTree()
+	PARTITION BY toYYYYMM(test_time)
+	ORDER BY (zone, net, node, test_time)
+	TTL test_time + INTERVAL 90 DAY`)
+
+	// Add node_test_vantage_results for what each location saw of a node
+	// when testing is spread over several. The rows of one test share the
+	// test_time of its combined node_test_results row.
+	schemas = append(schemas, `CREATE TABLE IF NOT EXISTS node_test_vantage_results (
+		test_time DateTime,
+		zone UInt16,
+		net UInt16,
+		node UInt16,
+		address String,
+		domain LowCardinality(String) DEFAULT 'fidonet',
+		vantage_point LowCardinality(String),
+		tested_at DateTime,
+		is_operational Bool,
+		tested_hostname String,
+		dns_error String,
+		protocols_ok Array(LowCardinality(String)),
+		protocols_failed Array(LowCardinality(String)),
+		response_ms UInt32,
+		error String
+	) ENGINE = MergeTree()
+	PARTITION BY toYYYYMM(test_time)
+	ORDER BY (zone, net, node, test_time)
+	TTL test_time + INTERVAL 180 DAY`)
+
 	for _, schema := range schemas {
 		if err := s.conn.Exec(ctx, schema); err != nil {
 			// Ignore "already exists" errors for views
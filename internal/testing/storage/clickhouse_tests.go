@@ -68,10 +68,16 @@ func (s *ClickHouseStorage) flushBatchLocked(ctx context.Context) error {
 		binkp_tested, binkp_success, binkp_response_ms,
 		binkp_system_name, binkp_sysop, binkp_location, binkp_version,
 		binkp_addresses, binkp_capabilities, binkp_error,
+		binkp_auth_method, binkp_mail_exchange, binkp_mail_detail,
+		binkp_opt_cram, binkp_opt_crypt, binkp_opt_plz, binkp_opt_gz, binkp_opt_bz2,
+		binkps_tested, binkps_success, binkps_response_ms, binkps_error,
+		binkps_cert_subject, binkps_cert_issuer, binkps_cert_not_after,
+		binkps_cert_chain_valid, binkps_cert_self_signed, binkps_cert_error,
 		ifcico_tested, ifcico_success, ifcico_response_ms,
 		ifcico_mailer_info, ifcico_system_name, ifcico_addresses,
 		ifcico_response_type, ifcico_error,
 		telnet_tested, telnet_success, telnet_response_ms, telnet_error,
+		telnet_mailer_info, telnet_system_name, telnet_sysop, telnet_addresses, telnet_response_type,
 		ftp_tested, ftp_success, ftp_response_ms, ftp_error,
 		vmodem_tested, vmodem_success, vmodem_response_ms, vmodem_error,
 		vmodem_variant, vmodem_conformant, vmodem_software, vmodem_system_name,
@@ -91,8 +97,13 @@ func (s *ClickHouseStorage) flushBatchLocked(ctx context.Context) error {
 		vmodem_ipv6_tested, vmodem_ipv6_success, vmodem_ipv6_response_ms, vmodem_ipv6_address, vmodem_ipv6_error,
 		tested_hostname, hostname_index, is_aggregated, total_hostnames, hostnames_tested, hostnames_operational,
 		binkp_ipv4_addresses, binkp_ipv6_addresses, ifcico_ipv4_addresses, ifcico_ipv6_addresses,
+		telnet_ipv4_addresses, telnet_ipv6_addresses,
 		address_validated_ipv4, address_validated_ipv6,
 		ftp_anon_success,
+		path_tcp, path_hops, path_last_hop, path_last_hop_ttl,
+		path_last_hop_asn, path_last_hop_as, path_verdict,
+		uptime_window, intermittent_by_schedule,
+		vantage_points_tested, vantage_points_reachable, vantage_verdict,
 		domain, derived_from_address
 	)`)
 	if err != nil {
@@ -110,10 +121,21 @@ func (s *ClickHouseStorage) flushBatchLocked(ctx context.Context) error {
 		return fmt.Errorf("failed to send batch: %w", err)
 	}
 
+	// The results are in; a failure from here on must not put them back
+	// in the batch to be written twice.
+	transcriptErr := s.storeTranscripts(ctx, s.resultsBatch)
+	vantageErr := s.storeVantageResults(ctx, s.resultsBatch)
+
 	// Clear batch
 	s.resultsBatch = s.resultsBatch[:0]
 	s.lastFlush = time.Now()
 
+	if transcriptErr != nil {
+		return fmt.Errorf("test results stored, transcripts lost: %w", transcriptErr)
+	}
+	if vantageErr != nil {
+		return fmt.Errorf("test results stored, per-location results lost: %w", vantageErr)
+	}
 	return nil
 }
 
@@ -525,23 +547,25 @@ func (s *ClickHouseStorage) GetNodeTestHistory(ctx context.Context, zone, net, n
 }
 
 // GetRecentAnnouncedAKAs returns, for each node identity with a recent
-// successful direct test, the union of addresses announced during BinkP and
-// IFCICO handshakes. Used to seed the AKA equivalence index at daemon startup.
+// successful direct test, the union of addresses announced during BinkP,
+// IFCICO and telnet EMSI handshakes. Used to seed the AKA equivalence index at daemon startup.
 func (s *ClickHouseStorage) GetRecentAnnouncedAKAs(ctx context.Context, days int) ([]models.AnnouncedAKARecord, error) {
 	query := `
 		SELECT
 			zone, net, node, domain,
 			arrayDistinct(arrayFlatten(groupArray(arrayConcat(
-				binkp_addresses, ifcico_addresses,
+				binkp_addresses, ifcico_addresses, telnet_addresses,
 				binkp_ipv4_addresses, binkp_ipv6_addresses,
-				ifcico_ipv4_addresses, ifcico_ipv6_addresses)))) AS announced
+				ifcico_ipv4_addresses, ifcico_ipv6_addresses,
+				telnet_ipv4_addresses, telnet_ipv6_addresses)))) AS announced
 		FROM node_test_results
 		WHERE test_time >= now() - INTERVAL ? DAY
 			AND derived_from_address = ''
 			AND is_operational = true
 			AND (length(binkp_addresses) > 0 OR length(ifcico_addresses) > 0
 				OR length(binkp_ipv4_addresses) > 0 OR length(binkp_ipv6_addresses) > 0
-				OR length(ifcico_ipv4_addresses) > 0 OR length(ifcico_ipv6_addresses) > 0)
+				OR length(ifcico_ipv4_addresses) > 0 OR length(ifcico_ipv6_addresses) > 0
+				OR length(telnet_addresses) > 0)
 		GROUP BY zone, net, node, domain
 	`
 
@@ -566,6 +590,57 @@ func (s *ClickHouseStorage) GetRecentAnnouncedAKAs(ctx context.Context, days int
 	return records, rows.Err()
 }
 
+// GetUptimeHours returns, per node identity and UTC hour of the day, how
+// many of the last days had a test in that hour and how many of those found
+// the node operational. A day counts as reachable in an hour if any test in
+// it succeeded, so the per-hostname rows and the aggregate of one cycle count
+// once. AKA-derived rows are left out: they are another node's test. The
+// newest day of each hour comes along so results still arriving for it are
+// not counted again.
+func (s *ClickHouseStorage) GetUptimeHours(ctx context.Context, days int) ([]models.UptimeHourRecord, error) {
+	query := `
+		SELECT zone, net, node, domain, hour,
+			count() AS days, countIf(up) AS reachable,
+			max(day) AS last_day, argMax(up, day) AS last_up
+		FROM (
+			SELECT zone, net, node, domain,
+				toDate(test_time, 'UTC') AS day,
+				toHour(test_time, 'UTC') AS hour,
+				max(is_operational) AS up
+			FROM node_test_results
+			WHERE test_time >= now() - INTERVAL ? DAY
+				AND derived_from_address = ''
+			GROUP BY zone, net, node, domain, day, hour
+		)
+		GROUP BY zone, net, node, domain, hour
+	`
+
+	rows, err := s.conn.Query(ctx, query, days)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query uptime hours: %w", err)
+	}
+	defer rows.Close()
+
+	var records []models.UptimeHourRecord
+	for rows.Next() {
+		var rec models.UptimeHourRecord
+		var zone, net, node uint16
+		var hour uint8
+		var days, reachable uint64
+		if err := rows.Scan(&zone, &net, &node, &rec.Domain, &hour, &days, &reachable, &rec.LastDay, &rec.LastUp); err != nil {
+			return nil, fmt.Errorf("failed to scan uptime hour row: %w", err)
+		}
+		rec.Zone = int(zone)
+		rec.Net = int(net)
+		rec.Node = int(node)
+		rec.Hour = int(hour)
+		rec.Days = int(days)
+		rec.Reachable = int(reachable)
+		records = append(records, rec)
+	}
+	return records, rows.Err()
+}
+
 // resultToValues converts TestResult to values for batch insert
 func (s *ClickHouseStorage) resultToValues(r *models.TestResult) []interface{} {
 	domain := r.Domain
@@ -578,6 +653,8 @@ func (s *ClickHouseStorage) resultToValues(r *models.TestResult) []interface{} {
 	var binkpResponseMs uint32
 	var binkpSystemName, binkpSysop, binkpLocation, binkpVersion, binkpError string
 	var binkpAddresses, binkpCapabilities []string
+	var binkpAuthMethod, binkpMailExchange, binkpMailDetail string
+	var binkpOptCRAM, binkpOptCrypt, binkpOptPLZ, binkpOptGZ, binkpOptBZ2 string
 
 	if r.BinkPResult != nil {
 		binkpTested = r.BinkPResult.Tested
@@ -594,6 +671,8 @@ func (s *ClickHouseStorage) resultToValues(r *models.TestResult) []interface{} {
 			binkpVersion = details.Version
 			binkpAddresses = details.Addresses
 			binkpCapabilities = details.Capabilities
+			binkpAuthMethod, binkpMailExchange, binkpMailDetail = details.AuthMethod, details.MailExchange, details.MailDetail
+			binkpOptCRAM, binkpOptCrypt, binkpOptPLZ, binkpOptGZ, binkpOptBZ2 = details.OptCRAM, details.OptCrypt, details.OptPLZ, details.OptGZ, details.OptBZ2
 		} else if details, ok := r.BinkPResult.Details["ipv4"].(*models.BinkPTestDetails); ok {
 			binkpSystemName = details.SystemName
 			binkpSysop = details.Sysop
@@ -601,6 +680,8 @@ func (s *ClickHouseStorage) resultToValues(r *models.TestResult) []interface{} {
 			binkpVersion = details.Version
 			binkpAddresses = details.Addresses
 			binkpCapabilities = details.Capabilities
+			binkpAuthMethod, binkpMailExchange, binkpMailDetail = details.AuthMethod, details.MailExchange, details.MailDetail
+			binkpOptCRAM, binkpOptCrypt, binkpOptPLZ, binkpOptGZ, binkpOptBZ2 = details.OptCRAM, details.OptCrypt, details.OptPLZ, details.OptGZ, details.OptBZ2
 		} else {
 			// Fall back to flat string extraction for backward compatibility
 			if sysName, ok := r.BinkPResult.Details["system_name"].(string); ok {
@@ -624,6 +705,28 @@ func (s *ClickHouseStorage) resultToValues(r *models.TestResult) []interface{} {
 		}
 	}
 
+	var binkpsTested, binkpsSuccess bool
+	var binkpsResponseMs uint32
+	var binkpsError, binkpsCertSubject, binkpsCertIssuer, binkpsCertError string
+	var binkpsCertNotAfter *time.Time
+	var binkpsCertChainValid, binkpsCertSelfSigned bool
+
+	if r.BinkPSResult != nil {
+		binkpsTested = r.BinkPSResult.Tested
+		binkpsSuccess = r.BinkPSResult.Success
+		binkpsResponseMs = r.BinkPSResult.ResponseMs
+		binkpsError = r.BinkPSResult.Error
+		if d := r.BinkPSResult.BinkPSDetails(); d != nil && d.Certificate != nil {
+			c := d.Certificate
+			binkpsCertSubject, binkpsCertIssuer, binkpsCertError = c.Subject, c.Issuer, c.Error
+			binkpsCertChainValid, binkpsCertSelfSigned = c.ChainValid, c.SelfSigned
+			if !c.NotAfter.IsZero() {
+				notAfter := c.NotAfter.UTC()
+				binkpsCertNotAfter = &notAfter
+			}
+		}
+	}
+
 	// Similar extraction for other protocols
 	var ifcicoTested, ifcicoSuccess bool
 	var ifcicoResponseMs uint32
@@ -674,11 +777,24 @@ func (s *ClickHouseStorage) resultToValues(r *models.TestResult) []interface{} {
 	var vmodemAddresses []string
 	var vmodemDetail, vmodemCallOutcome, vmodemBanner string
 
+	var telnetMailerInfo, telnetSystemName, telnetSysop, telnetResponseType string
+	var telnetAddresses []string
+
 	if r.TelnetResult != nil {
 		telnetTested = r.TelnetResult.Tested
 		telnetSuccess = r.TelnetResult.Success
 		telnetResponseMs = r.TelnetResult.ResponseMs
 		telnetError = r.TelnetResult.Error
+
+		// The mailer behind the port, if any, IPv6 first as for ifcico.
+		details, ok := r.TelnetResult.Details["ipv6"].(*models.TelnetTestDetails)
+		if !ok {
+			details, ok = r.TelnetResult.Details["ipv4"].(*models.TelnetTestDetails)
+		}
+		if ok {
+			telnetMailerInfo, telnetSystemName, telnetSysop = details.MailerInfo, details.SystemName, details.Sysop
+			telnetAddresses, telnetResponseType = details.Addresses, details.ResponseType
+		}
 	}
 
 	if r.FTPResult != nil {
@@ -832,6 +948,26 @@ func (s *ClickHouseStorage) resultToValues(r *models.TestResult) []interface{} {
 		}
 	}
 
+	var telnetIPv4Addrs, telnetIPv6Addrs []string
+	if r.TelnetResult != nil {
+		if details, ok := r.TelnetResult.Details["ipv6"].(*models.TelnetTestDetails); ok {
+			telnetIPv6Addrs = details.Addresses
+		}
+		if details, ok := r.TelnetResult.Details["ipv4"].(*models.TelnetTestDetails); ok {
+			telnetIPv4Addrs = details.Addresses
+		}
+	}
+
+	var pathTCP, pathLastHop, pathLastHopAS, pathVerdict string
+	var pathHops []string
+	var pathLastHopTTL uint8
+	var pathLastHopASN uint32
+	if pd := r.PathDiagnostic; pd != nil {
+		pathTCP, pathHops, pathLastHop = pd.TCP, pd.Hops, pd.LastHop
+		pathLastHopTTL, pathLastHopASN, pathLastHopAS = uint8(pd.LastHopTTL), pd.LastHopASN, pd.LastHopAS
+		pathVerdict = pd.Verdict
+	}
+
 	// Handle legacy compatibility: set defaults if hostname_index not set
 	testedHostname := r.TestedHostname
 	if testedHostname == "" {
@@ -846,9 +982,15 @@ func (s *ClickHouseStorage) resultToValues(r *models.TestResult) []interface{} {
 		binkpTested, binkpSuccess, binkpResponseMs, binkpSystemName,
 		binkpSysop, binkpLocation, binkpVersion, binkpAddresses,
 		binkpCapabilities, binkpError,
+		binkpAuthMethod, binkpMailExchange, binkpMailDetail,
+		binkpOptCRAM, binkpOptCrypt, binkpOptPLZ, binkpOptGZ, binkpOptBZ2,
+		binkpsTested, binkpsSuccess, binkpsResponseMs, binkpsError,
+		binkpsCertSubject, binkpsCertIssuer, binkpsCertNotAfter,
+		binkpsCertChainValid, binkpsCertSelfSigned, binkpsCertError,
 		ifcicoTested, ifcicoSuccess, ifcicoResponseMs, ifcicoMailerInfo,
 		ifcicoSystemName, ifcicoAddresses, ifcicoResponseType, ifcicoError,
 		telnetTested, telnetSuccess, telnetResponseMs, telnetError,
+		telnetMailerInfo, telnetSystemName, telnetSysop, telnetAddresses, telnetResponseType,
 		ftpTested, ftpSuccess, ftpResponseMs, ftpError,
 		vmodemTested, vmodemSuccess, vmodemResponseMs, vmodemError,
 		vmodemVariant, vmodemConformant, vmodemSoftware, vmodemSystemName,
@@ -873,9 +1015,17 @@ func (s *ClickHouseStorage) resultToValues(r *models.TestResult) []interface{} {
 		r.TotalHostnames, r.HostnamesTested, r.HostnamesOperational,
 		// Per-IP-version AKA addresses and validation flags
 		binkpIPv4Addrs, binkpIPv6Addrs, ifcicoIPv4Addrs, ifcicoIPv6Addrs,
+		telnetIPv4Addrs, telnetIPv6Addrs,
 		r.AddressValidatedIPv4, r.AddressValidatedIPv6,
 		// FTP anonymous login result
 		ftpAnonSuccess,
+		// Path probe of a node that failed every protocol
+		pathTCP, pathHops, pathLastHop, pathLastHopTTL,
+		pathLastHopASN, pathLastHopAS, pathVerdict,
+		// Learned uptime window and whether this failure fell outside it
+		r.UptimeWindow, r.IntermittentBySchedule,
+		// How many locations tested the node and how many reached it
+		uint8(r.VantagePointsTested), uint8(r.VantagePointsReachable), r.VantageVerdict,
 		// Multi-network identity and AKA-derivation provenance
 		domain, r.DerivedFromAddress,
 	}
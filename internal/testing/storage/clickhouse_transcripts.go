@@ -0,0 +1,61 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+// transcriptInsertSQL is batch-shaped like the other inserts here; see
+// emailDomainCheckInsertSQL for why there are no VALUES placeholders.
+const transcriptInsertSQL = `INSERT INTO node_test_transcripts (
+	test_time, zone, net, node, address, domain, hostname,
+	protocol, remote_ip, success, error,
+	started_at, raw_bytes, truncated, transcript
+)`
+
+// transcriptValues is one node_test_transcripts row, in transcriptInsertSQL
+// order.
+func transcriptValues(r *models.TestResult, t models.TestTranscript) []interface{} {
+	domain := r.Domain
+	if domain == "" {
+		domain = models.DefaultDomain
+	}
+	hostname := r.TestedHostname
+	if hostname == "" {
+		hostname = r.Hostname
+	}
+	return []interface{}{
+		r.TestTime, uint16(r.Zone), uint16(r.Net), uint16(r.Node), r.Address, domain, hostname,
+		t.Protocol, t.RemoteIP, t.Success, t.Error,
+		t.Started, uint32(t.RawBytes), t.Truncated, string(t.Data),
+	}
+}
+
+// storeTranscripts writes the transcripts the results carry.
+func (s *ClickHouseStorage) storeTranscripts(ctx context.Context, results []*models.TestResult) error {
+	var n int
+	for _, r := range results {
+		n += len(r.Transcripts)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	batch, err := s.conn.PrepareBatch(ctx, transcriptInsertSQL)
+	if err != nil {
+		return fmt.Errorf("failed to prepare transcript batch: %w", err)
+	}
+	for _, r := range results {
+		for _, t := range r.Transcripts {
+			if err := batch.Append(transcriptValues(r, t)...); err != nil {
+				return fmt.Errorf("failed to append transcript: %w", err)
+			}
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send %d transcript(s): %w", n, err)
+	}
+	return nil
+}
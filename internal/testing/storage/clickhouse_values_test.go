@@ -1,6 +1,7 @@
 package storage
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/nodelistdb/internal/testing/models"
@@ -10,7 +11,7 @@ import (
 // flushBatchLocked. resultToValues must return exactly this many values in the same
 // order, or ClickHouse batch appends fail at runtime. If you add or remove a
 // column, update the INSERT list, resultToValues, AND this constant together.
-const resultToValuesColumns = 128
+const resultToValuesColumns = 165
 
 func TestResultToValuesColumnCount(t *testing.T) {
 	s := &ClickHouseStorage{}
@@ -19,3 +20,21 @@ func TestResultToValuesColumnCount(t *testing.T) {
 		t.Fatalf("resultToValues returned %d values, want %d (must match the flushBatchLocked INSERT column list)", len(vals), resultToValuesColumns)
 	}
 }
+
+func TestTranscriptValuesColumnCount(t *testing.T) {
+	list := transcriptInsertSQL[strings.Index(transcriptInsertSQL, "(")+1 : strings.LastIndex(transcriptInsertSQL, ")")]
+	columns := len(strings.Split(list, ","))
+	vals := transcriptValues(&models.TestResult{}, models.TestTranscript{})
+	if len(vals) != columns {
+		t.Fatalf("transcriptValues returned %d values, transcriptInsertSQL lists %d columns", len(vals), columns)
+	}
+}
+
+func TestVantageValuesColumnCount(t *testing.T) {
+	list := vantageInsertSQL[strings.Index(vantageInsertSQL, "(")+1 : strings.LastIndex(vantageInsertSQL, ")")]
+	columns := len(strings.Split(list, ","))
+	vals := vantageValues(&models.TestResult{}, models.VantageResult{})
+	if len(vals) != columns {
+		t.Fatalf("vantageValues returned %d values, vantageInsertSQL lists %d columns", len(vals), columns)
+	}
+}
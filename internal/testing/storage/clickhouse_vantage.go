@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/nodelistdb/internal/testing/models"
+)
+
+// vantageInsertSQL is batch-shaped like the other inserts here; see
+// emailDomainCheckInsertSQL for why there are no VALUES placeholders.
+const vantageInsertSQL = `INSERT INTO node_test_vantage_results (
+	test_time, zone, net, node, address, domain, vantage_point,
+	tested_at, is_operational, tested_hostname, dns_error,
+	protocols_ok, protocols_failed, response_ms, error
+)`
+
+// vantageValues is one node_test_vantage_results row, in vantageInsertSQL
+// order. test_time is the combined result's, so the rows of one test share
+// it; tested_at is when that location ran its test.
+func vantageValues(r *models.TestResult, v models.VantageResult) []interface{} {
+	domain := r.Domain
+	if domain == "" {
+		domain = models.DefaultDomain
+	}
+	protocolsOK := v.ProtocolsOK
+	if protocolsOK == nil {
+		protocolsOK = []string{}
+	}
+	protocolsFailed := v.ProtocolsFailed
+	if protocolsFailed == nil {
+		protocolsFailed = []string{}
+	}
+	return []interface{}{
+		r.TestTime, uint16(r.Zone), uint16(r.Net), uint16(r.Node), r.Address, domain, v.VantagePoint,
+		v.TestTime, v.IsOperational, v.TestedHostname, v.DNSError,
+		protocolsOK, protocolsFailed, v.ResponseMs, v.Error,
+	}
+}
+
+// storeVantageResults writes the per-location results the combined results
+// carry.
+func (s *ClickHouseStorage) storeVantageResults(ctx context.Context, results []*models.TestResult) error {
+	var n int
+	for _, r := range results {
+		n += len(r.VantageResults)
+	}
+	if n == 0 {
+		return nil
+	}
+
+	batch, err := s.conn.PrepareBatch(ctx, vantageInsertSQL)
+	if err != nil {
+		return fmt.Errorf("failed to prepare vantage batch: %w", err)
+	}
+	for _, r := range results {
+		for _, v := range r.VantageResults {
+			if err := batch.Append(vantageValues(r, v)...); err != nil {
+				return fmt.Errorf("failed to append vantage result: %w", err)
+			}
+		}
+	}
+	if err := batch.Send(); err != nil {
+		return fmt.Errorf("failed to send %d vantage result(s): %w", n, err)
+	}
+	return nil
+}
@@ -26,6 +26,7 @@ type Storage interface {
 	GetLatestTestResults(ctx context.Context, limit int) ([]*models.TestResult, error)
 	GetNodeTestHistory(ctx context.Context, zone, net, node int, domain string, days int) ([]*models.TestResult, error)
 	GetRecentAnnouncedAKAs(ctx context.Context, days int) ([]models.AnnouncedAKARecord, error)
+	GetUptimeHours(ctx context.Context, days int) ([]models.UptimeHourRecord, error)
 
 	// WHOIS operations
 	StoreWhoisResult(ctx context.Context, result *models.WhoisResult) error
@@ -0,0 +1,376 @@
+package transcript
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/protocols/binkp"
+)
+
+// Event is one protocol unit read back out of a transcript: a BinkP frame,
+// an EMSI packet, or the text between EMSI packets.
+type Event struct {
+	At     time.Duration // when the chunk it starts in was seen
+	Dir    string        // TX or RX
+	Name   string        // M_NUL, DATA, EMSI_DAT, text, ...
+	Detail string
+	// Bad marks a unit that did not parse: a frame cut short, an EMSI
+	// packet with a wrong CRC.
+	Bad bool
+}
+
+// maxDetail is how much of a unit's content an Event carries.
+const maxDetail = 512
+
+// frameNames are the BinkP command frames by number.
+var frameNames = map[byte]string{
+	binkp.M_NUL:  "M_NUL",
+	binkp.M_ADR:  "M_ADR",
+	binkp.M_PWD:  "M_PWD",
+	binkp.M_FILE: "M_FILE",
+	binkp.M_OK:   "M_OK",
+	binkp.M_EOB:  "M_EOB",
+	binkp.M_GOT:  "M_GOT",
+	binkp.M_ERR:  "M_ERR",
+	binkp.M_BSY:  "M_BSY",
+	binkp.M_GET:  "M_GET",
+	binkp.M_SKIP: "M_SKIP",
+}
+
+// binkpFrame is a frame located in one direction's stream.
+type binkpFrame struct {
+	start, end int // header start; one past the body
+	command    bool
+	compressed bool
+	typ        byte
+	body       []byte // without the command byte; inflated when compressed
+	err        string // set on the last frame when the stream ends inside it
+}
+
+// parseBinkPFrames splits one direction's stream into frames. With plz,
+// header bit 14 marks a zlib block. stop, when set, ends the parse after
+// the frame it returns true for.
+func parseBinkPFrames(data []byte, plz bool, stop func(binkpFrame) bool) (frames []binkpFrame, rest int) {
+	pos := 0
+	for pos < len(data) {
+		if len(data)-pos < 2 {
+			frames = append(frames, binkpFrame{start: pos, end: len(data), err: "stream ends inside a frame header"})
+			return frames, len(data)
+		}
+		h := binary.BigEndian.Uint16(data[pos:])
+		f := binkpFrame{start: pos, command: h&0x8000 != 0}
+		n := int(h & 0x7FFF)
+		if plz && h&0x4000 != 0 {
+			f.compressed, n = true, int(h&0x3FFF)
+		}
+		if pos+2+n > len(data) {
+			f.end = len(data)
+			f.err = fmt.Sprintf("frame announces %d bytes, %d arrived", n, len(data)-pos-2)
+			return append(frames, f), len(data)
+		}
+		f.end = pos + 2 + n
+		body := data[pos+2 : f.end]
+		if f.compressed {
+			inflated, err := inflate(body)
+			if err != nil {
+				f.err = "bad PLZ block: " + err.Error()
+			}
+			body = inflated
+		}
+		if f.command {
+			if len(body) == 0 {
+				f.err = "command frame with no command byte"
+			} else {
+				f.typ, body = body[0], body[1:]
+			}
+		}
+		f.body = body
+		frames = append(frames, f)
+		pos = f.end
+		if stop != nil && stop(f) {
+			break
+		}
+	}
+	return frames, pos
+}
+
+func inflate(data []byte) ([]byte, error) {
+	zr, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer zr.Close()
+	return io.ReadAll(io.LimitReader(zr, 1<<16))
+}
+
+// binkpOptions is what one side announced in its M_NUL OPT frames.
+func binkpOptions(frames []binkpFrame) map[string]bool {
+	opts := make(map[string]bool)
+	for _, f := range frames {
+		if !f.command || f.typ != binkp.M_NUL {
+			continue
+		}
+		if key, value := binkp.ParseM_NUL(f.body); key == "OPT" {
+			for _, o := range strings.Fields(value) {
+				opts[strings.ToUpper(o)] = true
+			}
+		}
+	}
+	return opts
+}
+
+// BinkPFrames decodes a BinkP transcript into its frames, both directions
+// merged in time order.
+//
+// With PLZ agreed the compressed blocks are inflated. With CRYPT agreed
+// everything after the password exchange is ciphertext and is reported as
+// one undecoded event per direction.
+func BinkPFrames(t *Transcript) []Event {
+	tx, txStarts, txChunks := t.Stream(TX)
+	rx, rxStarts, rxChunks := t.Stream(RX)
+
+	// A first pass with PLZ off finds the options: OPT goes out before
+	// anything that could have bit 14 set.
+	txPlain, _ := parseBinkPFrames(tx, false, nil)
+	rxPlain, _ := parseBinkPFrames(rx, false, nil)
+	ourOpts, theirOpts := binkpOptions(txPlain), binkpOptions(rxPlain)
+	plz := ourOpts["PLZ"] && theirOpts["PLZ"]
+	crypt := ourOpts["CRYPT"] && theirOpts["CRYPT"]
+
+	// The caller encrypts after its M_PWD, the answerer after its M_OK.
+	decode := func(dir string, data []byte, starts []int, chunks []Chunk, last byte) []Event {
+		var stop func(binkpFrame) bool
+		if crypt {
+			stop = func(f binkpFrame) bool { return f.command && f.typ == last }
+		}
+		frames, rest := parseBinkPFrames(data, plz, stop)
+		events := make([]Event, 0, len(frames)+1)
+		for _, f := range frames {
+			events = append(events, frameEvent(dir, f, chunkTime(f.start, starts, chunks)))
+		}
+		if rest < len(data) {
+			events = append(events, Event{
+				At:     chunkTime(rest, starts, chunks),
+				Dir:    dir,
+				Name:   "CRYPT",
+				Detail: fmt.Sprintf("%d encrypted bytes not decoded", len(data)-rest),
+			})
+		}
+		return events
+	}
+	return merge(
+		decode(TX, tx, txStarts, txChunks, binkp.M_PWD),
+		decode(RX, rx, rxStarts, rxChunks, binkp.M_OK),
+	)
+}
+
+func frameEvent(dir string, f binkpFrame, at time.Duration) Event {
+	e := Event{At: at, Dir: dir, Bad: f.err != ""}
+	switch {
+	case f.err != "" && len(f.body) == 0:
+		e.Name, e.Detail = "partial", f.err
+		return e
+	case !f.command:
+		e.Name, e.Detail = "DATA", fmt.Sprintf("%d bytes", len(f.body))
+	default:
+		e.Name = frameNames[f.typ]
+		if e.Name == "" {
+			e.Name = fmt.Sprintf("0x%02X", f.typ)
+		}
+		e.Detail = printable(f.body, maxDetail)
+	}
+	if f.compressed {
+		e.Detail += " (PLZ)"
+	}
+	if f.err != "" {
+		e.Detail += "; " + f.err
+	}
+	return e
+}
+
+// RedactBinkPPasswords blanks out the body of every M_PWD we sent, whether
+// a plain password or a CRAM digest, so that a stored transcript gives
+// nothing away.
+func RedactBinkPPasswords(t *Transcript) {
+	tx, starts, chunks := t.Stream(TX)
+	frames, _ := parseBinkPFrames(tx, false, func(f binkpFrame) bool {
+		return f.command && f.typ == binkp.M_PWD
+	})
+	for _, f := range frames {
+		if !f.command || f.typ != binkp.M_PWD || f.err != "" {
+			continue
+		}
+		// Header, command byte, then the password. The chunks returned by
+		// Stream share their bytes with t.
+		for off := f.start + 3; off < f.end; off++ {
+			i := sort.SearchInts(starts, off+1) - 1
+			chunks[i].Data[off-starts[i]] = '*'
+		}
+	}
+}
+
+// emsiPrefix starts every EMSI packet.
+const emsiPrefix = "**EMSI_"
+
+// EMSIPackets decodes an IFCICO transcript into its EMSI packets and the
+// text around them (banners, prompts), both directions merged in time order.
+// The CRC of every packet is checked.
+func EMSIPackets(t *Transcript) []Event {
+	decode := func(dir string) []Event {
+		data, starts, chunks := t.Stream(dir)
+		var events []Event
+		text := func(from, to int) {
+			if s := bytes.TrimSpace(data[from:to]); len(s) > 0 {
+				events = append(events, Event{At: chunkTime(from, starts, chunks), Dir: dir, Name: "text", Detail: printable(s, maxDetail)})
+			}
+		}
+		pos := 0
+		for {
+			i := bytes.Index(data[pos:], []byte(emsiPrefix))
+			if i < 0 {
+				text(pos, len(data))
+				return events
+			}
+			start := pos + i
+			text(pos, start)
+			e, end := emsiPacket(data, start)
+			e.At, e.Dir = chunkTime(start, starts, chunks), dir
+			events = append(events, e)
+			pos = end
+		}
+	}
+	return merge(decode(TX), decode(RX))
+}
+
+// emsiPacket reads the packet starting at data[start:], which begins with
+// emsiPrefix, and returns it with the offset just past it.
+func emsiPacket(data []byte, start int) (Event, int) {
+	p := data[start+2:] // from "EMSI_"
+	if len(p) < 8 {
+		return Event{Name: "EMSI_", Detail: "stream ends inside the packet", Bad: true}, len(data)
+	}
+	e := Event{Name: string(p[:8])}
+	body := 8
+	if e.Name == "EMSI_DAT" {
+		if len(p) < 12 {
+			e.Detail, e.Bad = "stream ends inside the length", true
+			return e, len(data)
+		}
+		n, err := strconv.ParseUint(string(p[8:12]), 16, 16)
+		if err != nil {
+			e.Detail, e.Bad = fmt.Sprintf("bad length %q", p[8:12]), true
+			return e, start + 2 + 12
+		}
+		body = 12 + int(n)
+		if len(p) < body {
+			e.Detail, e.Bad = fmt.Sprintf("announces %d bytes, %d arrived", n, len(p)-12), true
+			return e, len(data)
+		}
+		e.Detail = printable(p[12:body], maxDetail)
+	}
+	if len(p) < body+4 {
+		e.Detail, e.Bad = strings.TrimPrefix(e.Detail+"; stream ends inside the CRC", "; "), true
+		return e, len(data)
+	}
+	want := fmt.Sprintf("%04X", crc16(p[:body]))
+	if got := strings.ToUpper(string(p[body : body+4])); got != want {
+		e.Detail = strings.TrimPrefix(e.Detail+fmt.Sprintf("; CRC %s, expected %s", got, want), "; ")
+		e.Bad = true
+	}
+	return e, start + 2 + body + 4
+}
+
+// crc16 is the CRC-16/XMODEM EMSI packets carry.
+func crc16(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return crc
+}
+
+// chunkTime is when the byte at off of a direction's stream was seen.
+func chunkTime(off int, starts []int, chunks []Chunk) time.Duration {
+	i := sort.SearchInts(starts, off+1) - 1
+	if i < 0 {
+		return 0
+	}
+	return chunks[i].At
+}
+
+// merge interleaves the two directions by time, keeping each in its order.
+func merge(tx, rx []Event) []Event {
+	out := append(tx, rx...)
+	sort.SliceStable(out, func(i, j int) bool { return out[i].At < out[j].At })
+	return out
+}
+
+// printable renders b as text, escaping control and non-ASCII bytes and
+// cutting it at limit bytes.
+func printable(b []byte, limit int) string {
+	var sb strings.Builder
+	more := 0
+	if len(b) > limit {
+		b, more = b[:limit], len(b)-limit
+	}
+	for _, c := range b {
+		switch {
+		case c == '\r':
+			sb.WriteString(`\r`)
+		case c == '\n':
+			sb.WriteString(`\n`)
+		case c == '\\':
+			sb.WriteString(`\\`)
+		case c < 0x20 || c >= 0x7F:
+			fmt.Fprintf(&sb, `\x%02X`, c)
+		default:
+			sb.WriteByte(c)
+		}
+	}
+	if more > 0 {
+		fmt.Fprintf(&sb, "... (%d more bytes)", more)
+	}
+	return sb.String()
+}
+
+// Hexdump renders a chunk's bytes the way hexdump -C does, for the raw view.
+func Hexdump(data []byte) string {
+	var sb strings.Builder
+	for off := 0; off < len(data); off += 16 {
+		line := data[off:min(off+16, len(data))]
+		fmt.Fprintf(&sb, "%08x ", off)
+		for i := 0; i < 16; i++ {
+			if i == 8 {
+				sb.WriteByte(' ')
+			}
+			if i < len(line) {
+				fmt.Fprintf(&sb, " %02x", line[i])
+			} else {
+				sb.WriteString("   ")
+			}
+		}
+		sb.WriteString("  |")
+		for _, c := range line {
+			if c < 0x20 || c >= 0x7F {
+				c = '.'
+			}
+			sb.WriteByte(c)
+		}
+		sb.WriteString("|\n")
+	}
+	return sb.String()
+}
@@ -0,0 +1,168 @@
+package transcript
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// DefaultIdle is how long Replay waits for the caller to send what it sent
+// in the recording before answering anyway.
+const DefaultIdle = 2 * time.Second
+
+// Replay plays the remote's side of t to c, which is connected to the
+// tester under test.
+//
+// Timing is not reproduced, ordering is: each chunk the remote sent is
+// written once the caller has sent as many bytes as it had by then in the
+// recording. What the caller sends is not compared: a nonce or a timestamp
+// differs every run. A caller that sends less than it did (a different
+// tester build, a different password) gets the next chunk after idle of
+// silence. When the remote hung up in the recording, Replay closes c at the
+// end; otherwise it holds the line until the caller closes it.
+func Replay(c net.Conn, t *Transcript, idle time.Duration) error {
+	var (
+		mu       sync.Mutex
+		received int
+		readErr  error
+		progress = make(chan struct{}, 1)
+	)
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := c.Read(buf)
+			mu.Lock()
+			received += n
+			if err != nil {
+				readErr = err
+			}
+			mu.Unlock()
+			select {
+			case progress <- struct{}{}:
+			default:
+			}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	// waitFor returns once the caller has sent want bytes, has been quiet
+	// for idle, or has gone.
+	waitFor := func(want int) (gone bool) {
+		timer := time.NewTimer(idle)
+		defer timer.Stop()
+		for {
+			mu.Lock()
+			got, err := received, readErr
+			mu.Unlock()
+			if err != nil {
+				return true
+			}
+			if got >= want {
+				return false
+			}
+			select {
+			case <-progress:
+				timer.Reset(idle)
+			case <-timer.C:
+				return false
+			}
+		}
+	}
+
+	sent := 0
+	for i, chunk := range t.Chunks {
+		if chunk.Dir == TX {
+			sent += len(chunk.Data)
+			continue
+		}
+		if waitFor(sent) {
+			return fmt.Errorf("caller hung up before chunk %d of %d", i+1, len(t.Chunks))
+		}
+		if _, err := c.Write(chunk.Data); err != nil {
+			return fmt.Errorf("chunk %d: %w", i+1, err)
+		}
+	}
+
+	if t.RemoteClosed {
+		return c.Close()
+	}
+	for {
+		mu.Lock()
+		err := readErr
+		mu.Unlock()
+		if err != nil {
+			if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
+				return nil
+			}
+			return err
+		}
+		<-progress
+	}
+}
+
+// Server replays one transcript to the first connection made to it.
+type Server struct {
+	ln   net.Listener
+	done chan struct{}
+	err  error
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// Serve starts a Server for t on a loopback port.
+func Serve(t *Transcript, idle time.Duration) (*Server, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+	s := &Server{ln: ln, done: make(chan struct{})}
+	go func() {
+		defer close(s.done)
+		c, err := ln.Accept()
+		ln.Close()
+		if err != nil {
+			s.err = err
+			return
+		}
+		s.mu.Lock()
+		s.conn = c
+		s.mu.Unlock()
+		s.err = Replay(c, t, idle)
+		c.Close()
+	}()
+	return s, nil
+}
+
+// Host is the address to dial.
+func (s *Server) Host() string {
+	return s.ln.Addr().(*net.TCPAddr).IP.String()
+}
+
+// Port is the port to dial.
+func (s *Server) Port() int {
+	return s.ln.Addr().(*net.TCPAddr).Port
+}
+
+// Wait returns once the replay has finished, with its error.
+func (s *Server) Wait() error {
+	<-s.done
+	return s.err
+}
+
+// Close stops the server, cutting off a replay still in progress.
+func (s *Server) Close() error {
+	s.ln.Close()
+	s.mu.Lock()
+	if s.conn != nil {
+		s.conn.Close()
+	}
+	s.mu.Unlock()
+	<-s.done
+	return nil
+}
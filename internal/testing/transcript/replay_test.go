@@ -0,0 +1,131 @@
+package transcript_test
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/fakemailer"
+	"github.com/nodelistdb/internal/testing/protocols"
+	"github.com/nodelistdb/internal/testing/transcript"
+)
+
+var identity = fakemailer.Identity{
+	Addresses:  []string{"2:5020/1@fidonet", "2:5020/2@fidonet"},
+	SystemName: "Fake Node",
+	Sysop:      "Jane Doe",
+	Location:   "Moscow",
+	Mailer:     "binkd",
+	Version:    "1.1a-115",
+}
+
+// record runs tester against a fake mailer and returns its result, which
+// carries the transcript.
+func record(t *testing.T, cfg fakemailer.Config, tester protocols.Tester) protocols.TestResult {
+	t.Helper()
+	m, err := fakemailer.Start(cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close()
+	tester.(protocols.TranscriptSetter).SetTranscriptCapture(true)
+	return tester.Test(context.Background(), m.Host(), m.Port(), "2:5020/1")
+}
+
+// replay runs tester against a replay of tr.
+func replay(t *testing.T, tr *transcript.Transcript, tester protocols.Tester) protocols.TestResult {
+	t.Helper()
+	srv, err := transcript.Serve(tr, 200*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer srv.Close()
+	return tester.Test(context.Background(), srv.Host(), srv.Port(), "2:5020/1")
+}
+
+func TestReplayReproducesBinkPTest(t *testing.T) {
+	withPassword := identity
+	withPassword.Password = "s3cr3t"
+
+	tests := []struct {
+		name     string
+		cfg      fakemailer.Config
+		password string
+	}{
+		{"anonymous session", fakemailer.Config{Protocol: fakemailer.BinkP, Identity: identity}, ""},
+		{"password session", fakemailer.Config{Protocol: fakemailer.BinkP, Identity: withPassword}, "s3cr3t"},
+		{"busy", fakemailer.Config{Protocol: fakemailer.BinkP, Identity: identity, Fault: fakemailer.FaultBusy}, ""},
+		{"truncated frame", fakemailer.Config{Protocol: fakemailer.BinkP, Identity: identity, Fault: fakemailer.FaultMalformed}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			newTester := func() *protocols.BinkPTester {
+				tester := protocols.NewBinkPTester(5*time.Second, "2:5001/5001")
+				if tt.password != "" {
+					tester.SetSessionPasswords(map[string]string{"2:5020/1": tt.password})
+				}
+				return tester
+			}
+			live := record(t, tt.cfg, newTester()).(*protocols.BinkPTestResult)
+			if live.Transcript == nil || len(live.Transcript.Chunks) == 0 {
+				t.Fatalf("no transcript recorded (result %+v)", live)
+			}
+			if live.Transcript.Protocol != "binkp" {
+				t.Errorf("Protocol = %q", live.Transcript.Protocol)
+			}
+			if tt.password != "" {
+				if tx, _, _ := live.Transcript.Stream(transcript.TX); bytes.Contains(tx, []byte("CRAM-MD5-")) {
+					t.Error("the CRAM response was stored")
+				}
+			}
+
+			// What is stored is what is replayed.
+			data, err := live.Transcript.Encode()
+			if err != nil {
+				t.Fatal(err)
+			}
+			stored, err := transcript.Decode(data)
+			if err != nil {
+				t.Fatal(err)
+			}
+			again := replay(t, stored, newTester()).(*protocols.BinkPTestResult)
+
+			if again.Success != live.Success || again.SystemName != live.SystemName ||
+				!reflect.DeepEqual(again.Addresses, live.Addresses) || again.AddressValid != live.AddressValid {
+				t.Errorf("replay = %v %q %v %v, live = %v %q %v %v", again.Success, again.SystemName, again.Addresses, again.AddressValid,
+					live.Success, live.SystemName, live.Addresses, live.AddressValid)
+			}
+			if (again.Error == "") != (live.Error == "") {
+				t.Errorf("replay error %q, live error %q", again.Error, live.Error)
+			}
+		})
+	}
+}
+
+func TestReplayReproducesIfcicoTest(t *testing.T) {
+	cfg := fakemailer.Config{Protocol: fakemailer.EMSI, Identity: identity}
+	newTester := func() *protocols.IfcicoTester {
+		return protocols.NewIfcicoTester(5*time.Second, "2:5001/5001")
+	}
+	live := record(t, cfg, newTester()).(*protocols.IfcicoTestResult)
+	if !live.Success || live.Transcript == nil {
+		t.Fatalf("live test: %+v", live)
+	}
+
+	var sawDAT bool
+	for _, e := range transcript.EMSIPackets(live.Transcript) {
+		if e.Dir == transcript.RX && e.Name == "EMSI_DAT" {
+			sawDAT = !e.Bad
+		}
+	}
+	if !sawDAT {
+		t.Error("the node's EMSI_DAT was not decoded from the transcript")
+	}
+
+	again := replay(t, live.Transcript, newTester()).(*protocols.IfcicoTestResult)
+	if !again.Success || again.MailerInfo != live.MailerInfo || !reflect.DeepEqual(again.Addresses, live.Addresses) {
+		t.Errorf("replay = %+v, live = %+v", again, live)
+	}
+}
@@ -0,0 +1,226 @@
+// Package transcript records what crossed the wire during a protocol test
+// and plays it back.
+//
+// A failed BinkP or IFCICO test leaves one line of error text behind, which
+// is rarely enough to tell a broken mailer from a bug in our own parser. A
+// Recorder wraps the test's connection and keeps every byte in both
+// directions with the time it was seen; BinkPFrames and EMSIPackets turn
+// that back into something readable, and Replay serves the remote's half to
+// a tester again, so a parser bug found in the field can be reproduced on a
+// developer's machine without the node that triggered it.
+package transcript
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// Directions of a Chunk, as seen from the tester.
+const (
+	TX = "tx" // we sent it
+	RX = "rx" // the remote sent it
+)
+
+// DefaultLimit is how many bytes a Recorder keeps before it stops recording.
+// A handshake is a few KiB; the limit only matters for a remote that floods
+// the line, and what it sent first is what explains the failure.
+const DefaultLimit = 256 << 10
+
+// maxDecoded caps what Decode inflates, well above anything Encode makes
+// from a DefaultLimit transcript.
+const maxDecoded = 8 << 20
+
+// Transcript is one recorded session.
+type Transcript struct {
+	Protocol string    `json:"protocol"` // binkp | binkps | ifcico
+	Remote   string    `json:"remote"`   // host:port dialled
+	Started  time.Time `json:"started"`
+	Chunks   []Chunk   `json:"chunks"`
+	// Truncated is set when the session went on past the recording limit.
+	Truncated bool `json:"truncated,omitempty"`
+	// RemoteClosed is set when the remote hung up or reset the connection;
+	// otherwise the session ended on our side, by a timeout or after a clean
+	// finish.
+	RemoteClosed bool `json:"remote_closed,omitempty"`
+}
+
+// Chunk is a run of bytes that went one way, At after the session started.
+type Chunk struct {
+	At   time.Duration `json:"at"`
+	Dir  string        `json:"dir"`
+	Data []byte        `json:"data"`
+}
+
+// Bytes is the number of bytes recorded in both directions.
+func (t *Transcript) Bytes() int {
+	n := 0
+	for _, c := range t.Chunks {
+		n += len(c.Data)
+	}
+	return n
+}
+
+// Stream is everything that went in direction dir, in order, with the
+// offset in it at which each chunk starts.
+func (t *Transcript) Stream(dir string) (data []byte, starts []int, chunks []Chunk) {
+	for _, c := range t.Chunks {
+		if c.Dir != dir {
+			continue
+		}
+		starts = append(starts, len(data))
+		chunks = append(chunks, c)
+		data = append(data, c.Data...)
+	}
+	return data, starts, chunks
+}
+
+// Encode serialises t compressed, the form it is stored in.
+func (t *Transcript) Encode() ([]byte, error) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if err := json.NewEncoder(zw).Encode(t); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// Decode reads a transcript written by Encode.
+func Decode(data []byte) (*Transcript, error) {
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("not a transcript: %w", err)
+	}
+	defer zr.Close()
+	raw, err := io.ReadAll(io.LimitReader(zr, maxDecoded+1))
+	if err != nil {
+		return nil, fmt.Errorf("corrupt transcript: %w", err)
+	}
+	if len(raw) > maxDecoded {
+		return nil, fmt.Errorf("transcript inflates past %d bytes", maxDecoded)
+	}
+	var t Transcript
+	if err := json.Unmarshal(raw, &t); err != nil {
+		return nil, fmt.Errorf("corrupt transcript: %w", err)
+	}
+	for i, c := range t.Chunks {
+		if c.Dir != TX && c.Dir != RX {
+			return nil, fmt.Errorf("corrupt transcript: chunk %d has direction %q", i, c.Dir)
+		}
+	}
+	return &t, nil
+}
+
+// Recorder is a net.Conn that records what passes through it. Wrap the
+// connection below any layer whose bytes should be seen as sent: over TLS,
+// wrap the tls.Conn, not the TCP connection under it.
+type Recorder struct {
+	net.Conn
+	limit int
+
+	mu   sync.Mutex
+	t    Transcript
+	size int
+}
+
+// Record starts recording c, up to DefaultLimit bytes.
+func Record(c net.Conn, protocol string) *Recorder {
+	remote := ""
+	if addr := c.RemoteAddr(); addr != nil {
+		remote = addr.String()
+	}
+	return &Recorder{
+		Conn:  c,
+		limit: DefaultLimit,
+		t:     Transcript{Protocol: protocol, Remote: remote, Started: time.Now()},
+	}
+}
+
+// SetLimit changes how many bytes are kept.
+func (r *Recorder) SetLimit(limit int) {
+	r.mu.Lock()
+	r.limit = limit
+	r.mu.Unlock()
+}
+
+func (r *Recorder) Read(p []byte) (int, error) {
+	n, err := r.Conn.Read(p)
+	r.add(RX, p[:n])
+	if err != nil && remoteHangup(err) {
+		r.mu.Lock()
+		r.t.RemoteClosed = true
+		r.mu.Unlock()
+	}
+	return n, err
+}
+
+// remoteHangup tells an I/O error caused by the remote (an EOF, a reset, a
+// broken pipe) from our own timeout or close.
+func remoteHangup(err error) bool {
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return false
+	}
+	return !errors.Is(err, net.ErrClosed)
+}
+
+func (r *Recorder) Write(p []byte) (int, error) {
+	n, err := r.Conn.Write(p)
+	r.add(TX, p[:n])
+	if err != nil && remoteHangup(err) {
+		r.mu.Lock()
+		r.t.RemoteClosed = true
+		r.mu.Unlock()
+	}
+	return n, err
+}
+
+// coalesce is how close together two same-way writes must be to be kept as
+// one chunk. BinkP writes a frame's header and body separately.
+const coalesce = time.Millisecond
+
+func (r *Recorder) add(dir string, p []byte) {
+	if len(p) == 0 {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.size+len(p) > r.limit {
+		p = p[:r.limit-r.size]
+		r.t.Truncated = true
+		if len(p) == 0 {
+			return
+		}
+	}
+	r.size += len(p)
+	at := time.Since(r.t.Started)
+	if n := len(r.t.Chunks); n > 0 {
+		last := &r.t.Chunks[n-1]
+		if last.Dir == dir && at-last.At < coalesce {
+			last.Data = append(last.Data, p...)
+			return
+		}
+	}
+	r.t.Chunks = append(r.t.Chunks, Chunk{At: at, Dir: dir, Data: append([]byte(nil), p...)})
+}
+
+// Transcript returns a copy of what has been recorded so far.
+func (r *Recorder) Transcript() *Transcript {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	t := r.t
+	t.Chunks = make([]Chunk, len(r.t.Chunks))
+	for i, c := range r.t.Chunks {
+		t.Chunks[i] = Chunk{At: c.At, Dir: c.Dir, Data: append([]byte(nil), c.Data...)}
+	}
+	return &t
+}
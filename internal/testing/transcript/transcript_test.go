@@ -0,0 +1,178 @@
+package transcript
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/testing/protocols/binkp"
+)
+
+func TestRecorder(t *testing.T) {
+	client, server := net.Pipe()
+	rec := Record(client, "binkp")
+	rec.SetLimit(10)
+
+	go func() {
+		buf := make([]byte, 16)
+		n, _ := server.Read(buf)
+		_, _ = server.Write(bytes.ToUpper(buf[:n]))
+		time.Sleep(5 * time.Millisecond)
+		_, _ = server.Write([]byte("overflowing"))
+		server.Close()
+	}()
+
+	if _, err := rec.Write([]byte("hello")); err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(rec)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != "HELLOoverflowing" {
+		t.Fatalf("read %q: the recorder must pass everything through", got)
+	}
+
+	tr := rec.Transcript()
+	var dirs []string
+	var data []string
+	for _, c := range tr.Chunks {
+		dirs = append(dirs, c.Dir)
+		data = append(data, string(c.Data))
+	}
+	if !reflect.DeepEqual(dirs, []string{TX, RX}) || !reflect.DeepEqual(data, []string{"hello", "HELLO"}) {
+		t.Errorf("chunks = %v %q, want tx hello, rx HELLO", dirs, data)
+	}
+	if !tr.Truncated || !tr.RemoteClosed {
+		t.Errorf("Truncated = %v, RemoteClosed = %v; want both", tr.Truncated, tr.RemoteClosed)
+	}
+	if tr.Bytes() != 10 {
+		t.Errorf("Bytes = %d, want the 10-byte limit", tr.Bytes())
+	}
+
+	encoded, err := tr.Encode()
+	if err != nil {
+		t.Fatal(err)
+	}
+	decoded, err := Decode(encoded)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(decoded.Chunks, tr.Chunks) || !decoded.Started.Equal(tr.Started) || decoded.Truncated != tr.Truncated {
+		t.Errorf("round trip changed the transcript: %+v, want %+v", decoded, tr)
+	}
+	if _, err := Decode([]byte("not gzip")); err == nil {
+		t.Error("Decode accepted garbage")
+	}
+}
+
+// frames encodes BinkP frames back to back.
+func frames(t *testing.T, fs ...*binkp.Frame) []byte {
+	t.Helper()
+	c1, c2 := net.Pipe()
+	go func() {
+		for _, f := range fs {
+			if err := binkp.WriteFrame(c1, f); err != nil {
+				t.Error(err)
+			}
+		}
+		c1.Close()
+	}()
+	b, _ := io.ReadAll(c2)
+	return b
+}
+
+func eventNames(events []Event) []string {
+	var names []string
+	for _, e := range events {
+		names = append(names, e.Dir+" "+e.Name)
+	}
+	return names
+}
+
+func TestBinkPFrames(t *testing.T) {
+	tx := frames(t, binkp.CreateM_NUL("SYS", "Our System"), binkp.CreateM_ADR("2:5001/5001"), binkp.CreateM_PWD("secret"))
+	rx := frames(t, binkp.CreateM_NUL("OPT", "NR CRAM-MD5-0123"), binkp.CreateM_OK(), &binkp.Frame{Data: []byte("file data")})
+	// The node hangs up halfway through a frame.
+	rx = append(rx, 0x80, 0x10, binkp.M_ERR, 'x')
+
+	tr := &Transcript{Chunks: []Chunk{
+		{At: 0, Dir: TX, Data: tx[:5]},
+		{At: time.Millisecond, Dir: RX, Data: rx},
+		{At: 2 * time.Millisecond, Dir: TX, Data: tx[5:]},
+	}}
+	RedactBinkPPasswords(tr)
+	if all, _, _ := tr.Stream(TX); bytes.Contains(all, []byte("secret")) || !bytes.Contains(all, []byte("******")) {
+		t.Errorf("password not blanked out: %q", all)
+	}
+
+	events := BinkPFrames(tr)
+	want := []string{"tx M_NUL", "rx M_NUL", "rx M_OK", "rx DATA", "rx partial", "tx M_ADR", "tx M_PWD"}
+	if got := eventNames(events); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if events[0].Detail != "SYS Our System" || events[3].Detail != "9 bytes" || events[6].Detail != "******" {
+		t.Errorf("details = %q, %q, %q", events[0].Detail, events[3].Detail, events[6].Detail)
+	}
+	if !events[4].Bad || !strings.Contains(events[4].Detail, "announces 16 bytes, 2 arrived") {
+		t.Errorf("truncated frame = %+v", events[4])
+	}
+	// A frame is dated by the chunk it starts in: M_NUL began in the first
+	// tx chunk and ended in the second.
+	if events[0].At != 0 || events[5].At != 2*time.Millisecond {
+		t.Errorf("M_NUL at %v, M_ADR at %v; want 0 and 2ms", events[0].At, events[5].At)
+	}
+}
+
+func TestBinkPFramesStopAtCrypt(t *testing.T) {
+	tx := append(frames(t, binkp.CreateM_NUL("OPT", "CRYPT"), binkp.CreateM_PWD("secret")), 0x12, 0x34, 0x56)
+	rx := append(frames(t, binkp.CreateM_NUL("OPT", "CRYPT"), binkp.CreateM_OK()), 0xAB, 0xCD)
+	events := BinkPFrames(&Transcript{Chunks: []Chunk{{Dir: TX, Data: tx}, {Dir: RX, Data: rx}}})
+	want := []string{"tx M_NUL", "tx M_PWD", "tx CRYPT", "rx M_NUL", "rx M_OK", "rx CRYPT"}
+	if got := eventNames(events); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+}
+
+func TestEMSIPackets(t *testing.T) {
+	dat := "EMSI_DAT0010{EMSI}{2:5020/1}"
+	datPacket := "**" + dat + fmt.Sprintf("%04X", crc16([]byte(dat)))
+
+	tr := &Transcript{Chunks: []Chunk{
+		{At: 0, Dir: RX, Data: []byte("\r\nFrontDoor 2.33\r\n**EMSI_REQA77E\r")},
+		{At: time.Millisecond, Dir: TX, Data: []byte("**EMSI_INQC816\r")},
+		{At: 2 * time.Millisecond, Dir: RX, Data: []byte(datPacket[:10])},
+		{At: 3 * time.Millisecond, Dir: RX, Data: []byte(datPacket[10:] + "\r**EMSI_ACK0000\r**EMSI_DAT00ff{short")},
+	}}
+	events := EMSIPackets(tr)
+	want := []string{"rx text", "rx EMSI_REQ", "tx EMSI_INQ", "rx EMSI_DAT", "rx EMSI_ACK", "rx EMSI_DAT"}
+	if got := eventNames(events); !reflect.DeepEqual(got, want) {
+		t.Fatalf("events = %v, want %v", got, want)
+	}
+	if events[0].Detail != "FrontDoor 2.33" {
+		t.Errorf("banner = %q", events[0].Detail)
+	}
+	if events[3].Bad || events[3].Detail != "{EMSI}{2:5020/1}" || events[3].At != 2*time.Millisecond {
+		t.Errorf("EMSI_DAT = %+v", events[3])
+	}
+	if !events[4].Bad || !strings.Contains(events[4].Detail, "CRC 0000, expected") {
+		t.Errorf("corrupt EMSI_ACK = %+v", events[4])
+	}
+	if !events[5].Bad || !strings.Contains(events[5].Detail, "announces 255 bytes, 6 arrived") {
+		t.Errorf("cut-off EMSI_DAT = %+v", events[5])
+	}
+}
+
+func TestHexdump(t *testing.T) {
+	got := Hexdump([]byte("**EMSI_INQC816\r\n!"))
+	want := "00000000  2a 2a 45 4d 53 49 5f 49  4e 51 43 38 31 36 0d 0a  |**EMSI_INQC816..|\n" +
+		"00000010  21                                                |!|\n"
+	if got != want {
+		t.Errorf("Hexdump =\n%s\nwant\n%s", got, want)
+	}
+}
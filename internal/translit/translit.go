@@ -0,0 +1,53 @@
+// Package translit folds names written in any script nodelists turn up in
+// to plain lowercase ASCII, so that "Jürgen", "Juergen" and "Юрген" can be
+// compared, or "Sankt-Peterburg" looked up in a Latin gazetteer. It does the
+// part every caller shares; what counts as a match is left to them.
+package translit
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// cyrillicToLatin transliterates Russian, Ukrainian and Belarusian letters
+// the way sysops most often wrote their own names in ASCII nodelists, which
+// are also the spellings gazetteers give as Latin alternate names. Letters
+// with a diacritic (ё, й, ї, ў) decompose to one of these first.
+var cyrillicToLatin = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ж': "zh",
+	'з': "z", 'и': "i", 'к': "k", 'л': "l", 'м': "m", 'н': "n", 'о': "o",
+	'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u", 'ф': "f", 'х': "kh",
+	'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch", 'ъ': "", 'ы': "y", 'ь': "",
+	'э': "e", 'ю': "yu", 'я': "ya", 'і': "i", 'є': "ye", 'ґ': "g",
+}
+
+// latinSpecials are letters that Unicode decomposition does not reduce to a
+// plain ASCII letter.
+var latinSpecials = map[rune]string{
+	'ß': "ss", 'æ': "ae", 'œ': "oe", 'ø': "o", 'ł': "l", 'đ': "d", 'ð': "d",
+	'þ': "th", 'ı': "i",
+}
+
+// Words reduces s to lowercase ASCII words: underscores (how nodelists store
+// spaces) and punctuation separate words, Cyrillic is transliterated and
+// diacritics are dropped.
+func Words(s string) []string {
+	var b strings.Builder
+	for _, r := range norm.NFD.String(strings.ToLower(s)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// a combining mark split off by NFD: ü is u plus one
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)):
+			b.WriteRune(r)
+		case unicode.Is(unicode.Cyrillic, r):
+			b.WriteString(cyrillicToLatin[r])
+		case latinSpecials[r] != "":
+			b.WriteString(latinSpecials[r])
+		default:
+			b.WriteByte(' ')
+		}
+	}
+	return strings.Fields(b.String())
+}
@@ -0,0 +1,23 @@
+package translit
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestWords(t *testing.T) {
+	tests := map[string][]string{
+		"Sergey_Ivanov":        {"sergey", "ivanov"},
+		"Юрий Щукин":           {"yurii", "shchukin"},
+		"Jürgen Müller":        {"jurgen", "muller"},
+		"Søren Łukasz Weiß":    {"soren", "lukasz", "weiss"},
+		"Київ, Україна":        {"kiiv", "ukraina"},
+		"St.Petersburg 190000": {"st", "petersburg", "190000"},
+		" - ":                  {},
+	}
+	for in, want := range tests {
+		if got := Words(in); !reflect.DeepEqual(got, want) {
+			t.Errorf("Words(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -79,6 +79,18 @@ type VModemUnavailablePageConfig struct {
 	basePageConfig
 }
 
+// BinkPSCertificatesPageConfig configures the page listing binkps nodes whose
+// TLS certificate is self-signed or expired.
+type BinkPSCertificatesPageConfig struct {
+	basePageConfig
+}
+
+// BinkPOptionsPageConfig configures the page comparing the BinkP options
+// mailers advertise with how they held up when tried.
+type BinkPOptionsPageConfig struct {
+	basePageConfig
+}
+
 // OtherNetworksPageConfig configures the pages showing nodes that announce
 // AKAs in non-FidoNet networks (tqwnet, fsxnet, ...).
 type OtherNetworksPageConfig struct {
@@ -0,0 +1,62 @@
+package web
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+
+	"github.com/nodelistdb/internal/storage"
+)
+
+func TestBinkPOptionsRender(t *testing.T) {
+	s := &Server{templates: make(map[string]*template.Template), templatesFS: TemplatesFS}
+	if err := s.loadTemplates(); err != nil {
+		t.Fatalf("loading templates: %v", err)
+	}
+	tmpl, ok := s.templates["binkp_options_analytics"]
+	if !ok {
+		t.Fatal("binkp_options_analytics template not loaded")
+	}
+
+	options := func(cram, plz storage.BinkPOptionVerdicts) []storage.BinkPOptionVerdicts {
+		cram.Option, plz.Option = "CRAM-MD5", "PLZ"
+		return []storage.BinkPOptionVerdicts{cram, {Option: "CRYPT"}, plz, {Option: "GZ"}, {Option: "BZ2"}}
+	}
+	data := binkpOptionsAnalyticsData{
+		Title:      "BinkP Option Support",
+		ActivePage: "analytics",
+		Version:    "test",
+		Software: []storage.BinkPSoftwareOptions{
+			{Software: "Mystic", Version: "1.12A48", Nodes: 3, Failed: 3,
+				Options: options(storage.BinkPOptionVerdicts{Failed: 3}, storage.BinkPOptionVerdicts{})},
+			{Software: "binkd", Version: "1.1a-115", Nodes: 50,
+				Options: options(storage.BinkPOptionVerdicts{OK: 2, Untested: 48}, storage.BinkPOptionVerdicts{Untested: 6})},
+		},
+		Days: 30,
+		Config: BinkPOptionsPageConfig{basePageConfig: basePageConfig{
+			PageTitle:       "BinkP Option Support",
+			StatsHeading:    "Mailers Advertising Options",
+			EmptyStateTitle: "empty title",
+		}},
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"<th>CRAM-MD5</th>", "<th>BZ2</th>",
+		"Mystic", "3 failed",
+		"binkd", "2 ok", "48 untested", "6 untested",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render missing %q", want)
+		}
+	}
+	if strings.Contains(out, "empty title") {
+		t.Error("empty state rendered alongside results")
+	}
+}
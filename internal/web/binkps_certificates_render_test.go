@@ -0,0 +1,68 @@
+package web
+
+import (
+	"bytes"
+	"html/template"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/storage"
+)
+
+func TestBinkPSCertificatesRender(t *testing.T) {
+	s := &Server{templates: make(map[string]*template.Template), templatesFS: TemplatesFS}
+	if err := s.loadTemplates(); err != nil {
+		t.Fatalf("loading templates: %v", err)
+	}
+	tmpl, ok := s.templates["binkps_certificates_analytics"]
+	if !ok {
+		t.Fatal("binkps_certificates_analytics template not loaded")
+	}
+
+	now := time.Date(2026, 5, 1, 12, 0, 0, 0, time.UTC)
+	expired := now.AddDate(0, -2, 0)
+	valid := now.AddDate(1, 0, 0)
+
+	data := binkpsCertificatesAnalyticsData{
+		Title:      "binkps Certificate Problems",
+		ActivePage: "analytics",
+		Version:    "test",
+		Nodes: []storage.NodeTestResult{
+			{
+				Zone: 2, Net: 5020, Node: 1, Address: "2:5020/1", Hostname: "old.example.org",
+				TestTime: now, BinkPSTested: true, BinkPSSuccess: true,
+				BinkPSCertSubject: "CN=old.example.org", BinkPSCertIssuer: "CN=Some CA",
+				BinkPSCertNotAfter: &expired, BinkPSCertError: "x509: certificate has expired or is not yet valid",
+			},
+			{
+				Zone: 2, Net: 5020, Node: 2, Address: "2:5020/2", Hostname: "self.example.org",
+				TestTime: now, BinkPSTested: true, BinkPSSuccess: false, BinkPSError: "remote busy",
+				BinkPSCertSubject: "CN=self.example.org", BinkPSCertIssuer: "CN=self.example.org",
+				BinkPSCertNotAfter: &valid, BinkPSCertSelfSigned: true,
+			},
+		},
+		Now:    now,
+		Days:   30,
+		Limit:  1000,
+		Config: BinkPSCertificatesPageConfig{basePageConfig: basePageConfig{PageTitle: "binkps Certificate Problems", StatsHeading: "Certificate Problems"}},
+	}
+
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		t.Fatalf("Execute error: %v", err)
+	}
+	out := buf.String()
+
+	for _, want := range []string{
+		"2:5020/1", "CN=Some CA", "Expired", "certificate has expired",
+		"2:5020/2", "Self-signed", "Session: remote busy",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render missing %q", want)
+		}
+	}
+	if n := strings.Count(out, ">Expired<"); n != 1 {
+		t.Errorf("Expired badge rendered %d times, want 1 (only the past-dated certificate)", n)
+	}
+}
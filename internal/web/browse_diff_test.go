@@ -0,0 +1,119 @@
+package web
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/storage"
+)
+
+// browseDiffStub answers every date as held and records the comparison it
+// was asked for.
+type browseDiffStub struct {
+	stubStorage
+	diff     *storage.BrowseDiff
+	from, to time.Time
+	scope    storage.BrowseDiffScope
+}
+
+func (s *browseDiffStub) GetNearestAvailableDate(ctx context.Context, requested time.Time, domain string) (time.Time, error) {
+	return requested, nil
+}
+
+func (s *browseDiffStub) GetBrowseDiff(ctx context.Context, from, to time.Time, scope storage.BrowseDiffScope, domain string) (*storage.BrowseDiff, error) {
+	s.from, s.to, s.scope = from, to, scope
+	return s.diff, nil
+}
+
+func TestBrowseDiffHandler(t *testing.T) {
+	day := func(d int) time.Time { return time.Date(1997, 3, d, 0, 0, 0, 0, time.UTC) }
+	region := 50
+
+	tests := []struct {
+		name     string
+		target   string
+		diff     *storage.BrowseDiff
+		from, to time.Time
+		scope    storage.BrowseDiffScope
+		want     []string
+	}{
+		{
+			name:   "zones against the previous nodelist",
+			target: "/browse/diff",
+			diff: &storage.BrowseDiff{
+				Level:  storage.BrowseLevelZones,
+				Totals: storage.BrowseDiffCounts{Added: 4, Removed: 1, Modified: 2, Unchanged: 90},
+				Groups: []storage.BrowseDiffGroup{{Number: 2, Name: "Z2_Coordinator", FromNodes: 60, ToNodes: 63,
+					BrowseDiffCounts: storage.BrowseDiffCounts{Added: 4, Removed: 1, Modified: 2, Unchanged: 56}}},
+			},
+			from:  day(7),
+			to:    day(14),
+			scope: storage.BrowseDiffScope{Level: storage.BrowseLevelZones},
+			want:  []string{"4 added", "/browse/diff/zone/2?from=1997-03-07&amp;to=1997-03-14", "Z2 Coordinator"},
+		},
+		{
+			name:   "regions of a zone, dates given the wrong way round",
+			target: "/browse/diff/zone/2?from=1997-03-14&to=1997-03-01",
+			diff: &storage.BrowseDiff{
+				Level:  storage.BrowseLevelRegions,
+				Groups: []storage.BrowseDiffGroup{{Number: 0}, {Number: 50}},
+			},
+			from:  day(1),
+			to:    day(14),
+			scope: storage.BrowseDiffScope{Level: storage.BrowseLevelRegions, Zone: 2},
+			want:  []string{"No region", "/browse/diff/region/2/50?from=1997-03-01", "A net that moved region"},
+		},
+		{
+			name:   "nodes of a net",
+			target: "/browse/diff/net/2/5020?from=1997-03-01&to=1997-03-07",
+			diff: &storage.BrowseDiff{
+				Level: storage.BrowseLevelNodes,
+				Changes: []database.NodeChange{
+					{ChangeType: "added", NewNode: &database.Node{Zone: 2, Net: 5020, Node: 3, Region: &region, RawLine: ",3,Newcomer_BBS,Moscow"}},
+					{ChangeType: "modified", Changes: map[string]string{"name": "Old_Name → New_Name"},
+						OldNode: &database.Node{Zone: 2, Net: 5020, Node: 2}, NewNode: &database.Node{Zone: 2, Net: 5020, Node: 2, Region: &region}},
+				},
+			},
+			from:  day(1),
+			to:    day(7),
+			scope: storage.BrowseDiffScope{Level: storage.BrowseLevelNodes, Zone: 2, Net: 5020},
+			want:  []string{",3,Newcomer_BBS,Moscow", "Old_Name → New_Name", "/browse/diff/region/2/50?", "/node/2/5020/2"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ops := &browseDiffStub{
+				stubStorage: stubStorage{availableDates: []time.Time{day(14), day(7), day(1)}, latestDate: day(14)},
+				diff:        tt.diff,
+			}
+			s := newTestServer(t, ops)
+			rec := httptest.NewRecorder()
+			s.BrowseDiffHandler(rec, httptest.NewRequest("GET", tt.target, nil))
+
+			if rec.Code != 200 {
+				t.Fatalf("status = %d, want 200", rec.Code)
+			}
+			if !ops.from.Equal(tt.from) || !ops.to.Equal(tt.to) || ops.scope != tt.scope {
+				t.Errorf("compared %v..%v over %+v, want %v..%v over %+v", ops.from, ops.to, ops.scope, tt.from, tt.to, tt.scope)
+			}
+			body := rec.Body.String()
+			for _, want := range append(tt.want, "</html>") {
+				if !strings.Contains(body, want) {
+					t.Errorf("rendered page is missing %q", want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseBrowseDiffPathRejectsUnknownPages(t *testing.T) {
+	for _, path := range []string{"/browse/diff/zone", "/browse/diff/zone/two", "/browse/diff/net/2", "/browse/diff/point/2/5020/3"} {
+		if scope, err := parseBrowseDiffPath(path); err == nil {
+			t.Errorf("parseBrowseDiffPath(%q) = %+v, want an error", path, scope)
+		}
+	}
+}
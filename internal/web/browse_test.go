@@ -81,3 +81,35 @@ func TestBrowseTemplateRenders(t *testing.T) {
 		})
 	}
 }
+
+// TestBrowseLinksSegmentDownload checks the nets and nodes levels offer the
+// segment for the date being browsed.
+func TestBrowseLinksSegmentDownload(t *testing.T) {
+	s, err := New(nil, TemplatesFS, StaticFS)
+	if err != nil {
+		t.Fatalf("loading templates: %v", err)
+	}
+	for _, tc := range []struct {
+		data browseData
+		want string
+	}{
+		{
+			data: browseData{Level: "nets", Domain: "fidonet", Zone: 2, Region: 25, HasRegion: true, ActualDate: "2026-05-01",
+				Nets: []storage.BrowseNet{{Net: 250, NodeCount: 3}}},
+			want: `href="/api/nodelist/fidonet/2026-05-01/region/2/25.txt"`,
+		},
+		{
+			data: browseData{Level: "nodes", Domain: "fsxnet", Zone: 21, Net: 1, ActualDate: "2026-05-01",
+				Nodes: []database.Node{{Zone: 21, Net: 1, Node: 0, RawLine: "Host,1,fsxNet,NZ,Sysop,-Unpublished-,300,CM"}}},
+			want: `href="/api/nodelist/fsxnet/2026-05-01/net/21/1.txt"`,
+		},
+	} {
+		var buf bytes.Buffer
+		if err := s.templates["browse"].Execute(&buf, tc.data); err != nil {
+			t.Fatalf("execute browse template: %v", err)
+		}
+		if !strings.Contains(buf.String(), tc.want) {
+			t.Errorf("%s level missing segment link %s", tc.data.Level, tc.want)
+		}
+	}
+}
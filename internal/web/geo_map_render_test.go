@@ -0,0 +1,114 @@
+package web
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/storage"
+)
+
+// geoMapStub adds the node map to the stats stub, which already covers the
+// date lookups the page shares with the stats page.
+type geoMapStub struct {
+	stubStorage
+	nodeMap *storage.NodeMap
+	mapErr  error
+	mapDate time.Time
+}
+
+func (s *geoMapStub) GetNodeMap(ctx context.Context, date time.Time, domain string) (*storage.NodeMap, error) {
+	s.mapDate = date
+	return s.nodeMap, s.mapErr
+}
+
+func TestGeoMapHandlerRendersMap(t *testing.T) {
+	requested := time.Date(1995, 6, 1, 0, 0, 0, 0, time.UTC)
+	nearest := time.Date(1995, 6, 2, 0, 0, 0, 0, time.UTC)
+	ops := &geoMapStub{
+		stubStorage: stubStorage{availableDates: []time.Time{nearest}, nearestDate: nearest},
+		nodeMap: &storage.NodeMap{
+			Date: nearest,
+			Places: []storage.MapPlace{
+				{Place: "Moscow", CountryCode: "RU", Latitude: 55.7558, Longitude: 37.6173, Precision: "city", Nodes: 40},
+				{Place: "Texas", CountryCode: "US", Latitude: 31.5, Longitude: -99.3, Precision: "region", Nodes: 1},
+			},
+			Countries: []storage.MapCountry{
+				{CountryCode: "RU", Country: "Russia", Nodes: 40},
+				{CountryCode: "US", Country: "United States", Nodes: 1},
+			},
+			Located:   41,
+			Unlocated: 7,
+		},
+	}
+
+	s := newTestServer(t, ops)
+	rec := httptest.NewRecorder()
+	s.GeoMapHandler(rec, httptest.NewRequest("GET", "/analytics/geo-map?date=1995-06-01", nil))
+
+	if rec.Code != 200 {
+		t.Fatalf("status = %d, want 200", rec.Code)
+	}
+	if !ops.mapDate.Equal(nearest) {
+		t.Errorf("mapped %v for a request of %v, want the nearest nodelist %v", ops.mapDate, requested, nearest)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{
+		"<svg", "Moscow, RU: 40 nodes", "Texas, US: 1 node (location names no city)",
+		"geo-map-region", "Russia", "41 nodes placed", "1995-06-02", "</html>",
+	} {
+		if !strings.Contains(body, want) {
+			t.Errorf("rendered map page is missing %q", want)
+		}
+	}
+}
+
+func TestGeoMapHandlerEmptyAndFailing(t *testing.T) {
+	date := time.Date(2026, 7, 20, 0, 0, 0, 0, time.UTC)
+
+	cases := []struct {
+		name string
+		ops  *geoMapStub
+		want string
+	}{
+		{
+			name: "nothing geocoded",
+			ops: &geoMapStub{
+				stubStorage: stubStorage{latestDate: date},
+				nodeMap:     &storage.NodeMap{Date: date, Unlocated: 12},
+			},
+			want: "-geocode-locations",
+		},
+		{
+			name: "map query fails",
+			ops: &geoMapStub{
+				stubStorage: stubStorage{latestDate: date},
+				mapErr:      errors.New("clickhouse unavailable"),
+			},
+			want: "Failed to load the node map",
+		},
+		{
+			name: "date lookup fails",
+			ops:  &geoMapStub{stubStorage: stubStorage{latestDateErr: errors.New("clickhouse unavailable")}},
+			want: "failed to find latest nodelist date",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t, tc.ops)
+			rec := httptest.NewRecorder()
+			s.GeoMapHandler(rec, httptest.NewRequest("GET", "/analytics/geo-map", nil))
+
+			body := rec.Body.String()
+			if !strings.Contains(body, tc.want) {
+				t.Errorf("body does not carry %q", tc.want)
+			}
+			if !strings.Contains(body, "</html>") {
+				t.Errorf("page was truncated (no </html>); rendered %d bytes", len(body))
+			}
+		})
+	}
+}
@@ -441,6 +441,67 @@ func (s *Server) IfcicoSoftwareHandler(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// binkpOptionsAnalyticsData holds template data for the BinkP options page.
+type binkpOptionsAnalyticsData struct {
+	Title         string
+	ActivePage    string
+	Version       string
+	Software      []storage.BinkPSoftwareOptions
+	Days          int
+	Error         error
+	Config        BinkPOptionsPageConfig
+	ProcessedInfo []template.HTML
+}
+
+// BinkPOptionsHandler shows, per BinkP mailer, the options its nodes advertise
+// in OPT against the verdicts the testdaemon reached when it tried them.
+// Mailers with failed verdicts come first.
+func (s *Server) BinkPOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	config := BinkPOptionsPageConfig{
+		basePageConfig: basePageConfig{
+			PageTitle:    "BinkP Option Support",
+			PageSubtitle: template.HTML(`<p class="subtitle">Which mailers advertise BinkP options they cannot honour</p>`),
+			StatsHeading: "Mailers Advertising Options",
+			InfoText: []string{
+				`<strong>Note:</strong> Counts are over each node's latest successful BinkP test in the last %d days. <em>OK</em> means the option was used and worked; <em>failed</em> means the CRAM challenge was malformed, GZ was offered without EXTCMD, or the transfer broke off while the option was in use; <em>untested</em> means it was advertised but the session could not try it.`,
+				`<strong>Why most verdicts are untested:</strong> CRYPT, PLZ and GZ can only be tried in a password-protected session with a probe transfer, which the testdaemon runs only for nodes whose sysop has agreed a session password. BZ2 is never tried: there is no bzip2 compressor to send with.`,
+			},
+			EmptyStateTitle: "No advertised BinkP options found for the selected period.",
+			EmptyStateDesc:  "No node tested during this period advertised CRAM-MD5, CRYPT, PLZ, GZ or BZ2, or none was tested since option verdicts were first recorded.",
+		},
+	}
+
+	params := parseAnalyticsParams(r)
+
+	support, err := s.storage.GetBinkPOptionSupport(r.Context(), params.Days, params.Domain)
+	var software []storage.BinkPSoftwareOptions
+	var displayError error
+	if err != nil {
+		var handled bool
+		if displayError, handled = storageFailure("BinkP Options Analytics", "Failed to fetch analytics data. Please try again later", err); handled {
+			return
+		}
+	} else {
+		software = support.Software
+		if params.ValidationError != "" {
+			displayError = fmt.Errorf("%s", params.ValidationError)
+		}
+	}
+
+	data := binkpOptionsAnalyticsData{
+		Title:         config.PageTitle,
+		ActivePage:    "analytics",
+		Version:       version.GetVersionInfo(),
+		Software:      software,
+		Days:          params.Days,
+		Error:         displayError,
+		Config:        config,
+		ProcessedInfo: config.processInfoText(params.Days),
+	}
+
+	s.renderStatus(w, "binkp_options_analytics", data, statusFor(displayError))
+}
+
 // TelnetAnalyticsHandler shows Telnet enabled nodes analytics
 func (s *Server) TelnetAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
 	config := ProtocolPageConfig{
@@ -548,6 +609,70 @@ func (s *Server) VModemUnavailableAnalyticsHandler(w http.ResponseWriter, r *htt
 	s.renderStatus(w, "vmodem_unavailable_analytics", data, statusFor(displayError))
 }
 
+// binkpsCertificatesAnalyticsData holds template data for the binkps
+// certificate analytics page.
+type binkpsCertificatesAnalyticsData struct {
+	Title            string
+	ActivePage       string
+	Version          string
+	Nodes            []storage.NodeTestResult
+	Now              time.Time
+	Days             int
+	Limit            int
+	IncludeZeroNodes bool
+	Error            error
+	Config           BinkPSCertificatesPageConfig
+	ProcessedInfo    []template.HTML
+}
+
+// BinkPSCertificatesAnalyticsHandler lists nodes running BinkP over TLS whose
+// certificate is self-signed or has expired.
+func (s *Server) BinkPSCertificatesAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
+	config := BinkPSCertificatesPageConfig{
+		basePageConfig: basePageConfig{
+			PageTitle:    "binkps Certificate Problems",
+			PageSubtitle: template.HTML(`<p class="subtitle">Nodes running BinkP over TLS with a self-signed or expired certificate</p>`),
+			StatsHeading: "Certificate Problems",
+			InfoText: []string{
+				`<strong>Note:</strong> Nodes announcing IBNS are tested with BinkP over TLS. The test accepts any certificate so that it can record it; this report lists the nodes whose latest certificate, seen over the last %d days, was self-signed or is now past its expiry date.`,
+				`A self-signed certificate still encrypts the session, but nothing vouches for it, so a caller cannot tell it apart from an impostor's.`,
+			},
+			EmptyStateTitle: "No certificate problems found for the selected period.",
+			EmptyStateDesc:  "Every binkps node tested during this period presented an in-date certificate issued by someone else.",
+		},
+	}
+
+	params := parseAnalyticsParams(r)
+
+	nodes, err := s.storage.GetBinkPSCertificateIssues(r.Context(), params.Limit, params.Days, params.IncludeZeroNodes, params.Domain)
+	var displayError error
+	if err != nil {
+		var handled bool
+		if displayError, handled = storageFailure("binkps Certificate Analytics", "Failed to fetch analytics data. Please try again later", err); handled {
+			return
+		}
+		nodes = []storage.NodeTestResult{}
+	} else if params.ValidationError != "" {
+		displayError = fmt.Errorf("%s", params.ValidationError)
+	}
+
+	data := binkpsCertificatesAnalyticsData{
+		Title:            config.PageTitle,
+		ActivePage:       "analytics",
+		Version:          version.GetVersionInfo(),
+		Nodes:            nodes,
+		Now:              time.Now(),
+		Days:             params.Days,
+		Limit:            params.Limit,
+		IncludeZeroNodes: params.IncludeZeroNodes,
+		Error:            displayError,
+		Config:           config,
+		ProcessedInfo:    config.processInfoText(params.Days),
+	}
+
+	s.renderStatus(w, "binkps_certificates_analytics", data, statusFor(displayError))
+}
+
 // FTPAnalyticsHandler shows FTP enabled nodes analytics
 func (s *Server) FTPAnalyticsHandler(w http.ResponseWriter, r *http.Request) {
 	config := ProtocolPageConfig{
@@ -591,7 +716,7 @@ func (s *Server) AKAMismatchAnalyticsHandler(w http.ResponseWriter, r *http.Requ
 			PageSubtitle: template.HTML(`<p class="subtitle">Nodes where the announced AKA address doesn't match the expected nodelist address</p>`),
 			StatsHeading: "AKA Mismatch",
 			InfoText: []string{
-				`<strong>What is an AKA mismatch?</strong> During BinkP or IFCICO handshakes, nodes announce their addresses (AKAs). An AKA mismatch occurs when the expected nodelist address (zone:net/node) is not found in the list of addresses the node announces.`,
+				`<strong>What is an AKA mismatch?</strong> During BinkP or IFCICO handshakes, and EMSI handshakes with mailers behind a telnet port, nodes announce their addresses (AKAs). An AKA mismatch occurs when the expected nodelist address (zone:net/node) is not found in the list of addresses the node announces.`,
 				`<strong>Common causes:</strong> Misconfigured mailer software, outdated address lists, node address changes, or AKA consolidation where a node responds for multiple addresses.`,
 				`<strong>Note:</strong> This report shows nodes that were operational (responded successfully) but announced different addresses than expected over the last %d days.`,
 			},
@@ -1383,6 +1508,13 @@ func (s *Server) GeoHostingAnalyticsHandler(w http.ResponseWriter, r *http.Reque
 		}
 	}
 
+	// The path-probe breakdown is a secondary table: without it the page
+	// is still complete, so a failure only drops the section.
+	unreachable, err := s.storage.GetUnreachableByASN(r.Context(), days, requestDomain(r))
+	if err != nil {
+		logging.Errorf("Error getting unreachable nodes by ASN: %v", err)
+	}
+
 	// Build template data
 	data := struct {
 		Title        string
@@ -1390,6 +1522,7 @@ func (s *Server) GeoHostingAnalyticsHandler(w http.ResponseWriter, r *http.Reque
 		Version      string
 		Days         int
 		Distribution *storage.GeoHostingDistribution
+		Unreachable  []storage.UnreachableASNStats
 		Updated      string
 		Error        error
 	}{
@@ -1398,6 +1531,7 @@ func (s *Server) GeoHostingAnalyticsHandler(w http.ResponseWriter, r *http.Reque
 		Version:      version.GetVersionInfo(),
 		Days:         days,
 		Distribution: dist,
+		Unreachable:  unreachable,
 		Error:        displayError,
 	}
 
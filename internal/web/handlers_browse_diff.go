@@ -0,0 +1,192 @@
+package web
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/storage"
+	"github.com/nodelistdb/internal/version"
+)
+
+// browseDiffData is the payload of browse_diff.html, the compare mode of the
+// hierarchy browser: every level of /browse, but counting what changed
+// between two nodelists instead of listing one.
+type browseDiffData struct {
+	Title          string
+	ActivePage     string
+	Level          string // storage.BrowseLevel*
+	Version        string
+	Error          string
+	Domain         string
+	AvailableDates []time.Time
+
+	RequestedFrom string // raw ?from= and ?to=, for the date inputs
+	RequestedTo   string
+	From          string // resolved nodelist dates (YYYY-MM-DD)
+	To            string
+	DatesAdjusted bool   // a requested date was snapped to a nearby one
+	DiffQuery     string // "?from=...&to=...[&domain=...]" carried on drill-down links
+	BrowseQuery   string // the same for the plain browser, at the later date
+
+	// Breadcrumb context, as in browseData.
+	Zone      int
+	Region    int
+	Net       int
+	HasRegion bool
+
+	Diff *storage.BrowseDiff
+}
+
+// resolveDiffDates reads ?from= and ?to= and returns the nodelist dates to
+// compare, earlier first. to defaults to the latest nodelist and from to the
+// one before to; a date the network has no nodelist for snaps to the
+// nearest, and a malformed one falls back to the default.
+func (s *Server) resolveDiffDates(r *http.Request, domain string, dates []time.Time) (from, to time.Time, adjusted bool, err error) {
+	q := r.URL.Query()
+	if raw := q.Get("to"); raw != "" {
+		if parsed, perr := time.Parse("2006-01-02", raw); perr == nil {
+			if to, err = s.storage.GetNearestAvailableDate(r.Context(), parsed, domain); err != nil {
+				return from, to, false, fmt.Errorf("failed to find available date: %w", err)
+			}
+			adjusted = !to.Equal(parsed)
+		} else {
+			adjusted = true
+		}
+	}
+	if to.IsZero() {
+		if to, err = s.storage.GetLatestStatsDate(r.Context(), domain); err != nil {
+			return from, to, adjusted, fmt.Errorf("failed to find latest nodelist date: %w", err)
+		}
+	}
+
+	if raw := q.Get("from"); raw != "" {
+		if parsed, perr := time.Parse("2006-01-02", raw); perr == nil {
+			if from, err = s.storage.GetNearestAvailableDate(r.Context(), parsed, domain); err != nil {
+				return from, to, adjusted, fmt.Errorf("failed to find available date: %w", err)
+			}
+			adjusted = adjusted || !from.Equal(parsed)
+		} else {
+			adjusted = true
+		}
+	}
+	if from.IsZero() {
+		from = to
+		if prev, ok := storage.PreviousNodelistDate(dates, to); ok {
+			from = prev
+		}
+	}
+	if from.After(to) {
+		from, to = to, from
+	}
+	return from, to, adjusted, nil
+}
+
+// parseBrowseDiffPath maps a /browse/diff path onto the scope it compares,
+// mirroring the plain browser's paths:
+//
+//	/browse/diff                        every zone
+//	/browse/diff/zone/{zone}            the regions of a zone
+//	/browse/diff/region/{zone}/{region} the nets of a region (0 = no region)
+//	/browse/diff/net/{zone}/{net}       the nodes of a net
+func parseBrowseDiffPath(path string) (storage.BrowseDiffScope, error) {
+	parts := pathSegments(path, "/browse/diff")
+	if len(parts) == 0 {
+		return storage.BrowseDiffScope{Level: storage.BrowseLevelZones}, nil
+	}
+	want := map[string]int{"zone": 1, "region": 2, "net": 2}[parts[0]]
+	if want == 0 || len(parts) != want+1 {
+		return storage.BrowseDiffScope{}, fmt.Errorf("unknown compare page %s", path)
+	}
+	numbers := make([]int, want)
+	for i := range numbers {
+		n, err := strconv.Atoi(parts[i+1])
+		if err != nil {
+			return storage.BrowseDiffScope{}, fmt.Errorf("invalid %s address in %s", parts[0], path)
+		}
+		numbers[i] = n
+	}
+	switch parts[0] {
+	case "zone":
+		return storage.BrowseDiffScope{Level: storage.BrowseLevelRegions, Zone: numbers[0]}, nil
+	case "region":
+		return storage.BrowseDiffScope{Level: storage.BrowseLevelNets, Zone: numbers[0], Region: numbers[1]}, nil
+	}
+	return storage.BrowseDiffScope{Level: storage.BrowseLevelNodes, Zone: numbers[0], Net: numbers[1]}, nil
+}
+
+// BrowseDiffHandler renders every level of the browser's compare mode.
+// Path: /browse/diff[/zone/{zone}|/region/{zone}/{region}|/net/{zone}/{net}]
+func (s *Server) BrowseDiffHandler(w http.ResponseWriter, r *http.Request) {
+	data := &browseDiffData{
+		Title:      "Compare Nodelists",
+		ActivePage: "browse",
+		Version:    version.GetVersionInfo(),
+		Domain:     requestDomain(r),
+	}
+	data.RequestedFrom = r.URL.Query().Get("from")
+	data.RequestedTo = r.URL.Query().Get("to")
+	data.AvailableDates, _ = s.storage.GetAvailableDates(r.Context(), data.Domain)
+
+	scope, err := parseBrowseDiffPath(r.URL.Path)
+	if err != nil {
+		data.Error = err.Error()
+		s.renderStatus(w, "browse_diff", data, http.StatusOK)
+		return
+	}
+	data.Level = scope.Level
+	data.Zone, data.Region, data.Net = scope.Zone, scope.Region, scope.Net
+	data.HasRegion = scope.Level == storage.BrowseLevelNets && scope.Region != 0
+
+	from, to, adjusted, err := s.resolveDiffDates(r, data.Domain, data.AvailableDates)
+	if err != nil {
+		if clientGone("Browse diff date resolution", err) {
+			return
+		}
+		data.Error = "Failed to determine nodelist dates: " + err.Error()
+		s.renderStatus(w, "browse_diff", data, http.StatusOK)
+		return
+	}
+	data.From = from.Format("2006-01-02")
+	data.To = to.Format("2006-01-02")
+	data.DatesAdjusted = adjusted
+
+	params := url.Values{"from": {data.From}, "to": {data.To}}
+	browse := url.Values{"date": {data.To}}
+	if data.Domain != database.DefaultDomain {
+		params.Set("domain", data.Domain)
+		browse.Set("domain", data.Domain)
+	}
+	data.DiffQuery = "?" + params.Encode()
+	data.BrowseQuery = "?" + browse.Encode()
+
+	diff, err := s.storage.GetBrowseDiff(r.Context(), from, to, scope, data.Domain)
+	if err != nil {
+		display, handled := storageFailure("Browse diff", "Failed to compare nodelists: "+err.Error(), err)
+		if handled {
+			return
+		}
+		data.Error = display.Error()
+		s.renderStatus(w, "browse_diff", data, statusFor(display))
+		return
+	}
+	data.Diff = diff
+
+	// The net level derives its region from the entries, as BrowseNetHandler
+	// does, so the breadcrumb can lead back up.
+	for _, c := range diff.Changes {
+		n := c.NewNode
+		if n == nil {
+			n = c.OldNode
+		}
+		if n.Region != nil && *n.Region != 0 {
+			data.Region = *n.Region
+			data.HasRegion = true
+		}
+	}
+
+	s.renderStatus(w, "browse_diff", data, http.StatusOK)
+}
@@ -0,0 +1,172 @@
+package web
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/nodelistdb/internal/geocode"
+	"github.com/nodelistdb/internal/logging"
+	"github.com/nodelistdb/internal/storage"
+	"github.com/nodelistdb/internal/version"
+)
+
+// The node map is drawn server-side as an equirectangular SVG: longitude and
+// latitude map linearly onto x and y, which is all a dot map of a few
+// hundred places needs and keeps the page free of a mapping library and of
+// tile requests to a third party.
+const (
+	geoMapWidth  = 720
+	geoMapHeight = 360
+
+	geoMapMinRadius = 2.0
+	geoMapMaxRadius = 20.0
+)
+
+// geoMapPoint is one circle of the map.
+type geoMapPoint struct {
+	X, Y, R float64
+	Title   string
+	Class   string
+}
+
+// geoMapView is the drawing geo_map.html renders.
+type geoMapView struct {
+	Width, Height int
+	Meridians     []float64 // x of every 30th meridian
+	Parallels     []float64 // y of every 30th parallel
+	Backdrop      []geoMapPoint
+	Bubbles       []geoMapPoint // largest first, so smaller ones draw on top
+}
+
+// geoMapPageData is the whole payload geo_map.html reads, named for the same
+// reason as statsPageData: every exit path must carry every field.
+type geoMapPageData struct {
+	Title          string
+	ActivePage     string
+	Version        string
+	Domain         string
+	Error          error
+	AvailableDates []time.Time
+	SelectedDate   string
+	ActualDate     string
+	DateAdjusted   bool
+	Map            *storage.NodeMap
+	View           geoMapView
+}
+
+// GeoMapHandler maps one nodelist's nodes by their geocoded Location field.
+// Unlike the hosting page it needs no test results, so it works for any
+// nodelist in the database, back to the earliest.
+func (s *Server) GeoMapHandler(w http.ResponseWriter, r *http.Request) {
+	domain := requestDomain(r)
+	data := geoMapPageData{
+		Title:          "Node Map",
+		ActivePage:     "analytics",
+		Version:        version.GetVersionInfo(),
+		Domain:         domain,
+		AvailableDates: []time.Time{},
+	}
+
+	// The date picker is a convenience; without it the page still maps the
+	// requested date.
+	if dates, err := s.storage.GetAvailableDates(r.Context(), domain); err == nil {
+		data.AvailableDates = dates
+	}
+
+	actualDate, rawDate, adjusted, err := s.resolveBrowseDate(r, domain)
+	data.SelectedDate = rawDate
+	if err != nil {
+		if clientGone("Geo map: date resolution", err) {
+			return
+		}
+		data.Error = err
+		s.renderStatus(w, "geo_map", data, statusFor(data.Error))
+		return
+	}
+	data.ActualDate = actualDate.Format("2006-01-02")
+	data.DateAdjusted = adjusted
+
+	nodeMap, err := s.storage.GetNodeMap(r.Context(), actualDate, domain)
+	if err != nil {
+		display, handled := storageFailure("Geo map: node map", "Failed to load the node map: "+err.Error(), err)
+		if handled {
+			return
+		}
+		data.Error = display
+		s.renderStatus(w, "geo_map", data, statusFor(data.Error))
+		return
+	}
+	data.Map = nodeMap
+	data.View = newGeoMapView(nodeMap)
+
+	s.renderStatus(w, "geo_map", data, http.StatusOK)
+}
+
+// geoMapProject maps a coordinate onto the SVG canvas.
+func geoMapProject(lat, lon float64) (x, y float64) {
+	x = (lon + 180) / 360 * geoMapWidth
+	y = (90 - lat) / 180 * geoMapHeight
+	return math.Round(x*10) / 10, math.Round(y*10) / 10
+}
+
+// geoMapBackdrop is every gazetteer place as a faint dot, one per canvas
+// pixel, standing in for coastlines.
+var geoMapBackdrop = sync.OnceValue(func() []geoMapPoint {
+	g, err := geocode.Default()
+	if err != nil {
+		logging.Errorf("Geo map: gazetteer unavailable, drawing no backdrop: %v", err)
+		return nil
+	}
+	seen := make(map[[2]int]bool)
+	var dots []geoMapPoint
+	for _, p := range g.Places() {
+		x, y := geoMapProject(p.Lat, p.Lon)
+		px := [2]int{int(x), int(y)}
+		if seen[px] {
+			continue
+		}
+		seen[px] = true
+		dots = append(dots, geoMapPoint{X: x, Y: y, R: 0.8, Class: "geo-map-land"})
+	}
+	return dots
+})
+
+func newGeoMapView(m *storage.NodeMap) geoMapView {
+	v := geoMapView{Width: geoMapWidth, Height: geoMapHeight, Backdrop: geoMapBackdrop()}
+	for lon := -150.0; lon < 180; lon += 30 {
+		x, _ := geoMapProject(0, lon)
+		v.Meridians = append(v.Meridians, x)
+	}
+	for lat := -60.0; lat <= 60; lat += 30 {
+		_, y := geoMapProject(lat, 0)
+		v.Parallels = append(v.Parallels, y)
+	}
+
+	if m == nil || len(m.Places) == 0 {
+		return v
+	}
+	largest := 0
+	for _, p := range m.Places {
+		largest = max(largest, p.Nodes)
+	}
+	for _, p := range m.Places {
+		x, y := geoMapProject(p.Latitude, p.Longitude)
+		r := geoMapMinRadius + (geoMapMaxRadius-geoMapMinRadius)*math.Sqrt(float64(p.Nodes)/float64(largest))
+		title := fmt.Sprintf("%s, %s: %d nodes", p.Place, p.CountryCode, p.Nodes)
+		if p.Nodes == 1 {
+			title = fmt.Sprintf("%s, %s: 1 node", p.Place, p.CountryCode)
+		}
+		if p.Precision != string(geocode.PrecisionCity) {
+			title += " (location names no city)"
+		}
+		v.Bubbles = append(v.Bubbles, geoMapPoint{
+			X: x, Y: y, R: math.Round(r*10) / 10,
+			Title: title,
+			Class: "geo-map-" + p.Precision,
+		})
+	}
+	return v
+}
@@ -125,6 +125,19 @@ func (s *Server) getFilteredReachabilityNodes(ctx context.Context, statusFilter,
 			return nil, err
 		}
 		allNodes = nodes
+	case "down", "intermittent":
+		// Both split the failed nodes: a failure outside the node's learned
+		// uptime window is intermittent by schedule, any other is down
+		nodes, err := s.storage.SearchNodesByReachability(ctx, false, fetchLimit, periodFilter, domain)
+		if err != nil {
+			return nil, err
+		}
+		wantIntermittent := statusFilter == "intermittent"
+		for _, node := range nodes {
+			if node.IntermittentBySchedule == wantIntermittent {
+				allNodes = append(allNodes, node)
+			}
+		}
 	default: // "all" or empty
 		// Get both operational and failed nodes - fetch more to ensure we get both types
 		// When status=all, we want to show a mix of both operational and failed
@@ -319,6 +332,9 @@ type testDetailPage struct {
 	template string
 	subject  string // for log lines and the not-found message
 	fetch    func(zone, net, node int, testTime, domain string) (result any, found bool, err error)
+	// transcripts shows the wire transcripts recorded in the test cycle,
+	// and what each location saw when it was tested from several.
+	transcripts bool
 }
 
 // TestResultDetailHandler shows detailed information about a specific test result
@@ -331,6 +347,7 @@ func (s *Server) TestResultDetailHandler(w http.ResponseWriter, r *http.Request)
 			result, err := s.storage.GetDetailedTestResult(r.Context(), zone, net, node, testTime, domain)
 			return result, result != nil, err
 		},
+		transcripts: true,
 	})
 }
 
@@ -375,12 +392,21 @@ func (s *Server) renderTestDetail(w http.ResponseWriter, r *http.Request, page t
 		nodeInfo = &nodeHistory[len(nodeHistory)-1]
 	}
 
+	var transcripts []transcriptView
+	var vantages []storage.VantageResult
+	if page.transcripts {
+		transcripts = s.testTranscripts(r.Context(), zone, net, node, testTime, domain)
+		vantages = s.vantageResults(r.Context(), zone, net, node, testTime, domain)
+	}
+
 	s.render(w, page.template, map[string]interface{}{
-		"Title":      page.title,
-		"Version":    version.GetVersionInfo(),
-		"ActivePage": "reachability",
-		"TestResult": result,
-		"NodeInfo":   nodeInfo,
-		"Address":    fmt.Sprintf("%d:%d/%d", zone, net, node),
+		"Title":       page.title,
+		"Version":     version.GetVersionInfo(),
+		"ActivePage":  "reachability",
+		"TestResult":  result,
+		"NodeInfo":    nodeInfo,
+		"Address":     fmt.Sprintf("%d:%d/%d", zone, net, node),
+		"Transcripts": transcripts,
+		"Vantages":    vantages,
 	})
 }
@@ -19,6 +19,7 @@ func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
 	var count int
 	var searchErr error
 	var sysopName string
+	var fuzzySysop bool
 	isRootPage := r.URL.Path == "/"
 
 	// Only perform search on POST
@@ -46,8 +47,15 @@ func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
 
 			// Check if sysop_name field is filled
 			sysopName = r.FormValue("sysop_name")
+			fuzzySysop = r.FormValue("fuzzy_sysop") != ""
 
-			if sysopName != "" {
+			if sysopName != "" && fuzzySysop {
+				// Other spellings of the name too, best matches first
+				if searchErr = storage.CheckFuzzySysopName(sysopName); searchErr == nil {
+					nodes, searchErr = s.storage.SearchNodesBySysopFuzzy(r.Context(), sysopName, 100, requestDomain(r))
+					count = len(nodes)
+				}
+			} else if sysopName != "" {
 				// Perform sysop search, scoped to the selected network
 				nodes, searchErr = s.storage.SearchNodesBySysop(r.Context(), sysopName, 100, requestDomain(r))
 				count = len(nodes)
@@ -90,6 +98,7 @@ func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		Count      int
 		Error      error
 		SysopName  string
+		FuzzySysop bool
 		IsRootPage bool
 		Version    string
 	}{
@@ -101,6 +110,7 @@ func (s *Server) SearchHandler(w http.ResponseWriter, r *http.Request) {
 		Count:      count,
 		Error:      searchErr,
 		SysopName:  sysopName,
+		FuzzySysop: fuzzySysop,
 		IsRootPage: isRootPage,
 		Version:    version.GetVersionInfo(),
 	}
@@ -194,6 +204,12 @@ func (s *Server) NodeHistoryHandler(w http.ResponseWriter, r *http.Request) {
 	// Pointlist snapshot under this boss (empty for the vast majority of nodes)
 	points, _ := s.storage.GetPointsByBoss(r.Context(), resolvedDomain, zone, net, node, nil)
 
+	// The address's occupants, when it has been reassigned
+	tenancies, _ := s.storage.GetNodeTenancies(r.Context(), zone, net, node, resolvedDomain)
+
+	// The persons behind the sysop names (empty until parser -resolve-sysops has run)
+	sysops, _ := s.storage.GetNodeSysopIdentities(r.Context(), zone, net, node, resolvedDomain)
+
 	data := struct {
 		Title            string
 		Address          string
@@ -202,6 +218,8 @@ func (s *Server) NodeHistoryHandler(w http.ResponseWriter, r *http.Request) {
 		History          []database.Node
 		Changes          []database.NodeChange
 		Points           []database.Point
+		Tenancies        []storage.NodeTenancy
+		Sysops           []storage.SysopIdentityRef
 		FirstDate        time.Time
 		LastDate         time.Time
 		CurrentlyActive  bool
@@ -216,6 +234,8 @@ func (s *Server) NodeHistoryHandler(w http.ResponseWriter, r *http.Request) {
 		History:          history,
 		Changes:          changes,
 		Points:           points,
+		Tenancies:        tenancies,
+		Sysops:           sysops,
 		FirstDate:        activityInfo.FirstDate,
 		LastDate:         activityInfo.LastDate,
 		CurrentlyActive:  activityInfo.CurrentlyActive,
@@ -0,0 +1,54 @@
+package web
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/nodelistdb/internal/storage"
+	"github.com/nodelistdb/internal/version"
+)
+
+// sysopIDPattern matches the IDs parser -resolve-sysops mints.
+var sysopIDPattern = regexp.MustCompile(`^[0-9a-f]{12}$`)
+
+// SysopHandler shows one person's FTN career: every node and point they ran,
+// in every network, under every spelling of their name.
+func (s *Server) SysopHandler(w http.ResponseWriter, r *http.Request) {
+	id := strings.Trim(strings.TrimPrefix(r.URL.Path, "/sysop/"), "/")
+	if !sysopIDPattern.MatchString(id) {
+		http.Error(w, "Invalid sysop ID", http.StatusBadRequest)
+		return
+	}
+
+	identity, err := s.storage.GetSysopIdentity(r.Context(), id)
+	if err != nil {
+		httpStorageError(w, "Error retrieving sysop", "Error retrieving sysop", err)
+		return
+	}
+	if identity == nil {
+		http.Error(w, "Sysop not found", http.StatusNotFound)
+		return
+	}
+
+	// The ID was retired when its person was merged with another (or split);
+	// send old links to the page that took it over.
+	if identity.ID != id {
+		http.Redirect(w, r, "/sysop/"+identity.ID, http.StatusMovedPermanently)
+		return
+	}
+
+	data := struct {
+		Title      string
+		Sysop      *storage.SysopIdentity
+		Version    string
+		ActivePage string
+	}{
+		Title:      "Sysop",
+		Sysop:      identity,
+		Version:    version.GetVersionInfo(),
+		ActivePage: "",
+	}
+
+	s.render(w, "sysop", data)
+}
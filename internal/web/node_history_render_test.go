@@ -10,12 +10,20 @@ import (
 
 	"github.com/nodelistdb/internal/database"
 	"github.com/nodelistdb/internal/flags"
+	"github.com/nodelistdb/internal/storage"
 )
 
 // renderNodeHistory loads the real embedded templates and renders the node page
 // for a node whose first nodelist entry carries the given internet config.
 func renderNodeHistory(t *testing.T, internetConfig string) string {
 	t.Helper()
+	return renderNodeHistoryWithTenancies(t, internetConfig, nil)
+}
+
+// renderNodeHistoryWithTenancies renders the same page for an address with the
+// given occupants.
+func renderNodeHistoryWithTenancies(t *testing.T, internetConfig string, tenancies []storage.NodeTenancy) string {
+	t.Helper()
 
 	s := &Server{templates: make(map[string]*template.Template), templatesFS: TemplatesFS}
 	if err := s.loadTemplates(); err != nil {
@@ -49,6 +57,8 @@ func renderNodeHistory(t *testing.T, internetConfig string) string {
 		History          []database.Node
 		Changes          []database.NodeChange
 		Points           []database.Point
+		Tenancies        []storage.NodeTenancy
+		Sysops           []storage.SysopIdentityRef
 		FirstDate        time.Time
 		LastDate         time.Time
 		CurrentlyActive  bool
@@ -61,6 +71,7 @@ func renderNodeHistory(t *testing.T, internetConfig string) string {
 		Domain:           "fidonet",
 		History:          []database.Node{node},
 		Changes:          []database.NodeChange{{Date: date, ChangeType: "added", NewNode: &node}},
+		Tenancies:        tenancies,
 		FirstDate:        date,
 		LastDate:         date,
 		CurrentlyActive:  true,
@@ -133,3 +144,31 @@ func TestNodeHistoryHidesAddressesWhenAbsent(t *testing.T) {
 		t.Error("Internet Addresses section should be hidden when the node has no INA/IEM")
 	}
 }
+
+// A reassigned address lists its occupants apart; one that never changed
+// hands shows no occupants section at all.
+func TestNodeHistoryRendersTenancies(t *testing.T) {
+	year := func(y int) time.Time { return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC) }
+	out := renderNodeHistoryWithTenancies(t, `{}`, []storage.NodeTenancy{
+		{Tenancy: 1, SysopName: "Boris_Paleev", SystemName: "Minas_Anor", FirstDate: year(1995), LastDate: year(2003), Entries: 420},
+		{Tenancy: 2, SysopName: "Ivan_Petrov", SystemName: "Station_7", FirstDate: year(2009), LastDate: year(2026),
+			CurrentlyActive: true, StartReason: "sysop"},
+	})
+	for _, want := range []string{
+		`id="tenancies"`,
+		`id="tenancy-2"`,
+		"1995-01-01 &ndash; 2003-01-01",
+		"2009-01-01 &ndash; now",
+		"Ivan Petrov",
+		"a new sysop",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render missing %q", want)
+		}
+	}
+
+	single := renderNodeHistoryWithTenancies(t, `{}`, []storage.NodeTenancy{{Tenancy: 1, SysopName: "Boris_Paleev"}})
+	if strings.Contains(single, `id="tenancies"`) || strings.Contains(single, "Occupants:") {
+		t.Error("an address with one occupant should not show the occupants section")
+	}
+}
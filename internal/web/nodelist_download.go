@@ -3,8 +3,10 @@ package web
 import (
 	"archive/tar"
 	"compress/gzip"
+	"context"
 	"fmt"
 	"io"
+	"log/slog"
 	"net/http"
 	"os"
 	"path/filepath"
@@ -13,6 +15,7 @@ import (
 	"strings"
 
 	"github.com/nodelistdb/internal/database"
+	"github.com/nodelistdb/internal/logging"
 	"github.com/nodelistdb/internal/nodelistfs"
 	"github.com/nodelistdb/internal/version"
 )
@@ -58,6 +61,31 @@ func selectNodelistYear(years []nodelistfs.NodelistYear, selectedYear string) (n
 	return nodelistfs.NodelistYear{}, false
 }
 
+// crcMismatchDates returns the dates (as "2006-01-02") of the network's
+// nodelists whose computed CRC disagreed with their header when the parser
+// checked them, so the download pages can flag them as known-bad.
+//
+// The pages are served off disk and must keep working without the registry:
+// no storage (as in the download-only tests) or a failed query just means no
+// flags, never a failed page.
+func (s *Server) crcMismatchDates(ctx context.Context, network string) map[string]bool {
+	if s.storage == nil {
+		return nil
+	}
+	files, err := s.storage.GetNodelistFiles(ctx, network)
+	if err != nil {
+		logging.Warn("Nodelist CRC registry unavailable", slog.String("network", network), slog.Any("error", err))
+		return nil
+	}
+	bad := make(map[string]bool)
+	for _, f := range files {
+		if f.CRCMismatch() {
+			bad[f.NodelistDate.Format("2006-01-02")] = true
+		}
+	}
+	return bad
+}
+
 // requestNodelistNetwork resolves the network the downloads pages should be
 // scoped to: explicit ?domain= wins, then the global switcher cookie, then
 // fidonet. The result is always a concrete network name.
@@ -125,6 +153,7 @@ func (s *Server) NodelistHandler(w http.ResponseWriter, r *http.Request) {
 		BaseURL       string
 		Version       string
 		HasPointlists bool
+		CRCMismatch   map[string]bool
 	}{
 		Title:         "Downloads",
 		ActivePage:    "nodelists",
@@ -137,6 +166,7 @@ func (s *Server) NodelistHandler(w http.ResponseWriter, r *http.Request) {
 		BaseURL:       baseURL,
 		Version:       version.GetVersionInfo(),
 		HasPointlists: len(listPointlistSources()) > 0,
+		CRCMismatch:   s.crcMismatchDates(r.Context(), network),
 	}
 
 	// Find latest nodelist
@@ -195,17 +225,19 @@ func (s *Server) NodelistYearHandler(w http.ResponseWriter, r *http.Request) {
 	title := "Nodelists — " + nodelistfs.DisplayName(network) + " " + year
 
 	data := struct {
-		Title      string
-		ActivePage string
-		Network    string
-		Year       nodelistfs.NodelistYear
-		Version    string
+		Title       string
+		ActivePage  string
+		Network     string
+		Year        nodelistfs.NodelistYear
+		Version     string
+		CRCMismatch map[string]bool
 	}{
-		Title:      title,
-		ActivePage: "nodelists",
-		Network:    network,
-		Year:       yearData,
-		Version:    version.GetVersionInfo(),
+		Title:       title,
+		ActivePage:  "nodelists",
+		Network:     network,
+		Year:        yearData,
+		Version:     version.GetVersionInfo(),
+		CRCMismatch: s.crcMismatchDates(r.Context(), network),
 	}
 
 	s.render(w, "nodelist_year", data)
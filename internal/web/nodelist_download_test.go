@@ -1,12 +1,17 @@
 package web
 
 import (
+	"context"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/database"
 )
 
 // archiveLayout selects where a test fixture puts FidoNet's year directories.
@@ -432,3 +437,54 @@ func TestURLListHandlerNamesEveryNetwork(t *testing.T) {
 		}
 	}
 }
+
+// crcRegistryStub serves a fixed nodelist_files registry.
+type crcRegistryStub struct {
+	Storage
+	files []database.NodelistFile
+	err   error
+}
+
+func (s *crcRegistryStub) GetNodelistFiles(ctx context.Context, domain string) ([]database.NodelistFile, error) {
+	return s.files, s.err
+}
+
+// TestNodelistPagesFlagCRCMismatch verifies that a file the parser found
+// corrupt is flagged on both download pages, and that an unavailable registry
+// costs the flags but never the page.
+func TestNodelistPagesFlagCRCMismatch(t *testing.T) {
+	setupNodelistArchive(t, layoutCanonical)
+	bad := database.NodelistFile{
+		Domain:       database.DefaultDomain,
+		NodelistDate: time.Date(2026, 4, 10, 0, 0, 0, 0, time.UTC), // nodelist.100
+		CRCVerdict:   "mismatch",
+	}
+
+	for _, tc := range []struct {
+		name     string
+		stub     *crcRegistryStub
+		wantFlag bool
+	}{
+		{"mismatch recorded", &crcRegistryStub{files: []database.NodelistFile{bad}}, true},
+		{"verdict ok", &crcRegistryStub{files: []database.NodelistFile{{NodelistDate: bad.NodelistDate, CRCVerdict: "ok"}}}, false},
+		{"registry down", &crcRegistryStub{err: errors.New("clickhouse unavailable")}, false},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			s := newTestServer(t, tc.stub)
+			pages := map[string]func(w http.ResponseWriter, r *http.Request){
+				"/nodelists":              s.NodelistHandler,
+				"/nodelists/fidonet/2026": s.NodelistYearHandler,
+			}
+			for path, handler := range pages {
+				w := httptest.NewRecorder()
+				handler(w, httptest.NewRequest("GET", path, nil))
+				if w.Code != http.StatusOK {
+					t.Fatalf("%s: status = %d, want 200", path, w.Code)
+				}
+				if got := strings.Contains(w.Body.String(), "CRC mismatch"); got != tc.wantFlag {
+					t.Errorf("%s: CRC mismatch flag shown = %t, want %t", path, got, tc.wantFlag)
+				}
+			}
+		})
+	}
+}
@@ -9,6 +9,7 @@ import (
 
 	"github.com/nodelistdb/internal/database"
 	"github.com/nodelistdb/internal/storage"
+	"github.com/nodelistdb/internal/testing/transcript"
 )
 
 // renderPage executes one embedded template against a payload and returns the
@@ -216,6 +217,7 @@ type reachabilityStub struct {
 
 	trends    []storage.ReachabilityTrend
 	nodes     []storage.NodeTestResult
+	failed    []storage.NodeTestResult
 	searchLog []reachabilitySearch
 }
 
@@ -237,7 +239,7 @@ func (s *reachabilityStub) GetReachabilityTrendsAllTime(ctx context.Context, dom
 func (s *reachabilityStub) SearchNodesByReachability(ctx context.Context, operational bool, limit int, days int, domain string) ([]storage.NodeTestResult, error) {
 	s.searchLog = append(s.searchLog, reachabilitySearch{operational: operational, limit: limit, days: days, domain: domain})
 	if !operational {
-		return nil, nil
+		return s.failed, nil
 	}
 	return s.nodes, nil
 }
@@ -292,6 +294,62 @@ func TestReachabilityHandlerOpensOnRecentNodes(t *testing.T) {
 	}
 }
 
+// TestReachabilityStatusFilterSplitsFailures checks that "down" and
+// "intermittent" divide the failed nodes between them by the
+// intermittent_by_schedule verdict, and that the list marks the latter.
+func TestReachabilityStatusFilterSplitsFailures(t *testing.T) {
+	down := sampleReachabilityNode()
+	down.IsOperational = false
+	down.Hostname, down.TestedHostname = "down.example.org", "down.example.org"
+	asleep := down
+	asleep.Hostname, asleep.TestedHostname = "asleep.example.org", "asleep.example.org"
+	asleep.UptimeWindow = "20-06 UTC"
+	asleep.IntermittentBySchedule = true
+
+	ops := &reachabilityStub{failed: []storage.NodeTestResult{down, asleep}}
+	s := newTestServer(t, ops)
+
+	for _, tc := range []struct {
+		status, want, notWant string
+	}{
+		{"down", "down.example.org", "asleep.example.org"},
+		{"intermittent", "asleep.example.org", "down.example.org"},
+	} {
+		rec := httptest.NewRecorder()
+		s.ReachabilityHandler(rec, httptest.NewRequest("GET", "/reachability?status="+tc.status, nil))
+		body := rec.Body.String()
+		if !strings.Contains(body, tc.want) || strings.Contains(body, tc.notWant) {
+			t.Errorf("status=%s: want %s listed and %s not", tc.status, tc.want, tc.notWant)
+		}
+		if tc.status == "intermittent" && !strings.Contains(body, `title="Answers 20-06 UTC">Intermittent`) {
+			t.Error("status=intermittent: node not marked intermittent with its window")
+		}
+	}
+}
+
+// TestReachabilityListShowsVantageCount checks that a node tested from
+// several locations says from how many it was reachable, and that one tested
+// from a single location says nothing.
+func TestReachabilityListShowsVantageCount(t *testing.T) {
+	regional := sampleReachabilityNode()
+	regional.VantagePointsTested, regional.VantagePointsReachable = 3, 2
+	regional.VantageVerdict = "regional"
+	single := sampleReachabilityNode()
+	single.Hostname, single.TestedHostname = "single.example.org", "single.example.org"
+	single.VantagePointsTested, single.VantagePointsReachable = 1, 1
+
+	s := newTestServer(t, &reachabilityStub{nodes: []storage.NodeTestResult{regional, single}})
+	rec := httptest.NewRecorder()
+	s.ReachabilityHandler(rec, httptest.NewRequest("GET", "/reachability", nil))
+	body := rec.Body.String()
+	if !strings.Contains(body, `class="vantage-count regional" title="regional">reachable from 2/3 locations`) {
+		t.Error("regional node not marked reachable from 2/3 locations")
+	}
+	if strings.Contains(body, "from 1/1 locations") {
+		t.Error("a single-location result shows a location count")
+	}
+}
+
 // TestTestDetailTemplatesRender pins the two detail pages renderTestDetail
 // serves. Both take .TestResult as an `any` the handler filled from a
 // different store, so the template is the only thing that says which concrete
@@ -328,6 +386,85 @@ func TestTestDetailTemplatesRender(t *testing.T) {
 		})
 	})
 
+	t.Run("test_detail with path diagnostic", func(t *testing.T) {
+		result := sampleReachabilityNode()
+		result.IsOperational = false
+		result.BinkPSuccess = false
+		result.PathTCP = "filtered"
+		result.PathHops = []string{"10.0.0.1", "", "203.0.113.9"}
+		result.PathLastHop = "203.0.113.9"
+		result.PathLastHopTTL = 3
+		result.PathLastHopASN = 64500
+		result.PathLastHopAS = "Example Transit"
+		result.PathVerdict = "path-broken"
+		html := renderPage(t, "test_detail", map[string]interface{}{
+			"Title":      "Test Result Details",
+			"Version":    "test",
+			"ActivePage": "reachability",
+			"TestResult": &result,
+			"NodeInfo":   nodeInfo,
+			"Address":    "2:5001/100",
+		})
+		for _, want := range []string{"Path Diagnostic", "path-broken", "203.0.113.9 (hop 3) AS64500 Example Transit", "2. *"} {
+			if !strings.Contains(html, want) {
+				t.Errorf("rendered page missing %q", want)
+			}
+		}
+	})
+
+	t.Run("test_detail with vantage points", func(t *testing.T) {
+		result := sampleReachabilityNode()
+		result.VantagePointsTested = 3
+		result.VantagePointsReachable = 2
+		result.VantageVerdict = "regional"
+		html := renderPage(t, "test_detail", map[string]interface{}{
+			"Title":      "Test Result Details",
+			"Version":    "test",
+			"ActivePage": "reachability",
+			"TestResult": &result,
+			"NodeInfo":   nodeInfo,
+			"Address":    "2:5001/100",
+			"Vantages": []storage.VantageResult{
+				{VantagePoint: "de-fra", IsOperational: true, ProtocolsOK: []string{"binkp", "ifcico"}, ResponseMs: 42},
+				{VantagePoint: "us-east", ProtocolsFailed: []string{"binkp"}, Error: "i/o timeout"},
+			},
+		})
+		for _, want := range []string{"Reachable from 2/3 locations", "regional: a routing problem", "de-fra:", "via binkp, ifcico in 42 ms", "us-east:", "i/o timeout"} {
+			if !strings.Contains(html, want) {
+				t.Errorf("rendered page missing %q", want)
+			}
+		}
+	})
+
+	t.Run("test_detail with transcripts", func(t *testing.T) {
+		result := sampleReachabilityNode()
+		recorded := &transcript.Transcript{Protocol: "ifcico", Chunks: []transcript.Chunk{
+			{Dir: transcript.RX, Data: []byte("Welcome <mailer>\r\n")},
+			{At: 5 * time.Millisecond, Dir: transcript.TX, Data: []byte("**EMSI_INQC816\r")},
+		}}
+		data, err := recorded.Encode()
+		if err != nil {
+			t.Fatal(err)
+		}
+		html := renderPage(t, "test_detail", map[string]interface{}{
+			"Title":      "Test Result Details",
+			"Version":    "test",
+			"ActivePage": "reachability",
+			"TestResult": &result,
+			"NodeInfo":   nodeInfo,
+			"Address":    "2:5001/100",
+			"Transcripts": []transcriptView{
+				decodeTranscript(storage.TestTranscript{Protocol: "ifcico", RemoteIP: "192.0.2.1", Error: "handshake failed", Data: data}),
+				decodeTranscript(storage.TestTranscript{Protocol: "binkp", RemoteIP: "192.0.2.2", Data: []byte("garbage")}),
+			},
+		})
+		for _, want := range []string{"Wire Transcripts", "EMSI_INQ", "Welcome &lt;mailer&gt;", "5 ms", "Transcript unreadable"} {
+			if !strings.Contains(html, want) {
+				t.Errorf("rendered page missing %q", want)
+			}
+		}
+	})
+
 	t.Run("modem_test_detail", func(t *testing.T) {
 		html := renderPage(t, "modem_test_detail", map[string]interface{}{
 			"Title":      "Modem Test Details",
@@ -51,10 +51,13 @@ func (s *Server) SetupRoutes(mux *http.ServeMux) {
 	handle("/links", s.LinksHandler)
 	handle("/node/", varyByCookie(s.NodeHistoryHandler))
 	handle("/points/", varyByCookie(s.PointHistoryHandler))
+	handle("/sysop/", varyByCookie(s.SysopHandler))
 	handle("/browse", varyByCookie(s.BrowseZonesHandler))
 	handle("/browse/zone/", varyByCookie(s.BrowseZoneHandler))
 	handle("/browse/region/", varyByCookie(s.BrowseRegionHandler))
 	handle("/browse/net/", varyByCookie(s.BrowseNetHandler))
+	handle("/browse/diff", varyByCookie(s.BrowseDiffHandler))
+	handle("/browse/diff/", varyByCookie(s.BrowseDiffHandler))
 	handle("/analytics", varyByCookie(s.AnalyticsHandler))
 	handle("/analytics/flag", varyByCookie(s.AnalyticsFlagHandler))
 	handle("/analytics/network", varyByCookie(s.AnalyticsNetworkHandler))
@@ -70,6 +73,7 @@ func (s *Server) SetupRoutes(mux *http.ServeMux) {
 	handle("/analytics/telnet", varyByCookie(s.TelnetAnalyticsHandler))
 	handle("/analytics/vmodem", varyByCookie(s.VModemAnalyticsHandler))
 	handle("/analytics/vmodem-unavailable", varyByCookie(s.VModemUnavailableAnalyticsHandler))
+	handle("/analytics/binkps-certificates", varyByCookie(s.BinkPSCertificatesAnalyticsHandler))
 	handle("/analytics/ftp", varyByCookie(s.FTPAnalyticsHandler))
 	handle("/analytics/aka-mismatch", varyByCookie(s.AKAMismatchAnalyticsHandler))
 	handle("/analytics/other-networks", varyByCookie(s.OtherNetworksAnalyticsHandler))
@@ -81,9 +85,11 @@ func (s *Server) SetupRoutes(mux *http.ServeMux) {
 	handle("/analytics/email", varyByCookie(s.EmailAnalyticsHandler))
 	handle("/analytics/software/binkp", varyByCookie(s.BinkPSoftwareHandler))
 	handle("/analytics/software/ifcico", varyByCookie(s.IfcicoSoftwareHandler))
+	handle("/analytics/software/binkp-options", varyByCookie(s.BinkPOptionsHandler))
 	handle("/analytics/geo-hosting", varyByCookie(s.GeoHostingAnalyticsHandler))
 	handle("/analytics/geo-hosting/country", varyByCookie(s.GeoCountryNodesHandler))
 	handle("/analytics/geo-hosting/provider", varyByCookie(s.GeoProviderNodesHandler))
+	handle("/analytics/geo-map", varyByCookie(s.GeoMapHandler))
 	handle("/analytics/pioneers", varyByCookie(s.PioneersHandler))
 	// The list pages scope by the ftn_network cookie, so their output varies
 	// by cookie; the /nodes drill-down keys on the ?domain= URL param instead.
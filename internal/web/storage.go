@@ -27,12 +27,17 @@ type NodeReader interface {
 	GetNodeHistory(ctx context.Context, zone, net, node int, domain string) ([]database.Node, error)
 	GetNodeDomains(ctx context.Context, zone, net, node int) ([]string, error)
 	GetNodeChanges(ctx context.Context, zone, net, node int, domain string) ([]database.NodeChange, error)
+	GetNodeTenancies(ctx context.Context, zone, net, node int, domain string) ([]storage.NodeTenancy, error)
 	SearchNodesWithLifetime(ctx context.Context, filter database.NodeFilter) ([]storage.NodeSummary, error)
 	SearchNodesBySysop(ctx context.Context, sysopName string, limit int, domain string) ([]storage.NodeSummary, error)
+	SearchNodesBySysopFuzzy(ctx context.Context, sysopName string, limit int, domain string) ([]storage.NodeSummary, error)
+	GetSysopIdentity(ctx context.Context, id string) (*storage.SysopIdentity, error)
+	GetNodeSysopIdentities(ctx context.Context, zone, net, node int, domain string) ([]storage.SysopIdentityRef, error)
 	GetBrowseZones(ctx context.Context, date time.Time, domain string) ([]storage.BrowseZone, error)
 	GetBrowseRegions(ctx context.Context, date time.Time, zone int, domain string) ([]storage.BrowseRegion, error)
 	GetBrowseNets(ctx context.Context, date time.Time, zone, region int, domain string) ([]storage.BrowseNet, error)
 	GetBrowseNodes(ctx context.Context, date time.Time, zone, net int, domain string) ([]database.Node, error)
+	GetBrowseDiff(ctx context.Context, from, to time.Time, scope storage.BrowseDiffScope, domain string) (*storage.BrowseDiff, error)
 }
 
 // PointReader is the pointlist side of the same addresses.
@@ -52,6 +57,8 @@ type StatsReader interface {
 	GetAvailableDates(ctx context.Context, domain string) ([]time.Time, error)
 	GetNearestAvailableDate(ctx context.Context, requestedDate time.Time, domain string) (time.Time, error)
 	GetNodeCountHistory(ctx context.Context, domain string) ([]storage.NodeCountByDate, error)
+	GetNodelistFiles(ctx context.Context, domain string) ([]database.NodelistFile, error)
+	GetNodeMap(ctx context.Context, date time.Time, domain string) (*storage.NodeMap, error)
 }
 
 // FlagReader is flag and network provenance: when something first showed up and how it spread.
@@ -67,6 +74,8 @@ type FlagReader interface {
 type ReachabilityReader interface {
 	GetNodeTestHistory(ctx context.Context, zone, net, node int, days int, domain string) ([]storage.NodeTestResult, error)
 	GetDetailedTestResult(ctx context.Context, zone, net, node int, testTime string, domain string) (*storage.NodeTestResult, error)
+	GetTestTranscripts(ctx context.Context, zone, net, node int, testTime string, domain string) ([]storage.TestTranscript, error)
+	GetVantageResults(ctx context.Context, zone, net, node int, testTime string, domain string) ([]storage.VantageResult, error)
 	GetNodeReachabilityStats(ctx context.Context, zone, net, node int, days int, domain string) (*storage.NodeReachabilityStats, error)
 	GetReachabilityTrends(ctx context.Context, days int, domain string) ([]storage.ReachabilityTrend, error)
 	GetReachabilityTrendsAllTime(ctx context.Context, domain string) ([]storage.ReachabilityTrend, error)
@@ -80,6 +89,7 @@ type ProtocolReader interface {
 	GetTelnetEnabledNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]storage.NodeTestResult, error)
 	GetVModemEnabledNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]storage.NodeTestResult, error)
 	GetVModemUnconfirmedNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]storage.NodeTestResult, error)
+	GetBinkPSCertificateIssues(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]storage.NodeTestResult, error)
 	GetFTPEnabledNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]storage.NodeTestResult, error)
 	GetIPv6EnabledNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]storage.NodeTestResult, error)
 	GetIPv6NonWorkingNodes(ctx context.Context, limit int, days int, includeZeroNodes bool, domain string) ([]storage.NodeTestResult, error)
@@ -95,7 +105,9 @@ type ProtocolReader interface {
 
 // AnalyticsReader is the remaining reports: geography, other networks, PSTN, modem, file request and email.
 type AnalyticsReader interface {
+	GetBinkPOptionSupport(ctx context.Context, days int, domain string) (*storage.BinkPOptionSupport, error)
 	GetGeoHostingDistribution(ctx context.Context, days int, domain string) (*storage.GeoHostingDistribution, error)
+	GetUnreachableByASN(ctx context.Context, days int, domain string) ([]storage.UnreachableASNStats, error)
 	GetNodesByCountry(ctx context.Context, countryCode string, days int, domain string) ([]storage.NodeTestResult, error)
 	GetNodesByProvider(ctx context.Context, provider string, days int, domain string) ([]storage.NodeTestResult, error)
 	GetOtherNetworksSummary(ctx context.Context, days int, domain string) ([]storage.OtherNetworkSummary, error)
@@ -0,0 +1,87 @@
+package web
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/nodelistdb/internal/storage"
+)
+
+// sysopStorage answers GetSysopIdentity from a map, as the storage layer does
+// after following a retired ID's redirect.
+type sysopStorage struct {
+	Storage
+	identities map[string]*storage.SysopIdentity
+}
+
+func (s *sysopStorage) GetSysopIdentity(ctx context.Context, id string) (*storage.SysopIdentity, error) {
+	return s.identities[id], nil
+}
+
+func TestSysopHandler(t *testing.T) {
+	day := func(y int) time.Time { return time.Date(y, 1, 1, 0, 0, 0, 0, time.UTC) }
+	person := &storage.SysopIdentity{
+		ID:        "0123456789ab",
+		Name:      "Sergey_Ivanov",
+		Aliases:   []string{"Sergey_Ivanov", "Sergei_Ivanoff"},
+		Domains:   []string{"fidonet", "fsxnet"},
+		FirstSeen: day(1994), LastSeen: day(2026),
+		NodeCount: 2, PointCount: 1, Active: 1,
+		Career: []storage.SysopTenure{
+			{Domain: "fidonet", Zone: 2, Net: 5020, Node: 100, Point: 7, IsPoint: true,
+				SysopName: "Sergei_Ivanoff", FirstSeen: day(1994), LastSeen: day(1996), Evidence: []string{"similar-name"}},
+			{Domain: "fidonet", Zone: 2, Net: 5020, Node: 113, SysopName: "Sergey_Ivanov",
+				SystemName: "Minas_Anor", FirstSeen: day(1996), LastSeen: day(2003), Evidence: []string{"name", "host:bbs.example.org"}},
+			{Domain: "fsxnet", Zone: 21, Net: 1, Node: 150, SysopName: "Sergey_Ivanov",
+				FirstSeen: day(2017), LastSeen: day(2026), CurrentlyListed: true, Evidence: []string{"host:bbs.example.org"}},
+		},
+	}
+	s := newTestServer(t, &sysopStorage{identities: map[string]*storage.SysopIdentity{
+		"0123456789ab": person,
+		"ba9876543210": person, // retired when merged into person
+	}})
+
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		s.SysopHandler(rec, httptest.NewRequest("GET", path, nil))
+		return rec
+	}
+
+	rec := get("/sysop/0123456789ab")
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", rec.Code, rec.Body.String())
+	}
+	out := rec.Body.String()
+	for _, want := range []string{
+		"Sergei Ivanoff",                      // the other spelling
+		`href="/points/2/5020/100/7"`,         // a point tenure links to the point
+		`href="/node/2/5020/113"`,             // a node tenure to the node
+		`href="/node/21/1/150?domain=fsxnet"`, // in its own network
+		"host:bbs.example.org",                // and says why it is the same person
+		"1996-01-01 &ndash; 2003-01-01",       // a closed period
+		"2017-01-01 &ndash; now",              // and one still listed
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("render missing %q", want)
+		}
+	}
+
+	rec = get("/sysop/ba9876543210")
+	if rec.Code != http.StatusMovedPermanently || rec.Header().Get("Location") != "/sysop/0123456789ab" {
+		t.Errorf("retired ID: status %d to %q, want 301 to the identity it was merged into",
+			rec.Code, rec.Header().Get("Location"))
+	}
+
+	if rec := get("/sysop/ffffffffffff"); rec.Code != http.StatusNotFound {
+		t.Errorf("unknown ID: status = %d, want 404", rec.Code)
+	}
+	for _, path := range []string{"/sysop/", "/sysop/Sergey_Ivanov", "/sysop/0123456789AB"} {
+		if rec := get(path); rec.Code != http.StatusBadRequest {
+			t.Errorf("%s: status = %d, want 400", path, rec.Code)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package web
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/nodelistdb/internal/logging"
+	"github.com/nodelistdb/internal/storage"
+	"github.com/nodelistdb/internal/testing/transcript"
+)
+
+// transcriptView is one recorded session as the test detail page shows it.
+type transcriptView struct {
+	storage.TestTranscript
+	Events []transcriptEvent
+	// DecodeError is set when the stored transcript could not be read; the
+	// row's own fields are still shown.
+	DecodeError string
+}
+
+type transcriptEvent struct {
+	transcript.Event
+	Ms int64 // Event.At in milliseconds, for display
+}
+
+// testTranscripts loads and decodes the transcripts of a test cycle. They
+// are an aid to the page, not its subject: a failure is logged and the page
+// renders without them.
+func (s *Server) testTranscripts(ctx context.Context, zone, net, node int, testTime, domain string) []transcriptView {
+	stored, err := s.storage.GetTestTranscripts(ctx, zone, net, node, testTime, domain)
+	if err != nil {
+		logging.Errorf("Error getting test transcripts for %d:%d/%d at %s: %v", zone, net, node, testTime, err)
+		return nil
+	}
+	views := make([]transcriptView, 0, len(stored))
+	for _, st := range stored {
+		views = append(views, decodeTranscript(st))
+	}
+	return views
+}
+
+// vantageResults loads what each location saw in the test, on the same terms
+// as testTranscripts.
+func (s *Server) vantageResults(ctx context.Context, zone, net, node int, testTime, domain string) []storage.VantageResult {
+	results, err := s.storage.GetVantageResults(ctx, zone, net, node, testTime, domain)
+	if err != nil {
+		logging.Errorf("Error getting vantage results for %d:%d/%d at %s: %v", zone, net, node, testTime, err)
+		return nil
+	}
+	return results
+}
+
+// decodeTranscript reads a stored transcript back into protocol events.
+func decodeTranscript(st storage.TestTranscript) transcriptView {
+	view := transcriptView{TestTranscript: st}
+	view.Data = nil // rendered through Events, not carried to the template
+	t, err := transcript.Decode(st.Data)
+	if err != nil {
+		view.DecodeError = err.Error()
+		return view
+	}
+	var events []transcript.Event
+	switch st.Protocol {
+	case "binkp", "binkps":
+		events = transcript.BinkPFrames(t)
+	case "ifcico":
+		events = transcript.EMSIPackets(t)
+	default:
+		view.DecodeError = fmt.Sprintf("no decoder for protocol %q", st.Protocol)
+		return view
+	}
+	view.Events = make([]transcriptEvent, len(events))
+	for i, e := range events {
+		view.Events[i] = transcriptEvent{Event: e, Ms: int64(e.At / time.Millisecond)}
+	}
+	return view
+}
@@ -31,6 +31,7 @@ CREATE TABLE IF NOT EXISTS nodelistdb.nodes
     `has_conflict` Bool DEFAULT false,
     `fts_id` String,
     `raw_line` String DEFAULT '',
+    `line_number` UInt32 DEFAULT 0,
     `year` UInt16 MATERIALIZED toYear(nodelist_date),
     `json_protocols` Array(String) MATERIALIZED extractAll(toString(internet_config), '"([A-Z]{3})"'),
     INDEX idx_nodes_date nodelist_date TYPE minmax GRANULARITY 1,
@@ -86,6 +87,24 @@ CREATE TABLE IF NOT EXISTS nodelistdb.node_test_results
     `binkp_addresses` Array(String),
     `binkp_capabilities` Array(String),
     `binkp_error` String,
+    `binkp_auth_method` String DEFAULT '',
+    `binkp_mail_exchange` String DEFAULT '',
+    `binkp_mail_detail` String DEFAULT '',
+    `binkp_opt_cram` String DEFAULT '',
+    `binkp_opt_crypt` String DEFAULT '',
+    `binkp_opt_plz` String DEFAULT '',
+    `binkp_opt_gz` String DEFAULT '',
+    `binkp_opt_bz2` String DEFAULT '',
+    `binkps_tested` Bool DEFAULT false,
+    `binkps_success` Bool DEFAULT false,
+    `binkps_response_ms` UInt32 DEFAULT 0,
+    `binkps_error` String DEFAULT '',
+    `binkps_cert_subject` String DEFAULT '',
+    `binkps_cert_issuer` String DEFAULT '',
+    `binkps_cert_not_after` Nullable(DateTime) DEFAULT NULL,
+    `binkps_cert_chain_valid` Bool DEFAULT false,
+    `binkps_cert_self_signed` Bool DEFAULT false,
+    `binkps_cert_error` String DEFAULT '',
     `ifcico_tested` Bool,
     `ifcico_success` Bool,
     `ifcico_response_ms` UInt32,
@@ -98,6 +117,11 @@ CREATE TABLE IF NOT EXISTS nodelistdb.node_test_results
     `telnet_success` Bool,
     `telnet_response_ms` UInt32,
     `telnet_error` String,
+    `telnet_mailer_info` String DEFAULT '',
+    `telnet_system_name` String DEFAULT '',
+    `telnet_sysop` String DEFAULT '',
+    `telnet_addresses` Array(String) DEFAULT [],
+    `telnet_response_type` String DEFAULT '',
     `ftp_tested` Bool,
     `ftp_success` Bool,
     `ftp_response_ms` UInt32,
@@ -120,6 +144,21 @@ CREATE TABLE IF NOT EXISTS nodelistdb.node_test_results
     `is_operational` Bool,
     `has_connectivity_issues` Bool,
     `address_validated` Bool,
+    -- Path probe of a node that failed every protocol; empty otherwise
+    `path_tcp` LowCardinality(String) DEFAULT '',
+    `path_hops` Array(String) DEFAULT [],
+    `path_last_hop` String DEFAULT '',
+    `path_last_hop_ttl` UInt8 DEFAULT 0,
+    `path_last_hop_asn` UInt32 DEFAULT 0,
+    `path_last_hop_as` String DEFAULT '',
+    `path_verdict` LowCardinality(String) DEFAULT '',
+    -- Learned reachable hours; a failure outside them is intermittent, not down
+    `uptime_window` String DEFAULT '',
+    `intermittent_by_schedule` Bool DEFAULT false,
+    -- Locations that tested the node and reached it; see node_test_vantage_results
+    `vantage_points_tested` UInt8 DEFAULT 0,
+    `vantage_points_reachable` UInt8 DEFAULT 0,
+    `vantage_verdict` LowCardinality(String) DEFAULT '',
     `binkp_ipv4_tested` Bool DEFAULT 0,
     `binkp_ipv4_success` Bool DEFAULT 0,
     `binkp_ipv4_response_ms` UInt32 DEFAULT 0,
@@ -161,6 +200,8 @@ CREATE TABLE IF NOT EXISTS nodelistdb.node_test_results
     `binkp_ipv6_addresses` Array(String) DEFAULT [],
     `ifcico_ipv4_addresses` Array(String) DEFAULT [],
     `ifcico_ipv6_addresses` Array(String) DEFAULT [],
+    `telnet_ipv4_addresses` Array(String) DEFAULT [],
+    `telnet_ipv6_addresses` Array(String) DEFAULT [],
     `address_validated_ipv4` Bool DEFAULT false,
     `address_validated_ipv6` Bool DEFAULT false,
     `ftp_ipv4_tested` Bool DEFAULT false,
@@ -268,6 +309,65 @@ PARTITION BY toYYYYMM(test_date)
 ORDER BY (test_date, zone, net, node)
 SETTINGS index_granularity = 8192;
 
+-- Wire transcripts of BinkP, binkps and IFCICO test sessions
+-- Written by testdaemon when protocols.<binkp|ifcico>.transcripts is failed or
+-- all; read by /reachability/test and cmd/transcript-replay. A row belongs to
+-- the node_test_results rows of the same node within a test cycle, not to one
+-- row exactly: a multi-hostname node is tested over a span of seconds.
+-- transcript is a gzip-compressed JSON document (internal/testing/transcript);
+-- BinkP passwords we sent are blanked out before it is written.
+CREATE TABLE IF NOT EXISTS nodelistdb.node_test_transcripts
+(
+    `test_time` DateTime,                   -- test_time of the result it was recorded for
+    `zone` UInt16,
+    `net` UInt16,
+    `node` UInt16,
+    `address` String,
+    `domain` LowCardinality(String) DEFAULT 'fidonet',
+    `hostname` String,                      -- hostname tested
+    `protocol` LowCardinality(String),      -- binkp | binkps | ifcico
+    `remote_ip` String,
+    `success` Bool,
+    `error` String,
+    `started_at` DateTime64(3),             -- when the connection was made
+    `raw_bytes` UInt32,                     -- bytes recorded, both directions
+    `truncated` Bool,                       -- session outran the recording limit
+    `transcript` String CODEC(ZSTD(1))
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(test_time)
+ORDER BY (zone, net, node, test_time)
+TTL test_time + INTERVAL 90 DAY
+SETTINGS index_granularity = 8192;
+
+-- What each location saw of a node, when testing is spread over a coordinator
+-- testdaemon and its agents (vantage in the testdaemon config). One row per
+-- location per test, the coordinator's own included; the rows of one test
+-- carry the test_time of its combined node_test_results row.
+CREATE TABLE IF NOT EXISTS nodelistdb.node_test_vantage_results
+(
+    `test_time` DateTime,                   -- test_time of the combined result
+    `zone` UInt16,
+    `net` UInt16,
+    `node` UInt16,
+    `address` String,
+    `domain` LowCardinality(String) DEFAULT 'fidonet',
+    `vantage_point` LowCardinality(String), -- location name from the config
+    `tested_at` DateTime,                   -- when that location tested
+    `is_operational` Bool,
+    `tested_hostname` String,
+    `dns_error` String,
+    `protocols_ok` Array(LowCardinality(String)),
+    `protocols_failed` Array(LowCardinality(String)),
+    `response_ms` UInt32,                   -- fastest answering protocol
+    `error` String                          -- first protocol error when nothing answered
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(test_time)
+ORDER BY (zone, net, node, test_time)
+TTL test_time + INTERVAL 180 DAY
+SETTINGS index_granularity = 8192;
+
 -- Daily statistics table - aggregated test statistics per day
 CREATE TABLE IF NOT EXISTS nodelistdb.node_test_daily_stats
 (
@@ -384,6 +484,134 @@ ENGINE = ReplacingMergeTree(imported_at)
 ORDER BY (domain, list_source, pointlist_date)
 SETTINGS index_granularity = 8192;
 
+-- Nodelist CRC registry
+-- One row per nodelist file cmd/parser examined: the CRC-16 its header declares,
+-- the CRC its contents compute to, and the verdict. Refused files (-crc-check
+-- strict) are recorded too, with imported = false. Read by the download pages
+-- to flag known-bad archive files.
+CREATE TABLE IF NOT EXISTS nodelistdb.nodelist_files
+(
+    `domain`          LowCardinality(String),
+    `nodelist_date`   Date,
+    `day_number`      Int32,
+    `filename`        String,
+    `header_crc`      UInt16,
+    `has_header_crc`  Bool,
+    `computed_crc`    UInt16,
+    `crc_verdict`     LowCardinality(String),   -- ok | mismatch | no_header
+    `nodes_count`     UInt32,
+    `imported`        Bool,
+    `checked_at`      DateTime DEFAULT now()
+)
+ENGINE = ReplacingMergeTree(checked_at)
+ORDER BY (domain, nodelist_date)
+SETTINGS index_granularity = 8192;
+
+-- Nodelist text lines: every line of an imported nodelist that no node row
+-- reproduces verbatim (header, comments, blanks, unparsed lines), so that
+-- together with nodes.line_number a file can be rebuilt byte for byte
+CREATE TABLE IF NOT EXISTS nodelistdb.nodelist_lines
+(
+    `domain`          LowCardinality(String),
+    `nodelist_date`   Date,
+    `line_number`     UInt32,
+    `raw_line`        String
+)
+ENGINE = ReplacingMergeTree
+PARTITION BY toYear(nodelist_date)
+ORDER BY (domain, nodelist_date, line_number)
+SETTINGS index_granularity = 8192;
+
+-- Change notification delivery log
+-- One row per attempt to deliver a batch of node change events to a
+-- subscriber; written by cmd/parser -notify (see internal/notify)
+CREATE TABLE IF NOT EXISTS nodelistdb.notification_deliveries
+(
+    `delivery_id`      String,
+    `subscription_id`  String,
+    `channel`          LowCardinality(String),   -- webhook | email
+    `target`           String,
+    `domain`           LowCardinality(String),
+    `nodelist_date`    Date,
+    `event_count`      UInt32,
+    `attempt`          UInt8,
+    `status`           LowCardinality(String),   -- delivered | retrying | failed
+    `http_status`      UInt16,
+    `error`            String,
+    `duration_ms`      UInt32,
+    `attempted_at`     DateTime
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(attempted_at)
+ORDER BY (subscription_id, attempted_at)
+TTL attempted_at + INTERVAL 1 YEAR
+SETTINGS index_granularity = 8192;
+
+-- Change notification subscriptions registered through the API
+-- Written by POST/DELETE /api/subscriptions, read by cmd/parser -notify next
+-- to the subscriptions file (see internal/notify). The newest row per
+-- subscription_id wins; a deleted subscription is a newer row with deleted.
+CREATE TABLE IF NOT EXISTS nodelistdb.notification_subscriptions
+(
+    `subscription_id`  String,
+    `owner`            String,                   -- API key_id that registered it
+    `domain`           LowCardinality(String),
+    `filter_address`   String,
+    `filter_net`       String,
+    `filter_sysop`     String,
+    `filter_flag`      String,
+    `events`           Array(LowCardinality(String)),   -- empty: all of them
+    `webhook`          String,
+    `secret`           String,
+    `email`            String,
+    `deleted`          Bool,
+    `updated_at`       DateTime64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY subscription_id
+SETTINGS index_granularity = 8192;
+
+-- Public API keys
+-- Read by the server when api_access.keys_source is clickhouse (see
+-- internal/apiaccess). Only the SHA-256 of each key is stored; the newest row
+-- per key_id wins, so a key is changed or revoked by inserting it again
+CREATE TABLE IF NOT EXISTS nodelistdb.api_keys
+(
+    `key_id`               String,
+    `owner`                String,
+    `key_hash`             String,                   -- sha256:<hex>
+    `requests_per_minute`  UInt32,                   -- 0: api_access.default_key
+    `burst`                UInt32,                   -- 0: api_access.default_key
+    `admin`                Bool,                     -- may read /api/usage
+    `disabled`             Bool,
+    `updated_at`           DateTime DEFAULT now()
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY key_id
+SETTINGS index_granularity = 8192;
+
+-- Public API usage
+-- Requests per caller, route and status, summed per minute by the server
+-- and merged further by the engine. Anonymous callers have an empty key_id
+-- and are told apart by client_ip; keyed ones leave client_ip empty
+CREATE TABLE IF NOT EXISTS nodelistdb.api_usage
+(
+    `period`       DateTime,
+    `key_id`       LowCardinality(String),
+    `client_ip`    String,
+    `method`       LowCardinality(String),
+    `route`        LowCardinality(String),   -- chi route pattern
+    `status`       UInt16,
+    `requests`     UInt64,
+    `bytes`        UInt64,
+    `duration_ms`  UInt64
+)
+ENGINE = SummingMergeTree((requests, bytes, duration_ms))
+PARTITION BY toYYYYMM(period)
+ORDER BY (period, key_id, client_ip, route, method, status)
+TTL period + INTERVAL 1 YEAR
+SETTINGS index_granularity = 8192;
+
 -- Domain WHOIS cache table
 -- Stores WHOIS lookup results for domains used by FidoNet nodes
 -- Used by testdaemon (writes) and server analytics page (reads)
@@ -427,3 +655,90 @@ ENGINE = ReplacingMergeTree(last_attempt_time)
 ORDER BY domain
 TTL last_attempt_time + INTERVAL 180 DAY
 SETTINGS index_granularity = 8192;
+
+-- Sysop identities
+-- One row per node or point tenure (an address under one sysop name),
+-- labelled with the person it was resolved to. Rebuilt whole by the parser's
+-- -resolve-sysops mode: each run writes a new resolved_at and deletes the
+-- older ones, and readers only look at the newest. sysop_id survives rebuilds;
+-- an ID retired by a merge or split redirects to its successor
+CREATE TABLE IF NOT EXISTS nodelistdb.sysop_identities
+(
+    `sysop_id`          String,                  -- stable across resolutions
+    `display_name`      String,                  -- the spelling listed longest
+    `domain`            LowCardinality(String),
+    `zone`              Int32,
+    `net`               Int32,
+    `node`              Int32,
+    `point`             Int32,                   -- 0 for nodes
+    `is_point`          Bool,
+    `sysop_name`        String,                  -- as listed at this address
+    `system_name`       String,                  -- as last listed
+    `first_seen`        Date,
+    `last_seen`         Date,
+    `currently_listed`  Bool,
+    `evidence`          Array(String),           -- name, similar-name, host:..., email:..., handover
+    `resolved_at`       DateTime
+)
+ENGINE = MergeTree
+ORDER BY (sysop_id, domain, zone, net, node, point, sysop_name)
+SETTINGS index_granularity = 8192;
+
+CREATE TABLE IF NOT EXISTS nodelistdb.sysop_identity_redirects
+(
+    `old_id`       String,                       -- retired by a merge or split
+    `sysop_id`     String,                       -- the identity it now points at
+    `resolved_at`  DateTime
+)
+ENGINE = MergeTree
+ORDER BY old_id
+SETTINGS index_granularity = 8192;
+
+-- Node tenancies
+-- A node address's history cut where its occupant changed: a different
+-- sysop, a new system under an unnamed one, or a new system after a year
+-- unlisted. Rebuilt whole by the parser's -detect-tenancies mode the same way
+-- as sysop_identities, under a new detected_at. The node searches ASOF-join
+-- it on first_date to report one lifetime per occupant
+CREATE TABLE IF NOT EXISTS nodelistdb.node_tenancies
+(
+    `domain`        LowCardinality(String),
+    `zone`          Int32,
+    `net`           Int32,
+    `node`          Int32,
+    `tenancy`       UInt16,                      -- 1 for the first occupant
+    `tenancies`     UInt16,                      -- how many the address has had
+    `sysop_name`    String,                      -- as last listed
+    `system_name`   String,                      -- as last listed
+    `location`      String,                      -- as last listed
+    `first_date`    Date,
+    `last_date`     Date,
+    `entries`       UInt32,                      -- nodelists listing it
+    `start_reason`  LowCardinality(String),      -- '', sysop, system or relisted
+    `detected_at`   DateTime
+)
+ENGINE = MergeTree
+ORDER BY (domain, zone, net, node, first_date)
+SETTINGS index_granularity = 8192;
+
+-- Location geocodes
+-- Each distinct nodelist Location string resolved to coordinates with the
+-- gazetteer bundled in the binaries (internal/geocode) by the parser's
+-- -geocode-locations mode. One row per string, not per node: the node map
+-- joins a date's nodes to it. Unresolved strings are stored with an empty
+-- precision so they are not retried until the gazetteer version changes
+CREATE TABLE IF NOT EXISTS nodelistdb.location_geocodes
+(
+    `location`      String,                      -- as listed
+    `place`         String,                      -- the gazetteer's name for it
+    `country_code`  LowCardinality(String),      -- ISO 3166-1 alpha-2
+    `country`       LowCardinality(String),
+    `latitude`      Float64,
+    `longitude`     Float64,
+    `precision`     LowCardinality(String),      -- city, region, country or '' (unresolved)
+    `gazetteer`     LowCardinality(String),      -- version it was resolved with
+    `geocoded_at`   DateTime
+)
+ENGINE = ReplacingMergeTree(geocoded_at)
+ORDER BY location
+SETTINGS index_granularity = 8192;
@@ -0,0 +1,35 @@
+-- Migration 015: nodelist CRC registry
+--
+-- cmd/parser now checks every nodelist's FTS-5000 CRC-16 - the value the
+-- ";A ... : NNNNN" header declares against the one its contents compute to -
+-- and records the outcome here, whether or not the file was imported. Under
+-- -crc-check strict a mismatched file is refused and quarantined, and its row
+-- carries imported = false.
+--
+-- Purely additive: creates one new table, touches nothing existing. Safe to
+-- run before or after deploying new binaries; the parser also creates it.
+--
+-- Keyed by (domain, nodelist_date) like the archive itself: one file per
+-- network per day. A corrected reissue replaces the earlier verdict, which is
+-- why the engine version is checked_at.
+--
+-- Nodelists imported before this migration have no row. The download pages
+-- treat a missing row as "not checked", never as "ok".
+
+CREATE TABLE IF NOT EXISTS nodelistdb.nodelist_files
+(
+    `domain`          LowCardinality(String),
+    `nodelist_date`   Date,
+    `day_number`      Int32,
+    `filename`        String,
+    `header_crc`      UInt16,
+    `has_header_crc`  Bool,
+    `computed_crc`    UInt16,
+    `crc_verdict`     LowCardinality(String),   -- ok | mismatch | no_header
+    `nodes_count`     UInt32,
+    `imported`        Bool,
+    `checked_at`      DateTime DEFAULT now()
+)
+ENGINE = ReplacingMergeTree(checked_at)
+ORDER BY (domain, nodelist_date)
+SETTINGS index_granularity = 8192;
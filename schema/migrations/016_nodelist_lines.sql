@@ -0,0 +1,36 @@
+-- Migration 016: byte-exact nodelist rebuild
+--
+-- The nodes table keeps every node line's text in raw_line, but not where in
+-- the file it stood, and nothing keeps the header, the comment blocks or the
+-- lines the parser skipped. Both are needed to serve a nodelist for a date the
+-- on-disk archive is missing (/api/nodelist/{domain}/{date}.txt and
+-- cmd/parser -export-date).
+--
+--   nodes.line_number      1-based line of the file each node row came from
+--   nodelist_lines         every other line, verbatim, by line number
+--
+-- A node line whose raw text differs from its stored raw_line (raw_line is
+-- trimmed) also gets a nodelist_lines row; on rebuild the verbatim text wins.
+--
+-- ALTER ADD COLUMN ... DEFAULT is metadata-only on MergeTree, and the insert
+-- column lists are explicit, so old binaries keep working. Rows imported
+-- before this migration keep line_number = 0 and have no text lines; those
+-- dates can only be approximated (address order, synthesized header), and the
+-- export says so in its X-Nodelist-Rebuild header.
+--
+-- nodelist_lines is ReplacingMergeTree keyed down to the line, so re-running
+-- an import cannot duplicate lines.
+
+ALTER TABLE nodelistdb.nodes ADD COLUMN IF NOT EXISTS `line_number` UInt32 DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS nodelistdb.nodelist_lines
+(
+    `domain`          LowCardinality(String),
+    `nodelist_date`   Date,
+    `line_number`     UInt32,
+    `raw_line`        String
+)
+ENGINE = ReplacingMergeTree
+PARTITION BY toYear(nodelist_date)
+ORDER BY (domain, nodelist_date, line_number)
+SETTINGS index_granularity = 8192;
@@ -0,0 +1,37 @@
+-- Migration 017: change notification delivery log
+--
+-- cmd/parser -notify compares each newly imported nodelist with the one
+-- before it and sends the node changes - added, removed, flags changed, gone
+-- Down or Hold - to the subscribers listed in the notifications
+-- subscriptions file, by webhook or by mail. Every delivery attempt lands
+-- here, failed ones included, so an operator can see why a subscriber heard
+-- nothing: the attempts of one batch share a delivery_id, and only the last
+-- of them is delivered or failed.
+--
+-- Purely additive: creates one new table, touches nothing existing. Safe to
+-- run before or after deploying new binaries; the parser also creates it.
+--
+-- A log, not state: plain MergeTree, and kept for a year. Nothing reads it
+-- back to decide what to send.
+
+CREATE TABLE IF NOT EXISTS nodelistdb.notification_deliveries
+(
+    `delivery_id`      String,
+    `subscription_id`  String,
+    `channel`          LowCardinality(String),   -- webhook | email
+    `target`           String,
+    `domain`           LowCardinality(String),
+    `nodelist_date`    Date,
+    `event_count`      UInt32,
+    `attempt`          UInt8,
+    `status`           LowCardinality(String),   -- delivered | retrying | failed
+    `http_status`      UInt16,
+    `error`            String,
+    `duration_ms`      UInt32,
+    `attempted_at`     DateTime
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(attempted_at)
+ORDER BY (subscription_id, attempted_at)
+TTL attempted_at + INTERVAL 1 YEAR
+SETTINGS index_granularity = 8192;
@@ -0,0 +1,54 @@
+-- Migration 018: public API keys and usage
+--
+-- The public API used to be anonymous and unlimited apart from /api/modem,
+-- so one scraper could use up the query budget for everyone. With
+-- api_access enabled the server rate-limits each API key and each anonymous
+-- client address, and accounts every request here.
+--
+-- api_keys holds the keys when api_access.keys_source is clickhouse (the
+-- alternative is a YAML file). The newest row per key_id wins; to revoke a
+-- key insert it again with disabled = true. The server re-reads the table
+-- every api_access.reload_interval.
+--
+--   INSERT INTO nodelistdb.api_keys (key_id, owner, key_hash)
+--   VALUES ('research-lab', 'jane@example.org', 'sha256:<hex of the key>');
+--
+-- api_usage is written by the server once a minute: one row per caller,
+-- route, method and status per minute, which SummingMergeTree folds further.
+-- It is read by GET /api/usage.
+--
+-- Purely additive: creates two new tables, touches nothing existing. Safe to
+-- run before or after deploying new binaries.
+
+CREATE TABLE IF NOT EXISTS nodelistdb.api_keys
+(
+    `key_id`               String,
+    `owner`                String,
+    `key_hash`             String,                   -- sha256:<hex>
+    `requests_per_minute`  UInt32,                   -- 0: api_access.default_key
+    `burst`                UInt32,                   -- 0: api_access.default_key
+    `admin`                Bool,                     -- may read /api/usage
+    `disabled`             Bool,
+    `updated_at`           DateTime DEFAULT now()
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY key_id
+SETTINGS index_granularity = 8192;
+
+CREATE TABLE IF NOT EXISTS nodelistdb.api_usage
+(
+    `period`       DateTime,
+    `key_id`       LowCardinality(String),
+    `client_ip`    String,
+    `method`       LowCardinality(String),
+    `route`        LowCardinality(String),   -- chi route pattern
+    `status`       UInt16,
+    `requests`     UInt64,
+    `bytes`        UInt64,
+    `duration_ms`  UInt64
+)
+ENGINE = SummingMergeTree((requests, bytes, duration_ms))
+PARTITION BY toYYYYMM(period)
+ORDER BY (period, key_id, client_ip, route, method, status)
+TTL period + INTERVAL 1 YEAR
+SETTINGS index_granularity = 8192;
@@ -0,0 +1,32 @@
+-- Migration 019: record the outcome of password-protected BinkP sessions
+--
+-- The BinkP tester has only ever run the anonymous handshake (M_PWD "-"),
+-- which shows a mailer answers but not that it would exchange mail. For nodes
+-- the testdaemon holds a session password for (protocols.binkp.passwords) it
+-- now authenticates, sends a zero-byte probe file and skips whatever the node
+-- has queued for us. Three columns carry the result:
+--
+--   binkp_auth_method    how the session authenticated: cram-md5 | plain.
+--                        Empty when we hold no password for the node, which
+--                        is the normal case.
+--   binkp_mail_exchange  the groupable outcome, safe to GROUP BY:
+--                        ok          probe file acknowledged with M_GOT
+--                        refused     probe file answered with M_SKIP
+--                        not-secure  remote has no password for us; no probe
+--                                    was sent into its unprotected inbound
+--                        auth-failed remote rejected our password
+--                        failed      the transfer stage broke off
+--   binkp_mail_detail    the human-readable note behind the outcome, e.g. the
+--                        remote's M_ERR text or how many files it had queued.
+--
+-- All three are additive String columns with an empty default: existing rows
+-- read back as '' and old binaries keep inserting without them. This is a
+-- metadata-only ALTER — no data is rewritten.
+--
+-- Run on production ClickHouse BEFORE deploying the new testdaemon/server
+-- binaries (the new INSERT names these columns; the new SELECTs read them).
+
+ALTER TABLE node_test_results
+    ADD COLUMN IF NOT EXISTS `binkp_auth_method` String DEFAULT '' AFTER `binkp_error`,
+    ADD COLUMN IF NOT EXISTS `binkp_mail_exchange` String DEFAULT '' AFTER `binkp_auth_method`,
+    ADD COLUMN IF NOT EXISTS `binkp_mail_detail` String DEFAULT '' AFTER `binkp_mail_exchange`;
@@ -0,0 +1,36 @@
+-- Migration 020: record whether advertised BinkP options actually work
+--
+-- binkp_capabilities holds the remote's OPT line as it was announced. That
+-- says what a mailer claims, not what it can do. The tester now negotiates
+-- the options it implements and records a verdict per option:
+--
+--   binkp_opt_cram   CRAM-MD5 challenge (FTS-1027)
+--   binkp_opt_crypt  CRYPT, binkd's session encryption
+--   binkp_opt_plz    PLZ, zlib-compressed data frames
+--   binkp_opt_gz     EXTCMD GZ, files sent as zlib streams
+--   binkp_opt_bz2    EXTCMD BZ2; we cannot compress bzip2, so never exercised
+--
+-- Each holds one of:
+--
+--   ''        the remote did not advertise the option
+--   ok        exercised, and it worked
+--   failed    the challenge was malformed, GZ came without EXTCMD, or the
+--             transfer broke off while the option was in use
+--   untested  advertised, but this session could not exercise it
+--
+-- Only CRAM and GZ can be judged in an anonymous session, and then only when
+-- malformed; the rest needs a session password (protocols.binkp.passwords),
+-- so most rows read 'untested'.
+--
+-- Additive String columns with an empty default, like migration 019: a
+-- metadata-only ALTER.
+--
+-- Run on production ClickHouse BEFORE deploying the new testdaemon/server
+-- binaries.
+
+ALTER TABLE node_test_results
+    ADD COLUMN IF NOT EXISTS `binkp_opt_cram` String DEFAULT '' AFTER `binkp_mail_detail`,
+    ADD COLUMN IF NOT EXISTS `binkp_opt_crypt` String DEFAULT '' AFTER `binkp_opt_cram`,
+    ADD COLUMN IF NOT EXISTS `binkp_opt_plz` String DEFAULT '' AFTER `binkp_opt_crypt`,
+    ADD COLUMN IF NOT EXISTS `binkp_opt_gz` String DEFAULT '' AFTER `binkp_opt_plz`,
+    ADD COLUMN IF NOT EXISTS `binkp_opt_bz2` String DEFAULT '' AFTER `binkp_opt_gz`;
@@ -0,0 +1,37 @@
+-- Migration 021: BinkP over TLS (binkps) and the certificates it presents
+--
+-- Nodes announcing IBNS (non-standard; port 24553 unless the flag says
+-- otherwise) run BinkP inside TLS. The testdaemon now tests them there, next
+-- to and independently of plain BinkP on IBN. The TLS handshake accepts any
+-- certificate so that a broken one can be recorded rather than just failing
+-- the test:
+--
+--   binkps_tested / _success / _response_ms / _error
+--                             as for binkp_*, over TLS
+--   binkps_cert_subject       leaf certificate subject, RFC 2253 form
+--   binkps_cert_issuer        leaf certificate issuer
+--   binkps_cert_not_after     expiry; NULL when no certificate was seen
+--   binkps_cert_chain_valid   chains to a system root, names the host that
+--                             was tested, and was in date at test time
+--   binkps_cert_self_signed   issued and signed by itself
+--   binkps_cert_error         why the chain did not verify
+--
+-- The certificate columns are filled even when the BinkP session behind the
+-- TLS handshake failed.
+--
+-- Additive columns with defaults: a metadata-only ALTER.
+--
+-- Run on production ClickHouse BEFORE deploying the new testdaemon/server
+-- binaries.
+
+ALTER TABLE node_test_results
+    ADD COLUMN IF NOT EXISTS `binkps_tested` Bool DEFAULT false AFTER `binkp_opt_bz2`,
+    ADD COLUMN IF NOT EXISTS `binkps_success` Bool DEFAULT false AFTER `binkps_tested`,
+    ADD COLUMN IF NOT EXISTS `binkps_response_ms` UInt32 DEFAULT 0 AFTER `binkps_success`,
+    ADD COLUMN IF NOT EXISTS `binkps_error` String DEFAULT '' AFTER `binkps_response_ms`,
+    ADD COLUMN IF NOT EXISTS `binkps_cert_subject` String DEFAULT '' AFTER `binkps_error`,
+    ADD COLUMN IF NOT EXISTS `binkps_cert_issuer` String DEFAULT '' AFTER `binkps_cert_subject`,
+    ADD COLUMN IF NOT EXISTS `binkps_cert_not_after` Nullable(DateTime) DEFAULT NULL AFTER `binkps_cert_issuer`,
+    ADD COLUMN IF NOT EXISTS `binkps_cert_chain_valid` Bool DEFAULT false AFTER `binkps_cert_not_after`,
+    ADD COLUMN IF NOT EXISTS `binkps_cert_self_signed` Bool DEFAULT false AFTER `binkps_cert_chain_valid`,
+    ADD COLUMN IF NOT EXISTS `binkps_cert_error` String DEFAULT '' AFTER `binkps_cert_self_signed`;
@@ -0,0 +1,34 @@
+-- Migration 022: EMSI identity of mailers behind ITN (telnet) ports
+--
+-- Many nodes flagged ITN run a FidoNet mailer behind telnet rather than a BBS
+-- login. When the port answers EMSI, the testdaemon now completes the EMSI
+-- handshake over telnet-binary and records what the mailer announced, as it
+-- does for IFC:
+--
+--   telnet_mailer_info     mailer name and version (from the EMSI_DAT, or
+--                          read off the banner when no EMSI_DAT arrived)
+--   telnet_system_name     system name, location appended
+--   telnet_sysop           sysop name
+--   telnet_addresses       announced addresses (AKAs)
+--   telnet_response_type   'EMSI' once an identity was read, else ''
+--   telnet_ipv4_addresses / telnet_ipv6_addresses
+--                          the same per address family, for the IPv4/IPv6
+--                          AKA mismatch reports
+--
+-- address_validated now also counts the telnet mailer, so telnet-only nodes
+-- show up in the AKA mismatch reports. A telnet port with a BBS login leaves
+-- all of these empty.
+--
+-- Additive columns with defaults: a metadata-only ALTER.
+--
+-- Run on production ClickHouse BEFORE deploying the new testdaemon/server
+-- binaries.
+
+ALTER TABLE node_test_results
+    ADD COLUMN IF NOT EXISTS `telnet_mailer_info` String DEFAULT '' AFTER `telnet_error`,
+    ADD COLUMN IF NOT EXISTS `telnet_system_name` String DEFAULT '' AFTER `telnet_mailer_info`,
+    ADD COLUMN IF NOT EXISTS `telnet_sysop` String DEFAULT '' AFTER `telnet_system_name`,
+    ADD COLUMN IF NOT EXISTS `telnet_addresses` Array(String) DEFAULT [] AFTER `telnet_sysop`,
+    ADD COLUMN IF NOT EXISTS `telnet_response_type` String DEFAULT '' AFTER `telnet_addresses`,
+    ADD COLUMN IF NOT EXISTS `telnet_ipv4_addresses` Array(String) DEFAULT [] AFTER `ifcico_ipv6_addresses`,
+    ADD COLUMN IF NOT EXISTS `telnet_ipv6_addresses` Array(String) DEFAULT [] AFTER `telnet_ipv4_addresses`;
@@ -0,0 +1,42 @@
+-- Migration 023: wire transcripts of BinkP and IFCICO tests
+--
+-- A failed test used to leave only binkp_error / ifcico_error behind. With
+-- protocols.binkp.transcripts or protocols.ifcico.transcripts set to failed
+-- (or all), the testdaemon now records every byte of the session in both
+-- directions and stores it here, one row per session. The server shows the
+-- decoded BinkP frames or EMSI packets on /reachability/test, and
+-- cmd/transcript-replay plays a transcript back to a tester.
+--
+--   transcript   gzip-compressed JSON (internal/testing/transcript), with
+--                the BinkP passwords we sent blanked out
+--   raw_bytes    bytes recorded; a session is cut at 256 KiB (truncated)
+--
+-- Rows expire after 90 days.
+--
+-- Purely additive: creates one new table, touches nothing existing. Safe to
+-- run before or after deploying new binaries; the testdaemon also creates it
+-- on start.
+
+CREATE TABLE IF NOT EXISTS nodelistdb.node_test_transcripts
+(
+    `test_time`   DateTime,
+    `zone`        UInt16,
+    `net`         UInt16,
+    `node`        UInt16,
+    `address`     String,
+    `domain`      LowCardinality(String) DEFAULT 'fidonet',
+    `hostname`    String,
+    `protocol`    LowCardinality(String),   -- binkp | binkps | ifcico
+    `remote_ip`   String,
+    `success`     Bool,
+    `error`       String,
+    `started_at`  DateTime64(3),
+    `raw_bytes`   UInt32,
+    `truncated`   Bool,
+    `transcript`  String CODEC(ZSTD(1))
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(test_time)
+ORDER BY (zone, net, node, test_time)
+TTL test_time + INTERVAL 90 DAY
+SETTINGS index_granularity = 8192;
@@ -0,0 +1,36 @@
+-- Migration 024: path diagnostics for unreachable nodes
+--
+-- When every protocol test of a node fails, the testdaemon (with
+-- services.path_diagnostics.enabled) probes it once more to tell a host that
+-- is down from a network path that is broken:
+--
+--   path_tcp            TCP connect to the first tested protocol's port:
+--                       open | refused | timeout | unreachable | error
+--   path_hops           routers that answered a UDP probe, by TTL from 1;
+--                       '' for a silent hop. Empty when the TCP probe was
+--                       answered and the path was not followed.
+--   path_last_hop       the last address that answered, and its TTL
+--   path_last_hop_ttl
+--   path_last_hop_asn   its AS number and name (from geolocation), 0/'' for
+--   path_last_hop_as    a private or unknown address
+--   path_verdict        open | refused | filtered | host-down | path-broken |
+--                       unknown; host-down means the path reached the
+--                       node's own ASN, path-broken that it stopped in
+--                       another one
+--
+-- All empty on rows of operational nodes, and on every row written before
+-- this migration or with the probe disabled.
+--
+-- Additive columns with defaults: a metadata-only ALTER.
+--
+-- Run on production ClickHouse BEFORE deploying the new testdaemon/server
+-- binaries.
+
+ALTER TABLE node_test_results
+    ADD COLUMN IF NOT EXISTS `path_tcp` LowCardinality(String) DEFAULT '' AFTER `address_validated`,
+    ADD COLUMN IF NOT EXISTS `path_hops` Array(String) DEFAULT [] AFTER `path_tcp`,
+    ADD COLUMN IF NOT EXISTS `path_last_hop` String DEFAULT '' AFTER `path_hops`,
+    ADD COLUMN IF NOT EXISTS `path_last_hop_ttl` UInt8 DEFAULT 0 AFTER `path_last_hop`,
+    ADD COLUMN IF NOT EXISTS `path_last_hop_asn` UInt32 DEFAULT 0 AFTER `path_last_hop_ttl`,
+    ADD COLUMN IF NOT EXISTS `path_last_hop_as` String DEFAULT '' AFTER `path_last_hop_asn`,
+    ADD COLUMN IF NOT EXISTS `path_verdict` LowCardinality(String) DEFAULT '' AFTER `path_last_hop_as`;
@@ -0,0 +1,23 @@
+-- Migration 025: learned uptime windows
+--
+-- The testdaemon learns from node_test_results in which UTC hours of the day
+-- each node answers. A node that answers at night and never by day keeps a
+-- schedule; it is not down. Every test result now records:
+--
+--   uptime_window             the learned hours, e.g. '22-06 UTC' or
+--                             '08-12,20-23 UTC'; empty when the history
+--                             shows no daily pattern (always up, never up,
+--                             or too few tests)
+--   intermittent_by_schedule  the test failed outside those hours
+--
+-- With daemon.schedule_strategy: uptime the daemon also schedules retests
+-- inside the window.
+--
+-- Additive columns with defaults: a metadata-only ALTER.
+--
+-- Run on production ClickHouse BEFORE deploying the new testdaemon/server
+-- binaries.
+
+ALTER TABLE node_test_results
+    ADD COLUMN IF NOT EXISTS `uptime_window` String DEFAULT '' AFTER `path_verdict`,
+    ADD COLUMN IF NOT EXISTS `intermittent_by_schedule` Bool DEFAULT false AFTER `uptime_window`;
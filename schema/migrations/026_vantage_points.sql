@@ -0,0 +1,48 @@
+-- Migration 026: testing from several vantage points
+--
+-- A testdaemon can now coordinate agents at other locations: every node it
+-- tests is tested by the agents too, and the stored result is the combination.
+-- A node that one location cannot reach while another can has a routing
+-- problem, not a dead mailer. Every test result now records:
+--
+--   vantage_points_tested     locations that tested the node (0 on results
+--                             from a testdaemon with no agents)
+--   vantage_points_reachable  locations that reached it
+--   vantage_verdict           reachable | regional | unreachable; empty for
+--                             a single location
+--
+-- and node_test_vantage_results keeps what each location saw, one row per
+-- location per test. Rows expire after 180 days.
+--
+-- Additive columns with defaults and one new table. Run on production
+-- ClickHouse BEFORE deploying the new testdaemon/server binaries; the
+-- testdaemon also creates the table on start.
+
+ALTER TABLE node_test_results
+    ADD COLUMN IF NOT EXISTS `vantage_points_tested` UInt8 DEFAULT 0 AFTER `intermittent_by_schedule`,
+    ADD COLUMN IF NOT EXISTS `vantage_points_reachable` UInt8 DEFAULT 0 AFTER `vantage_points_tested`,
+    ADD COLUMN IF NOT EXISTS `vantage_verdict` LowCardinality(String) DEFAULT '' AFTER `vantage_points_reachable`;
+
+CREATE TABLE IF NOT EXISTS nodelistdb.node_test_vantage_results
+(
+    `test_time`        DateTime,
+    `zone`             UInt16,
+    `net`              UInt16,
+    `node`             UInt16,
+    `address`          String,
+    `domain`           LowCardinality(String) DEFAULT 'fidonet',
+    `vantage_point`    LowCardinality(String),
+    `tested_at`        DateTime,
+    `is_operational`   Bool,
+    `tested_hostname`  String,
+    `dns_error`        String,
+    `protocols_ok`     Array(LowCardinality(String)),
+    `protocols_failed` Array(LowCardinality(String)),
+    `response_ms`      UInt32,
+    `error`            String
+)
+ENGINE = MergeTree
+PARTITION BY toYYYYMM(test_time)
+ORDER BY (zone, net, node, test_time)
+TTL test_time + INTERVAL 180 DAY
+SETTINGS index_granularity = 8192;
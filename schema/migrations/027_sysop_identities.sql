@@ -0,0 +1,53 @@
+-- Migration 027: sysop identities
+--
+-- One person appears under several spellings, addresses and networks: a
+-- point that became a node, a node that moved nets, a second system in
+-- fsxnet, "Sergey_Ivanov" in one decade and "Сергей Иванов" the next. The
+-- parser's -resolve-sysops mode clusters every node and point tenure (one
+-- address under one sysop name) into persons by name similarity, shared
+-- hostnames and email addresses, and respellings at the same address, and
+-- writes them here. The server reads them for /sysop/{id} and
+-- /api/sysop/{id}.
+--
+-- Each run replaces the whole resolution: it writes under a new resolved_at,
+-- then deletes the older rows. Persons keep their sysop_id from run to run;
+-- when two merge or one splits, the retired ID is kept in
+-- sysop_identity_redirects.
+--
+-- Purely additive: creates two new tables, touches nothing existing. Safe to
+-- run before or after deploying new binaries. The tables stay empty until
+-- the first resolution:
+--
+--   ./bin/parser -resolve-sysops
+
+CREATE TABLE IF NOT EXISTS nodelistdb.sysop_identities
+(
+    `sysop_id`          String,                  -- stable across resolutions
+    `display_name`      String,                  -- the spelling listed longest
+    `domain`            LowCardinality(String),
+    `zone`              Int32,
+    `net`               Int32,
+    `node`              Int32,
+    `point`             Int32,                   -- 0 for nodes
+    `is_point`          Bool,
+    `sysop_name`        String,                  -- as listed at this address
+    `system_name`       String,                  -- as last listed
+    `first_seen`        Date,
+    `last_seen`         Date,
+    `currently_listed`  Bool,
+    `evidence`          Array(String),           -- name, similar-name, host:..., email:..., handover
+    `resolved_at`       DateTime
+)
+ENGINE = MergeTree
+ORDER BY (sysop_id, domain, zone, net, node, point, sysop_name)
+SETTINGS index_granularity = 8192;
+
+CREATE TABLE IF NOT EXISTS nodelistdb.sysop_identity_redirects
+(
+    `old_id`       String,                       -- retired by a merge or split
+    `sysop_id`     String,                       -- the identity it now points at
+    `resolved_at`  DateTime
+)
+ENGINE = MergeTree
+ORDER BY old_id
+SETTINGS index_granularity = 8192;
@@ -0,0 +1,42 @@
+-- Migration 028: node tenancies
+--
+-- FidoNet addresses are reassigned: 2:5020/113 can be one sysop's system for
+-- a decade and someone else's after. The parser's -detect-tenancies mode cuts
+-- every node address's history into tenancies, one per occupant, and writes
+-- them here. A new tenancy starts when the sysop changes to a different
+-- person (respellings do not count), when the system changes while the
+-- sysop is unnamed, or when the system changes after the address sat
+-- unlisted for over a year.
+--
+-- The node searches join this table so an address shows one lifetime per
+-- occupant; the node page segments its own history on the fly and does not
+-- need it. Each run writes under a new detected_at and then deletes the
+-- older rows.
+--
+-- Purely additive: creates one new table, touches nothing existing. The new
+-- server binaries need it to exist (the searches join it), so apply it
+-- before deploying them. Until the first detection, searches behave as
+-- before:
+--
+--   ./bin/parser -detect-tenancies
+
+CREATE TABLE IF NOT EXISTS nodelistdb.node_tenancies
+(
+    `domain`        LowCardinality(String),
+    `zone`          Int32,
+    `net`           Int32,
+    `node`          Int32,
+    `tenancy`       UInt16,                      -- 1 for the first occupant
+    `tenancies`     UInt16,                      -- how many the address has had
+    `sysop_name`    String,                      -- as last listed
+    `system_name`   String,                      -- as last listed
+    `location`      String,                      -- as last listed
+    `first_date`    Date,
+    `last_date`     Date,
+    `entries`       UInt32,                      -- nodelists listing it
+    `start_reason`  LowCardinality(String),      -- '', sysop, system or relisted
+    `detected_at`   DateTime
+)
+ENGINE = MergeTree
+ORDER BY (domain, zone, net, node, first_date)
+SETTINGS index_granularity = 8192;
@@ -0,0 +1,39 @@
+-- Migration 029: location geocodes
+--
+-- Until now the only geographic data came from IP geolocation of tested
+-- nodes, which says nothing about the many historical nodes that never had
+-- an IP. Every nodelist entry has a Location field, though ("Moscow",
+-- "Portland, ME", "Smalltown, Germany"). The parser's -geocode-locations
+-- mode resolves each distinct Location string offline, against a gazetteer
+-- bundled in the binaries, and writes the result here. The node map
+-- (/analytics/geo-map, /api/geo/map) joins any nodelist date's nodes to it.
+--
+-- Rows are keyed by the location string and replaced when it is resolved
+-- again; readers use FINAL. Each row records the gazetteer version, and a
+-- run only resolves strings without a row for the current version, so
+-- upgrading the gazetteer re-resolves everything once.
+--
+-- Purely additive: creates one new table, touches nothing existing. The new
+-- server binaries need it to exist (the map joins it), so apply it before
+-- deploying them, then fill it:
+--
+--   ./bin/parser -geocode-locations
+--
+-- and again after each import to pick up new locations. It combines with
+-- -resolve-sysops and -detect-tenancies.
+
+CREATE TABLE IF NOT EXISTS nodelistdb.location_geocodes
+(
+    `location`      String,                      -- as listed
+    `place`         String,                      -- the gazetteer's name for it
+    `country_code`  LowCardinality(String),      -- ISO 3166-1 alpha-2
+    `country`       LowCardinality(String),
+    `latitude`      Float64,
+    `longitude`     Float64,
+    `precision`     LowCardinality(String),      -- city, region, country or '' (unresolved)
+    `gazetteer`     LowCardinality(String),      -- version it was resolved with
+    `geocoded_at`   DateTime
+)
+ENGINE = ReplacingMergeTree(geocoded_at)
+ORDER BY location
+SETTINGS index_granularity = 8192;
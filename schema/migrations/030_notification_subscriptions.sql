@@ -0,0 +1,33 @@
+-- Migration 030: change notification subscriptions registered through the API
+--
+-- Node change subscriptions used to live only in the operator's
+-- subscriptions file. With api_access and notifications both enabled, the
+-- holder of an API key can now register, list and delete their own with
+-- POST, GET and DELETE /api/subscriptions, and they are kept here. The
+-- parser sends to these and to the file's subscriptions alike.
+--
+-- The newest row per subscription_id wins; a deleted subscription is
+-- inserted again with deleted = true. Readers use FINAL.
+--
+-- Purely additive: creates one new table, touches nothing existing. Safe to
+-- run before or after deploying new binaries; the parser also creates it.
+
+CREATE TABLE IF NOT EXISTS nodelistdb.notification_subscriptions
+(
+    `subscription_id`  String,
+    `owner`            String,                   -- API key_id that registered it
+    `domain`           LowCardinality(String),
+    `filter_address`   String,
+    `filter_net`       String,
+    `filter_sysop`     String,
+    `filter_flag`      String,
+    `events`           Array(LowCardinality(String)),   -- empty: all of them
+    `webhook`          String,
+    `secret`           String,
+    `email`            String,
+    `deleted`          Bool,
+    `updated_at`       DateTime64(3)
+)
+ENGINE = ReplacingMergeTree(updated_at)
+ORDER BY subscription_id
+SETTINGS index_granularity = 8192;